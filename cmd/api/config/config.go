@@ -2,58 +2,351 @@ package config
 
 import (
 	"fmt"
+	"mime"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
 )
 
 // Config stores all application configuration
 type Config struct {
-	Server  ServerConfig
-	MongoDB MongoDBConfig
-	Redis   RedisConfig
-	Kafka   KafkaConfig
-	Logging LoggingConfig
-	App     AppConfig
+	Server    ServerConfig
+	MongoDB   MongoDBConfig
+	Redis     RedisConfig
+	Kafka     KafkaConfig
+	Logging   LoggingConfig
+	App       AppConfig
+	Catalog   CatalogConfig
+	Customers CustomersConfig
+	Inventory InventoryConfig
+	SLA       SLAConfig
+	Features  FeaturesConfig
+	Tenancy   TenancyConfig
+	RateLimit RateLimitConfig
+	Quotas    QuotaConfig
+	Outbound  OutboundConfig
+	// LegacyEnv lists the flat environment variables in use, deprecated in
+	// favour of their ORDERS_ prefixed names
+	LegacyEnv []string
 }
 
+// Environments the service runs in. Each one has its own defaults, and
+// production is validated more strictly.
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// How log levels are encoded
+const (
+	LevelEncoderAuto         = "auto"
+	LevelEncoderCapital      = "capital"
+	LevelEncoderCapitalColor = "capitalColor"
+)
+
+// When the Server-Timing header is sent
+const (
+	ServerTimingOff    = "off"
+	ServerTimingDebug  = "debug"
+	ServerTimingAlways = "always"
+)
+
 // ServerConfig defines the HTTP server configuration
 type ServerConfig struct {
-	Port         string
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
-	Environment  string
+	Port              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration // bounds slow clients sending headers
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration // keep-alive connections between requests
+	MaxHeaderBytes    int
+	// HandlerTimeout bounds request processing, independently of WriteTimeout.
+	HandlerTimeout time.Duration
+	Environment    string
+	Version        string // deployed version, attached to published events
+	GinMode        string // debug, release or test
+	EnableSwagger  bool   // serve the Swagger UI under /api/swagger
+	Shutdown       ShutdownConfig
+	TLS            TLSConfig
+	Health         HealthConfig
+	// ServerTiming sends the Server-Timing header: off, debug (to requests
+	// sending X-Debug-Timing) or always
+	ServerTiming string
+}
+
+// IsProduction reports whether the service runs in production
+func (c ServerConfig) IsProduction() bool {
+	return c.Environment == EnvProduction
+}
+
+// plainLogs reports whether logs default to no colors and no callers: JSON
+// logs in production, which are ingested rather than read
+func (c *Config) plainLogs() bool {
+	return c.Server.IsProduction() && strings.EqualFold(c.Logging.Format, "json")
+}
+
+// LogColor reports whether log levels are colored
+func (c *Config) LogColor() bool {
+	if c.Logging.LevelEncoder == LevelEncoderAuto {
+		return !c.plainLogs()
+	}
+	return c.Logging.LevelEncoder == LevelEncoderCapitalColor
+}
+
+// LogCaller reports whether log entries carry the file and line logging them
+func (c *Config) LogCaller() bool {
+	if c.Logging.Caller == "auto" {
+		return !c.plainLogs()
+	}
+	return c.Logging.Caller == "true"
+}
+
+// TLSConfig enables TLS on the HTTP server when a certificate is set
+type TLSConfig struct {
+	CertFile   string
+	KeyFile    string
+	MinVersion string // 1.2 or 1.3
+	// ClientCAFile requires clients to present a certificate signed by it
+	ClientCAFile string
+}
+
+// Enabled reports whether the server is served over TLS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// HealthConfig sets how dependencies are checked in the background for the
+// health endpoint
+type HealthConfig struct {
+	HistorySize      int           // checks kept per dependency
+	FailureThreshold int           // consecutive failures before unhealthy
+	RefreshInterval  time.Duration // between checks
+}
+
+// ShutdownConfig bounds each phase of the graceful shutdown
+type ShutdownConfig struct {
+	// ReadinessDelay is how long the service reports not ready before it stops
+	// accepting connections, so load balancers can take it out of rotation.
+	ReadinessDelay  time.Duration
+	DrainTimeout    time.Duration // in-flight requests
+	WorkersTimeout  time.Duration // each background worker
+	ProducerTimeout time.Duration // flushing the Kafka producer
+	StoresTimeout   time.Duration // each of Redis and MongoDB
 }
 
 // MongoDBConfig defines the MongoDB connection configuration
 type MongoDBConfig struct {
-	URI               string
-	Database          string
-	ConnectionTimeout time.Duration
-	MaxPoolSize       uint64
+	URI                    string
+	Database               string
+	ConnectionTimeout      time.Duration
+	ServerSelectionTimeout time.Duration
+	MaxPoolSize            uint64
+	// MinPoolSize connections are kept open so bursts do not pay for new ones
+	MinPoolSize     uint64
+	MaxConnIdleTime time.Duration // 0 keeps idle connections open
+	// RequireIndexes makes startup fail when the indexes cannot be created or
+	// are missing; otherwise it only logs a warning
+	RequireIndexes bool
+	// BreakerEnabled fails order queries fast with 503 once
+	// BreakerFailureThreshold consecutive ones failed, probing MongoDB again
+	// after BreakerOpenTimeout
+	BreakerEnabled          bool
+	BreakerFailureThreshold int
+	BreakerOpenTimeout      time.Duration
+	// ListMaxTime bounds how long MongoDB may spend counting and finding the
+	// orders of a listing, 0 leaves it unbounded
+	ListMaxTime time.Duration
 }
 
 // RedisConfig defines the Redis cache configuration
 type RedisConfig struct {
-	URL        string
-	Password   string
-	DB         int
-	PoolSize   int
-	DefaultTTL time.Duration
+	URL      string
+	Password string
+	// PasswordFile is the file Password was read from, read again to rotate it
+	PasswordFile        string
+	DB                  int
+	PoolSize            int
+	DefaultTTL          time.Duration
+	StatsTTL            time.Duration // how long the order stats are cached, 0 disables it
+	ReconcileEnabled    bool
+	ReconcileInterval   time.Duration
+	ReconcileSampleSize int
+	RetryQueueSize      int
+	RetryMaxAttempts    int
+	RetryDelay          time.Duration
+	// Compress gzips the cached orders of at least CompressMinSize bytes
+	Compress        bool
+	CompressMinSize int
 }
 
 // KafkaConfig defines the Kafka configuration for producers and consumers
 type KafkaConfig struct {
 	Brokers        []string
 	TopicOrders    string
+	TopicRoutes    map[string]string // event type -> topic, falls back to TopicOrders
 	ConsumerGroup  string
 	EnableProducer bool
+	EventFormat    string // flat or cdc
+	// AutoCreateTopics lets the producer create missing topics, never in production
+	AutoCreateTopics bool
+	// Events kept by the in-memory bus used when the producer is disabled
+	InMemoryBufferSize int
+	// Delivery confirmations published by the logistics partner move orders to DELIVERED
+	ConsumeDeliveries bool
+	DeliveriesTopic   string
+	// ConsumeSummary keeps the orders summary up to date from the order
+	// events, consumed in SummaryConsumerGroup
+	ConsumeSummary       bool
+	SummaryConsumerGroup string
+	// PublishTimeout bounds each publish, 0 waits for the writer to give up
+	PublishTimeout time.Duration
+	// BreakerEnabled fails publishes at once after BreakerFailureThreshold
+	// consecutive failures, trying the brokers again after BreakerOpenTimeout
+	BreakerEnabled          bool
+	BreakerFailureThreshold int
+	BreakerOpenTimeout      time.Duration
+	// ReplayRate caps the events per second published by replays of the
+	// event log by time range, 0 does not limit them
+	ReplayRate int
+	// Notifications publishes a notification request to NotificationsTopic
+	// when an order is delivered or cancelled. Requests that fail are retried
+	// up to NotificationRetryMaxAttempts times, then sent to
+	// NotificationsDLQTopic
+	Notifications                bool
+	NotificationsTopic           string
+	NotificationsDLQTopic        string
+	NotificationRetryQueueSize   int
+	NotificationRetryMaxAttempts int
+	NotificationRetryDelay       time.Duration
+}
+
+// CatalogConfig defines the catalog service integration used for server-side
+// pricing and SKU validation
+type CatalogConfig struct {
+	Enabled       bool
+	BaseURL       string
+	APIKey        string
+	Timeout       time.Duration
+	PriceCacheTTL time.Duration
+	ValidateSKUs  bool
+	SKUFailOpen   bool // accept orders when SKUs cannot be checked
+	SKUCacheTTL   time.Duration
+}
+
+// CustomersConfig defines the customers service integration used to validate
+// that orders are placed for existing customers
+type CustomersConfig struct {
+	ValidationMode string // none, http or fake
+	BaseURL        string
+	Timeout        time.Duration
+	CacheTTL       time.Duration
+	SoftFail       bool
+	FakeIDs        []string
+	IDFormat       string // uuid, alphanumeric or any
+}
+
+// InventoryConfig defines the inventory service integration that reserves the
+// stock of new orders and releases it when they are cancelled
+type InventoryConfig struct {
+	Enabled bool // reserve stock on order creation, also toggleable at runtime
+	BaseURL string
+	APIKey  string
+	Timeout time.Duration
+	Stage   string // pre-persist or post-persist
+	// Releases that failed are retried every ReleaseRetryInterval, the first
+	// time after ReleaseRetryDelay and backing off exponentially
+	ReleaseRetryInterval time.Duration
+	ReleaseRetryDelay    time.Duration
+	ReleaseBatchSize     int
+	// The service is not ready once the release retrier has not run for
+	// ReleaseStaleAfter; 0 disables the check
+	ReleaseStaleAfter time.Duration
+}
+
+// SLAConfig defines the delivery promise of new orders and the sweep that
+// reports missed promises
+type SLAConfig struct {
+	DefaultDuration time.Duration
+	MinLead         time.Duration
+	MaxLead         time.Duration
+	SweepEnabled    bool
+	SweepInterval   time.Duration
+	SweepBatchSize  int
+}
+
+// FeaturesConfig collects the opt-in behaviours of the service. Some of them
+// can also be toggled at runtime through the admin API.
+type FeaturesConfig struct {
+	Cache             bool // connect to Redis and cache orders
+	CompactSummaries  bool
+	EventSnapshots    bool // attach the order before and after the change to events
+	SchemaValidation  bool
+	ItemConsolidation bool
+	// SummaryReads serves listings, counts and stats from the orders summary
+	SummaryReads bool
+}
+
+// TenancyConfig defines the tenants orders are isolated between. Single-tenant
+// deployments serve every request as DefaultTenant.
+type TenancyConfig struct {
+	Enabled bool // require X-Tenant-ID on order requests
+	Tenants []string
+	// DefaultTenant also owns the orders stored before tenants were introduced
+	DefaultTenant string
+}
+
+// RateLimitConfig defines the leaky bucket smoothing the order creation
+// bursts of each customer
+type RateLimitConfig struct {
+	Enabled   bool
+	PerMinute int
+	Burst     int
+	Mode      string        // reject or delay
+	MaxDelay  time.Duration // longest a creation is delayed in delay mode
+}
+
+// QuotaConfig defines the request quotas of the API clients, counted in
+// Redis per client and day
+type QuotaConfig struct {
+	Enabled bool
+	// Daily and Monthly map client names to their quota; clients without one
+	// are counted but not limited
+	Daily         map[string]int64
+	Monthly       map[string]int64
+	RetentionDays int // how long daily counters are kept for usage reports
+}
+
+// OutboundConfig defines how the catalog, customers and inventory services
+// are called; each integration has its own timeout
+type OutboundConfig struct {
+	// MaxRetries is how many times idempotent calls are retried after a
+	// network error, 429 or 5xx, waiting RetryBackoff, doubled every retry
+	MaxRetries   int
+	RetryBackoff time.Duration
+	// BreakerFailureThreshold consecutive failed calls to a service fail the
+	// next ones at once for BreakerOpenTimeout; 0 disables the breakers
+	BreakerFailureThreshold int
+	BreakerOpenTimeout      time.Duration
 }
 
 // LoggingConfig defines logging level and format
 type LoggingConfig struct {
 	Level  string
 	Format string
+	// AllowDebugInProduction accepts the debug level in production
+	AllowDebugInProduction bool
+	// LevelEncoder is capital, capitalColor or auto, and Caller true, false
+	// or auto. Auto leaves colors and callers out of JSON logs in production
+	// and keeps them otherwise
+	LevelEncoder string
+	Caller       string
 }
 
 // AppConfig defines general application settings
@@ -62,56 +355,264 @@ type AppConfig struct {
 	MaxItemsPerOrder int
 	DefaultPageSize  int
 	MaxPageSize      int
+	MaxScanWindow    int // deepest page*limit a listing may reach, 0 disables the limit
+	StreamThreshold  int // largest limit listed from a buffered page, 0 never streams
+	MaxNoteLength    int
+	MaxNotesPerOrder int
+	ReturnWindow     time.Duration
+	MaxOrderWeight   int // grams, 0 disables the limit
+	AdminAPIKeys     []string
+	AdminPIIAPIKeys  []string          // admin keys also granted the pii:read scope
+	ClientAPIKeys    map[string]string // client name -> API key
+	IDField          string            // JSON field the order ID is served as, orderId or id
+	// AuthTokenSecret verifies the HS256 bearer tokens identifying customers
+	// and their roles; bearer tokens are rejected when it is empty
+	AuthTokenSecret string
+	// RequestIDMaxLength and RequestIDPattern restrict the X-Request-ID
+	// accepted from clients, other IDs are replaced by a generated one
+	RequestIDMaxLength int
+	RequestIDPattern   string
+	// InitialStatuses are the statuses clients can create orders in, only
+	// NEW when empty
+	InitialStatuses []string
+	// ContentTypes are the Content-Type values accepted on request bodies
+	ContentTypes []string
+	// Currency is the ISO 4217 currency order amounts are in
+	Currency string
 }
 
-// Load loads configuration from environment variables and .env file
+// Load loads configuration from environment variables and from the file set
+// in CONFIG_FILE, or the .env file when it is not set. Settings have a nested
+// key, server.port, set in the file or with ORDERS_SERVER_PORT, and a flat
+// name, PORT, still accepted everywhere. Environment variables take
+// precedence over the file, prefixed ones over flat ones.
 func Load() (*Config, error) {
-	viper.SetConfigFile(".env")
-	viper.AutomaticEnv()
-
-	// Attempt to load .env file (optional)
-	_ = viper.ReadInConfig()
+	bindKeys()
+	if err := readConfigFile(); err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
 
 	setDefaults()
+	setProfileDefaults(viper.GetString("ENV"))
+	if err := readSecretFiles(); err != nil {
+		return nil, err
+	}
+
+	topicRoutes, err := getMap("KAFKA_TOPIC_ROUTES")
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	clientAPIKeys, err := getMap("CLIENT_API_KEYS")
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	dailyQuotas, err := getCountMap("CLIENT_DAILY_QUOTAS")
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	monthlyQuotas, err := getCountMap("CLIENT_MONTHLY_QUOTAS")
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 
 	config := &Config{
 		Server: ServerConfig{
-			Port:         viper.GetString("PORT"),
-			ReadTimeout:  viper.GetDuration("SERVER_READ_TIMEOUT"),
-			WriteTimeout: viper.GetDuration("SERVER_WRITE_TIMEOUT"),
-			Environment:  viper.GetString("ENV"),
+			Port:              viper.GetString("PORT"),
+			ReadTimeout:       viper.GetDuration("SERVER_READ_TIMEOUT"),
+			ReadHeaderTimeout: viper.GetDuration("SERVER_READ_HEADER_TIMEOUT"),
+			WriteTimeout:      viper.GetDuration("SERVER_WRITE_TIMEOUT"),
+			IdleTimeout:       viper.GetDuration("SERVER_IDLE_TIMEOUT"),
+			MaxHeaderBytes:    viper.GetInt("SERVER_MAX_HEADER_BYTES"),
+			HandlerTimeout:    viper.GetDuration("HANDLER_TIMEOUT"),
+			Environment:       viper.GetString("ENV"),
+			Version:           viper.GetString("SERVICE_VERSION"),
+			GinMode:           viper.GetString("GIN_MODE"),
+			EnableSwagger:     viper.GetBool("ENABLE_SWAGGER"),
+			ServerTiming:      viper.GetString("SERVER_TIMING"),
+			Shutdown: ShutdownConfig{
+				ReadinessDelay:  viper.GetDuration("SHUTDOWN_READINESS_DELAY"),
+				DrainTimeout:    viper.GetDuration("SHUTDOWN_DRAIN_TIMEOUT"),
+				WorkersTimeout:  viper.GetDuration("SHUTDOWN_WORKERS_TIMEOUT"),
+				ProducerTimeout: viper.GetDuration("SHUTDOWN_PRODUCER_TIMEOUT"),
+				StoresTimeout:   viper.GetDuration("SHUTDOWN_STORES_TIMEOUT"),
+			},
+			TLS: TLSConfig{
+				CertFile:     viper.GetString("SERVER_TLS_CERT_FILE"),
+				KeyFile:      viper.GetString("SERVER_TLS_KEY_FILE"),
+				MinVersion:   viper.GetString("SERVER_TLS_MIN_VERSION"),
+				ClientCAFile: viper.GetString("SERVER_TLS_CLIENT_CA_FILE"),
+			},
+			Health: HealthConfig{
+				HistorySize:      viper.GetInt("HEALTH_HISTORY_SIZE"),
+				FailureThreshold: viper.GetInt("HEALTH_FAILURE_THRESHOLD"),
+				RefreshInterval:  viper.GetDuration("HEALTH_REFRESH_INTERVAL"),
+			},
 		},
 		MongoDB: MongoDBConfig{
-			URI:               viper.GetString("MONGODB_URI"),
-			Database:          viper.GetString("MONGODB_DATABASE"),
-			ConnectionTimeout: viper.GetDuration("MONGODB_CONNECTION_TIMEOUT"),
-			MaxPoolSize:       viper.GetUint64("MONGODB_MAX_POOL_SIZE"),
+			URI:                     viper.GetString("MONGODB_URI"),
+			Database:                viper.GetString("MONGODB_DATABASE"),
+			ConnectionTimeout:       viper.GetDuration("MONGODB_CONNECTION_TIMEOUT"),
+			ServerSelectionTimeout:  viper.GetDuration("MONGODB_SERVER_SELECTION_TIMEOUT"),
+			MaxPoolSize:             viper.GetUint64("MONGODB_MAX_POOL_SIZE"),
+			MinPoolSize:             viper.GetUint64("MONGODB_MIN_POOL_SIZE"),
+			MaxConnIdleTime:         viper.GetDuration("MONGODB_MAX_CONN_IDLE_TIME"),
+			RequireIndexes:          viper.GetBool("MONGODB_REQUIRE_INDEXES"),
+			BreakerEnabled:          viper.GetBool("MONGODB_BREAKER_ENABLED"),
+			BreakerFailureThreshold: viper.GetInt("MONGODB_BREAKER_FAILURE_THRESHOLD"),
+			BreakerOpenTimeout:      viper.GetDuration("MONGODB_BREAKER_OPEN_TIMEOUT"),
+			ListMaxTime:             viper.GetDuration("MONGODB_LIST_MAX_TIME"),
 		},
 		Redis: RedisConfig{
-			URL:        viper.GetString("REDIS_URL"),
-			Password:   viper.GetString("REDIS_PASSWORD"),
-			DB:         viper.GetInt("REDIS_DB"),
-			PoolSize:   viper.GetInt("REDIS_POOL_SIZE"),
-			DefaultTTL: viper.GetDuration("REDIS_DEFAULT_TTL"),
+			URL:                 viper.GetString("REDIS_URL"),
+			Password:            viper.GetString("REDIS_PASSWORD"),
+			PasswordFile:        viper.GetString("REDIS_PASSWORD_FILE"),
+			DB:                  viper.GetInt("REDIS_DB"),
+			PoolSize:            viper.GetInt("REDIS_POOL_SIZE"),
+			DefaultTTL:          viper.GetDuration("REDIS_DEFAULT_TTL"),
+			StatsTTL:            viper.GetDuration("CACHE_STATS_TTL"),
+			ReconcileEnabled:    viper.GetBool("CACHE_RECONCILE_ENABLED"),
+			ReconcileInterval:   viper.GetDuration("CACHE_RECONCILE_INTERVAL"),
+			ReconcileSampleSize: viper.GetInt("CACHE_RECONCILE_SAMPLE_SIZE"),
+			RetryQueueSize:      viper.GetInt("CACHE_WRITE_RETRY_QUEUE_SIZE"),
+			RetryMaxAttempts:    viper.GetInt("CACHE_WRITE_RETRY_MAX_ATTEMPTS"),
+			RetryDelay:          viper.GetDuration("CACHE_WRITE_RETRY_DELAY"),
+			Compress:            viper.GetBool("CACHE_COMPRESS"),
+			CompressMinSize:     viper.GetInt("CACHE_COMPRESS_MIN_SIZE"),
 		},
 		Kafka: KafkaConfig{
-			Brokers:        viper.GetStringSlice("KAFKA_BROKERS"),
+			Brokers:        getList("KAFKA_BROKERS"),
 			TopicOrders:    viper.GetString("KAFKA_TOPIC_ORDERS"),
+			TopicRoutes:    topicRoutes,
 			ConsumerGroup:  viper.GetString("KAFKA_CONSUMER_GROUP"),
 			EnableProducer: viper.GetBool("KAFKA_ENABLE_PRODUCER"),
+			EventFormat:    viper.GetString("KAFKA_EVENT_FORMAT"),
+
+			AutoCreateTopics:   viper.GetBool("KAFKA_AUTO_CREATE_TOPICS"),
+			InMemoryBufferSize: viper.GetInt("KAFKA_IN_MEMORY_BUFFER_SIZE"),
+
+			ConsumeDeliveries: viper.GetBool("KAFKA_CONSUME_DELIVERY_CONFIRMATIONS"),
+			DeliveriesTopic:   viper.GetString("KAFKA_TOPIC_DELIVERY_CONFIRMATIONS"),
+
+			ConsumeSummary:       viper.GetBool("KAFKA_CONSUME_ORDER_SUMMARY"),
+			SummaryConsumerGroup: viper.GetString("KAFKA_SUMMARY_CONSUMER_GROUP"),
+
+			PublishTimeout:          viper.GetDuration("KAFKA_PUBLISH_TIMEOUT"),
+			BreakerEnabled:          viper.GetBool("KAFKA_BREAKER_ENABLED"),
+			BreakerFailureThreshold: viper.GetInt("KAFKA_BREAKER_FAILURE_THRESHOLD"),
+			BreakerOpenTimeout:      viper.GetDuration("KAFKA_BREAKER_OPEN_TIMEOUT"),
+			ReplayRate:              viper.GetInt("EVENT_REPLAY_RATE"),
+
+			Notifications:                viper.GetBool("KAFKA_NOTIFICATIONS_ENABLED"),
+			NotificationsTopic:           viper.GetString("KAFKA_TOPIC_NOTIFICATIONS"),
+			NotificationsDLQTopic:        viper.GetString("KAFKA_TOPIC_NOTIFICATIONS_DLQ"),
+			NotificationRetryQueueSize:   viper.GetInt("NOTIFICATION_RETRY_QUEUE_SIZE"),
+			NotificationRetryMaxAttempts: viper.GetInt("NOTIFICATION_RETRY_MAX_ATTEMPTS"),
+			NotificationRetryDelay:       viper.GetDuration("NOTIFICATION_RETRY_DELAY"),
 		},
 		Logging: LoggingConfig{
 			Level:  viper.GetString("LOG_LEVEL"),
 			Format: viper.GetString("LOG_FORMAT"),
+
+			AllowDebugInProduction: viper.GetBool("LOG_ALLOW_DEBUG_IN_PRODUCTION"),
+			LevelEncoder:           viper.GetString("LOG_LEVEL_ENCODER"),
+			Caller:                 viper.GetString("LOG_CALLER"),
 		},
 		App: AppConfig{
-			RequestTimeout:   viper.GetDuration("REQUEST_TIMEOUT"),
-			MaxItemsPerOrder: viper.GetInt("MAX_ITEMS_PER_ORDER"),
-			DefaultPageSize:  viper.GetInt("DEFAULT_PAGE_SIZE"),
-			MaxPageSize:      viper.GetInt("MAX_PAGE_SIZE"),
+			RequestTimeout:     viper.GetDuration("REQUEST_TIMEOUT"),
+			MaxItemsPerOrder:   viper.GetInt("MAX_ITEMS_PER_ORDER"),
+			DefaultPageSize:    viper.GetInt("DEFAULT_PAGE_SIZE"),
+			MaxPageSize:        viper.GetInt("MAX_PAGE_SIZE"),
+			MaxScanWindow:      viper.GetInt("MAX_LIST_SCAN_WINDOW"),
+			StreamThreshold:    viper.GetInt("LIST_STREAM_THRESHOLD"),
+			MaxNoteLength:      viper.GetInt("MAX_NOTE_LENGTH"),
+			MaxNotesPerOrder:   viper.GetInt("MAX_NOTES_PER_ORDER"),
+			ReturnWindow:       viper.GetDuration("RETURN_WINDOW"),
+			MaxOrderWeight:     viper.GetInt("MAX_ORDER_WEIGHT_GRAMS"),
+			InitialStatuses:    getList("ORDER_INITIAL_STATUSES"),
+			AdminAPIKeys:       getList("ADMIN_API_KEYS"),
+			AdminPIIAPIKeys:    getList("ADMIN_PII_API_KEYS"),
+			ClientAPIKeys:      clientAPIKeys,
+			AuthTokenSecret:    viper.GetString("AUTH_TOKEN_SECRET"),
+			IDField:            viper.GetString("API_ID_FIELD"),
+			RequestIDMaxLength: viper.GetInt("REQUEST_ID_MAX_LENGTH"),
+			RequestIDPattern:   viper.GetString("REQUEST_ID_PATTERN"),
+			ContentTypes:       getList("REQUEST_CONTENT_TYPES"),
+			Currency:           strings.ToUpper(viper.GetString("ORDER_CURRENCY")),
+		},
+		Catalog: CatalogConfig{
+			Enabled:       viper.GetBool("CATALOG_ENABLED"),
+			BaseURL:       viper.GetString("CATALOG_BASE_URL"),
+			APIKey:        viper.GetString("CATALOG_API_KEY"),
+			Timeout:       viper.GetDuration("CATALOG_TIMEOUT"),
+			PriceCacheTTL: viper.GetDuration("CATALOG_PRICE_CACHE_TTL"),
+			ValidateSKUs:  viper.GetBool("CATALOG_SKU_VALIDATION"),
+			SKUFailOpen:   viper.GetBool("CATALOG_SKU_FAIL_OPEN"),
+			SKUCacheTTL:   viper.GetDuration("CATALOG_SKU_CACHE_TTL"),
+		},
+		Customers: CustomersConfig{
+			ValidationMode: viper.GetString("CUSTOMER_VALIDATION_MODE"),
+			BaseURL:        viper.GetString("CUSTOMERS_BASE_URL"),
+			Timeout:        viper.GetDuration("CUSTOMERS_TIMEOUT"),
+			CacheTTL:       viper.GetDuration("CUSTOMERS_CACHE_TTL"),
+			SoftFail:       viper.GetBool("CUSTOMER_VALIDATION_SOFT_FAIL"),
+			FakeIDs:        getList("CUSTOMERS_FAKE_IDS"),
+			IDFormat:       viper.GetString("CUSTOMER_ID_FORMAT"),
+		},
+		Inventory: InventoryConfig{
+			Enabled:              viper.GetBool("INVENTORY_RESERVATION_ENABLED"),
+			BaseURL:              viper.GetString("INVENTORY_BASE_URL"),
+			APIKey:               viper.GetString("INVENTORY_API_KEY"),
+			Timeout:              viper.GetDuration("INVENTORY_TIMEOUT"),
+			Stage:                viper.GetString("INVENTORY_RESERVATION_STAGE"),
+			ReleaseRetryInterval: viper.GetDuration("INVENTORY_RELEASE_RETRY_INTERVAL"),
+			ReleaseRetryDelay:    viper.GetDuration("INVENTORY_RELEASE_RETRY_DELAY"),
+			ReleaseBatchSize:     viper.GetInt("INVENTORY_RELEASE_BATCH_SIZE"),
+			ReleaseStaleAfter:    viper.GetDuration("INVENTORY_RELEASE_STALE_AFTER"),
+		},
+		SLA: SLAConfig{
+			DefaultDuration: viper.GetDuration("DELIVERY_SLA"),
+			MinLead:         viper.GetDuration("DELIVERY_PROMISE_MIN_LEAD"),
+			MaxLead:         viper.GetDuration("DELIVERY_PROMISE_MAX_LEAD"),
+			SweepEnabled:    viper.GetBool("SLA_SWEEP_ENABLED"),
+			SweepInterval:   viper.GetDuration("SLA_SWEEP_INTERVAL"),
+			SweepBatchSize:  viper.GetInt("SLA_SWEEP_BATCH_SIZE"),
+		},
+		Features: FeaturesConfig{
+			Cache:             viper.GetBool("CACHE_ENABLED"),
+			CompactSummaries:  viper.GetBool("CACHE_COMPACT_SUMMARIES"),
+			EventSnapshots:    viper.GetBool("EVENT_SNAPSHOTS_ENABLED"),
+			SchemaValidation:  viper.GetBool("SCHEMA_VALIDATION_ENABLED"),
+			ItemConsolidation: viper.GetBool("CONSOLIDATE_DUPLICATE_SKUS"),
+			SummaryReads:      viper.GetBool("SUMMARY_READS_ENABLED"),
+		},
+		Tenancy: TenancyConfig{
+			Enabled:       viper.GetBool("MULTI_TENANT_ENABLED"),
+			Tenants:       getList("TENANTS"),
+			DefaultTenant: viper.GetString("DEFAULT_TENANT"),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:   viper.GetBool("CUSTOMER_RATE_LIMIT_ENABLED"),
+			PerMinute: viper.GetInt("CUSTOMER_RATE_LIMIT_PER_MINUTE"),
+			Burst:     viper.GetInt("CUSTOMER_RATE_LIMIT_BURST"),
+			Mode:      viper.GetString("CUSTOMER_RATE_LIMIT_MODE"),
+			MaxDelay:  viper.GetDuration("CUSTOMER_RATE_LIMIT_MAX_DELAY"),
+		},
+		Quotas: QuotaConfig{
+			Enabled:       viper.GetBool("CLIENT_QUOTAS_ENABLED"),
+			Daily:         dailyQuotas,
+			Monthly:       monthlyQuotas,
+			RetentionDays: viper.GetInt("CLIENT_USAGE_RETENTION_DAYS"),
+		},
+		Outbound: OutboundConfig{
+			MaxRetries:              viper.GetInt("OUTBOUND_MAX_RETRIES"),
+			RetryBackoff:            viper.GetDuration("OUTBOUND_RETRY_BACKOFF"),
+			BreakerFailureThreshold: viper.GetInt("OUTBOUND_BREAKER_FAILURE_THRESHOLD"),
+			BreakerOpenTimeout:      viper.GetDuration("OUTBOUND_BREAKER_OPEN_TIMEOUT"),
 		},
 	}
 
+	config.LegacyEnv = legacyEnv()
+
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
@@ -119,53 +620,637 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
-// Validate checks required configuration values
+// Validate checks required configuration values, and the stricter rules of
+// production
 func (c *Config) Validate() error {
+	switch c.Server.Environment {
+	case EnvDevelopment, EnvStaging, EnvProduction:
+	default:
+		return fmt.Errorf("ENV must be one of development, staging, production")
+	}
+	switch c.Server.GinMode {
+	case "debug", "release", "test":
+	default:
+		return fmt.Errorf("GIN_MODE must be one of debug, release, test")
+	}
 	if c.Server.Port == "" {
 		return fmt.Errorf("PORT is required")
 	}
+	if err := c.validateDurations(); err != nil {
+		return err
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.ReadHeaderTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 {
+		return fmt.Errorf("SERVER_READ_TIMEOUT, SERVER_READ_HEADER_TIMEOUT, SERVER_WRITE_TIMEOUT and SERVER_IDLE_TIMEOUT must be positive")
+	}
+	if c.Server.ReadHeaderTimeout > c.Server.ReadTimeout {
+		return fmt.Errorf("SERVER_READ_HEADER_TIMEOUT must not be greater than SERVER_READ_TIMEOUT")
+	}
+	if c.Server.MaxHeaderBytes <= 0 {
+		return fmt.Errorf("SERVER_MAX_HEADER_BYTES must be positive")
+	}
+	// The socket would be closed before the handler timeout response is written
+	if c.Server.HandlerTimeout > 0 && c.Server.WriteTimeout <= c.Server.HandlerTimeout {
+		return fmt.Errorf("SERVER_WRITE_TIMEOUT must be greater than HANDLER_TIMEOUT")
+	}
+	if c.Server.Shutdown.DrainTimeout <= 0 {
+		return fmt.Errorf("SHUTDOWN_DRAIN_TIMEOUT must be positive")
+	}
+	if c.Server.TLS.Enabled() && (c.Server.TLS.CertFile == "" || c.Server.TLS.KeyFile == "") {
+		return fmt.Errorf("SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE must be set together")
+	}
+	if c.Server.TLS.ClientCAFile != "" && !c.Server.TLS.Enabled() {
+		return fmt.Errorf("SERVER_TLS_CLIENT_CA_FILE requires SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE")
+	}
+	if c.Server.TLS.MinVersion != "1.2" && c.Server.TLS.MinVersion != "1.3" {
+		return fmt.Errorf("SERVER_TLS_MIN_VERSION must be one of 1.2, 1.3")
+	}
+	switch c.Logging.LevelEncoder {
+	case LevelEncoderAuto, LevelEncoderCapital, LevelEncoderCapitalColor:
+	default:
+		return fmt.Errorf("LOG_LEVEL_ENCODER must be one of auto, capital, capitalColor")
+	}
+	switch c.Logging.Caller {
+	case "auto", "true", "false":
+	default:
+		return fmt.Errorf("LOG_CALLER must be one of auto, true, false")
+	}
+	switch c.Server.ServerTiming {
+	case ServerTimingOff, ServerTimingDebug, ServerTimingAlways:
+	default:
+		return fmt.Errorf("SERVER_TIMING must be one of off, debug, always")
+	}
+	if c.Server.Health.HistorySize <= 0 || c.Server.Health.FailureThreshold <= 0 {
+		return fmt.Errorf("HEALTH_HISTORY_SIZE and HEALTH_FAILURE_THRESHOLD must be positive")
+	}
+	if c.Server.Health.FailureThreshold > c.Server.Health.HistorySize {
+		return fmt.Errorf("HEALTH_FAILURE_THRESHOLD must not be greater than HEALTH_HISTORY_SIZE")
+	}
 	if c.MongoDB.URI == "" {
 		return fmt.Errorf("MONGODB_URI is required")
 	}
-	if c.Redis.URL == "" {
-		return fmt.Errorf("REDIS_URL is required")
+	if c.MongoDB.MaxPoolSize > 0 && c.MongoDB.MinPoolSize > c.MongoDB.MaxPoolSize {
+		return fmt.Errorf("MONGODB_MIN_POOL_SIZE must not be greater than MONGODB_MAX_POOL_SIZE")
+	}
+	if c.MongoDB.BreakerEnabled {
+		if c.MongoDB.BreakerFailureThreshold < 1 {
+			return fmt.Errorf("MONGODB_BREAKER_FAILURE_THRESHOLD must be at least 1")
+		}
+		if c.MongoDB.BreakerOpenTimeout <= 0 {
+			return fmt.Errorf("MONGODB_BREAKER_OPEN_TIMEOUT must be positive")
+		}
+	}
+	if c.Tenancy.DefaultTenant == "" {
+		return fmt.Errorf("DEFAULT_TENANT is required")
+	}
+	if c.Tenancy.Enabled && len(c.Tenancy.Tenants) == 0 {
+		return fmt.Errorf("TENANTS is required when MULTI_TENANT_ENABLED is true")
+	}
+	if c.Features.Cache && c.Redis.URL == "" {
+		return fmt.Errorf("REDIS_URL is required when CACHE_ENABLED is true")
+	}
+	if c.RateLimit.Enabled {
+		if !c.Features.Cache {
+			return fmt.Errorf("CUSTOMER_RATE_LIMIT_ENABLED requires CACHE_ENABLED")
+		}
+		if c.RateLimit.PerMinute <= 0 || c.RateLimit.Burst <= 0 {
+			return fmt.Errorf("CUSTOMER_RATE_LIMIT_PER_MINUTE and CUSTOMER_RATE_LIMIT_BURST must be positive")
+		}
+		switch c.RateLimit.Mode {
+		case "reject":
+		case "delay":
+			if c.RateLimit.MaxDelay <= 0 {
+				return fmt.Errorf("CUSTOMER_RATE_LIMIT_MAX_DELAY must be positive when CUSTOMER_RATE_LIMIT_MODE is delay")
+			}
+		default:
+			return fmt.Errorf("CUSTOMER_RATE_LIMIT_MODE must be one of reject, delay")
+		}
+	}
+	if c.Outbound.MaxRetries < 0 {
+		return fmt.Errorf("OUTBOUND_MAX_RETRIES must not be negative")
 	}
-	if len(c.Kafka.Brokers) == 0 {
-		return fmt.Errorf("KAFKA_BROKERS is required")
+	if c.Outbound.BreakerFailureThreshold < 0 {
+		return fmt.Errorf("OUTBOUND_BREAKER_FAILURE_THRESHOLD must not be negative")
+	}
+	if c.Outbound.BreakerFailureThreshold > 0 && c.Outbound.BreakerOpenTimeout <= 0 {
+		return fmt.Errorf("OUTBOUND_BREAKER_OPEN_TIMEOUT must be positive")
+	}
+	if c.Quotas.Enabled {
+		if !c.Features.Cache {
+			return fmt.Errorf("CLIENT_QUOTAS_ENABLED requires CACHE_ENABLED")
+		}
+		if c.Quotas.RetentionDays < 31 {
+			return fmt.Errorf("CLIENT_USAGE_RETENTION_DAYS must be at least 31")
+		}
+		quotas := []struct {
+			key    string
+			values map[string]int64
+		}{
+			{"CLIENT_DAILY_QUOTAS", c.Quotas.Daily},
+			{"CLIENT_MONTHLY_QUOTAS", c.Quotas.Monthly},
+		}
+		for _, q := range quotas {
+			for client, quota := range q.values {
+				if _, ok := c.App.ClientAPIKeys[client]; !ok {
+					return fmt.Errorf("%s must only list clients of CLIENT_API_KEYS", q.key)
+				}
+				if quota <= 0 {
+					return fmt.Errorf("%s must be positive", q.key)
+				}
+			}
+		}
+	}
+	if (c.Kafka.EnableProducer || c.Kafka.ConsumeDeliveries || c.Kafka.ConsumeSummary) && len(c.Kafka.Brokers) == 0 {
+		return fmt.Errorf("KAFKA_BROKERS is required when KAFKA_ENABLE_PRODUCER, KAFKA_CONSUME_DELIVERY_CONFIRMATIONS or KAFKA_CONSUME_ORDER_SUMMARY is true")
+	}
+	if err := c.validateKafkaBrokers(); err != nil {
+		return err
+	}
+	if !c.Kafka.EnableProducer && c.Kafka.InMemoryBufferSize <= 0 {
+		return fmt.Errorf("KAFKA_IN_MEMORY_BUFFER_SIZE must be positive when KAFKA_ENABLE_PRODUCER is false")
+	}
+	if c.Kafka.EventFormat != "flat" && c.Kafka.EventFormat != "cdc" {
+		return fmt.Errorf("KAFKA_EVENT_FORMAT must be one of flat, cdc")
+	}
+	if c.Kafka.ConsumeDeliveries && (c.Kafka.DeliveriesTopic == "" || c.Kafka.ConsumerGroup == "") {
+		return fmt.Errorf("KAFKA_TOPIC_DELIVERY_CONFIRMATIONS and KAFKA_CONSUMER_GROUP are required when KAFKA_CONSUME_DELIVERY_CONFIRMATIONS is true")
+	}
+	if c.Kafka.ConsumeSummary && c.Kafka.SummaryConsumerGroup == "" {
+		return fmt.Errorf("KAFKA_SUMMARY_CONSUMER_GROUP is required when KAFKA_CONSUME_ORDER_SUMMARY is true")
+	}
+	if c.Kafka.ReplayRate < 0 {
+		return fmt.Errorf("EVENT_REPLAY_RATE must not be negative")
+	}
+	if c.Kafka.Notifications {
+		if !c.Kafka.EnableProducer {
+			return fmt.Errorf("KAFKA_NOTIFICATIONS_ENABLED requires KAFKA_ENABLE_PRODUCER")
+		}
+		if c.Kafka.NotificationsTopic == "" || c.Kafka.NotificationsDLQTopic == "" {
+			return fmt.Errorf("KAFKA_TOPIC_NOTIFICATIONS and KAFKA_TOPIC_NOTIFICATIONS_DLQ are required when KAFKA_NOTIFICATIONS_ENABLED is true")
+		}
+		if c.Kafka.NotificationRetryQueueSize <= 0 || c.Kafka.NotificationRetryMaxAttempts <= 0 || c.Kafka.NotificationRetryDelay <= 0 {
+			return fmt.Errorf("NOTIFICATION_RETRY_QUEUE_SIZE, NOTIFICATION_RETRY_MAX_ATTEMPTS and NOTIFICATION_RETRY_DELAY must be positive")
+		}
+	}
+	if c.Kafka.BreakerEnabled {
+		if c.Kafka.BreakerFailureThreshold < 1 {
+			return fmt.Errorf("KAFKA_BREAKER_FAILURE_THRESHOLD must be at least 1")
+		}
+		if c.Kafka.BreakerOpenTimeout <= 0 {
+			return fmt.Errorf("KAFKA_BREAKER_OPEN_TIMEOUT must be positive")
+		}
+	}
+	if c.Redis.ReconcileEnabled && (c.Redis.ReconcileInterval <= 0 || c.Redis.ReconcileSampleSize <= 0) {
+		return fmt.Errorf("CACHE_RECONCILE_INTERVAL and CACHE_RECONCILE_SAMPLE_SIZE must be positive when CACHE_RECONCILE_ENABLED is true")
+	}
+	if c.Redis.CompressMinSize < 0 {
+		return fmt.Errorf("CACHE_COMPRESS_MIN_SIZE must not be negative")
+	}
+	if c.App.DefaultPageSize <= 0 || c.App.MaxPageSize < c.App.DefaultPageSize {
+		return fmt.Errorf("DEFAULT_PAGE_SIZE must be positive and not greater than MAX_PAGE_SIZE")
+	}
+	if c.App.MaxScanWindow < 0 || (c.App.MaxScanWindow > 0 && c.App.MaxScanWindow < c.App.MaxPageSize) {
+		return fmt.Errorf("MAX_LIST_SCAN_WINDOW must be 0 or at least MAX_PAGE_SIZE")
+	}
+	if c.App.StreamThreshold < 0 {
+		return fmt.Errorf("LIST_STREAM_THRESHOLD must not be negative")
+	}
+	for _, key := range c.App.AdminPIIAPIKeys {
+		if !slices.Contains(c.App.AdminAPIKeys, key) {
+			return fmt.Errorf("ADMIN_PII_API_KEYS must only list keys of ADMIN_API_KEYS")
+		}
+	}
+	if c.App.AuthTokenSecret != "" && len(c.App.AuthTokenSecret) < 32 {
+		return fmt.Errorf("AUTH_TOKEN_SECRET must be at least 32 bytes long")
+	}
+	for _, status := range c.App.InitialStatuses {
+		if status != "NEW" && status != "IN_PROGRESS" {
+			return fmt.Errorf("ORDER_INITIAL_STATUSES must only list NEW, IN_PROGRESS")
+		}
+	}
+	if c.App.IDField != "orderId" && c.App.IDField != "id" {
+		return fmt.Errorf("API_ID_FIELD must be one of orderId, id")
+	}
+	if c.App.RequestIDMaxLength <= 0 {
+		return fmt.Errorf("REQUEST_ID_MAX_LENGTH must be positive")
+	}
+	if _, err := regexp.Compile(c.App.RequestIDPattern); err != nil {
+		return fmt.Errorf("REQUEST_ID_PATTERN must be a valid regular expression: %w", err)
+	}
+	if !currencyCode.MatchString(c.App.Currency) {
+		return fmt.Errorf("ORDER_CURRENCY must be an ISO 4217 currency code")
+	}
+	if len(c.App.ContentTypes) == 0 {
+		return fmt.Errorf("REQUEST_CONTENT_TYPES is required")
+	}
+	for _, contentType := range c.App.ContentTypes {
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			return fmt.Errorf("REQUEST_CONTENT_TYPES must only list valid media types: %w", err)
+		}
+	}
+	if c.SLA.MaxLead > 0 && c.SLA.MaxLead < c.SLA.MinLead {
+		return fmt.Errorf("DELIVERY_PROMISE_MAX_LEAD must not be lower than DELIVERY_PROMISE_MIN_LEAD")
+	}
+	if c.SLA.SweepEnabled && (c.SLA.SweepInterval <= 0 || c.SLA.SweepBatchSize <= 0) {
+		return fmt.Errorf("SLA_SWEEP_INTERVAL and SLA_SWEEP_BATCH_SIZE must be positive when SLA_SWEEP_ENABLED is true")
+	}
+	if c.Catalog.Enabled && c.Catalog.BaseURL == "" {
+		return fmt.Errorf("CATALOG_BASE_URL is required when CATALOG_ENABLED is true")
+	}
+	if c.Catalog.ValidateSKUs && c.Catalog.BaseURL == "" {
+		return fmt.Errorf("CATALOG_BASE_URL is required when CATALOG_SKU_VALIDATION is true")
+	}
+	switch c.Customers.ValidationMode {
+	case "none", "fake":
+	case "http":
+		if c.Customers.BaseURL == "" {
+			return fmt.Errorf("CUSTOMERS_BASE_URL is required when CUSTOMER_VALIDATION_MODE is http")
+		}
+	default:
+		return fmt.Errorf("CUSTOMER_VALIDATION_MODE must be one of none, http, fake")
+	}
+	switch c.Customers.IDFormat {
+	case "uuid", "alphanumeric", "any":
+	default:
+		return fmt.Errorf("CUSTOMER_ID_FORMAT must be one of uuid, alphanumeric, any")
+	}
+	if c.Inventory.Enabled {
+		if err := c.validateInventory(); err != nil {
+			return err
+		}
+	}
+	if c.Server.IsProduction() {
+		return c.validateProduction()
+	}
+	return nil
+}
+
+// validateInventory checks the inventory integration once reservations are
+// enabled
+func (c *Config) validateInventory() error {
+	if c.Inventory.BaseURL == "" {
+		return fmt.Errorf("INVENTORY_BASE_URL is required when INVENTORY_RESERVATION_ENABLED is true")
+	}
+	switch c.Inventory.Stage {
+	case "pre-persist", "post-persist":
+	default:
+		return fmt.Errorf("INVENTORY_RESERVATION_STAGE must be one of pre-persist, post-persist")
+	}
+	if c.Inventory.Timeout <= 0 || c.Inventory.ReleaseRetryInterval <= 0 || c.Inventory.ReleaseRetryDelay <= 0 {
+		return fmt.Errorf("INVENTORY_TIMEOUT, INVENTORY_RELEASE_RETRY_INTERVAL and INVENTORY_RELEASE_RETRY_DELAY must be positive")
+	}
+	if c.Inventory.ReleaseBatchSize <= 0 {
+		return fmt.Errorf("INVENTORY_RELEASE_BATCH_SIZE must be positive")
+	}
+	// A run may last a whole interval before the heartbeat of the next one
+	if c.Inventory.ReleaseStaleAfter > 0 && c.Inventory.ReleaseStaleAfter <= 2*c.Inventory.ReleaseRetryInterval {
+		return fmt.Errorf("INVENTORY_RELEASE_STALE_AFTER must be greater than twice INVENTORY_RELEASE_RETRY_INTERVAL")
+	}
+	return nil
+}
+
+// validateKafkaBrokers checks that every broker is a host:port address,
+// trimming the whitespace around them
+func (c *Config) validateKafkaBrokers() error {
+	for i, broker := range c.Kafka.Brokers {
+		broker = strings.TrimSpace(broker)
+		if broker == "" {
+			return fmt.Errorf("KAFKA_BROKERS entry %d is empty", i+1)
+		}
+		host, port, err := net.SplitHostPort(broker)
+		if err != nil {
+			return fmt.Errorf("KAFKA_BROKERS entry %q must be host:port", broker)
+		}
+		if strings.TrimSpace(host) != host || host == "" {
+			return fmt.Errorf("KAFKA_BROKERS entry %q has an invalid host", broker)
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("KAFKA_BROKERS entry %q has an invalid port", broker)
+		}
+		c.Kafka.Brokers[i] = broker
+	}
+	return nil
+}
+
+// validateDurations checks that timeouts are set and that optional durations,
+// where 0 disables a limit, are not negative
+func (c *Config) validateDurations() error {
+	positive := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"MONGODB_CONNECTION_TIMEOUT", c.MongoDB.ConnectionTimeout},
+		{"MONGODB_SERVER_SELECTION_TIMEOUT", c.MongoDB.ServerSelectionTimeout},
+		{"REDIS_DEFAULT_TTL", c.Redis.DefaultTTL},
+		{"REQUEST_TIMEOUT", c.App.RequestTimeout},
+		{"CATALOG_TIMEOUT", c.Catalog.Timeout},
+		{"CUSTOMERS_TIMEOUT", c.Customers.Timeout},
+		{"SHUTDOWN_WORKERS_TIMEOUT", c.Server.Shutdown.WorkersTimeout},
+		{"SHUTDOWN_PRODUCER_TIMEOUT", c.Server.Shutdown.ProducerTimeout},
+		{"SHUTDOWN_STORES_TIMEOUT", c.Server.Shutdown.StoresTimeout},
+		{"HEALTH_REFRESH_INTERVAL", c.Server.Health.RefreshInterval},
+	}
+	for _, d := range positive {
+		if d.value <= 0 {
+			return fmt.Errorf("%s must be positive", d.key)
+		}
+	}
+
+	optional := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"HANDLER_TIMEOUT", c.Server.HandlerTimeout},
+		{"MONGODB_MAX_CONN_IDLE_TIME", c.MongoDB.MaxConnIdleTime},
+		{"MONGODB_LIST_MAX_TIME", c.MongoDB.ListMaxTime},
+		{"SHUTDOWN_READINESS_DELAY", c.Server.Shutdown.ReadinessDelay},
+		{"CACHE_WRITE_RETRY_DELAY", c.Redis.RetryDelay},
+		{"CACHE_STATS_TTL", c.Redis.StatsTTL},
+		{"KAFKA_PUBLISH_TIMEOUT", c.Kafka.PublishTimeout},
+		{"RETURN_WINDOW", c.App.ReturnWindow},
+		{"CATALOG_PRICE_CACHE_TTL", c.Catalog.PriceCacheTTL},
+		{"CATALOG_SKU_CACHE_TTL", c.Catalog.SKUCacheTTL},
+		{"CUSTOMERS_CACHE_TTL", c.Customers.CacheTTL},
+		{"DELIVERY_SLA", c.SLA.DefaultDuration},
+		{"DELIVERY_PROMISE_MIN_LEAD", c.SLA.MinLead},
+		{"DELIVERY_PROMISE_MAX_LEAD", c.SLA.MaxLead},
+		{"INVENTORY_RELEASE_STALE_AFTER", c.Inventory.ReleaseStaleAfter},
+		{"OUTBOUND_RETRY_BACKOFF", c.Outbound.RetryBackoff},
+	}
+	for _, d := range optional {
+		if d.value < 0 {
+			return fmt.Errorf("%s must not be negative", d.key)
+		}
 	}
 	return nil
 }
 
+// currencyCode matches ISO 4217 alphabetic currency codes
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// developmentPasswords are the credentials of the local stack, never valid in
+// production
+var developmentPasswords = map[string]bool{
+	"":         true,
+	"password": true,
+	"admin":    true,
+}
+
+// validateProduction rejects settings that are only meant for development
+func (c *Config) validateProduction() error {
+	if c.Server.GinMode != "release" {
+		return fmt.Errorf("GIN_MODE must be release in production")
+	}
+	if strings.EqualFold(c.Logging.Level, "debug") && !c.Logging.AllowDebugInProduction {
+		return fmt.Errorf("LOG_LEVEL debug is not allowed in production unless LOG_ALLOW_DEBUG_IN_PRODUCTION is true")
+	}
+	if c.Kafka.AutoCreateTopics {
+		return fmt.Errorf("KAFKA_AUTO_CREATE_TOPICS must be false in production")
+	}
+	if !c.MongoDB.RequireIndexes {
+		return fmt.Errorf("MONGODB_REQUIRE_INDEXES must be true in production")
+	}
+
+	uri, err := url.Parse(c.MongoDB.URI)
+	if err != nil {
+		return fmt.Errorf("MONGODB_URI is not a valid URI")
+	}
+	if uri.User == nil {
+		return fmt.Errorf("MONGODB_URI must include credentials in production")
+	}
+	if password, _ := uri.User.Password(); developmentPasswords[password] {
+		return fmt.Errorf("MONGODB_URI must not use the development credentials in production")
+	}
+	if c.Features.Cache && developmentPasswords[c.Redis.Password] {
+		return fmt.Errorf("REDIS_PASSWORD must be set to a non-default password in production")
+	}
+	return nil
+}
+
+// getList reads a comma-separated list, or a list of the configuration file,
+// dropping blank entries
+func getList(key string) []string {
+	entries := strings.Split(viper.GetString(key), ",")
+	if list, ok := viper.Get(key).([]interface{}); ok {
+		entries = cast.ToStringSlice(list)
+	}
+
+	var values []string
+	for _, value := range entries {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getMap reads a comma-separated list of key=value pairs, or a map of the
+// configuration file
+func getMap(key string) (map[string]string, error) {
+	values := make(map[string]string)
+	if _, ok := viper.Get(key).(map[string]interface{}); ok {
+		for k, v := range viper.GetStringMapString(key) {
+			values[k] = v
+		}
+		return values, nil
+	}
+	for _, entry := range getList(key) {
+		k, v, ok := strings.Cut(entry, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("%s entry %q must be in the form key=value", key, entry)
+		}
+		values[k] = v
+	}
+	return values, nil
+}
+
+// getCountMap reads a map of key=count pairs like getMap
+func getCountMap(key string) (map[string]int64, error) {
+	entries, err := getMap(key)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(entries))
+	for k, v := range entries {
+		count, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s entry %q must be a whole number", key, k)
+		}
+		counts[k] = count
+	}
+	return counts, nil
+}
+
 // setDefaults sets default values for all configuration keys
 func setDefaults() {
 	// Server defaults
 	viper.SetDefault("ENV", "development")
+	viper.SetDefault("SERVICE_VERSION", "dev")
 	viper.SetDefault("PORT", "3000")
 	viper.SetDefault("SERVER_READ_TIMEOUT", "10s")
+	viper.SetDefault("SERVER_READ_HEADER_TIMEOUT", "5s")
 	viper.SetDefault("SERVER_WRITE_TIMEOUT", "10s")
+	viper.SetDefault("SERVER_IDLE_TIMEOUT", "120s")
+	viper.SetDefault("SERVER_MAX_HEADER_BYTES", 1<<20)
+	viper.SetDefault("HANDLER_TIMEOUT", "8s")
+	viper.SetDefault("SHUTDOWN_READINESS_DELAY", "5s")
+	viper.SetDefault("SHUTDOWN_DRAIN_TIMEOUT", "10s")
+	viper.SetDefault("SHUTDOWN_WORKERS_TIMEOUT", "10s")
+	viper.SetDefault("SHUTDOWN_PRODUCER_TIMEOUT", "5s")
+	viper.SetDefault("SHUTDOWN_STORES_TIMEOUT", "5s")
+	viper.SetDefault("SERVER_TLS_MIN_VERSION", "1.2")
+	viper.SetDefault("HEALTH_HISTORY_SIZE", 10)
+	viper.SetDefault("HEALTH_FAILURE_THRESHOLD", 3)
+	viper.SetDefault("HEALTH_REFRESH_INTERVAL", "5s")
+	viper.SetDefault("GIN_MODE", "debug")
+	viper.SetDefault("ENABLE_SWAGGER", true)
+	viper.SetDefault("SERVER_TIMING", ServerTimingOff)
 
 	// MongoDB defaults
 	viper.SetDefault("MONGODB_DATABASE", "orders_db")
 	viper.SetDefault("MONGODB_CONNECTION_TIMEOUT", "10s")
+	viper.SetDefault("MONGODB_SERVER_SELECTION_TIMEOUT", "30s")
 	viper.SetDefault("MONGODB_MAX_POOL_SIZE", 100)
+	viper.SetDefault("MONGODB_MIN_POOL_SIZE", 0)
+	viper.SetDefault("MONGODB_MAX_CONN_IDLE_TIME", "0s")
+	viper.SetDefault("MONGODB_REQUIRE_INDEXES", false)
+	viper.SetDefault("MONGODB_BREAKER_ENABLED", false)
+	viper.SetDefault("MONGODB_BREAKER_FAILURE_THRESHOLD", 5)
+	viper.SetDefault("MONGODB_BREAKER_OPEN_TIMEOUT", "30s")
+	viper.SetDefault("MONGODB_LIST_MAX_TIME", "5s")
 
 	// Redis defaults
+	viper.SetDefault("CACHE_ENABLED", true)
 	viper.SetDefault("REDIS_DB", 0)
 	viper.SetDefault("REDIS_POOL_SIZE", 10)
 	viper.SetDefault("REDIS_DEFAULT_TTL", "60s")
+	viper.SetDefault("CACHE_STATS_TTL", "30s")
+	viper.SetDefault("CACHE_COMPACT_SUMMARIES", false)
+	viper.SetDefault("CACHE_RECONCILE_ENABLED", false)
+	viper.SetDefault("CACHE_RECONCILE_INTERVAL", "5m")
+	viper.SetDefault("CACHE_RECONCILE_SAMPLE_SIZE", 100)
+	viper.SetDefault("CACHE_WRITE_RETRY_QUEUE_SIZE", 1000)
+	viper.SetDefault("CACHE_WRITE_RETRY_MAX_ATTEMPTS", 3)
+	viper.SetDefault("CACHE_WRITE_RETRY_DELAY", "200ms")
+	viper.SetDefault("CACHE_COMPRESS", false)
+	viper.SetDefault("CACHE_COMPRESS_MIN_SIZE", 1024)
 
 	// Kafka defaults
 	viper.SetDefault("KAFKA_TOPIC_ORDERS", "orders.events")
 	viper.SetDefault("KAFKA_CONSUMER_GROUP", "orders-service")
 	viper.SetDefault("KAFKA_ENABLE_PRODUCER", true)
+	viper.SetDefault("KAFKA_EVENT_FORMAT", "flat")
+	viper.SetDefault("KAFKA_AUTO_CREATE_TOPICS", true)
+	viper.SetDefault("KAFKA_IN_MEMORY_BUFFER_SIZE", 1000)
+	viper.SetDefault("KAFKA_CONSUME_DELIVERY_CONFIRMATIONS", false)
+	viper.SetDefault("KAFKA_TOPIC_DELIVERY_CONFIRMATIONS", "logistics.delivery-confirmations")
+	viper.SetDefault("KAFKA_CONSUME_ORDER_SUMMARY", false)
+	viper.SetDefault("KAFKA_SUMMARY_CONSUMER_GROUP", "orders-service-summary")
+	viper.SetDefault("KAFKA_PUBLISH_TIMEOUT", "5s")
+	viper.SetDefault("KAFKA_BREAKER_ENABLED", false)
+	viper.SetDefault("KAFKA_BREAKER_FAILURE_THRESHOLD", 5)
+	viper.SetDefault("KAFKA_BREAKER_OPEN_TIMEOUT", "30s")
+	viper.SetDefault("EVENT_REPLAY_RATE", 200)
+	viper.SetDefault("KAFKA_NOTIFICATIONS_ENABLED", false)
+	viper.SetDefault("KAFKA_TOPIC_NOTIFICATIONS", "notifications.requests")
+	viper.SetDefault("KAFKA_TOPIC_NOTIFICATIONS_DLQ", "notifications.requests.dlq")
+	viper.SetDefault("NOTIFICATION_RETRY_QUEUE_SIZE", 1000)
+	viper.SetDefault("NOTIFICATION_RETRY_MAX_ATTEMPTS", 5)
+	viper.SetDefault("NOTIFICATION_RETRY_DELAY", "2s")
 
 	// Logging defaults
 	viper.SetDefault("LOG_LEVEL", "info")
 	viper.SetDefault("LOG_FORMAT", "json")
+	viper.SetDefault("LOG_ALLOW_DEBUG_IN_PRODUCTION", false)
+	viper.SetDefault("LOG_LEVEL_ENCODER", LevelEncoderAuto)
+	viper.SetDefault("LOG_CALLER", "auto")
 
 	// App defaults
 	viper.SetDefault("REQUEST_TIMEOUT", "30s")
 	viper.SetDefault("MAX_ITEMS_PER_ORDER", 100)
 	viper.SetDefault("DEFAULT_PAGE_SIZE", 10)
 	viper.SetDefault("MAX_PAGE_SIZE", 100)
+	viper.SetDefault("MAX_LIST_SCAN_WINDOW", 10000)
+	viper.SetDefault("LIST_STREAM_THRESHOLD", 50)
+	viper.SetDefault("API_ID_FIELD", "orderId")
+	viper.SetDefault("REQUEST_ID_MAX_LENGTH", 128)
+	viper.SetDefault("REQUEST_ID_PATTERN", `^[A-Za-z0-9._:-]+$`)
+	viper.SetDefault("REQUEST_CONTENT_TYPES", "application/json")
+	viper.SetDefault("ORDER_CURRENCY", "USD")
+	viper.SetDefault("MAX_NOTE_LENGTH", 2000)
+	viper.SetDefault("MAX_NOTES_PER_ORDER", 100)
+	viper.SetDefault("RETURN_WINDOW", "720h")
+	viper.SetDefault("CONSOLIDATE_DUPLICATE_SKUS", true)
+	viper.SetDefault("SUMMARY_READS_ENABLED", false)
+	viper.SetDefault("MAX_ORDER_WEIGHT_GRAMS", 1000000)
+	viper.SetDefault("ORDER_INITIAL_STATUSES", "NEW")
+	viper.SetDefault("SCHEMA_VALIDATION_ENABLED", false)
+	viper.SetDefault("EVENT_SNAPSHOTS_ENABLED", true)
+
+	// Catalog defaults
+	viper.SetDefault("CATALOG_ENABLED", false)
+	viper.SetDefault("CATALOG_TIMEOUT", "2s")
+	viper.SetDefault("CATALOG_PRICE_CACHE_TTL", "30s")
+	viper.SetDefault("CATALOG_SKU_VALIDATION", false)
+	viper.SetDefault("CATALOG_SKU_FAIL_OPEN", false)
+	viper.SetDefault("CATALOG_SKU_CACHE_TTL", "1m")
+
+	// Customers defaults
+	viper.SetDefault("CUSTOMER_VALIDATION_MODE", "none")
+	viper.SetDefault("CUSTOMERS_TIMEOUT", "2s")
+	viper.SetDefault("CUSTOMERS_CACHE_TTL", "60s")
+	viper.SetDefault("CUSTOMER_VALIDATION_SOFT_FAIL", false)
+	viper.SetDefault("CUSTOMER_ID_FORMAT", "uuid")
+
+	// Inventory defaults
+	viper.SetDefault("INVENTORY_RESERVATION_ENABLED", false)
+	viper.SetDefault("INVENTORY_TIMEOUT", "2s")
+	viper.SetDefault("INVENTORY_RESERVATION_STAGE", "pre-persist")
+	viper.SetDefault("INVENTORY_RELEASE_RETRY_INTERVAL", "30s")
+	viper.SetDefault("INVENTORY_RELEASE_RETRY_DELAY", "1m")
+	viper.SetDefault("INVENTORY_RELEASE_BATCH_SIZE", 100)
+	viper.SetDefault("INVENTORY_RELEASE_STALE_AFTER", "5m")
+
+	// SLA defaults
+	viper.SetDefault("DELIVERY_SLA", "48h")
+	viper.SetDefault("DELIVERY_PROMISE_MIN_LEAD", "1h")
+	viper.SetDefault("DELIVERY_PROMISE_MAX_LEAD", "720h")
+	viper.SetDefault("SLA_SWEEP_ENABLED", false)
+	viper.SetDefault("SLA_SWEEP_INTERVAL", "1m")
+	viper.SetDefault("SLA_SWEEP_BATCH_SIZE", 100)
+
+	// Tenancy defaults
+	viper.SetDefault("MULTI_TENANT_ENABLED", false)
+	viper.SetDefault("DEFAULT_TENANT", "default")
+
+	// Rate limit defaults
+	viper.SetDefault("CUSTOMER_RATE_LIMIT_ENABLED", false)
+	viper.SetDefault("CUSTOMER_RATE_LIMIT_PER_MINUTE", 10)
+	viper.SetDefault("CUSTOMER_RATE_LIMIT_BURST", 5)
+	viper.SetDefault("CUSTOMER_RATE_LIMIT_MODE", "reject")
+	viper.SetDefault("CUSTOMER_RATE_LIMIT_MAX_DELAY", "2s")
+
+	// Client quota defaults
+	viper.SetDefault("CLIENT_QUOTAS_ENABLED", false)
+	viper.SetDefault("CLIENT_USAGE_RETENTION_DAYS", 400)
+
+	// Outbound call defaults
+	viper.SetDefault("OUTBOUND_MAX_RETRIES", 2)
+	viper.SetDefault("OUTBOUND_RETRY_BACKOFF", "100ms")
+	viper.SetDefault("OUTBOUND_BREAKER_FAILURE_THRESHOLD", 5)
+	viper.SetDefault("OUTBOUND_BREAKER_OPEN_TIMEOUT", "30s")
+}
+
+// setProfileDefaults overrides the defaults of the environment. Values set
+// explicitly still win, and are validated against the environment.
+func setProfileDefaults(env string) {
+	switch env {
+	case EnvStaging:
+		viper.SetDefault("GIN_MODE", "release")
+	case EnvProduction:
+		viper.SetDefault("GIN_MODE", "release")
+		viper.SetDefault("KAFKA_AUTO_CREATE_TOPICS", false)
+		viper.SetDefault("MONGODB_REQUIRE_INDEXES", true)
+		viper.SetDefault("ENABLE_SWAGGER", false)
+	}
 }
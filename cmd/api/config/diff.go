@@ -0,0 +1,33 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Diff returns the settings that differ between two configurations, named by
+// their field path such as Redis.DefaultTTL, in alphabetical order.
+func Diff(old, updated *Config) []string {
+	var changed []string
+	diffFields("", reflect.ValueOf(*old), reflect.ValueOf(*updated), &changed)
+	sort.Strings(changed)
+	return changed
+}
+
+func diffFields(prefix string, old, updated reflect.Value, changed *[]string) {
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		if field.Name == "LegacyEnv" {
+			continue
+		}
+		path := prefix + field.Name
+		oldValue, updatedValue := old.Field(i), updated.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			diffFields(path+".", oldValue, updatedValue, changed)
+			continue
+		}
+		if !reflect.DeepEqual(oldValue.Interface(), updatedValue.Interface()) {
+			*changed = append(*changed, path)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix prefixes the environment variables of the nested keys, so
+// server.port is set with ORDERS_SERVER_PORT.
+const EnvPrefix = "ORDERS"
+
+// configFileEnv points to a YAML or TOML configuration file. When it is not
+// set, the .env file of the working directory is read, if any.
+const configFileEnv = "CONFIG_FILE"
+
+// keys maps the flat names settings were historically read from to their
+// nested key. The flat names keep working, as environment variables and in
+// .env files, while they are deprecated.
+var keys = []struct {
+	legacy string
+	nested string
+}{
+	// Server
+	{"ENV", "server.environment"},
+	{"SERVICE_VERSION", "server.version"},
+	{"PORT", "server.port"},
+	{"GIN_MODE", "server.gin_mode"},
+	{"ENABLE_SWAGGER", "server.enable_swagger"},
+	{"SERVER_TIMING", "server.server_timing"},
+	{"SERVER_READ_TIMEOUT", "server.read_timeout"},
+	{"SERVER_READ_HEADER_TIMEOUT", "server.read_header_timeout"},
+	{"SERVER_WRITE_TIMEOUT", "server.write_timeout"},
+	{"SERVER_IDLE_TIMEOUT", "server.idle_timeout"},
+	{"SERVER_MAX_HEADER_BYTES", "server.max_header_bytes"},
+	{"HANDLER_TIMEOUT", "server.handler_timeout"},
+	{"SHUTDOWN_READINESS_DELAY", "server.shutdown.readiness_delay"},
+	{"SHUTDOWN_DRAIN_TIMEOUT", "server.shutdown.drain_timeout"},
+	{"SHUTDOWN_WORKERS_TIMEOUT", "server.shutdown.workers_timeout"},
+	{"SHUTDOWN_PRODUCER_TIMEOUT", "server.shutdown.producer_timeout"},
+	{"SHUTDOWN_STORES_TIMEOUT", "server.shutdown.stores_timeout"},
+	{"SERVER_TLS_CERT_FILE", "server.tls.cert_file"},
+	{"SERVER_TLS_KEY_FILE", "server.tls.key_file"},
+	{"SERVER_TLS_MIN_VERSION", "server.tls.min_version"},
+	{"SERVER_TLS_CLIENT_CA_FILE", "server.tls.client_ca_file"},
+	{"HEALTH_HISTORY_SIZE", "server.health.history_size"},
+	{"HEALTH_FAILURE_THRESHOLD", "server.health.failure_threshold"},
+	{"HEALTH_REFRESH_INTERVAL", "server.health.refresh_interval"},
+
+	// MongoDB
+	{"MONGODB_URI", "mongodb.uri"},
+	{"MONGODB_URI_FILE", "mongodb.uri_file"},
+	{"MONGODB_DATABASE", "mongodb.database"},
+	{"MONGODB_CONNECTION_TIMEOUT", "mongodb.connection_timeout"},
+	{"MONGODB_SERVER_SELECTION_TIMEOUT", "mongodb.server_selection_timeout"},
+	{"MONGODB_MAX_POOL_SIZE", "mongodb.max_pool_size"},
+	{"MONGODB_MIN_POOL_SIZE", "mongodb.min_pool_size"},
+	{"MONGODB_MAX_CONN_IDLE_TIME", "mongodb.max_conn_idle_time"},
+	{"MONGODB_REQUIRE_INDEXES", "mongodb.require_indexes"},
+	{"MONGODB_BREAKER_ENABLED", "mongodb.breaker_enabled"},
+	{"MONGODB_BREAKER_FAILURE_THRESHOLD", "mongodb.breaker_failure_threshold"},
+	{"MONGODB_BREAKER_OPEN_TIMEOUT", "mongodb.breaker_open_timeout"},
+	{"MONGODB_LIST_MAX_TIME", "mongodb.list_max_time"},
+
+	// Redis
+	{"REDIS_URL", "redis.url"},
+	{"REDIS_PASSWORD", "redis.password"},
+	{"REDIS_PASSWORD_FILE", "redis.password_file"},
+	{"REDIS_DB", "redis.db"},
+	{"REDIS_POOL_SIZE", "redis.pool_size"},
+	{"REDIS_DEFAULT_TTL", "redis.default_ttl"},
+	{"CACHE_STATS_TTL", "redis.stats_ttl"},
+	{"CACHE_RECONCILE_ENABLED", "redis.reconcile.enabled"},
+	{"CACHE_RECONCILE_INTERVAL", "redis.reconcile.interval"},
+	{"CACHE_RECONCILE_SAMPLE_SIZE", "redis.reconcile.sample_size"},
+	{"CACHE_WRITE_RETRY_QUEUE_SIZE", "redis.write_retry.queue_size"},
+	{"CACHE_WRITE_RETRY_MAX_ATTEMPTS", "redis.write_retry.max_attempts"},
+	{"CACHE_WRITE_RETRY_DELAY", "redis.write_retry.delay"},
+	{"CACHE_COMPRESS", "redis.compress"},
+	{"CACHE_COMPRESS_MIN_SIZE", "redis.compress_min_size"},
+
+	// Kafka
+	{"KAFKA_BROKERS", "kafka.brokers"},
+	{"KAFKA_TOPIC_ORDERS", "kafka.topic_orders"},
+	{"KAFKA_TOPIC_ROUTES", "kafka.topic_routes"},
+	{"KAFKA_CONSUMER_GROUP", "kafka.consumer_group"},
+	{"KAFKA_ENABLE_PRODUCER", "kafka.enable_producer"},
+	{"KAFKA_EVENT_FORMAT", "kafka.event_format"},
+	{"KAFKA_AUTO_CREATE_TOPICS", "kafka.auto_create_topics"},
+	{"KAFKA_IN_MEMORY_BUFFER_SIZE", "kafka.in_memory_buffer_size"},
+	{"KAFKA_CONSUME_DELIVERY_CONFIRMATIONS", "kafka.consume_delivery_confirmations"},
+	{"KAFKA_TOPIC_DELIVERY_CONFIRMATIONS", "kafka.topic_delivery_confirmations"},
+	{"KAFKA_CONSUME_ORDER_SUMMARY", "kafka.consume_order_summary"},
+	{"KAFKA_SUMMARY_CONSUMER_GROUP", "kafka.summary_consumer_group"},
+	{"KAFKA_PUBLISH_TIMEOUT", "kafka.publish_timeout"},
+	{"KAFKA_BREAKER_ENABLED", "kafka.breaker_enabled"},
+	{"KAFKA_BREAKER_FAILURE_THRESHOLD", "kafka.breaker_failure_threshold"},
+	{"KAFKA_BREAKER_OPEN_TIMEOUT", "kafka.breaker_open_timeout"},
+	{"EVENT_REPLAY_RATE", "kafka.event_replay_rate"},
+	{"KAFKA_NOTIFICATIONS_ENABLED", "kafka.notifications.enabled"},
+	{"KAFKA_TOPIC_NOTIFICATIONS", "kafka.notifications.topic"},
+	{"KAFKA_TOPIC_NOTIFICATIONS_DLQ", "kafka.notifications.dlq_topic"},
+	{"NOTIFICATION_RETRY_QUEUE_SIZE", "kafka.notifications.retry_queue_size"},
+	{"NOTIFICATION_RETRY_MAX_ATTEMPTS", "kafka.notifications.retry_max_attempts"},
+	{"NOTIFICATION_RETRY_DELAY", "kafka.notifications.retry_delay"},
+
+	// Logging
+	{"LOG_LEVEL", "logging.level"},
+	{"LOG_FORMAT", "logging.format"},
+	{"LOG_ALLOW_DEBUG_IN_PRODUCTION", "logging.allow_debug_in_production"},
+	{"LOG_LEVEL_ENCODER", "logging.level_encoder"},
+	{"LOG_CALLER", "logging.caller"},
+
+	// App
+	{"REQUEST_TIMEOUT", "app.request_timeout"},
+	{"MAX_ITEMS_PER_ORDER", "app.max_items_per_order"},
+	{"DEFAULT_PAGE_SIZE", "app.default_page_size"},
+	{"MAX_PAGE_SIZE", "app.max_page_size"},
+	{"MAX_LIST_SCAN_WINDOW", "app.max_list_scan_window"},
+	{"LIST_STREAM_THRESHOLD", "app.list_stream_threshold"},
+	{"MAX_NOTE_LENGTH", "app.max_note_length"},
+	{"MAX_NOTES_PER_ORDER", "app.max_notes_per_order"},
+	{"RETURN_WINDOW", "app.return_window"},
+	{"MAX_ORDER_WEIGHT_GRAMS", "app.max_order_weight_grams"},
+	{"ORDER_INITIAL_STATUSES", "app.initial_statuses"},
+	{"ADMIN_API_KEYS", "app.admin_api_keys"},
+	{"ADMIN_API_KEYS_FILE", "app.admin_api_keys_file"},
+	{"ADMIN_PII_API_KEYS", "app.admin_pii_api_keys"},
+	{"ADMIN_PII_API_KEYS_FILE", "app.admin_pii_api_keys_file"},
+	{"CLIENT_API_KEYS", "app.client_api_keys"},
+	{"CLIENT_API_KEYS_FILE", "app.client_api_keys_file"},
+	{"AUTH_TOKEN_SECRET", "app.auth_token_secret"},
+	{"AUTH_TOKEN_SECRET_FILE", "app.auth_token_secret_file"},
+	{"API_ID_FIELD", "app.id_field"},
+	{"REQUEST_ID_MAX_LENGTH", "app.request_id.max_length"},
+	{"REQUEST_ID_PATTERN", "app.request_id.pattern"},
+	{"REQUEST_CONTENT_TYPES", "app.request_content_types"},
+	{"ORDER_CURRENCY", "app.currency"},
+
+	// Catalog
+	{"CATALOG_ENABLED", "catalog.enabled"},
+	{"CATALOG_BASE_URL", "catalog.base_url"},
+	{"CATALOG_API_KEY", "catalog.api_key"},
+	{"CATALOG_API_KEY_FILE", "catalog.api_key_file"},
+	{"CATALOG_TIMEOUT", "catalog.timeout"},
+	{"CATALOG_PRICE_CACHE_TTL", "catalog.price_cache_ttl"},
+	{"CATALOG_SKU_VALIDATION", "catalog.sku_validation"},
+	{"CATALOG_SKU_FAIL_OPEN", "catalog.sku_fail_open"},
+	{"CATALOG_SKU_CACHE_TTL", "catalog.sku_cache_ttl"},
+
+	// Customers
+	{"CUSTOMER_VALIDATION_MODE", "customers.validation_mode"},
+	{"CUSTOMERS_BASE_URL", "customers.base_url"},
+	{"CUSTOMERS_TIMEOUT", "customers.timeout"},
+	{"CUSTOMERS_CACHE_TTL", "customers.cache_ttl"},
+	{"CUSTOMER_VALIDATION_SOFT_FAIL", "customers.validation_soft_fail"},
+	{"CUSTOMERS_FAKE_IDS", "customers.fake_ids"},
+	{"CUSTOMER_ID_FORMAT", "customers.id_format"},
+
+	// Inventory
+	{"INVENTORY_RESERVATION_ENABLED", "inventory.reservation_enabled"},
+	{"INVENTORY_BASE_URL", "inventory.base_url"},
+	{"INVENTORY_API_KEY", "inventory.api_key"},
+	{"INVENTORY_API_KEY_FILE", "inventory.api_key_file"},
+	{"INVENTORY_TIMEOUT", "inventory.timeout"},
+	{"INVENTORY_RESERVATION_STAGE", "inventory.reservation_stage"},
+	{"INVENTORY_RELEASE_RETRY_INTERVAL", "inventory.release_retry_interval"},
+	{"INVENTORY_RELEASE_RETRY_DELAY", "inventory.release_retry_delay"},
+	{"INVENTORY_RELEASE_BATCH_SIZE", "inventory.release_batch_size"},
+	{"INVENTORY_RELEASE_STALE_AFTER", "inventory.release_stale_after"},
+
+	// SLA
+	{"DELIVERY_SLA", "sla.default_duration"},
+	{"DELIVERY_PROMISE_MIN_LEAD", "sla.promise_min_lead"},
+	{"DELIVERY_PROMISE_MAX_LEAD", "sla.promise_max_lead"},
+	{"SLA_SWEEP_ENABLED", "sla.sweep_enabled"},
+	{"SLA_SWEEP_INTERVAL", "sla.sweep_interval"},
+	{"SLA_SWEEP_BATCH_SIZE", "sla.sweep_batch_size"},
+
+	// Features
+	{"CACHE_ENABLED", "features.cache"},
+	{"CACHE_COMPACT_SUMMARIES", "features.compact_summaries"},
+	{"EVENT_SNAPSHOTS_ENABLED", "features.event_snapshots"},
+	{"SCHEMA_VALIDATION_ENABLED", "features.schema_validation"},
+	{"CONSOLIDATE_DUPLICATE_SKUS", "features.item_consolidation"},
+	{"SUMMARY_READS_ENABLED", "features.summary_reads"},
+
+	// Tenancy
+	{"MULTI_TENANT_ENABLED", "tenancy.enabled"},
+	{"TENANTS", "tenancy.tenants"},
+	{"DEFAULT_TENANT", "tenancy.default_tenant"},
+
+	// Rate limiting
+	{"CUSTOMER_RATE_LIMIT_ENABLED", "rate_limit.enabled"},
+	{"CUSTOMER_RATE_LIMIT_PER_MINUTE", "rate_limit.per_minute"},
+	{"CUSTOMER_RATE_LIMIT_BURST", "rate_limit.burst"},
+	{"CUSTOMER_RATE_LIMIT_MODE", "rate_limit.mode"},
+	{"CUSTOMER_RATE_LIMIT_MAX_DELAY", "rate_limit.max_delay"},
+	{"CLIENT_QUOTAS_ENABLED", "quotas.enabled"},
+	{"CLIENT_DAILY_QUOTAS", "quotas.daily"},
+	{"CLIENT_MONTHLY_QUOTAS", "quotas.monthly"},
+	{"CLIENT_USAGE_RETENTION_DAYS", "quotas.retention_days"},
+
+	// Outbound calls
+	{"OUTBOUND_MAX_RETRIES", "outbound.max_retries"},
+	{"OUTBOUND_RETRY_BACKOFF", "outbound.retry_backoff"},
+	{"OUTBOUND_BREAKER_FAILURE_THRESHOLD", "outbound.breaker_failure_threshold"},
+	{"OUTBOUND_BREAKER_OPEN_TIMEOUT", "outbound.breaker_open_timeout"},
+}
+
+// prefixedEnv returns the environment variable of a nested key.
+func prefixedEnv(nested string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(nested, ".", "_"))
+}
+
+// bindKeys lets settings be read by their flat name, which the rest of the
+// package uses, from any of their sources. From highest to lowest precedence:
+// the prefixed environment variable, the flat environment variable, the
+// configuration file and the defaults.
+func bindKeys() {
+	for _, k := range keys {
+		viper.RegisterAlias(k.legacy, k.nested)
+		_ = viper.BindEnv(k.nested, prefixedEnv(k.nested), k.legacy)
+	}
+}
+
+// legacyEnv returns the flat environment variables set without their prefixed
+// replacement, to be reported as deprecated.
+func legacyEnv() []string {
+	var deprecated []string
+	for _, k := range keys {
+		if _, ok := os.LookupEnv(k.legacy); !ok {
+			continue
+		}
+		if _, ok := os.LookupEnv(prefixedEnv(k.nested)); !ok {
+			deprecated = append(deprecated, k.legacy)
+		}
+	}
+	return deprecated
+}
+
+// readConfigFile reads the file set in CONFIG_FILE, which must exist, or else
+// the optional .env file. Settings in .env use their flat names and are
+// stored under their nested keys.
+func readConfigFile() error {
+	file := os.Getenv(prefixedEnv(configFileEnv))
+	if file == "" {
+		file = os.Getenv(configFileEnv)
+	}
+	if file != "" {
+		viper.SetConfigFile(file)
+		return viper.ReadInConfig()
+	}
+
+	dotenv := viper.New()
+	dotenv.SetConfigFile(".env")
+	if err := dotenv.ReadInConfig(); err != nil {
+		return nil
+	}
+
+	nested := make(map[string]string, len(keys))
+	for _, k := range keys {
+		nested[strings.ToLower(k.legacy)] = k.nested
+	}
+	settings := make(map[string]interface{})
+	for _, key := range dotenv.AllKeys() {
+		if path, ok := nested[key]; ok {
+			setPath(settings, strings.Split(path, "."), dotenv.Get(key))
+		}
+	}
+	return viper.MergeConfigMap(settings)
+}
+
+// setPath stores value under the nested path of settings.
+func setPath(settings map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := settings[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			settings[key] = child
+		}
+		settings = child
+	}
+	settings[path[len(path)-1]] = value
+}
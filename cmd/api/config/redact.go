@@ -0,0 +1,61 @@
+package config
+
+import "net/url"
+
+// redacted replaces secrets in the configuration dump.
+const redacted = "REDACTED"
+
+// Redacted returns a copy of the configuration with credentials and API keys
+// masked, safe to log.
+func (c Config) Redacted() Config {
+	c.MongoDB.URI = redactURI(c.MongoDB.URI)
+	c.Redis.Password = redactSecret(c.Redis.Password)
+	c.Catalog.APIKey = redactSecret(c.Catalog.APIKey)
+	c.Inventory.APIKey = redactSecret(c.Inventory.APIKey)
+	c.App.AuthTokenSecret = redactSecret(c.App.AuthTokenSecret)
+
+	c.App.AdminAPIKeys = redactList(c.App.AdminAPIKeys)
+	c.App.AdminPIIAPIKeys = redactList(c.App.AdminPIIAPIKeys)
+	if c.App.ClientAPIKeys != nil {
+		keys := make(map[string]string, len(c.App.ClientAPIKeys))
+		for client := range c.App.ClientAPIKeys {
+			keys[client] = redacted
+		}
+		c.App.ClientAPIKeys = keys
+	}
+	return c
+}
+
+func redactList(secrets []string) []string {
+	if secrets == nil {
+		return nil
+	}
+	masked := make([]string, len(secrets))
+	for i := range masked {
+		masked[i] = redacted
+	}
+	return masked
+}
+
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactURI masks the password of a URI. URIs that cannot be parsed are
+// masked entirely, as they may still hold credentials.
+func redactURI(uri string) string {
+	if uri == "" {
+		return ""
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return redacted
+	}
+	if _, ok := parsed.User.Password(); ok {
+		parsed.User = url.UserPassword(parsed.User.Username(), redacted)
+	}
+	return parsed.String()
+}
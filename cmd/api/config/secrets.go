@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// secretKeys are the settings holding credentials. Each of them can instead
+// be read from the file named by <KEY>_FILE, the way Docker and Kubernetes
+// mount secrets.
+var secretKeys = []string{
+	"MONGODB_URI",
+	"REDIS_PASSWORD",
+	"CATALOG_API_KEY",
+	"INVENTORY_API_KEY",
+	"ADMIN_API_KEYS",
+	"ADMIN_PII_API_KEYS",
+	"CLIENT_API_KEYS",
+	"AUTH_TOKEN_SECRET",
+}
+
+// ReadSecretFile returns the contents of a secret file, without the
+// surrounding whitespace editors and templates tend to leave.
+func ReadSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readSecretFiles replaces the secrets whose <KEY>_FILE is set with the
+// contents of that file. The file takes precedence over the configuration
+// file, but setting both the environment variable and its file is an error.
+func readSecretFiles() error {
+	for _, key := range secretKeys {
+		file := viper.GetString(key + "_FILE")
+		if file == "" {
+			continue
+		}
+		if envSet(key) {
+			return fmt.Errorf("%s and %s_FILE must not both be set", key, key)
+		}
+		value, err := ReadSecretFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s_FILE: %w", key, err)
+		}
+		viper.Set(key, value)
+	}
+	return nil
+}
+
+// envSet reports whether a setting is set in the environment, by its flat or
+// its prefixed name.
+func envSet(legacy string) bool {
+	if os.Getenv(legacy) != "" {
+		return true
+	}
+	for _, k := range keys {
+		if k.legacy == legacy {
+			return os.Getenv(prefixedEnv(k.nested)) != ""
+		}
+	}
+	return false
+}
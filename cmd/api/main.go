@@ -1,16 +1,15 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"orders/cmd/api/config"
 	"orders/cmd/api/server"
+	"orders/internal/middlewares"
 	"orders/pkg/logger"
 
 	"go.uber.org/zap"
@@ -30,7 +29,7 @@ func main() {
 	}
 
 	// Initialize logger
-	if err := logger.Init(cfg.Logging.Level, cfg.Logging.Format); err != nil {
+	if err := logger.Init(cfg.Logging.Level, cfg.Logging.Format, logger.Options{Color: cfg.LogColor(), Caller: cfg.LogCaller()}); err != nil {
 		fmt.Printf("Failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
@@ -41,29 +40,63 @@ func main() {
 		zap.String("environment", cfg.Server.Environment),
 		zap.String("port", cfg.Server.Port),
 	)
+	log.Info("Effective configuration", zap.Reflect("config", cfg.Redacted()))
+	if len(cfg.LegacyEnv) > 0 {
+		log.Warn("Deprecated environment variables in use, set their ORDERS_ prefixed names instead",
+			zap.Strings("variables", cfg.LegacyEnv),
+		)
+	}
 
 	// Initialize dependencies (MongoDB, Redis, Kafka, repositories, services, handlers)
 	deps, err := server.Initialize(cfg, log)
 	if err != nil {
 		log.Fatal("Failed to initialize dependencies", zap.Error(err))
 	}
-	defer deps.Close()
+	lifecycle := server.NewLifecycle(deps, cfg.Server.Shutdown, log)
 
 	// Setup routes and middlewares
-	router := server.SetupRouter(deps, cfg)
+	router := server.SetupRouter(deps, cfg, lifecycle)
 
 	// Configure HTTP server
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%s", cfg.Server.Port),
-		Handler:      router,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
+		Addr:              fmt.Sprintf(":%s", cfg.Server.Port),
+		Handler:           middlewares.HandlerTimeout(router, cfg.Server.HandlerTimeout),
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+
+	tlsConfig, certReloader, err := server.NewTLSConfig(cfg.Server.TLS)
+	if err != nil {
+		log.Fatal("Failed to configure TLS", zap.Error(err))
+	}
+	srv.TLSConfig = tlsConfig
+
+	// Certificates renewed on disk are picked up on SIGHUP
+	if certReloader != nil {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		go server.ReloadOnSignal(certReloader, reload, log)
 	}
 
+	// A Redis password rotated in its secret file is also picked up on SIGHUP
+	if deps.RedisCredentials != nil {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		go server.ReloadRedisPasswordOnSignal(deps.RedisCredentials, reload, log)
+	}
+
+	// Dynamic settings are reloaded on SIGHUP as well
+	reloadConfig := make(chan os.Signal, 1)
+	signal.Notify(reloadConfig, syscall.SIGHUP)
+	go server.ReloadConfigOnSignal(deps.ConfigReloader, reloadConfig, log)
+
 	// Start server in a separate goroutine
 	go func() {
-		log.Info("Server starting", zap.String("address", srv.Addr))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Info("Server starting", zap.String("address", srv.Addr), zap.Bool("tls", tlsConfig != nil))
+		if err := server.ListenAndServe(srv); err != nil && err != http.ErrServerClosed {
 			log.Fatal("Failed to start server", zap.Error(err))
 		}
 	}()
@@ -74,12 +107,7 @@ func main() {
 	<-quit
 
 	log.Info("Shutting down server...")
-	ctxShutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(ctxShutdown); err != nil {
-		log.Error("Server forced to shutdown", zap.Error(err))
-	}
+	lifecycle.Shutdown(srv)
 
 	log.Info("Server stopped")
 }
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"orders/cmd/api/config"
+	"orders/internal/handlers"
+	"orders/internal/messages/kafka"
+	"orders/internal/repositories/mongodb"
+	redisrepo "orders/internal/repositories/redis"
+)
+
+// Dependencies are probed for diagnostics for at most diagnosticsTimeout
+// each, and at most once per diagnosticsTTL.
+const (
+	diagnosticsTimeout = 2 * time.Second
+	diagnosticsTTL     = time.Minute
+)
+
+// newDiagnosticsHandler describes the dependencies the service is connected
+// to. Redis is left out when the cache is disabled, and Kafka when no
+// brokers are configured.
+func newDiagnosticsHandler(deps *Dependencies, cfg *config.Config) *handlers.DiagnosticsHandler {
+	redacted := cfg.Redacted()
+	settings := handlers.DiagnosticsSettings{
+		MongoURI:         redacted.MongoDB.URI,
+		MongoMaxPoolSize: cfg.MongoDB.MaxPoolSize,
+		MongoMinPoolSize: cfg.MongoDB.MinPoolSize,
+		KafkaBrokers:     cfg.Kafka.Brokers,
+	}
+
+	var probes []handlers.DiagnosticsProbe
+	if deps.MongoClient != nil {
+		probes = append(probes, handlers.DiagnosticsProbe{Name: "mongodb", Describe: func(ctx context.Context) (interface{}, error) {
+			return mongodb.Describe(ctx, deps.MongoClient)
+		}})
+	}
+	if deps.RedisClient != nil {
+		settings.RedisAddr = cfg.Redis.URL
+		settings.RedisPoolSize = cfg.Redis.PoolSize
+		probes = append(probes, handlers.DiagnosticsProbe{Name: "redis", Describe: func(ctx context.Context) (interface{}, error) {
+			return redisrepo.Describe(ctx, deps.RedisClient)
+		}})
+	}
+	if len(cfg.Kafka.Brokers) > 0 {
+		brokers := cfg.Kafka.Brokers
+		probes = append(probes, handlers.DiagnosticsProbe{Name: "kafka", Describe: func(ctx context.Context) (interface{}, error) {
+			return kafka.DescribeBrokers(ctx, brokers)
+		}})
+	}
+	return handlers.NewDiagnosticsHandler(probes, settings, deps.Features, diagnosticsTimeout, diagnosticsTTL, nil)
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"orders/internal/repositories/mongodb"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// indexedRepository is a MongoDB repository whose queries rely on indexes.
+type indexedRepository interface {
+	Indexes() []mongo.IndexModel
+	CreateIndexes(ctx context.Context) error
+	EnsureIndexes(ctx context.Context) (mongodb.IndexReport, error)
+}
+
+// setupIndexes creates the indexes of the repositories and checks that they
+// all exist. Without them every list query scans the whole collection, so
+// when required a failure stops the startup; otherwise it is only logged.
+func setupIndexes(ctx context.Context, repos []indexedRepository, required bool, log *zap.Logger) error {
+	for _, repo := range repos {
+		if err := repo.CreateIndexes(ctx); err != nil {
+			if required {
+				return fmt.Errorf("failed to create MongoDB indexes: %w", err)
+			}
+			log.Warn("Failed to create MongoDB indexes", zap.Error(err))
+		}
+
+		report, err := repo.EnsureIndexes(ctx)
+		if err != nil {
+			if required {
+				return fmt.Errorf("failed to list MongoDB indexes of %s: %w", report.Collection, err)
+			}
+			log.Warn("Failed to list MongoDB indexes", zap.String("collection", report.Collection), zap.Error(err))
+			continue
+		}
+		if len(report.Missing) > 0 {
+			if required {
+				return fmt.Errorf("missing MongoDB indexes on %s: %s", report.Collection, strings.Join(report.Missing, ", "))
+			}
+			log.Warn("Missing MongoDB indexes, list queries will scan the collection",
+				zap.String("collection", report.Collection),
+				zap.Strings("missing", report.Missing),
+			)
+		}
+
+		missing := make(map[string]bool, len(report.Missing))
+		for _, name := range report.Missing {
+			missing[name] = true
+		}
+		for _, index := range repo.Indexes() {
+			if name := mongodb.IndexName(index); !missing[name] {
+				log.Info("MongoDB index ready",
+					zap.String("collection", report.Collection),
+					zap.String("index", name),
+					zap.String("keys", mongodb.IndexKeys(index)),
+				)
+			}
+		}
+	}
+	return nil
+}
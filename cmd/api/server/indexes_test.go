@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"orders/internal/repositories/mongodb"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeIndexedRepository simula un repositorio con un índice por estado
+type fakeIndexedRepository struct {
+	createErr error
+	missing   []string
+}
+
+func (r *fakeIndexedRepository) Indexes() []mongo.IndexModel {
+	return []mongo.IndexModel{{Keys: bson.D{{Key: "status", Value: 1}}}}
+}
+
+func (r *fakeIndexedRepository) CreateIndexes(context.Context) error {
+	return r.createErr
+}
+
+func (r *fakeIndexedRepository) EnsureIndexes(context.Context) (mongodb.IndexReport, error) {
+	return mongodb.IndexReport{Collection: "orders", Expected: 1, Missing: r.missing}, nil
+}
+
+func TestSetupIndexes(t *testing.T) {
+	denied := &fakeIndexedRepository{createErr: errors.New("not authorized"), missing: []string{"status_1"}}
+
+	t.Run("Required fails the startup", func(t *testing.T) {
+		err := setupIndexes(context.Background(), []indexedRepository{denied}, true, zap.NewNop())
+		assert.ErrorContains(t, err, "failed to create MongoDB indexes: not authorized")
+
+		err = setupIndexes(context.Background(), []indexedRepository{&fakeIndexedRepository{missing: []string{"status_1"}}}, true, zap.NewNop())
+		assert.EqualError(t, err, "missing MongoDB indexes on orders: status_1")
+	})
+
+	t.Run("Not required only warns", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+
+		err := setupIndexes(context.Background(), []indexedRepository{denied}, false, zap.New(core))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, logs.FilterMessage("Failed to create MongoDB indexes").Len())
+		assert.Equal(t, 1, logs.FilterMessage("Missing MongoDB indexes, list queries will scan the collection").Len())
+	})
+
+	t.Run("Logs every index with its keys", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+
+		err := setupIndexes(context.Background(), []indexedRepository{&fakeIndexedRepository{}}, true, zap.New(core))
+
+		assert.NoError(t, err)
+		ready := logs.FilterMessage("MongoDB index ready").All()
+		if assert.Len(t, ready, 1) {
+			assert.Equal(t, "status_1", ready[0].ContextMap()["index"])
+			assert.Equal(t, "{status: 1}", ready[0].ContextMap()["keys"])
+		}
+	})
+}
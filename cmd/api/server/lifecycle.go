@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"orders/cmd/api/config"
+
+	"go.uber.org/zap"
+)
+
+// httpServer is the part of http.Server the lifecycle shuts down.
+type httpServer interface {
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
+type worker struct {
+	name string
+	stop func()
+}
+
+type store struct {
+	name  string
+	close func(ctx context.Context) error
+}
+
+// Lifecycle shuts the service down in order: it reports not ready, stops
+// accepting connections and waits for in-flight requests, stops the
+// background workers, flushes the Kafka producer and finally closes Redis and
+// MongoDB. Every phase has its own deadline; a phase that misses it is logged
+// and the shutdown moves on.
+type Lifecycle struct {
+	ready    atomic.Bool
+	workers  []worker
+	producer io.Closer
+	stores   []store
+	timeouts config.ShutdownConfig
+	logger   *zap.Logger
+}
+
+// NewLifecycle creates the lifecycle of the given dependencies. The service
+// is reported ready until Shutdown is called.
+func NewLifecycle(deps *Dependencies, timeouts config.ShutdownConfig, logger *zap.Logger) *Lifecycle {
+	l := &Lifecycle{
+		timeouts: timeouts,
+		logger:   logger,
+	}
+	l.ready.Store(true)
+
+	// Workers that change orders stop first
+	if deps.Deliveries != nil {
+		l.workers = append(l.workers, worker{"delivery consumer", deps.Deliveries.Stop})
+	}
+	if deps.SLASweeper != nil {
+		l.workers = append(l.workers, worker{"SLA sweeper", deps.SLASweeper.Stop})
+	}
+	if deps.InventoryReleases != nil {
+		l.workers = append(l.workers, worker{"inventory release retrier", deps.InventoryReleases.Stop})
+	}
+	if deps.Notifications != nil {
+		l.workers = append(l.workers, worker{"notification retrier", deps.Notifications.Stop})
+	}
+	if deps.Reconciler != nil {
+		l.workers = append(l.workers, worker{"cache reconciler", deps.Reconciler.Stop})
+	}
+	if deps.CacheRetrier != nil {
+		l.workers = append(l.workers, worker{"cache write retrier", deps.CacheRetrier.Stop})
+	}
+	if deps.Summaries != nil {
+		l.workers = append(l.workers, worker{"summary consumer", deps.Summaries.Stop})
+	}
+	if deps.EventReplayer != nil {
+		l.workers = append(l.workers, worker{"event replayer", deps.EventReplayer.Stop})
+	}
+	if deps.Health != nil {
+		l.workers = append(l.workers, worker{"health monitor", deps.Health.Stop})
+	}
+
+	if deps.KafkaProducer != nil {
+		l.producer = deps.KafkaProducer
+	}
+
+	if deps.RedisClient != nil {
+		redisClient := deps.RedisClient
+		l.stores = append(l.stores, store{"redis", func(context.Context) error { return redisClient.Close() }})
+	}
+	if deps.MongoClient != nil {
+		l.stores = append(l.stores, store{"mongodb", deps.MongoClient.Disconnect})
+	}
+
+	return l
+}
+
+// Ready reports whether the service accepts traffic.
+func (l *Lifecycle) Ready() bool {
+	return l.ready.Load()
+}
+
+// Shutdown runs every phase of the shutdown, returning once all of them
+// completed or missed their deadline.
+func (l *Lifecycle) Shutdown(srv httpServer) {
+	l.ready.Store(false)
+	if l.timeouts.ReadinessDelay > 0 {
+		l.logger.Info("Reporting not ready before draining", zap.Duration("delay", l.timeouts.ReadinessDelay))
+		time.Sleep(l.timeouts.ReadinessDelay)
+	}
+
+	l.logger.Info("Draining HTTP server")
+	l.within("http server", l.timeouts.DrainTimeout, func(ctx context.Context) error {
+		if err := srv.Shutdown(ctx); err != nil {
+			// Requests still running are cut off
+			_ = srv.Close()
+			return err
+		}
+		return nil
+	})
+
+	for _, w := range l.workers {
+		l.within(w.name, l.timeouts.WorkersTimeout, func(context.Context) error {
+			w.stop()
+			return nil
+		})
+	}
+
+	if l.producer != nil {
+		l.within("kafka producer", l.timeouts.ProducerTimeout, func(context.Context) error {
+			return l.producer.Close()
+		})
+	}
+
+	for _, s := range l.stores {
+		l.within(s.name, l.timeouts.StoresTimeout, s.close)
+	}
+
+	l.logger.Info("Shutdown complete")
+}
+
+// within runs a shutdown step, giving up on it once the timeout elapses. The
+// step is given a context bounded by the same timeout.
+func (l *Lifecycle) within(name string, timeout time.Duration, step func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- step(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			l.logger.Error("Failed to stop component", zap.String("component", name), zap.Error(err))
+			return
+		}
+		l.logger.Info("Component stopped", zap.String("component", name))
+	case <-ctx.Done():
+		l.logger.Error("Component did not stop in time", zap.String("component", name), zap.Duration("timeout", timeout))
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"orders/cmd/api/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// steps registra el orden en que termina cada fase
+type steps struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (s *steps) add(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.names = append(s.names, name)
+}
+
+func (s *steps) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.names...)
+}
+
+func TestLifecycle_DrainsRequestsBeforeClosingStores(t *testing.T) {
+	var done steps
+	started := make(chan struct{})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte("done"))
+		done.add("request")
+	})}
+	go func() { _ = srv.Serve(listener) }()
+
+	lifecycle := NewLifecycle(&Dependencies{}, config.ShutdownConfig{
+		DrainTimeout:    2 * time.Second,
+		WorkersTimeout:  time.Second,
+		ProducerTimeout: time.Second,
+		StoresTimeout:   time.Second,
+	}, zap.NewNop())
+	lifecycle.workers = append(lifecycle.workers, worker{"worker", func() { done.add("worker") }})
+	lifecycle.stores = append(lifecycle.stores, store{"mongodb", func(context.Context) error {
+		done.add("mongodb")
+		return nil
+	}})
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	assert.True(t, lifecycle.Ready())
+	lifecycle.Shutdown(srv)
+
+	assert.False(t, lifecycle.Ready())
+	response := <-responses
+	require.NoError(t, response.err)
+	assert.Equal(t, "done", response.body)
+	assert.Equal(t, []string{"request", "worker", "mongodb"}, done.list())
+}
+
+func TestLifecycle_MovesOnWhenAPhaseTimesOut(t *testing.T) {
+	var done steps
+	lifecycle := NewLifecycle(&Dependencies{}, config.ShutdownConfig{
+		DrainTimeout:   time.Second,
+		WorkersTimeout: 50 * time.Millisecond,
+		StoresTimeout:  time.Second,
+	}, zap.NewNop())
+	stuck := make(chan struct{})
+	defer close(stuck)
+	lifecycle.workers = append(lifecycle.workers, worker{"stuck worker", func() { <-stuck }})
+	lifecycle.stores = append(lifecycle.stores, store{"mongodb", func(context.Context) error {
+		done.add("mongodb")
+		return nil
+	}})
+
+	lifecycle.Shutdown(&http.Server{})
+
+	assert.Equal(t, []string{"mongodb"}, done.list())
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"os"
+	"sync"
+
+	"orders/cmd/api/config"
+
+	"go.uber.org/zap"
+)
+
+// ConfigReloader loads the configuration again and applies the settings that
+// can change while the service runs. Any other change is only reported, as
+// it takes a restart to apply.
+type ConfigReloader struct {
+	mu       sync.Mutex
+	started  *config.Config
+	current  *config.Config
+	load     func() (*config.Config, error)
+	settings map[string]func(cfg *config.Config)
+	logger   *zap.Logger
+}
+
+// NewConfigReloader creates a reloader for the configuration the service
+// started with, loading new ones with load.
+func NewConfigReloader(started *config.Config, load func() (*config.Config, error), logger *zap.Logger) *ConfigReloader {
+	return &ConfigReloader{
+		started:  started,
+		current:  started,
+		load:     load,
+		settings: make(map[string]func(cfg *config.Config)),
+		logger:   logger,
+	}
+}
+
+// Register makes a setting, named by its field path as reported by
+// config.Diff, reloadable by calling apply with the new configuration.
+func (r *ConfigReloader) Register(apply func(cfg *config.Config), settings ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, setting := range settings {
+		r.settings[setting] = apply
+	}
+}
+
+// Reload loads the configuration and applies the reloadable settings changed
+// since the last reload. It also returns the settings changed since startup
+// that still need a restart. An invalid configuration is not applied at all.
+func (r *ConfigReloader) Reload() (applied, requiresRestart []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	updated, err := r.load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, setting := range config.Diff(r.current, updated) {
+		if apply, ok := r.settings[setting]; ok {
+			apply(updated)
+			applied = append(applied, setting)
+		}
+	}
+	for _, setting := range config.Diff(r.started, updated) {
+		if _, ok := r.settings[setting]; !ok {
+			requiresRestart = append(requiresRestart, setting)
+		}
+	}
+	r.current = updated
+
+	if len(applied) > 0 {
+		r.logger.Info("Configuration reloaded", zap.Strings("settings", applied))
+	}
+	if len(requiresRestart) > 0 {
+		r.logger.Warn("Configuration changes require restart", zap.Strings("settings", requiresRestart))
+	}
+	return applied, requiresRestart, nil
+}
+
+// ReloadConfigOnSignal reloads the configuration every time a signal is
+// received, until signals is closed.
+func ReloadConfigOnSignal(reloader *ConfigReloader, signals <-chan os.Signal, logger *zap.Logger) {
+	for range signals {
+		if _, _, err := reloader.Reload(); err != nil {
+			logger.Error("Failed to reload configuration, keeping the current one", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"orders/cmd/api/config"
+	"orders/internal/models"
+	redisrepo "orders/internal/repositories/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stagedConfig devuelve en cada recarga la configuración indicada
+type stagedConfig struct {
+	next *config.Config
+	err  error
+}
+
+func (s *stagedConfig) load() (*config.Config, error) {
+	return s.next, s.err
+}
+
+func TestConfigReloader_AppliesTTLWithoutReconnecting(t *testing.T) {
+	mr := miniredis.RunT(t)
+	started := &config.Config{
+		Server: config.ServerConfig{Port: "3000"},
+		Redis:  config.RedisConfig{URL: mr.Addr(), DefaultTTL: time.Minute},
+	}
+	client := ConnectRedis(started.Redis, nil)
+	defer client.Close()
+	cache := redisrepo.NewCacheRepository(client, started.Redis.DefaultTTL)
+	ctx := context.Background()
+
+	require.Nil(t, cache.SetOrder(ctx, &models.Order{ID: "order-1"}))
+	assert.Equal(t, time.Minute, mr.TTL("order:order-1"))
+	connections := mr.TotalConnectionCount()
+
+	staged := &stagedConfig{}
+	reloader := NewConfigReloader(started, staged.load, zap.NewNop())
+	reloader.Register(func(cfg *config.Config) { cache.SetDefaultTTL(cfg.Redis.DefaultTTL) }, "Redis.DefaultTTL")
+
+	updated := *started
+	updated.Redis.DefaultTTL = 5 * time.Minute
+	updated.Server.Port = "4000"
+	staged.next = &updated
+
+	applied, requiresRestart, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Redis.DefaultTTL"}, applied)
+	assert.Equal(t, []string{"Server.Port"}, requiresRestart)
+
+	// La nueva TTL se aplica con la misma conexión a Redis
+	require.Nil(t, cache.SetOrder(ctx, &models.Order{ID: "order-2"}))
+	assert.Equal(t, 5*time.Minute, mr.TTL("order:order-2"))
+	assert.Equal(t, connections, mr.TotalConnectionCount())
+
+	// Sin cambios nuevos no se aplica nada, pero el puerto sigue pendiente de reinicio
+	applied, requiresRestart, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.Equal(t, []string{"Server.Port"}, requiresRestart)
+}
+
+func TestConfigReloader_KeepsConfigurationOnError(t *testing.T) {
+	started := &config.Config{Redis: config.RedisConfig{DefaultTTL: time.Minute}}
+	staged := &stagedConfig{err: errors.New("invalid configuration: REDIS_URL is required when CACHE_ENABLED is true")}
+	reloader := NewConfigReloader(started, staged.load, zap.NewNop())
+
+	ttl := started.Redis.DefaultTTL
+	reloader.Register(func(cfg *config.Config) { ttl = cfg.Redis.DefaultTTL }, "Redis.DefaultTTL")
+
+	_, _, err := reloader.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	// Una recarga válida posterior compara con la configuración vigente
+	updated := *started
+	updated.Redis.DefaultTTL = time.Hour
+	staged.next, staged.err = &updated, nil
+	applied, _, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Redis.DefaultTTL"}, applied)
+	assert.Equal(t, time.Hour, ttl)
+}
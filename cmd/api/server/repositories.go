@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"orders/cmd/api/config"
+	"orders/internal/repositories/mongodb"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -11,16 +12,11 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-func ConnectMongoDB(cfg config.MongoDBConfig) (*mongo.Client, error) {
+func ConnectMongoDB(cfg config.MongoDBConfig, pool *mongodb.PoolMonitor) (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectionTimeout)
 	defer cancel()
 
-	clientOptions := options.Client().
-		ApplyURI(cfg.URI).
-		SetMaxPoolSize(cfg.MaxPoolSize).
-		SetConnectTimeout(cfg.ConnectionTimeout)
-
-	client, err := mongo.Connect(ctx, clientOptions)
+	client, err := mongo.Connect(ctx, mongoClientOptions(cfg, pool))
 	if err != nil {
 		return nil, err
 	}
@@ -31,11 +27,29 @@ func ConnectMongoDB(cfg config.MongoDBConfig) (*mongo.Client, error) {
 	return client, nil
 }
 
-func ConnectRedis(cfg config.RedisConfig) *redis.Client {
-	return redis.NewClient(&redis.Options{
+// mongoClientOptions sizes the connection pool and reports its events to pool.
+func mongoClientOptions(cfg config.MongoDBConfig, pool *mongodb.PoolMonitor) *options.ClientOptions {
+	return options.Client().
+		ApplyURI(cfg.URI).
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetMinPoolSize(cfg.MinPoolSize).
+		SetMaxConnIdleTime(cfg.MaxConnIdleTime).
+		SetConnectTimeout(cfg.ConnectionTimeout).
+		SetServerSelectionTimeout(cfg.ServerSelectionTimeout).
+		SetPoolMonitor(pool.Monitor())
+}
+
+// ConnectRedis creates the Redis client. With credentials, new connections
+// authenticate with their current password instead of the configured one.
+func ConnectRedis(cfg config.RedisConfig, credentials *RedisCredentials) *redis.Client {
+	redisOptions := &redis.Options{
 		Addr:     cfg.URL,
 		Password: cfg.Password,
 		DB:       cfg.DB,
 		PoolSize: cfg.PoolSize,
-	})
+	}
+	if credentials != nil {
+		redisOptions.CredentialsProvider = credentials.Credentials
+	}
+	return redis.NewClient(redisOptions)
 }
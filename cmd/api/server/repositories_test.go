@@ -0,0 +1,31 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"orders/cmd/api/config"
+	"orders/internal/repositories/mongodb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMongoClientOptions(t *testing.T) {
+	cfg := config.MongoDBConfig{
+		URI:                    "mongodb://localhost:27017",
+		ConnectionTimeout:      10 * time.Second,
+		ServerSelectionTimeout: 5 * time.Second,
+		MaxPoolSize:            50,
+		MinPoolSize:            5,
+		MaxConnIdleTime:        2 * time.Minute,
+	}
+
+	clientOptions := mongoClientOptions(cfg, mongodb.NewPoolMonitor())
+
+	assert.Equal(t, uint64(50), *clientOptions.MaxPoolSize)
+	assert.Equal(t, uint64(5), *clientOptions.MinPoolSize)
+	assert.Equal(t, 2*time.Minute, *clientOptions.MaxConnIdleTime)
+	assert.Equal(t, 10*time.Second, *clientOptions.ConnectTimeout)
+	assert.Equal(t, 5*time.Second, *clientOptions.ServerSelectionTimeout)
+	assert.NotNil(t, clientOptions.PoolMonitor)
+}
@@ -1,9 +1,16 @@
 package server
 
 import (
+	"maps"
+	"regexp"
+	"slices"
+
 	"orders/cmd/api/config"
 	"orders/internal/handlers"
+	"orders/internal/metrics"
 	"orders/internal/middlewares"
+	"orders/internal/models"
+	"orders/internal/schemas"
 	"orders/pkg/logger"
 
 	_ "orders/cmd/api/docs"
@@ -15,37 +22,134 @@ import (
 
 // SetupRouter initializes the Gin router, applies global middlewares,
 // and registers all API routes.
-func SetupRouter(deps *Dependencies, cfg *config.Config) *gin.Engine {
+func SetupRouter(deps *Dependencies, cfg *config.Config, lifecycle *Lifecycle) *gin.Engine {
+	// The mode comes from the configuration rather than GIN_MODE being read
+	// by gin itself, so production is validated to run in release mode
+	gin.SetMode(cfg.Server.GinMode)
 	router := gin.New()
 	log := logger.Get()
 
 	// Global middlewares
 	router.Use(
 		gin.Recovery(),
-		middlewares.RequestID(),
+		middlewares.RequestID(middlewares.RequestIDFormat{
+			MaxLength: cfg.App.RequestIDMaxLength,
+			Pattern:   regexp.MustCompile(cfg.App.RequestIDPattern),
+		}),
 		middlewares.Security(),
 		middlewares.CORS(),
 		middlewares.Logger(log),
 		middlewares.ErrorHandler(log),
 	)
+	switch cfg.Server.ServerTiming {
+	case config.ServerTimingDebug:
+		router.Use(middlewares.ServerTiming(false))
+	case config.ServerTimingAlways:
+		router.Use(middlewares.ServerTiming(true))
+	}
 
 	// Handlers initialization
-	orderHandler := handlers.NewOrderHandler(deps.OrderService, log, cfg.App.DefaultPageSize, cfg.App.MaxPageSize)
-	healthHandler := handlers.NewHealthHandler(deps.MongoDB, deps.RedisClient)
+	customerIDFormat := models.CustomerIDFormat(cfg.Customers.IDFormat)
+	handlers.SetCustomerIDFormat(customerIDFormat)
+	orderHandler := handlers.NewOrderHandler(deps.OrderService, log, cfg.App.DefaultPageSize, cfg.App.MaxPageSize, cfg.App.MaxScanWindow, cfg.App.IDField)
+	orderHandler.SetStreamThreshold(cfg.App.StreamThreshold)
+	orderHandler.SetCurrency(cfg.App.Currency)
+	orderHandler.SetReturnWindow(cfg.App.ReturnWindow)
+	healthHandler := handlers.NewHealthHandler(deps.Health, deps.MongoPool, lifecycle.Ready)
+	healthHandler.SetIndexCheckers(deps.Indexes...)
+	if deps.InventoryReleases != nil && cfg.Inventory.ReleaseStaleAfter > 0 {
+		healthHandler.SetReleaseRetrier(deps.InventoryReleases, cfg.Inventory.ReleaseStaleAfter)
+	}
+	featureHandler := handlers.NewFeatureHandler(deps.Features)
+	configHandler := handlers.NewConfigHandler(deps.ConfigReloader, log)
+	diagnosticsHandler := newDiagnosticsHandler(deps, cfg)
+	var replayer handlers.EventReplayer
+	if deps.EventReplayer != nil {
+		replayer = deps.EventReplayer
+	}
+	replayHandler := handlers.NewReplayHandler(replayer, log)
+	var usage handlers.UsageReader
+	if deps.Usage != nil {
+		usage = deps.Usage
+	}
+	usageHandler := handlers.NewUsageHandler(usage, slices.Collect(maps.Keys(cfg.App.ClientAPIKeys)), log)
+	deps.ConfigReloader.Register(func(cfg *config.Config) {
+		orderHandler.SetPageLimits(cfg.App.DefaultPageSize, cfg.App.MaxPageSize, cfg.App.MaxScanWindow)
+	}, "App.DefaultPageSize", "App.MaxPageSize", "App.MaxScanWindow")
+
+	// Schema validation is opt-in per route
+	createOrder := []gin.HandlerFunc{orderHandler.CreateOrder}
+	if cfg.Features.SchemaValidation {
+		createOrder = append([]gin.HandlerFunc{middlewares.ValidateJSONSchema(schemas.MustCompile(schemas.CreateOrder, schemas.WithCustomerIDFormat(customerIDFormat)))}, createOrder...)
+	}
 
 	// Routes definition
 	router.GET("/health", healthHandler.CheckHealth)
+	router.GET("/ready", healthHandler.CheckReadiness)
+	router.GET("/metrics", metrics.Handler())
 
-	api := router.Group("/api")
+	api := router.Group("/api", middlewares.IdentifyClient(cfg.App.ClientAPIKeys), middlewares.IdentifyAdmin(cfg.App.AdminAPIKeys), middlewares.Authenticate([]byte(cfg.App.AuthTokenSecret)), middlewares.RequireContentType(cfg.App.ContentTypes))
+	if deps.Usage != nil {
+		api.Use(middlewares.EnforceQuota(deps.Usage, clientQuotas(cfg.Quotas), log))
+	}
 	{
-		api.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+		// The API surface is not published where Swagger is disabled
+		if cfg.Server.EnableSwagger {
+			api.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+		}
+
+		// Every order route acts for the tenant of the request
+		tenantScope := middlewares.Tenant(cfg.Tenancy.Enabled, cfg.Tenancy.Tenants, cfg.Tenancy.DefaultTenant)
+		orders := api.Group("/orders", tenantScope)
+		orders.GET("", orderHandler.ListOrders)
+		orders.POST("", createOrder...)
+		orders.GET("/statuses", orderHandler.GetOrderStatuses)
+		orders.GET("/:id", orderHandler.GetOrder)
+		orders.GET("/:id/summary", orderHandler.GetOrderSummary)
+		orders.GET("/:id/transitions", orderHandler.GetOrderTransitions)
+		orders.PUT("/:id", orderHandler.UpdateOrderStatus)
+		orders.PATCH("/:id/priority", orderHandler.UpdateOrderPriority)
+		orders.PUT("/:id/tags", orderHandler.UpdateOrderTags)
+		orders.POST("/:id/deliveries", orderHandler.RecordDelivery)
+		orders.POST("/:id/return", orderHandler.ReturnOrder)
+		orders.GET("/:id/notes", orderHandler.ListOrderNotes)
+		orders.POST("/:id/notes", orderHandler.AddOrderNote)
+		orders.POST("/:id/events:action", middlewares.RequireAdmin(cfg.App.AdminAPIKeys), orderHandler.OrderEventsAction)
 
-		api.GET("/orders", orderHandler.ListOrders)
-		api.POST("/orders", orderHandler.CreateOrder)
-		api.GET("/orders/:id", orderHandler.GetOrder)
-		api.PUT("/orders/:id", orderHandler.UpdateOrderStatus)
+		api.GET("/stats", middlewares.RequireAdmin(cfg.App.AdminAPIKeys), tenantScope, orderHandler.GetOrderStats)
+
+		admin := api.Group("/admin", middlewares.RequireAdmin(cfg.App.AdminAPIKeys), middlewares.GrantScope(middlewares.ScopePIIRead, cfg.App.AdminPIIAPIKeys))
+		admin.GET("/features", featureHandler.ListFeatures)
+		admin.PUT("/features/:name", featureHandler.SetFeature)
+		admin.POST("/config/reload", configHandler.ReloadConfig)
+		admin.GET("/diagnostics", diagnosticsHandler.GetDiagnostics)
+		admin.POST("/orders/:id/force-status", orderHandler.ForceOrderStatus)
+		admin.GET("/events", orderHandler.ListEvents)
+		admin.GET("/events/:eventId", orderHandler.GetEvent)
+		admin.GET("/outbox", orderHandler.ListOutbox)
+		admin.POST("/outbox/:eventId/retry", orderHandler.RetryOutboxEvent)
+		admin.POST("/events/replay-range", replayHandler.ReplayRange)
+		admin.GET("/events/replay-range/:jobId", replayHandler.GetReplayJob)
+		admin.POST("/events/replay-range/:jobId/cancel", replayHandler.CancelReplayJob)
+		admin.GET("/usage", usageHandler.GetUsage)
 
 	}
 
 	return router
 }
+
+// clientQuotas returns the daily and monthly quotas of each client
+func clientQuotas(cfg config.QuotaConfig) map[string]models.Quota {
+	quotas := make(map[string]models.Quota)
+	for client, daily := range cfg.Daily {
+		quota := quotas[client]
+		quota.Daily = daily
+		quotas[client] = quota
+	}
+	for client, monthly := range cfg.Monthly {
+		quota := quotas[client]
+		quota.Monthly = monthly
+		quotas[client] = quota
+	}
+	return quotas
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"orders/cmd/api/config"
+	"orders/internal/features"
+	"orders/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestRouter monta el router sin conexiones a MongoDB, Redis ni Kafka
+func newTestRouter(t *testing.T, cfg *config.Config) http.Handler {
+	require.NoError(t, logger.Init("error", "json", logger.Options{}))
+	deps := &Dependencies{
+		Features:       features.New(nil, nil, zap.NewNop()),
+		ConfigReloader: NewConfigReloader(cfg, nil, zap.NewNop()),
+	}
+	return SetupRouter(deps, cfg, NewLifecycle(deps, cfg.Server.Shutdown, zap.NewNop()))
+}
+
+func TestSetupRouter_Swagger(t *testing.T) {
+	tests := []struct {
+		name           string
+		enabled        bool
+		expectedStatus int
+	}{
+		{"Served when enabled", true, http.StatusOK},
+		{"Absent when disabled", false, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Server: config.ServerConfig{GinMode: "test", EnableSwagger: tt.enabled},
+				App:    config.AppConfig{DefaultPageSize: 10, MaxPageSize: 100, IDField: "orderId", RequestIDPattern: `^[A-Za-z0-9._:-]+$`, ContentTypes: []string{"application/json"}, Currency: "USD"},
+			}
+			router := newTestRouter(t, cfg)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/swagger/index.html", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"os"
+	"sync/atomic"
+
+	"orders/cmd/api/config"
+
+	"go.uber.org/zap"
+)
+
+// RedisCredentials provides the Redis password to every new connection, so
+// a password rotated in REDIS_PASSWORD_FILE is picked up without rebuilding
+// the client. Connections already open stay authenticated.
+type RedisCredentials struct {
+	file     string
+	password atomic.Pointer[string]
+}
+
+// NewRedisCredentials starts with the configured password, read again from
+// its file on Reload.
+func NewRedisCredentials(cfg config.RedisConfig) *RedisCredentials {
+	credentials := &RedisCredentials{file: cfg.PasswordFile}
+	password := cfg.Password
+	credentials.password.Store(&password)
+	return credentials
+}
+
+// Credentials returns the username and password, as a go-redis credentials
+// provider.
+func (c *RedisCredentials) Credentials() (string, string) {
+	return "", *c.password.Load()
+}
+
+// Reload reads the password file again. The current password is kept when
+// the file cannot be read.
+func (c *RedisCredentials) Reload() error {
+	password, err := config.ReadSecretFile(c.file)
+	if err != nil {
+		return err
+	}
+	c.password.Store(&password)
+	return nil
+}
+
+// ReloadRedisPasswordOnSignal reloads the Redis password every time a signal
+// is received, until signals is closed.
+func ReloadRedisPasswordOnSignal(credentials *RedisCredentials, signals <-chan os.Signal, logger *zap.Logger) {
+	for range signals {
+		if err := credentials.Reload(); err != nil {
+			logger.Error("Failed to reload the Redis password, keeping the current one", zap.Error(err))
+			continue
+		}
+		logger.Info("Redis password reloaded", zap.String("passwordFile", credentials.file))
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"orders/cmd/api/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRedisCredentials_RotatePassword(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.RequireAuth("old-password")
+
+	file := filepath.Join(t.TempDir(), "redis-password")
+	require.NoError(t, os.WriteFile(file, []byte("old-password\n"), 0o600))
+	cfg := config.RedisConfig{URL: mr.Addr(), Password: "old-password", PasswordFile: file, PoolSize: 1}
+
+	credentials := NewRedisCredentials(cfg)
+	client := ConnectRedis(cfg, credentials)
+	defer client.Close()
+	ctx := context.Background()
+	require.NoError(t, client.Ping(ctx).Err())
+
+	// Se rota la contraseña en Redis y en el fichero, y se recarga con la señal
+	mr.RequireAuth("new-password")
+	require.NoError(t, os.WriteFile(file, []byte("new-password\n"), 0o600))
+	signals := make(chan os.Signal, 1)
+	signals <- os.Interrupt
+	close(signals)
+	ReloadRedisPasswordOnSignal(credentials, signals, zap.NewNop())
+
+	_, password := credentials.Credentials()
+	assert.Equal(t, "new-password", password)
+
+	// La conexión ya abierta sigue autenticada
+	require.NoError(t, client.Ping(ctx).Err())
+
+	// Las conexiones nuevas usan la contraseña rotada
+	fresh := ConnectRedis(cfg, credentials)
+	defer fresh.Close()
+	assert.NoError(t, fresh.Ping(ctx).Err())
+
+	stale := ConnectRedis(config.RedisConfig{URL: mr.Addr(), Password: "old-password"}, nil)
+	defer stale.Close()
+	assert.Error(t, stale.Ping(ctx).Err())
+}
+
+func TestRedisCredentials_KeepsPasswordOnFailedReload(t *testing.T) {
+	credentials := NewRedisCredentials(config.RedisConfig{
+		Password:     "r3dis",
+		PasswordFile: filepath.Join(t.TempDir(), "missing"),
+	})
+
+	assert.Error(t, credentials.Reload())
+	_, password := credentials.Credentials()
+	assert.Equal(t, "r3dis", password)
+}
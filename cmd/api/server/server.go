@@ -2,85 +2,446 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"time"
 
 	"orders/cmd/api/config"
+	"orders/internal/breaker"
+	"orders/internal/clients/catalog"
+	"orders/internal/clients/customers"
+	"orders/internal/clients/inventory"
+	"orders/internal/features"
+	"orders/internal/handlers"
 	"orders/internal/messages/kafka"
+	"orders/internal/messages/memory"
+	"orders/internal/metrics"
+	"orders/internal/models"
 	"orders/internal/repositories/mongodb"
 	redisrepo "orders/internal/repositories/redis"
 	"orders/internal/services"
+	"orders/internal/workers"
+	"orders/pkg/httpclient"
+	"orders/pkg/logger"
 
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.uber.org/zap"
 )
 
 // Dependencies holds all shared resources used by the application,
 // including database connections, external clients, and services.
 type Dependencies struct {
-	MongoClient   *mongo.Client
-	MongoDB       *mongo.Database
-	RedisClient   *redis.Client
-	OrderService  services.OrderService
-	KafkaProducer *kafka.Producer
+	MongoClient *mongo.Client
+	MongoDB     *mongo.Database
+	MongoPool   *mongodb.PoolMonitor
+	// Indexes check the indexes of the MongoDB repositories on verbose readiness
+	Indexes []handlers.IndexChecker
+	// Health checks MongoDB and Redis in the background for the health endpoint
+	Health      *handlers.HealthMonitor
+	RedisClient *redis.Client
+	// RedisCredentials is set when the Redis password is read from a file
+	RedisCredentials *RedisCredentials
+	OrderService     services.OrderService
+	Features         *features.Flags
+	ConfigReloader   *ConfigReloader
+	KafkaProducer    *kafka.Producer
+	EventBus         *memory.InMemoryPublisher // set instead of KafkaProducer when it is disabled
+	Reconciler       *workers.CacheReconciler
+	CacheRetrier     *workers.CacheWriteRetrier
+	SLASweeper       *workers.SLASweeper
+	// InventoryReleases retries the inventory releases that failed, set when
+	// inventory reservations are enabled
+	InventoryReleases *workers.InventoryReleaseRetrier
+	// Notifications retries the customer notification requests that failed,
+	// set when notifications are enabled
+	Notifications *workers.NotificationRetrier
+	Deliveries    *kafka.DeliveryConsumer
+	// Summaries keeps the orders summary up to date, set when it consumes
+	// the order events
+	Summaries *kafka.SummaryConsumer
+	// EventReplayer replays the event log by time range, set when Redis is
+	// connected to hold the replay lock and jobs
+	EventReplayer *services.EventReplayer
+	// Usage counts the requests of the API clients against their quotas, set
+	// when client quotas are enabled
+	Usage *redisrepo.UsageRepository
 }
 
 // Initialize sets up and returns all core dependencies such as
 // MongoDB, Redis, Kafka, and application services.
 func Initialize(cfg *config.Config, log *zap.Logger) (*Dependencies, error) {
 	// MongoDB setup
-	mongoClient, err := ConnectMongoDB(cfg.MongoDB)
+	mongoPool := mongodb.NewPoolMonitor()
+	mongoClient, err := ConnectMongoDB(cfg.MongoDB, mongoPool)
 	if err != nil {
 		return nil, err
 	}
 	mongoDB := mongoClient.Database(cfg.MongoDB.Database)
 
 	orderRepo := mongodb.NewOrderRepository(mongoDB)
+	orderRepo.SetListMaxTime(cfg.MongoDB.ListMaxTime)
+	eventRepo := mongodb.NewEventRepository(mongoDB)
+	indexed := []indexedRepository{orderRepo, eventRepo}
+	indexes := []handlers.IndexChecker{orderRepo, eventRepo}
+	var releaseRepo *mongodb.InventoryReleaseRepository
+	if cfg.Inventory.Enabled {
+		releaseRepo = mongodb.NewInventoryReleaseRepository(mongoDB)
+		indexed = append(indexed, releaseRepo)
+		indexes = append(indexes, releaseRepo)
+	}
+	// The orders summary is only indexed once it is maintained or read
+	var summaryRepo *mongodb.OrderSummaryRepository
+	if cfg.Kafka.ConsumeSummary || cfg.Features.SummaryReads {
+		summaryRepo = mongodb.NewOrderSummaryRepository(mongoDB)
+		summaryRepo.SetListMaxTime(cfg.MongoDB.ListMaxTime)
+		indexed = append(indexed, summaryRepo)
+		indexes = append(indexes, summaryRepo)
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	_ = orderRepo.CreateIndexes(ctx) // Ignore index creation errors during initialization
-
-	// Redis setup
-	redisClient := ConnectRedis(cfg.Redis)
-	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := redisClient.Ping(ctx).Err(); err != nil {
+	if err := setupIndexes(ctx, indexed, cfg.MongoDB.RequireIndexes, log); err != nil {
 		return nil, err
 	}
 
-	// Kafka Producer setup (optional)
+	// Operations served to the application are timed
+	orders := mongodb.Instrument(orderRepo)
+	if cfg.MongoDB.BreakerEnabled {
+		orders = mongodb.WithCircuitBreaker(orders, breaker.New(cfg.MongoDB.BreakerFailureThreshold, cfg.MongoDB.BreakerOpenTimeout, metrics.MongoCircuitBreakerState))
+	}
+
+	// Orders and events stored before tenants were introduced belong to the
+	// default tenant; left without one they would be hidden from every tenant
+	if err := orderRepo.AssignTenant(ctx, cfg.Tenancy.DefaultTenant); err != nil {
+		return nil, fmt.Errorf("failed to assign orders to the default tenant: %w", err)
+	}
+	if err := eventRepo.AssignTenant(ctx, cfg.Tenancy.DefaultTenant); err != nil {
+		return nil, fmt.Errorf("failed to assign events to the default tenant: %w", err)
+	}
+
+	// Redis setup (skipped entirely when the cache is disabled)
+	var redisClient *redis.Client
+	var redisCredentials *RedisCredentials
+	if cfg.Features.Cache {
+		if cfg.Redis.PasswordFile != "" {
+			redisCredentials = NewRedisCredentials(cfg.Redis)
+		}
+		redisClient = ConnectRedis(cfg.Redis, redisCredentials)
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := redisClient.Ping(ctx).Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		log.Info("Cache disabled, Redis will not be used")
+	}
+
+	// Kafka Producer setup (optional), events stay in memory without it
 	var kafkaProducer *kafka.Producer
+	var eventBus *memory.InMemoryPublisher
+	var eventPublisher services.EventPublisher
 	if cfg.Kafka.EnableProducer {
-		kafkaProducer = kafka.NewProducer(cfg.Kafka.Brokers, cfg.Kafka.TopicOrders, log)
+		producerOpts := []kafka.ProducerOption{
+			kafka.WithFormat(cfg.Kafka.EventFormat),
+			kafka.WithOrigin(cfg.Server.Version, cfg.Server.Environment),
+			kafka.WithTopicAutoCreation(cfg.Kafka.AutoCreateTopics),
+			kafka.WithPublishTimeout(cfg.Kafka.PublishTimeout),
+		}
+		if cfg.Kafka.BreakerEnabled {
+			producerOpts = append(producerOpts, kafka.WithCircuitBreaker(
+				breaker.New(cfg.Kafka.BreakerFailureThreshold, cfg.Kafka.BreakerOpenTimeout, metrics.KafkaProducerCircuitBreakerState)))
+		}
+		kafkaProducer = kafka.NewProducer(cfg.Kafka.Brokers, cfg.Kafka.TopicOrders, topicRoutes(cfg), log, producerOpts...)
+		eventPublisher = kafkaProducer
+	} else {
+		log.Info("Kafka producer disabled, events will be kept in memory",
+			zap.Int("bufferSize", cfg.Kafka.InMemoryBufferSize))
+		eventBus = memory.NewInMemoryPublisher(cfg.Kafka.InMemoryBufferSize, log)
+		eventPublisher = eventBus
 	}
 
 	// Repositories and services initialization
-	cacheRepo := redisrepo.NewCacheRepository(redisClient, cfg.Redis.DefaultTTL)
-	orderService := services.NewOrderService(orderRepo, cacheRepo, kafkaProducer, log)
+	var cacheRepo *redisrepo.CacheRepository
+	if redisClient != nil {
+		cacheRepo = redisrepo.NewCacheRepository(redisClient, cfg.Redis.DefaultTTL)
+		cacheRepo.SetCompression(cfg.Redis.Compress, cfg.Redis.CompressMinSize)
+	}
+
+	// The cache can only be switched at runtime when Redis is connected, and
+	// inventory reservations when the inventory service is configured
+	toggleable := []string{features.EventSnapshots}
+	if cacheRepo != nil {
+		toggleable = append(toggleable, features.Cache, features.CompactSummaries)
+	}
+	if cfg.Inventory.Enabled {
+		toggleable = append(toggleable, features.InventoryReservation)
+	}
+	if summaryRepo != nil {
+		toggleable = append(toggleable, features.SummaryReads)
+	}
+	featureFlags := features.New(map[string]bool{
+		features.Cache:                cacheRepo != nil,
+		features.CompactSummaries:     cfg.Features.CompactSummaries,
+		features.EventSnapshots:       cfg.Features.EventSnapshots,
+		features.SchemaValidation:     cfg.Features.SchemaValidation,
+		features.ItemConsolidation:    cfg.Features.ItemConsolidation,
+		features.InventoryReservation: cfg.Inventory.Enabled,
+		features.SummaryReads:         cfg.Features.SummaryReads,
+	}, toggleable, log)
+
+	// Settings applied on reload without reconnecting or restarting
+	reloader := NewConfigReloader(cfg, config.Load, log)
+	reloader.Register(func(cfg *config.Config) { logger.SetLevel(cfg.Logging.Level) }, "Logging.Level")
+	for _, flag := range []struct {
+		name    string
+		setting string
+		enabled func(cfg *config.Config) bool
+	}{
+		{features.Cache, "Features.Cache", func(cfg *config.Config) bool { return cfg.Features.Cache }},
+		{features.CompactSummaries, "Features.CompactSummaries", func(cfg *config.Config) bool { return cfg.Features.CompactSummaries }},
+		{features.EventSnapshots, "Features.EventSnapshots", func(cfg *config.Config) bool { return cfg.Features.EventSnapshots }},
+		{features.InventoryReservation, "Inventory.Enabled", func(cfg *config.Config) bool { return cfg.Inventory.Enabled }},
+		{features.SummaryReads, "Features.SummaryReads", func(cfg *config.Config) bool { return cfg.Features.SummaryReads }},
+	} {
+		if slices.Contains(toggleable, flag.name) {
+			reloader.Register(func(cfg *config.Config) { _, _ = featureFlags.Set(flag.name, flag.enabled(cfg)) }, flag.setting)
+		}
+	}
+
+	serviceOpts := []services.Option{
+		services.WithCache(cacheRepo != nil),
+		services.WithFeatureFlags(featureFlags),
+		services.WithMaxNoteLength(cfg.App.MaxNoteLength),
+		services.WithMaxNotesPerOrder(cfg.App.MaxNotesPerOrder),
+		services.WithReturnWindow(cfg.App.ReturnWindow),
+		services.WithItemConsolidation(cfg.Features.ItemConsolidation),
+		services.WithMaxOrderWeight(cfg.App.MaxOrderWeight),
+		services.WithCustomerIDFormat(models.CustomerIDFormat(cfg.Customers.IDFormat)),
+		services.WithEventStore(eventRepo),
+		services.WithDeliverySLA(cfg.SLA.DefaultDuration, cfg.SLA.MinLead, cfg.SLA.MaxLead),
+		services.WithStatsCacheTTL(cfg.Redis.StatsTTL),
+	}
+	if summaryRepo != nil {
+		serviceOpts = append(serviceOpts, services.WithSummaryReads(summaryRepo))
+	}
+	if len(cfg.App.InitialStatuses) > 0 {
+		initialStatuses := make([]models.OrderStatus, len(cfg.App.InitialStatuses))
+		for i, status := range cfg.App.InitialStatuses {
+			initialStatuses[i] = models.OrderStatus(status)
+		}
+		serviceOpts = append(serviceOpts, services.WithInitialStatuses(initialStatuses))
+	}
+
+	// Listing writes are tracked for If-Modified-Since
+	if redisClient != nil {
+		lastWrites := redisrepo.NewLastWriteRepository(redisClient, cfg.Redis.DefaultTTL)
+		serviceOpts = append(serviceOpts, services.WithWriteTracker(lastWrites))
+		reloader.Register(func(cfg *config.Config) {
+			cacheRepo.SetDefaultTTL(cfg.Redis.DefaultTTL)
+			lastWrites.SetTTL(cfg.Redis.DefaultTTL)
+		}, "Redis.DefaultTTL")
+		reloader.Register(func(cfg *config.Config) {
+			cacheRepo.SetCompression(cfg.Redis.Compress, cfg.Redis.CompressMinSize)
+		}, "Redis.Compress", "Redis.CompressMinSize")
+	}
+
+	// Failed cache writes are retried in the background; a zero queue size disables it
+	var cacheRetrier *workers.CacheWriteRetrier
+	if cacheRepo != nil && cfg.Redis.RetryQueueSize > 0 {
+		cacheRetrier = workers.NewCacheWriteRetrier(cacheRepo, cfg.Redis.RetryQueueSize, cfg.Redis.RetryMaxAttempts, cfg.Redis.RetryDelay, log)
+		cacheRetrier.Start()
+		serviceOpts = append(serviceOpts, services.WithCacheWriteRetry(cacheRetrier))
+	}
+	// Customers are notified through the notifications pipeline, fed by the
+	// producer; failed requests are retried and then dead lettered
+	var notificationRetrier *workers.NotificationRetrier
+	if cfg.Kafka.Notifications && kafkaProducer != nil {
+		notifier := kafka.NewNotifier(kafkaProducer)
+		notificationRetrier = workers.NewNotificationRetrier(notifier, cfg.Kafka.NotificationRetryQueueSize,
+			cfg.Kafka.NotificationRetryMaxAttempts, cfg.Kafka.NotificationRetryDelay, log)
+		notificationRetrier.Start()
+		serviceOpts = append(serviceOpts, services.WithNotifier(notifier, notificationRetrier))
+	}
+	if cfg.Catalog.Enabled || cfg.Catalog.ValidateSKUs {
+		var priceCache catalog.PriceCache
+		var skuCache catalog.SKUCache
+		if redisClient != nil {
+			prices := redisrepo.NewPriceCacheRepository(redisClient, cfg.Catalog.PriceCacheTTL)
+			skus := redisrepo.NewSKUCacheRepository(redisClient, cfg.Catalog.SKUCacheTTL)
+			reloader.Register(func(cfg *config.Config) { prices.SetTTL(cfg.Catalog.PriceCacheTTL) }, "Catalog.PriceCacheTTL")
+			reloader.Register(func(cfg *config.Config) { skus.SetTTL(cfg.Catalog.SKUCacheTTL) }, "Catalog.SKUCacheTTL")
+			priceCache, skuCache = prices, skus
+		}
+		catalogClient := catalog.NewClient(cfg.Catalog.BaseURL, cfg.Catalog.APIKey, outboundClient(cfg, cfg.Catalog.Timeout), priceCache, skuCache, log)
+		if cfg.Catalog.Enabled {
+			serviceOpts = append(serviceOpts, services.WithPriceProvider(catalogClient))
+		}
+		if cfg.Catalog.ValidateSKUs {
+			serviceOpts = append(serviceOpts, services.WithSKUValidator(catalogClient, cfg.Catalog.SKUFailOpen))
+		}
+	}
+
+	// Stock is reserved for new orders and released on cancellation; releases
+	// that fail are kept in MongoDB and retried in the background
+	var releaseRetrier *workers.InventoryReleaseRetrier
+	if cfg.Inventory.Enabled {
+		inventoryClient := inventory.NewClient(cfg.Inventory.BaseURL, cfg.Inventory.APIKey, outboundClient(cfg, cfg.Inventory.Timeout))
+		serviceOpts = append(serviceOpts,
+			services.WithInventoryReserver(inventoryClient, services.ReservationStage(cfg.Inventory.Stage)),
+			services.WithInventoryReleaseOutbox(releaseRepo, cfg.Inventory.ReleaseRetryDelay),
+		)
+		releaseRetrier = workers.NewInventoryReleaseRetrier(releaseRepo, inventoryClient,
+			cfg.Inventory.ReleaseRetryInterval, cfg.Inventory.ReleaseRetryDelay, cfg.Inventory.ReleaseBatchSize, log)
+		releaseRetrier.Start()
+	}
+
+	switch cfg.Customers.ValidationMode {
+	case "http":
+		var customerCache customers.ExistenceCache
+		if redisClient != nil {
+			customersCache := redisrepo.NewCustomerCacheRepository(redisClient, cfg.Customers.CacheTTL)
+			reloader.Register(func(cfg *config.Config) { customersCache.SetTTL(cfg.Customers.CacheTTL) }, "Customers.CacheTTL")
+			customerCache = customersCache
+		}
+		customersClient := customers.NewClient(cfg.Customers.BaseURL, outboundClient(cfg, cfg.Customers.Timeout), customerCache, log)
+		serviceOpts = append(serviceOpts, services.WithCustomerValidator(customersClient, cfg.Customers.SoftFail))
+	case "fake":
+		serviceOpts = append(serviceOpts, services.WithCustomerValidator(customers.NewFake(cfg.Customers.FakeIDs...), cfg.Customers.SoftFail))
+	}
+
+	// Order creation bursts are smoothed per customer; delay mode holds
+	// creations over the rate for up to the maximum delay
+	if redisClient != nil && cfg.RateLimit.Enabled {
+		var maxDelay time.Duration
+		if cfg.RateLimit.Mode == "delay" {
+			maxDelay = cfg.RateLimit.MaxDelay
+		}
+		creationRate := redisrepo.NewCreationRateRepository(redisClient, cfg.RateLimit.PerMinute, cfg.RateLimit.Burst, maxDelay)
+		serviceOpts = append(serviceOpts, services.WithCreationLimiter(creationRate))
+	}
+
+	orderService := services.NewOrderService(orders, cacheRepo, eventPublisher, log, serviceOpts...)
+
+	// Cache reconciliation (optional)
+	var reconciler *workers.CacheReconciler
+	if cacheRepo != nil && cfg.Redis.ReconcileEnabled {
+		reconciler = workers.NewCacheReconciler(cacheRepo, orders, cfg.Redis.ReconcileInterval, cfg.Redis.ReconcileSampleSize, log)
+		reconciler.Start()
+	}
+
+	// SLA breach sweep (optional)
+	var slaSweeper *workers.SLASweeper
+	if cfg.SLA.SweepEnabled {
+		slaSweeper = workers.NewSLASweeper(orderService, cfg.SLA.SweepInterval, cfg.SLA.SweepBatchSize, log)
+		slaSweeper.Start()
+	}
+
+	// Delivery confirmations consumer (optional)
+	var deliveries *kafka.DeliveryConsumer
+	if cfg.Kafka.ConsumeDeliveries {
+		deliveries = kafka.NewDeliveryConsumer(cfg.Kafka.Brokers, cfg.Kafka.DeliveriesTopic, cfg.Kafka.ConsumerGroup, orderService, log)
+		deliveries.Start()
+	}
+
+	// Orders summary consumer (optional), reading the orders it summarizes
+	var summaries *kafka.SummaryConsumer
+	if cfg.Kafka.ConsumeSummary {
+		summaries = kafka.NewSummaryConsumer(cfg.Kafka.Brokers, orderTopics(cfg), cfg.Kafka.SummaryConsumerGroup, orders, summaryRepo, log)
+		summaries.Start()
+	}
+
+	// Event replays by time range (require Redis)
+	var eventReplayer *services.EventReplayer
+	if redisClient != nil {
+		eventReplayer = services.NewEventReplayer(eventRepo, redisrepo.NewReplayJobRepository(redisClient), eventPublisher, cfg.Kafka.ReplayRate, log)
+	}
+
+	// Client request quotas (require Redis)
+	var usage *redisrepo.UsageRepository
+	if redisClient != nil && cfg.Quotas.Enabled {
+		usage = redisrepo.NewUsageRepository(redisClient, time.Duration(cfg.Quotas.RetentionDays)*24*time.Hour)
+	}
+
+	// Dependency health, checked in the background so health checks do not
+	// ping the stores on every request
+	healthChecks := []handlers.DependencyCheck{
+		{Name: "mongodb", Pinger: handlers.PingFunc(func(ctx context.Context) error {
+			return mongoClient.Ping(ctx, readpref.Primary())
+		})},
+		{Name: "redis"},
+	}
+	if redisClient != nil {
+		healthChecks[1].Pinger = handlers.PingFunc(func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
+	healthMonitor := handlers.NewHealthMonitor(healthChecks, cfg.Server.Health.HistorySize,
+		cfg.Server.Health.FailureThreshold, cfg.Server.Health.RefreshInterval, nil)
+	healthMonitor.Start()
 
 	return &Dependencies{
-		MongoClient:   mongoClient,
-		MongoDB:       mongoDB,
-		RedisClient:   redisClient,
-		OrderService:  orderService,
-		KafkaProducer: kafkaProducer,
+		MongoClient:       mongoClient,
+		MongoDB:           mongoDB,
+		MongoPool:         mongoPool,
+		Indexes:           indexes,
+		Health:            healthMonitor,
+		RedisClient:       redisClient,
+		RedisCredentials:  redisCredentials,
+		OrderService:      orderService,
+		Features:          featureFlags,
+		ConfigReloader:    reloader,
+		KafkaProducer:     kafkaProducer,
+		EventBus:          eventBus,
+		Reconciler:        reconciler,
+		CacheRetrier:      cacheRetrier,
+		SLASweeper:        slaSweeper,
+		InventoryReleases: releaseRetrier,
+		Notifications:     notificationRetrier,
+		Deliveries:        deliveries,
+		Summaries:         summaries,
+		EventReplayer:     eventReplayer,
+		Usage:             usage,
 	}, nil
 }
 
-// Close gracefully shuts down all active connections and releases resources.
-func (d *Dependencies) Close() {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if d.MongoClient != nil {
-		_ = d.MongoClient.Disconnect(ctx)
+// topicRoutes returns the topic of each event type published by the
+// producer. The notification requests go to the notification topics unless
+// KAFKA_TOPIC_ROUTES routes them elsewhere.
+func topicRoutes(cfg *config.Config) map[string]string {
+	routes := make(map[string]string, len(cfg.Kafka.TopicRoutes)+2)
+	if cfg.Kafka.Notifications {
+		routes[string(models.NotificationRequested)] = cfg.Kafka.NotificationsTopic
+		routes[string(models.NotificationDeadLettered)] = cfg.Kafka.NotificationsDLQTopic
 	}
-
-	if d.RedisClient != nil {
-		_ = d.RedisClient.Close()
+	for eventType, topic := range cfg.Kafka.TopicRoutes {
+		routes[eventType] = topic
 	}
+	return routes
+}
 
-	if d.KafkaProducer != nil {
-		_ = d.KafkaProducer.Close()
+// orderTopics are the topics the order events are published to: the orders
+// topic and the topics events are routed to, notifications aside.
+func orderTopics(cfg *config.Config) []string {
+	topics := []string{cfg.Kafka.TopicOrders}
+	for _, topic := range cfg.Kafka.TopicRoutes {
+		if !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
 	}
+	slices.Sort(topics[1:])
+	return topics
+}
+
+// outboundClient returns the HTTP client of an integration, retrying and
+// failing fast as configured and bounding every attempt by timeout.
+func outboundClient(cfg *config.Config, timeout time.Duration) *httpclient.Client {
+	return httpclient.New(httpclient.Config{
+		Timeout:                 timeout,
+		MaxRetries:              cfg.Outbound.MaxRetries,
+		RetryBackoff:            cfg.Outbound.RetryBackoff,
+		BreakerFailureThreshold: cfg.Outbound.BreakerFailureThreshold,
+		BreakerOpenTimeout:      cfg.Outbound.BreakerOpenTimeout,
+		BreakerState:            metrics.OutboundCircuitBreakerState,
+	})
 }
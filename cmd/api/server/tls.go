@@ -0,0 +1,124 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"orders/cmd/api/config"
+
+	"go.uber.org/zap"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// CertReloader serves the server certificate, loaded from disk again on
+// Reload. Handshakes in progress keep the certificate they started with and
+// established connections are not affected.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// NewCertReloader loads the certificate and its key.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the certificate and its key again. The current certificate is
+// kept when they cannot be loaded.
+func (r *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate %s: %w", r.certFile, err)
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// NewTLSConfig creates the TLS configuration of the HTTP server, or nil when
+// TLS is disabled. When a client CA is set, clients must present a
+// certificate it signed.
+func NewTLSConfig(cfg config.TLSConfig) (*tls.Config, *CertReloader, error) {
+	if !cfg.Enabled() {
+		return nil, nil, nil
+	}
+
+	reloader, err := NewCertReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	minVersion, ok := tlsVersions[cfg.MinVersion]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported TLS version %q", cfg.MinVersion)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read TLS client CA %s: %w", cfg.ClientCAFile, err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("no certificates found in TLS client CA %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, reloader, nil
+}
+
+// ReloadOnSignal reloads the certificate every time a signal is received,
+// until signals is closed.
+func ReloadOnSignal(reloader *CertReloader, signals <-chan os.Signal, logger *zap.Logger) {
+	for range signals {
+		if err := reloader.Reload(); err != nil {
+			logger.Error("Failed to reload TLS certificate, keeping the current one", zap.Error(err))
+			continue
+		}
+		logger.Info("TLS certificate reloaded", zap.String("certFile", reloader.certFile))
+	}
+}
+
+// ListenAndServe listens on the server address and serves it, see Serve.
+func ListenAndServe(srv *http.Server) error {
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	return Serve(srv, listener)
+}
+
+// Serve serves over TLS when the server has a TLS configuration, and in plain
+// HTTP otherwise.
+func Serve(srv *http.Server, listener net.Listener) error {
+	if srv.TLSConfig != nil {
+		// The certificate comes from TLSConfig.GetCertificate
+		return srv.ServeTLS(listener, "", "")
+	}
+	return srv.Serve(listener)
+}
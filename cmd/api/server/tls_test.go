@@ -0,0 +1,250 @@
+package server
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"orders/cmd/api/config"
+	"orders/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testCA firma los certificados generados en los tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+	file string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "orders test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+
+	return &testCA{cert: cert, key: key, pool: pool, file: file}
+}
+
+// issue firma un certificado de servidor o de cliente y lo escribe en dir
+func (ca *testCA) issue(t *testing.T, dir string, serial int64, usage x509.ExtKeyUsage) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "orders"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+// serveHealth sirve las rutas de salud con la configuración TLS dada
+func serveHealth(t *testing.T, tlsConfig *tls.Config) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	healthHandler := handlers.NewHealthHandler(nil, nil, func() bool { return true })
+	router.GET("/ready", healthHandler.CheckReadiness)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: router, TLSConfig: tlsConfig}
+	go func() { _ = Serve(srv, listener) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	return listener.Addr().String()
+}
+
+func TestServe_PlainAndTLS(t *testing.T) {
+	ca := newTestCA(t)
+	certFile, keyFile := ca.issue(t, t.TempDir(), 2, x509.ExtKeyUsageServerAuth)
+
+	t.Run("plain", func(t *testing.T) {
+		tlsConfig, reloader, err := NewTLSConfig(config.TLSConfig{MinVersion: "1.2"})
+		require.NoError(t, err)
+		assert.Nil(t, tlsConfig)
+		assert.Nil(t, reloader)
+
+		addr := serveHealth(t, tlsConfig)
+		resp, err := http.Get("http://" + addr + "/ready")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("tls", func(t *testing.T) {
+		tlsConfig, _, err := NewTLSConfig(config.TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"})
+		require.NoError(t, err)
+
+		addr := serveHealth(t, tlsConfig)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.pool}}}
+		resp, err := client.Get("https://" + addr + "/ready")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, uint16(tls.VersionTLS13), resp.TLS.Version)
+
+		// Los clientes en texto plano son rechazados
+		plain, err := http.Get("http://" + addr + "/ready")
+		if err == nil {
+			defer plain.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, plain.StatusCode)
+		}
+	})
+}
+
+func TestCertReloader_ReloadKeepsConnections(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.issue(t, dir, 2, x509.ExtKeyUsageServerAuth)
+
+	tlsConfig, reloader, err := NewTLSConfig(config.TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.2"})
+	require.NoError(t, err)
+	addr := serveHealth(t, tlsConfig)
+
+	ready := func(conn *tls.Conn) int {
+		req, err := http.NewRequest(http.MethodGet, "https://"+addr+"/ready", nil)
+		require.NoError(t, err)
+		require.NoError(t, req.Write(conn))
+		resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	before, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: ca.pool})
+	require.NoError(t, err)
+	defer before.Close()
+	assert.Equal(t, http.StatusOK, ready(before))
+
+	// El certificado renovado reemplaza al anterior en disco
+	ca.issue(t, dir, 3, x509.ExtKeyUsageServerAuth)
+	signals := make(chan os.Signal, 1)
+	signals <- os.Interrupt
+	close(signals)
+	ReloadOnSignal(reloader, signals, zap.NewNop())
+
+	after, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: ca.pool})
+	require.NoError(t, err)
+	defer after.Close()
+	assert.Equal(t, int64(3), after.ConnectionState().PeerCertificates[0].SerialNumber.Int64())
+	assert.Equal(t, http.StatusOK, ready(after))
+
+	// La conexión abierta antes de la recarga sigue funcionando
+	assert.Equal(t, int64(2), before.ConnectionState().PeerCertificates[0].SerialNumber.Int64())
+	assert.Equal(t, http.StatusOK, ready(before))
+}
+
+func TestCertReloader_KeepsCertificateOnFailedReload(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.issue(t, dir, 2, x509.ExtKeyUsageServerAuth)
+
+	reloader, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	current, _ := reloader.GetCertificate(nil)
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0o600))
+	assert.Error(t, reloader.Reload())
+
+	kept, _ := reloader.GetCertificate(nil)
+	assert.Same(t, current, kept)
+}
+
+func TestNewTLSConfig_RequiresClientCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	certFile, keyFile := ca.issue(t, t.TempDir(), 2, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, t.TempDir(), 3, x509.ExtKeyUsageClientAuth)
+
+	tlsConfig, _, err := NewTLSConfig(config.TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		MinVersion:   "1.2",
+		ClientCAFile: ca.file,
+	})
+	require.NoError(t, err)
+	addr := serveHealth(t, tlsConfig)
+
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.pool}}}
+	_, err = anonymous.Get("https://" + addr + "/ready")
+	assert.Error(t, err)
+
+	cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+	authenticated := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      ca.pool,
+		Certificates: []tls.Certificate{cert},
+	}}}
+	resp, err := authenticated.Get("https://" + addr + "/ready")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestNewTLSConfig_InvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	_, _, err := NewTLSConfig(config.TLSConfig{
+		CertFile:   filepath.Join(dir, "missing.crt"),
+		KeyFile:    filepath.Join(dir, "missing.key"),
+		MinVersion: "1.2",
+	})
+	assert.ErrorContains(t, err, "failed to load TLS certificate")
+
+	ca := newTestCA(t)
+	certFile, keyFile := ca.issue(t, dir, 2, x509.ExtKeyUsageServerAuth)
+	_, _, err = NewTLSConfig(config.TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		MinVersion:   "1.2",
+		ClientCAFile: keyFile,
+	})
+	assert.ErrorContains(t, err, "no certificates found in TLS client CA")
+}
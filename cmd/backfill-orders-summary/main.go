@@ -0,0 +1,58 @@
+// Command backfill-orders-summary seeds the orders summary from the stored
+// orders, before listings are switched to it. It reads the same configuration
+// as the API and can be run while the summary consumer is running, as often
+// as needed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"orders/cmd/api/config"
+	"orders/cmd/api/server"
+	"orders/internal/repositories/mongodb"
+	"orders/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", 500, "orders summarized per round trip")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if err := logger.Init(cfg.Logging.Level, cfg.Logging.Format, logger.Options{Color: cfg.LogColor(), Caller: cfg.LogCaller()}); err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+	log := logger.Get()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := server.ConnectMongoDB(cfg.MongoDB, mongodb.NewPoolMonitor())
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB", zap.Error(err))
+	}
+	defer func() { _ = client.Disconnect(context.Background()) }()
+	repo := mongodb.NewOrderSummaryRepository(client.Database(cfg.MongoDB.Database))
+
+	if err := repo.CreateIndexes(ctx); err != nil {
+		log.Fatal("Failed to create MongoDB indexes", zap.Error(err))
+	}
+
+	written, err := repo.Backfill(ctx, *batchSize)
+	if err != nil {
+		log.Fatal("Failed to backfill orders summary", zap.Int64("written", written), zap.Error(err))
+	}
+	log.Info("Orders summary backfilled", zap.Int64("written", written))
+}
@@ -0,0 +1,58 @@
+// Command backfill-search-keys sets the search keys of the orders stored
+// before they were introduced. It reads the same configuration as the API and
+// can be run while the API is serving, as often as needed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"orders/cmd/api/config"
+	"orders/cmd/api/server"
+	"orders/internal/repositories/mongodb"
+	"orders/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", 500, "orders updated per round trip")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if err := logger.Init(cfg.Logging.Level, cfg.Logging.Format, logger.Options{Color: cfg.LogColor(), Caller: cfg.LogCaller()}); err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+	log := logger.Get()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := server.ConnectMongoDB(cfg.MongoDB, mongodb.NewPoolMonitor())
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB", zap.Error(err))
+	}
+	defer func() { _ = client.Disconnect(context.Background()) }()
+	repo := mongodb.NewOrderRepository(client.Database(cfg.MongoDB.Database))
+
+	// Searches rely on the index as soon as the keys are in place
+	if err := repo.CreateIndexes(ctx); err != nil {
+		log.Fatal("Failed to create MongoDB indexes", zap.Error(err))
+	}
+
+	updated, err := repo.BackfillSearchKeys(ctx, *batchSize)
+	if err != nil {
+		log.Fatal("Failed to backfill search keys", zap.Int64("updated", updated), zap.Error(err))
+	}
+	log.Info("Search keys backfilled", zap.Int64("updated", updated))
+}
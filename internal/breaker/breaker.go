@@ -0,0 +1,122 @@
+// Package breaker implements the circuit breaker guarding calls to MongoDB
+// and Kafka, so an unhealthy dependency fails calls at once instead of
+// making every request wait for it.
+package breaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed lets every call through while counting failures.
+	Closed State = iota
+	// HalfOpen lets a single probe through to find out whether the
+	// dependency recovered.
+	HalfOpen
+	// Open fails every call without reaching the dependency.
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case HalfOpen:
+		return "half-open"
+	case Open:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker opens after failureThreshold consecutive failures. While open,
+// calls are refused; after openTimeout a single probe is let through,
+// closing the breaker when it succeeds and opening it again when it fails.
+type Breaker struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	gauge            prometheus.Gauge
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// New creates a closed breaker reporting its state in gauge: 0 closed,
+// 1 half-open, 2 open.
+func New(failureThreshold int, openTimeout time.Duration, gauge prometheus.Gauge) *Breaker {
+	gauge.Set(float64(Closed))
+	return &Breaker{failureThreshold: failureThreshold, openTimeout: openTimeout, gauge: gauge}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open && time.Since(b.openedAt) >= b.openTimeout {
+		return HalfOpen
+	}
+	return b.state
+}
+
+// Allow reports whether a call may go through, moving an open breaker to
+// half-open once openTimeout has passed. Every allowed call must be followed
+// by Record.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open && time.Since(b.openedAt) >= b.openTimeout {
+		b.setState(HalfOpen)
+	}
+	switch b.state {
+	case Open:
+		return false
+	case HalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// Record accounts for the outcome of an allowed call.
+func (b *Breaker) Record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen {
+		b.probing = false
+		if failed {
+			b.trip()
+		} else {
+			b.failures = 0
+			b.setState(Closed)
+		}
+		return
+	}
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == Closed && b.failures >= b.failureThreshold {
+		b.trip()
+	}
+}
+
+func (b *Breaker) trip() {
+	b.openedAt = time.Now()
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(state State) {
+	b.state = state
+	b.gauge.Set(float64(state))
+}
@@ -0,0 +1,34 @@
+package breaker_test
+
+import (
+	"testing"
+	"time"
+
+	"orders/internal/breaker"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_SingleProbeWhileHalfOpen(t *testing.T) {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_breaker_state"})
+	b := breaker.New(1, 10*time.Millisecond, gauge)
+
+	assert.True(t, b.Allow())
+	b.Record(true)
+	assert.Equal(t, breaker.Open, b.State())
+	assert.Equal(t, float64(breaker.Open), testutil.ToFloat64(gauge))
+	assert.False(t, b.Allow())
+
+	// Pasado el tiempo de apertura solo pasa una sonda a la vez
+	time.Sleep(15 * time.Millisecond)
+	assert.True(t, b.Allow())
+	assert.Equal(t, float64(breaker.HalfOpen), testutil.ToFloat64(gauge))
+	assert.False(t, b.Allow())
+
+	b.Record(false)
+	assert.Equal(t, breaker.Closed, b.State())
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+}
@@ -0,0 +1,241 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/pkg/httpclient"
+
+	"go.uber.org/zap"
+)
+
+// PriceCache stores catalog prices briefly to avoid hammering the catalog service.
+type PriceCache interface {
+	GetPrices(ctx context.Context, skus []string) (map[string]models.ItemPrice, *repositories.RepositoryError)
+	SetPrices(ctx context.Context, prices []models.ItemPrice) *repositories.RepositoryError
+}
+
+// SKUCache stores the outcome of SKU existence checks briefly, including
+// SKUs the catalog does not know.
+type SKUCache interface {
+	GetExists(ctx context.Context, skus []string) (map[string]bool, *repositories.RepositoryError)
+	SetExists(ctx context.Context, exists map[string]bool) *repositories.RepositoryError
+}
+
+// Client is an HTTP client for the catalog service.
+type Client struct {
+	httpClient *httpclient.Client
+	baseURL    string
+	apiKey     string
+	cache      PriceCache
+	skuCache   SKUCache
+	logger     *zap.Logger
+}
+
+type pricesResponse struct {
+	Prices []struct {
+		SKU   string  `json:"sku"`
+		Price float64 `json:"price"`
+	} `json:"prices"`
+}
+
+// NewClient creates a catalog client. The caches are optional and may be nil.
+func NewClient(baseURL, apiKey string, httpClient *httpclient.Client, cache PriceCache, skuCache SKUCache, logger *zap.Logger) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		cache:      cache,
+		skuCache:   skuCache,
+		logger:     logger,
+	}
+}
+
+// ResolvePrices replaces the price of every item with the catalog price and
+// stamps the time of the catalog snapshot on the item.
+func (c *Client) ResolvePrices(ctx context.Context, items []models.OrderItem) ([]models.OrderItem, error) {
+	skus := uniqueSKUs(items)
+
+	prices, err := c.GetPrices(ctx, skus)
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	for _, sku := range skus {
+		if _, ok := prices[sku]; !ok {
+			unknown = append(unknown, sku)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, &models.UnknownSKUsError{SKUs: unknown}
+	}
+
+	resolved := make([]models.OrderItem, len(items))
+	for i, item := range items {
+		price := prices[item.SKU]
+		snapshotAt := price.SnapshotAt
+		item.Price = price.Price
+		item.PriceSnapshotAt = &snapshotAt
+		resolved[i] = item
+	}
+
+	return resolved, nil
+}
+
+// GetPrices returns the catalog prices of the given SKUs, serving what it can
+// from the cache. SKUs unknown to the catalog are absent from the result.
+func (c *Client) GetPrices(ctx context.Context, skus []string) (map[string]models.ItemPrice, error) {
+	prices := make(map[string]models.ItemPrice, len(skus))
+
+	missing := skus
+	if c.cache != nil {
+		cached, err := c.cache.GetPrices(ctx, skus)
+		if err != nil {
+			c.logger.Warn("Failed to read cached prices", zap.String("cause", err.Cause))
+		}
+		missing = make([]string, 0, len(skus))
+		for _, sku := range skus {
+			if price, ok := cached[sku]; ok {
+				prices[sku] = price
+				continue
+			}
+			missing = append(missing, sku)
+		}
+	}
+
+	if len(missing) == 0 {
+		return prices, nil
+	}
+
+	fetched, err := c.fetchPrices(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, price := range fetched {
+		prices[price.SKU] = price
+	}
+
+	if c.cache != nil && len(fetched) > 0 {
+		if err := c.cache.SetPrices(ctx, fetched); err != nil {
+			c.logger.Warn("Failed to cache prices", zap.String("cause", err.Cause))
+		}
+	}
+
+	return prices, nil
+}
+
+// Exists reports whether the SKU is known to the catalog.
+func (c *Client) Exists(ctx context.Context, sku string) (bool, error) {
+	exists, err := c.ExistingSKUs(ctx, []string{sku})
+	if err != nil {
+		return false, err
+	}
+	return exists[sku], nil
+}
+
+// ExistingSKUs reports whether each of the SKUs is known to the catalog. SKUs
+// that are not cached are looked up in a single request; a SKU exists when
+// the catalog has a price for it.
+func (c *Client) ExistingSKUs(ctx context.Context, skus []string) (map[string]bool, error) {
+	exists := make(map[string]bool, len(skus))
+
+	missing := skus
+	if c.skuCache != nil {
+		cached, err := c.skuCache.GetExists(ctx, skus)
+		if err != nil {
+			c.logger.Warn("Failed to read cached SKUs", zap.String("cause", err.Cause))
+		}
+		missing = make([]string, 0, len(skus))
+		for _, sku := range skus {
+			if found, ok := cached[sku]; ok {
+				exists[sku] = found
+				continue
+			}
+			missing = append(missing, sku)
+		}
+	}
+
+	if len(missing) == 0 {
+		return exists, nil
+	}
+
+	fetched, err := c.fetchPrices(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	looked := make(map[string]bool, len(missing))
+	for _, sku := range missing {
+		looked[sku] = false
+	}
+	for _, price := range fetched {
+		if _, ok := looked[price.SKU]; ok {
+			looked[price.SKU] = true
+		}
+	}
+	for sku, found := range looked {
+		exists[sku] = found
+	}
+
+	if c.skuCache != nil {
+		if err := c.skuCache.SetExists(ctx, looked); err != nil {
+			c.logger.Warn("Failed to cache SKUs", zap.String("cause", err.Cause))
+		}
+	}
+
+	return exists, nil
+}
+
+func (c *Client) fetchPrices(ctx context.Context, skus []string) ([]models.ItemPrice, error) {
+	endpoint := fmt.Sprintf("%s/prices?skus=%s", c.baseURL, url.QueryEscape(strings.Join(skus, ",")))
+
+	req, err := httpclient.NewJSONRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build catalog request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	var body pricesResponse
+	var status *httpclient.StatusError
+	if err := c.httpClient.DoJSON(req, &body); errors.As(err, &status) {
+		return nil, fmt.Errorf("catalog service returned status %d", status.StatusCode)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to call catalog service: %w", err)
+	}
+
+	snapshotAt := time.Now().UTC()
+	prices := make([]models.ItemPrice, 0, len(body.Prices))
+	for _, p := range body.Prices {
+		prices = append(prices, models.ItemPrice{
+			SKU:        p.SKU,
+			Price:      p.Price,
+			SnapshotAt: snapshotAt,
+		})
+	}
+
+	return prices, nil
+}
+
+func uniqueSKUs(items []models.OrderItem) []string {
+	seen := make(map[string]struct{}, len(items))
+	skus := make([]string, 0, len(items))
+	for _, item := range items {
+		if _, ok := seen[item.SKU]; ok {
+			continue
+		}
+		seen[item.SKU] = struct{}{}
+		skus = append(skus, item.SKU)
+	}
+	return skus
+}
@@ -0,0 +1,112 @@
+package catalog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"orders/internal/clients/catalog"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/pkg/httpclient"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newCatalogServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/prices", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_ResolvePrices_Success(t *testing.T) {
+	server := newCatalogServer(t, `{"prices":[{"sku":"LAPTOP-001","price":999.99}]}`)
+	client := catalog.NewClient(server.URL, "secret", httpclient.New(httpclient.Config{Timeout: time.Second}), nil, nil, zap.NewNop())
+
+	items := []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 0.01}}
+	resolved, err := client.ResolvePrices(context.Background(), items)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 999.99, resolved[0].Price)
+	assert.NotNil(t, resolved[0].PriceSnapshotAt)
+	assert.Equal(t, 0.01, items[0].Price, "input items must not be mutated")
+}
+
+func TestClient_ResolvePrices_UnknownSKU(t *testing.T) {
+	server := newCatalogServer(t, `{"prices":[{"sku":"LAPTOP-001","price":999.99}]}`)
+	client := catalog.NewClient(server.URL, "secret", httpclient.New(httpclient.Config{Timeout: time.Second}), nil, nil, zap.NewNop())
+
+	items := []models.OrderItem{
+		{SKU: "LAPTOP-001", Quantity: 1},
+		{SKU: "GHOST-404", Quantity: 1},
+	}
+	_, err := client.ResolvePrices(context.Background(), items)
+
+	var unknownErr *models.UnknownSKUsError
+	assert.ErrorAs(t, err, &unknownErr)
+	assert.Equal(t, []string{"GHOST-404"}, unknownErr.SKUs)
+}
+
+func TestClient_ResolvePrices_ServiceError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	client := catalog.NewClient(server.URL, "", httpclient.New(httpclient.Config{Timeout: time.Second}), nil, nil, zap.NewNop())
+
+	_, err := client.ResolvePrices(context.Background(), []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1}})
+
+	assert.Error(t, err)
+}
+
+// skuCache guarda en memoria las comprobaciones de SKUs
+type skuCache struct {
+	exists map[string]bool
+}
+
+func (c *skuCache) GetExists(_ context.Context, skus []string) (map[string]bool, *repositories.RepositoryError) {
+	found := make(map[string]bool)
+	for _, sku := range skus {
+		if exists, ok := c.exists[sku]; ok {
+			found[sku] = exists
+		}
+	}
+	return found, nil
+}
+
+func (c *skuCache) SetExists(_ context.Context, exists map[string]bool) *repositories.RepositoryError {
+	for sku, found := range exists {
+		c.exists[sku] = found
+	}
+	return nil
+}
+
+func TestClient_ExistingSKUs(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Query().Get("skus"))
+		_, _ = w.Write([]byte(`{"prices":[{"sku":"LAPTOP-001","price":999.99}]}`))
+	}))
+	defer server.Close()
+	cache := &skuCache{exists: map[string]bool{}}
+	client := catalog.NewClient(server.URL, "", httpclient.New(httpclient.Config{Timeout: time.Second}), nil, cache, zap.NewNop())
+
+	exists, err := client.ExistingSKUs(context.Background(), []string{"LAPTOP-001", "GHOST-404"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"LAPTOP-001": true, "GHOST-404": false}, exists)
+	assert.Equal(t, []string{"LAPTOP-001,GHOST-404"}, requested)
+
+	// Unknown SKUs are cached as well
+	found, err := client.Exists(context.Background(), "GHOST-404")
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Len(t, requested, 1)
+}
@@ -0,0 +1,106 @@
+package customers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/pkg/httpclient"
+
+	"go.uber.org/zap"
+)
+
+// ExistenceCache stores the result of customer lookups for a short period.
+// Only customers found are cached, so that a customer created right after a
+// failed lookup can place orders straight away.
+type ExistenceCache interface {
+	GetExists(ctx context.Context, customerID string) (bool, bool, *repositories.RepositoryError)
+	SetExists(ctx context.Context, customerID string, exists bool) *repositories.RepositoryError
+}
+
+// Client is an HTTP client for the customers service.
+type Client struct {
+	httpClient *httpclient.Client
+	baseURL    string
+	cache      ExistenceCache
+	logger     *zap.Logger
+}
+
+// NewClient creates a customers client. The cache is optional and may be nil.
+func NewClient(baseURL string, httpClient *httpclient.Client, cache ExistenceCache, logger *zap.Logger) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		cache:      cache,
+		logger:     logger,
+	}
+}
+
+// Exists reports whether the customers service knows the given customer.
+func (c *Client) Exists(ctx context.Context, customerID string) (bool, error) {
+	if c.cache != nil {
+		exists, found, err := c.cache.GetExists(ctx, customerID)
+		if err != nil {
+			c.logger.Warn("Failed to read cached customer", zap.String("cause", err.Cause))
+		} else if found {
+			return exists, nil
+		}
+	}
+
+	exists, err := c.lookup(ctx, customerID)
+	if err != nil {
+		return false, err
+	}
+
+	if exists && c.cache != nil {
+		if err := c.cache.SetExists(ctx, customerID, true); err != nil {
+			c.logger.Warn("Failed to cache customer", zap.String("cause", err.Cause))
+		}
+	}
+
+	return exists, nil
+}
+
+// Contact returns the contact details the customers service holds for the
+// customer, or nil when the customer is unknown.
+func (c *Client) Contact(ctx context.Context, customerID string) (*models.CustomerSnapshot, error) {
+	var contact models.CustomerSnapshot
+	found, err := c.get(ctx, customerID, &contact)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (c *Client) lookup(ctx context.Context, customerID string) (bool, error) {
+	return c.get(ctx, customerID, nil)
+}
+
+// get fetches the customer into out, unless nil, and reports whether the
+// customers service knows it.
+func (c *Client) get(ctx context.Context, customerID string, out interface{}) (bool, error) {
+	endpoint := fmt.Sprintf("%s/customers/%s", c.baseURL, url.PathEscape(customerID))
+
+	req, err := httpclient.NewJSONRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to build customers request: %w", err)
+	}
+
+	var status *httpclient.StatusError
+	err = c.httpClient.DoJSON(req, out)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &status) && status.StatusCode == http.StatusNotFound:
+		return false, nil
+	case status != nil:
+		return false, fmt.Errorf("customers service returned status %d", status.StatusCode)
+	default:
+		return false, fmt.Errorf("failed to call customers service: %w", err)
+	}
+}
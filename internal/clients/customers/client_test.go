@@ -0,0 +1,123 @@
+package customers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"orders/internal/clients/customers"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/pkg/httpclient"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestClient_Exists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/customers/known":
+			w.WriteHeader(http.StatusOK)
+		case "/customers/unknown":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := customers.NewClient(server.URL, httpclient.New(httpclient.Config{Timeout: time.Second}), nil, zap.NewNop())
+
+	exists, err := client.Exists(context.Background(), "known")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = client.Exists(context.Background(), "unknown")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = client.Exists(context.Background(), "broken")
+	assert.Error(t, err)
+}
+
+// memoryCache es una caché de existencia de clientes en memoria
+type memoryCache map[string]bool
+
+func (c memoryCache) GetExists(ctx context.Context, customerID string) (bool, bool, *repositories.RepositoryError) {
+	exists, found := c[customerID]
+	return exists, found, nil
+}
+
+func (c memoryCache) SetExists(ctx context.Context, customerID string, exists bool) *repositories.RepositoryError {
+	c[customerID] = exists
+	return nil
+}
+
+func TestClient_Exists_CachesOnlyKnownCustomers(t *testing.T) {
+	var calls atomic.Int32
+	known := map[string]bool{"known": true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if known[strings.TrimPrefix(r.URL.Path, "/customers/")] {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cache := memoryCache{}
+	client := customers.NewClient(server.URL, httpclient.New(httpclient.Config{Timeout: time.Second}), cache, zap.NewNop())
+
+	// El cliente conocido se resuelve desde la caché la segunda vez
+	for i := 0; i < 2; i++ {
+		exists, err := client.Exists(context.Background(), "known")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Un cliente desconocido no se cachea y se ve en cuanto se da de alta
+	exists, err := client.Exists(context.Background(), "new")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.NotContains(t, cache, "new")
+
+	known["new"] = true
+	exists, err = client.Exists(context.Background(), "new")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClient_Contact(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/customers/known":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"known","email":"jane@example.com","name":"Jane Doe","phone":"+34600123456"}`))
+		case "/customers/unknown":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := customers.NewClient(server.URL, httpclient.New(httpclient.Config{Timeout: time.Second}), nil, zap.NewNop())
+
+	contact, err := client.Contact(context.Background(), "known")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.CustomerSnapshot{Email: "jane@example.com", Name: "Jane Doe", Phone: "+34600123456"}, contact)
+
+	contact, err = client.Contact(context.Background(), "unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, contact)
+
+	_, err = client.Contact(context.Background(), "broken")
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package customers
+
+import (
+	"context"
+	"sync"
+
+	"orders/internal/models"
+)
+
+// Fake is an in-memory customer validator for tests and local development.
+type Fake struct {
+	mu        sync.RWMutex
+	customers map[string]struct{}
+	contacts  map[string]models.CustomerSnapshot
+	err       error
+}
+
+// NewFake creates a fake validator that knows the given customer IDs.
+func NewFake(customerIDs ...string) *Fake {
+	f := &Fake{
+		customers: make(map[string]struct{}, len(customerIDs)),
+		contacts:  make(map[string]models.CustomerSnapshot),
+	}
+	for _, id := range customerIDs {
+		f.customers[id] = struct{}{}
+	}
+	return f
+}
+
+// Add registers a customer as existing.
+func (f *Fake) Add(customerID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.customers[customerID] = struct{}{}
+}
+
+// SetContact registers a customer as existing with the given contact details.
+func (f *Fake) SetContact(customerID string, contact models.CustomerSnapshot) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.customers[customerID] = struct{}{}
+	f.contacts[customerID] = contact
+}
+
+// FailWith makes every lookup fail with err, simulating an outage. A nil err
+// restores normal behavior.
+func (f *Fake) FailWith(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *Fake) Exists(_ context.Context, customerID string) (bool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.err != nil {
+		return false, f.err
+	}
+	_, ok := f.customers[customerID]
+	return ok, nil
+}
+
+func (f *Fake) Contact(_ context.Context, customerID string) (*models.CustomerSnapshot, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	contact, ok := f.contacts[customerID]
+	if !ok {
+		return nil, nil
+	}
+	return &contact, nil
+}
@@ -0,0 +1,118 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"orders/internal/models"
+	"orders/pkg/httpclient"
+)
+
+// Client is an HTTP client for the inventory service, which holds stock for
+// orders until they are fulfilled or cancelled.
+type Client struct {
+	httpClient *httpclient.Client
+	baseURL    string
+	apiKey     string
+}
+
+type reservationItem struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type reservationRequest struct {
+	OrderID string            `json:"orderId"`
+	Items   []reservationItem `json:"items"`
+}
+
+// shortageResponse is the body of a reservation refused for lack of stock.
+type shortageResponse struct {
+	Unavailable []struct {
+		SKU string `json:"sku"`
+	} `json:"unavailable"`
+}
+
+// NewClient creates an inventory client.
+func NewClient(baseURL, apiKey string, httpClient *httpclient.Client) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+	}
+}
+
+// Reserve holds the stock of the items for the order. Reserving again for the
+// same order replaces its reservation. A shortage is reported with
+// *models.InsufficientStockError listing the SKUs that cannot be reserved.
+func (c *Client) Reserve(ctx context.Context, orderID string, items []models.OrderItem) error {
+	body := reservationRequest{OrderID: orderID, Items: make([]reservationItem, len(items))}
+	for i, item := range items {
+		body.Items[i] = reservationItem{SKU: item.SKU, Quantity: item.Quantity}
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.baseURL+"/reservations", body)
+	if err != nil {
+		return err
+	}
+	// The reservation replaces any previous one, so it is safe to retry
+	req.Header.Set(httpclient.IdempotencyKeyHeader, orderID)
+
+	err = c.httpClient.DoJSON(req, nil)
+	var status *httpclient.StatusError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &status) && status.StatusCode == http.StatusConflict:
+		var shortage shortageResponse
+		if err := status.Decode(&shortage); err != nil || len(shortage.Unavailable) == 0 {
+			return fmt.Errorf("inventory service refused the reservation without listing the unavailable SKUs")
+		}
+		skus := make([]string, len(shortage.Unavailable))
+		for i, unavailable := range shortage.Unavailable {
+			skus[i] = unavailable.SKU
+		}
+		return &models.InsufficientStockError{SKUs: skus}
+	case status != nil:
+		return fmt.Errorf("inventory service returned status %d", status.StatusCode)
+	default:
+		return fmt.Errorf("failed to call inventory service: %w", err)
+	}
+}
+
+// Release frees the stock reserved for the order. Releasing an order without
+// a reservation, or one already released, succeeds.
+func (c *Client) Release(ctx context.Context, orderID string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, fmt.Sprintf("%s/reservations/%s", c.baseURL, url.PathEscape(orderID)), nil)
+	if err != nil {
+		return err
+	}
+
+	err = c.httpClient.DoJSON(req, nil)
+	var status *httpclient.StatusError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &status) && status.StatusCode == http.StatusNotFound:
+		return nil
+	case status != nil:
+		return fmt.Errorf("inventory service returned status %d", status.StatusCode)
+	default:
+		return fmt.Errorf("failed to call inventory service: %w", err)
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, method, endpoint string, body interface{}) (*http.Request, error) {
+	req, err := httpclient.NewJSONRequest(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build inventory request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return req, nil
+}
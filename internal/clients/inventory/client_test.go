@@ -0,0 +1,131 @@
+package inventory_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"orders/internal/clients/inventory"
+	"orders/internal/models"
+	"orders/pkg/httpclient"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInventory simula el servicio de inventario con el stock disponible por SKU
+type fakeInventory struct {
+	stock    map[string]int
+	reserved map[string][]models.OrderItem
+}
+
+func newFakeInventory(t *testing.T, stock map[string]int) (*fakeInventory, *httptest.Server) {
+	t.Helper()
+	fake := &fakeInventory{stock: stock, reserved: map[string][]models.OrderItem{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /reservations", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var body struct {
+			OrderID string             `json:"orderId"`
+			Items   []models.OrderItem `json:"items"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		var unavailable []map[string]string
+		for _, item := range body.Items {
+			if fake.stock[item.SKU] < item.Quantity {
+				unavailable = append(unavailable, map[string]string{"sku": item.SKU})
+			}
+		}
+		if len(unavailable) > 0 {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"unavailable": unavailable})
+			return
+		}
+		fake.reserved[body.OrderID] = body.Items
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("DELETE /reservations/{orderId}", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := fake.reserved[r.PathValue("orderId")]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(fake.reserved, r.PathValue("orderId"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func TestClient_ReserveAndRelease(t *testing.T) {
+	fake, server := newFakeInventory(t, map[string]int{"LAPTOP-001": 5, "MOUSE-002": 10})
+	client := inventory.NewClient(server.URL, "secret", httpclient.New(httpclient.Config{Timeout: time.Second}))
+	ctx := context.Background()
+
+	items := []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 2}, {SKU: "MOUSE-002", Quantity: 1}}
+	require.NoError(t, client.Reserve(ctx, "order-123", items))
+	if assert.Len(t, fake.reserved["order-123"], 2) {
+		assert.Equal(t, 2, fake.reserved["order-123"][0].Quantity)
+	}
+
+	require.NoError(t, client.Release(ctx, "order-123"))
+	assert.Empty(t, fake.reserved)
+
+	// Liberar de nuevo, o un pedido sin reserva, no es un error
+	assert.NoError(t, client.Release(ctx, "order-123"))
+}
+
+func TestClient_Reserve_Shortage(t *testing.T) {
+	fake, server := newFakeInventory(t, map[string]int{"LAPTOP-001": 1, "MOUSE-002": 10})
+	client := inventory.NewClient(server.URL, "secret", httpclient.New(httpclient.Config{Timeout: time.Second}))
+
+	items := []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 2}, {SKU: "MOUSE-002", Quantity: 1}, {SKU: "GHOST-404", Quantity: 1}}
+	err := client.Reserve(context.Background(), "order-123", items)
+
+	var shortage *models.InsufficientStockError
+	require.ErrorAs(t, err, &shortage)
+	assert.Equal(t, []string{"LAPTOP-001", "GHOST-404"}, shortage.SKUs)
+	assert.Empty(t, fake.reserved)
+}
+
+func TestClient_ServiceDown(t *testing.T) {
+	items := []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1}}
+
+	t.Run("Unreachable", func(t *testing.T) {
+		_, server := newFakeInventory(t, nil)
+		server.Close()
+		client := inventory.NewClient(server.URL, "secret", httpclient.New(httpclient.Config{Timeout: time.Second}))
+
+		err := client.Reserve(context.Background(), "order-123", items)
+		assert.ErrorContains(t, err, "failed to call inventory service")
+		var shortage *models.InsufficientStockError
+		assert.NotErrorAs(t, err, &shortage)
+		assert.ErrorContains(t, client.Release(context.Background(), "order-123"), "failed to call inventory service")
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		client := inventory.NewClient(server.URL, "", httpclient.New(httpclient.Config{Timeout: time.Second}))
+
+		assert.EqualError(t, client.Reserve(context.Background(), "order-123", items), "inventory service returned status 503")
+		assert.EqualError(t, client.Release(context.Background(), "order-123"), "inventory service returned status 503")
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+		client := inventory.NewClient(server.URL, "", httpclient.New(httpclient.Config{Timeout: 20 * time.Millisecond}))
+
+		assert.ErrorContains(t, client.Reserve(context.Background(), "order-123", items), "failed to call inventory service")
+	})
+}
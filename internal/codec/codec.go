@@ -0,0 +1,32 @@
+// Package codec serializes the orders kept in the cache and served by the
+// API. It uses the same JSON library gin renders responses with: encoding/json
+// by default, or a faster one when built with the sonic, go_json or jsoniter
+// tag, so cached orders and responses always agree on the bytes.
+package codec
+
+import (
+	"io"
+
+	"github.com/gin-gonic/gin/codec/json"
+)
+
+// Package is the JSON library in use.
+const Package = json.Package
+
+// Encoder writes JSON values to a stream, each followed by a newline.
+type Encoder = json.Encoder
+
+// Marshal returns the JSON encoding of v.
+func Marshal(v any) ([]byte, error) {
+	return json.API.Marshal(v)
+}
+
+// Unmarshal parses the JSON-encoded data into v.
+func Unmarshal(data []byte, v any) error {
+	return json.API.Unmarshal(data, v)
+}
+
+// NewEncoder returns an encoder that writes to w.
+func NewEncoder(w io.Writer) Encoder {
+	return json.API.NewEncoder(w)
+}
@@ -0,0 +1,90 @@
+package codec_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"orders/internal/codec"
+	"orders/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orderWithItems devuelve un pedido cacheable con n líneas
+func orderWithItems(n int) *models.Order {
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	items := make([]models.OrderItem, n)
+	for i := range items {
+		items[i] = models.OrderItem{SKU: fmt.Sprintf("SKU-%03d", i), Quantity: i%5 + 1, Price: 9.99, WeightGrams: 250, PriceSnapshotAt: &createdAt}
+	}
+	return &models.Order{
+		ID:               "order-123",
+		CustomerID:       "customer-1",
+		Status:           models.StatusInProgress,
+		Priority:         models.PriorityNormal,
+		Items:            items,
+		Tags:             []string{"vip"},
+		TotalAmount:      9.99 * float64(n),
+		Notes:            "Dejar en portería <antes de las 10>",
+		CustomerSnapshot: &models.CustomerSnapshot{Email: "jane.doe@example.com", Name: "Jane Doe"},
+		Version:          2,
+		CreatedAt:        createdAt,
+		UpdatedAt:        createdAt,
+	}
+}
+
+func TestCodec_MatchesEncodingJSON(t *testing.T) {
+	order := orderWithItems(3)
+
+	data, err := codec.Marshal(order)
+	require.NoError(t, err)
+	expected, err := json.Marshal(order)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(data), "serialized with %s", codec.Package)
+
+	var decoded models.Order
+	require.NoError(t, codec.Unmarshal(data, &decoded))
+	assert.Equal(t, order.Items, decoded.Items)
+	assert.Equal(t, order.Notes, decoded.Notes)
+	assert.Equal(t, order.CustomerSnapshot, decoded.CustomerSnapshot)
+
+	// El encoder termina cada valor con un salto de línea, como encoding/json
+	var buf bytes.Buffer
+	require.NoError(t, codec.NewEncoder(&buf).Encode(order))
+	assert.Equal(t, string(expected)+"\n", buf.String())
+}
+
+// BenchmarkCodec mide las idas y vueltas a la caché de un pedido de 1 y de
+// 100 líneas. Para comparar librerías:
+//
+//	go test ./internal/codec -bench . -benchmem -tags go_json
+func BenchmarkCodec(b *testing.B) {
+	for _, items := range []int{1, 100} {
+		order := orderWithItems(items)
+		data, err := codec.Marshal(order)
+		require.NoError(b, err)
+
+		b.Run(fmt.Sprintf("Marshal/%d_items", items), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := codec.Marshal(order); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Unmarshal/%d_items", items), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				var decoded models.Order
+				if err := codec.Unmarshal(data, &decoded); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
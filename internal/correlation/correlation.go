@@ -0,0 +1,59 @@
+// Package correlation carries the identifiers that tie events to the business
+// transaction, and to the event, that caused them.
+package correlation
+
+import "context"
+
+type contextKey int
+
+const (
+	correlationIDKey contextKey = iota
+	causationIDKey
+	traceKey
+)
+
+// trace holds the W3C trace context headers of a request.
+type trace struct {
+	parent string
+	state  string
+}
+
+// WithID returns a context carrying the correlation ID shared by every event
+// of a business transaction, usually the request ID.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+// ID returns the correlation ID carried by the context, if any.
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// WithCausationID returns a context carrying the ID of the event that caused
+// the work done with it.
+func WithCausationID(ctx context.Context, eventID string) context.Context {
+	return context.WithValue(ctx, causationIDKey, eventID)
+}
+
+// CausationID returns the causation ID carried by the context, if any.
+func CausationID(ctx context.Context) string {
+	id, _ := ctx.Value(causationIDKey).(string)
+	return id
+}
+
+// WithTrace returns a context carrying the traceparent and tracestate headers
+// of the request being served, to pass them on to the services it calls.
+func WithTrace(ctx context.Context, parent, state string) context.Context {
+	if parent == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, traceKey, trace{parent: parent, state: state})
+}
+
+// Trace returns the traceparent and tracestate headers carried by the
+// context, if any.
+func Trace(ctx context.Context) (parent, state string) {
+	t, _ := ctx.Value(traceKey).(trace)
+	return t.parent, t.state
+}
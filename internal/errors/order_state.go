@@ -0,0 +1,21 @@
+package errors
+
+import "orders/internal/models"
+
+// OrderStateDetails is the current state of an order returned with conflict
+// and invalid transition errors, so clients can retry without reading the
+// order again.
+type OrderStateDetails struct {
+	CurrentStatus      models.OrderStatus   `json:"currentStatus"`
+	CurrentVersion     int                  `json:"currentVersion"`
+	AllowedTransitions []models.OrderStatus `json:"allowedTransitions"`
+}
+
+// NewOrderStateDetails describes the given state of an order.
+func NewOrderStateDetails(order *models.Order) *OrderStateDetails {
+	return &OrderStateDetails{
+		CurrentStatus:      order.Status,
+		CurrentVersion:     order.Version,
+		AllowedTransitions: order.Status.Transitions(),
+	}
+}
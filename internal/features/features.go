@@ -0,0 +1,131 @@
+package features
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"orders/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Names of the feature flags.
+const (
+	// Cache serves and fills the Redis order cache.
+	Cache = "cache"
+	// CompactSummaries caches order summaries apart from the full orders.
+	CompactSummaries = "compactSummaries"
+	// EventSnapshots attaches the order before and after the change to events.
+	EventSnapshots = "eventSnapshots"
+	// SchemaValidation validates order creation bodies against their JSON schema.
+	SchemaValidation = "schemaValidation"
+	// ItemConsolidation merges order lines sharing a SKU at creation.
+	ItemConsolidation = "itemConsolidation"
+	// InventoryReservation reserves the stock of new orders in the inventory
+	// service.
+	InventoryReservation = "inventoryReservation"
+	// SummaryReads serves listings, counts and stats from the orders summary.
+	SummaryReads = "summaryReads"
+)
+
+var (
+	// ErrUnknownFlag is returned when setting a flag that does not exist.
+	ErrUnknownFlag = errors.New("unknown feature flag")
+	// ErrNotToggleable is returned when setting a flag that is only read at
+	// startup.
+	ErrNotToggleable = errors.New("feature flag cannot be changed at runtime")
+)
+
+// Flag is the effective state of a feature flag.
+type Flag struct {
+	Name       string `json:"name"`
+	Enabled    bool   `json:"enabled"`
+	Toggleable bool   `json:"toggleable"`
+}
+
+type flag struct {
+	name       string
+	enabled    atomic.Bool
+	toggleable bool
+}
+
+// Flags holds the feature flags of the service. Toggleable flags can be
+// changed at runtime, for instance to bypass the cache during an incident;
+// the others keep the value they were configured with.
+type Flags struct {
+	flags  []*flag
+	logger *zap.Logger
+}
+
+// New creates the flags with their configured values.
+func New(values map[string]bool, toggleable []string, logger *zap.Logger) *Flags {
+	canToggle := make(map[string]bool, len(toggleable))
+	for _, name := range toggleable {
+		canToggle[name] = true
+	}
+
+	f := &Flags{logger: logger}
+	for _, name := range []string{Cache, CompactSummaries, EventSnapshots, SchemaValidation, ItemConsolidation, InventoryReservation, SummaryReads} {
+		fl := &flag{name: name, toggleable: canToggle[name]}
+		fl.enabled.Store(values[name])
+		f.flags = append(f.flags, fl)
+		recordState(name, values[name])
+	}
+	return f
+}
+
+// Enabled reports whether the named flag is on. Unknown flags are off.
+func (f *Flags) Enabled(name string) bool {
+	if fl := f.lookup(name); fl != nil {
+		return fl.enabled.Load()
+	}
+	return false
+}
+
+// Set turns a toggleable flag on or off, returning its new state.
+func (f *Flags) Set(name string, enabled bool) (Flag, error) {
+	fl := f.lookup(name)
+	if fl == nil {
+		return Flag{}, ErrUnknownFlag
+	}
+	if !fl.toggleable {
+		return Flag{}, ErrNotToggleable
+	}
+
+	if previous := fl.enabled.Swap(enabled); previous != enabled {
+		f.logger.Warn("Feature flag changed",
+			zap.String("flag", name),
+			zap.Bool("enabled", enabled),
+			zap.Bool("previous", previous),
+		)
+		recordState(name, enabled)
+		metrics.FeatureFlagChangesTotal.WithLabelValues(name).Inc()
+	}
+	return Flag{Name: name, Enabled: enabled, Toggleable: true}, nil
+}
+
+// All returns the effective state of every flag.
+func (f *Flags) All() []Flag {
+	all := make([]Flag, 0, len(f.flags))
+	for _, fl := range f.flags {
+		all = append(all, Flag{Name: fl.name, Enabled: fl.enabled.Load(), Toggleable: fl.toggleable})
+	}
+	return all
+}
+
+func (f *Flags) lookup(name string) *flag {
+	for _, fl := range f.flags {
+		if fl.name == name {
+			return fl
+		}
+	}
+	return nil
+}
+
+func recordState(name string, enabled bool) {
+	value := 0.0
+	if enabled {
+		value = 1
+	}
+	metrics.FeatureFlagEnabled.WithLabelValues(name).Set(value)
+}
@@ -0,0 +1,59 @@
+package features_test
+
+import (
+	"testing"
+
+	"orders/internal/features"
+	"orders/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFlags(t *testing.T) {
+	flags := features.New(map[string]bool{
+		features.Cache:            true,
+		features.SchemaValidation: true,
+	}, []string{features.Cache, features.EventSnapshots}, zap.NewNop())
+
+	assert.True(t, flags.Enabled(features.Cache))
+	assert.False(t, flags.Enabled(features.EventSnapshots))
+	assert.False(t, flags.Enabled("unknown"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.FeatureFlagEnabled.WithLabelValues(features.Cache)))
+
+	t.Run("toggle", func(t *testing.T) {
+		changes := testutil.ToFloat64(metrics.FeatureFlagChangesTotal.WithLabelValues(features.Cache))
+
+		flag, err := flags.Set(features.Cache, false)
+		require.NoError(t, err)
+		assert.Equal(t, features.Flag{Name: features.Cache, Enabled: false, Toggleable: true}, flag)
+		assert.False(t, flags.Enabled(features.Cache))
+		assert.Equal(t, 0.0, testutil.ToFloat64(metrics.FeatureFlagEnabled.WithLabelValues(features.Cache)))
+		assert.Equal(t, changes+1, testutil.ToFloat64(metrics.FeatureFlagChangesTotal.WithLabelValues(features.Cache)))
+
+		// Repetir el mismo valor no cuenta como un cambio
+		_, err = flags.Set(features.Cache, false)
+		require.NoError(t, err)
+		assert.Equal(t, changes+1, testutil.ToFloat64(metrics.FeatureFlagChangesTotal.WithLabelValues(features.Cache)))
+	})
+
+	t.Run("not toggleable", func(t *testing.T) {
+		_, err := flags.Set(features.SchemaValidation, false)
+		assert.ErrorIs(t, err, features.ErrNotToggleable)
+		assert.True(t, flags.Enabled(features.SchemaValidation))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := flags.Set("unknown", true)
+		assert.ErrorIs(t, err, features.ErrUnknownFlag)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		all := flags.All()
+		require.Len(t, all, 7)
+		assert.Contains(t, all, features.Flag{Name: features.SchemaValidation, Enabled: true})
+		assert.Contains(t, all, features.Flag{Name: features.EventSnapshots, Toggleable: true})
+	})
+}
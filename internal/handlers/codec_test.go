@@ -0,0 +1,174 @@
+package handlers_test
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"orders/internal/codec"
+	"orders/internal/handlers"
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// Los ficheros golden se generan con encoding/json:
+//
+//	go test ./internal/handlers -run Golden -update
+//
+// y se comprueban también con el resto de librerías, p. ej. -tags go_json
+var update = flag.Bool("update", false, "rewrite the golden responses")
+
+// goldenOrder devuelve un pedido con todos los campos que se sirven: texto que
+// JSON escapa, unicode, decimales sin representación exacta y fechas con zona
+func goldenOrder() *models.Order {
+	madrid := time.FixedZone("CET", 3600)
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 123456789, madrid)
+	deliveredAt := createdAt.Add(26 * time.Hour)
+	promisedAt := createdAt.Add(48 * time.Hour)
+	return &models.Order{
+		ID:         "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+		TenantID:   "brand-a",
+		CustomerID: "customer-1",
+		Status:     models.StatusReturnRequested,
+		Priority:   models.PriorityHigh,
+		Channel:    models.ChannelWeb,
+		Items: []models.OrderItem{
+			{SKU: "SKU-001", Quantity: 3, Price: 0.1, WeightGrams: 250, DeliveredQuantity: 3},
+			{SKU: "SKU-ÑÜ", Quantity: 1, Price: 1e21, VolumeCm3: 1200, DeliveredQuantity: 1, PriceSnapshotAt: &createdAt},
+		},
+		Tags:        []string{"vip", "regalo"},
+		TotalAmount: 0.1 + 0.2,
+		Notes:       "Dejar en \"portería\" <b>antes</b> de las 10 & llamar\n 😀",
+		NoteEntries: []models.OrderNote{{Author: "ops", Text: "Cliente avisado", CreatedAt: createdAt}},
+		Version:     4,
+		CreatedAt:   createdAt,
+		UpdatedAt:   deliveredAt,
+		ClientMetadata: map[string]string{
+			"utm_source": "newsletter",
+			"campaign":   "spring<2025>",
+		},
+		CustomerSnapshot:   &models.CustomerSnapshot{Email: "jane.doe@example.com", Name: "Jane Doe", Phone: "+34600123456"},
+		TotalWeightGrams:   750,
+		TotalVolumeCm3:     1200,
+		PromisedDeliveryAt: &promisedAt,
+		DeliveredAt:        &deliveredAt,
+		Return: &models.OrderReturn{
+			Reason:      "Talla incorrecta",
+			Items:       []models.ReturnItem{{SKU: "SKU-001", Quantity: 1}},
+			RequestedAt: deliveredAt.Add(time.Hour),
+		},
+	}
+}
+
+// newOrder devuelve un pedido recién creado: sin fechas de entrega, devolución,
+// medidas ni datos opcionales, que no deben servirse con valores cero
+func newOrder() *models.Order {
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	return &models.Order{
+		ID:          "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+		CustomerID:  "customer-1",
+		Status:      models.StatusNew,
+		Priority:    models.PriorityNormal,
+		Items:       []models.OrderItem{{SKU: "SKU-001", Quantity: 1, Price: 9.99}},
+		TotalAmount: 9.99,
+		Version:     1,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+}
+
+func TestOrderHandler_GoldenResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	order := goldenOrder()
+	stats := &models.OrderStats{
+		OrdersByStatus:    map[models.OrderStatus]int64{models.StatusNew: 3, models.StatusDelivered: 5, models.StatusCancelled: 1},
+		TotalOrders:       9,
+		AverageOrderValue: 2.0 / 3.0,
+		OrdersLast24h:     2,
+		GeneratedAt:       time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	// Entregado hace tiempo, fuera del plazo de devolución
+	delivered := goldenOrder()
+	delivered.ID = "3f2504e0-4f89-41d3-9a0c-0305e82c3301"
+	delivered.Status = models.StatusDelivered
+	delivered.Return = nil
+
+	mockService := new(MockOrderService)
+	mockService.On("GetOrderByID", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").Return(order, (*services.ServiceError)(nil))
+	mockService.On("GetOrderByID", mock.Anything, "3f2504e0-4f89-41d3-9a0c-0305e82c3301").Return(delivered, (*services.ServiceError)(nil))
+	mockService.On("GetOrderByID", mock.Anything, "16fd2706-8baf-433b-82eb-8c7fada847da").Return((*models.Order)(nil), &services.ServiceError{
+		Status:  http.StatusNotFound,
+		Code:    "ORDER_NOT_FOUND",
+		Message: "Order with ID 16fd2706-8baf-433b-82eb-8c7fada847da not found",
+	})
+	mockService.On("GetOrderSummary", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").Return(order.Summary(), (*services.ServiceError)(nil))
+	mockService.On("GetOrderStats", mock.Anything).Return(stats, (*services.ServiceError)(nil))
+	mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+	mockService.On("ListOrders", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*models.Order{order, order}, int64(12), (*services.ServiceError)(nil))
+	mockService.On("CreateOrder", mock.Anything, mock.Anything).Return(newOrder(), (*services.ServiceError)(nil))
+	mockService.On("StreamOrders", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*models.Order{order, order}, int64(12), (*services.ServiceError)(nil))
+
+	router := func(idField string) *gin.Engine {
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, idField)
+		handler.SetReturnWindow(14 * 24 * time.Hour)
+		router := gin.New()
+		router.POST("/orders", handler.CreateOrder)
+		router.GET("/orders", handler.ListOrders)
+		router.GET("/orders/:id", handler.GetOrder)
+		router.GET("/orders/:id/summary", handler.GetOrderSummary)
+		router.GET("/stats", handler.GetOrderStats)
+		return router
+	}
+
+	tests := []struct {
+		golden  string
+		idField string
+		url     string
+		// body se envía por POST
+		body string
+	}{
+		{"order.json", handlers.IDFieldOrderID, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7", ""},
+		{"order_id_field.json", handlers.IDFieldID, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7", ""},
+		{"order_delivered.json", handlers.IDFieldOrderID, "/orders/3f2504e0-4f89-41d3-9a0c-0305e82c3301", ""},
+		{"order_summary.json", handlers.IDFieldOrderID, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/summary", ""},
+		{"order_not_found.json", handlers.IDFieldOrderID, "/orders/16fd2706-8baf-433b-82eb-8c7fada847da", ""},
+		{"list_buffered.json", handlers.IDFieldOrderID, "/orders?page=2&limit=10", ""},
+		{"list_streamed.json", handlers.IDFieldID, "/orders?page=2&limit=100", ""},
+		{"stats.json", handlers.IDFieldOrderID, "/stats", ""},
+		{"order_created.json", handlers.IDFieldOrderID, "/orders", `{"customerId":"123e4567-e89b-12d3-a456-426614174000","items":[{"sku":"SKU-001","quantity":1,"price":9.99}]}`},
+		{"order_created_id_field.json", handlers.IDFieldID, "/orders", `{"customerId":"123e4567-e89b-12d3-a456-426614174000","items":[{"sku":"SKU-001","quantity":1,"price":9.99}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.body != "" {
+				req = httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			router(tt.idField).ServeHTTP(w, req)
+
+			path := filepath.Join("testdata", "golden", tt.golden)
+			if *update {
+				require.Equal(t, "encoding/json", codec.Package, "golden responses are generated with encoding/json")
+				require.NoError(t, os.WriteFile(path, w.Body.Bytes(), 0o644))
+			}
+			golden, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, string(golden), w.Body.String(), "serialized with %s", codec.Package)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ConfigReloader reloads the configuration, applying the settings that can
+// change at runtime and reporting the ones that need a restart.
+type ConfigReloader interface {
+	Reload() (applied, requiresRestart []string, err error)
+}
+
+// ConfigHandler lets administrators reload the configuration.
+type ConfigHandler struct {
+	reloader ConfigReloader
+	logger   *zap.Logger
+}
+
+// NewConfigHandler creates a new instance of ConfigHandler.
+func NewConfigHandler(reloader ConfigReloader, logger *zap.Logger) *ConfigHandler {
+	return &ConfigHandler{
+		reloader: reloader,
+		logger:   logger,
+	}
+}
+
+// ReloadResponse lists the settings applied by a reload and those changed
+// but only applied on restart.
+type ReloadResponse struct {
+	Applied         []string `json:"applied"`
+	RequiresRestart []string `json:"requiresRestart"`
+}
+
+// ReloadConfig godoc
+// @Summary Reload the configuration
+// @Description Loads the configuration again, as on SIGHUP. Log level, cache TTLs, page size caps and runtime feature flags apply immediately; other changes are reported as requiring a restart.
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} ReloadResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /api/admin/config/reload [post]
+func (h *ConfigHandler) ReloadConfig(c *gin.Context) {
+	applied, requiresRestart, err := h.reloader.Reload()
+	if err != nil {
+		h.logger.Warn("Failed to reload configuration", zap.Error(err), zap.String("requestId", getRequestID(c)))
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid configuration, the current one is kept", "cause": []string{err.Error()}})
+		return
+	}
+
+	c.JSON(http.StatusOK, ReloadResponse{
+		Applied:         nonNil(applied),
+		RequiresRestart: nonNil(requiresRestart),
+	})
+}
+
+// nonNil serializes a missing list as empty rather than null.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
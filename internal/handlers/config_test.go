@@ -0,0 +1,53 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"orders/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeReloader devuelve el resultado de recarga indicado
+type fakeReloader struct {
+	applied, requiresRestart []string
+	err                      error
+}
+
+func (f fakeReloader) Reload() ([]string, []string, error) {
+	return f.applied, f.requiresRestart, f.err
+}
+
+func TestConfigHandler_ReloadConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		reloader fakeReloader
+		expected int
+		body     string
+	}{
+		{"Applied", fakeReloader{applied: []string{"Redis.DefaultTTL"}, requiresRestart: []string{"Server.Port"}}, http.StatusOK,
+			`{"applied":["Redis.DefaultTTL"],"requiresRestart":["Server.Port"]}`},
+		{"No changes", fakeReloader{}, http.StatusOK, `{"applied":[],"requiresRestart":[]}`},
+		{"Invalid configuration", fakeReloader{err: errors.New("invalid configuration: REDIS_URL is required")}, http.StatusUnprocessableEntity,
+			`{"error":"Invalid configuration, the current one is kept","cause":["invalid configuration: REDIS_URL is required"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/api/admin/config/reload", handlers.NewConfigHandler(tt.reloader, zap.NewNop()).ReloadConfig)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/config/reload", nil))
+
+			assert.Equal(t, tt.expected, w.Code)
+			assert.JSONEq(t, tt.body, w.Body.String())
+		})
+	}
+}
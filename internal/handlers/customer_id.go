@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"sync/atomic"
+
+	"orders/internal/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// customerIDFormat is the format checked by the customerid binding tag.
+var customerIDFormat atomic.Value
+
+func init() {
+	customerIDFormat.Store(models.CustomerIDUUID)
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		_ = engine.RegisterValidation("customerid", validateCustomerID)
+	}
+}
+
+// SetCustomerIDFormat sets the format request bodies and queries must use for
+// customer IDs. It should be called once at startup; UUIDs are the default.
+func SetCustomerIDFormat(format models.CustomerIDFormat) {
+	customerIDFormat.Store(format)
+}
+
+func validateCustomerID(fl validator.FieldLevel) bool {
+	return customerIDFormat.Load().(models.CustomerIDFormat).Accepts(fl.Field().String())
+}
+
+// normalizeCustomerID returns the customer ID as it is stored, lowercasing
+// UUIDs, so lookups do not depend on the casing clients send.
+func normalizeCustomerID(customerID string) string {
+	return customerIDFormat.Load().(models.CustomerIDFormat).Normalize(customerID)
+}
+
+// customerIDsAreUUIDs reports whether customer IDs must be UUIDs, and
+// malformed ones are then answered with INVALID_UUID.
+func customerIDsAreUUIDs() bool {
+	format := customerIDFormat.Load().(models.CustomerIDFormat)
+	return format == "" || format == models.CustomerIDUUID
+}
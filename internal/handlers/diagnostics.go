@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"orders/internal/features"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiagnosticsProbe describes a dependency, e.g. the version and topology of
+// the server the service reached.
+type DiagnosticsProbe struct {
+	Name     string
+	Describe func(ctx context.Context) (interface{}, error)
+}
+
+// DiagnosticsSettings are the connection settings of the dependencies, with
+// credentials redacted.
+type DiagnosticsSettings struct {
+	MongoURI         string   `json:"mongoUri"`
+	MongoMaxPoolSize uint64   `json:"mongoMaxPoolSize"`
+	MongoMinPoolSize uint64   `json:"mongoMinPoolSize"`
+	RedisAddr        string   `json:"redisAddr,omitempty"`
+	RedisPoolSize    int      `json:"redisPoolSize,omitempty"`
+	KafkaBrokers     []string `json:"kafkaBrokers,omitempty"`
+}
+
+// DependencyDiagnostics is what a probe found, or why it failed.
+type DependencyDiagnostics struct {
+	Info  interface{} `json:"info,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// DiagnosticsResponse describes the dependencies the service talks to and
+// how it is configured to.
+type DiagnosticsResponse struct {
+	// GeneratedAt is when the dependencies were probed
+	GeneratedAt  time.Time                        `json:"generatedAt"`
+	Dependencies map[string]DependencyDiagnostics `json:"dependencies"`
+	Settings     DiagnosticsSettings              `json:"settings"`
+	Features     []features.Flag                  `json:"features"`
+}
+
+// DiagnosticsHandler reports the dependencies of the service to
+// administrators during incidents. Probes run with a short timeout and their
+// results are reused for a while, so repeated requests do not load the
+// dependencies; the feature flags are always current.
+type DiagnosticsHandler struct {
+	probes   []DiagnosticsProbe
+	settings DiagnosticsSettings
+	flags    *features.Flags
+	timeout  time.Duration
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu          sync.Mutex
+	probedAt    time.Time
+	diagnostics map[string]DependencyDiagnostics
+}
+
+// NewDiagnosticsHandler creates a new instance of DiagnosticsHandler running
+// each probe for up to timeout and keeping their results for ttl. now
+// defaults to time.Now.
+func NewDiagnosticsHandler(probes []DiagnosticsProbe, settings DiagnosticsSettings, flags *features.Flags, timeout, ttl time.Duration, now func() time.Time) *DiagnosticsHandler {
+	if now == nil {
+		now = time.Now
+	}
+	return &DiagnosticsHandler{
+		probes:   probes,
+		settings: settings,
+		flags:    flags,
+		timeout:  timeout,
+		ttl:      ttl,
+		now:      now,
+	}
+}
+
+// GetDiagnostics godoc
+// @Summary Describe the dependencies
+// @Description Reports the MongoDB version and topology, the Redis version, role and memory, the Kafka brokers, the connection settings with credentials redacted and the effective feature flags. Dependencies are probed at most once a minute. Requires admin credentials.
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} DiagnosticsResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/diagnostics [get]
+func (h *DiagnosticsHandler) GetDiagnostics(c *gin.Context) {
+	probedAt, diagnostics := h.probe(c.Request.Context())
+	c.JSON(http.StatusOK, DiagnosticsResponse{
+		GeneratedAt:  probedAt,
+		Dependencies: diagnostics,
+		Settings:     h.settings,
+		Features:     h.flags.All(),
+	})
+}
+
+// probe returns the results of the probes, running them again once they are
+// older than the TTL. Requests arriving meanwhile wait for the same run.
+func (h *DiagnosticsHandler) probe(ctx context.Context) (time.Time, map[string]DependencyDiagnostics) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.diagnostics != nil && h.now().Sub(h.probedAt) < h.ttl {
+		return h.probedAt, h.diagnostics
+	}
+
+	diagnostics := make(map[string]DependencyDiagnostics, len(h.probes))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, probe := range h.probes {
+		wg.Add(1)
+		go func(probe DiagnosticsProbe) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.timeout)
+			defer cancel()
+
+			var result DependencyDiagnostics
+			info, err := probe.Describe(probeCtx)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Info = info
+			}
+			mu.Lock()
+			diagnostics[probe.Name] = result
+			mu.Unlock()
+		}(probe)
+	}
+	wg.Wait()
+
+	h.probedAt = h.now().UTC()
+	h.diagnostics = diagnostics
+	return h.probedAt, h.diagnostics
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"orders/internal/middlewares"
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListEventsQuery holds the query parameters accepted by ListEvents.
+type ListEventsQuery struct {
+	OrderID string     `form:"orderId" binding:"omitempty,max=100"`
+	Type    string     `form:"type" binding:"omitempty,oneof=ORDER_CREATED ORDER_STATUS_CHANGED ORDER_SLA_BREACHED ORDER_PRIORITY_CHANGED ORDER_TAGS_CHANGED ORDER_ITEMS_DELIVERED ORDER_RETURN_REQUESTED ORDER_RETURNED"`
+	Status  string     `form:"status" binding:"omitempty,oneof=pending sent failed"`
+	From    *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To      *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// Filter converts the query into the service filter.
+func (q ListEventsQuery) Filter() services.ListEventsFilter {
+	return services.ListEventsFilter{
+		OrderID:   q.OrderID,
+		EventType: models.EventType(q.Type),
+		Status:    models.EventDeliveryStatus(strings.ToUpper(q.Status)),
+		From:      q.From,
+		To:        q.To,
+	}
+}
+
+type ListEventsResponse struct {
+	Events     []*models.EventRecord `json:"events"`
+	Pagination PaginationResponse    `json:"pagination"`
+}
+
+// EventResponse is an event of the log with the order before and after the
+// change, which are not part of the flat event format.
+type EventResponse struct {
+	*models.EventRecord
+	Before *OrderResponse `json:"before,omitempty"`
+	After  *OrderResponse `json:"after,omitempty"`
+}
+
+// ListEvents godoc
+// @Summary Browse the event log
+// @Description Lists the events emitted for every order, newest first, with their delivery status. Customer contact data is masked unless the admin key has the pii:read scope. Requires admin credentials.
+// @Tags admin
+// @Produce json
+// @Param orderId query string false "Only events of this order"
+// @Param type query string false "Only events of this type"
+// @Param status query string false "Delivery status" Enums(pending, sent, failed)
+// @Param from query string false "Only events emitted at or after this time (RFC 3339)"
+// @Param to query string false "Only events emitted at or before this time (RFC 3339)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} ListEventsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/admin/events [get]
+func (h *OrderHandler) ListEvents(c *gin.Context) {
+	requestID := getRequestID(c)
+
+	var query ListEventsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		writeQueryError(c, queryFieldErrors(err, query))
+		return
+	}
+	if query.From != nil && query.To != nil && query.To.Before(*query.From) {
+		writeQueryError(c, []middlewares.FieldError{{Field: "to", Message: "must not be before from"}})
+		return
+	}
+	if orderID, ok := models.CanonicalUUID(query.OrderID); ok {
+		query.OrderID = orderID
+	}
+	page, limit, requestedLimit := h.pageParams(c)
+
+	events, total, svcErr := h.service.ListEvents(c.Request.Context(), query.Filter(), page, limit)
+	if svcErr != nil {
+		h.logger.Error("Failed to list events", zap.Error(svcErr), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to list events")
+		return
+	}
+
+	if !middlewares.HasScope(c, middlewares.ScopePIIRead) {
+		for i, event := range events {
+			events[i] = event.Redacted()
+		}
+	}
+
+	pagination := newPagination(page, limit, total)
+	pagination.RequestedLimit = requestedLimit
+	c.JSON(http.StatusOK, ListEventsResponse{Events: events, Pagination: pagination})
+}
+
+// GetEvent godoc
+// @Summary Get an event of the log
+// @Description Returns an event with its full payload, including the order before and after the change, and its delivery attempts. Customer contact data is masked unless the admin key has the pii:read scope. Requires admin credentials.
+// @Tags admin
+// @Produce json
+// @Param eventId path string true "Event ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} EventResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/admin/events/{eventId} [get]
+func (h *OrderHandler) GetEvent(c *gin.Context) {
+	requestID := getRequestID(c)
+	eventID := c.Param("eventId")
+
+	event, svcErr := h.service.GetEvent(c.Request.Context(), eventID)
+	if svcErr != nil {
+		h.logger.Error("Failed to get event", zap.Error(svcErr), zap.String("eventId", eventID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to get event")
+		return
+	}
+
+	if !middlewares.HasScope(c, middlewares.ScopePIIRead) {
+		event = event.Redacted()
+	}
+
+	now := time.Now()
+	c.JSON(http.StatusOK, EventResponse{
+		EventRecord: event,
+		Before:      ToOrderResponse(event.Before, h.idField, h.currency, h.returnWindow, now),
+		After:       ToOrderResponse(event.After, h.idField, h.currency, h.returnWindow, now),
+	})
+}
@@ -0,0 +1,227 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"orders/internal/handlers"
+	"orders/internal/middlewares"
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newEventsRouter monta las rutas del registro de eventos como en el servidor,
+// concediendo pii:read solo a la clave "pii-key"
+func newEventsRouter(mockService *MockOrderService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+	router := gin.New()
+	admin := router.Group("/api/admin",
+		middlewares.RequireAdmin([]string{"admin-key", "pii-key"}),
+		middlewares.GrantScope(middlewares.ScopePIIRead, []string{"pii-key"}),
+	)
+	admin.GET("/events", handler.ListEvents)
+	admin.GET("/events/:eventId", handler.GetEvent)
+	admin.GET("/outbox", handler.ListOutbox)
+	admin.POST("/outbox/:eventId/retry", handler.RetryOutboxEvent)
+	return router
+}
+
+// createdEvent devuelve un evento de creación con los datos de contacto del cliente
+func createdEvent() *models.EventRecord {
+	customer := &models.CustomerSnapshot{Email: "jane.doe@example.com", Name: "Jane Doe", Phone: "+34600123456"}
+	order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", CustomerID: "customer-1", Status: models.StatusNew, CustomerSnapshot: customer}
+	event := models.NewOrderCreatedEvent(order).SetStates(nil, order)
+	record := models.NewEventRecord(event)
+	record.Status = models.EventStatusFailed
+	record.Attempts = 3
+	record.LastError = "broker down"
+	return record
+}
+
+func TestOrderHandler_ListEvents(t *testing.T) {
+	tests := []struct {
+		name          string
+		key           string
+		expectedEmail string
+	}{
+		{"Masked without pii:read", "admin-key", "j*******@example.com"},
+		{"Unmasked with pii:read", "pii-key", "jane.doe@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			mockService.On("ListEvents", mock.Anything, services.ListEventsFilter{
+				OrderID: "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+				Status:  models.EventStatusFailed,
+			}, 1, 10).Return([]*models.EventRecord{createdEvent()}, int64(1), (*services.ServiceError)(nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/events?orderId=7c9e6679-7425-40de-944b-e07fc1f90ae7&status=failed", nil)
+			req.Header.Set(middlewares.AdminAPIKeyHeader, tt.key)
+			w := httptest.NewRecorder()
+
+			newEventsRouter(mockService).ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var response handlers.ListEventsResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			require.Len(t, response.Events, 1)
+			assert.Equal(t, tt.expectedEmail, response.Events[0].CustomerSnapshot.Email)
+			assert.Equal(t, 3, response.Events[0].Attempts)
+			assert.Equal(t, int64(1), response.Pagination.Total)
+			mockService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Invalid status", func(t *testing.T) {
+		mockService := new(MockOrderService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/events?status=lost", nil)
+		req.Header.Set(middlewares.AdminAPIKeyHeader, "admin-key")
+		w := httptest.NewRecorder()
+
+		newEventsRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"status"`)
+		mockService.AssertNotCalled(t, "ListEvents")
+	})
+}
+
+func TestOrderHandler_ListOutbox(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected models.EventDeliveryStatus
+	}{
+		{"Pending by default", "", models.EventStatusPending},
+		{"Pending", "?status=pending", models.EventStatusPending},
+		{"Failed", "?status=failed", models.EventStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			record := createdEvent()
+			record.Status = models.EventStatusPending
+			mockService.On("ListEvents", mock.Anything, services.ListEventsFilter{Status: tt.expected}, 1, 10).
+				Return([]*models.EventRecord{record}, int64(1), (*services.ServiceError)(nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/outbox"+tt.query, nil)
+			req.Header.Set(middlewares.AdminAPIKeyHeader, "admin-key")
+			w := httptest.NewRecorder()
+
+			newEventsRouter(mockService).ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var response handlers.ListEventsResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			require.Len(t, response.Events, 1)
+			assert.Equal(t, "j*******@example.com", response.Events[0].CustomerSnapshot.Email)
+			mockService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Sent events are not stuck", func(t *testing.T) {
+		mockService := new(MockOrderService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/outbox?status=sent", nil)
+		req.Header.Set(middlewares.AdminAPIKeyHeader, "admin-key")
+		w := httptest.NewRecorder()
+
+		newEventsRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ListEvents")
+	})
+}
+
+func TestOrderHandler_RetryOutboxEvent(t *testing.T) {
+	t.Run("Published", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		record := createdEvent()
+		record.Status = models.EventStatusSent
+		record.Attempts = 4
+		record.LastError = ""
+		mockService.On("RetryEvent", mock.Anything, record.EventID).Return(record, (*services.ServiceError)(nil))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/outbox/"+record.EventID+"/retry", nil)
+		req.Header.Set(middlewares.AdminAPIKeyHeader, "admin-key")
+		w := httptest.NewRecorder()
+
+		newEventsRouter(mockService).ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response models.EventRecord
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, models.EventStatusSent, response.Status)
+		assert.Equal(t, 4, response.Attempts)
+		assert.Equal(t, "j*******@example.com", response.CustomerSnapshot.Email)
+	})
+
+	t.Run("Broker still down", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("RetryEvent", mock.Anything, "event-1").Return((*models.EventRecord)(nil), &services.ServiceError{
+			Status:  http.StatusBadGateway,
+			Message: "Failed to publish event",
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/outbox/event-1/retry", nil)
+		req.Header.Set(middlewares.AdminAPIKeyHeader, "admin-key")
+		w := httptest.NewRecorder()
+
+		newEventsRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+
+	t.Run("Requires admin credentials", func(t *testing.T) {
+		mockService := new(MockOrderService)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/outbox/event-1/retry", nil)
+		w := httptest.NewRecorder()
+
+		newEventsRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertNotCalled(t, "RetryEvent")
+	})
+}
+
+func TestOrderHandler_GetEvent(t *testing.T) {
+	mockService := new(MockOrderService)
+	record := createdEvent()
+	mockService.On("GetEvent", mock.Anything, record.EventID).Return(record, (*services.ServiceError)(nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/events/"+record.EventID, nil)
+	req.Header.Set(middlewares.AdminAPIKeyHeader, "admin-key")
+	w := httptest.NewRecorder()
+
+	newEventsRouter(mockService).ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		EventID   string        `json:"eventId"`
+		Status    string        `json:"status"`
+		LastError string        `json:"lastError"`
+		After     *models.Order `json:"after"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, record.EventID, response.EventID)
+	assert.Equal(t, "FAILED", response.Status)
+	assert.Equal(t, "broker down", response.LastError)
+	require.NotNil(t, response.After)
+	// El pedido incluido en el evento también se enmascara
+	assert.Equal(t, "**********56", response.After.CustomerSnapshot.Phone)
+	// El registro guardado no se modifica
+	assert.Equal(t, "+34600123456", record.After.CustomerSnapshot.Phone)
+}
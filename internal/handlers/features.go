@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"orders/internal/features"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureHandler exposes the feature flags to administrators.
+type FeatureHandler struct {
+	flags *features.Flags
+}
+
+// NewFeatureHandler creates a new instance of FeatureHandler. Changes are
+// logged by the flags themselves.
+func NewFeatureHandler(flags *features.Flags) *FeatureHandler {
+	return &FeatureHandler{flags: flags}
+}
+
+// FeaturesResponse lists the effective feature flags.
+type FeaturesResponse struct {
+	Features []features.Flag `json:"features"`
+}
+
+// SetFeatureRequest turns a feature flag on or off.
+type SetFeatureRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// ListFeatures godoc
+// @Summary List feature flags
+// @Description Returns the effective feature flags and whether they can be changed at runtime
+// @Tags admin
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} FeaturesResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/admin/features [get]
+func (h *FeatureHandler) ListFeatures(c *gin.Context) {
+	c.JSON(http.StatusOK, FeaturesResponse{Features: h.flags.All()})
+}
+
+// SetFeature godoc
+// @Summary Toggle a feature flag
+// @Description Turns a feature flag on or off until the next restart. Only flags marked toggleable can be changed.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param name path string true "Feature flag name"
+// @Param flag body SetFeatureRequest true "New state"
+// @Success 200 {object} features.Flag
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/admin/features/{name} [put]
+func (h *FeatureHandler) SetFeature(c *gin.Context) {
+	name := c.Param("name")
+
+	var req SetFeatureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format or missing required fields"})
+		return
+	}
+
+	flag, err := h.flags.Set(name, *req.Enabled)
+	switch {
+	case errors.Is(err, features.ErrUnknownFlag):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feature flag " + name + " not found"})
+		return
+	case errors.Is(err, features.ErrNotToggleable):
+		c.JSON(http.StatusConflict, gin.H{"error": "Feature flag " + name + " cannot be changed at runtime"})
+		return
+	}
+
+	c.JSON(http.StatusOK, flag)
+}
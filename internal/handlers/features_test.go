@@ -0,0 +1,74 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"orders/internal/features"
+	"orders/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// setupFeatureRouter expone los flags con la caché cambiable en caliente
+func setupFeatureRouter() (*gin.Engine, *features.Flags) {
+	gin.SetMode(gin.TestMode)
+	flags := features.New(map[string]bool{features.Cache: true, features.SchemaValidation: true},
+		[]string{features.Cache}, zap.NewNop())
+	handler := handlers.NewFeatureHandler(flags)
+
+	router := gin.New()
+	router.GET("/api/admin/features", handler.ListFeatures)
+	router.PUT("/api/admin/features/:name", handler.SetFeature)
+	return router, flags
+}
+
+func TestFeatureHandler_ListFeatures(t *testing.T) {
+	router, _ := setupFeatureRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/features", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response handlers.FeaturesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Contains(t, response.Features, features.Flag{Name: features.Cache, Enabled: true, Toggleable: true})
+	assert.Contains(t, response.Features, features.Flag{Name: features.SchemaValidation, Enabled: true})
+}
+
+func TestFeatureHandler_SetFeature(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     string
+		body     string
+		expected int
+	}{
+		{"Toggle", features.Cache, `{"enabled":false}`, http.StatusOK},
+		{"Missing state", features.Cache, `{}`, http.StatusBadRequest},
+		{"Not toggleable", features.SchemaValidation, `{"enabled":false}`, http.StatusConflict},
+		{"Unknown flag", "cursorPagination", `{"enabled":true}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, flags := setupFeatureRouter()
+
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/features/"+tt.flag, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+			if tt.expected == http.StatusOK {
+				assert.False(t, flags.Enabled(tt.flag))
+				assert.JSONEq(t, `{"name":"cache","enabled":false,"toggleable":true}`, w.Body.String())
+			}
+		})
+	}
+}
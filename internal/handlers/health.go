@@ -5,24 +5,110 @@ import (
 	"net/http"
 	"time"
 
+	"orders/internal/repositories/mongodb"
+
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
-// HealthHandler handles the health check endpoint.
+// HealthHandler handles the health and readiness check endpoints.
 type HealthHandler struct {
-	mongoDB *mongo.Database
-	redis   *redis.Client
+	monitor *HealthMonitor
+	pool    *mongodb.PoolMonitor
+	ready   func() bool
+	indexes []IndexChecker
+
+	releaseRetrier Heartbeat
+	staleAfter     time.Duration
+}
+
+// Heartbeat reports when a background loop last ran.
+type Heartbeat interface {
+	LastRun() time.Time
 }
 
-// NewHealthHandler creates a new instance of HealthHandler.
-func NewHealthHandler(mongoDB *mongo.Database, redis *redis.Client) *HealthHandler {
+// IndexChecker reports the missing indexes of a MongoDB repository.
+type IndexChecker interface {
+	EnsureIndexes(ctx context.Context) (mongodb.IndexReport, error)
+}
+
+// NewHealthHandler creates a new instance of HealthHandler. monitor holds
+// the last checks of the dependencies. pool reports the MongoDB connection
+// pool usage in verbose checks and may be nil. ready reports whether the
+// service accepts traffic; it turns false once the service starts shutting
+// down.
+func NewHealthHandler(monitor *HealthMonitor, pool *mongodb.PoolMonitor, ready func() bool) *HealthHandler {
 	return &HealthHandler{
-		mongoDB: mongoDB,
-		redis:   redis,
+		monitor: monitor,
+		pool:    pool,
+		ready:   ready,
+	}
+}
+
+// SetIndexCheckers sets the repositories whose indexes verbose readiness
+// checks report.
+func (h *HealthHandler) SetIndexCheckers(checkers ...IndexChecker) {
+	h.indexes = checkers
+}
+
+// SetReleaseRetrier makes readiness fail once the inventory release retrier
+// has not run for staleAfter, so a retrier that died is noticed. Order events
+// have no background dispatcher to watch, as they are published while the
+// request is served.
+func (h *HealthHandler) SetReleaseRetrier(retrier Heartbeat, staleAfter time.Duration) {
+	h.releaseRetrier = retrier
+	h.staleAfter = staleAfter
+}
+
+// ReadinessResponse represents the response structure for readiness checks.
+type ReadinessResponse struct {
+	Status string `json:"status"`
+	// ReleaseRetrierLastRun is reported when the inventory release retrier is
+	// watched
+	ReleaseRetrierLastRun *time.Time `json:"releaseRetrierLastRun,omitempty"`
+	// Indexes is only reported by verbose checks
+	Indexes []mongodb.IndexReport `json:"indexes,omitempty"`
+}
+
+// CheckReadiness reports whether the service accepts traffic. Returns HTTP 200
+// while it does and HTTP 503 once it is shutting down, so load balancers stop
+// routing to it before connections are refused, or while the inventory
+// release retrier has stalled. With verbose=true it also lists the MongoDB indexes
+// and reports the missing ones.
+func (h *HealthHandler) CheckReadiness(c *gin.Context) {
+	if !h.ready() {
+		c.JSON(http.StatusServiceUnavailable, ReadinessResponse{Status: "shutting down"})
+		return
+	}
+
+	response := ReadinessResponse{Status: "ready"}
+	status := http.StatusOK
+	if h.releaseRetrier != nil {
+		lastRun := h.releaseRetrier.LastRun()
+		response.ReleaseRetrierLastRun = &lastRun
+		if time.Since(lastRun) > h.staleAfter {
+			response.Status = "inventory release retrier stalled"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	if c.Query("verbose") == "true" {
+		response.Indexes = h.checkIndexes(c.Request.Context())
+	}
+	c.JSON(status, response)
+}
+
+func (h *HealthHandler) checkIndexes(ctx context.Context) []mongodb.IndexReport {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	reports := make([]mongodb.IndexReport, 0, len(h.indexes))
+	for _, checker := range h.indexes {
+		report, err := checker.EnsureIndexes(ctx)
+		if err != nil {
+			report.Error = "failed to list indexes"
+		}
+		reports = append(reports, report)
 	}
+	return reports
 }
 
 // HealthResponse represents the response structure for health checks.
@@ -30,35 +116,31 @@ type HealthResponse struct {
 	Status       string            `json:"status"`
 	Timestamp    time.Time         `json:"timestamp"`
 	Dependencies map[string]string `json:"dependencies"`
+	// MongoDBPool and Checks are only reported by verbose checks
+	MongoDBPool *mongodb.PoolStats          `json:"mongodbPool,omitempty"`
+	Checks      map[string]DependencyHealth `json:"checks,omitempty"`
 }
 
-// CheckHealth checks the status of the service and its dependencies (MongoDB, Redis, Kafka).
-// Returns HTTP 200 if all dependencies are healthy, otherwise HTTP 503.
+// CheckHealth reports the status of the service and its dependencies
+// (MongoDB, Redis, Kafka) from their last background checks, so it adds no
+// load to them. Returns HTTP 200 if all dependencies are healthy, otherwise
+// HTTP 503; a dependency is only unhealthy after several failed checks in a
+// row. With verbose=true it also reports the MongoDB connection pool usage
+// and the last checks and error of each dependency.
 func (h *HealthHandler) CheckHealth(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	checks := h.monitor.Dependencies()
 
-	dependencies := make(map[string]string)
+	dependencies := make(map[string]string, len(checks)+1)
 	allHealthy := true
-
-	// Check MongoDB connection
-	mongoStatus := "connected"
-	if err := h.mongoDB.Client().Ping(ctx, readpref.Primary()); err != nil {
-		mongoStatus = "disconnected"
-		allHealthy = false
+	for name, check := range checks {
+		dependencies[name] = check.Status
+		if check.Status == DependencyDisconnected {
+			allHealthy = false
+		}
 	}
-	dependencies["mongodb"] = mongoStatus
-
-	// Check Redis connection
-	redisStatus := "connected"
-	if err := h.redis.Ping(ctx).Err(); err != nil {
-		redisStatus = "disconnected"
-		allHealthy = false
-	}
-	dependencies["redis"] = redisStatus
 
 	// Kafka status (simplified - in production verify actual connection)
-	dependencies["kafka"] = "connected"
+	dependencies["kafka"] = DependencyConnected
 
 	status := "healthy"
 	statusCode := http.StatusOK
@@ -72,6 +154,13 @@ func (h *HealthHandler) CheckHealth(c *gin.Context) {
 		Timestamp:    time.Now(),
 		Dependencies: dependencies,
 	}
+	if c.Query("verbose") == "true" {
+		if h.pool != nil {
+			stats := h.pool.Stats()
+			response.MongoDBPool = &stats
+		}
+		response.Checks = checks
+	}
 
 	c.JSON(statusCode, response)
 }
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Dependency statuses reported by health checks.
+const (
+	DependencyConnected    = "connected"
+	DependencyDisconnected = "disconnected"
+	DependencyDisabled     = "disabled"
+	// DependencyUnknown is reported until the dependency is checked once.
+	DependencyUnknown = "unknown"
+)
+
+// healthPingTimeout bounds each ping of a dependency.
+const healthPingTimeout = 5 * time.Second
+
+// Pinger checks that a dependency answers.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingFunc adapts a function to a Pinger.
+type PingFunc func(ctx context.Context) error
+
+func (f PingFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+// DependencyCheck names a dependency and how it is pinged. A nil Pinger
+// reports the dependency as disabled.
+type DependencyCheck struct {
+	Name   string
+	Pinger Pinger
+}
+
+// HealthCheckResult is the outcome of one ping of a dependency.
+type HealthCheckResult struct {
+	At        time.Time `json:"at"`
+	Healthy   bool      `json:"healthy"`
+	LatencyMs int64     `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// DependencyHealth is the state of a dependency along with its last checks,
+// oldest first.
+type DependencyHealth struct {
+	Status              string              `json:"status"`
+	ConsecutiveFailures int                 `json:"consecutiveFailures"`
+	LastError           string              `json:"lastError,omitempty"`
+	History             []HealthCheckResult `json:"history"`
+}
+
+type dependencyState struct {
+	check    DependencyCheck
+	history  []HealthCheckResult
+	failures int
+	// lastError is kept after the dependency recovers
+	lastError string
+}
+
+// HealthMonitor pings the dependencies in the background and keeps their
+// last historySize results, so health checks are answered from memory
+// without adding load to the dependencies. A dependency is only reported
+// disconnected after failureThreshold consecutive failed pings, so a single
+// slow ping does not flip the service to unhealthy.
+type HealthMonitor struct {
+	deps             []*dependencyState
+	historySize      int
+	failureThreshold int
+	interval         time.Duration
+	now              func() time.Time
+	mu               sync.RWMutex
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewHealthMonitor creates the monitor of the dependencies. now is the clock
+// the checks are timed with, time.Now when nil.
+func NewHealthMonitor(checks []DependencyCheck, historySize, failureThreshold int, interval time.Duration, now func() time.Time) *HealthMonitor {
+	if now == nil {
+		now = time.Now
+	}
+	deps := make([]*dependencyState, 0, len(checks))
+	for _, check := range checks {
+		deps = append(deps, &dependencyState{check: check})
+	}
+	return &HealthMonitor{
+		deps:             deps,
+		historySize:      historySize,
+		failureThreshold: failureThreshold,
+		interval:         interval,
+		now:              now,
+		stop:             make(chan struct{}),
+	}
+}
+
+// Start checks the dependencies once, so the first health check has a
+// result, and then every interval until Stop is called.
+func (m *HealthMonitor) Start() {
+	m.Refresh(context.Background())
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				m.Refresh(context.Background())
+			}
+		}
+	}()
+}
+
+// Stop stops checking the dependencies.
+func (m *HealthMonitor) Stop() {
+	close(m.stop)
+	m.wg.Wait()
+}
+
+// Refresh pings every enabled dependency and records the results.
+func (m *HealthMonitor) Refresh(ctx context.Context) {
+	for _, dep := range m.deps {
+		if dep.check.Pinger == nil {
+			continue
+		}
+		result := m.ping(ctx, dep.check.Pinger)
+
+		m.mu.Lock()
+		dep.history = append(dep.history, result)
+		if len(dep.history) > m.historySize {
+			dep.history = dep.history[len(dep.history)-m.historySize:]
+		}
+		if result.Healthy {
+			dep.failures = 0
+		} else {
+			dep.failures++
+			dep.lastError = result.Error
+		}
+		m.mu.Unlock()
+	}
+}
+
+func (m *HealthMonitor) ping(ctx context.Context, pinger Pinger) HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+	defer cancel()
+
+	start := m.now()
+	err := pinger.Ping(ctx)
+	result := HealthCheckResult{
+		At:        start,
+		Healthy:   err == nil,
+		LatencyMs: m.now().Sub(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Dependencies returns the state of every dependency by name.
+func (m *HealthMonitor) Dependencies() map[string]DependencyHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	health := make(map[string]DependencyHealth, len(m.deps))
+	for _, dep := range m.deps {
+		health[dep.check.Name] = DependencyHealth{
+			Status:              m.status(dep),
+			ConsecutiveFailures: dep.failures,
+			LastError:           dep.lastError,
+			History:             append([]HealthCheckResult{}, dep.history...),
+		}
+	}
+	return health
+}
+
+func (m *HealthMonitor) status(dep *dependencyState) string {
+	switch {
+	case dep.check.Pinger == nil:
+		return DependencyDisabled
+	case len(dep.history) == 0:
+		return DependencyUnknown
+	case dep.failures >= m.failureThreshold:
+		return DependencyDisconnected
+	default:
+		return DependencyConnected
+	}
+}
@@ -0,0 +1,182 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"orders/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePinger falla mientras failing sea true y cuenta los pings
+type fakePinger struct {
+	failing atomic.Bool
+	pings   atomic.Int32
+}
+
+func (p *fakePinger) Ping(ctx context.Context) error {
+	p.pings.Add(1)
+	if p.failing.Load() {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+// fakeClock avanza un segundo en cada lectura
+func fakeClock() func() time.Time {
+	now := time.Date(2025, 10, 25, 10, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+// setupHealthRouter expone /health con MongoDB comprobado y Redis deshabilitado
+func setupHealthRouter(historySize, threshold int) (*gin.Engine, *handlers.HealthMonitor, *fakePinger) {
+	gin.SetMode(gin.TestMode)
+	mongo := &fakePinger{}
+	monitor := handlers.NewHealthMonitor([]handlers.DependencyCheck{
+		{Name: "mongodb", Pinger: mongo},
+		{Name: "redis"},
+	}, historySize, threshold, time.Hour, fakeClock())
+	handler := handlers.NewHealthHandler(monitor, nil, func() bool { return true })
+
+	router := gin.New()
+	router.GET("/health", handler.CheckHealth)
+	return router, monitor, mongo
+}
+
+func getHealth(t *testing.T, router *gin.Engine, path string) (int, handlers.HealthResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var response handlers.HealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestHealthHandler_CheckHealth_Cached(t *testing.T) {
+	router, monitor, mongo := setupHealthRouter(5, 2)
+	monitor.Refresh(context.Background())
+
+	code, response := getHealth(t, router, "/health")
+	getHealth(t, router, "/health")
+
+	// Las peticiones no vuelven a comprobar las dependencias
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int32(1), mongo.pings.Load())
+	assert.Equal(t, "healthy", response.Status)
+	assert.Equal(t, map[string]string{"mongodb": "connected", "redis": "disabled", "kafka": "connected"}, response.Dependencies)
+	assert.Nil(t, response.Checks)
+}
+
+func TestHealthHandler_CheckHealth_FlapSuppression(t *testing.T) {
+	router, monitor, mongo := setupHealthRouter(5, 3)
+	monitor.Refresh(context.Background())
+	mongo.failing.Store(true)
+
+	// Dos fallos seguidos no bastan para reportar la caída
+	for i := 0; i < 2; i++ {
+		monitor.Refresh(context.Background())
+		code, response := getHealth(t, router, "/health")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "connected", response.Dependencies["mongodb"])
+	}
+
+	monitor.Refresh(context.Background())
+	code, response := getHealth(t, router, "/health")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unhealthy", response.Status)
+	assert.Equal(t, "disconnected", response.Dependencies["mongodb"])
+
+	// Un ping correcto la recupera
+	mongo.failing.Store(false)
+	monitor.Refresh(context.Background())
+	code, _ = getHealth(t, router, "/health")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestHealthHandler_CheckHealth_Verbose(t *testing.T) {
+	router, monitor, mongo := setupHealthRouter(2, 1)
+	monitor.Refresh(context.Background())
+	mongo.failing.Store(true)
+	monitor.Refresh(context.Background())
+	mongo.failing.Store(false)
+	monitor.Refresh(context.Background())
+
+	_, response := getHealth(t, router, "/health?verbose=true")
+
+	// Solo se guardan las dos últimas comprobaciones y el último error
+	check := response.Checks["mongodb"]
+	assert.Equal(t, "connected", check.Status)
+	assert.Equal(t, 0, check.ConsecutiveFailures)
+	assert.Equal(t, "connection refused", check.LastError)
+	require.Len(t, check.History, 2)
+	assert.False(t, check.History[0].Healthy)
+	assert.True(t, check.History[1].Healthy)
+	assert.True(t, check.History[0].At.Before(check.History[1].At))
+	assert.Equal(t, int64(1000), check.History[1].LatencyMs)
+	assert.Equal(t, "disabled", response.Checks["redis"].Status)
+	assert.Empty(t, response.Checks["redis"].History)
+}
+
+func TestHealthMonitor_Start(t *testing.T) {
+	mongo := &fakePinger{}
+	monitor := handlers.NewHealthMonitor([]handlers.DependencyCheck{{Name: "mongodb", Pinger: mongo}},
+		5, 1, 10*time.Millisecond, nil)
+
+	// La primera comprobación es síncrona; las demás, periódicas
+	monitor.Start()
+	assert.Equal(t, "connected", monitor.Dependencies()["mongodb"].Status)
+	assert.Eventually(t, func() bool { return mongo.pings.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	monitor.Stop()
+}
+
+// fakeHeartbeat informa de la última vuelta del reintento de liberaciones
+type fakeHeartbeat struct {
+	lastRun atomic.Int64
+}
+
+func (h *fakeHeartbeat) LastRun() time.Time {
+	return time.Unix(0, h.lastRun.Load())
+}
+
+func TestHealthHandler_CheckReadiness_ReleaseRetrier(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	retrier := &fakeHeartbeat{}
+	retrier.lastRun.Store(time.Now().UnixNano())
+	handler := handlers.NewHealthHandler(nil, nil, func() bool { return true })
+	handler.SetReleaseRetrier(retrier, time.Minute)
+	router := gin.New()
+	router.GET("/ready", handler.CheckReadiness)
+
+	getReady := func() (int, handlers.ReadinessResponse) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		var response handlers.ReadinessResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	code, response := getReady()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", response.Status)
+	require.NotNil(t, response.ReleaseRetrierLastRun)
+
+	// Un latido antiguo deja el servicio sin estar listo
+	stale := time.Now().Add(-2 * time.Minute)
+	retrier.lastRun.Store(stale.UnixNano())
+	code, response = getReady()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "inventory release retrier stalled", response.Status)
+	assert.WithinDuration(t, stale, *response.ReleaseRetrierLastRun, time.Millisecond)
+}
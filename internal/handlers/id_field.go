@@ -0,0 +1,17 @@
+package handlers
+
+// JSON fields the order ID can be served as.
+const (
+	IDFieldOrderID = "orderId"
+	IDFieldID      = "id"
+)
+
+// setOrderID stores id in the field of a response the configured ID field
+// is served as: orderID, the default, or id.
+func setOrderID(orderID, idFieldValue *string, id, idField string) {
+	if idField == IDFieldID {
+		*idFieldValue = id
+		return
+	}
+	*orderID = id
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// link is a hypermedia link to a resource.
+type link struct {
+	Href string `json:"href"`
+}
+
+// orderLinks returns the path of the order created by the request and its
+// links: self, the order itself, and status, where its status is updated.
+// They are derived from the host and path of the request, so they hold behind
+// any prefix the API is served under.
+func orderLinks(c *gin.Context, orderID string) (string, map[string]link) {
+	path := strings.TrimSuffix(c.Request.URL.Path, "/") + "/" + orderID
+	self := requestScheme(c) + "://" + c.Request.Host + path
+	return path, map[string]link{
+		"self":   {Href: self},
+		"status": {Href: self + "/status"},
+	}
+}
+
+// requestScheme returns the scheme the client used, as forwarded by a proxy
+// terminating TLS.
+func requestScheme(c *gin.Context) string {
+	if c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https") {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"orders/internal/codec"
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DefaultStreamThreshold is the largest limit served from a buffered page,
+// larger pages are streamed.
+const DefaultStreamThreshold = 50
+
+// SetStreamThreshold changes the largest limit served from a buffered page.
+// Listings with a larger limit are written order by order as they are read
+// from the database; 0 always buffers them.
+func (h *OrderHandler) SetStreamThreshold(threshold int) {
+	h.streamThreshold = threshold
+}
+
+// streams reports whether a listing with the limit is streamed.
+func (h *OrderHandler) streams(limit int) bool {
+	return h.streamThreshold > 0 && limit > h.streamThreshold
+}
+
+// listStream writes a ListOrdersResponse as the orders are read, byte for
+// byte as the buffered response. Nothing is written until the first order
+// arrives, so errors before it are still answered with their status.
+type listStream struct {
+	w      gin.ResponseWriter
+	buf    bytes.Buffer
+	enc    codec.Encoder
+	render func(*models.Order) *OrderResponse
+	count  int
+	// begin sets the headers and status of the response
+	begin func()
+}
+
+func newListStream(c *gin.Context, render func(*models.Order) *OrderResponse, begin func()) *listStream {
+	s := &listStream{w: c.Writer, render: render, begin: begin}
+	s.enc = codec.NewEncoder(&s.buf)
+	return s
+}
+
+// write encodes the order into the reused buffer and writes it, opening the
+// response before the first one.
+func (s *listStream) write(order *models.Order) error {
+	s.buf.Reset()
+	if s.count == 0 {
+		s.buf.WriteString(`{"orders":[`)
+	} else {
+		s.buf.WriteByte(',')
+	}
+	if err := s.enc.Encode(s.render(order)); err != nil {
+		return err
+	}
+	if s.count == 0 {
+		s.begin()
+	}
+	s.count++
+	// The encoder ends every value with a newline the buffered response lacks
+	_, err := s.w.Write(bytes.TrimSuffix(s.buf.Bytes(), []byte("\n")))
+	return err
+}
+
+// close writes the end of the response. An empty page is served as null,
+// as the buffered response serves the page the repository returns.
+func (s *listStream) close(pagination PaginationResponse) error {
+	s.buf.Reset()
+	if s.count == 0 {
+		s.begin()
+		s.buf.WriteString(`{"orders":null`)
+	} else {
+		s.buf.WriteByte(']')
+	}
+	s.buf.WriteString(`,"pagination":`)
+	if err := s.enc.Encode(pagination); err != nil {
+		return err
+	}
+	s.buf.Truncate(s.buf.Len() - 1)
+	s.buf.WriteByte('}')
+	_, err := s.w.Write(s.buf.Bytes())
+	return err
+}
+
+// streamOrders serves the listing streamed from the service.
+func (h *OrderHandler) streamOrders(c *gin.Context, query ListOrdersQuery, filter services.ListOrdersFilter, version *models.ListVersion, requestID string) {
+	stream := newListStream(c, h.render, func() {
+		if version != nil {
+			c.Header("Last-Modified", version.LastModified().Format(http.TimeFormat))
+		}
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		c.Status(http.StatusOK)
+	})
+
+	total, svcErr := h.service.StreamOrders(requestContext(c), filter, query.Page, *query.Limit, stream.write)
+	if svcErr != nil {
+		h.logger.Error("Failed to stream orders", zap.Error(svcErr), zap.String("requestId", requestID), zap.Int("written", stream.count))
+		if stream.count == 0 {
+			writeServiceError(c, svcErr, "Internal server error - Failed to list orders")
+			return
+		}
+		// The status is already sent, the client sees a truncated body
+		c.Abort()
+		return
+	}
+
+	pagination := newPagination(query.Page, *query.Limit, total)
+	pagination.MaxPage = query.maxPage
+	pagination.RequestedLimit = query.requestedLimit
+	if err := stream.close(pagination); err != nil {
+		h.logger.Warn("Failed to finish streamed orders", zap.Error(err), zap.String("requestId", requestID))
+	}
+}
@@ -0,0 +1,188 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"orders/internal/handlers"
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// pageOfOrders devuelve n pedidos con varias líneas, notas con caracteres que
+// JSON escapa y campos opcionales
+func pageOfOrders(n int) []*models.Order {
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	orders := make([]*models.Order, n)
+	for i := range orders {
+		items := make([]models.OrderItem, 5)
+		for j := range items {
+			items[j] = models.OrderItem{SKU: fmt.Sprintf("SKU-%03d", j), Quantity: j + 1, Price: 9.99 * float64(j+1), WeightGrams: 250}
+		}
+		orders[i] = &models.Order{
+			ID:          fmt.Sprintf("order-%03d", i),
+			CustomerID:  "customer-1",
+			Status:      models.StatusNew,
+			Items:       items,
+			TotalAmount: 149.85,
+			Notes:       `Dejar en "portería" <b>antes</b> de las 10 & llamar`,
+			Tags:        []string{"vip", "express"},
+			CreatedAt:   createdAt.Add(time.Duration(i) * time.Minute),
+			UpdatedAt:   createdAt.Add(time.Duration(i) * time.Minute),
+			Version:     1,
+		}
+	}
+	return orders
+}
+
+// newListHandler crea un handler que lista los pedidos dados con el umbral de
+// streaming indicado; 0 los sirve siempre en memoria
+func newListHandler(idField string, threshold int, orders []*models.Order, version *models.ListVersion) *handlers.OrderHandler {
+	mockService := new(MockOrderService)
+	mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return(version)
+	mockService.On("ListOrders", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(orders, int64(250), (*services.ServiceError)(nil))
+	mockService.On("StreamOrders", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(orders, int64(250), (*services.ServiceError)(nil))
+
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, idField)
+	handler.SetStreamThreshold(threshold)
+	return handler
+}
+
+// listOrders sirve la URL con el handler
+func listOrders(handler *handlers.OrderHandler, url string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	serveList(handler, w, url)
+	return w
+}
+
+func serveList(handler *handlers.OrderHandler, w http.ResponseWriter, url string) {
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, url, nil)
+	handler.ListOrders(c)
+}
+
+// discardResponse descarta el cuerpo como una conexión, para que el benchmark
+// mida solo la memoria que reserva el handler
+type discardResponse struct {
+	header http.Header
+}
+
+func (w *discardResponse) Header() http.Header         { return w.header }
+func (w *discardResponse) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardResponse) WriteHeader(int)             {}
+
+func TestOrderHandler_ListOrders_StreamMatchesBuffered(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	version := &models.ListVersion{LastWrite: time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC), ObservedAt: time.Date(2025, 3, 1, 9, 0, 5, 0, time.UTC)}
+
+	tests := []struct {
+		name    string
+		idField string
+		orders  []*models.Order
+		version *models.ListVersion
+	}{
+		{"Full page", handlers.IDFieldOrderID, pageOfOrders(100), nil},
+		{"Single order", handlers.IDFieldOrderID, pageOfOrders(1), nil},
+		{"ID served as id", handlers.IDFieldID, pageOfOrders(100), nil},
+		{"With Last-Modified", handlers.IDFieldOrderID, pageOfOrders(3), version},
+		// El repositorio devuelve nil para las páginas vacías
+		{"Empty page", handlers.IDFieldOrderID, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := "/orders?page=2&limit=100"
+			buffered := listOrders(newListHandler(tt.idField, 0, tt.orders, tt.version), url)
+			streamed := listOrders(newListHandler(tt.idField, handlers.DefaultStreamThreshold, tt.orders, tt.version), url)
+
+			require.Equal(t, http.StatusOK, buffered.Code)
+			assert.Equal(t, http.StatusOK, streamed.Code)
+			assert.Equal(t, buffered.Body.String(), streamed.Body.String())
+			assert.Equal(t, buffered.Header().Get("Content-Type"), streamed.Header().Get("Content-Type"))
+			assert.Equal(t, buffered.Header().Get("Last-Modified"), streamed.Header().Get("Last-Modified"))
+			assert.True(t, json.Valid(streamed.Body.Bytes()))
+		})
+	}
+}
+
+func TestOrderHandler_ListOrders_StreamErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Before the first order", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+		mockService.On("StreamOrders", mock.Anything, mock.Anything, 1, 100).Return([]*models.Order{}, int64(0), &services.ServiceError{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to count orders",
+		})
+
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/orders?limit=100", nil)
+
+		handler.ListOrders(c)
+
+		// Aún no se ha escrito nada, así que se responde con el error
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "Failed to list orders")
+		mockService.AssertNotCalled(t, "ListOrders")
+	})
+
+	t.Run("After the first order", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+		mockService.On("StreamOrders", mock.Anything, mock.Anything, 1, 100).Return(pageOfOrders(2), int64(0), &services.ServiceError{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to decode order",
+			Cause:   []interface{}{"cursor killed"},
+		})
+
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/orders?limit=100", nil)
+
+		handler.ListOrders(c)
+
+		// El estado ya se envió: el cliente recibe un cuerpo truncado
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.False(t, json.Valid(w.Body.Bytes()))
+		assert.NotContains(t, w.Body.String(), "pagination")
+	})
+}
+
+// BenchmarkListOrders compara las reservas de memoria de una página de 100
+// pedidos servida en memoria y en streaming. En memoria se reserva la
+// respuesta entera; en streaming solo el pedido que se está escribiendo
+func BenchmarkListOrders(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	orders := pageOfOrders(100)
+
+	for _, bb := range []struct {
+		name      string
+		threshold int
+	}{
+		{"Buffered", 0},
+		{"Streamed", handlers.DefaultStreamThreshold},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			handler := newListHandler(handlers.IDFieldOrderID, bb.threshold, orders, nil)
+			w := &discardResponse{header: http.Header{}}
+			b.ReportAllocs()
+			for b.Loop() {
+				serveList(handler, w, "/orders?limit=100")
+			}
+		})
+	}
+}
@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"math"
 	"net/http"
+	"orders/internal/middlewares"
 	"orders/internal/models"
+	"orders/internal/repositories"
 	"orders/internal/services"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -18,164 +24,483 @@ type ErrorResponse struct {
 }
 
 type OrderHandler struct {
-	service         services.OrderService
-	validator       *validator.Validate
-	logger          *zap.Logger
-	maxPageSize     int
+	service   services.OrderService
+	validator *validator.Validate
+	logger    *zap.Logger
+	limits    atomic.Pointer[pageLimits]
+	idField   string
+	// currency is the ISO 4217 currency order amounts are in
+	currency string
+	// returnWindow is how long after delivery a return is allowed, 0 always
+	returnWindow time.Duration
+	// streamThreshold is the largest limit served from a buffered page
+	streamThreshold int
+}
+
+// pageLimits bound the listings. They are replaced as a whole, so a listing
+// never sees a mix of old and new limits.
+type pageLimits struct {
 	defaultPageSize int
+	maxPageSize     int
+	maxScanWindow   int
 }
 
-func NewOrderHandler(service services.OrderService, logger *zap.Logger, defaultPageSize, maxPageSize int) *OrderHandler {
-	return &OrderHandler{
-		service:         service,
-		validator:       validator.New(),
-		logger:          logger,
-		maxPageSize:     maxPageSize,
-		defaultPageSize: defaultPageSize,
+// NewOrderHandler creates the order handler. maxScanWindow bounds how deep
+// listings can page, as page*limit; 0 disables the limit. Orders are served
+// with their ID under idField, IDFieldOrderID or IDFieldID.
+func NewOrderHandler(service services.OrderService, logger *zap.Logger, defaultPageSize, maxPageSize, maxScanWindow int, idField string) *OrderHandler {
+	h := &OrderHandler{
+		service:   service,
+		validator: validator.New(),
+		logger:    logger,
+		idField:   idField,
+		currency:  models.DefaultCurrency,
+
+		streamThreshold: DefaultStreamThreshold,
 	}
+	h.SetPageLimits(defaultPageSize, maxPageSize, maxScanWindow)
+	return h
+}
+
+// SetCurrency sets the ISO 4217 currency order amounts are in, which
+// totalAmountMinor is counted in minor units of. It is USD by default.
+func (h *OrderHandler) SetCurrency(currency string) {
+	h.currency = currency
+}
+
+// SetReturnWindow sets how long after delivery orders list RETURN_REQUESTED
+// among their allowed transitions, which should match the window the service
+// enforces. Zero lists it at any time.
+func (h *OrderHandler) SetReturnWindow(window time.Duration) {
+	h.returnWindow = window
+}
+
+// SetPageLimits changes the page sizes and scan window of the listings served
+// from now on.
+func (h *OrderHandler) SetPageLimits(defaultPageSize, maxPageSize, maxScanWindow int) {
+	h.limits.Store(&pageLimits{
+		defaultPageSize: defaultPageSize,
+		maxPageSize:     maxPageSize,
+		maxScanWindow:   maxScanWindow,
+	})
 }
 
 type CreateOrderRequest struct {
-	CustomerID string             `json:"customerId" binding:"required,uuid"`
-	Items      []models.OrderItem `json:"items" binding:"required,min=1,max=100,dive"`
+	CustomerID         string             `json:"customerId" binding:"required,customerid"`
+	Items              []models.OrderItem `json:"items" binding:"required,min=1,max=100,dive"`
+	Notes              string             `json:"notes,omitempty"`
+	PromisedDeliveryAt *time.Time         `json:"promisedDeliveryAt,omitempty"`
+	Priority           string             `json:"priority,omitempty"`
+	Tags               []string           `json:"tags,omitempty"`
+	Channel            string             `json:"channel,omitempty"`
+	ClientMetadata     map[string]string  `json:"clientMetadata,omitempty"`
+	Customer           *CustomerContact   `json:"customer,omitempty"`
+	// InitialStatus creates the order in IN_PROGRESS instead of NEW, when the
+	// deployment allows it.
+	InitialStatus string `json:"initialStatus,omitempty" binding:"omitempty,oneof=NEW IN_PROGRESS"`
+	// TotalAmount is optional; when given it must match the computed total.
+	TotalAmount *float64 `json:"totalAmount,omitempty" binding:"omitempty,gte=0"`
+}
+
+// CustomerContact is the optional contact data of the customer placing an
+// order. Phone numbers use the E.164 format.
+type CustomerContact struct {
+	Email string `json:"email,omitempty" binding:"omitempty,email,max=254"`
+	Name  string `json:"name,omitempty" binding:"omitempty,max=200"`
+	Phone string `json:"phone,omitempty" binding:"omitempty,e164"`
+}
+
+type AddNoteRequest struct {
+	Author string `json:"author" binding:"required,max=100"`
+	Text   string `json:"text" binding:"required"`
 }
 
 type UpdateStatusRequest struct {
-	Status string `json:"status" binding:"required,oneof=NEW IN_PROGRESS DELIVERED CANCELLED"`
+	Status string `json:"status" binding:"required,oneof=NEW IN_PROGRESS DELIVERED CANCELLED RETURNED"`
+}
+
+// ForceStatusRequest forces an order into a status. The reason is recorded
+// along with the admin doing it, taken from the credentials of the request.
+type ForceStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=NEW IN_PROGRESS DELIVERED CANCELLED RETURNED"`
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+type UpdatePriorityRequest struct {
+	Priority string `json:"priority" binding:"required"`
 }
 
+type UpdateTagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+type DeliveryItemRequest struct {
+	SKU      string `json:"sku" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+}
+
+type RecordDeliveryRequest struct {
+	Items []DeliveryItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type ReturnItemRequest struct {
+	SKU      string `json:"sku" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1"`
+}
+
+type ReturnOrderRequest struct {
+	Reason string              `json:"reason" binding:"required,max=500"`
+	Items  []ReturnItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type ReplayEventsResponse struct {
+	OrderID  string `json:"orderId"`
+	Replayed int    `json:"replayed"`
+}
+
+// PaginationResponse describes the page returned. Total and TotalPages are -1
+// when the listing was not counted. MaxPage is the last page reachable within
+// the scan window at this limit, omitted when pages are not capped.
+// RequestedLimit is the limit asked for when the page was served with
+// another one, omitted otherwise.
 type PaginationResponse struct {
-	Page       int   `json:"page"`
-	Limit      int   `json:"limit"`
-	Total      int64 `json:"total"`
-	TotalPages int   `json:"totalPages"`
+	Page           int   `json:"page"`
+	Limit          int   `json:"limit"`
+	Total          int64 `json:"total"`
+	TotalPages     int   `json:"totalPages"`
+	MaxPage        int   `json:"maxPage,omitempty"`
+	RequestedLimit *int  `json:"requestedLimit,omitempty"`
 }
 
 type ListOrdersResponse struct {
-	Orders     []*models.Order    `json:"orders"`
+	Orders     []*OrderResponse   `json:"orders"`
+	Pagination PaginationResponse `json:"pagination"`
+}
+
+// maxExpandedEvents caps the events joined into an order by expand=events.
+const maxExpandedEvents = 50
+
+// OrderWithEventsResponse is an order with the last events emitted for it.
+type OrderWithEventsResponse struct {
+	*OrderResponse
+	Events []*models.EventRecord `json:"events"`
+}
+
+type StatusGraphResponse struct {
+	Statuses []models.StatusTransitions `json:"statuses"`
+}
+
+type ListNotesResponse struct {
+	Notes      []models.OrderNote `json:"notes"`
 	Pagination PaginationResponse `json:"pagination"`
 }
 
 // CreateOrder godoc
 // @Summary Create a new order
-// @Description Creates a new delivery order
+// @Description Creates a new delivery order, in NEW unless initialStatus names another status the deployment allows orders to start in
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param order body CreateOrderRequest true "Order data"
-// @Success 201 {object} models.Order
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 201 {object} OrderResponse "The created order, with its self and status links under _links"
+// @Header 201 {string} Location "Path of the created order"
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Not enough stock for some SKUs"
+// @Failure 422 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse "Too many orders created by the customer, see Retry-After"
 // @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
 // @Router /api/orders [post]
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	requestID := getRequestID(c)
-	ctx := c.Request.Context()
+	ctx := requestContext(c)
 
 	var req CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Warn("Invalid request body", zap.Error(err), zap.String("requestId", requestID))
+		if field := invalidUUIDField(err); field != "" {
+			writeInvalidUUID(c, field)
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
-	order, err := h.service.CreateOrder(ctx, req.CustomerID, req.Items)
-	if err != nil {
-		h.logger.Error("Failed to create order", zap.String("requestId", requestID))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	// Clients identified by their API key default to the channel of the key
+	channel := models.OrderChannel(req.Channel)
+	if channel == "" {
+		if client := c.GetString(middlewares.ClientNameKey); client != "" {
+			channel = models.ChannelForClient(client)
+		}
+	}
+
+	var customer *models.CustomerSnapshot
+	if req.Customer != nil {
+		customer = &models.CustomerSnapshot{
+			Email: req.Customer.Email,
+			Name:  strings.TrimSpace(req.Customer.Name),
+			Phone: req.Customer.Phone,
+		}
+	}
+
+	order, svcErr := h.service.CreateOrder(ctx, services.CreateOrderInput{
+		CustomerID:         normalizeCustomerID(req.CustomerID),
+		Items:              req.Items,
+		Notes:              req.Notes,
+		PromisedDeliveryAt: req.PromisedDeliveryAt,
+		Priority:           models.OrderPriority(req.Priority),
+		Tags:               req.Tags,
+		Channel:            channel,
+		ClientMetadata:     req.ClientMetadata,
+		Customer:           customer,
+		InitialStatus:      models.OrderStatus(req.InitialStatus),
+		TotalAmount:        req.TotalAmount,
+	})
+	if svcErr != nil {
+		h.logger.Error("Failed to create order", zap.Error(svcErr), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error")
 		return
 	}
 
-	c.JSON(http.StatusCreated, order)
+	location, links := orderLinks(c, order.ID)
+	c.Header("Location", location)
+	c.JSON(http.StatusCreated, h.renderWithLinks(order, links))
 }
 
 // GetOrder godoc
 // @Summary Get order by ID
-// @Description Retrieves a specific order by its ID
+// @Description Retrieves a specific order by its ID. Soft-deleted orders are not found, except for admins who get 410 Gone. Authenticated customers only read their own orders.
 // @Tags orders
 // @Produce json
 // @Param id path string true "Order ID"
-// @Success 200 {object} models.Order
+// @Param expand query string false "Join related data into the order: events adds its last 50 events" Enums(events)
+// @Param X-Admin-Key header string false "Admin API key"
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse "The order belongs to another customer"
 // @Failure 404 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
 // @Router /api/orders/{id} [get]
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	requestID := getRequestID(c)
-	ctx := c.Request.Context()
-	orderID := c.Param("id")
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
+		return
+	}
+
+	expandEvents := false
+	if expand := c.Query("expand"); expand != "" {
+		for _, field := range strings.Split(expand, ",") {
+			if strings.TrimSpace(field) != "events" {
+				writeQueryError(c, []middlewares.FieldError{{Field: "expand", Message: "must be one of events"}})
+				return
+			}
+			expandEvents = true
+		}
+	}
+
+	order, svcErr := h.service.GetOrderByID(ctx, orderID)
+	if svcErr != nil {
+		h.logger.Error("Failed to get order", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to get order")
+		return
+	}
+	if !canReadCustomer(c, order.CustomerID) {
+		writeAccessDenied(c)
+		return
+	}
+
+	if !expandEvents {
+		c.JSON(http.StatusOK, h.render(order))
+		return
+	}
 
-	if orderID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Order ID is required"})
+	events, svcErr := h.service.ListOrderEvents(ctx, orderID, maxExpandedEvents)
+	if svcErr != nil {
+		h.logger.Error("Failed to list order events", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to list order events")
 		return
 	}
 
-	order, err := h.service.GetOrderByID(ctx, orderID)
-	if err != nil {
-		h.logger.Error("Failed to get order", zap.Error(err), zap.String("orderId", orderID), zap.String("requestId", requestID))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error - Failed to get order"})
+	c.JSON(http.StatusOK, OrderWithEventsResponse{OrderResponse: h.render(order), Events: events})
+}
+
+// GetOrderSummary godoc
+// @Summary Get order summary
+// @Description Retrieves the compact projection of an order: ID, customer, status, total and version
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} OrderSummaryResponse
+// @Failure 403 {object} ErrorResponse "The order belongs to another customer"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/orders/{id}/summary [get]
+func (h *OrderHandler) GetOrderSummary(c *gin.Context) {
+	requestID := getRequestID(c)
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, order)
+	summary, svcErr := h.service.GetOrderSummary(ctx, orderID)
+	if svcErr != nil {
+		h.logger.Error("Failed to get order summary", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to get order summary")
+		return
+	}
+	if !canReadCustomer(c, summary.CustomerID) {
+		writeAccessDenied(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, ToOrderSummaryResponse(summary, h.idField, h.currency))
+}
+
+// GetOrderTransitions godoc
+// @Summary Get the next statuses of an order
+// @Description Lists the statuses the order can move to now, so clients can offer only valid changes. A return is only listed within the return window. Authenticated customers only read their own orders.
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} models.StatusTransitions
+// @Failure 403 {object} ErrorResponse "The order belongs to another customer"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/orders/{id}/transitions [get]
+func (h *OrderHandler) GetOrderTransitions(c *gin.Context) {
+	requestID := getRequestID(c)
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeOrderRead(c, orderID, requestID) {
+		return
+	}
+
+	transitions, svcErr := h.service.GetOrderTransitions(ctx, orderID)
+	if svcErr != nil {
+		h.logger.Error("Failed to get order transitions", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to get order transitions")
+		return
+	}
+
+	c.JSON(http.StatusOK, transitions)
+}
+
+// GetOrderStatuses godoc
+// @Summary Get order status graph
+// @Description Lists every order status with the statuses an order can move to from it
+// @Tags orders
+// @Produce json
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} StatusGraphResponse
+// @Router /api/orders/statuses [get]
+func (h *OrderHandler) GetOrderStatuses(c *gin.Context) {
+	c.JSON(http.StatusOK, StatusGraphResponse{Statuses: models.StatusGraph()})
 }
 
 // ListOrders godoc
 // @Summary List orders
-// @Description Lists orders with optional filters and pagination
+// @Description Lists orders with optional filters and pagination. Authenticated customers only list their own orders.
 // @Tags orders
 // @Produce json
-// @Param status query string false "Filter by status"
+// @Param status query string false "Filter by status" Enums(NEW, IN_PROGRESS, PARTIALLY_DELIVERED, DELIVERED, CANCELLED, RETURN_REQUESTED, RETURNED)
 // @Param customerId query string false "Filter by customer ID"
-// @Param page query int false "Page number" default(1)
-// @Param limit query int false "Results per page" default(10)
+// @Param priority query string false "Filter by priority" Enums(LOW, NORMAL, HIGH, URGENT)
+// @Param channel query string false "Filter by channel" Enums(MOBILE, WEB, PARTNER_API)
+// @Param tag query []string false "Only orders carrying all of these tags" collectionFormat(multi)
+// @Param slaBreached query bool false "Filter by whether the promised delivery time was missed"
+// @Param from query string false "Only orders created at or after this time (RFC 3339)"
+// @Param to query string false "Only orders created at or before this time (RFC 3339)"
+// @Param minWeight query int false "Only orders weighing at least this many grams"
+// @Param maxWeight query int false "Only orders weighing at most this many grams"
+// @Param minAmount query number false "Only orders with a total amount of at least this"
+// @Param maxAmount query number false "Only orders with a total amount of at most this"
+// @Param q query string false "Text search over SKUs and notes"
+// @Param sortBy query string false "Sort field, relevance when searching" Enums(createdAt, updatedAt, totalAmount, totalWeightGrams, priority, relevance) default(createdAt)
+// @Param sortDir query string false "Sort direction" Enums(asc, desc) default(desc)
+// @Param page query int false "Page number; page * limit may not exceed the scan window" default(1)
+// @Param limit query int false "Results per page, capped at the maximum page size (X-Pagination-Limit-Adjusted is then true and pagination.requestedLimit holds the limit asked for); larger pages are streamed and end truncated if reading fails midway" default(10)
+// @Param withTotal query bool false "Count the matching orders; when false total and totalPages are -1 and a short page is the last one" default(true)
+// @Param If-Modified-Since header string false "Answer 304 when no order of the listing changed since this time"
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
 // @Success 200 {object} ListOrdersResponse
-// @Failure 400 {object} ErrorResponse
+// @Success 304 "Not modified"
+// @Failure 400 {object} ErrorResponse "Invalid query, or RESULT_WINDOW_EXCEEDED with X-Pagination-Limit-Reached true when page is past maxPage"
+// @Failure 403 {object} ErrorResponse "customerId names another customer than the authenticated one"
 // @Failure 500 {object} ErrorResponse
 // @Router /api/orders [get]
 func (h *OrderHandler) ListOrders(c *gin.Context) {
 	requestID := getRequestID(c)
-	ctx := c.Request.Context()
+	ctx := requestContext(c)
 
-	status := c.Query("status")
-	customerID := c.Query("customerId")
-
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil || page < 1 {
-		page = 1
+	if !checkCustomerIDQuery(c) {
+		return
 	}
-
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(h.defaultPageSize)))
-	if err != nil || limit < 1 {
-		limit = h.defaultPageSize
+	query, fieldErrs := h.bindListOrdersQuery(c)
+	if fieldErrs != nil {
+		writeQueryError(c, fieldErrs)
+		return
 	}
-	if limit > h.maxPageSize {
-		limit = h.maxPageSize
+	if !h.checkScanWindow(c, query) {
+		return
+	}
+
+	// Customers only list their own orders
+	filter := query.Filter()
+	if userID := c.GetString(middlewares.UserIDKey); userID != "" && !middlewares.IsAdmin(c) {
+		userID = normalizeCustomerID(userID)
+		if filter.CustomerID != "" && filter.CustomerID != userID {
+			writeAccessDenied(c)
+			return
+		}
+		filter.CustomerID = userID
 	}
 
-	if status != "" {
-		statusEnum := models.OrderStatus(status)
-		if !statusEnum.IsValid() {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status value"})
+	// The version is read before the orders, so it never covers writes the
+	// listing does not include
+	version := h.service.ListOrdersVersion(ctx, filter)
+	if version != nil {
+		if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !version.ModifiedSince(since) {
+			c.Status(http.StatusNotModified)
 			return
 		}
 	}
 
-	orders, total, err := h.service.ListOrders(ctx, status, customerID, page, limit)
-	if err != nil {
-		h.logger.Error("Failed to list orders", zap.String("requestId", requestID))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error - Failed to list orders"})
+	// Large pages are written as they are read instead of held in memory
+	if h.streams(*query.Limit) {
+		h.streamOrders(c, query, filter, version, requestID)
 		return
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	orders, total, svcErr := h.service.ListOrders(ctx, filter, query.Page, *query.Limit)
+	if svcErr != nil {
+		h.logger.Error("Failed to list orders", zap.Error(svcErr), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to list orders")
+		return
+	}
+
+	if version != nil {
+		c.Header("Last-Modified", version.LastModified().Format(http.TimeFormat))
+	}
 
 	response := ListOrdersResponse{
-		Orders: orders,
-		Pagination: PaginationResponse{
-			Page:       page,
-			Total:      total,
-			TotalPages: totalPages,
-		},
+		Orders:     h.renderAll(orders),
+		Pagination: newPagination(query.Page, *query.Limit, total),
 	}
+	response.Pagination.MaxPage = query.maxPage
+	response.Pagination.RequestedLimit = query.requestedLimit
 
 	c.JSON(http.StatusOK, response)
 }
@@ -188,7 +513,8 @@ func (h *OrderHandler) ListOrders(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Order ID"
 // @Param status body UpdateStatusRequest true "New status"
-// @Success 200 {object} models.Order
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} OrderResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
@@ -196,11 +522,9 @@ func (h *OrderHandler) ListOrders(c *gin.Context) {
 // @Router /api/orders/{id}/status [patch]
 func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	requestID := getRequestID(c)
-	ctx := c.Request.Context()
-	orderID := c.Param("id")
-
-	if orderID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Order ID is required"})
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
 		return
 	}
 
@@ -211,23 +535,472 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	}
 
 	newStatus := models.OrderStatus(req.Status)
-	order, err := h.service.UpdateOrderStatus(ctx, orderID, newStatus)
-	if err != nil {
-		h.logger.Error("Failed to update order status", zap.String("orderId", orderID), zap.String("requestId", requestID))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error - Failed to update order status"})
+	order, svcErr := h.service.UpdateOrderStatus(ctx, orderID, newStatus)
+	if svcErr != nil {
+		h.logger.Error("Failed to update order status", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to update order status")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.render(order))
+}
+
+// UpdateOrderPriority godoc
+// @Summary Update order priority
+// @Description Changes the priority of an order that is not delivered or cancelled yet
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param priority body UpdatePriorityRequest true "New priority"
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/orders/{id}/priority [patch]
+func (h *OrderHandler) UpdateOrderPriority(c *gin.Context) {
+	requestID := getRequestID(c)
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdatePriorityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format or missing required fields"})
+		return
+	}
+
+	order, svcErr := h.service.UpdateOrderPriority(ctx, orderID, models.OrderPriority(req.Priority))
+	if svcErr != nil {
+		h.logger.Error("Failed to update order priority", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to update order priority")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.render(order))
+}
+
+// UpdateOrderTags godoc
+// @Summary Replace order tags
+// @Description Replaces the tags of an order. Tags are lower-cased and deduplicated.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param tags body UpdateTagsRequest true "New tags"
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/orders/{id}/tags [put]
+func (h *OrderHandler) UpdateOrderTags(c *gin.Context) {
+	requestID := getRequestID(c)
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, order)
+	var req UpdateTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format or missing required fields"})
+		return
+	}
+
+	order, svcErr := h.service.UpdateOrderTags(ctx, orderID, req.Tags)
+	if svcErr != nil {
+		h.logger.Error("Failed to update order tags", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to update order tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.render(order))
 }
 
-// Helper function to retrieve request ID from headers or context
+// RecordDelivery godoc
+// @Summary Record a delivery
+// @Description Records the items delivered to the customer. The order becomes PARTIALLY_DELIVERED, or DELIVERED once every item is delivered.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param delivery body RecordDeliveryRequest true "Delivered items"
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/orders/{id}/deliveries [post]
+func (h *OrderHandler) RecordDelivery(c *gin.Context) {
+	requestID := getRequestID(c)
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req RecordDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format or missing required fields"})
+		return
+	}
+
+	items := make([]models.DeliveryItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.DeliveryItem{SKU: item.SKU, Quantity: item.Quantity}
+	}
+
+	order, svcErr := h.service.RecordOrderDelivery(ctx, orderID, items)
+	if svcErr != nil {
+		h.logger.Error("Failed to record order delivery", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to record order delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.render(order))
+}
+
+// ReturnOrder godoc
+// @Summary Request the return of an order
+// @Description Requests the return of some or all of the items of a delivered order
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param return body ReturnOrderRequest true "Return reason and items"
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/orders/{id}/return [post]
+func (h *OrderHandler) ReturnOrder(c *gin.Context) {
+	requestID := getRequestID(c)
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ReturnOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format or missing required fields"})
+		return
+	}
+
+	items := make([]models.ReturnItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.ReturnItem{SKU: item.SKU, Quantity: item.Quantity}
+	}
+
+	order, svcErr := h.service.RequestOrderReturn(ctx, orderID, req.Reason, items)
+	if svcErr != nil {
+		h.logger.Error("Failed to request order return", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to request order return")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.render(order))
+}
+
+// AddOrderNote godoc
+// @Summary Add a note to an order
+// @Description Appends a timestamped free-text note to an order without changing its status
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param note body AddNoteRequest true "Note"
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 201 {object} OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/orders/{id}/notes [post]
+func (h *OrderHandler) AddOrderNote(c *gin.Context) {
+	requestID := getRequestID(c)
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req AddNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format or missing required fields"})
+		return
+	}
+
+	order, svcErr := h.service.AddOrderNote(ctx, orderID, req.Author, req.Text)
+	if svcErr != nil {
+		h.logger.Error("Failed to add order note", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to add order note")
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.render(order))
+}
+
+// ListOrderNotes godoc
+// @Summary List order notes
+// @Description Returns the notes of an order, newest first. Authenticated customers only read their own orders.
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Results per page" default(10)
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} ListNotesResponse
+// @Failure 403 {object} ErrorResponse "The order belongs to another customer"
+// @Failure 404 {object} ErrorResponse
+// @Failure 410 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/orders/{id}/notes [get]
+func (h *OrderHandler) ListOrderNotes(c *gin.Context) {
+	requestID := getRequestID(c)
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
+		return
+	}
+
+	if !h.authorizeOrderRead(c, orderID, requestID) {
+		return
+	}
+
+	page, limit, requestedLimit := h.pageParams(c)
+
+	notes, total, svcErr := h.service.ListOrderNotes(ctx, orderID, page, limit)
+	if svcErr != nil {
+		h.logger.Error("Failed to list order notes", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to list order notes")
+		return
+	}
+
+	pagination := newPagination(page, limit, int64(total))
+	pagination.RequestedLimit = requestedLimit
+	c.JSON(http.StatusOK, ListNotesResponse{
+		Notes:      notes,
+		Pagination: pagination,
+	})
+}
+
+// OrderEventsAction godoc
+// @Summary Replay order events
+// @Description Re-publishes the persisted events of an order, in order and with their original IDs, flagged with a replay header. Requires admin credentials.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} ReplayEventsResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/orders/{id}/events:replay [post]
+func (h *OrderHandler) OrderEventsAction(c *gin.Context) {
+	// The route is registered as /orders/:id/events:action, so the custom
+	// method arrives with its leading colon.
+	if c.Param("action") != ":replay" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown action"})
+		return
+	}
+
+	requestID := getRequestID(c)
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
+		return
+	}
+
+	replayed, svcErr := h.service.ReplayOrderEvents(ctx, orderID)
+	if svcErr != nil {
+		h.logger.Error("Failed to replay order events", zap.Error(svcErr), zap.String("orderId", orderID), zap.Int("replayed", replayed), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to replay order events")
+		return
+	}
+
+	c.JSON(http.StatusOK, ReplayEventsResponse{OrderID: orderID, Replayed: replayed})
+}
+
+// ForceOrderStatus godoc
+// @Summary Force order status
+// @Description Moves an order to a status bypassing the status transition rules, e.g. back to IN_PROGRESS after a mistaken delivery scan. The status changed event is flagged as forced with the reason and the actor, the admin key or user making the request. Requires admin credentials.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param status body ForceStatusRequest true "Status to force and reason"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} OrderResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/admin/orders/{id}/force-status [post]
+func (h *OrderHandler) ForceOrderStatus(c *gin.Context) {
+	requestID := getRequestID(c)
+	ctx := requestContext(c)
+	orderID, ok := orderIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ForceStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format or missing required fields"})
+		return
+	}
+
+	order, svcErr := h.service.ForceOrderStatus(ctx, orderID, models.OrderStatus(req.Status), middlewares.Actor(c), req.Reason)
+	if svcErr != nil {
+		h.logger.Error("Failed to force order status", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to force order status")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.render(order))
+}
+
+// pageParams reads the page and limit query parameters, falling back to the
+// defaults on invalid values and capping the limit at the maximum page size.
+// The limit requested is also returned when it was adjusted, nil otherwise.
+func (h *OrderHandler) pageParams(c *gin.Context) (int, int, *int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limits := h.limits.Load()
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(limits.defaultPageSize)))
+	requested := limit
+	if err != nil || limit < 1 {
+		limit = limits.defaultPageSize
+	}
+	if limit > limits.maxPageSize {
+		limit = limits.maxPageSize
+	}
+
+	if err != nil || limit == requested {
+		return page, limit, nil
+	}
+	limitAdjusted(c)
+	return page, limit, &requested
+}
+
+// limitAdjusted flags a response served with another limit than requested.
+func limitAdjusted(c *gin.Context) {
+	c.Header(PaginationLimitAdjustedHeader, "true")
+}
+
+func newPagination(page, limit int, total int64) PaginationResponse {
+	if total == repositories.UnknownTotal {
+		return PaginationResponse{Page: page, Limit: limit, Total: total, TotalPages: -1}
+	}
+	return PaginationResponse{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	}
+}
+
+// writeServiceError maps a service error to its HTTP status. Server-side
+// failures only expose the given fallback message to the client.
+func writeServiceError(c *gin.Context, err *services.ServiceError, fallbackMessage string) {
+	status := err.Status
+	if status < http.StatusBadRequest {
+		status = http.StatusInternalServerError
+	}
+
+	// Internal details are not exposed, but a timed out request is not an
+	// internal error
+	if status >= http.StatusInternalServerError && status != http.StatusGatewayTimeout {
+		c.JSON(status, gin.H{"error": fallbackMessage})
+		return
+	}
+	if err.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	}
+
+	body := gin.H{"error": err.Message}
+	if err.Code != "" {
+		body["code"] = err.Code
+	}
+	if len(err.Cause) > 0 {
+		body["cause"] = err.Cause
+	}
+	if err.Details != nil {
+		body["details"] = err.Details
+	}
+	c.JSON(status, body)
+}
+
+// Helper function to retrieve request ID from context or headers. The ID set
+// by the RequestID middleware comes first, as the header sent by the client
+// may have been replaced for not having the expected format.
 func getRequestID(c *gin.Context) string {
-	requestID := c.GetHeader("X-Request-ID")
-	if requestID == "" {
-		if id, exists := c.Get("requestId"); exists {
-			requestID = id.(string)
-		}
+	if requestID := c.GetString("requestId"); requestID != "" {
+		return requestID
+	}
+	return c.GetHeader(middlewares.RequestIDHeader)
+}
+
+// canReadCustomer reports whether the caller may read the orders of the
+// customer. Customers authenticated by bearer token only read their own;
+// admins and callers without a user, identified by API key, read any.
+func canReadCustomer(c *gin.Context, customerID string) bool {
+	userID := c.GetString(middlewares.UserIDKey)
+	return userID == "" || normalizeCustomerID(userID) == normalizeCustomerID(customerID) || middlewares.IsAdmin(c)
+}
+
+// authorizeOrderRead checks that the caller may read the order, for reads
+// whose result does not carry the customer. Only authenticated customers are
+// checked, looking the order up; on failure the response is written and false
+// returned.
+func (h *OrderHandler) authorizeOrderRead(c *gin.Context, orderID, requestID string) bool {
+	if c.GetString(middlewares.UserIDKey) == "" || middlewares.IsAdmin(c) {
+		return true
+	}
+
+	order, svcErr := h.service.GetOrderByID(requestContext(c), orderID)
+	if svcErr != nil {
+		h.logger.Error("Failed to get order", zap.Error(svcErr), zap.String("orderId", orderID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to get order")
+		return false
+	}
+	if !canReadCustomer(c, order.CustomerID) {
+		writeAccessDenied(c)
+		return false
+	}
+	return true
+}
+
+func writeAccessDenied(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": "Orders of other customers cannot be read",
+		"code":  "ORDER_ACCESS_DENIED",
+	})
+}
+
+// requestContext returns the context handlers pass to the service. Admins
+// see soft-deleted orders as gone rather than not found.
+func requestContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if c.GetBool(middlewares.AdminKey) {
+		ctx = repositories.WithDeleted(ctx)
 	}
-	return requestID
+	return ctx
 }
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"time"
+
+	"orders/internal/models"
+)
+
+// OrderResponse is an order as served by the API. It is kept apart from
+// models.Order, which is also cached, stored and published, so storage fields
+// never reach clients and every field served is added here deliberately. Fields
+// are serialized in the order declared, whichever ID field is configured.
+// Optional fields are omitted when unset instead of served as zero values.
+type OrderResponse struct {
+	// OrderID or ID holds the order ID, depending on the configured ID field
+	OrderID     string               `json:"orderId,omitempty"`
+	ID          string               `json:"id,omitempty"`
+	TenantID    string               `json:"tenantId,omitempty"`
+	CustomerID  string               `json:"customerId"`
+	Status      models.OrderStatus   `json:"status"`
+	Priority    models.OrderPriority `json:"priority"`
+	Channel     models.OrderChannel  `json:"channel,omitempty"`
+	Items       []OrderItemResponse  `json:"items"`
+	Tags        []string             `json:"tags,omitempty"`
+	TotalAmount float64              `json:"totalAmount"`
+	// TotalAmountMinor is TotalAmount in minor units of the currency, e.g.
+	// cents, for clients that handle money as integers
+	TotalAmountMinor   int64                     `json:"totalAmountMinor"`
+	Notes              string                    `json:"notes,omitempty"`
+	NoteEntries        []models.OrderNote        `json:"noteEntries,omitempty"`
+	Version            int                       `json:"version"`
+	CreatedAt          time.Time                 `json:"createdAt"`
+	UpdatedAt          time.Time                 `json:"updatedAt"`
+	DeletedAt          *time.Time                `json:"deletedAt,omitempty"`
+	ClientMetadata     map[string]string         `json:"clientMetadata,omitempty"`
+	CustomerSnapshot   *CustomerSnapshotResponse `json:"customerSnapshot,omitempty"`
+	TotalWeightGrams   int                       `json:"totalWeightGrams,omitempty"`
+	TotalVolumeCm3     int                       `json:"totalVolumeCm3,omitempty"`
+	PromisedDeliveryAt *time.Time                `json:"promisedDeliveryAt,omitempty"`
+	DeliveredAt        *time.Time                `json:"deliveredAt,omitempty"`
+	Return             *OrderReturnResponse      `json:"return,omitempty"`
+	BreachedSLA        bool                      `json:"breachedSLA"`
+	AllowedTransitions []models.OrderStatus      `json:"allowedTransitions"`
+	Links              map[string]link           `json:"_links,omitempty"`
+}
+
+// OrderItemResponse is a line of an OrderResponse.
+type OrderItemResponse struct {
+	SKU               string     `json:"sku"`
+	Quantity          int        `json:"quantity"`
+	Price             float64    `json:"price"`
+	WeightGrams       int        `json:"weightGrams,omitempty"`
+	VolumeCm3         int        `json:"volumeCm3,omitempty"`
+	DeliveredQuantity int        `json:"deliveredQuantity"`
+	PriceSnapshotAt   *time.Time `json:"priceSnapshotAt,omitempty"`
+}
+
+// CustomerSnapshotResponse is the customer contact data of an OrderResponse.
+type CustomerSnapshotResponse struct {
+	Email string `json:"email,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// OrderReturnResponse is the return requested for an OrderResponse.
+type OrderReturnResponse struct {
+	Reason      string              `json:"reason"`
+	Items       []models.ReturnItem `json:"items"`
+	RequestedAt time.Time           `json:"requestedAt"`
+	ReturnedAt  *time.Time          `json:"returnedAt,omitempty"`
+}
+
+// OrderSummaryResponse is the compact projection of an order served by the
+// summary endpoint.
+type OrderSummaryResponse struct {
+	OrderID     string             `json:"orderId,omitempty"`
+	ID          string             `json:"id,omitempty"`
+	TenantID    string             `json:"tenantId,omitempty"`
+	CustomerID  string             `json:"customerId"`
+	Status      models.OrderStatus `json:"status"`
+	TotalAmount float64            `json:"totalAmount"`
+	// TotalAmountMinor is TotalAmount in minor units of the currency
+	TotalAmountMinor int64 `json:"totalAmountMinor"`
+	Version          int   `json:"version"`
+}
+
+// ToOrderResponse returns the order as served with its ID under idField and
+// its total also in minor units of currency, computing BreachedSLA and
+// AllowedTransitions at now. A return is only allowed within returnWindow of
+// the delivery, as in GET /transitions. A nil order is served as nil.
+func ToOrderResponse(order *models.Order, idField, currency string, returnWindow time.Duration, now time.Time) *OrderResponse {
+	if order == nil {
+		return nil
+	}
+	response := &OrderResponse{
+		TenantID:           order.TenantID,
+		CustomerID:         order.CustomerID,
+		Status:             order.Status,
+		Priority:           order.Priority,
+		Channel:            order.Channel,
+		Tags:               order.Tags,
+		TotalAmount:        order.TotalAmount,
+		TotalAmountMinor:   models.MinorUnits(order.TotalAmount, currency),
+		Notes:              order.Notes,
+		NoteEntries:        order.NoteEntries,
+		Version:            order.Version,
+		CreatedAt:          order.CreatedAt,
+		UpdatedAt:          order.UpdatedAt,
+		DeletedAt:          order.DeletedAt,
+		ClientMetadata:     order.ClientMetadata,
+		TotalWeightGrams:   order.TotalWeightGrams,
+		TotalVolumeCm3:     order.TotalVolumeCm3,
+		PromisedDeliveryAt: order.PromisedDeliveryAt,
+		DeliveredAt:        order.DeliveredAt,
+		BreachedSLA:        order.IsSLABreached(now),
+		AllowedTransitions: order.NextStatuses(returnWindow, now),
+	}
+	setOrderID(&response.OrderID, &response.ID, order.ID, idField)
+	if order.Items != nil {
+		response.Items = make([]OrderItemResponse, len(order.Items))
+		for i, item := range order.Items {
+			response.Items[i] = OrderItemResponse{
+				SKU:               item.SKU,
+				Quantity:          item.Quantity,
+				Price:             item.Price,
+				WeightGrams:       item.WeightGrams,
+				VolumeCm3:         item.VolumeCm3,
+				DeliveredQuantity: item.DeliveredQuantity,
+				PriceSnapshotAt:   item.PriceSnapshotAt,
+			}
+		}
+	}
+	if snapshot := order.CustomerSnapshot; snapshot != nil {
+		response.CustomerSnapshot = &CustomerSnapshotResponse{Email: snapshot.Email, Name: snapshot.Name, Phone: snapshot.Phone}
+	}
+	if ret := order.Return; ret != nil {
+		response.Return = &OrderReturnResponse{
+			Reason:      ret.Reason,
+			Items:       ret.Items,
+			RequestedAt: ret.RequestedAt,
+			ReturnedAt:  ret.ReturnedAt,
+		}
+	}
+	return response
+}
+
+// ToOrderSummaryResponse returns the summary as served with its ID under
+// idField and its total also in minor units of currency.
+func ToOrderSummaryResponse(summary *models.OrderSummary, idField, currency string) *OrderSummaryResponse {
+	response := &OrderSummaryResponse{
+		TenantID:         summary.TenantID,
+		CustomerID:       summary.CustomerID,
+		Status:           summary.Status,
+		TotalAmount:      summary.TotalAmount,
+		TotalAmountMinor: models.MinorUnits(summary.TotalAmount, currency),
+		Version:          summary.Version,
+	}
+	setOrderID(&response.OrderID, &response.ID, summary.ID, idField)
+	return response
+}
+
+// render returns the order as served by the handler.
+func (h *OrderHandler) render(order *models.Order) *OrderResponse {
+	return ToOrderResponse(order, h.idField, h.currency, h.returnWindow, time.Now())
+}
+
+// renderAll returns the orders as served by the handler. An empty page is
+// served as an empty list, and no page as null.
+func (h *OrderHandler) renderAll(orders []*models.Order) []*OrderResponse {
+	if orders == nil {
+		return nil
+	}
+	now := time.Now()
+	responses := make([]*OrderResponse, len(orders))
+	for i, order := range orders {
+		responses[i] = ToOrderResponse(order, h.idField, h.currency, h.returnWindow, now)
+	}
+	return responses
+}
+
+// renderWithLinks is render with the links of the order under _links.
+func (h *OrderHandler) renderWithLinks(order *models.Order, links map[string]link) *OrderResponse {
+	response := h.render(order)
+	response.Links = links
+	return response
+}
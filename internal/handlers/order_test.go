@@ -5,16 +5,23 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	apperrors "orders/internal/errors"
 	"orders/internal/handlers"
+	"orders/internal/middlewares"
 	"orders/internal/models"
+	"orders/internal/repositories"
 	"orders/internal/services"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 // Mock del servicio
@@ -22,8 +29,8 @@ type MockOrderService struct {
 	mock.Mock
 }
 
-func (m *MockOrderService) CreateOrder(ctx context.Context, customerID string, items []models.OrderItem) (*models.Order, *services.ServiceError) {
-	args := m.Called(ctx, customerID, items)
+func (m *MockOrderService) CreateOrder(ctx context.Context, input services.CreateOrderInput) (*models.Order, *services.ServiceError) {
+	args := m.Called(ctx, input)
 	return args.Get(0).(*models.Order), args.Error(1).(*services.ServiceError)
 }
 
@@ -32,31 +39,128 @@ func (m *MockOrderService) GetOrderByID(ctx context.Context, orderID string) (*m
 	return args.Get(0).(*models.Order), args.Error(1).(*services.ServiceError)
 }
 
-func (m *MockOrderService) ListOrders(ctx context.Context, status, customerID string, page, limit int) ([]*models.Order, int64, *services.ServiceError) {
-	args := m.Called(ctx, status, customerID, page, limit)
+func (m *MockOrderService) ListOrders(ctx context.Context, filter services.ListOrdersFilter, page, limit int) ([]*models.Order, int64, *services.ServiceError) {
+	args := m.Called(ctx, filter, page, limit)
 	return args.Get(0).([]*models.Order), args.Get(1).(int64), args.Error(2).(*services.ServiceError)
 }
 
+// StreamOrders entrega uno a uno los pedidos configurados como primer retorno
+func (m *MockOrderService) StreamOrders(ctx context.Context, filter services.ListOrdersFilter, page, limit int, each func(*models.Order) error) (int64, *services.ServiceError) {
+	args := m.Called(ctx, filter, page, limit)
+	for _, order := range args.Get(0).([]*models.Order) {
+		if err := each(order); err != nil {
+			return 0, &services.ServiceError{Status: http.StatusInternalServerError, Message: "Failed to stream orders", Cause: []interface{}{err.Error()}}
+		}
+	}
+	return args.Get(1).(int64), args.Error(2).(*services.ServiceError)
+}
+
+func (m *MockOrderService) ListOrdersVersion(ctx context.Context, filter services.ListOrdersFilter) *models.ListVersion {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(*models.ListVersion)
+}
+
 func (m *MockOrderService) UpdateOrderStatus(ctx context.Context, orderID string, newStatus models.OrderStatus) (*models.Order, *services.ServiceError) {
 	args := m.Called(ctx, orderID, newStatus)
 	return args.Get(0).(*models.Order), args.Error(1).(*services.ServiceError)
 }
 
+func (m *MockOrderService) ForceOrderStatus(ctx context.Context, orderID string, newStatus models.OrderStatus, actor, reason string) (*models.Order, *services.ServiceError) {
+	args := m.Called(ctx, orderID, newStatus, actor, reason)
+	return args.Get(0).(*models.Order), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) UpdateOrderPriority(ctx context.Context, orderID string, priority models.OrderPriority) (*models.Order, *services.ServiceError) {
+	args := m.Called(ctx, orderID, priority)
+	return args.Get(0).(*models.Order), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) UpdateOrderTags(ctx context.Context, orderID string, tags []string) (*models.Order, *services.ServiceError) {
+	args := m.Called(ctx, orderID, tags)
+	return args.Get(0).(*models.Order), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) RequestOrderReturn(ctx context.Context, orderID, reason string, items []models.ReturnItem) (*models.Order, *services.ServiceError) {
+	args := m.Called(ctx, orderID, reason, items)
+	return args.Get(0).(*models.Order), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) RecordOrderDelivery(ctx context.Context, orderID string, items []models.DeliveryItem) (*models.Order, *services.ServiceError) {
+	args := m.Called(ctx, orderID, items)
+	return args.Get(0).(*models.Order), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) GetOrderSummary(ctx context.Context, orderID string) (*models.OrderSummary, *services.ServiceError) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).(*models.OrderSummary), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) GetOrderTransitions(ctx context.Context, orderID string) (*models.StatusTransitions, *services.ServiceError) {
+	args := m.Called(ctx, orderID)
+	return args.Get(0).(*models.StatusTransitions), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) NotifySLABreaches(ctx context.Context, limit int) (int, *services.ServiceError) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) ListOrderEvents(ctx context.Context, orderID string, limit int) ([]*models.EventRecord, *services.ServiceError) {
+	args := m.Called(ctx, orderID, limit)
+	return args.Get(0).([]*models.EventRecord), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) ListEvents(ctx context.Context, filter services.ListEventsFilter, page, limit int) ([]*models.EventRecord, int64, *services.ServiceError) {
+	args := m.Called(ctx, filter, page, limit)
+	return args.Get(0).([]*models.EventRecord), args.Get(1).(int64), args.Error(2).(*services.ServiceError)
+}
+
+func (m *MockOrderService) GetEvent(ctx context.Context, eventID string) (*models.EventRecord, *services.ServiceError) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).(*models.EventRecord), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) RetryEvent(ctx context.Context, eventID string) (*models.EventRecord, *services.ServiceError) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).(*models.EventRecord), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) GetOrderStats(ctx context.Context) (*models.OrderStats, *services.ServiceError) {
+	args := m.Called(ctx)
+	return args.Get(0).(*models.OrderStats), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) ReplayOrderEvents(ctx context.Context, orderID string) (int, *services.ServiceError) {
+	args := m.Called(ctx, orderID)
+	return args.Int(0), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) AddOrderNote(ctx context.Context, orderID, author, text string) (*models.Order, *services.ServiceError) {
+	args := m.Called(ctx, orderID, author, text)
+	return args.Get(0).(*models.Order), args.Error(1).(*services.ServiceError)
+}
+
+func (m *MockOrderService) ListOrderNotes(ctx context.Context, orderID string, page, limit int) ([]models.OrderNote, int, *services.ServiceError) {
+	args := m.Called(ctx, orderID, page, limit)
+	return args.Get(0).([]models.OrderNote), args.Int(1), args.Error(2).(*services.ServiceError)
+}
+
 func TestOrderHandler_CreateOrder_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(MockOrderService)
 	logger, _ := zap.NewDevelopment()
-	handler := handlers.NewOrderHandler(mockService, logger, 10, 100)
+	handler := handlers.NewOrderHandler(mockService, logger, 10, 100, 10000, handlers.IDFieldOrderID)
 
 	order := &models.Order{
-		ID:          "order-123",
+		ID:          "7c9e6679-7425-40de-944b-e07fc1f90ae7",
 		CustomerID:  "123e4567-e89b-12d3-a456-426614174000",
 		Status:      models.StatusNew,
 		TotalAmount: 100,
 	}
 
-	mockService.On("CreateOrder", mock.Anything, order.CustomerID, mock.Anything).
-		Return(order, (*services.ServiceError)(nil))
+	mockService.On("CreateOrder", mock.Anything, mock.MatchedBy(func(input services.CreateOrderInput) bool {
+		return input.CustomerID == order.CustomerID
+	})).Return(order, (*services.ServiceError)(nil))
 
 	body := `{"customerId":"123e4567-e89b-12d3-a456-426614174000","items":[{"sku":"ITEM-1","quantity":1,"price":100}]}`
 	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
@@ -76,9 +180,267 @@ func TestOrderHandler_CreateOrder_Success(t *testing.T) {
 	assert.Equal(t, order.ID, resp.ID)
 }
 
+func TestOrderHandler_CreateOrder_Links(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldID)
+	order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", CustomerID: "123e4567-e89b-12d3-a456-426614174000", Status: models.StatusNew}
+	mockService.On("CreateOrder", mock.Anything, mock.Anything).Return(order, (*services.ServiceError)(nil))
+
+	body := `{"customerId":"123e4567-e89b-12d3-a456-426614174000","items":[{"sku":"ITEM-1","quantity":1,"price":100}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(body))
+	req.Host = "orders.example.com"
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.CreateOrder(c)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/api/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7", w.Header().Get("Location"))
+
+	var resp struct {
+		ID    string `json:"id"`
+		Links map[string]struct {
+			Href string `json:"href"`
+		} `json:"_links"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	// Los enlaces se añaden sin perder el campo de ID configurado
+	assert.Equal(t, order.ID, resp.ID)
+	assert.Equal(t, "https://orders.example.com/api/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7", resp.Links["self"].Href)
+	assert.Equal(t, "https://orders.example.com/api/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/status", resp.Links["status"].Href)
+}
+
+func TestOrderHandler_CreateOrder_InitialStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	create := func(handler *handlers.OrderHandler, initialStatus string) *httptest.ResponseRecorder {
+		body := `{"customerId":"123e4567-e89b-12d3-a456-426614174000","items":[{"sku":"ITEM-1","quantity":1,"price":100}],"initialStatus":"` + initialStatus + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		handler.CreateOrder(c)
+		return w
+	}
+
+	t.Run("allowed initial status", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+		order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", CustomerID: "123e4567-e89b-12d3-a456-426614174000", Status: models.StatusInProgress}
+		mockService.On("CreateOrder", mock.Anything, mock.MatchedBy(func(input services.CreateOrderInput) bool {
+			return input.InitialStatus == models.StatusInProgress
+		})).Return(order, (*services.ServiceError)(nil))
+
+		w := create(handler, "IN_PROGRESS")
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"IN_PROGRESS"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not a starting status", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+		w := create(handler, "DELIVERED")
+
+		// Se rechaza sin llegar al servicio
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("starting status not allowed by the deployment", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+		mockService.On("CreateOrder", mock.Anything, mock.Anything).Return((*models.Order)(nil), &services.ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_INITIAL_STATUS",
+			Message: "Orders cannot be created in this status",
+			Cause:   []interface{}{"NEW"},
+		})
+
+		w := create(handler, "IN_PROGRESS")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"INVALID_INITIAL_STATUS"`)
+	})
+}
+
+func TestOrderHandler_CreateOrder_RateLimited(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	mockService.On("CreateOrder", mock.Anything, mock.Anything).Return((*models.Order)(nil), &services.ServiceError{
+		Status:     http.StatusTooManyRequests,
+		Code:       "RATE_LIMITED",
+		Message:    "Too many orders created by the customer, retry later",
+		RetryAfter: 1500 * time.Millisecond,
+	})
+
+	body := `{"customerId":"123e4567-e89b-12d3-a456-426614174000","items":[{"sku":"ITEM-1","quantity":1,"price":100}]}`
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	handler.CreateOrder(c)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	// Los segundos se redondean hacia arriba
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), `"code":"RATE_LIMITED"`)
+}
+
+func TestOrderHandler_CreateOrder_ChannelFromClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		client   string
+		body     string
+		expected models.OrderChannel
+	}{
+		{"Named after a channel", "mobile", `{}`, models.ChannelMobile},
+		{"Partner client", "acme", `{}`, models.ChannelPartner},
+		{"Explicit channel wins", "mobile", `{"channel":"WEB"}`, models.ChannelWeb},
+		{"Anonymous", "", `{}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+			mockService.On("CreateOrder", mock.Anything, mock.MatchedBy(func(input services.CreateOrderInput) bool {
+				return input.Channel == tt.expected
+			})).Return(&models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}, (*services.ServiceError)(nil))
+
+			var body map[string]interface{}
+			_ = json.Unmarshal([]byte(tt.body), &body)
+			body["customerId"] = "123e4567-e89b-12d3-a456-426614174000"
+			body["items"] = []map[string]interface{}{{"sku": "ITEM-1", "quantity": 1, "price": 100}}
+			payload, _ := json.Marshal(body)
+
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(string(payload)))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			if tt.client != "" {
+				c.Set(middlewares.ClientNameKey, tt.client)
+			}
+
+			handler.CreateOrder(c)
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrderHandler_CreateOrder_CustomerContact(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		customer string
+		status   int
+	}{
+		{"Valid contact", `{"email":"jane@example.com","name":"Jane Doe","phone":"+34600123456"}`, http.StatusCreated},
+		{"Invalid email", `{"email":"not-an-email"}`, http.StatusBadRequest},
+		{"Phone not in E.164", `{"phone":"600 123 456"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+			mockService.On("CreateOrder", mock.Anything, mock.MatchedBy(func(input services.CreateOrderInput) bool {
+				return input.Customer != nil && input.Customer.Email == "jane@example.com"
+			})).Return(&models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}, (*services.ServiceError)(nil))
+
+			body := `{"customerId":"123e4567-e89b-12d3-a456-426614174000",` +
+				`"items":[{"sku":"ITEM-1","quantity":1,"price":100}],"customer":` + tt.customer + `}`
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.CreateOrder(c)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status != http.StatusCreated {
+				mockService.AssertNotCalled(t, "CreateOrder")
+			}
+		})
+	}
+}
+
+func TestOrderHandler_CreateOrder_DeclaredTotal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		total    string
+		expected *float64
+		svcErr   *services.ServiceError
+		status   int
+	}{
+		{"Omitted", "", nil, nil, http.StatusCreated},
+		{"Matching", `,"totalAmount":100`, func() *float64 { v := 100.0; return &v }(), nil, http.StatusCreated},
+		{"Mismatching", `,"totalAmount":90`, func() *float64 { v := 90.0; return &v }(),
+			&services.ServiceError{Status: http.StatusBadRequest, Code: "TOTAL_MISMATCH", Message: "Declared total 90.00 does not match the computed total 100.00"},
+			http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+			var order *models.Order
+			if tt.svcErr == nil {
+				order = &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", TotalAmount: 100}
+			}
+			mockService.On("CreateOrder", mock.Anything, mock.MatchedBy(func(input services.CreateOrderInput) bool {
+				return assert.ObjectsAreEqual(tt.expected, input.TotalAmount)
+			})).Return(order, tt.svcErr)
+
+			body := `{"customerId":"123e4567-e89b-12d3-a456-426614174000",` +
+				`"items":[{"sku":"ITEM-1","quantity":1,"price":100}]` + tt.total + `}`
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.CreateOrder(c)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.svcErr != nil {
+				assert.Contains(t, w.Body.String(), `"code":"TOTAL_MISMATCH"`)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestOrderHandler_CreateOrder_InvalidJSON(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	handler := handlers.NewOrderHandler(new(MockOrderService), zap.NewNop(), 10, 100)
+	handler := handlers.NewOrderHandler(new(MockOrderService), zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
 
 	body := `{"customerId":"not-uuid"}`
 	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
@@ -93,38 +455,381 @@ func TestOrderHandler_CreateOrder_InvalidJSON(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestOrderHandler_RequestIDLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		requestID string
+		preserved bool
+	}{
+		{"Valid supplied ID", "checkout-7f3a", true},
+		{"Log injection", "abc\nlevel=error msg=forged", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.WarnLevel)
+			handler := handlers.NewOrderHandler(new(MockOrderService), zap.New(core), 10, 100, 10000, handlers.IDFieldOrderID)
+			router := gin.New()
+			router.Use(middlewares.RequestID(middlewares.RequestIDFormat{
+				MaxLength: 64,
+				Pattern:   regexp.MustCompile(`^[A-Za-z0-9._:-]+$`),
+			}))
+			router.POST("/orders", handler.CreateOrder)
+
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"customerId":"not-uuid"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(middlewares.RequestIDHeader, tt.requestID)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			// Se registra el ID devuelto al cliente, nunca uno rechazado
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			requestID := w.Header().Get(middlewares.RequestIDHeader)
+			entries := logs.FilterMessage("Invalid request body").All()
+			require.Len(t, entries, 1)
+			assert.Equal(t, requestID, entries[0].ContextMap()["requestId"])
+			if tt.preserved {
+				assert.Equal(t, tt.requestID, requestID)
+			} else {
+				assert.NotEqual(t, tt.requestID, requestID)
+			}
+		})
+	}
+}
+
 func TestOrderHandler_GetOrder_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(MockOrderService)
 	logger, _ := zap.NewDevelopment()
-	handler := handlers.NewOrderHandler(mockService, logger, 10, 100)
+	handler := handlers.NewOrderHandler(mockService, logger, 10, 100, 10000, handlers.IDFieldOrderID)
 
-	order := &models.Order{ID: "order-123"}
-	mockService.On("GetOrderByID", mock.Anything, "order-123").Return(order, (*services.ServiceError)(nil))
+	order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}
+	mockService.On("GetOrderByID", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").Return(order, (*services.ServiceError)(nil))
 
-	req := httptest.NewRequest(http.MethodGet, "/orders/order-123", nil)
+	req := httptest.NewRequest(http.MethodGet, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7", nil)
 	w := httptest.NewRecorder()
 
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
-	c.Params = gin.Params{{Key: "id", Value: "order-123"}}
+	c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
 
 	handler.GetOrder(c)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestOrderHandler_GetOrder_TotalAmountMinor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		currency    string
+		totalAmount float64
+		expected    int64
+	}{
+		{"USD", 1999.98, 199998},
+		// El yen no tiene decimales
+		{"JPY", 1500, 1500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.currency, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+			handler.SetCurrency(tt.currency)
+
+			order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Status: models.StatusNew, TotalAmount: tt.totalAmount}
+			mockService.On("GetOrderByID", mock.Anything, order.ID).Return(order, (*services.ServiceError)(nil))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/orders/"+order.ID, nil)
+			c.Params = gin.Params{{Key: "id", Value: order.ID}}
+
+			handler.GetOrder(c)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var resp handlers.OrderResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.totalAmount, resp.TotalAmount)
+			assert.Equal(t, tt.expected, resp.TotalAmountMinor)
+		})
+	}
+}
+
+func TestOrderHandler_GetOrder_AllowedTransitions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name        string
+		deliveredAt time.Time
+		expected    []models.OrderStatus
+	}{
+		{"within the return window", time.Now().Add(-time.Hour), []models.OrderStatus{models.StatusReturnRequested}},
+		// Como en GET /transitions, la devolución ya no se ofrece
+		{"past the return window", time.Now().Add(-48 * time.Hour), []models.OrderStatus{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+			handler.SetReturnWindow(24 * time.Hour)
+
+			order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Status: models.StatusDelivered, DeliveredAt: &tt.deliveredAt}
+			mockService.On("GetOrderByID", mock.Anything, order.ID).Return(order, (*services.ServiceError)(nil))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/orders/"+order.ID, nil)
+			c.Params = gin.Params{{Key: "id", Value: order.ID}}
+
+			handler.GetOrder(c)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var resp handlers.OrderResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expected, resp.AllowedTransitions)
+			assert.Equal(t, order.NextStatuses(24*time.Hour, time.Now()), resp.AllowedTransitions)
+		})
+	}
+}
+
+func TestToOrderResponse_AgreesWithModelJSON(t *testing.T) {
+	deliveredAt := time.Now().Add(-48 * time.Hour)
+	order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", CustomerID: "customer-1",
+		Status: models.StatusDelivered, TotalAmount: 9.99, Version: 3, DeliveredAt: &deliveredAt}
+
+	modelData, err := json.Marshal(order)
+	require.NoError(t, err)
+	responseData, err := json.Marshal(handlers.ToOrderResponse(order, handlers.IDFieldID, "EUR", 24*time.Hour, time.Now()))
+	require.NoError(t, err)
+	var model, response map[string]interface{}
+	require.NoError(t, json.Unmarshal(modelData, &model))
+	require.NoError(t, json.Unmarshal(responseData, &response))
+
+	// Pasada la ventana la API no ofrece la devolución y el modelo no la anuncia
+	assert.Equal(t, []interface{}{}, response["allowedTransitions"])
+	assert.NotContains(t, model, "allowedTransitions")
+	for key, value := range model {
+		if other, ok := response[key]; ok {
+			assert.Equal(t, value, other, key)
+		}
+	}
+}
+
+func TestOrderHandler_GetOrder_ExpandEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Status: models.StatusInProgress}
+	record := models.NewEventRecord(models.NewOrderStatusChangedEvent("7c9e6679-7425-40de-944b-e07fc1f90ae7", "customer-1", models.StatusNew, models.StatusInProgress))
+	mockService.On("GetOrderByID", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").Return(order, (*services.ServiceError)(nil))
+	mockService.On("ListOrderEvents", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", 50).
+		Return([]*models.EventRecord{record}, (*services.ServiceError)(nil))
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, url, nil)
+		c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+		handler.GetOrder(c)
+		return w
+	}
+
+	w := get("/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7?expand=events")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		OrderID string `json:"orderId"`
+		Status  string `json:"status"`
+		Events  []struct {
+			EventID string `json:"eventId"`
+			Status  string `json:"status"`
+		} `json:"events"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "7c9e6679-7425-40de-944b-e07fc1f90ae7", resp.OrderID)
+	assert.Equal(t, "IN_PROGRESS", resp.Status)
+	if assert.Len(t, resp.Events, 1) {
+		assert.Equal(t, record.EventID, resp.Events[0].EventID)
+		assert.Equal(t, "PENDING", resp.Events[0].Status)
+	}
+
+	// Without expand the events are not looked up
+	w = get("/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `"events"`)
+	mockService.AssertNumberOfCalls(t, "ListOrderEvents", 1)
+
+	w = get("/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7?expand=notes")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_QUERY")
+}
+
+const (
+	customerScopeOwner = "123e4567-e89b-12d3-a456-426614174000"
+	customerScopeOther = "123e4567-e89b-12d3-a456-426614174001"
+)
+
+func TestOrderHandler_GetOrder_CustomerScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		userID string
+		roles  []string
+		status int
+	}{
+		{"Owner", customerScopeOwner, nil, http.StatusOK},
+		{"Other customer", customerScopeOther, nil, http.StatusForbidden},
+		{"Admin", "operator-1", []string{middlewares.RoleAdmin}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+			order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", CustomerID: customerScopeOwner}
+			mockService.On("GetOrderByID", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").Return(order, (*services.ServiceError)(nil))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7", nil)
+			c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+			c.Set(middlewares.UserIDKey, tt.userID)
+			c.Set(middlewares.RolesKey, tt.roles)
+
+			handler.GetOrder(c)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), `"code":"ORDER_ACCESS_DENIED"`)
+				assert.NotContains(t, w.Body.String(), "7c9e6679-7425-40de-944b-e07fc1f90ae7")
+			}
+		})
+	}
+}
+
+func TestOrderHandler_ListOrders_CustomerScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		query    string
+		userID   string
+		roles    []string
+		status   int
+		customer string
+	}{
+		// El cliente solo ve sus pedidos aunque no filtre por cliente
+		{"Owner", "", customerScopeOwner, nil, http.StatusOK, customerScopeOwner},
+		{"Owner filtering by itself", "&customerId=" + customerScopeOwner, customerScopeOwner, nil, http.StatusOK, customerScopeOwner},
+		{"Owner filtering in uppercase", "&customerId=" + strings.ToUpper(customerScopeOwner), strings.ToUpper(customerScopeOwner), nil, http.StatusOK, customerScopeOwner},
+		{"Other customer", "&customerId=" + customerScopeOther, customerScopeOwner, nil, http.StatusForbidden, ""},
+		{"Admin", "&customerId=" + customerScopeOther, "operator-1", []string{middlewares.RoleAdmin}, http.StatusOK, customerScopeOther},
+		{"Admin listing every customer", "", "operator-1", []string{middlewares.RoleAdmin}, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+			mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+			mockService.On("ListOrders", mock.Anything, mock.MatchedBy(func(filter services.ListOrdersFilter) bool {
+				return filter.CustomerID == tt.customer
+			}), 1, 10).Return([]*models.Order{}, int64(0), (*services.ServiceError)(nil))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/orders?page=1&limit=10"+tt.query, nil)
+			c.Set(middlewares.UserIDKey, tt.userID)
+			c.Set(middlewares.RolesKey, tt.roles)
+
+			handler.ListOrders(c)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusForbidden {
+				mockService.AssertNotCalled(t, "ListOrders", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				mockService.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestOrderHandler_OrderReads_CustomerScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reads := []struct {
+		name   string
+		path   string
+		handle func(h *handlers.OrderHandler) gin.HandlerFunc
+	}{
+		{"transitions", "/transitions", func(h *handlers.OrderHandler) gin.HandlerFunc { return h.GetOrderTransitions }},
+		{"notes", "/notes", func(h *handlers.OrderHandler) gin.HandlerFunc { return h.ListOrderNotes }},
+	}
+	tests := []struct {
+		name   string
+		userID string
+		roles  []string
+		status int
+	}{
+		{"Owner", customerScopeOwner, nil, http.StatusOK},
+		{"Other customer", customerScopeOther, nil, http.StatusForbidden},
+		{"Admin", "operator-1", []string{middlewares.RoleAdmin}, http.StatusOK},
+	}
+
+	for _, read := range reads {
+		for _, tt := range tests {
+			t.Run(read.name+"/"+tt.name, func(t *testing.T) {
+				mockService := new(MockOrderService)
+				handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+				order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", CustomerID: customerScopeOwner}
+				mockService.On("GetOrderByID", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").Return(order, (*services.ServiceError)(nil))
+				mockService.On("GetOrderTransitions", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").
+					Return(&models.StatusTransitions{Status: models.StatusNew, Transitions: []models.OrderStatus{models.StatusCancelled}}, (*services.ServiceError)(nil))
+				mockService.On("ListOrderNotes", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", 1, 10).
+					Return([]models.OrderNote{{Text: "Llamar antes"}}, 1, (*services.ServiceError)(nil))
+
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest(http.MethodGet, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7"+read.path, nil)
+				c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+				c.Set(middlewares.UserIDKey, tt.userID)
+				c.Set(middlewares.RolesKey, tt.roles)
+
+				read.handle(handler)(c)
+
+				assert.Equal(t, tt.status, w.Code)
+				if tt.status == http.StatusForbidden {
+					// No se revela nada del pedido ajeno
+					assert.Contains(t, w.Body.String(), `"code":"ORDER_ACCESS_DENIED"`)
+					assert.NotContains(t, w.Body.String(), "Llamar antes")
+					mockService.AssertNotCalled(t, "GetOrderTransitions", mock.Anything, mock.Anything)
+					mockService.AssertNotCalled(t, "ListOrderNotes", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				}
+			})
+		}
+	}
+}
+
 func TestOrderHandler_ListOrders_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(MockOrderService)
 	logger, _ := zap.NewDevelopment()
-	handler := handlers.NewOrderHandler(mockService, logger, 10, 100)
+	handler := handlers.NewOrderHandler(mockService, logger, 10, 100, 10000, handlers.IDFieldOrderID)
 
 	orders := []*models.Order{
 		{ID: "order-1"},
 		{ID: "order-2"},
 	}
-	mockService.On("ListOrders", mock.Anything, "", "", 1, 10).Return(orders, int64(2), (*services.ServiceError)(nil))
+	defaultFilter := services.ListOrdersFilter{SortBy: "createdAt", SortDir: "desc"}
+	mockService.On("ListOrders", mock.Anything, defaultFilter, 1, 10).Return(orders, int64(2), (*services.ServiceError)(nil))
+	mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
 
 	req := httptest.NewRequest(http.MethodGet, "/orders?page=1&limit=10", nil)
 	w := httptest.NewRecorder()
@@ -137,34 +842,234 @@ func TestOrderHandler_ListOrders_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestOrderHandler_ListOrders_WithoutTotal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	mockService.On("ListOrders", mock.Anything, mock.MatchedBy(func(filter services.ListOrdersFilter) bool {
+		return filter.SkipTotal
+	}), 1, 10).Return([]*models.Order{{ID: "order-1"}}, repositories.UnknownTotal, (*services.ServiceError)(nil))
+	mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders?withTotal=false", nil)
+
+	handler.ListOrders(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp handlers.ListOrdersResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handlers.PaginationResponse{Page: 1, Limit: 10, Total: -1, TotalPages: -1, MaxPage: 1000}, resp.Pagination)
+	mockService.AssertExpectations(t)
+}
+
+func TestOrderHandler_ListOrders_SLABreachedFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	mockService.On("ListOrders", mock.Anything, mock.MatchedBy(func(filter services.ListOrdersFilter) bool {
+		return filter.SLABreached != nil && *filter.SLABreached
+	}), 1, 10).Return([]*models.Order{}, int64(0), (*services.ServiceError)(nil))
+	mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders?slaBreached=true", nil)
+
+	handler.ListOrders(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders?slaBreached=maybe", nil)
+
+	handler.ListOrders(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestOrderHandler_ListOrders_IfModifiedSince(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	version := &models.ListVersion{
+		LastWrite:  time.Date(2025, 3, 1, 10, 0, 0, 500_000_000, time.UTC),
+		ObservedAt: time.Date(2025, 3, 1, 10, 5, 0, 0, time.UTC),
+	}
+	customerID := "123e4567-e89b-12d3-a456-426614174000"
+	mockService.On("ListOrdersVersion", mock.Anything, mock.MatchedBy(func(filter services.ListOrdersFilter) bool {
+		return filter.CustomerID == customerID
+	})).Return(version)
+	mockService.On("ListOrders", mock.Anything, mock.Anything, 1, 10).Return([]*models.Order{}, int64(0), (*services.ServiceError)(nil))
+
+	list := func(ifModifiedSince string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/orders?customerId="+customerID, nil)
+		if ifModifiedSince != "" {
+			c.Request.Header.Set("If-Modified-Since", ifModifiedSince)
+		}
+		handler.ListOrders(c)
+		c.Writer.WriteHeaderNow()
+		return w
+	}
+
+	w := list("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Sat, 01 Mar 2025 10:00:00 GMT", w.Header().Get("Last-Modified"))
+
+	w = list("Sat, 01 Mar 2025 10:00:00 GMT")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	mockService.AssertNumberOfCalls(t, "ListOrders", 1)
+
+	// Earlier, malformed and future dates are answered in full
+	for _, since := range []string{"Sat, 01 Mar 2025 09:59:59 GMT", "yesterday", "Sat, 01 Mar 2025 11:00:00 GMT"} {
+		assert.Equal(t, http.StatusOK, list(since).Code, since)
+	}
+	mockService.AssertNumberOfCalls(t, "ListOrders", 4)
+}
+
 func TestOrderHandler_UpdateOrderStatus_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(MockOrderService)
 	logger, _ := zap.NewDevelopment()
-	handler := handlers.NewOrderHandler(mockService, logger, 10, 100)
+	handler := handlers.NewOrderHandler(mockService, logger, 10, 100, 10000, handlers.IDFieldOrderID)
 
-	order := &models.Order{ID: "order-123", Status: models.StatusInProgress}
-	mockService.On("UpdateOrderStatus", mock.Anything, "order-123", models.StatusInProgress).Return(order, (*services.ServiceError)(nil))
+	order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Status: models.StatusInProgress}
+	mockService.On("UpdateOrderStatus", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", models.StatusInProgress).Return(order, (*services.ServiceError)(nil))
 
 	body := `{"status":"IN_PROGRESS"}`
-	req := httptest.NewRequest(http.MethodPatch, "/orders/order-123/status", strings.NewReader(body))
+	req := httptest.NewRequest(http.MethodPatch, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/status", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
-	c.Params = gin.Params{{Key: "id", Value: "order-123"}}
+	c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
 
 	handler.UpdateOrderStatus(c)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestOrderHandler_ForceOrderStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Forces the status with the actor and reason", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+		router := gin.New()
+		router.POST("/api/admin/orders/:id/force-status", middlewares.RequireAdmin([]string{"admin-secret"}), handler.ForceOrderStatus)
+
+		// El actor es la clave de administración usada, no el declarado en el cuerpo
+		order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Status: models.StatusInProgress, Version: 4}
+		mockService.On("ForceOrderStatus", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", models.StatusInProgress, middlewares.AdminKeyID("admin-secret"), "Mistaken delivery scan").
+			Return(order, (*services.ServiceError)(nil))
+
+		body := `{"status":"IN_PROGRESS","actor":"someone-else@example.com","reason":"Mistaken delivery scan"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/force-status", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middlewares.AdminAPIKeyHeader, "admin-secret")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Requires a reason", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+		body := `{"status":"IN_PROGRESS"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/force-status", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+
+		handler.ForceOrderStatus(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ForceOrderStatus")
+	})
+}
+
+func TestOrderHandler_UpdateOrderStatus_CurrentStateDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		svcErr   *services.ServiceError
+		expected string
+	}{
+		{
+			name: "stale version",
+			svcErr: &services.ServiceError{
+				Status:  http.StatusConflict,
+				Code:    "VERSION_CONFLICT",
+				Message: "Order was modified by another process",
+				Details: apperrors.NewOrderStateDetails(&models.Order{Status: models.StatusInProgress, Version: 2}),
+			},
+			expected: `{
+				"error": "Order was modified by another process",
+				"code": "VERSION_CONFLICT",
+				"details": {"currentStatus": "IN_PROGRESS", "currentVersion": 2, "allowedTransitions": ["PARTIALLY_DELIVERED", "DELIVERED", "CANCELLED"]}
+			}`,
+		},
+		{
+			name: "bad transition",
+			svcErr: &services.ServiceError{
+				Status:  http.StatusBadRequest,
+				Code:    "INVALID_STATUS_TRANSITION",
+				Message: "Invalid status transition",
+				Cause:   []interface{}{"invalid status transition from CANCELLED to NEW"},
+				Details: apperrors.NewOrderStateDetails(&models.Order{Status: models.StatusCancelled, Version: 3}),
+			},
+			expected: `{
+				"error": "Invalid status transition",
+				"code": "INVALID_STATUS_TRANSITION",
+				"cause": ["invalid status transition from CANCELLED to NEW"],
+				"details": {"currentStatus": "CANCELLED", "currentVersion": 3, "allowedTransitions": []}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+			mockService.On("UpdateOrderStatus", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", models.StatusNew).Return((*models.Order)(nil), tt.svcErr)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/status", strings.NewReader(`{"status":"NEW"}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+
+			handler.UpdateOrderStatus(c)
+
+			assert.Equal(t, tt.svcErr.Status, w.Code)
+			assert.JSONEq(t, tt.expected, w.Body.String())
+		})
+	}
+}
+
 func TestOrderHandler_GetOrder_EmptyID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(MockOrderService)
 	logger, _ := zap.NewDevelopment()
-	handler := handlers.NewOrderHandler(mockService, logger, 10, 100)
+	handler := handlers.NewOrderHandler(mockService, logger, 10, 100, 10000, handlers.IDFieldOrderID)
 
 	req := httptest.NewRequest(http.MethodGet, "/orders/", nil)
 	w := httptest.NewRecorder()
@@ -176,29 +1081,140 @@ func TestOrderHandler_GetOrder_EmptyID(t *testing.T) {
 	handler.GetOrder(c)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"INVALID_UUID"`)
+	mockService.AssertExpectations(t)
+}
 
-	var resp map[string]string
-	err := json.Unmarshal(w.Body.Bytes(), &resp)
-	assert.NoError(t, err)
-	assert.Equal(t, "Order ID is required", resp["error"])
+func TestOrderHandler_UUIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const orderID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	const customerID = "123e4567-e89b-12d3-a456-426614174000"
+
+	t.Run("order IDs are lowercased in any accepted form", func(t *testing.T) {
+		forms := []string{
+			orderID,
+			"7C9E6679-7425-40DE-944B-E07FC1F90AE7",
+			"{7c9e6679-7425-40de-944b-e07fc1f90ae7}",
+			"urn:uuid:7C9E6679-7425-40DE-944B-E07FC1F90AE7",
+		}
+		for _, id := range forms {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+			mockService.On("GetOrderByID", mock.Anything, orderID).Return(&models.Order{ID: orderID, CustomerID: customerID}, (*services.ServiceError)(nil))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/orders/"+id, nil)
+			c.Params = gin.Params{{Key: "id", Value: id}}
+
+			handler.GetOrder(c)
+
+			assert.Equal(t, http.StatusOK, w.Code, id)
+			mockService.AssertExpectations(t)
+		}
+	})
+
+	t.Run("malformed order IDs are rejected", func(t *testing.T) {
+		// El UUID nulo y las versiones desconocidas tampoco son IDs de pedido
+		for _, id := range []string{"order-123", "7c9e6679-7425-40de-944b", "00000000-0000-0000-0000-000000000000", "7c9e6679-7425-f0de-944b-e07fc1f90ae7"} {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/orders/"+id, strings.NewReader(`{"status":"IN_PROGRESS"}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Params = gin.Params{{Key: "id", Value: id}}
+
+			handler.UpdateOrderStatus(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, id)
+			assert.JSONEq(t, `{"error":"Invalid UUID","code":"INVALID_UUID","fields":[{"field":"id","message":"must be a valid UUID"}]}`, w.Body.String())
+			mockService.AssertNotCalled(t, "UpdateOrderStatus")
+		}
+	})
+
+	t.Run("customer IDs of new orders are stored lowercase", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+		mockService.On("CreateOrder", mock.Anything, mock.MatchedBy(func(input services.CreateOrderInput) bool {
+			return input.CustomerID == customerID
+		})).Return(&models.Order{ID: orderID, CustomerID: customerID}, (*services.ServiceError)(nil))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body := `{"customerId":"{123E4567-E89B-12D3-A456-426614174000}","items":[{"sku":"ITEM-1","quantity":1,"price":100}]}`
+		c.Request = httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.CreateOrder(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("malformed customer IDs are rejected", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body := `{"customerId":"customer-1","items":[{"sku":"ITEM-1","quantity":1,"price":100}]}`
+		c.Request = httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.CreateOrder(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"Invalid UUID","code":"INVALID_UUID","fields":[{"field":"customerId","message":"must be a valid UUID"}]}`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/orders?customerId=customer-1", nil)
+
+		handler.ListOrders(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"INVALID_UUID"`)
+		mockService.AssertNotCalled(t, "CreateOrder")
+		mockService.AssertNotCalled(t, "ListOrders")
+	})
+
+	t.Run("customer ID filters are lowercased", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+		mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+		mockService.On("ListOrders", mock.Anything, mock.MatchedBy(func(filter services.ListOrdersFilter) bool {
+			return filter.CustomerID == customerID
+		}), 1, 10).Return([]*models.Order{}, int64(0), (*services.ServiceError)(nil))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/orders?customerId=urn:uuid:123E4567-E89B-12D3-A456-426614174000", nil)
+
+		handler.ListOrders(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
 }
 
 func TestOrderHandler_GetOrder_NonExistentID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(MockOrderService)
 	logger, _ := zap.NewDevelopment()
-	handler := handlers.NewOrderHandler(mockService, logger, 10, 100)
+	handler := handlers.NewOrderHandler(mockService, logger, 10, 100, 10000, handlers.IDFieldOrderID)
 
 	// Simulamos que el servicio devuelve error (orden no encontrada)
-	mockService.On("GetOrderByID", mock.Anything, "nonexistent-id").
+	mockService.On("GetOrderByID", mock.Anything, "16fd2706-8baf-433b-82eb-8c7fada847da").
 		Return((*models.Order)(nil), &services.ServiceError{Message: "order not found"})
 
-	req := httptest.NewRequest(http.MethodGet, "/orders/nonexistent-id", nil)
+	req := httptest.NewRequest(http.MethodGet, "/orders/16fd2706-8baf-433b-82eb-8c7fada847da", nil)
 	w := httptest.NewRecorder()
 
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
-	c.Params = gin.Params{{Key: "id", Value: "nonexistent-id"}}
+	c.Params = gin.Params{{Key: "id", Value: "16fd2706-8baf-433b-82eb-8c7fada847da"}}
 
 	handler.GetOrder(c)
 
@@ -210,11 +1226,33 @@ func TestOrderHandler_GetOrder_NonExistentID(t *testing.T) {
 	assert.Contains(t, resp["error"], "Internal server error")
 }
 
+func TestOrderHandler_GetOrder_RequestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	mockService.On("GetOrderByID", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").
+		Return((*models.Order)(nil), &services.ServiceError{Status: http.StatusGatewayTimeout, Code: "REQUEST_TIMEOUT", Message: "Request timed out"})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7", nil)
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+
+	handler.GetOrder(c)
+
+	// Un timeout no se presenta como error interno
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.JSONEq(t, `{"error":"Request timed out","code":"REQUEST_TIMEOUT"}`, w.Body.String())
+}
+
 func TestOrderHandler_ListOrders_InvalidStatus(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(MockOrderService)
 	logger, _ := zap.NewDevelopment()
-	handler := handlers.NewOrderHandler(mockService, logger, 10, 100)
+	handler := handlers.NewOrderHandler(mockService, logger, 10, 100, 10000, handlers.IDFieldOrderID)
 
 	// status inválido que no existe en OrderStatus
 	req := httptest.NewRequest(http.MethodGet, "/orders?status=INVALID_STATUS", nil)
@@ -227,27 +1265,31 @@ func TestOrderHandler_ListOrders_InvalidStatus(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var resp map[string]string
+	var resp map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, "Invalid status value", resp["error"])
+	assert.Equal(t, "INVALID_QUERY", resp["code"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"field":   "status",
+		"message": "must be one of NEW, IN_PROGRESS, PARTIALLY_DELIVERED, DELIVERED, CANCELLED, RETURN_REQUESTED, RETURNED",
+	}}, resp["fields"])
 }
 
 func TestOrderHandler_UpdateOrderStatus_InvalidJSON(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(MockOrderService)
 	logger, _ := zap.NewDevelopment()
-	handler := handlers.NewOrderHandler(mockService, logger, 10, 100)
+	handler := handlers.NewOrderHandler(mockService, logger, 10, 100, 10000, handlers.IDFieldOrderID)
 
 	// JSON inválido (missing "status")
 	body := `{"wrongField":"IN_PROGRESS"}`
-	req := httptest.NewRequest(http.MethodPatch, "/orders/order-123/status", strings.NewReader(body))
+	req := httptest.NewRequest(http.MethodPatch, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/status", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
-	c.Params = gin.Params{{Key: "id", Value: "order-123"}}
+	c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
 
 	handler.UpdateOrderStatus(c)
 
@@ -263,7 +1305,7 @@ func TestOrderHandler_UpdateOrderStatus_EmptyID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(MockOrderService)
 	logger, _ := zap.NewDevelopment()
-	handler := handlers.NewOrderHandler(mockService, logger, 10, 100)
+	handler := handlers.NewOrderHandler(mockService, logger, 10, 100, 10000, handlers.IDFieldOrderID)
 
 	body := `{"status":"IN_PROGRESS"}`
 	req := httptest.NewRequest(http.MethodPatch, "/orders//status", strings.NewReader(body))
@@ -277,9 +1319,656 @@ func TestOrderHandler_UpdateOrderStatus_EmptyID(t *testing.T) {
 	handler.UpdateOrderStatus(c)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"INVALID_UUID"`)
+	mockService.AssertExpectations(t)
+}
 
-	var resp map[string]string
+func TestOrderHandler_AddOrderNote_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", NoteEntries: []models.OrderNote{{Text: "gate code 4411"}}}
+	mockService.On("AddOrderNote", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", "dispatcher", "gate code 4411").Return(order, (*services.ServiceError)(nil))
+
+	body := `{"author":"dispatcher","text":"gate code 4411"}`
+	req := httptest.NewRequest(http.MethodPost, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/notes", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+
+	handler.AddOrderNote(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestOrderHandler_AddOrderNote_TooLong(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	mockService.On("AddOrderNote", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", "dispatcher", mock.Anything).
+		Return((*models.Order)(nil), &services.ServiceError{Status: http.StatusBadRequest, Code: "NOTE_TOO_LONG", Message: "Note must be at most 5 characters"})
+
+	body := `{"author":"dispatcher","text":"too long"}`
+	req := httptest.NewRequest(http.MethodPost, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/notes", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+
+	handler.AddOrderNote(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NoError(t, err)
-	assert.Equal(t, "Order ID is required", resp["error"])
+	assert.Equal(t, "NOTE_TOO_LONG", resp["code"])
+}
+
+func TestOrderHandler_OrderEventsAction_Replay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+	mockService.On("ReplayOrderEvents", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").Return(2, (*services.ServiceError)(nil))
+
+	router := gin.New()
+	router.POST("/orders/:id/events:action", handler.OrderEventsAction)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/events:replay", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"orderId":"7c9e6679-7425-40de-944b-e07fc1f90ae7","replayed":2}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/events:purge", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertNumberOfCalls(t, "ReplayOrderEvents", 1)
+}
+
+func TestOrderHandler_AddOrderNote_MissingAuthor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	body := `{"text":"gate code 4411"}`
+	req := httptest.NewRequest(http.MethodPost, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/notes", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+
+	handler.AddOrderNote(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "AddOrderNote")
+}
+
+func TestOrderHandler_ListOrderNotes_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	notes := []models.OrderNote{{Author: "ops", Text: "second"}, {Author: "ops", Text: "first"}}
+	mockService.On("ListOrderNotes", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", 1, 2).Return(notes, 3, (*services.ServiceError)(nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/notes?limit=2", nil)
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+
+	handler.ListOrderNotes(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp handlers.ListNotesResponse
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", resp.Notes[0].Text)
+	assert.Equal(t, 2, resp.Pagination.Limit)
+	assert.Equal(t, int64(3), resp.Pagination.Total)
+	assert.Equal(t, 2, resp.Pagination.TotalPages)
+}
+
+func TestOrderHandler_UpdateOrderPriority_InvalidPriority(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	mockService.On("UpdateOrderPriority", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", models.OrderPriority("CRITICAL")).
+		Return((*models.Order)(nil), &services.ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_PRIORITY",
+			Message: "Invalid priority",
+			Cause:   []interface{}{"LOW", "NORMAL", "HIGH", "URGENT"},
+		})
+
+	req := httptest.NewRequest(http.MethodPatch, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/priority", strings.NewReader(`{"priority":"CRITICAL"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+
+	handler.UpdateOrderPriority(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "INVALID_PRIORITY", resp["code"])
+	assert.Equal(t, []interface{}{"LOW", "NORMAL", "HIGH", "URGENT"}, resp["cause"])
+}
+
+func TestOrderHandler_ListOrders_PriorityFilterAndSort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	expected := services.ListOrdersFilter{Priority: "HIGH", SortBy: "priority", SortDir: "desc"}
+	mockService.On("ListOrders", mock.Anything, expected, 1, 10).Return([]*models.Order{}, int64(0), (*services.ServiceError)(nil))
+	mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders?priority=HIGH&sortBy=priority", nil)
+
+	handler.ListOrders(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders?priority=CRITICAL", nil)
+
+	handler.ListOrders(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestOrderHandler_ListOrders_QueryDefaults(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		url           string
+		expectedPage  int
+		expectedLimit int
+		expectedSort  string
+		expectedDir   string
+	}{
+		{"no parameters", "/orders", 1, 10, "createdAt", "desc"},
+		{"limit capped at the maximum", "/orders?page=3&limit=500", 3, 100, "createdAt", "desc"},
+		{"last page of the scan window", "/orders?page=1000", 1000, 10, "createdAt", "desc"},
+		{"last page of the scan window at the maximum limit", "/orders?page=100&limit=100", 100, 100, "createdAt", "desc"},
+		{"explicit sort", "/orders?sortBy=totalAmount&sortDir=asc", 1, 10, "totalAmount", "asc"},
+		{"search sorts by relevance", "/orders?q=laptop", 1, 10, "relevance", "desc"},
+		{"search with explicit sort", "/orders?q=laptop&sortBy=createdAt&sortDir=asc", 1, 10, "createdAt", "asc"},
+		{"heaviest first", "/orders?minWeight=1000&maxWeight=5000&sortBy=totalWeightGrams", 1, 10, "totalWeightGrams", "desc"},
+		{"cheapest first within an amount range", "/orders?minAmount=50&maxAmount=199.99&sortBy=totalAmount&sortDir=asc", 1, 10, "totalAmount", "asc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+			// Las páginas grandes también se sirven en memoria, solo se comprueba la consulta
+			handler.SetStreamThreshold(0)
+
+			mockService.On("ListOrders", mock.Anything, mock.MatchedBy(func(filter services.ListOrdersFilter) bool {
+				return filter.SortBy == tt.expectedSort && filter.SortDir == tt.expectedDir
+			}), tt.expectedPage, tt.expectedLimit).Return([]*models.Order{}, int64(0), (*services.ServiceError)(nil))
+			mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, tt.url, nil)
+
+			handler.ListOrders(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrderHandler_ListOrders_InvalidQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		url           string
+		expectedField string
+	}{
+		{"page below one", "/orders?page=0", "page"},
+		{"negative page", "/orders?page=-1", "page"},
+		{"limit below one", "/orders?limit=0", "limit"},
+		{"negative limit", "/orders?limit=-5", "limit"},
+		{"non-numeric limit", "/orders?limit=abc", ""},
+		{"unknown sort field", "/orders?sortBy=customerId", "sortBy"},
+		{"unknown sort direction", "/orders?sortDir=up", "sortDir"},
+		{"malformed date", "/orders?from=yesterday", ""},
+		{"to before from", "/orders?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", "to"},
+		{"empty tag", "/orders?tag=", "tag"},
+		{"relevance without search", "/orders?sortBy=relevance", "sortBy"},
+		{"relevance ascending", "/orders?q=laptop&sortDir=asc", "sortDir"},
+		{"negative weight", "/orders?minWeight=-1", "minWeight"},
+		{"maxWeight below minWeight", "/orders?minWeight=5000&maxWeight=1000", "maxWeight"},
+		{"negative amount", "/orders?minAmount=-0.5", "minAmount"},
+		{"maxAmount below minAmount", "/orders?minAmount=200&maxAmount=50", "maxAmount"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, tt.url, nil)
+
+			handler.ListOrders(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var resp struct {
+				Code   string `json:"code"`
+				Fields []struct {
+					Field string `json:"field"`
+				} `json:"fields"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, "INVALID_QUERY", resp.Code)
+			assert.Len(t, resp.Fields, 1)
+			assert.Equal(t, tt.expectedField, resp.Fields[0].Field)
+			mockService.AssertNotCalled(t, "ListOrders")
+		})
+	}
+}
+
+func TestOrderHandler_ListOrders_MaxPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		url             string
+		expectedStatus  int
+		expectedMaxPage int
+		limitReached    bool
+	}{
+		{"last page", "/orders?page=1000", http.StatusOK, 1000, false},
+		{"last page at the maximum limit", "/orders?page=100&limit=100", http.StatusOK, 100, false},
+		{"past the last page", "/orders?page=1001", http.StatusBadRequest, 0, true},
+		{"past the last page at the maximum limit", "/orders?page=101&limit=100", http.StatusBadRequest, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+			mockService.On("ListOrders", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*models.Order{}, int64(0), (*services.ServiceError)(nil))
+			// Las páginas de 100 pedidos se sirven en streaming
+			mockService.On("StreamOrders", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*models.Order{}, int64(0), (*services.ServiceError)(nil))
+			mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, tt.url, nil)
+
+			handler.ListOrders(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.limitReached {
+				assert.Equal(t, "true", w.Header().Get(handlers.PaginationLimitReachedHeader))
+				var resp struct {
+					Error string `json:"error"`
+					Code  string `json:"code"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, "RESULT_WINDOW_EXCEEDED", resp.Code)
+				assert.Contains(t, resp.Error, "passing the createdAt of the last order received as to")
+				mockService.AssertNotCalled(t, "ListOrders")
+				mockService.AssertNotCalled(t, "StreamOrders")
+				return
+			}
+			assert.Empty(t, w.Header().Get(handlers.PaginationLimitReachedHeader))
+
+			var resp handlers.ListOrdersResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedMaxPage, resp.Pagination.MaxPage)
+		})
+	}
+}
+
+func TestOrderHandler_ListOrders_ScanWindowLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zap.WarnLevel)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.New(core), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders?page=50000&limit=100", nil)
+	c.Set(middlewares.ClientNameKey, "crawler")
+
+	handler.ListOrders(c)
+
+	// Se rechaza antes de consultar la base de datos, registrando quién lo pidió
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "ListOrdersVersion", mock.Anything, mock.Anything)
+	entries := logs.FilterField(zap.String("client", "crawler")).All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(50000), entries[0].ContextMap()["page"])
+}
+
+func TestOrderHandler_ListOrders_LimitAdjusted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedLimit  int
+		requestedLimit int
+	}{
+		{"over the maximum", "/orders?limit=500", 100, 500},
+		{"within the maximum", "/orders?limit=20", 20, 0},
+		{"default", "/orders", 10, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+			mockService.On("ListOrders", mock.Anything, mock.Anything, 1, tt.expectedLimit).Return([]*models.Order{}, int64(0), (*services.ServiceError)(nil))
+			mockService.On("StreamOrders", mock.Anything, mock.Anything, 1, tt.expectedLimit).Return([]*models.Order{}, int64(0), (*services.ServiceError)(nil))
+			mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, tt.url, nil)
+
+			handler.ListOrders(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var resp handlers.ListOrdersResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedLimit, resp.Pagination.Limit)
+			if tt.requestedLimit > 0 {
+				assert.Equal(t, "true", w.Header().Get(handlers.PaginationLimitAdjustedHeader))
+				if assert.NotNil(t, resp.Pagination.RequestedLimit) {
+					assert.Equal(t, tt.requestedLimit, *resp.Pagination.RequestedLimit)
+				}
+			} else {
+				// Sin ajuste no se informa nada
+				assert.Empty(t, w.Header().Get(handlers.PaginationLimitAdjustedHeader))
+				assert.NotContains(t, w.Body.String(), "requestedLimit")
+			}
+		})
+	}
+}
+
+func TestOrderHandler_ListOrders_RepeatedTags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	mockService.On("ListOrders", mock.Anything, mock.MatchedBy(func(filter services.ListOrdersFilter) bool {
+		return assert.ObjectsAreEqual([]string{"fragile", "vip"}, filter.Tags)
+	}), 1, 10).Return([]*models.Order{}, int64(0), (*services.ServiceError)(nil))
+	mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders?tag=Fragile&tag=vip", nil)
+
+	handler.ListOrders(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestOrderHandler_UpdateOrderTags_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Tags: []string{"fragile", "vip"}}
+	mockService.On("UpdateOrderTags", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", []string{"Fragile", "vip"}).Return(order, (*services.ServiceError)(nil))
+
+	req := httptest.NewRequest(http.MethodPut, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/tags", strings.NewReader(`{"tags":["Fragile","vip"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+
+	handler.UpdateOrderTags(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestOrderHandler_ReturnOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(body string) (*gin.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPost, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/return", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+		return c, w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+		items := []models.ReturnItem{{SKU: "LAPTOP-001", Quantity: 1}}
+		order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Status: models.StatusReturnRequested}
+		mockService.On("RequestOrderReturn", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", "damaged", items).Return(order, (*services.ServiceError)(nil))
+
+		c, w := newContext(`{"reason":"damaged","items":[{"sku":"LAPTOP-001","quantity":1}]}`)
+		handler.ReturnOrder(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Missing items", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+		c, w := newContext(`{"reason":"damaged","items":[]}`)
+		handler.ReturnOrder(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "RequestOrderReturn")
+	})
+
+	t.Run("Window expired", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+		mockService.On("RequestOrderReturn", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", "damaged", mock.Anything).Return((*models.Order)(nil), &services.ServiceError{
+			Status:  http.StatusConflict,
+			Code:    "RETURN_WINDOW_EXPIRED",
+			Message: "The return window of the order has expired",
+		})
+
+		c, w := newContext(`{"reason":"damaged","items":[{"sku":"LAPTOP-001","quantity":1}]}`)
+		handler.ReturnOrder(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "RETURN_WINDOW_EXPIRED")
+	})
+}
+
+func TestOrderHandler_RecordDelivery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOrderService)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	items := []models.DeliveryItem{{SKU: "LAPTOP-001", Quantity: 1}}
+	order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Status: models.StatusPartiallyDelivered}
+	mockService.On("RecordOrderDelivery", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", items).Return(order, (*services.ServiceError)(nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/deliveries", strings.NewReader(`{"items":[{"sku":"LAPTOP-001","quantity":1}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+
+	handler.RecordDelivery(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"PARTIALLY_DELIVERED"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestOrderHandler_GetOrderStatuses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := handlers.NewOrderHandler(new(MockOrderService), zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders/statuses", nil)
+
+	handler.GetOrderStatuses(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp handlers.StatusGraphResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, models.StatusGraph(), resp.Statuses)
+	assert.Equal(t, models.StatusNew, resp.Statuses[0].Status)
+	assert.Equal(t, []models.OrderStatus{models.StatusInProgress, models.StatusCancelled}, resp.Statuses[0].Transitions)
+}
+
+func TestOrderHandler_GetOrderTransitions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		transitions *models.StatusTransitions
+		expected    string
+	}{
+		{"new order", &models.StatusTransitions{Status: models.StatusNew, Transitions: []models.OrderStatus{models.StatusInProgress, models.StatusCancelled}},
+			`{"status":"NEW","transitions":["IN_PROGRESS","CANCELLED"]}`},
+		{"delivered order past the return window", &models.StatusTransitions{Status: models.StatusDelivered, Transitions: []models.OrderStatus{}},
+			`{"status":"DELIVERED","transitions":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+			mockService.On("GetOrderTransitions", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").Return(tt.transitions, (*services.ServiceError)(nil))
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/transitions", nil)
+			c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+
+			handler.GetOrderTransitions(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, tt.expected, w.Body.String())
+		})
+	}
+
+	t.Run("order not found", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+
+		mockService.On("GetOrderTransitions", mock.Anything, "16fd2706-8baf-433b-82eb-8c7fada847da").
+			Return((*models.StatusTransitions)(nil), &services.ServiceError{Status: http.StatusNotFound, Message: "order not found"})
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/orders/16fd2706-8baf-433b-82eb-8c7fada847da/transitions", nil)
+		c.Params = gin.Params{{Key: "id", Value: "16fd2706-8baf-433b-82eb-8c7fada847da"}}
+
+		handler.GetOrderTransitions(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestOrderHandler_IDField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, idField := range []string{handlers.IDFieldOrderID, handlers.IDFieldID} {
+		t.Run(idField, func(t *testing.T) {
+			mockService := new(MockOrderService)
+			handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, idField)
+			otherField := handlers.IDFieldID
+			if idField == handlers.IDFieldID {
+				otherField = handlers.IDFieldOrderID
+			}
+
+			order := &models.Order{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Status: models.StatusNew}
+			mockService.On("GetOrderByID", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").Return(order, (*services.ServiceError)(nil))
+			mockService.On("ListOrderEvents", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7", 50).
+				Return([]*models.EventRecord{}, (*services.ServiceError)(nil))
+			mockService.On("GetOrderSummary", mock.Anything, "7c9e6679-7425-40de-944b-e07fc1f90ae7").
+				Return(&models.OrderSummary{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Status: models.StatusNew}, (*services.ServiceError)(nil))
+			mockService.On("ListOrders", mock.Anything, mock.Anything, 1, 10).
+				Return([]*models.Order{order}, int64(1), (*services.ServiceError)(nil))
+			mockService.On("ListOrdersVersion", mock.Anything, mock.Anything).Return((*models.ListVersion)(nil))
+
+			// decode devuelve el cuerpo como mapa para revisar los nombres de campo
+			decode := func(w *httptest.ResponseRecorder) map[string]interface{} {
+				assert.Equal(t, http.StatusOK, w.Code)
+				var body map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				return body
+			}
+			call := func(handle gin.HandlerFunc, url string) map[string]interface{} {
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest(http.MethodGet, url, nil)
+				c.Params = gin.Params{{Key: "id", Value: "7c9e6679-7425-40de-944b-e07fc1f90ae7"}}
+				handle(c)
+				return decode(w)
+			}
+
+			responses := map[string]map[string]interface{}{
+				"get":     call(handler.GetOrder, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7"),
+				"expand":  call(handler.GetOrder, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7?expand=events"),
+				"summary": call(handler.GetOrderSummary, "/orders/7c9e6679-7425-40de-944b-e07fc1f90ae7/summary"),
+			}
+			list := call(handler.ListOrders, "/orders")
+			if orders, ok := list["orders"].([]interface{}); assert.True(t, ok) && assert.Len(t, orders, 1) {
+				responses["list"] = orders[0].(map[string]interface{})
+			}
+
+			for name, body := range responses {
+				assert.Equal(t, "7c9e6679-7425-40de-944b-e07fc1f90ae7", body[idField], name)
+				assert.NotContains(t, body, otherField, name)
+				assert.Equal(t, "NEW", body["status"], name)
+			}
+		})
+	}
 }
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"orders/internal/middlewares"
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListOutboxQuery holds the query parameters accepted by ListOutbox.
+type ListOutboxQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending failed"`
+}
+
+// ListOutbox godoc
+// @Summary List the events stuck in the outbox
+// @Description Lists the events of the log that did not reach the broker, newest first: the pending ones by default, or the failed ones. Customer contact data is masked unless the admin key has the pii:read scope. Requires admin credentials.
+// @Tags admin
+// @Produce json
+// @Param status query string false "Delivery status" Enums(pending, failed) default(pending)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} ListEventsResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/admin/outbox [get]
+func (h *OrderHandler) ListOutbox(c *gin.Context) {
+	requestID := getRequestID(c)
+
+	var query ListOutboxQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		writeQueryError(c, queryFieldErrors(err, query))
+		return
+	}
+	status := models.EventStatusPending
+	if query.Status != "" {
+		status = models.EventDeliveryStatus(strings.ToUpper(query.Status))
+	}
+	page, limit, requestedLimit := h.pageParams(c)
+
+	events, total, svcErr := h.service.ListEvents(c.Request.Context(), services.ListEventsFilter{Status: status}, page, limit)
+	if svcErr != nil {
+		h.logger.Error("Failed to list outbox", zap.Error(svcErr), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to list outbox")
+		return
+	}
+
+	if !middlewares.HasScope(c, middlewares.ScopePIIRead) {
+		for i, event := range events {
+			events[i] = event.Redacted()
+		}
+	}
+
+	pagination := newPagination(page, limit, total)
+	pagination.RequestedLimit = requestedLimit
+	c.JSON(http.StatusOK, ListEventsResponse{Events: events, Pagination: pagination})
+}
+
+// RetryOutboxEvent godoc
+// @Summary Publish a stuck event again
+// @Description Publishes again an event of the outbox that is pending or failed, keeping its event ID, and returns it with the outcome of the attempt. Customer contact data is masked unless the admin key has the pii:read scope. Requires admin credentials.
+// @Tags admin
+// @Produce json
+// @Param eventId path string true "Event ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} models.EventRecord
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "The event was already published"
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse "The broker rejected the event again"
+// @Failure 503 {object} ErrorResponse
+// @Router /api/admin/outbox/{eventId}/retry [post]
+func (h *OrderHandler) RetryOutboxEvent(c *gin.Context) {
+	requestID := getRequestID(c)
+	eventID := c.Param("eventId")
+
+	event, svcErr := h.service.RetryEvent(c.Request.Context(), eventID)
+	if svcErr != nil {
+		h.logger.Error("Failed to retry event", zap.Error(svcErr), zap.String("eventId", eventID), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to retry event")
+		return
+	}
+
+	if !middlewares.HasScope(c, middlewares.ScopePIIRead) {
+		event = event.Redacted()
+	}
+
+	c.JSON(http.StatusOK, event)
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"orders/internal/middlewares"
+	"orders/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// ListOrdersQuery holds the query parameters accepted by ListOrders.
+type ListOrdersQuery struct {
+	Status      string     `form:"status" binding:"omitempty,oneof=NEW IN_PROGRESS PARTIALLY_DELIVERED DELIVERED CANCELLED RETURN_REQUESTED RETURNED"`
+	CustomerID  string     `form:"customerId" binding:"omitempty,customerid"`
+	Priority    string     `form:"priority" binding:"omitempty,oneof=LOW NORMAL HIGH URGENT"`
+	Channel     string     `form:"channel" binding:"omitempty,oneof=MOBILE WEB PARTNER_API"`
+	Tags        []string   `form:"tag" binding:"omitempty,max=10,dive,min=1,max=30"`
+	SLABreached *bool      `form:"slaBreached"`
+	Query       string     `form:"q" binding:"omitempty,max=200"`
+	Page        int        `form:"page,default=1" binding:"min=1"`
+	Limit       *int       `form:"limit" binding:"omitempty,min=1"`
+	From        *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To          *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinWeight   *int       `form:"minWeight" binding:"omitempty,min=0"`
+	MaxWeight   *int       `form:"maxWeight" binding:"omitempty,min=0"`
+	MinAmount   *float64   `form:"minAmount" binding:"omitempty,min=0"`
+	MaxAmount   *float64   `form:"maxAmount" binding:"omitempty,min=0"`
+	SortBy      string     `form:"sortBy" binding:"omitempty,oneof=createdAt updatedAt totalAmount totalWeightGrams priority relevance"`
+	SortDir     string     `form:"sortDir" binding:"omitempty,oneof=asc desc"`
+	WithTotal   *bool      `form:"withTotal"`
+
+	// maxPage is the last page within the scan window, 0 when uncapped
+	maxPage int
+	// requestedLimit is the limit asked for when it was capped, nil otherwise
+	requestedLimit *int
+	// maxScanWindow is the deepest page*limit that may be listed, 0 if any
+	maxScanWindow int
+}
+
+// PaginationLimitReachedHeader is set to true when the requested page is past
+// the last one reachable within the scan window.
+const PaginationLimitReachedHeader = "X-Pagination-Limit-Reached"
+
+// PaginationLimitAdjustedHeader is set to true when the page is served with
+// another limit than requested, e.g. capped at the maximum page size.
+const PaginationLimitAdjustedHeader = "X-Pagination-Limit-Adjusted"
+
+// bindListOrdersQuery binds and validates the ListOrders query, applying the
+// page defaults. It returns the field errors to report when the query is invalid.
+func (h *OrderHandler) bindListOrdersQuery(c *gin.Context) (ListOrdersQuery, []middlewares.FieldError) {
+	var query ListOrdersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return query, queryFieldErrors(err, query)
+	}
+
+	if query.From != nil && query.To != nil && query.To.Before(*query.From) {
+		return query, []middlewares.FieldError{{Field: "to", Message: "must not be before from"}}
+	}
+	if query.MinWeight != nil && query.MaxWeight != nil && *query.MaxWeight < *query.MinWeight {
+		return query, []middlewares.FieldError{{Field: "maxWeight", Message: "must not be below minWeight"}}
+	}
+	if query.MinAmount != nil && query.MaxAmount != nil && *query.MaxAmount < *query.MinAmount {
+		return query, []middlewares.FieldError{{Field: "maxAmount", Message: "must not be below minAmount"}}
+	}
+
+	limits := *h.limits.Load()
+	if query.Limit == nil {
+		query.Limit = &limits.defaultPageSize
+	}
+	if *query.Limit > limits.maxPageSize {
+		query.requestedLimit = query.Limit
+		query.Limit = &limits.maxPageSize
+		limitAdjusted(c)
+	}
+	// Deep pages make the database skip every order before them; past the
+	// window, clients page by narrowing the creation time instead
+	if limits.maxScanWindow > 0 {
+		query.maxPage = limits.maxScanWindow / *query.Limit
+		query.maxScanWindow = limits.maxScanWindow
+	}
+	// Searches sort by relevance unless told otherwise; relevance only makes
+	// sense for a search and always lists the best match first.
+	if query.SortBy == "relevance" && query.Query == "" {
+		return query, []middlewares.FieldError{{Field: "sortBy", Message: "relevance requires q"}}
+	}
+	if query.SortBy == "" {
+		query.SortBy = "createdAt"
+		if query.Query != "" {
+			query.SortBy = "relevance"
+		}
+	}
+	if query.SortBy == "relevance" && query.SortDir == "asc" {
+		return query, []middlewares.FieldError{{Field: "sortDir", Message: "relevance is always sorted best match first"}}
+	}
+	if query.SortDir == "" {
+		query.SortDir = "desc"
+	}
+
+	return query, nil
+}
+
+// Filter converts the query into the service filter.
+func (q ListOrdersQuery) Filter() services.ListOrdersFilter {
+	return services.ListOrdersFilter{
+		Status:      q.Status,
+		CustomerID:  normalizeCustomerID(q.CustomerID),
+		Priority:    q.Priority,
+		Channel:     q.Channel,
+		Tags:        normalizeTagFilter(q.Tags),
+		SLABreached: q.SLABreached,
+		Query:       strings.TrimSpace(q.Query),
+		From:        q.From,
+		To:          q.To,
+		MinWeight:   q.MinWeight,
+		MaxWeight:   q.MaxWeight,
+		MinAmount:   q.MinAmount,
+		MaxAmount:   q.MaxAmount,
+		SortBy:      q.SortBy,
+		SortDir:     q.SortDir,
+		SkipTotal:   q.WithTotal != nil && !*q.WithTotal,
+	}
+}
+
+// normalizeTagFilter lower-cases the tag filter so it matches stored tags.
+func normalizeTagFilter(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	normalized := make([]string, len(tags))
+	for i, tag := range tags {
+		normalized[i] = strings.ToLower(strings.TrimSpace(tag))
+	}
+	return normalized
+}
+
+// queryFieldErrors turns a binding error into field errors named after the
+// query parameters rather than the struct fields.
+func queryFieldErrors(err error, query interface{}) []middlewares.FieldError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		// Type conversion errors (e.g. page=abc) carry no field information.
+		return []middlewares.FieldError{{Message: err.Error()}}
+	}
+
+	queryType := reflect.TypeOf(query)
+	fieldErrs := make([]middlewares.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		name := fe.Field()
+		// Errors on slice elements are reported on the parameter itself.
+		structField, _, _ := strings.Cut(fe.StructField(), "[")
+		if field, ok := queryType.FieldByName(structField); ok {
+			name, _, _ = strings.Cut(field.Tag.Get("form"), ",")
+		}
+		fieldErrs = append(fieldErrs, middlewares.FieldError{Field: name, Message: queryErrorMessage(fe)})
+	}
+	return fieldErrs
+}
+
+func queryErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min":
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters long"
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.Slice {
+			return "must have at most " + fe.Param() + " values"
+		}
+		return "must be at most " + fe.Param() + " characters long"
+	case "uuid":
+		return "must be a valid UUID"
+	case "customerid":
+		return "must be a valid customer ID"
+	}
+	return "is invalid"
+}
+
+// checkScanWindow answers 400 RESULT_WINDOW_EXCEEDED when the page of the
+// query is past the scan window and reports whether it is within.
+func (h *OrderHandler) checkScanWindow(c *gin.Context, query ListOrdersQuery) bool {
+	if query.maxScanWindow == 0 || query.Page <= query.maxPage {
+		return true
+	}
+	h.logger.Warn("Listing past the scan window rejected",
+		zap.Int("page", query.Page),
+		zap.Int("limit", *query.Limit),
+		zap.String("client", c.GetString(middlewares.ClientNameKey)),
+		zap.String("userId", c.GetString(middlewares.UserIDKey)),
+		zap.String("ip", c.ClientIP()),
+		zap.String("requestId", getRequestID(c)),
+	)
+	c.Header(PaginationLimitReachedHeader, "true")
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": fmt.Sprintf("page * limit must not exceed %d; page further by passing the createdAt of the last order received as to", query.maxScanWindow),
+		"code":  "RESULT_WINDOW_EXCEEDED",
+	})
+	return false
+}
+
+func writeQueryError(c *gin.Context, fieldErrs []middlewares.FieldError) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "Invalid query parameters",
+		"code":   "INVALID_QUERY",
+		"fields": fieldErrs,
+	})
+}
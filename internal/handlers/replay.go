@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EventReplayer replays the events of a time range as a background job.
+type EventReplayer interface {
+	CountRange(ctx context.Context, replay services.ReplayRange) (int64, *services.ServiceError)
+	StartRange(ctx context.Context, replay services.ReplayRange) (*models.ReplayJob, *services.ServiceError)
+	GetJob(ctx context.Context, jobID string) (*models.ReplayJob, *services.ServiceError)
+	CancelJob(ctx context.Context, jobID string) (*models.ReplayJob, *services.ServiceError)
+}
+
+// ReplayHandler lets administrators replay the event log by time range.
+type ReplayHandler struct {
+	replayer EventReplayer
+	logger   *zap.Logger
+}
+
+// NewReplayHandler creates a new instance of ReplayHandler. replayer is nil
+// when the event log or Redis is not configured, and replays are then
+// unavailable.
+func NewReplayHandler(replayer EventReplayer, logger *zap.Logger) *ReplayHandler {
+	return &ReplayHandler{
+		replayer: replayer,
+		logger:   logger,
+	}
+}
+
+// ReplayRangeRequest selects the events to replay. Without types, events of
+// every type are replayed.
+type ReplayRangeRequest struct {
+	From   *time.Time `json:"from" binding:"required"`
+	To     *time.Time `json:"to" binding:"required"`
+	Types  []string   `json:"types" binding:"omitempty,dive,oneof=ORDER_CREATED ORDER_STATUS_CHANGED ORDER_SLA_BREACHED ORDER_PRIORITY_CHANGED ORDER_TAGS_CHANGED ORDER_ITEMS_DELIVERED ORDER_RETURN_REQUESTED ORDER_RETURNED"`
+	DryRun bool       `json:"dryRun"`
+}
+
+// Range converts the request into the replayed range.
+func (r ReplayRangeRequest) Range() services.ReplayRange {
+	types := make([]models.EventType, len(r.Types))
+	for i, eventType := range r.Types {
+		types[i] = models.EventType(eventType)
+	}
+	return services.ReplayRange{From: *r.From, To: *r.To, Types: types}
+}
+
+// ReplayDryRunResponse is the number of events a replay would publish.
+type ReplayDryRunResponse struct {
+	DryRun bool  `json:"dryRun"`
+	Count  int64 `json:"count"`
+}
+
+// ReplayRange godoc
+// @Summary Replay the events of a time range
+// @Description Publishes the events emitted between from and to again, with a replay header, so downstream consumers can rebuild their projections. The replay runs in the background at a limited rate; follow it with the returned job. Only one replay runs at a time. With dryRun only the number of matching events is returned. Requires admin credentials.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param replay body ReplayRangeRequest true "Time range and event types to replay"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} ReplayDryRunResponse
+// @Success 202 {object} models.ReplayJob
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Another replay is running"
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/admin/events/replay-range [post]
+func (h *ReplayHandler) ReplayRange(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	requestID := getRequestID(c)
+
+	var req ReplayRangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format or missing required fields"})
+		return
+	}
+	if req.To.Before(*req.From) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+
+	if req.DryRun {
+		count, svcErr := h.replayer.CountRange(c.Request.Context(), req.Range())
+		if svcErr != nil {
+			h.logger.Error("Failed to count events to replay", zap.Error(svcErr), zap.String("requestId", requestID))
+			writeServiceError(c, svcErr, "Internal server error - Failed to count events")
+			return
+		}
+		c.JSON(http.StatusOK, ReplayDryRunResponse{DryRun: true, Count: count})
+		return
+	}
+
+	job, svcErr := h.replayer.StartRange(c.Request.Context(), req.Range())
+	if svcErr != nil {
+		h.logger.Error("Failed to start event replay", zap.Error(svcErr), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to start event replay")
+		return
+	}
+
+	h.logger.Info("Event replay started",
+		zap.String("jobId", job.ID),
+		zap.Time("from", job.From),
+		zap.Time("to", job.To),
+		zap.Int64("total", job.Total),
+		zap.String("requestId", requestID),
+	)
+	c.Header("Location", "/api/admin/events/replay-range/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetReplayJob godoc
+// @Summary Get the progress of an event replay
+// @Description Returns the state of a replay job and how many of its events were published. Requires admin credentials.
+// @Tags admin
+// @Produce json
+// @Param jobId path string true "Replay job ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} models.ReplayJob
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/admin/events/replay-range/{jobId} [get]
+func (h *ReplayHandler) GetReplayJob(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	jobID := c.Param("jobId")
+
+	job, svcErr := h.replayer.GetJob(c.Request.Context(), jobID)
+	if svcErr != nil {
+		h.logger.Error("Failed to get replay job", zap.Error(svcErr), zap.String("jobId", jobID), zap.String("requestId", getRequestID(c)))
+		writeServiceError(c, svcErr, "Internal server error - Failed to get replay job")
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelReplayJob godoc
+// @Summary Cancel an event replay
+// @Description Asks a running replay to stop; it stops within a second and is then reported as CANCELLED. Finished jobs are returned unchanged. Requires admin credentials.
+// @Tags admin
+// @Produce json
+// @Param jobId path string true "Replay job ID"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 202 {object} models.ReplayJob
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/admin/events/replay-range/{jobId}/cancel [post]
+func (h *ReplayHandler) CancelReplayJob(c *gin.Context) {
+	if !h.available(c) {
+		return
+	}
+	jobID := c.Param("jobId")
+
+	job, svcErr := h.replayer.CancelJob(c.Request.Context(), jobID)
+	if svcErr != nil {
+		h.logger.Error("Failed to cancel replay job", zap.Error(svcErr), zap.String("jobId", jobID), zap.String("requestId", getRequestID(c)))
+		writeServiceError(c, svcErr, "Internal server error - Failed to cancel replay job")
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// available answers 503 when replays are not configured.
+func (h *ReplayHandler) available(c *gin.Context) bool {
+	if h.replayer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Event replay requires the event log and the Redis cache",
+			"code":  "REPLAY_UNAVAILABLE",
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"orders/internal/handlers"
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockEventReplayer es un mock del replay de eventos por rango
+type MockEventReplayer struct {
+	mock.Mock
+}
+
+func (m *MockEventReplayer) CountRange(ctx context.Context, replay services.ReplayRange) (int64, *services.ServiceError) {
+	args := m.Called(ctx, replay)
+	return args.Get(0).(int64), args.Get(1).(*services.ServiceError)
+}
+
+func (m *MockEventReplayer) StartRange(ctx context.Context, replay services.ReplayRange) (*models.ReplayJob, *services.ServiceError) {
+	args := m.Called(ctx, replay)
+	return args.Get(0).(*models.ReplayJob), args.Get(1).(*services.ServiceError)
+}
+
+func (m *MockEventReplayer) GetJob(ctx context.Context, jobID string) (*models.ReplayJob, *services.ServiceError) {
+	args := m.Called(ctx, jobID)
+	return args.Get(0).(*models.ReplayJob), args.Get(1).(*services.ServiceError)
+}
+
+func (m *MockEventReplayer) CancelJob(ctx context.Context, jobID string) (*models.ReplayJob, *services.ServiceError) {
+	args := m.Called(ctx, jobID)
+	return args.Get(0).(*models.ReplayJob), args.Get(1).(*services.ServiceError)
+}
+
+func setupReplayRouter(replayer handlers.EventReplayer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := handlers.NewReplayHandler(replayer, zap.NewNop())
+
+	router := gin.New()
+	router.POST("/api/admin/events/replay-range", handler.ReplayRange)
+	router.GET("/api/admin/events/replay-range/:jobId", handler.GetReplayJob)
+	router.POST("/api/admin/events/replay-range/:jobId/cancel", handler.CancelReplayJob)
+	return router
+}
+
+func TestReplayHandler_ReplayRange(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(48 * time.Hour)
+	replay := services.ReplayRange{From: from, To: to, Types: []models.EventType{models.EventOrderCreated}}
+	job := &models.ReplayJob{ID: "job-1", Status: models.ReplayRunning, From: from, To: to, Total: 42}
+
+	replayer := new(MockEventReplayer)
+	replayer.On("CountRange", mock.Anything, replay).Return(int64(42), (*services.ServiceError)(nil))
+	replayer.On("StartRange", mock.Anything, replay).Return(job, (*services.ServiceError)(nil)).Once()
+	replayer.On("StartRange", mock.Anything, replay).Return((*models.ReplayJob)(nil), &services.ServiceError{
+		Status:  http.StatusConflict,
+		Code:    "REPLAY_IN_PROGRESS",
+		Message: "Another event replay is running",
+	})
+	router := setupReplayRouter(replayer)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Dry run", `{"from":"2025-03-01T00:00:00Z","to":"2025-03-03T00:00:00Z","types":["ORDER_CREATED"],"dryRun":true}`, http.StatusOK, `{"dryRun":true,"count":42}`},
+		{"Started", `{"from":"2025-03-01T00:00:00Z","to":"2025-03-03T00:00:00Z","types":["ORDER_CREATED"]}`, http.StatusAccepted, `"jobId":"job-1"`},
+		{"Already running", `{"from":"2025-03-01T00:00:00Z","to":"2025-03-03T00:00:00Z","types":["ORDER_CREATED"]}`, http.StatusConflict, `"code":"REPLAY_IN_PROGRESS"`},
+		{"Missing range", `{"from":"2025-03-01T00:00:00Z"}`, http.StatusBadRequest, `"error"`},
+		{"Unknown type", `{"from":"2025-03-01T00:00:00Z","to":"2025-03-03T00:00:00Z","types":["ORDER_DELETED"]}`, http.StatusBadRequest, `"error"`},
+		{"Inverted range", `{"from":"2025-03-03T00:00:00Z","to":"2025-03-01T00:00:00Z"}`, http.StatusBadRequest, `"to must not be before from"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/events/replay-range", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			if tt.expectedStatus == http.StatusAccepted {
+				assert.Equal(t, "/api/admin/events/replay-range/job-1", w.Header().Get("Location"))
+			}
+		})
+	}
+	replayer.AssertNumberOfCalls(t, "StartRange", 2)
+}
+
+func TestReplayHandler_Jobs(t *testing.T) {
+	job := &models.ReplayJob{ID: "job-1", Status: models.ReplayRunning, Total: 42, Replayed: 10}
+	replayer := new(MockEventReplayer)
+	replayer.On("GetJob", mock.Anything, "job-1").Return(job, (*services.ServiceError)(nil))
+	replayer.On("CancelJob", mock.Anything, "job-1").Return(&models.ReplayJob{ID: "job-1", Status: models.ReplayRunning, CancelRequested: true}, (*services.ServiceError)(nil))
+	replayer.On("GetJob", mock.Anything, "missing").Return((*models.ReplayJob)(nil), &services.ServiceError{Status: http.StatusNotFound, Message: "Replay job not found"})
+	router := setupReplayRouter(replayer)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/events/replay-range/job-1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var progress models.ReplayJob
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &progress))
+	assert.Equal(t, int64(10), progress.Replayed)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/events/replay-range/job-1/cancel", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"cancelRequested":true`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/events/replay-range/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestReplayHandler_Unavailable(t *testing.T) {
+	router := setupReplayRouter(nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/events/replay-range", strings.NewReader(`{}`)))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"REPLAY_UNAVAILABLE"`)
+}
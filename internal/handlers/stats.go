@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetOrderStats godoc
+// @Summary Get the order KPIs
+// @Description Returns the orders by status, the average order value and the orders created in the last 24 hours of the tenant, for dashboards that consume JSON rather than Prometheus. The KPIs are cached briefly, so they may lag behind the latest orders. Requires admin credentials.
+// @Tags stats
+// @Produce json
+// @Param X-Admin-Key header string true "Admin API key"
+// @Param X-Tenant-ID header string false "Tenant the request acts for, required when multi-tenancy is enabled"
+// @Success 200 {object} models.OrderStats
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/stats [get]
+func (h *OrderHandler) GetOrderStats(c *gin.Context) {
+	requestID := getRequestID(c)
+
+	stats, svcErr := h.service.GetOrderStats(c.Request.Context())
+	if svcErr != nil {
+		h.logger.Error("Failed to get order stats", zap.Error(svcErr), zap.String("requestId", requestID))
+		writeServiceError(c, svcErr, "Internal server error - Failed to get order stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
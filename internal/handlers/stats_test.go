@@ -0,0 +1,90 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"orders/internal/handlers"
+	"orders/internal/middlewares"
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newStatsRouter monta la ruta de estadísticas protegida por la clave "admin-key"
+func newStatsRouter(mockService *MockOrderService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := handlers.NewOrderHandler(mockService, zap.NewNop(), 10, 100, 10000, handlers.IDFieldOrderID)
+	router := gin.New()
+	router.GET("/api/stats", middlewares.RequireAdmin([]string{"admin-key"}), handler.GetOrderStats)
+	return router
+}
+
+func TestOrderHandler_GetOrderStats(t *testing.T) {
+	t.Run("Serves the aggregated KPIs", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("GetOrderStats", mock.Anything).Return(&models.OrderStats{
+			OrdersByStatus:    map[models.OrderStatus]int64{models.StatusNew: 3, models.StatusDelivered: 1},
+			TotalOrders:       4,
+			AverageOrderValue: 62.5,
+			OrdersLast24h:     2,
+			GeneratedAt:       time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		}, (*services.ServiceError)(nil))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+		req.Header.Set(middlewares.AdminAPIKeyHeader, "admin-key")
+		w := httptest.NewRecorder()
+
+		newStatsRouter(mockService).ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{
+			"ordersByStatus": {"NEW": 3, "DELIVERED": 1},
+			"totalOrders": 4,
+			"averageOrderValue": 62.5,
+			"ordersLast24h": 2,
+			"generatedAt": "2025-03-01T10:00:00Z"
+		}`, w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Requires admin credentials", func(t *testing.T) {
+		mockService := new(MockOrderService)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+		w := httptest.NewRecorder()
+
+		newStatsRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertNotCalled(t, "GetOrderStats", mock.Anything)
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		mockService := new(MockOrderService)
+		mockService.On("GetOrderStats", mock.Anything).Return((*models.OrderStats)(nil), &services.ServiceError{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to aggregate order stats",
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+		req.Header.Set(middlewares.AdminAPIKeyHeader, "admin-key")
+		w := httptest.NewRecorder()
+
+		newStatsRouter(mockService).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		// Los errores internos no se exponen
+		assert.Equal(t, "Internal server error - Failed to get order stats", body["error"])
+	})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"orders/internal/middlewares"
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxUsageDays bounds the days a usage report covers.
+const maxUsageDays = 366
+
+// UsageReader reads the request counters of the API clients.
+type UsageReader interface {
+	Usage(ctx context.Context, client string, from, to time.Time) (*models.ClientUsage, *repositories.RepositoryError)
+}
+
+// UsageHandler reports the requests of the API clients for billing.
+type UsageHandler struct {
+	usage   UsageReader
+	clients []string
+	logger  *zap.Logger
+}
+
+// NewUsageHandler creates a new instance of UsageHandler reporting the usage
+// of clients. usage is nil when client quotas are disabled, and usage is then
+// not tracked.
+func NewUsageHandler(usage UsageReader, clients []string, logger *zap.Logger) *UsageHandler {
+	clients = slices.Clone(clients)
+	slices.Sort(clients)
+	return &UsageHandler{
+		usage:   usage,
+		clients: clients,
+		logger:  logger,
+	}
+}
+
+// UsageQuery holds the query parameters accepted by GetUsage. Days are in
+// UTC.
+type UsageQuery struct {
+	Client string     `form:"client" binding:"omitempty,max=100"`
+	From   *time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To     *time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+}
+
+// UsageResponse is the usage of the clients over a range of days.
+type UsageResponse struct {
+	From    string                `json:"from"`
+	To      string                `json:"to"`
+	Clients []*models.ClientUsage `json:"clients"`
+}
+
+// GetUsage godoc
+// @Summary Report the usage of the API clients
+// @Description Sums the requests of each API client per day and route over a range of days, in UTC, for billing. Defaults to the current month. Requires admin credentials.
+// @Tags admin
+// @Produce json
+// @Param client query string false "Only this client"
+// @Param from query string false "First day (YYYY-MM-DD), the first day of the month by default"
+// @Param to query string false "Last day (YYYY-MM-DD), today by default"
+// @Param X-Admin-Key header string true "Admin API key"
+// @Success 200 {object} UsageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Unknown client"
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse "Client quotas are disabled"
+// @Router /api/admin/usage [get]
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	if h.usage == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Client usage is not tracked"})
+		return
+	}
+
+	var query UsageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		writeQueryError(c, queryFieldErrors(err, query))
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if query.From != nil {
+		from = *query.From
+	}
+	to := now
+	if query.To != nil {
+		to = *query.To
+	}
+	if to.Before(from) {
+		writeQueryError(c, []middlewares.FieldError{{Field: "to", Message: "must not be before from"}})
+		return
+	}
+	if to.Sub(from) >= maxUsageDays*24*time.Hour {
+		writeQueryError(c, []middlewares.FieldError{{Field: "to", Message: "must be within 366 days of from"}})
+		return
+	}
+
+	clients := h.clients
+	if query.Client != "" {
+		if !slices.Contains(h.clients, query.Client) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown client"})
+			return
+		}
+		clients = []string{query.Client}
+	}
+
+	response := UsageResponse{
+		From:    from.Format("2006-01-02"),
+		To:      to.Format("2006-01-02"),
+		Clients: make([]*models.ClientUsage, 0, len(clients)),
+	}
+	for _, client := range clients {
+		usage, err := h.usage.Usage(c.Request.Context(), client, from, to)
+		if err != nil {
+			h.logger.Error("Failed to read client usage",
+				zap.String("client", client),
+				zap.String("cause", err.Cause),
+				zap.String("requestId", getRequestID(c)),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error - Failed to read client usage"})
+			return
+		}
+		response.Clients = append(response.Clients, usage)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,105 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"orders/internal/handlers"
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeUsage devuelve una petición diaria por cliente y guarda los rangos leídos
+type fakeUsage struct {
+	ranges []string
+}
+
+func (f *fakeUsage) Usage(_ context.Context, client string, from, to time.Time) (*models.ClientUsage, *repositories.RepositoryError) {
+	f.ranges = append(f.ranges, client+" "+from.Format("2006-01-02")+" "+to.Format("2006-01-02"))
+	return &models.ClientUsage{Client: client, Requests: 1, Days: []models.DailyUsage{
+		{Date: from.Format("2006-01-02"), Requests: 1, Routes: map[string]int64{"GET /api/orders": 1}},
+	}}, nil
+}
+
+func getUsage(t *testing.T, usage handlers.UsageReader, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	handler := handlers.NewUsageHandler(usage, []string{"partner", "mobile"}, zap.NewNop())
+	router := gin.New()
+	router.GET("/api/admin/usage", handler.GetUsage)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/admin/usage"+query, nil))
+	return w
+}
+
+func TestUsageHandler_GetUsage(t *testing.T) {
+	t.Run("Every client", func(t *testing.T) {
+		usage := &fakeUsage{}
+
+		w := getUsage(t, usage, "?from=2025-03-01&to=2025-03-31")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response handlers.UsageResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "2025-03-01", response.From)
+		assert.Equal(t, "2025-03-31", response.To)
+		require.Len(t, response.Clients, 2)
+		assert.Equal(t, []string{"mobile 2025-03-01 2025-03-31", "partner 2025-03-01 2025-03-31"}, usage.ranges)
+	})
+
+	t.Run("One client", func(t *testing.T) {
+		usage := &fakeUsage{}
+
+		w := getUsage(t, usage, "?client=partner&from=2025-03-01&to=2025-03-02")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"partner 2025-03-01 2025-03-02"}, usage.ranges)
+	})
+
+	t.Run("Current month by default", func(t *testing.T) {
+		usage := &fakeUsage{}
+
+		w := getUsage(t, usage, "?client=partner")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		now := time.Now().UTC()
+		assert.Equal(t, []string{"partner " + now.Format("2006-01") + "-01 " + now.Format("2006-01-02")}, usage.ranges)
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{"Unknown client", "?client=other", http.StatusNotFound},
+		{"Invalid day", "?from=2025-03-01T00:00:00Z", http.StatusBadRequest},
+		{"To before from", "?from=2025-03-02&to=2025-03-01", http.StatusBadRequest},
+		{"Range too long", "?from=2024-01-01&to=2025-03-01", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := &fakeUsage{}
+
+			w := getUsage(t, usage, tt.query)
+
+			assert.Equal(t, tt.expected, w.Code)
+			assert.Empty(t, usage.ranges)
+		})
+	}
+
+	t.Run("Quotas disabled", func(t *testing.T) {
+		w := getUsage(t, nil, "")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"orders/internal/middlewares"
+	"orders/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// orderIDParam returns the order ID path parameter in its canonical lowercase
+// form. Order IDs are UUIDs and may be sent in any casing, braced or as a
+// URN; anything else is answered with 400 INVALID_UUID and ok is false.
+func orderIDParam(c *gin.Context) (orderID string, ok bool) {
+	orderID, ok = models.CanonicalUUID(c.Param("id"))
+	if !ok {
+		writeInvalidUUID(c, "id")
+	}
+	return orderID, ok
+}
+
+// invalidUUIDField returns the field of a binding error that holds a
+// malformed UUID, or "" when the error is about something else. Only
+// customer IDs are bound as UUIDs, when that is their format.
+func invalidUUIDField(err error) string {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) || !customerIDsAreUUIDs() {
+		return ""
+	}
+	for _, fe := range validationErrs {
+		if fe.Tag() == "customerid" {
+			return "customerId"
+		}
+	}
+	return ""
+}
+
+// checkCustomerIDQuery answers 400 INVALID_UUID and returns false when the
+// customerId query parameter is not a UUID but customer IDs must be.
+func checkCustomerIDQuery(c *gin.Context) bool {
+	customerID := c.Query("customerId")
+	if customerID == "" || !customerIDsAreUUIDs() {
+		return true
+	}
+	if _, ok := models.CanonicalUUID(customerID); !ok {
+		writeInvalidUUID(c, "customerId")
+		return false
+	}
+	return true
+}
+
+func writeInvalidUUID(c *gin.Context, field string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "Invalid UUID",
+		"code":   "INVALID_UUID",
+		"fields": []middlewares.FieldError{{Field: field, Message: "must be a valid UUID"}},
+	})
+}
@@ -0,0 +1,195 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"orders/internal/correlation"
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// messageReader is the part of kafka.Reader used by the consumer.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// OrderStatusUpdater is the part of the order service used to apply delivery
+// confirmations.
+type OrderStatusUpdater interface {
+	GetOrderByID(ctx context.Context, orderID string) (*models.Order, *services.ServiceError)
+	UpdateOrderStatus(ctx context.Context, orderID string, newStatus models.OrderStatus) (*models.Order, *services.ServiceError)
+}
+
+// DeliveryConfirmation is the message the logistics partner publishes when
+// an order has been handed over to the customer.
+type DeliveryConfirmation struct {
+	ConfirmationID string    `json:"confirmationId"`
+	OrderID        string    `json:"orderId"`
+	DeliveredAt    time.Time `json:"deliveredAt"`
+}
+
+// DeliveryConsumer moves orders to DELIVERED as delivery confirmations arrive.
+// Confirmations for orders that are already delivered, or that cannot be
+// delivered, are skipped; transient failures are retried.
+type DeliveryConsumer struct {
+	reader     messageReader
+	orders     OrderStatusUpdater
+	retryDelay time.Duration
+	logger     *zap.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDeliveryConsumer creates a consumer of the given topic in the consumer group.
+func NewDeliveryConsumer(brokers []string, topic, groupID string, orders OrderStatusUpdater, logger *zap.Logger) *DeliveryConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: brokers,
+		Topic:   topic,
+		GroupID: groupID,
+	})
+	return newDeliveryConsumer(reader, orders, time.Second, logger)
+}
+
+func newDeliveryConsumer(reader messageReader, orders OrderStatusUpdater, retryDelay time.Duration, logger *zap.Logger) *DeliveryConsumer {
+	return &DeliveryConsumer{
+		reader:     reader,
+		orders:     orders,
+		retryDelay: retryDelay,
+		logger:     logger,
+	}
+}
+
+// Start consumes confirmations in the background until Stop is called.
+func (c *DeliveryConsumer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.run(ctx)
+	}()
+}
+
+// Stop signals the consumer to exit, waits for the current message and
+// closes the reader.
+func (c *DeliveryConsumer) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+	_ = c.reader.Close()
+}
+
+func (c *DeliveryConsumer) run(ctx context.Context) {
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Error("Failed to fetch delivery confirmation", zap.Error(err))
+			if !c.wait(ctx) {
+				return
+			}
+			continue
+		}
+
+		// Transient failures are retried until they succeed or the consumer stops
+		for {
+			err := c.handle(ctx, msg)
+			if err == nil {
+				break
+			}
+			c.logger.Warn("Failed to apply delivery confirmation, retrying",
+				zap.Error(err),
+				zap.Int64("offset", msg.Offset),
+			)
+			if !c.wait(ctx) {
+				return
+			}
+		}
+
+		if err := c.reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			c.logger.Error("Failed to commit delivery confirmation",
+				zap.Error(err),
+				zap.Int64("offset", msg.Offset),
+			)
+		}
+	}
+}
+
+// wait sleeps for the retry delay, reporting false if the consumer stopped.
+func (c *DeliveryConsumer) wait(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(c.retryDelay):
+		return true
+	}
+}
+
+// handle applies a confirmation. It only returns an error for failures worth
+// retrying; malformed, duplicate and inapplicable confirmations are logged
+// and skipped.
+func (c *DeliveryConsumer) handle(ctx context.Context, msg kafka.Message) error {
+	var confirmation DeliveryConfirmation
+	if err := json.Unmarshal(msg.Value, &confirmation); err != nil || confirmation.OrderID == "" {
+		c.logger.Warn("Skipping malformed delivery confirmation", zap.Int64("offset", msg.Offset))
+		return nil
+	}
+
+	if confirmation.ConfirmationID != "" {
+		ctx = correlation.WithID(ctx, confirmation.ConfirmationID)
+	}
+	logger := c.logger.With(
+		zap.String("orderId", confirmation.OrderID),
+		zap.String("confirmationId", confirmation.ConfirmationID),
+	)
+
+	order, svcErr := c.orders.GetOrderByID(ctx, confirmation.OrderID)
+	if svcErr != nil {
+		if retryable(svcErr) {
+			return svcErr
+		}
+		logger.Warn("Skipping delivery confirmation for unknown order", zap.String("message", svcErr.Message))
+		return nil
+	}
+
+	// Duplicate or late confirmations: the order already went past delivery
+	switch order.Status {
+	case models.StatusDelivered, models.StatusReturnRequested, models.StatusReturned:
+		logger.Info("Order already delivered, skipping confirmation", zap.String("status", string(order.Status)))
+		return nil
+	}
+
+	if _, svcErr := c.orders.UpdateOrderStatus(ctx, confirmation.OrderID, models.StatusDelivered); svcErr != nil {
+		if retryable(svcErr) {
+			return svcErr
+		}
+		logger.Warn("Delivery confirmation not applicable, skipping",
+			zap.String("status", string(order.Status)),
+			zap.String("message", svcErr.Message),
+		)
+		return nil
+	}
+
+	logger.Info("Order delivered from confirmation")
+	return nil
+}
+
+// retryable reports whether a service error is transient, e.g. the database
+// being unavailable, rather than a rejection of the confirmation.
+func retryable(err *services.ServiceError) bool {
+	return err.Status >= http.StatusInternalServerError
+}
@@ -0,0 +1,113 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeReader entrega los mensajes encolados y registra los confirmados
+type fakeReader struct {
+	mu        sync.Mutex
+	messages  chan kafka.Message
+	committed []kafka.Message
+}
+
+func newFakeReader(msgs ...kafka.Message) *fakeReader {
+	r := &fakeReader{messages: make(chan kafka.Message, len(msgs))}
+	for _, msg := range msgs {
+		r.messages <- msg
+	}
+	return r
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case msg := <-r.messages:
+		return msg, nil
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	return nil
+}
+
+func (r *fakeReader) committedCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.committed)
+}
+
+// fakeOrders simula el servicio de pedidos con los estados dados
+type fakeOrders struct {
+	mu       sync.Mutex
+	statuses map[string]models.OrderStatus
+	updates  []string
+}
+
+func (f *fakeOrders) GetOrderByID(_ context.Context, orderID string) (*models.Order, *services.ServiceError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status, ok := f.statuses[orderID]
+	if !ok {
+		return nil, &services.ServiceError{Status: 404, Message: "Order not found"}
+	}
+	return &models.Order{ID: orderID, Status: status}, nil
+}
+
+func (f *fakeOrders) UpdateOrderStatus(_ context.Context, orderID string, newStatus models.OrderStatus) (*models.Order, *services.ServiceError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updates = append(f.updates, orderID)
+	f.statuses[orderID] = newStatus
+	return &models.Order{ID: orderID, Status: newStatus}, nil
+}
+
+func TestDeliveryConsumer_AppliesConfirmations(t *testing.T) {
+	orders := &fakeOrders{statuses: map[string]models.OrderStatus{
+		"order-in-progress": models.StatusInProgress,
+		"order-delivered":   models.StatusDelivered,
+	}}
+	reader := newFakeReader(
+		kafka.Message{Offset: 1, Value: []byte(`{"confirmationId":"c-1","orderId":"order-in-progress"}`)},
+		kafka.Message{Offset: 2, Value: []byte(`{"confirmationId":"c-2","orderId":"order-delivered"}`)},
+		// A duplicate confirmation arriving after the order was delivered
+		kafka.Message{Offset: 3, Value: []byte(`{"confirmationId":"c-1","orderId":"order-in-progress"}`)},
+	)
+	consumer := newDeliveryConsumer(reader, orders, time.Millisecond, zap.NewNop())
+
+	consumer.Start()
+	assert.Eventually(t, func() bool { return reader.committedCount() == 3 }, time.Second, 5*time.Millisecond)
+	consumer.Stop()
+
+	assert.Equal(t, []string{"order-in-progress"}, orders.updates)
+	assert.Equal(t, models.StatusDelivered, orders.statuses["order-in-progress"])
+}
+
+func TestDeliveryConsumer_Handle(t *testing.T) {
+	orders := &fakeOrders{statuses: map[string]models.OrderStatus{"order-delivered": models.StatusDelivered}}
+	consumer := newDeliveryConsumer(newFakeReader(), orders, time.Millisecond, zap.NewNop())
+
+	// Already delivered orders, unknown orders and malformed messages are skipped
+	assert.NoError(t, consumer.handle(context.Background(), kafka.Message{Value: []byte(`{"orderId":"order-delivered"}`)}))
+	assert.NoError(t, consumer.handle(context.Background(), kafka.Message{Value: []byte(`{"orderId":"order-unknown"}`)}))
+	assert.NoError(t, consumer.handle(context.Background(), kafka.Message{Value: []byte(`not json`)}))
+	assert.Empty(t, orders.updates)
+}
@@ -0,0 +1,89 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// BrokerInfo describes a broker of the Kafka cluster.
+type BrokerInfo struct {
+	ID         int    `json:"id"`
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	Rack       string `json:"rack,omitempty"`
+	Controller bool   `json:"controller"`
+}
+
+// metadataConn is the part of kafka.Conn used to read the cluster metadata.
+type metadataConn interface {
+	Brokers() ([]kafka.Broker, error)
+	Controller() (kafka.Broker, error)
+	Close() error
+}
+
+// dialFunc connects to a broker, bounded by the deadline of ctx.
+type dialFunc func(ctx context.Context, address string) (metadataConn, error)
+
+func dialBroker(ctx context.Context, address string) (metadataConn, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// DescribeBrokers lists the brokers of the cluster from the metadata of the
+// first configured broker that answers.
+func DescribeBrokers(ctx context.Context, brokers []string) ([]BrokerInfo, error) {
+	return describeBrokers(ctx, brokers, dialBroker)
+}
+
+func describeBrokers(ctx context.Context, brokers []string, dial dialFunc) ([]BrokerInfo, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("no brokers configured")
+	}
+	var errs []error
+	for _, address := range brokers {
+		conn, err := dial(ctx, address)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", address, err))
+			continue
+		}
+		infos, err := readBrokers(conn)
+		_ = conn.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", address, err))
+			continue
+		}
+		return infos, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+func readBrokers(conn metadataConn) ([]BrokerInfo, error) {
+	brokers, err := conn.Brokers()
+	if err != nil {
+		return nil, err
+	}
+	controller, err := conn.Controller()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]BrokerInfo, len(brokers))
+	for i, broker := range brokers {
+		infos[i] = BrokerInfo{
+			ID:         broker.ID,
+			Host:       broker.Host,
+			Port:       broker.Port,
+			Rack:       broker.Rack,
+			Controller: broker.ID == controller.ID,
+		}
+	}
+	return infos, nil
+}
@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMetadataConn devuelve los metadatos de un clúster de dos brokers
+type fakeMetadataConn struct {
+	closed bool
+}
+
+func (f *fakeMetadataConn) Brokers() ([]kafka.Broker, error) {
+	return []kafka.Broker{
+		{ID: 1, Host: "kafka-1", Port: 9092, Rack: "a"},
+		{ID: 2, Host: "kafka-2", Port: 9092, Rack: "b"},
+	}, nil
+}
+
+func (f *fakeMetadataConn) Controller() (kafka.Broker, error) {
+	return kafka.Broker{ID: 2, Host: "kafka-2", Port: 9092}, nil
+}
+
+func (f *fakeMetadataConn) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestDescribeBrokers(t *testing.T) {
+	conn := &fakeMetadataConn{}
+	var dialed []string
+	dial := func(_ context.Context, address string) (metadataConn, error) {
+		dialed = append(dialed, address)
+		if address == "kafka-down:9092" {
+			return nil, errors.New("connection refused")
+		}
+		return conn, nil
+	}
+
+	// El primer broker no responde y se pregunta al siguiente
+	brokers, err := describeBrokers(context.Background(), []string{"kafka-down:9092", "kafka-1:9092"}, dial)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"kafka-down:9092", "kafka-1:9092"}, dialed)
+	assert.Equal(t, []BrokerInfo{
+		{ID: 1, Host: "kafka-1", Port: 9092, Rack: "a"},
+		{ID: 2, Host: "kafka-2", Port: 9092, Rack: "b", Controller: true},
+	}, brokers)
+	assert.True(t, conn.closed)
+
+	_, err = describeBrokers(context.Background(), []string{"kafka-down:9092"}, dial)
+	assert.ErrorContains(t, err, "kafka-down:9092: connection refused")
+}
@@ -0,0 +1,79 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"orders/internal/models"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+const (
+	headerNotificationID = "notification-id"
+	headerTemplate       = "template"
+	headerError          = "error"
+)
+
+// Notifier publishes customer notification requests with the producer, to
+// the topics routed for models.NotificationRequested and, once they cannot
+// be published, models.NotificationDeadLettered.
+type Notifier struct {
+	producer *Producer
+}
+
+func NewNotifier(producer *Producer) *Notifier {
+	return &Notifier{producer: producer}
+}
+
+// Notify publishes the notification request.
+func (n *Notifier) Notify(ctx context.Context, request *models.NotificationRequest) error {
+	return n.publish(ctx, models.NotificationRequested, request)
+}
+
+// DeadLetter publishes a notification request that could not be published,
+// along with the last error, so it can be inspected and sent again.
+func (n *Notifier) DeadLetter(ctx context.Context, request *models.NotificationRequest, cause string) error {
+	return n.publish(ctx, models.NotificationDeadLettered, request, kafka.Header{Key: headerError, Value: []byte(cause)})
+}
+
+func (n *Notifier) publish(ctx context.Context, messageType models.EventType, request *models.NotificationRequest, extraHeaders ...kafka.Header) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification request: %w", err)
+	}
+
+	// Keyed by order, so the notifications of an order keep their order
+	topic := n.producer.topicFor(messageType)
+	message := kafka.Message{
+		Topic: topic,
+		Key:   []byte(request.OrderNumber),
+		Value: data,
+		Headers: append([]kafka.Header{
+			{Key: headerEventType, Value: []byte(messageType)},
+			{Key: headerNotificationID, Value: []byte(request.NotificationID)},
+			{Key: headerTemplate, Value: []byte(request.Template)},
+			{Key: headerTenantID, Value: []byte(request.TenantID)},
+		}, extraHeaders...),
+	}
+
+	if err := n.producer.write(ctx, message); err != nil {
+		n.producer.logger.Error("Failed to publish notification request",
+			zap.Error(err),
+			zap.String("notificationId", request.NotificationID),
+			zap.String("orderId", request.OrderNumber),
+			zap.String("topic", topic),
+		)
+		return fmt.Errorf("failed to publish notification request: %w", err)
+	}
+
+	n.producer.logger.Info("Notification request published",
+		zap.String("notificationId", request.NotificationID),
+		zap.String("template", request.Template),
+		zap.String("orderId", request.OrderNumber),
+		zap.String("topic", topic),
+	)
+	return nil
+}
@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"orders/internal/models"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNotifier_PublishesRoutedRequests(t *testing.T) {
+	writer := &fakeWriter{}
+	producer := newProducer(writer, "orders.events", map[string]string{
+		string(models.NotificationRequested):    "notifications.requests",
+		string(models.NotificationDeadLettered): "notifications.requests.dlq",
+	}, zap.NewNop())
+	notifier := NewNotifier(producer)
+
+	order := &models.Order{
+		ID:               "order-123",
+		CustomerID:       "customer-456",
+		TenantID:         "acme",
+		Status:           models.StatusDelivered,
+		CustomerSnapshot: &models.CustomerSnapshot{Email: "ana@example.com", Name: "Ana", Phone: "+34600000000"},
+	}
+	request := models.NewNotificationRequest(order, models.TemplateOrderDelivered)
+
+	require.NoError(t, notifier.Notify(context.Background(), request))
+	require.NoError(t, notifier.DeadLetter(context.Background(), request, "broker unavailable"))
+	require.Len(t, writer.messages, 2)
+
+	// La petición va al topic de notificaciones, con la clave del pedido
+	message := writer.messages[0]
+	assert.Equal(t, "notifications.requests", message.Topic)
+	assert.Equal(t, "order-123", string(message.Key))
+	headers := make(map[string]string)
+	for _, header := range message.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	assert.Equal(t, "NOTIFICATION_REQUESTED", headers[headerEventType])
+	assert.Equal(t, request.NotificationID, headers[headerNotificationID])
+	assert.Equal(t, "order.delivered", headers[headerTemplate])
+	assert.Equal(t, "acme", headers[headerTenantID])
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(message.Value, &body))
+	assert.Equal(t, "order-123", body["orderNumber"])
+	assert.Equal(t, "customer-456", body["customerId"])
+	assert.Equal(t, "order.delivered", body["template"])
+	assert.Equal(t, map[string]interface{}{"email": "ana@example.com", "name": "Ana", "phone": "+34600000000"}, body["contact"])
+
+	// y la que no se pudo publicar al topic de mensajes muertos, con el error
+	deadLetter := writer.messages[1]
+	assert.Equal(t, "notifications.requests.dlq", deadLetter.Topic)
+	assert.Equal(t, message.Value, deadLetter.Value)
+	assert.Contains(t, deadLetter.Headers, kafka.Header{Key: headerError, Value: []byte("broker unavailable")})
+}
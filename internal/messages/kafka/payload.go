@@ -0,0 +1,160 @@
+package kafka
+
+import (
+	"time"
+
+	"orders/internal/models"
+)
+
+// OrderEventPayload is an order as published in CDC envelopes. It is kept
+// apart from models.Order, which is also stored, cached and served by the
+// API, so consumers keep receiving the same fields when any of those change.
+type OrderEventPayload struct {
+	OrderID            string                   `json:"orderId"`
+	TenantID           string                   `json:"tenantId,omitempty"`
+	CustomerID         string                   `json:"customerId"`
+	Status             models.OrderStatus       `json:"status"`
+	Priority           models.OrderPriority     `json:"priority"`
+	Channel            models.OrderChannel      `json:"channel,omitempty"`
+	Items              []OrderItemPayload       `json:"items"`
+	Tags               []string                 `json:"tags,omitempty"`
+	TotalAmount        float64                  `json:"totalAmount"`
+	Notes              string                   `json:"notes,omitempty"`
+	NoteEntries        []OrderNotePayload       `json:"noteEntries,omitempty"`
+	Version            int                      `json:"version"`
+	CreatedAt          time.Time                `json:"createdAt"`
+	UpdatedAt          time.Time                `json:"updatedAt"`
+	DeletedAt          *time.Time               `json:"deletedAt,omitempty"`
+	ClientMetadata     map[string]string        `json:"clientMetadata,omitempty"`
+	CustomerSnapshot   *CustomerSnapshotPayload `json:"customerSnapshot,omitempty"`
+	TotalWeightGrams   int                      `json:"totalWeightGrams"`
+	TotalVolumeCm3     int                      `json:"totalVolumeCm3"`
+	PromisedDeliveryAt *time.Time               `json:"promisedDeliveryAt,omitempty"`
+	DeliveredAt        *time.Time               `json:"deliveredAt,omitempty"`
+	Return             *OrderReturnPayload      `json:"return,omitempty"`
+	BreachedSLA        bool                     `json:"breachedSLA"`
+	AllowedTransitions []models.OrderStatus     `json:"allowedTransitions"`
+}
+
+// OrderItemPayload is a line of an OrderEventPayload.
+type OrderItemPayload struct {
+	SKU               string     `json:"sku"`
+	Quantity          int        `json:"quantity"`
+	Price             float64    `json:"price"`
+	WeightGrams       int        `json:"weightGrams,omitempty"`
+	VolumeCm3         int        `json:"volumeCm3,omitempty"`
+	DeliveredQuantity int        `json:"deliveredQuantity"`
+	PriceSnapshotAt   *time.Time `json:"priceSnapshotAt,omitempty"`
+}
+
+// OrderNotePayload is a note of an OrderEventPayload.
+type OrderNotePayload struct {
+	Author    string    `json:"author,omitempty"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CustomerSnapshotPayload is the customer contact data of an
+// OrderEventPayload.
+type CustomerSnapshotPayload struct {
+	Email string `json:"email,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// OrderReturnPayload is the return of an OrderEventPayload.
+type OrderReturnPayload struct {
+	Reason      string              `json:"reason"`
+	Items       []ReturnItemPayload `json:"items"`
+	RequestedAt time.Time           `json:"requestedAt"`
+	ReturnedAt  *time.Time          `json:"returnedAt,omitempty"`
+}
+
+// ReturnItemPayload is a returned quantity of a SKU.
+type ReturnItemPayload struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+// cdcPayload is a models.CDCEnvelope as published.
+type cdcPayload struct {
+	Op     string             `json:"op"`
+	Before *OrderEventPayload `json:"before"`
+	After  *OrderEventPayload `json:"after"`
+	TS     time.Time          `json:"ts"`
+	Source models.CDCSource   `json:"source"`
+}
+
+// ToOrderEventPayload returns the order as published, with BreachedSLA and
+// AllowedTransitions computed at now. A nil order is published as null.
+func ToOrderEventPayload(order *models.Order, now time.Time) *OrderEventPayload {
+	if order == nil {
+		return nil
+	}
+	payload := &OrderEventPayload{
+		OrderID:            order.ID,
+		TenantID:           order.TenantID,
+		CustomerID:         order.CustomerID,
+		Status:             order.Status,
+		Priority:           order.Priority,
+		Channel:            order.Channel,
+		Tags:               order.Tags,
+		TotalAmount:        order.TotalAmount,
+		Notes:              order.Notes,
+		Version:            order.Version,
+		CreatedAt:          order.CreatedAt,
+		UpdatedAt:          order.UpdatedAt,
+		DeletedAt:          order.DeletedAt,
+		ClientMetadata:     order.ClientMetadata,
+		TotalWeightGrams:   order.TotalWeightGrams,
+		TotalVolumeCm3:     order.TotalVolumeCm3,
+		PromisedDeliveryAt: order.PromisedDeliveryAt,
+		DeliveredAt:        order.DeliveredAt,
+		BreachedSLA:        order.IsSLABreached(now),
+		AllowedTransitions: order.Status.Transitions(),
+	}
+	if order.Items != nil {
+		payload.Items = make([]OrderItemPayload, len(order.Items))
+		for i, item := range order.Items {
+			payload.Items[i] = OrderItemPayload{
+				SKU:               item.SKU,
+				Quantity:          item.Quantity,
+				Price:             item.Price,
+				WeightGrams:       item.WeightGrams,
+				VolumeCm3:         item.VolumeCm3,
+				DeliveredQuantity: item.DeliveredQuantity,
+				PriceSnapshotAt:   item.PriceSnapshotAt,
+			}
+		}
+	}
+	if order.NoteEntries != nil {
+		payload.NoteEntries = make([]OrderNotePayload, len(order.NoteEntries))
+		for i, note := range order.NoteEntries {
+			payload.NoteEntries[i] = OrderNotePayload{Author: note.Author, Text: note.Text, CreatedAt: note.CreatedAt}
+		}
+	}
+	if snapshot := order.CustomerSnapshot; snapshot != nil {
+		payload.CustomerSnapshot = &CustomerSnapshotPayload{Email: snapshot.Email, Name: snapshot.Name, Phone: snapshot.Phone}
+	}
+	if ret := order.Return; ret != nil {
+		payload.Return = &OrderReturnPayload{Reason: ret.Reason, RequestedAt: ret.RequestedAt, ReturnedAt: ret.ReturnedAt}
+		if ret.Items != nil {
+			payload.Return.Items = make([]ReturnItemPayload, len(ret.Items))
+			for i, item := range ret.Items {
+				payload.Return.Items[i] = ReturnItemPayload{SKU: item.SKU, Quantity: item.Quantity}
+			}
+		}
+	}
+	return payload
+}
+
+// toCDCPayload returns the envelope as published.
+func toCDCPayload(envelope *models.CDCEnvelope, now time.Time) *cdcPayload {
+	return &cdcPayload{
+		Op:     envelope.Op,
+		Before: ToOrderEventPayload(envelope.Before, now),
+		After:  ToOrderEventPayload(envelope.After, now),
+		TS:     envelope.TS,
+		Source: envelope.Source,
+	}
+}
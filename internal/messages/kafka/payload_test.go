@@ -0,0 +1,114 @@
+package kafka
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"orders/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// El fichero golden recoge el sobre CDC que reciben los consumidores; si
+// cambia, hay que acordarlo con ellos:
+//
+//	go test ./internal/messages/kafka -run Golden -update
+var update = flag.Bool("update", false, "rewrite the golden payloads")
+
+// publishedOrder devuelve un pedido con todos los campos que se publican
+func publishedOrder() *models.Order {
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 123000000, time.UTC)
+	deliveredAt := createdAt.Add(26 * time.Hour)
+	promisedAt := createdAt.Add(48 * time.Hour)
+	notifiedAt := createdAt.Add(50 * time.Hour)
+	deletedAt := createdAt.Add(72 * time.Hour)
+	return &models.Order{
+		ID:         "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+		TenantID:   "brand-a",
+		CustomerID: "customer-1",
+		Status:     models.StatusReturnRequested,
+		Priority:   models.PriorityHigh,
+		Channel:    models.ChannelWeb,
+		Items: []models.OrderItem{
+			{SKU: "SKU-001", Quantity: 3, Price: 0.1, WeightGrams: 250, DeliveredQuantity: 3},
+			{SKU: "SKU-ÑÜ", Quantity: 1, Price: 19.99, VolumeCm3: 1200, DeliveredQuantity: 1, PriceSnapshotAt: &createdAt},
+		},
+		Tags:                []string{"vip", "regalo"},
+		TotalAmount:         20.29,
+		Notes:               "Dejar en portería",
+		NoteEntries:         []models.OrderNote{{Author: "ops", Text: "Cliente avisado", CreatedAt: createdAt}},
+		Version:             4,
+		CreatedAt:           createdAt,
+		UpdatedAt:           deliveredAt,
+		DeletedAt:           &deletedAt,
+		ClientMetadata:      map[string]string{"utm_source": "newsletter"},
+		CustomerSnapshot:    &models.CustomerSnapshot{Email: "jane.doe@example.com", Name: "Jane Doe", Phone: "+34600123456"},
+		TotalWeightGrams:    750,
+		TotalVolumeCm3:      1200,
+		PromisedDeliveryAt:  &promisedAt,
+		DeliveredAt:         &deliveredAt,
+		SLABreachNotifiedAt: &notifiedAt,
+		PriorityRank:        models.PriorityHigh.Rank(),
+		Return: &models.OrderReturn{
+			Reason:      "Talla incorrecta",
+			Items:       []models.ReturnItem{{SKU: "SKU-001", Quantity: 1}},
+			RequestedAt: deliveredAt.Add(time.Hour),
+		},
+		SearchKeys: []string{"sku-001", "sku-ñü"},
+	}
+}
+
+func TestProducer_CDCEnvelopeGolden(t *testing.T) {
+	writer := &fakeWriter{}
+	producer := newProducer(writer, "orders.events", nil, zap.NewNop(), WithFormat(FormatCDC))
+	producer.origin = models.EventOrigin{ServiceVersion: "1.4.0", Environment: "production", Hostname: "orders-0"}
+
+	order := publishedOrder()
+	before := *order
+	before.Status = models.StatusDelivered
+	before.Return = nil
+	before.Version = 3
+	event := &models.OrderEvent{
+		EventID:       "5f0c6b1e-8d1a-4c55-9a57-3f2b8e6c1d20",
+		EventType:     models.EventOrderReturnRequested,
+		OrderID:       order.ID,
+		TenantID:      order.TenantID,
+		CustomerID:    order.CustomerID,
+		OldStatus:     models.StatusDelivered,
+		NewStatus:     models.StatusReturnRequested,
+		Timestamp:     order.UpdatedAt.Add(time.Hour),
+		Metadata:      models.EventMetadata{ChangedBy: "customer-1", Reason: "return_requested"},
+		CorrelationID: "corr-1",
+		CausationID:   "req-1",
+	}
+	require.NoError(t, producer.PublishOrderEvent(context.Background(), event.SetStates(&before, order)))
+	require.Len(t, writer.messages, 1)
+
+	path := filepath.Join("testdata", "golden", "cdc_envelope.json")
+	if *update {
+		require.NoError(t, os.WriteFile(path, writer.messages[0].Value, 0o644))
+	}
+	golden, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), string(writer.messages[0].Value))
+}
+
+func TestToOrderEventPayload(t *testing.T) {
+	assert.Nil(t, ToOrderEventPayload(nil, time.Now()))
+
+	// Un pedido abierto pasado su plazo se publica como incumplido
+	promisedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	order := &models.Order{ID: "order-1", Status: models.StatusInProgress, PromisedDeliveryAt: &promisedAt}
+
+	payload := ToOrderEventPayload(order, promisedAt.Add(time.Minute))
+	assert.True(t, payload.BreachedSLA)
+	assert.Equal(t, order.Status.Transitions(), payload.AllowedTransitions)
+	assert.Nil(t, payload.Items)
+	assert.False(t, ToOrderEventPayload(order, promisedAt).BreachedSLA)
+}
@@ -3,25 +3,109 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"orders/internal/breaker"
 	"orders/internal/models"
+	"orders/internal/timing"
+	"os"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 )
 
+// messageWriter is the part of kafka.Writer used by the producer.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Event formats supported by the producer.
+const (
+	// FormatFlat publishes events as they are, the default.
+	FormatFlat = "flat"
+	// FormatCDC wraps events in a models.CDCEnvelope, with the order before
+	// and after the change as OrderEventPayload.
+	FormatCDC = "cdc"
+)
+
+// ErrCircuitOpen is returned without publishing while the circuit breaker of
+// the producer is open.
+var ErrCircuitOpen = errors.New("kafka producer circuit breaker is open")
+
 // Producer implements a Kafka event producer
 type Producer struct {
-	writer *kafka.Writer
-	logger *zap.Logger
-	topic  string
+	writer  messageWriter
+	logger  *zap.Logger
+	topic   string
+	routes  map[models.EventType]string
+	format  string
+	origin  models.EventOrigin
+	timeout time.Duration
+	breaker *breaker.Breaker
+}
+
+// ProducerOption customizes a Producer.
+type ProducerOption func(*Producer)
+
+// WithFormat sets the format events are published in, FormatFlat or FormatCDC.
+func WithFormat(format string) ProducerOption {
+	return func(p *Producer) {
+		if format != "" {
+			p.format = format
+		}
+	}
+}
+
+// WithOrigin stamps published events with the service version and
+// environment, and with the hostname of the machine running the producer.
+func WithOrigin(serviceVersion, environment string) ProducerOption {
+	return func(p *Producer) {
+		hostname, _ := os.Hostname()
+		p.origin = models.EventOrigin{
+			ServiceVersion: serviceVersion,
+			Environment:    environment,
+			Hostname:       hostname,
+		}
+	}
 }
 
-// NewProducer creates a new Kafka producer instance
-func NewProducer(brokers []string, topic string, logger *zap.Logger) *Producer {
+// WithTopicAutoCreation sets whether publishing to a missing topic creates it.
+// It is enabled by default and only applies to producers created by
+// NewProducer.
+func WithTopicAutoCreation(enabled bool) ProducerOption {
+	return func(p *Producer) {
+		if writer, ok := p.writer.(*kafka.Writer); ok {
+			writer.AllowAutoTopicCreation = enabled
+		}
+	}
+}
+
+// WithPublishTimeout bounds how long publishing an event may wait for the
+// brokers, so a wedged broker does not hold up the caller until the write
+// gives up. 0 leaves publishing bounded by the caller's context only.
+func WithPublishTimeout(timeout time.Duration) ProducerOption {
+	return func(p *Producer) {
+		p.timeout = timeout
+	}
+}
+
+// WithCircuitBreaker makes publishing fail at once with ErrCircuitOpen while
+// the breaker is open, after repeated publish failures.
+func WithCircuitBreaker(b *breaker.Breaker) ProducerOption {
+	return func(p *Producer) {
+		p.breaker = b
+	}
+}
+
+// NewProducer creates a new Kafka producer instance. Events are published to
+// the topic routed for their event type, or to the default topic when the
+// type has no route.
+func NewProducer(brokers []string, topic string, routes map[string]string, logger *zap.Logger, opts ...ProducerOption) *Producer {
+	// The topic is set per message, so the writer must not have one
 	writer := &kafka.Writer{
 		Addr:                   kafka.TCP(brokers...),
-		Topic:                  topic,
 		Balancer:               &kafka.Hash{},    // Use hash to partition by key
 		AllowAutoTopicCreation: true,             // Automatically create topic if not exists
 		RequiredAcks:           kafka.RequireOne, // At-least-once delivery
@@ -29,17 +113,70 @@ func NewProducer(brokers []string, topic string, logger *zap.Logger) *Producer {
 		MaxAttempts:            3,                // Retry on failure
 	}
 
-	return &Producer{
+	return newProducer(writer, topic, routes, logger, opts...)
+}
+
+func newProducer(writer messageWriter, topic string, routes map[string]string, logger *zap.Logger, opts ...ProducerOption) *Producer {
+	eventRoutes := make(map[models.EventType]string, len(routes))
+	for eventType, route := range routes {
+		eventRoutes[models.EventType(eventType)] = route
+	}
+
+	p := &Producer{
 		writer: writer,
 		logger: logger,
 		topic:  topic,
+		routes: eventRoutes,
+		format: FormatFlat,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// topicFor returns the topic events of the given type are published to.
+func (p *Producer) topicFor(eventType models.EventType) string {
+	if topic, ok := p.routes[eventType]; ok {
+		return topic
 	}
+	return p.topic
 }
 
+const (
+	headerEventType = "event-type"
+	headerEventID   = "event-id"
+	headerReplay    = "replay"
+
+	headerCorrelationID = "correlation-id"
+	headerCausationID   = "causation-id"
+	headerTenantID      = "tenant-id"
+)
+
 // PublishOrderEvent publishes an order event to Kafka
 func (p *Producer) PublishOrderEvent(ctx context.Context, event *models.OrderEvent) error {
+	return p.publish(ctx, event)
+}
+
+// RepublishOrderEvent publishes a previously emitted event again, keeping its
+// original event ID and flagging the message with a replay header so
+// consumers can tell it apart from the first delivery.
+func (p *Producer) RepublishOrderEvent(ctx context.Context, event *models.OrderEvent) error {
+	return p.publish(ctx, event, kafka.Header{Key: headerReplay, Value: []byte("true")})
+}
+
+func (p *Producer) publish(ctx context.Context, event *models.OrderEvent, extraHeaders ...kafka.Header) error {
+	// The caller's event is left untouched; the origin is only published
+	stamped := *event
+	stamped.Metadata.EventOrigin = p.origin
+	event = &stamped
+
 	// Marshal event to JSON
-	data, err := json.Marshal(event)
+	var payload interface{} = event
+	if p.format == FormatCDC {
+		payload = toCDCPayload(models.NewCDCEnvelope(event), time.Now())
+	}
+	data, err := json.Marshal(payload)
 	if err != nil {
 		p.logger.Error("Failed to marshal event",
 			zap.Error(err),
@@ -49,22 +186,27 @@ func (p *Producer) PublishOrderEvent(ctx context.Context, event *models.OrderEve
 	}
 
 	// Create Kafka message, using orderID as key to preserve event order per order
+	topic := p.topicFor(event.EventType)
 	message := kafka.Message{
+		Topic: topic,
 		Key:   []byte(event.OrderID),
 		Value: data,
-		Headers: []kafka.Header{
-			{Key: "event-type", Value: []byte(event.EventType)},
-			{Key: "event-id", Value: []byte(event.EventID)},
-		},
+		Headers: append([]kafka.Header{
+			{Key: headerEventType, Value: []byte(event.EventType)},
+			{Key: headerEventID, Value: []byte(event.EventID)},
+			{Key: headerCorrelationID, Value: []byte(event.CorrelationID)},
+			{Key: headerCausationID, Value: []byte(event.CausationID)},
+			{Key: headerTenantID, Value: []byte(event.TenantID)},
+		}, extraHeaders...),
 	}
 
 	// Publish message
-	if err := p.writer.WriteMessages(ctx, message); err != nil {
+	if err := p.write(ctx, message); err != nil {
 		p.logger.Error("Failed to publish event",
 			zap.Error(err),
 			zap.String("eventId", event.EventID),
 			zap.String("orderId", event.OrderID),
-			zap.String("topic", p.topic),
+			zap.String("topic", topic),
 		)
 		return fmt.Errorf("failed to publish event: %w", err)
 	}
@@ -73,12 +215,36 @@ func (p *Producer) PublishOrderEvent(ctx context.Context, event *models.OrderEve
 		zap.String("eventId", event.EventID),
 		zap.String("eventType", string(event.EventType)),
 		zap.String("orderId", event.OrderID),
-		zap.String("topic", p.topic),
+		zap.String("tenantId", event.TenantID),
+		zap.String("topic", topic),
 	)
 
 	return nil
 }
 
+// write hands the message to the writer, within the publish timeout and
+// guarded by the circuit breaker when they are configured. Publishes given up
+// by the caller do not count as failures of the brokers.
+func (p *Producer) write(ctx context.Context, message kafka.Message) error {
+	defer timing.Since(ctx, timing.Kafka, time.Now())
+	if p.breaker != nil && !p.breaker.Allow() {
+		return ErrCircuitOpen
+	}
+
+	writeCtx := ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		writeCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	err := p.writer.WriteMessages(writeCtx, message)
+
+	if p.breaker != nil {
+		p.breaker.Record(err != nil && ctx.Err() == nil)
+	}
+	return err
+}
+
 // Close shuts down the Kafka producer
 func (p *Producer) Close() error {
 	return p.writer.Close()
@@ -0,0 +1,233 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"orders/internal/breaker"
+	"orders/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeWriter guarda los mensajes escritos en lugar de enviarlos al broker
+type fakeWriter struct {
+	messages []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	return nil
+}
+
+// wedgedWriter simula un broker colgado: cada escritura espera hasta que se
+// cancela su contexto
+type wedgedWriter struct {
+	calls int
+}
+
+func (w *wedgedWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.calls++
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (w *wedgedWriter) Close() error {
+	return nil
+}
+
+func TestProducer_CircuitBreaker(t *testing.T) {
+	writer := &wedgedWriter{}
+	b := breaker.New(3, time.Hour, prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_breaker_state"}))
+	producer := newProducer(writer, "orders.events", nil, zap.NewNop(),
+		WithPublishTimeout(20*time.Millisecond),
+		WithCircuitBreaker(b))
+	event := models.NewOrderCreatedEvent(&models.Order{ID: "order-123", CustomerID: "customer-1", Status: models.StatusNew})
+
+	// Cada publicación se abandona al vencer su timeout, y tres seguidas abren el circuito
+	for range 3 {
+		err := producer.PublishOrderEvent(context.Background(), event)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+	assert.Equal(t, breaker.Open, b.State())
+
+	// Con el circuito abierto se falla al momento, sin llegar al broker
+	start := time.Now()
+	err := producer.PublishOrderEvent(context.Background(), event)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, 3, writer.calls)
+}
+
+func TestProducer_CircuitBreakerRecovers(t *testing.T) {
+	writer := &fakeWriter{}
+	b := breaker.New(1, 10*time.Millisecond, prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_breaker_state"}))
+	producer := newProducer(writer, "orders.events", nil, zap.NewNop(), WithCircuitBreaker(b))
+	event := models.NewOrderCreatedEvent(&models.Order{ID: "order-123", CustomerID: "customer-1", Status: models.StatusNew})
+
+	// Una publicación abandonada por quien llama no cuenta como fallo del broker
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	producer.writer = &wedgedWriter{}
+	assert.Error(t, producer.PublishOrderEvent(ctx, event))
+	assert.Equal(t, breaker.Closed, b.State())
+
+	producer.writer = &wedgedWriter{}
+	producer.timeout = time.Millisecond
+	assert.Error(t, producer.PublishOrderEvent(context.Background(), event))
+	assert.Equal(t, breaker.Open, b.State())
+	assert.ErrorIs(t, producer.PublishOrderEvent(context.Background(), event), ErrCircuitOpen)
+
+	// La sonda tras el tiempo de apertura cierra el circuito al publicar
+	time.Sleep(15 * time.Millisecond)
+	producer.writer = writer
+	require.NoError(t, producer.PublishOrderEvent(context.Background(), event))
+	assert.Equal(t, breaker.Closed, b.State())
+	assert.Len(t, writer.messages, 1)
+}
+
+func TestProducer_RoutesEventsByType(t *testing.T) {
+	writer := &fakeWriter{}
+	producer := newProducer(writer, "orders.events", map[string]string{
+		"ORDER_CREATED":        "orders.analytics",
+		"ORDER_STATUS_CHANGED": "orders.fulfillment",
+	}, zap.NewNop())
+
+	order := &models.Order{ID: "order-123", CustomerID: "customer-1", Status: models.StatusNew}
+	events := []*models.OrderEvent{
+		models.NewOrderCreatedEvent(order),
+		models.NewOrderStatusChangedEvent(order.ID, order.CustomerID, models.StatusNew, models.StatusInProgress),
+		models.NewOrderTagsChangedEvent(order, nil),
+	}
+	for _, event := range events {
+		assert.NoError(t, producer.PublishOrderEvent(context.Background(), event))
+	}
+
+	if assert.Len(t, writer.messages, 3) {
+		assert.Equal(t, "orders.analytics", writer.messages[0].Topic)
+		assert.Equal(t, "orders.fulfillment", writer.messages[1].Topic)
+		// Event types without a route go to the default topic.
+		assert.Equal(t, "orders.events", writer.messages[2].Topic)
+		for _, message := range writer.messages {
+			assert.Equal(t, []byte("order-123"), message.Key)
+		}
+	}
+}
+
+func TestProducer_CorrelationHeaders(t *testing.T) {
+	writer := &fakeWriter{}
+	producer := newProducer(writer, "orders.events", nil, zap.NewNop())
+
+	event := models.NewOrderStatusChangedEvent("order-123", "customer-1", models.StatusNew, models.StatusInProgress)
+	event.CorrelationID = "request-1"
+	event.CausationID = "event-1"
+	assert.NoError(t, producer.PublishOrderEvent(context.Background(), event))
+
+	headers := make(map[string]string)
+	for _, header := range writer.messages[0].Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	assert.Equal(t, "request-1", headers["correlation-id"])
+	assert.Equal(t, "event-1", headers["causation-id"])
+}
+
+func TestProducer_TenantHeader(t *testing.T) {
+	writer := &fakeWriter{}
+	producer := newProducer(writer, "orders.events", nil, zap.NewNop())
+
+	event := models.NewOrderStatusChangedEvent("order-123", "customer-1", models.StatusNew, models.StatusInProgress)
+	event.TenantID = "brand-a"
+	assert.NoError(t, producer.PublishOrderEvent(context.Background(), event))
+
+	headers := make(map[string]string)
+	for _, header := range writer.messages[0].Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	assert.Equal(t, "brand-a", headers["tenant-id"])
+
+	var published models.OrderEvent
+	assert.NoError(t, json.Unmarshal(writer.messages[0].Value, &published))
+	assert.Equal(t, "brand-a", published.TenantID)
+}
+
+func TestProducer_CDCEnvelope(t *testing.T) {
+	writer := &fakeWriter{}
+	producer := newProducer(writer, "orders.events", nil, zap.NewNop(), WithFormat(FormatCDC))
+
+	order := &models.Order{ID: "order-123", CustomerID: "customer-1", Status: models.StatusNew}
+	before := order.Clone()
+	order.Status = models.StatusInProgress
+
+	assert.NoError(t, producer.PublishOrderEvent(context.Background(),
+		models.NewOrderCreatedEvent(before).SetStates(nil, before)))
+	assert.NoError(t, producer.PublishOrderEvent(context.Background(),
+		models.NewOrderStatusChangedEvent(order.ID, order.CustomerID, before.Status, order.Status).SetStates(before, order)))
+
+	var created, updated map[string]interface{}
+	assert.NoError(t, json.Unmarshal(writer.messages[0].Value, &created))
+	assert.NoError(t, json.Unmarshal(writer.messages[1].Value, &updated))
+
+	assert.Equal(t, "c", created["op"])
+	assert.Nil(t, created["before"])
+	assert.Equal(t, "NEW", created["after"].(map[string]interface{})["status"])
+	assert.NotEmpty(t, created["ts"])
+
+	assert.Equal(t, "u", updated["op"])
+	assert.Equal(t, "NEW", updated["before"].(map[string]interface{})["status"])
+	assert.Equal(t, "IN_PROGRESS", updated["after"].(map[string]interface{})["status"])
+	assert.Equal(t, "ORDER_STATUS_CHANGED", updated["source"].(map[string]interface{})["eventType"])
+}
+
+func TestProducer_FlatFormatByDefault(t *testing.T) {
+	writer := &fakeWriter{}
+	producer := newProducer(writer, "orders.events", nil, zap.NewNop())
+
+	order := &models.Order{ID: "order-123", CustomerID: "customer-1", Status: models.StatusNew}
+	assert.NoError(t, producer.PublishOrderEvent(context.Background(),
+		models.NewOrderCreatedEvent(order).SetStates(nil, order)))
+
+	var payload map[string]interface{}
+	assert.NoError(t, json.Unmarshal(writer.messages[0].Value, &payload))
+	assert.Equal(t, "ORDER_CREATED", payload["eventType"])
+	assert.NotContains(t, payload, "op")
+	assert.NotContains(t, payload, "after")
+}
+
+func TestProducer_StampsOrigin(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	origin := map[string]interface{}{"serviceVersion": "1.4.2", "environment": "staging", "hostname": hostname}
+
+	for _, format := range []string{FormatFlat, FormatCDC} {
+		t.Run(format, func(t *testing.T) {
+			writer := &fakeWriter{}
+			producer := newProducer(writer, "orders.events", nil, zap.NewNop(), WithFormat(format), WithOrigin("1.4.2", "staging"))
+
+			order := &models.Order{ID: "order-123", CustomerID: "customer-1", Status: models.StatusNew}
+			event := models.NewOrderCreatedEvent(order).SetStates(nil, order)
+			assert.NoError(t, producer.PublishOrderEvent(context.Background(), event))
+
+			var published map[string]interface{}
+			require.NoError(t, json.Unmarshal(writer.messages[0].Value, &published))
+			stamped := published["source"]
+			if format == FormatFlat {
+				stamped = published["metadata"]
+			}
+			for key, value := range origin {
+				assert.Equal(t, value, stamped.(map[string]interface{})[key], key)
+			}
+			assert.Empty(t, event.Metadata.EventOrigin, "the caller's event must not be modified")
+		})
+	}
+}
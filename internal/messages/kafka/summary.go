@@ -0,0 +1,168 @@
+package kafka
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// OrderReader is the part of the order repository used to read the order an
+// event is about.
+type OrderReader interface {
+	FindByID(ctx context.Context, id string) (*models.Order, *repositories.RepositoryError)
+}
+
+// SummaryStore is the part of the orders summary repository kept up to date
+// by the consumer.
+type SummaryStore interface {
+	Upsert(ctx context.Context, record *models.OrderSummaryRecord) (bool, *repositories.RepositoryError)
+	Delete(ctx context.Context, id string) *repositories.RepositoryError
+}
+
+// SummaryConsumer keeps the orders summary up to date from our own order
+// events. Events only say which order changed: the order is read again and
+// its current row written, so duplicate, late and out of order events leave
+// the summary as it should be whatever their format.
+type SummaryConsumer struct {
+	reader     messageReader
+	orders     OrderReader
+	summaries  SummaryStore
+	retryDelay time.Duration
+	logger     *zap.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSummaryConsumer creates a consumer of the order event topics in the
+// consumer group.
+func NewSummaryConsumer(brokers, topics []string, groupID string, orders OrderReader, summaries SummaryStore, logger *zap.Logger) *SummaryConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		GroupTopics: topics,
+		GroupID:     groupID,
+	})
+	return newSummaryConsumer(reader, orders, summaries, time.Second, logger)
+}
+
+func newSummaryConsumer(reader messageReader, orders OrderReader, summaries SummaryStore, retryDelay time.Duration, logger *zap.Logger) *SummaryConsumer {
+	return &SummaryConsumer{
+		reader:     reader,
+		orders:     orders,
+		summaries:  summaries,
+		retryDelay: retryDelay,
+		logger:     logger,
+	}
+}
+
+// Start consumes events in the background until Stop is called.
+func (c *SummaryConsumer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.run(ctx)
+	}()
+}
+
+// Stop signals the consumer to exit, waits for the current event and closes
+// the reader.
+func (c *SummaryConsumer) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+	_ = c.reader.Close()
+}
+
+func (c *SummaryConsumer) run(ctx context.Context) {
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Error("Failed to fetch order event", zap.Error(err))
+			if !c.wait(ctx) {
+				return
+			}
+			continue
+		}
+
+		// Transient failures are retried until they succeed or the consumer stops
+		for {
+			err := c.handle(ctx, msg)
+			if err == nil {
+				break
+			}
+			c.logger.Warn("Failed to update order summary, retrying",
+				zap.Error(err),
+				zap.String("orderId", string(msg.Key)),
+				zap.Int64("offset", msg.Offset),
+			)
+			if !c.wait(ctx) {
+				return
+			}
+		}
+
+		if err := c.reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			c.logger.Error("Failed to commit order event",
+				zap.Error(err),
+				zap.Int64("offset", msg.Offset),
+			)
+		}
+	}
+}
+
+// wait sleeps for the retry delay, reporting false if the consumer stopped.
+func (c *SummaryConsumer) wait(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(c.retryDelay):
+		return true
+	}
+}
+
+// handle writes the summary row of the order of an event, keyed by the order
+// ID, or removes it when the order is gone. It only returns an error for
+// failures worth retrying.
+func (c *SummaryConsumer) handle(ctx context.Context, msg kafka.Message) error {
+	orderID := string(msg.Key)
+	if orderID == "" {
+		c.logger.Warn("Skipping order event without key", zap.Int64("offset", msg.Offset))
+		return nil
+	}
+
+	order, repoErr := c.orders.FindByID(ctx, orderID)
+	if repoErr != nil {
+		if repoErr.StatusCode != http.StatusNotFound && repoErr.StatusCode != http.StatusGone {
+			return repoErr
+		}
+		if repoErr := c.summaries.Delete(ctx, orderID); repoErr != nil {
+			return repoErr
+		}
+		c.logger.Debug("Order gone, summary removed", zap.String("orderId", orderID))
+		return nil
+	}
+
+	written, repoErr := c.summaries.Upsert(ctx, order.SummaryRecord())
+	if repoErr != nil {
+		return repoErr
+	}
+	c.logger.Debug("Order summary updated",
+		zap.String("orderId", orderID),
+		zap.Int("version", order.Version),
+		zap.Bool("written", written),
+	)
+	return nil
+}
@@ -0,0 +1,112 @@
+package kafka
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeOrderReader devuelve los pedidos dados, o el error configurado
+type fakeOrderReader struct {
+	orders map[string]*models.Order
+	err    *repositories.RepositoryError
+}
+
+func (f *fakeOrderReader) FindByID(_ context.Context, id string) (*models.Order, *repositories.RepositoryError) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	order, ok := f.orders[id]
+	if !ok {
+		return nil, &repositories.RepositoryError{StatusCode: http.StatusNotFound, Message: "Order not found"}
+	}
+	return order, nil
+}
+
+// fakeSummaries guarda las filas del resumen respetando la versión
+type fakeSummaries struct {
+	mu      sync.Mutex
+	records map[string]*models.OrderSummaryRecord
+}
+
+func (f *fakeSummaries) Upsert(_ context.Context, record *models.OrderSummaryRecord) (bool, *repositories.RepositoryError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if stored, ok := f.records[record.ID]; ok && stored.Version > record.Version {
+		return false, nil
+	}
+	f.records[record.ID] = record
+	return true, nil
+}
+
+func (f *fakeSummaries) Delete(_ context.Context, id string) *repositories.RepositoryError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.records, id)
+	return nil
+}
+
+func (f *fakeSummaries) get(id string) *models.OrderSummaryRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.records[id]
+}
+
+func TestSummaryConsumer_UpdatesSummary(t *testing.T) {
+	orders := &fakeOrderReader{orders: map[string]*models.Order{
+		"order-1": {ID: "order-1", CustomerID: "customer-1", Status: models.StatusInProgress, TotalAmount: 10, Version: 2},
+	}}
+	summaries := &fakeSummaries{records: map[string]*models.OrderSummaryRecord{
+		"order-gone": {ID: "order-gone"},
+	}}
+	reader := newFakeReader(
+		kafka.Message{Offset: 1, Key: []byte("order-1"), Value: []byte(`{"eventType":"ORDER_CREATED"}`)},
+		kafka.Message{Offset: 2, Key: []byte("order-gone"), Value: []byte(`{}`)},
+		kafka.Message{Offset: 3, Value: []byte(`{}`)},
+	)
+	consumer := newSummaryConsumer(reader, orders, summaries, time.Millisecond, zap.NewNop())
+
+	consumer.Start()
+	assert.Eventually(t, func() bool { return reader.committedCount() == 3 }, time.Second, 5*time.Millisecond)
+	consumer.Stop()
+
+	// La fila refleja el pedido actual, no el evento
+	record := summaries.get("order-1")
+	if assert.NotNil(t, record) {
+		assert.Equal(t, models.StatusInProgress, record.Status)
+		assert.Equal(t, 10.0, record.TotalAmount)
+		assert.Equal(t, 2, record.Version)
+	}
+	assert.Nil(t, summaries.get("order-gone"))
+}
+
+func TestSummaryConsumer_Handle(t *testing.T) {
+	summaries := &fakeSummaries{records: map[string]*models.OrderSummaryRecord{
+		"order-1": {ID: "order-1", Version: 5},
+	}}
+
+	t.Run("Database errors are retried", func(t *testing.T) {
+		orders := &fakeOrderReader{err: &repositories.RepositoryError{StatusCode: http.StatusServiceUnavailable}}
+		consumer := newSummaryConsumer(newFakeReader(), orders, summaries, time.Millisecond, zap.NewNop())
+
+		assert.Error(t, consumer.handle(context.Background(), kafka.Message{Key: []byte("order-1")}))
+		assert.Equal(t, 5, summaries.get("order-1").Version)
+	})
+
+	t.Run("Newer rows are kept", func(t *testing.T) {
+		orders := &fakeOrderReader{orders: map[string]*models.Order{"order-1": {ID: "order-1", Version: 4}}}
+		consumer := newSummaryConsumer(newFakeReader(), orders, summaries, time.Millisecond, zap.NewNop())
+
+		assert.NoError(t, consumer.handle(context.Background(), kafka.Message{Key: []byte("order-1")}))
+		assert.Equal(t, 5, summaries.get("order-1").Version)
+	})
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"orders/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// PublishedEvent is an event captured by the in-memory publisher.
+type PublishedEvent struct {
+	Event models.OrderEvent
+	// Replayed is set for events published through RepublishOrderEvent
+	Replayed bool
+}
+
+// InMemoryPublisher is an event publisher that keeps the last published
+// events in a ring buffer instead of sending them to a broker. It lets the
+// service run without Kafka locally, and tests assert on the events an
+// operation emits.
+type InMemoryPublisher struct {
+	mu     sync.Mutex
+	events []PublishedEvent // ring buffer of capacity size
+	next   int              // slot the next event is written to
+	full   bool
+	logger *zap.Logger
+}
+
+// EventPublisher is where captured events are replayed to.
+type EventPublisher interface {
+	PublishOrderEvent(ctx context.Context, event *models.OrderEvent) error
+}
+
+// NewInMemoryPublisher creates a publisher keeping the last size events.
+func NewInMemoryPublisher(size int, logger *zap.Logger) *InMemoryPublisher {
+	if size <= 0 {
+		size = 1
+	}
+	return &InMemoryPublisher{
+		events: make([]PublishedEvent, size),
+		logger: logger,
+	}
+}
+
+// PublishOrderEvent captures the event.
+func (p *InMemoryPublisher) PublishOrderEvent(ctx context.Context, event *models.OrderEvent) error {
+	p.capture(event, false)
+	return nil
+}
+
+// RepublishOrderEvent captures the event, flagged as replayed.
+func (p *InMemoryPublisher) RepublishOrderEvent(ctx context.Context, event *models.OrderEvent) error {
+	p.capture(event, true)
+	return nil
+}
+
+func (p *InMemoryPublisher) capture(event *models.OrderEvent, replayed bool) {
+	p.mu.Lock()
+	// Copied so later changes of the caller do not alter what was published
+	p.events[p.next] = PublishedEvent{Event: *event, Replayed: replayed}
+	p.next = (p.next + 1) % len(p.events)
+	if p.next == 0 {
+		p.full = true
+	}
+	p.mu.Unlock()
+
+	p.logger.Debug("Event captured in memory",
+		zap.String("eventId", event.EventID),
+		zap.String("eventType", string(event.EventType)),
+		zap.String("orderId", event.OrderID),
+	)
+}
+
+// Events returns the captured events, oldest first.
+func (p *InMemoryPublisher) Events() []PublishedEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.full {
+		return append([]PublishedEvent(nil), p.events[:p.next]...)
+	}
+	events := make([]PublishedEvent, 0, len(p.events))
+	events = append(events, p.events[p.next:]...)
+	return append(events, p.events[:p.next]...)
+}
+
+// EventsFor returns the captured events of an order, oldest first.
+func (p *InMemoryPublisher) EventsFor(orderID string) []PublishedEvent {
+	var events []PublishedEvent
+	for _, published := range p.Events() {
+		if published.Event.OrderID == orderID {
+			events = append(events, published)
+		}
+	}
+	return events
+}
+
+// Replay publishes the captured events again to target, oldest first,
+// stopping at the first error. It moves events captured while running
+// without Kafka to a real producer.
+func (p *InMemoryPublisher) Replay(ctx context.Context, target EventPublisher) error {
+	for _, published := range p.Events() {
+		event := published.Event
+		if err := target.PublishOrderEvent(ctx, &event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset drops the captured events.
+func (p *InMemoryPublisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = make([]PublishedEvent, len(p.events))
+	p.next, p.full = 0, false
+}
@@ -0,0 +1,91 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"orders/internal/messages/memory"
+	"orders/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// recordingPublisher guarda los eventos reenviados por Replay
+type recordingPublisher struct {
+	events []*models.OrderEvent
+	err    error
+}
+
+func (p *recordingPublisher) PublishOrderEvent(ctx context.Context, event *models.OrderEvent) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+func newEvent(orderID string) *models.OrderEvent {
+	return models.NewOrderStatusChangedEvent(orderID, "customer-1", models.StatusNew, models.StatusInProgress)
+}
+
+func TestInMemoryPublisher_CapturesEvents(t *testing.T) {
+	bus := memory.NewInMemoryPublisher(10, zap.NewNop())
+
+	first, second := newEvent("order-1"), newEvent("order-2")
+	assert.NoError(t, bus.PublishOrderEvent(context.Background(), first))
+	assert.NoError(t, bus.RepublishOrderEvent(context.Background(), second))
+
+	// Los cambios posteriores del llamador no alteran lo publicado
+	first.NewStatus = models.StatusCancelled
+
+	events := bus.Events()
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, "order-1", events[0].Event.OrderID)
+		assert.Equal(t, models.StatusInProgress, events[0].Event.NewStatus)
+		assert.False(t, events[0].Replayed)
+		assert.Equal(t, second.EventID, events[1].Event.EventID)
+		assert.True(t, events[1].Replayed)
+	}
+	assert.Len(t, bus.EventsFor("order-2"), 1)
+	assert.Empty(t, bus.EventsFor("order-3"))
+}
+
+func TestInMemoryPublisher_KeepsLastEvents(t *testing.T) {
+	bus := memory.NewInMemoryPublisher(3, zap.NewNop())
+
+	var published []*models.OrderEvent
+	for i := 0; i < 5; i++ {
+		event := newEvent("order-1")
+		published = append(published, event)
+		assert.NoError(t, bus.PublishOrderEvent(context.Background(), event))
+	}
+
+	events := bus.Events()
+	if assert.Len(t, events, 3) {
+		for i, event := range events {
+			assert.Equal(t, published[i+2].EventID, event.Event.EventID)
+		}
+	}
+
+	bus.Reset()
+	assert.Empty(t, bus.Events())
+}
+
+func TestInMemoryPublisher_Replay(t *testing.T) {
+	bus := memory.NewInMemoryPublisher(10, zap.NewNop())
+	first, second := newEvent("order-1"), newEvent("order-2")
+	assert.NoError(t, bus.PublishOrderEvent(context.Background(), first))
+	assert.NoError(t, bus.PublishOrderEvent(context.Background(), second))
+
+	target := &recordingPublisher{}
+	assert.NoError(t, bus.Replay(context.Background(), target))
+	if assert.Len(t, target.events, 2) {
+		assert.Equal(t, first.EventID, target.events[0].EventID)
+		assert.Equal(t, second.EventID, target.events[1].EventID)
+	}
+
+	failing := &recordingPublisher{err: errors.New("broker unavailable")}
+	assert.EqualError(t, bus.Replay(context.Background(), failing), "broker unavailable")
+}
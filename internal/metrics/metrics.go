@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// CacheDriftTotal counts cached orders found out of date with the database.
+var CacheDriftTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "cache_drift_total",
+	Help: "Number of cached orders whose version differed from the database.",
+})
+
+// CacheWriteRetryDroppedTotal counts failed cache writes that could not be
+// queued for retry because the retry queue was full.
+var CacheWriteRetryDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "cache_write_retry_dropped_total",
+	Help: "Number of failed cache writes dropped because the retry queue was full.",
+})
+
+// CacheLookupsTotal counts the lookups of orders and summaries in the cache
+// by result: hit, miss or error.
+var CacheLookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_lookups_total",
+	Help: "Number of cache lookups by entity and result.",
+}, []string{"entity", "result"})
+
+// lifecycleBuckets span from a minute to two weeks, in seconds.
+var lifecycleBuckets = []float64{60, 300, 900, 1800, 3600, 3 * 3600, 6 * 3600, 12 * 3600, 24 * 3600, 2 * 86400, 3 * 86400, 7 * 86400, 14 * 86400}
+
+// OrderPickupDuration measures the time from the creation of an order until
+// it moves from NEW to IN_PROGRESS, by channel.
+var OrderPickupDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "order_pickup_duration_seconds",
+	Help:    "Time from the creation of an order until it is picked up (NEW to IN_PROGRESS).",
+	Buckets: lifecycleBuckets,
+}, []string{"channel"})
+
+// OrderCompletionDuration measures the time from the creation of an order
+// until it reaches a final status, e.g. DELIVERED or CANCELLED, by that status
+// and channel.
+var OrderCompletionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "order_completion_duration_seconds",
+	Help:    "Time from the creation of an order until it reaches a final status.",
+	Buckets: lifecycleBuckets,
+}, []string{"status", "channel"})
+
+// MongoOperationDuration times order repository operations by operation and
+// result, success or error.
+var MongoOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "mongo_operation_duration_seconds",
+	Help:    "Duration of MongoDB order repository operations.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"operation", "result"})
+
+// MongoPoolConnectionsOpen reports the connections open in the MongoDB pool.
+var MongoPoolConnectionsOpen = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "mongo_pool_connections_open",
+	Help: "Number of connections open in the MongoDB pool.",
+})
+
+// MongoPoolConnectionsInUse reports the pool connections checked out.
+var MongoPoolConnectionsInUse = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "mongo_pool_connections_in_use",
+	Help: "Number of MongoDB pool connections checked out.",
+})
+
+// MongoPoolConnectionsCreatedTotal counts the connections opened by the pool,
+// which keeps growing under churn.
+var MongoPoolConnectionsCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "mongo_pool_connections_created_total",
+	Help: "Number of connections created by the MongoDB pool.",
+})
+
+// MongoPoolCheckoutWait times connection checkouts by result, success or
+// error.
+var MongoPoolCheckoutWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "mongo_pool_checkout_wait_seconds",
+	Help:    "Time spent waiting to check out a MongoDB pool connection.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"result"})
+
+// OutboundCircuitBreakerState reports the state of the circuit breaker of
+// every host called by the integrations: 0 closed, 1 half-open, 2 open.
+var OutboundCircuitBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "outbound_circuit_breaker_state",
+	Help: "State of the circuit breaker of each called host: 0 closed, 1 half-open, 2 open.",
+}, []string{"host"})
+
+// MongoCircuitBreakerState reports the state of the MongoDB circuit breaker:
+// 0 closed, 1 half-open, 2 open.
+var MongoCircuitBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "mongo_circuit_breaker_state",
+	Help: "State of the MongoDB circuit breaker: 0 closed, 1 half-open, 2 open.",
+})
+
+// KafkaProducerCircuitBreakerState reports the state of the Kafka producer
+// circuit breaker: 0 closed, 1 half-open, 2 open.
+var KafkaProducerCircuitBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "kafka_producer_circuit_breaker_state",
+	Help: "State of the Kafka producer circuit breaker: 0 closed, 1 half-open, 2 open.",
+})
+
+// InventoryReservationsTotal counts the stock reservations of new orders by
+// outcome: reserved, shortage or error.
+var InventoryReservationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "inventory_reservations_total",
+	Help: "Number of inventory reservations of new orders by outcome.",
+}, []string{"outcome"})
+
+// InventoryReleasesTotal counts the attempts to release the stock of cancelled
+// orders by outcome: released or failed.
+var InventoryReleasesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "inventory_releases_total",
+	Help: "Number of attempts to release the inventory of cancelled orders by outcome.",
+}, []string{"outcome"})
+
+// NotificationRequestsTotal counts the customer notification requests that
+// failed to be published at first by outcome: retried once published by a
+// retry, dead_lettered or dropped.
+var NotificationRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "notification_requests_total",
+	Help: "Number of failed customer notification requests by outcome.",
+}, []string{"outcome"})
+
+// QuotaUnenforcedRequestsTotal counts the requests of API clients let
+// through without checking their quota because Redis was unavailable.
+var QuotaUnenforcedRequestsTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "quota_unenforced_requests_total",
+	Help: "Number of client requests accepted without counting them against their quota.",
+})
+
+// DispatcherLastRunTimestamp is when the inventory release retrier last went
+// over the failed releases, in seconds since the epoch. It keeps the name it
+// was requested under, although order events have no dispatcher.
+var DispatcherLastRunTimestamp = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "dispatcher_last_run_timestamp",
+	Help: "Unix time of the last run of the inventory release retrier; order events are published synchronously and are not covered.",
+})
+
+// FeatureFlagEnabled reports the effective state of each feature flag, 1 when
+// enabled.
+var FeatureFlagEnabled = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "feature_flag_enabled",
+	Help: "Whether each feature flag is enabled.",
+}, []string{"flag"})
+
+// FeatureFlagChangesTotal counts the feature flags changed at runtime.
+var FeatureFlagChangesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "feature_flag_changes_total",
+	Help: "Number of runtime changes of each feature flag.",
+}, []string{"flag"})
+
+// Handler exposes the registered metrics in the Prometheus text format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
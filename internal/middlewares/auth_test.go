@@ -0,0 +1,173 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"orders/internal/middlewares"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/admin", middlewares.RequireAdmin([]string{"s3cret"}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		key      string
+		expected int
+	}{
+		{"Missing key", "", http.StatusUnauthorized},
+		{"Wrong key", "guess", http.StatusForbidden},
+		{"Valid key", "s3cret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin", nil)
+			if tt.key != "" {
+				req.Header.Set(middlewares.AdminAPIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
+
+func TestIdentifyClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/orders", middlewares.IdentifyClient(map[string]string{"mobile": "m0b1le"}), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(middlewares.ClientNameKey))
+	})
+
+	tests := []struct {
+		name           string
+		key            string
+		expectedStatus int
+		expectedClient string
+	}{
+		{"Anonymous", "", http.StatusOK, ""},
+		{"Known key", "m0b1le", http.StatusOK, "mobile"},
+		{"Unknown key", "guess", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.key != "" {
+				req.Header.Set(middlewares.ClientAPIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedClient, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestIdentifyAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/orders/:id", middlewares.IdentifyAdmin([]string{"s3cret"}), func(c *gin.Context) {
+		c.String(http.StatusOK, strconv.FormatBool(c.GetBool(middlewares.AdminKey)))
+	})
+
+	tests := []struct {
+		name           string
+		key            string
+		expectedStatus int
+		expectedAdmin  string
+	}{
+		{"Regular caller", "", http.StatusOK, "false"},
+		{"Admin", "s3cret", http.StatusOK, "true"},
+		{"Wrong key", "guess", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/orders/order-123", nil)
+			if tt.key != "" {
+				req.Header.Set(middlewares.AdminAPIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedAdmin, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGrantScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin", middlewares.GrantScope(middlewares.ScopePIIRead, []string{"pii-key"}), func(c *gin.Context) {
+		c.String(http.StatusOK, strconv.FormatBool(middlewares.HasScope(c, middlewares.ScopePIIRead)))
+	})
+
+	tests := []struct {
+		name     string
+		key      string
+		expected string
+	}{
+		{"No key", "", "false"},
+		{"Key without the scope", "admin-key", "false"},
+		{"Key with the scope", "pii-key", "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.key != "" {
+				req.Header.Set(middlewares.AdminAPIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
+
+func TestActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/admin", middlewares.RequireAdmin([]string{"s3cret", "other"}), func(c *gin.Context) {
+		c.String(http.StatusOK, middlewares.Actor(c))
+	})
+
+	send := func(key string) string {
+		req := httptest.NewRequest(http.MethodPost, "/admin", nil)
+		req.Header.Set(middlewares.AdminAPIKeyHeader, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	// Cada clave se identifica de forma estable sin revelarla
+	actor := send("s3cret")
+	assert.Equal(t, middlewares.AdminKeyID("s3cret"), actor)
+	assert.NotContains(t, actor, "s3cret")
+	assert.Equal(t, actor, send("s3cret"))
+	assert.NotEqual(t, actor, send("other"))
+}
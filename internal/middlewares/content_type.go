@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireContentType answers 415 to requests carrying a body whose
+// Content-Type is not one of allowed, e.g. "application/json" or
+// "application/json; charset=utf-8", instead of letting binding fail on it.
+// Media types and parameters are compared case-insensitively; a type listed
+// without parameters only matches requests sending none. Requests without a
+// body are let through.
+func RequireContentType(allowed []string) gin.HandlerFunc {
+	accepted := make([]string, 0, len(allowed))
+	for _, contentType := range allowed {
+		if normalized, ok := normalizeContentType(contentType); ok {
+			accepted = append(accepted, normalized)
+		}
+	}
+	expected := strings.Join(allowed, " or ")
+
+	return func(c *gin.Context) {
+		if !hasBody(c.Request) {
+			c.Next()
+			return
+		}
+		if normalized, ok := normalizeContentType(c.GetHeader("Content-Type")); ok {
+			for _, contentType := range accepted {
+				if normalized == contentType {
+					c.Next()
+					return
+				}
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "Content-Type must be " + expected,
+			"code":  "UNSUPPORTED_MEDIA_TYPE",
+		})
+	}
+}
+
+// hasBody reports whether the request sends a body, of known length or
+// chunked.
+func hasBody(req *http.Request) bool {
+	return req.ContentLength > 0 || (req.ContentLength < 0 && req.Body != nil && req.Body != http.NoBody)
+}
+
+// normalizeContentType lowercases the media type and parameters of a
+// Content-Type, dropping optional whitespace, so equivalent spellings compare
+// equal.
+func normalizeContentType(contentType string) (string, bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	for key, value := range params {
+		params[key] = strings.ToLower(value)
+	}
+	return mime.FormatMediaType(mediaType, params), true
+}
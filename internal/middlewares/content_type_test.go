@@ -0,0 +1,81 @@
+package middlewares_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"orders/internal/middlewares"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newContentTypeRouter expone un handler que enlaza el cuerpo como JSON
+func newContentTypeRouter(allowed ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middlewares.RequireContentType(allowed))
+	router.POST("/orders", func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.JSON(http.StatusCreated, body)
+	})
+	router.POST("/orders/:id/events:replay", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRequireContentType(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowed        []string
+		contentType    string
+		expectedStatus int
+	}{
+		{"JSON", []string{"application/json"}, "application/json", http.StatusCreated},
+		{"Case insensitive", []string{"application/json"}, "Application/JSON", http.StatusCreated},
+		{"Charset not allowed", []string{"application/json"}, "application/json; charset=utf-8", http.StatusUnsupportedMediaType},
+		{"Charset allowed", []string{"application/json", "application/json; charset=utf-8"}, "application/json;charset=UTF-8", http.StatusCreated},
+		{"Missing", []string{"application/json"}, "", http.StatusUnsupportedMediaType},
+		{"Unsupported", []string{"application/json"}, "text/plain", http.StatusUnsupportedMediaType},
+		{"Malformed", []string{"application/json"}, "application/json; charset", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newContentTypeRouter(tt.allowed...)
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"customerId":"customer-1"}`))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusUnsupportedMediaType {
+				var resp map[string]string
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, "UNSUPPORTED_MEDIA_TYPE", resp["code"])
+				assert.Equal(t, "Content-Type must be "+strings.Join(tt.allowed, " or "), resp["error"])
+			}
+		})
+	}
+}
+
+func TestRequireContentType_NoBody(t *testing.T) {
+	router := newContentTypeRouter("application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders/order-1/events:replay", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
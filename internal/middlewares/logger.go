@@ -22,6 +22,7 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 
 		logger.Info("HTTP Request",
 			zap.String("requestId", requestID.(string)),
+			zap.String("tenantId", c.GetString(TenantKey)),
 			zap.String("method", c.Request.Method),
 			zap.String("path", c.Request.URL.Path),
 			zap.Int("status", c.Writer.Status()),
@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// QuotaCounter counts the requests of the API clients against their quotas.
+type QuotaCounter interface {
+	// Consume counts a request of the client to the route at now, unless it
+	// is over its quota. It returns the exhausted quota period, or "" when
+	// the request was counted.
+	Consume(ctx context.Context, client, route string, now time.Time, quota models.Quota) (string, *repositories.RepositoryError)
+}
+
+// EnforceQuota counts every request of the clients identified by
+// IdentifyClient, per route, and rejects with 429 QUOTA_EXHAUSTED the
+// requests of clients over their daily or monthly quota until the period
+// ends, in UTC. Clients without a quota are counted but not limited, and
+// anonymous requests are not counted. Requests go through uncounted when the
+// counter cannot be reached.
+func EnforceQuota(counter QuotaCounter, quotas map[string]models.Quota, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := c.GetString(ClientNameKey)
+		if client == "" {
+			c.Next()
+			return
+		}
+
+		now := time.Now().UTC()
+		route := c.Request.Method + " " + c.FullPath()
+		exhausted, err := counter.Consume(c.Request.Context(), client, route, now, quotas[client])
+		if err != nil {
+			metrics.QuotaUnenforcedRequestsTotal.Inc()
+			logger.Warn("Request quota unavailable, accepting request",
+				zap.String("client", client),
+				zap.String("cause", err.Cause),
+			)
+			c.Next()
+			return
+		}
+		if exhausted != "" {
+			logger.Warn("Request quota exhausted",
+				zap.String("client", client),
+				zap.String("quota", exhausted),
+			)
+			c.Header("Retry-After", strconv.Itoa(int(quotaReset(now, exhausted).Sub(now).Seconds())))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "Request quota exhausted",
+				"code":  "QUOTA_EXHAUSTED",
+				"quota": exhausted,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// quotaReset returns when the quota period that contains now ends.
+func quotaReset(now time.Time, period string) time.Time {
+	if period == models.QuotaMonthly {
+		return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+}
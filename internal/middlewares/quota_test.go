@@ -0,0 +1,86 @@
+package middlewares_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"orders/internal/metrics"
+	"orders/internal/middlewares"
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeQuotaCounter agota la cuota indicada y guarda las peticiones contadas
+type fakeQuotaCounter struct {
+	exhausted string
+	err       *repositories.RepositoryError
+	counted   []string
+	quotas    []models.Quota
+}
+
+func (f *fakeQuotaCounter) Consume(_ context.Context, client, route string, _ time.Time, quota models.Quota) (string, *repositories.RepositoryError) {
+	if f.err != nil || f.exhausted != "" {
+		return f.exhausted, f.err
+	}
+	f.counted = append(f.counted, client+" "+route)
+	f.quotas = append(f.quotas, quota)
+	return "", nil
+}
+
+func TestEnforceQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	quotas := map[string]models.Quota{"partner": {Monthly: 1000000}}
+
+	serve := func(counter *fakeQuotaCounter, key string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.Use(middlewares.IdentifyClient(map[string]string{"partner": "partner-key", "mobile": "mobile-key"}))
+		router.Use(middlewares.EnforceQuota(counter, quotas, zap.NewNop()))
+		router.GET("/api/orders/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(http.MethodGet, "/api/orders/order-123", nil)
+		if key != "" {
+			req.Header.Set(middlewares.ClientAPIKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Counts the requests of each client per route", func(t *testing.T) {
+		counter := &fakeQuotaCounter{}
+
+		assert.Equal(t, http.StatusOK, serve(counter, "partner-key").Code)
+		assert.Equal(t, http.StatusOK, serve(counter, "mobile-key").Code)
+		// Las peticiones anónimas no se cuentan
+		assert.Equal(t, http.StatusOK, serve(counter, "").Code)
+
+		assert.Equal(t, []string{"partner GET /api/orders/:id", "mobile GET /api/orders/:id"}, counter.counted)
+		assert.Equal(t, []models.Quota{{Monthly: 1000000}, {}}, counter.quotas)
+	})
+
+	t.Run("Rejects clients over their quota", func(t *testing.T) {
+		w := serve(&fakeQuotaCounter{exhausted: models.QuotaMonthly}, "partner-key")
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"QUOTA_EXHAUSTED"`)
+		assert.Contains(t, w.Body.String(), `"quota":"monthly"`)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	})
+
+	t.Run("Fails open without Redis", func(t *testing.T) {
+		before := testutil.ToFloat64(metrics.QuotaUnenforcedRequestsTotal)
+
+		w := serve(&fakeQuotaCounter{err: &repositories.RepositoryError{Cause: "connection refused"}}, "partner-key")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, before+1, testutil.ToFloat64(metrics.QuotaUnenforcedRequestsTotal))
+	})
+}
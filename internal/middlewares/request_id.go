@@ -1,18 +1,48 @@
 package middlewares
 
 import (
+	"regexp"
+
+	"orders/internal/correlation"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-func RequestID() gin.HandlerFunc {
+// RequestIDHeader carries the ID of the request, echoed in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDFormat restricts the request IDs accepted from clients, as they
+// end up in logs and events.
+type RequestIDFormat struct {
+	MaxLength int
+	Pattern   *regexp.Regexp
+}
+
+// Accepts reports whether a client supplied request ID can be kept.
+func (f RequestIDFormat) Accepts(requestID string) bool {
+	if requestID == "" || (f.MaxLength > 0 && len(requestID) > f.MaxLength) {
+		return false
+	}
+	return f.Pattern == nil || f.Pattern.MatchString(requestID)
+}
+
+// RequestID keeps the X-Request-ID sent by the client when it has the
+// expected format, and generates one otherwise.
+func RequestID(format RequestIDFormat) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !format.Accepts(requestID) {
 			requestID = uuid.New().String()
 		}
-		c.Writer.Header().Set("X-Request-ID", requestID)
+		c.Writer.Header().Set(RequestIDHeader, requestID)
+		c.Request.Header.Set(RequestIDHeader, requestID)
 		c.Set("requestId", requestID)
+		// Events emitted and services called while serving the request are
+		// correlated with it
+		ctx := correlation.WithID(c.Request.Context(), requestID)
+		ctx = correlation.WithTrace(ctx, c.GetHeader("traceparent"), c.GetHeader("tracestate"))
+		c.Request = c.Request.WithContext(ctx)
 		c.Next()
 	}
 }
@@ -0,0 +1,61 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"orders/internal/correlation"
+	"orders/internal/middlewares"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middlewares.RequestID(middlewares.RequestIDFormat{
+		MaxLength: 64,
+		Pattern:   regexp.MustCompile(`^[A-Za-z0-9._:-]+$`),
+	}))
+	router.GET("/orders", func(c *gin.Context) {
+		c.String(http.StatusOK, correlation.ID(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name      string
+		requestID string
+		preserved bool
+	}{
+		{"Valid supplied ID", "checkout-7f3a.42:retry-1", true},
+		{"Absent ID", "", false},
+		{"Log injection", "abc\nlevel=error msg=forged", false},
+		{"Disallowed characters", "<script>", false},
+		{"Too long", strings.Repeat("a", 65), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.requestID != "" {
+				req.Header.Set(middlewares.RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(middlewares.RequestIDHeader)
+			assert.Equal(t, requestID, w.Body.String())
+			if tt.preserved {
+				assert.Equal(t, tt.requestID, requestID)
+				return
+			}
+			_, err := uuid.Parse(requestID)
+			assert.NoError(t, err, "a generated ID replaces %q", tt.requestID)
+		})
+	}
+}
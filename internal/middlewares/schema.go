@@ -0,0 +1,82 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// FieldError describes a single schema violation of a request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidateJSONSchema validates the request body against the given schema
+// before the handler runs, answering 400 with field-level errors on failure.
+// The body is restored so handlers can still bind it.
+func ValidateJSONSchema(schema *jsonschema.Schema) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var document interface{}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		if err := schema.Validate(document); err != nil {
+			var validationErr *jsonschema.ValidationError
+			if !errors.As(err, &validationErr) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":  "Invalid request body",
+				"code":   "SCHEMA_VALIDATION_FAILED",
+				"fields": fieldErrors(validationErr),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// fieldErrors flattens the validation error tree into its leaf violations.
+func fieldErrors(err *jsonschema.ValidationError) []FieldError {
+	var fields []FieldError
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			field := e.InstanceLocation
+			if field == "" {
+				field = "/"
+			}
+			fields = append(fields, FieldError{Field: field, Message: e.Message})
+			return
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(err)
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Field < fields[j].Field
+	})
+	return fields
+}
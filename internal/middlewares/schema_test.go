@@ -0,0 +1,79 @@
+package middlewares_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"orders/internal/middlewares"
+	"orders/internal/schemas"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSchemaRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/orders", middlewares.ValidateJSONSchema(schemas.MustCompile(schemas.CreateOrder)), func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Status(http.StatusTeapot)
+			return
+		}
+		c.JSON(http.StatusCreated, body)
+	})
+	return router
+}
+
+func TestValidateJSONSchema_ValidCreateRequest(t *testing.T) {
+	router := newSchemaRouter(t)
+
+	body := `{"customerId":"123e4567-e89b-12d3-a456-426614174000","items":[{"sku":"ITEM-1","quantity":1,"price":100}]}`
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code, "handler must still be able to bind the body")
+}
+
+func TestValidateJSONSchema_InvalidCreateRequest(t *testing.T) {
+	router := newSchemaRouter(t)
+
+	body := `{"customerId":"not-a-uuid","items":[{"sku":"X","quantity":0}]}`
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp struct {
+		Code   string                   `json:"code"`
+		Fields []middlewares.FieldError `json:"fields"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "SCHEMA_VALIDATION_FAILED", resp.Code)
+
+	fields := make([]string, 0, len(resp.Fields))
+	for _, f := range resp.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.ElementsMatch(t, []string{"/customerId", "/items/0/quantity", "/items/0/sku"}, fields)
+}
+
+func TestValidateJSONSchema_MalformedJSON(t *testing.T) {
+	router := newSchemaRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"customerId":`))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
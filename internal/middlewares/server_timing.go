@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"orders/internal/timing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DebugTimingHeader asks for the Server-Timing header of a request when it is
+// only sent on demand.
+const DebugTimingHeader = "X-Debug-Timing"
+
+// ServerTiming reports in the Server-Timing header the time the request spent
+// in the cache, the database and Kafka, and in total. Every request gets it
+// when always is set; otherwise only the requests sending
+// X-Debug-Timing: true.
+func ServerTiming(always bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !always {
+			if debug, _ := strconv.ParseBool(c.GetHeader(DebugTimingHeader)); !debug {
+				c.Next()
+				return
+			}
+		}
+
+		collector := &timing.Collector{}
+		c.Request = c.Request.WithContext(timing.WithCollector(c.Request.Context(), collector))
+		writer := &serverTimingWriter{ResponseWriter: c.Writer, collector: collector, start: time.Now()}
+		c.Writer = writer
+
+		c.Next()
+
+		// Responses without a body, e.g. 304, are written after the middlewares
+		writer.setHeader()
+	}
+}
+
+// serverTimingWriter sets the Server-Timing header right before the headers
+// are sent, once the handler is done with the dependencies.
+type serverTimingWriter struct {
+	gin.ResponseWriter
+	collector *timing.Collector
+	start     time.Time
+	done      bool
+}
+
+func (w *serverTimingWriter) setHeader() {
+	if w.done || w.ResponseWriter.Written() {
+		return
+	}
+	w.done = true
+	w.Header().Set("Server-Timing", w.collector.Header(time.Since(w.start)))
+}
+
+func (w *serverTimingWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *serverTimingWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *serverTimingWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *serverTimingWriter) Flush() {
+	w.setHeader()
+	w.ResponseWriter.Flush()
+}
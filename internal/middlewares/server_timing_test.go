@@ -0,0 +1,87 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"orders/internal/middlewares"
+	redisrepo "orders/internal/repositories/redis"
+	"orders/internal/timing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerTiming(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	cache := redisrepo.NewCacheRepository(client, time.Minute)
+
+	newRouter := func(always bool) *gin.Engine {
+		router := gin.New()
+		router.Use(middlewares.ServerTiming(always))
+		// Fallo de caché seguido de una lectura de la base de datos
+		router.GET("/orders/:id", func(c *gin.Context) {
+			ctx := c.Request.Context()
+			order, err := cache.GetOrder(ctx, c.Param("id"))
+			require.Nil(t, err)
+			require.Nil(t, order)
+
+			start := time.Now()
+			time.Sleep(5 * time.Millisecond)
+			timing.Since(ctx, timing.DB, start)
+
+			c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+		})
+		router.GET("/unchanged", func(c *gin.Context) {
+			c.Status(http.StatusNotModified)
+		})
+		return router
+	}
+
+	t.Run("reports the cache miss and the database read", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newRouter(true).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/order-1", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		header := w.Header().Get("Server-Timing")
+		assert.Regexp(t, `^cache;dur=\d+\.\d, db;dur=\d+\.\d, total;dur=\d+\.\d$`, header)
+
+		db := regexp.MustCompile(`db;dur=([\d.]+)`).FindStringSubmatch(header)
+		require.Len(t, db, 2)
+		dbMs, err := strconv.ParseFloat(db[1], 64)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, dbMs, 5.0)
+	})
+
+	t.Run("reports responses without a body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newRouter(true).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unchanged", nil))
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Regexp(t, `^total;dur=\d+\.\d$`, w.Header().Get("Server-Timing"))
+	})
+
+	t.Run("only reports debug requests unless always", func(t *testing.T) {
+		router := newRouter(false)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/order-1", nil))
+		assert.Empty(t, w.Header().Get("Server-Timing"))
+
+		req := httptest.NewRequest(http.MethodGet, "/orders/order-1", nil)
+		req.Header.Set(middlewares.DebugTimingHeader, "true")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Regexp(t, `^cache;dur=\d+\.\d, db;dur=\d+\.\d, total;dur=\d+\.\d$`, w.Header().Get("Server-Timing"))
+	})
+}
@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"orders/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHeader names the tenant a request acts for.
+const TenantHeader = "X-Tenant-ID"
+
+// TenantKey is the context key holding the tenant of the request.
+const TenantKey = "tenantId"
+
+// Tenant resolves the tenant of the request and carries it in the request
+// context, which scopes every repository access to it. With multi-tenancy
+// disabled every request acts for defaultTenant and the header is ignored;
+// otherwise it is required and must name one of the configured tenants.
+func Tenant(enabled bool, tenants []string, defaultTenant string) gin.HandlerFunc {
+	known := make(map[string]bool, len(tenants))
+	for _, id := range tenants {
+		known[id] = true
+	}
+
+	return func(c *gin.Context) {
+		tenantID := defaultTenant
+		if enabled {
+			tenantID = c.GetHeader(TenantHeader)
+			if tenantID == "" {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": TenantHeader + " header is required"})
+				return
+			}
+			if !known[tenantID] {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Unknown tenant"})
+				return
+			}
+		}
+
+		c.Set(TenantKey, tenantID)
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), tenantID))
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"orders/internal/middlewares"
+	"orders/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		enabled        bool
+		header         string
+		expectedStatus int
+		expectedTenant string
+	}{
+		{"Single tenant uses the default tenant", false, "", http.StatusOK, "default"},
+		{"Single tenant ignores the header", false, "brand-b", http.StatusOK, "default"},
+		{"Missing tenant", true, "", http.StatusBadRequest, ""},
+		{"Unknown tenant", true, "brand-c", http.StatusForbidden, ""},
+		{"Known tenant", true, "brand-b", http.StatusOK, "brand-b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tenantID string
+			router := gin.New()
+			router.GET("/orders", middlewares.Tenant(tt.enabled, []string{"brand-a", "brand-b"}, "default"), func(c *gin.Context) {
+				tenantID = tenant.ID(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.header != "" {
+				req.Header.Set(middlewares.TenantHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedTenant, tenantID)
+		})
+	}
+}
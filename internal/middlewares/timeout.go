@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+)
+
+const handlerTimeoutBody = `{"error":{"code":"HANDLER_TIMEOUT","message":"The request took too long to process"}}`
+
+// HandlerTimeout bounds the time handlers have to produce a response. Requests
+// still running after timeout get a 503 and their context is cancelled, while
+// the server write timeout keeps bounding the socket. A zero timeout disables
+// the limit.
+//
+// It wraps the whole router rather than being a gin middleware: the response
+// of a timed out handler has to be buffered and discarded, which is what
+// http.TimeoutHandler does.
+func HandlerTimeout(next http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+	timeoutHandler := http.TimeoutHandler(next, timeout, handlerTimeoutBody)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Handlers that complete replace it with their own content type.
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		timeoutHandler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,92 @@
+package middlewares_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"orders/internal/middlewares"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signToken devuelve un JWT HS256 con las claims dadas
+func signToken(t *testing.T, alg string, claims map[string]interface{}, secret string) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const secret = "0123456789abcdef0123456789abcdef"
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name           string
+		secret         string
+		authorization  string
+		expectedStatus int
+		expectedUser   string
+		expectedRoles  []string
+	}{
+		{"No token", secret, "", http.StatusOK, "", nil},
+		{"Valid token", secret, "Bearer " + signToken(t, "HS256", map[string]interface{}{"sub": "customer-1", "roles": []string{"admin"}, "exp": exp}, secret), http.StatusOK, "customer-1", []string{"admin"}},
+		{"Without roles", secret, "Bearer " + signToken(t, "HS256", map[string]interface{}{"sub": "customer-1", "exp": exp}, secret), http.StatusOK, "customer-1", nil},
+		{"Wrong secret", secret, "Bearer " + signToken(t, "HS256", map[string]interface{}{"sub": "customer-1", "exp": exp}, "another-secret"), http.StatusUnauthorized, "", nil},
+		{"Unsigned", secret, "Bearer " + signToken(t, "none", map[string]interface{}{"sub": "customer-1", "exp": exp}, secret), http.StatusUnauthorized, "", nil},
+		{"Expired", secret, "Bearer " + signToken(t, "HS256", map[string]interface{}{"sub": "customer-1", "exp": time.Now().Add(-time.Minute).Unix()}, secret), http.StatusUnauthorized, "", nil},
+		{"Without expiry", secret, "Bearer " + signToken(t, "HS256", map[string]interface{}{"sub": "customer-1"}, secret), http.StatusUnauthorized, "", nil},
+		{"Not yet valid", secret, "Bearer " + signToken(t, "HS256", map[string]interface{}{"sub": "customer-1", "exp": exp, "nbf": exp}, secret), http.StatusUnauthorized, "", nil},
+		{"Without subject", secret, "Bearer " + signToken(t, "HS256", map[string]interface{}{"exp": exp}, secret), http.StatusUnauthorized, "", nil},
+		{"Malformed", secret, "Bearer not-a-token", http.StatusUnauthorized, "", nil},
+		{"Other scheme", secret, "Basic dXNlcjpwYXNz", http.StatusUnauthorized, "", nil},
+		{"No secret configured", "", "Bearer " + signToken(t, "HS256", map[string]interface{}{"sub": "customer-1", "exp": exp}, ""), http.StatusUnauthorized, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/orders", middlewares.Authenticate([]byte(tt.secret)), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{
+					"userId": c.GetString(middlewares.UserIDKey),
+					"roles":  c.GetStringSlice(middlewares.RolesKey),
+				})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				// El token no revela por qué se rechazó
+				assert.Contains(t, w.Body.String(), "Invalid bearer token")
+				return
+			}
+			var body struct {
+				UserID string   `json:"userId"`
+				Roles  []string `json:"roles"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.expectedUser, body.UserID)
+			assert.Equal(t, tt.expectedRoles, body.Roles)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import "math"
+
+// DefaultCurrency is the ISO 4217 currency order amounts are in, unless the
+// deployment configures another one.
+const DefaultCurrency = "USD"
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not a
+// hundredth of the major one, by the number of decimals it has.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyExponent returns the number of decimals of the minor unit of the
+// ISO 4217 currency: 2 for USD or EUR, 0 for JPY, 3 for KWD.
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// MinorUnits returns the amount as a whole number of minor units of the
+// currency, rounded half away from zero: 12.34 USD is 1234 cents and
+// 1500 JPY is 1500 yen.
+func MinorUnits(amount float64, currency string) int64 {
+	return int64(math.Round(amount * math.Pow10(CurrencyExponent(currency))))
+}
@@ -0,0 +1,38 @@
+package models_test
+
+import (
+	"testing"
+
+	. "orders/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinorUnits(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		expected int64
+	}{
+		{12.34, "USD", 1234},
+		// 0.1 + 0.2 no es exactamente 0.3
+		{0.1 + 0.2, "USD", 30},
+		{19.99, "EUR", 1999},
+		{1500, "JPY", 1500},
+		{1499.6, "JPY", 1500},
+		{1.234, "KWD", 1234},
+		{0, "USD", 0},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, MinorUnits(tt.amount, tt.currency), "%v %s", tt.amount, tt.currency)
+	}
+}
+
+func TestCurrencyExponent(t *testing.T) {
+	assert.Equal(t, 2, CurrencyExponent("USD"))
+	assert.Equal(t, 0, CurrencyExponent("JPY"))
+	assert.Equal(t, 3, CurrencyExponent("BHD"))
+	// Las monedas no listadas usan céntimos
+	assert.Equal(t, 2, CurrencyExponent("MXN"))
+}
@@ -9,23 +9,87 @@ import (
 type EventType string
 
 const (
-	EventOrderStatusChanged EventType = "ORDER_STATUS_CHANGED"
+	EventOrderCreated         EventType = "ORDER_CREATED"
+	EventOrderStatusChanged   EventType = "ORDER_STATUS_CHANGED"
+	EventOrderSLABreached     EventType = "ORDER_SLA_BREACHED"
+	EventOrderPriorityChanged EventType = "ORDER_PRIORITY_CHANGED"
+	EventOrderTagsChanged     EventType = "ORDER_TAGS_CHANGED"
+	EventOrderItemsDelivered  EventType = "ORDER_ITEMS_DELIVERED"
+	EventOrderReturnRequested EventType = "ORDER_RETURN_REQUESTED"
+	EventOrderReturned        EventType = "ORDER_RETURNED"
+)
+
+// EventDeliveryStatus tracks whether a persisted event reached the broker.
+type EventDeliveryStatus string
+
+const (
+	EventStatusPending EventDeliveryStatus = "PENDING"
+	EventStatusSent    EventDeliveryStatus = "SENT"
+	EventStatusFailed  EventDeliveryStatus = "FAILED"
 )
 
 type OrderEvent struct {
-	EventID    string        `json:"eventId"`
-	EventType  EventType     `json:"eventType"`
-	OrderID    string        `json:"orderId"`
-	CustomerID string        `json:"customerId"`
-	OldStatus  OrderStatus   `json:"oldStatus"`
-	NewStatus  OrderStatus   `json:"newStatus"`
-	Timestamp  time.Time     `json:"timestamp"`
-	Metadata   EventMetadata `json:"metadata"`
+	EventID    string        `json:"eventId" bson:"_id"`
+	EventType  EventType     `json:"eventType" bson:"eventType"`
+	OrderID    string        `json:"orderId" bson:"orderId"`
+	TenantID   string        `json:"tenantId,omitempty" bson:"tenantId,omitempty"`
+	CustomerID string        `json:"customerId" bson:"customerId"`
+	OldStatus  OrderStatus   `json:"oldStatus" bson:"oldStatus"`
+	NewStatus  OrderStatus   `json:"newStatus" bson:"newStatus"`
+	Timestamp  time.Time     `json:"timestamp" bson:"timestamp"`
+	Metadata   EventMetadata `json:"metadata" bson:"metadata"`
+
+	// CorrelationID is shared by every event of a business transaction and
+	// CausationID is the ID of the request or event that caused this one.
+	CorrelationID string `json:"correlationId,omitempty" bson:"correlationId,omitempty"`
+	CausationID   string `json:"causationId,omitempty" bson:"causationId,omitempty"`
+
+	Priority           OrderPriority     `json:"priority,omitempty" bson:"priority,omitempty"`
+	OldPriority        OrderPriority     `json:"oldPriority,omitempty" bson:"oldPriority,omitempty"`
+	Channel            OrderChannel      `json:"channel,omitempty" bson:"channel,omitempty"`
+	ClientMetadata     map[string]string `json:"clientMetadata,omitempty" bson:"clientMetadata,omitempty"`
+	Tags               []string          `json:"tags,omitempty" bson:"tags,omitempty"`
+	OldTags            []string          `json:"oldTags,omitempty" bson:"oldTags,omitempty"`
+	PromisedDeliveryAt *time.Time        `json:"promisedDeliveryAt,omitempty" bson:"promisedDeliveryAt,omitempty"`
+	DeliveredAt        *time.Time        `json:"deliveredAt,omitempty" bson:"deliveredAt,omitempty"`
+	Delivery           []DeliveryItem    `json:"delivery,omitempty" bson:"delivery,omitempty"`
+	Return             *OrderReturn      `json:"return,omitempty" bson:"return,omitempty"`
+	// CustomerSnapshot is only sent on ORDER_CREATED events.
+	CustomerSnapshot *CustomerSnapshot `json:"customerSnapshot,omitempty" bson:"customerSnapshot,omitempty"`
+	TotalWeightGrams int               `json:"totalWeightGrams,omitempty" bson:"totalWeightGrams,omitempty"`
+	TotalVolumeCm3   int               `json:"totalVolumeCm3,omitempty" bson:"totalVolumeCm3,omitempty"`
+
+	// Before and After are the full order around the change. They are not part
+	// of the flat event format and are only published in CDC envelopes.
+	Before *Order `json:"-" bson:"before,omitempty"`
+	After  *Order `json:"-" bson:"after,omitempty"`
 }
 
 type EventMetadata struct {
-	ChangedBy string `json:"changedBy"`
-	Reason    string `json:"reason"`
+	ChangedBy string `json:"changedBy" bson:"changedBy"`
+	Reason    string `json:"reason" bson:"reason"`
+	// Forced flags changes made by an admin bypassing the status transition
+	// rules; ChangedBy is then the admin and Reason the one they gave.
+	Forced bool `json:"forced,omitempty" bson:"forced,omitempty"`
+	// EventOrigin is stamped by the producer when the event is published.
+	EventOrigin `bson:",inline"`
+}
+
+// EventOrigin identifies the deployment that published an event.
+type EventOrigin struct {
+	ServiceVersion string `json:"serviceVersion,omitempty" bson:"serviceVersion,omitempty"`
+	Environment    string `json:"environment,omitempty" bson:"environment,omitempty"`
+	Hostname       string `json:"hostname,omitempty" bson:"hostname,omitempty"`
+}
+
+// EventRecord is an order event persisted in the event log together with
+// its delivery state, so it can be inspected and replayed later.
+type EventRecord struct {
+	OrderEvent  `bson:",inline"`
+	Status      EventDeliveryStatus `json:"status" bson:"status"`
+	Attempts    int                 `json:"attempts" bson:"attempts"`
+	LastError   string              `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	PublishedAt *time.Time          `json:"publishedAt,omitempty" bson:"publishedAt,omitempty"`
 }
 
 func NewOrderStatusChangedEvent(orderID, customerID string, oldStatus, newStatus OrderStatus) *OrderEvent {
@@ -43,3 +107,292 @@ func NewOrderStatusChangedEvent(orderID, customerID string, oldStatus, newStatus
 		},
 	}
 }
+
+// NewOrderStatusForcedEvent reports a status forced by an admin on the order,
+// flagged as an override with the admin and the reason they gave.
+func NewOrderStatusForcedEvent(order *Order, oldStatus OrderStatus, actor, reason string) *OrderEvent {
+	event := NewOrderStatusChangedEvent(order.ID, order.CustomerID, oldStatus, order.Status)
+	event.Metadata.ChangedBy = actor
+	event.Metadata.Reason = reason
+	event.Metadata.Forced = true
+	event.SetOrderDetails(order)
+	return event
+}
+
+// NewOrderCreatedEvent reports a newly placed order.
+func NewOrderCreatedEvent(order *Order) *OrderEvent {
+	event := &OrderEvent{
+		EventID:    uuid.New().String(),
+		EventType:  EventOrderCreated,
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		NewStatus:  order.Status,
+		Timestamp:  time.Now(),
+		Metadata: EventMetadata{
+			ChangedBy: "system",
+			Reason:    "order_created",
+		},
+		CustomerSnapshot: order.CustomerSnapshot,
+	}
+	event.SetOrderDetails(order)
+	return event
+}
+
+// NewOrderSLABreachedEvent reports an open order that passed its promised
+// delivery time.
+func NewOrderSLABreachedEvent(order *Order) *OrderEvent {
+	event := &OrderEvent{
+		EventID:    uuid.New().String(),
+		EventType:  EventOrderSLABreached,
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		OldStatus:  order.Status,
+		NewStatus:  order.Status,
+		Timestamp:  time.Now(),
+		Metadata: EventMetadata{
+			ChangedBy: "system",
+			Reason:    "sla_breached",
+		},
+	}
+	event.SetOrderDetails(order)
+	return event
+}
+
+// NewOrderPriorityChangedEvent reports a change of priority of an order.
+func NewOrderPriorityChangedEvent(order *Order, oldPriority OrderPriority) *OrderEvent {
+	event := &OrderEvent{
+		EventID:     uuid.New().String(),
+		EventType:   EventOrderPriorityChanged,
+		OrderID:     order.ID,
+		CustomerID:  order.CustomerID,
+		OldStatus:   order.Status,
+		NewStatus:   order.Status,
+		OldPriority: oldPriority,
+		Timestamp:   time.Now(),
+		Metadata: EventMetadata{
+			ChangedBy: "system",
+			Reason:    "priority_update",
+		},
+	}
+	event.SetOrderDetails(order)
+	return event
+}
+
+// NewOrderTagsChangedEvent reports a change of the tags of an order.
+func NewOrderTagsChangedEvent(order *Order, oldTags []string) *OrderEvent {
+	event := &OrderEvent{
+		EventID:    uuid.New().String(),
+		EventType:  EventOrderTagsChanged,
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		OldStatus:  order.Status,
+		NewStatus:  order.Status,
+		OldTags:    oldTags,
+		Timestamp:  time.Now(),
+		Metadata: EventMetadata{
+			ChangedBy: "system",
+			Reason:    "tags_update",
+		},
+	}
+	event.SetOrderDetails(order)
+	return event
+}
+
+// NewOrderItemsDeliveredEvent reports the items handed over in a delivery.
+func NewOrderItemsDeliveredEvent(order *Order, oldStatus OrderStatus, delivery []DeliveryItem) *OrderEvent {
+	event := &OrderEvent{
+		EventID:    uuid.New().String(),
+		EventType:  EventOrderItemsDelivered,
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		OldStatus:  oldStatus,
+		NewStatus:  order.Status,
+		Delivery:   delivery,
+		Timestamp:  time.Now(),
+		Metadata: EventMetadata{
+			ChangedBy: "system",
+			Reason:    "items_delivered",
+		},
+	}
+	event.SetOrderDetails(order)
+	return event
+}
+
+// NewOrderReturnEvent reports a return requested for an order, or completed
+// when the order moved to RETURNED.
+func NewOrderReturnEvent(order *Order, oldStatus OrderStatus) *OrderEvent {
+	eventType, reason := EventOrderReturnRequested, "return_requested"
+	if order.Status == StatusReturned {
+		eventType, reason = EventOrderReturned, "returned"
+	}
+
+	event := &OrderEvent{
+		EventID:    uuid.New().String(),
+		EventType:  eventType,
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		OldStatus:  oldStatus,
+		NewStatus:  order.Status,
+		Return:     order.Return,
+		Timestamp:  time.Now(),
+		Metadata: EventMetadata{
+			ChangedBy: "system",
+			Reason:    reason,
+		},
+	}
+	event.SetOrderDetails(order)
+	return event
+}
+
+// CausedBy records that the event was caused by the given event, carrying
+// over its correlation ID.
+func (e *OrderEvent) CausedBy(cause *OrderEvent) {
+	e.CorrelationID = cause.CorrelationID
+	e.CausationID = cause.EventID
+}
+
+// SetOrderDetails copies the order attributes carried by every event, its
+// priority, channel, client metadata, tags and delivery timestamps, onto the
+// event.
+func (e *OrderEvent) SetOrderDetails(order *Order) {
+	e.Priority = order.Priority
+	e.Channel = order.Channel
+	e.ClientMetadata = order.ClientMetadata
+	e.Tags = order.Tags
+	e.PromisedDeliveryAt = order.PromisedDeliveryAt
+	e.DeliveredAt = order.DeliveredAt
+	e.TotalWeightGrams = order.TotalWeightGrams
+	e.TotalVolumeCm3 = order.TotalVolumeCm3
+}
+
+// NewEventRecord wraps an event in a pending event log record.
+func NewEventRecord(event *OrderEvent) *EventRecord {
+	return &EventRecord{
+		OrderEvent: *event,
+		Status:     EventStatusPending,
+	}
+}
+
+// Redacted returns a copy of the record with the customer contact data
+// masked, here and in the order before and after the change, for callers not
+// allowed to read personal data.
+func (r *EventRecord) Redacted() *EventRecord {
+	redacted := *r
+	redacted.CustomerSnapshot = maskedSnapshot(r.CustomerSnapshot)
+	if r.Before != nil {
+		before := *r.Before
+		before.CustomerSnapshot = maskedSnapshot(r.Before.CustomerSnapshot)
+		redacted.Before = &before
+	}
+	if r.After != nil {
+		after := *r.After
+		after.CustomerSnapshot = maskedSnapshot(r.After.CustomerSnapshot)
+		redacted.After = &after
+	}
+	return &redacted
+}
+
+func maskedSnapshot(snapshot *CustomerSnapshot) *CustomerSnapshot {
+	if snapshot == nil {
+		return nil
+	}
+	masked := snapshot.Masked()
+	return &masked
+}
+
+// SetStates records the order before and after the change of the event.
+// Before is nil for creations.
+func (e *OrderEvent) SetStates(before, after *Order) *OrderEvent {
+	e.Before = before
+	e.After = after
+	return e
+}
+
+// CDC operations, following the Debezium convention.
+const (
+	CDCOpCreate = "c"
+	CDCOpUpdate = "u"
+	CDCOpDelete = "d"
+)
+
+// CDCEnvelope is the change-data-capture form of an event: the order before
+// and after the change, for consumers that expect Debezium-style records.
+type CDCEnvelope struct {
+	Op     string    `json:"op"`
+	Before *Order    `json:"before"`
+	After  *Order    `json:"after"`
+	TS     time.Time `json:"ts"`
+	Source CDCSource `json:"source"`
+}
+
+// CDCSource identifies the event a CDC envelope was built from.
+type CDCSource struct {
+	EventID       string    `json:"eventId"`
+	EventType     EventType `json:"eventType"`
+	OrderID       string    `json:"orderId"`
+	CorrelationID string    `json:"correlationId,omitempty"`
+	CausationID   string    `json:"causationId,omitempty"`
+	EventOrigin
+}
+
+// NewCDCEnvelope wraps the event in a CDC envelope. ORDER_CREATED events are
+// creations, events with a before but no after state deletions and any other
+// event an update.
+func NewCDCEnvelope(event *OrderEvent) *CDCEnvelope {
+	op := CDCOpUpdate
+	switch {
+	case event.EventType == EventOrderCreated:
+		op = CDCOpCreate
+	case event.Before != nil && event.After == nil:
+		op = CDCOpDelete
+	}
+
+	return &CDCEnvelope{
+		Op:     op,
+		Before: event.Before,
+		After:  event.After,
+		TS:     event.Timestamp,
+		Source: CDCSource{
+			EventID:       event.EventID,
+			EventType:     event.EventType,
+			OrderID:       event.OrderID,
+			CorrelationID: event.CorrelationID,
+			CausationID:   event.CausationID,
+			EventOrigin:   event.Metadata.EventOrigin,
+		},
+	}
+}
+
+// ReplayStatus is the state of an event replay job.
+type ReplayStatus string
+
+const (
+	ReplayRunning   ReplayStatus = "RUNNING"
+	ReplayCompleted ReplayStatus = "COMPLETED"
+	ReplayFailed    ReplayStatus = "FAILED"
+	ReplayCancelled ReplayStatus = "CANCELLED"
+)
+
+// ReplayJob tracks the replay of the events emitted in a time range, run in
+// the background.
+type ReplayJob struct {
+	ID         string       `json:"jobId"`
+	Status     ReplayStatus `json:"status"`
+	From       time.Time    `json:"from"`
+	To         time.Time    `json:"to"`
+	Types      []EventType  `json:"types,omitempty"`
+	Total      int64        `json:"total"`
+	Replayed   int64        `json:"replayed"`
+	Error      string       `json:"error,omitempty"`
+	StartedAt  time.Time    `json:"startedAt"`
+	UpdatedAt  time.Time    `json:"updatedAt"`
+	FinishedAt *time.Time   `json:"finishedAt,omitempty"`
+	// CancelRequested is set when a cancellation was asked for and the job
+	// has not stopped yet.
+	CancelRequested bool `json:"cancelRequested,omitempty"`
+}
+
+// Finished reports whether the job is no longer running.
+func (j *ReplayJob) Finished() bool {
+	return j.Status != ReplayRunning
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// InventoryRelease is a pending release of the stock reserved for an order.
+// Releases are recorded before they are attempted and removed once the
+// inventory confirms them, so a release that fails is retried rather than
+// lost.
+type InventoryRelease struct {
+	OrderID       string    `bson:"_id" json:"orderId"`
+	Attempts      int       `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time `bson:"nextAttemptAt" json:"nextAttemptAt"`
+	LastError     string    `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	CreatedAt     time.Time `bson:"createdAt" json:"createdAt"`
+}
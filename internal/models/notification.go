@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message types of the notification requests. They are routed to topics like
+// event types.
+const (
+	// NotificationRequested asks the notifications pipeline to notify a
+	// customer.
+	NotificationRequested EventType = "NOTIFICATION_REQUESTED"
+	// NotificationDeadLettered carries a notification request that could not
+	// be published after every retry.
+	NotificationDeadLettered EventType = "NOTIFICATION_DEAD_LETTERED"
+)
+
+// Templates of the customer notifications.
+const (
+	TemplateOrderDelivered = "order.delivered"
+	TemplateOrderCancelled = "order.cancelled"
+)
+
+// NotificationTemplates maps the statuses customers are notified of to the
+// template of their notification.
+var NotificationTemplates = map[OrderStatus]string{
+	StatusDelivered: TemplateOrderDelivered,
+	StatusCancelled: TemplateOrderCancelled,
+}
+
+// NotificationRequest asks the notifications pipeline to notify the customer
+// of an order with a template. Contact is the contact data the customer gave
+// with the order, if any.
+type NotificationRequest struct {
+	NotificationID string            `json:"notificationId"`
+	Template       string            `json:"template"`
+	OrderNumber    string            `json:"orderNumber"`
+	CustomerID     string            `json:"customerId"`
+	Contact        *CustomerSnapshot `json:"contact,omitempty"`
+	TenantID       string            `json:"tenantId,omitempty"`
+	RequestedAt    time.Time         `json:"requestedAt"`
+}
+
+// NewNotificationRequest requests the notification of the template for the
+// order. Orders are numbered by their ID.
+func NewNotificationRequest(order *Order, template string) *NotificationRequest {
+	return &NotificationRequest{
+		NotificationID: uuid.New().String(),
+		Template:       template,
+		OrderNumber:    order.ID,
+		CustomerID:     order.CustomerID,
+		Contact:        order.CustomerSnapshot,
+		TenantID:       order.TenantID,
+		RequestedAt:    time.Now().UTC(),
+	}
+}
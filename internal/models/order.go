@@ -2,7 +2,14 @@ package models
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"orders/internal/codec"
 
 	"github.com/google/uuid"
 )
@@ -12,47 +19,633 @@ const (
 	StatusInProgress OrderStatus = "IN_PROGRESS"
 	StatusDelivered  OrderStatus = "DELIVERED"
 	StatusCancelled  OrderStatus = "CANCELLED"
+
+	StatusPartiallyDelivered OrderStatus = "PARTIALLY_DELIVERED"
+
+	StatusReturnRequested OrderStatus = "RETURN_REQUESTED"
+	StatusReturned        OrderStatus = "RETURNED"
+)
+
+// statusTransitions lists, for every status, the statuses an order can move to.
+// Statuses without an entry are final.
+var statusTransitions = map[OrderStatus][]OrderStatus{
+	StatusNew:                {StatusInProgress, StatusCancelled},
+	StatusInProgress:         {StatusPartiallyDelivered, StatusDelivered, StatusCancelled},
+	StatusPartiallyDelivered: {StatusDelivered, StatusCancelled},
+	StatusDelivered:          {StatusReturnRequested},
+	StatusReturnRequested:    {StatusReturned},
+}
+
+// CustomerIDFormat is the format customer IDs must follow. Deployments whose
+// customers are not identified by UUIDs can relax it.
+type CustomerIDFormat string
+
+const (
+	// CustomerIDUUID only accepts UUIDs, the default.
+	CustomerIDUUID CustomerIDFormat = "uuid"
+	// CustomerIDAlphanumeric accepts letters, digits, '-' and '_', e.g. "10442" or "cus_8f2k".
+	CustomerIDAlphanumeric CustomerIDFormat = "alphanumeric"
+	// CustomerIDAny accepts any non-blank ID up to MaxCustomerIDLength characters.
+	CustomerIDAny CustomerIDFormat = "any"
+)
+
+// MaxCustomerIDLength is the longest customer ID accepted by the relaxed formats.
+const MaxCustomerIDLength = 64
+
+// Statuses lists every order status in the order of the fulfillment flow.
+var Statuses = []OrderStatus{
+	StatusNew, StatusInProgress, StatusPartiallyDelivered, StatusDelivered,
+	StatusCancelled, StatusReturnRequested, StatusReturned,
+}
+
+// StartingStatuses lists the statuses an order can be created in. Orders
+// start in NEW unless the deployment also accepts one of the others.
+var StartingStatuses = []OrderStatus{StatusNew, StatusInProgress}
+
+// StatusTransitions is a node of the status graph: a status and the statuses
+// an order in it can move to.
+type StatusTransitions struct {
+	Status      OrderStatus   `json:"status"`
+	Transitions []OrderStatus `json:"transitions"`
+}
+
+// StatusGraph returns the full status transition graph, one node per status.
+func StatusGraph() []StatusTransitions {
+	graph := make([]StatusTransitions, 0, len(Statuses))
+	for _, status := range Statuses {
+		graph = append(graph, StatusTransitions{Status: status, Transitions: status.Transitions()})
+	}
+	return graph
+}
+
+const (
+	PriorityLow    OrderPriority = "LOW"
+	PriorityNormal OrderPriority = "NORMAL"
+	PriorityHigh   OrderPriority = "HIGH"
+	PriorityUrgent OrderPriority = "URGENT"
+)
+
+const (
+	ChannelMobile  OrderChannel = "MOBILE"
+	ChannelWeb     OrderChannel = "WEB"
+	ChannelPartner OrderChannel = "PARTNER_API"
 )
 
+// Channels lists the channels orders can be placed through.
+var Channels = []OrderChannel{ChannelMobile, ChannelWeb, ChannelPartner}
+
+// Priorities lists the valid priorities from lowest to highest.
+var Priorities = []OrderPriority{PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent}
+
 var (
 	ErrInvalidStatusTransition = errors.New("invalid status transition")
 	ErrOrderNotFound           = errors.New("order not found")
 	ErrInvalidOrderData        = errors.New("invalid order data")
 	ErrVersionConflict         = errors.New("version conflict - order was modified")
+	ErrNoteEmpty               = errors.New("note text is required")
+	ErrNoteTooLong             = errors.New("note text exceeds the maximum length")
+	ErrNoteAuthorRequired      = errors.New("note author is required")
+	ErrOrderDeleted            = errors.New("order has been deleted")
+	ErrInvalidPromisedDelivery = errors.New("promised delivery time is out of the allowed range")
+	ErrInvalidPriority         = errors.New("invalid priority")
+	ErrOrderFinal              = errors.New("order is in a final status")
+	ErrTooManyTags             = errors.New("too many tags")
+	ErrInvalidTag              = errors.New("invalid tag")
+	ErrReturnWindowExpired     = errors.New("return window has expired")
+	ErrReturnReasonRequired    = errors.New("return reason is required")
+	ErrInvalidReturnItems      = errors.New("invalid return items")
+	ErrReturnDetailsRequired   = errors.New("returns must be requested with a reason and items")
+	ErrInvalidChannel          = errors.New("invalid channel")
+	ErrInvalidClientMetadata   = errors.New("invalid client metadata")
+	ErrInvalidDeliveryItems    = errors.New("invalid delivery items")
+	ErrDeliveryDetailsRequired = errors.New("partial deliveries must be recorded with the delivered items")
+	ErrOverrideReasonRequired  = errors.New("a reason is required to force a status")
+	ErrInvalidInitialStatus    = errors.New("invalid initial status")
+	ErrUnversionedChange       = errors.New("order changed without bumping its version")
+)
+
+// MaxItemQuantity is the largest quantity allowed on a single order line.
+const MaxItemQuantity = 10000
+
+const (
+	// MaxItemWeightGrams is the heaviest unit weight allowed on an order line.
+	MaxItemWeightGrams = 1_000_000
+	// MaxItemVolumeCm3 is the largest unit volume allowed on an order line.
+	MaxItemVolumeCm3 = 10_000_000
+)
+
+const (
+	// MaxTags is the largest number of tags an order can carry.
+	MaxTags = 10
+	// MaxTagLength is the longest tag allowed, in characters.
+	MaxTagLength = 30
+)
+
+const (
+	// SearchPrefixMinLength and SearchPrefixMaxLength bound the prefixes of
+	// the order ID an order can be searched by.
+	SearchPrefixMinLength = 4
+	SearchPrefixMaxLength = 12
+)
+
+const (
+	// MaxMetadataKeys is the largest number of client metadata entries.
+	MaxMetadataKeys = 20
+	// MaxMetadataKeyLength is the longest client metadata key, in characters.
+	MaxMetadataKeyLength = 64
+	// MaxMetadataValueLength is the longest client metadata value, in characters.
+	MaxMetadataValueLength = 256
 )
 
 type OrderStatus string
 
+type OrderPriority string
+
+// OrderChannel is where an order was placed from.
+type OrderChannel string
+
+// Order is an order as handled by the services and kept in the cache and
+// the event log. The orders collection stores it as mongodb.OrderDocument,
+// the API serves it as handlers.OrderResponse and CDC events carry it as
+// kafka.OrderEventPayload, so each can evolve apart.
 type Order struct {
-	ID          string      `json:"orderId" bson:"_id"`
-	CustomerID  string      `json:"customerId" bson:"customerId" validate:"required,uuid"`
-	Status      OrderStatus `json:"status" bson:"status"`
-	Items       []OrderItem `json:"items" bson:"items" validate:"required,min=1,max=100,dive"`
-	TotalAmount float64     `json:"totalAmount" bson:"totalAmount"`
-	Version     int         `json:"version" bson:"version"`
-	CreatedAt   time.Time   `json:"createdAt" bson:"createdAt"`
-	UpdatedAt   time.Time   `json:"updatedAt" bson:"updatedAt"`
+	ID          string        `json:"orderId" bson:"_id"`
+	TenantID    string        `json:"tenantId,omitempty" bson:"tenantId,omitempty"`
+	CustomerID  string        `json:"customerId" bson:"customerId" validate:"required,uuid"`
+	Status      OrderStatus   `json:"status" bson:"status"`
+	Priority    OrderPriority `json:"priority" bson:"priority"`
+	Channel     OrderChannel  `json:"channel,omitempty" bson:"channel,omitempty"`
+	Items       []OrderItem   `json:"items" bson:"items" validate:"required,min=1,max=100,dive"`
+	Tags        []string      `json:"tags,omitempty" bson:"tags,omitempty"`
+	TotalAmount float64       `json:"totalAmount" bson:"totalAmount"`
+	Notes       string        `json:"notes,omitempty" bson:"notes,omitempty"`
+	NoteEntries []OrderNote   `json:"noteEntries,omitempty" bson:"noteEntries,omitempty"`
+	Version     int           `json:"version" bson:"version"`
+	CreatedAt   time.Time     `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time     `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt   *time.Time    `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+
+	// ClientMetadata is free-form data supplied by the client placing the order.
+	ClientMetadata map[string]string `json:"clientMetadata,omitempty" bson:"clientMetadata,omitempty"`
+	// CustomerSnapshot is the customer contact data at the time of the order.
+	CustomerSnapshot *CustomerSnapshot `json:"customerSnapshot,omitempty" bson:"customerSnapshot,omitempty"`
+	// TotalWeightGrams and TotalVolumeCm3 add up the lines that declare them.
+	TotalWeightGrams int `json:"totalWeightGrams" bson:"totalWeightGrams"`
+	TotalVolumeCm3   int `json:"totalVolumeCm3" bson:"totalVolumeCm3"`
+
+	PromisedDeliveryAt  *time.Time `json:"promisedDeliveryAt,omitempty" bson:"promisedDeliveryAt,omitempty"`
+	DeliveredAt         *time.Time `json:"deliveredAt,omitempty" bson:"deliveredAt,omitempty"`
+	SLABreachNotifiedAt *time.Time `json:"-" bson:"slaBreachNotifiedAt,omitempty"`
+	// PriorityRank mirrors Priority as a number so orders can be sorted by it.
+	PriorityRank int `json:"-" bson:"priorityRank"`
+	// Return holds the return request of an order in RETURN_REQUESTED or RETURNED.
+	Return *OrderReturn `json:"return,omitempty" bson:"return,omitempty"`
+	// SearchKeys are the terms support searches match exactly, kept by the
+	// repository on every write, see RefreshSearchKeys.
+	SearchKeys []string `json:"-" bson:"searchKeys,omitempty"`
+	// BreachedSLA is computed when the order is serialized and never stored.
+	// The allowed transitions depend on the configured return window and are
+	// only served by the API, see NextStatuses.
+	BreachedSLA bool `json:"breachedSLA" bson:"-"`
 }
 
 type OrderItem struct {
 	SKU      string  `json:"sku" bson:"sku" validate:"required,min=3,max=50"`
 	Quantity int     `json:"quantity" bson:"quantity" validate:"required,min=1,max=10000"`
 	Price    float64 `json:"price" bson:"price" validate:"required,gt=0"`
+	// WeightGrams and VolumeCm3 are optional unit measures used for dispatch.
+	WeightGrams int `json:"weightGrams,omitempty" bson:"weightGrams,omitempty" validate:"omitempty,min=1,max=1000000"`
+	VolumeCm3   int `json:"volumeCm3,omitempty" bson:"volumeCm3,omitempty" validate:"omitempty,min=1,max=10000000"`
+	// DeliveredQuantity is how many units of the line have been delivered so far.
+	DeliveredQuantity int        `json:"deliveredQuantity" bson:"deliveredQuantity"`
+	PriceSnapshotAt   *time.Time `json:"priceSnapshotAt,omitempty" bson:"priceSnapshotAt,omitempty"`
+}
+
+// CustomerSnapshot holds the contact details of the customer as they were
+// when the order was placed. It is personal data: log it through Masked.
+type CustomerSnapshot struct {
+	Email string `json:"email,omitempty" bson:"email,omitempty"`
+	Name  string `json:"name,omitempty" bson:"name,omitempty"`
+	Phone string `json:"phone,omitempty" bson:"phone,omitempty"`
+}
+
+// IsEmpty reports whether the snapshot has no contact details.
+func (c *CustomerSnapshot) IsEmpty() bool {
+	return c == nil || (c.Email == "" && c.Name == "" && c.Phone == "")
+}
+
+// Merge fills the empty fields of the snapshot with the ones of other.
+func (c *CustomerSnapshot) Merge(other *CustomerSnapshot) {
+	if other == nil {
+		return
+	}
+	if c.Email == "" {
+		c.Email = other.Email
+	}
+	if c.Name == "" {
+		c.Name = other.Name
+	}
+	if c.Phone == "" {
+		c.Phone = other.Phone
+	}
+}
+
+// Masked returns a copy of the snapshot that is safe to log: only the first
+// character of the name and of the email local part, the email domain and
+// the last two digits of the phone are kept.
+func (c CustomerSnapshot) Masked() CustomerSnapshot {
+	masked := CustomerSnapshot{Name: maskPrefix(c.Name, 1)}
+	if at := strings.LastIndex(c.Email, "@"); at >= 0 {
+		masked.Email = maskPrefix(c.Email[:at], 1) + c.Email[at:]
+	} else {
+		masked.Email = maskPrefix(c.Email, 1)
+	}
+	if n := utf8.RuneCountInString(c.Phone); n > 2 {
+		runes := []rune(c.Phone)
+		masked.Phone = strings.Repeat("*", n-2) + string(runes[n-2:])
+	} else {
+		masked.Phone = strings.Repeat("*", n)
+	}
+	return masked
+}
+
+// String masks the snapshot so it is never printed in clear by accident.
+func (c CustomerSnapshot) String() string {
+	masked := c.Masked()
+	return fmt.Sprintf("{email:%s name:%s phone:%s}", masked.Email, masked.Name, masked.Phone)
+}
+
+// maskPrefix keeps the first keep runes of value and replaces the rest.
+func maskPrefix(value string, keep int) string {
+	runes := []rune(value)
+	if len(runes) <= keep {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:keep]) + strings.Repeat("*", len(runes)-keep)
+}
+
+// DeliveryItem is the quantity of a SKU of the order handed over in a delivery.
+type DeliveryItem struct {
+	SKU      string `json:"sku" bson:"sku"`
+	Quantity int    `json:"quantity" bson:"quantity"`
+}
+
+// OrderSummary is the compact projection of an order served to summary reads.
+type OrderSummary struct {
+	ID          string      `json:"orderId"`
+	TenantID    string      `json:"tenantId,omitempty"`
+	CustomerID  string      `json:"customerId"`
+	Status      OrderStatus `json:"status"`
+	TotalAmount float64     `json:"totalAmount"`
+	Version     int         `json:"version"`
+}
+
+// OrderSummaryRecord is the row of an order in the orders summary read model,
+// which serves listings, counts and stats without scanning the full orders.
+// TenantID scopes it like the order and Version keeps late updates from
+// overwriting newer ones.
+type OrderSummaryRecord struct {
+	ID          string      `bson:"_id"`
+	TenantID    string      `bson:"tenantId,omitempty"`
+	CustomerID  string      `bson:"customerId"`
+	Status      OrderStatus `bson:"status"`
+	TotalAmount float64     `bson:"totalAmount"`
+	Version     int         `bson:"version"`
+	CreatedAt   time.Time   `bson:"createdAt"`
+	UpdatedAt   time.Time   `bson:"updatedAt"`
+}
+
+// OrderStats are the business KPIs of the orders of a tenant, served as JSON
+// to the dashboards that do not read Prometheus.
+type OrderStats struct {
+	OrdersByStatus    map[OrderStatus]int64 `json:"ordersByStatus"`
+	TotalOrders       int64                 `json:"totalOrders"`
+	AverageOrderValue float64               `json:"averageOrderValue"`
+	OrdersLast24h     int64                 `json:"ordersLast24h"`
+	GeneratedAt       time.Time             `json:"generatedAt"`
+}
+
+// ReturnItem is the quantity of a SKU of the order being returned.
+type ReturnItem struct {
+	SKU      string `json:"sku" bson:"sku"`
+	Quantity int    `json:"quantity" bson:"quantity"`
+}
+
+// OrderReturn is the return requested by the customer for a delivered order.
+type OrderReturn struct {
+	Reason      string       `json:"reason" bson:"reason"`
+	Items       []ReturnItem `json:"items" bson:"items"`
+	RequestedAt time.Time    `json:"requestedAt" bson:"requestedAt"`
+	ReturnedAt  *time.Time   `json:"returnedAt,omitempty" bson:"returnedAt,omitempty"`
+}
+
+// ItemError is a field-level validation error on a specific order line.
+type ItemError struct {
+	SKU     string
+	Field   string
+	Message string
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("item %s: %s %s", e.SKU, e.Field, e.Message)
+}
+
+func (e *ItemError) Unwrap() error {
+	return ErrInvalidOrderData
+}
+
+// OrderNote is a timestamped free-text entry appended to an order.
+type OrderNote struct {
+	Author    string    `json:"author,omitempty" bson:"author,omitempty"`
+	Text      string    `json:"text" bson:"text"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// ItemPrice is the authoritative unit price of a SKU as reported by the catalog.
+type ItemPrice struct {
+	SKU        string    `json:"sku"`
+	Price      float64   `json:"price"`
+	SnapshotAt time.Time `json:"snapshotAt"`
+}
+
+// MetadataFieldError is a violation of the limits of a client metadata entry.
+type MetadataFieldError struct {
+	Field   string
+	Message string
+}
+
+// InvalidMetadataError reports every client metadata entry over the limits.
+type InvalidMetadataError struct {
+	Fields []MetadataFieldError
+}
+
+func (e *InvalidMetadataError) Error() string {
+	fields := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		fields[i] = field.Field + " " + field.Message
+	}
+	return fmt.Sprintf("invalid client metadata: %s", strings.Join(fields, ", "))
+}
+
+func (e *InvalidMetadataError) Unwrap() error {
+	return ErrInvalidClientMetadata
+}
+
+// InvalidTagsError reports the tags that are empty, too long or contain
+// characters other than letters, digits, '-' and '_'.
+type InvalidTagsError struct {
+	Tags []string
+}
+
+func (e *InvalidTagsError) Error() string {
+	return fmt.Sprintf("invalid tags: %s", strings.Join(e.Tags, ", "))
+}
+
+func (e *InvalidTagsError) Unwrap() error {
+	return ErrInvalidTag
+}
+
+// InvalidReturnItemsError reports the return lines that reference SKUs not on
+// the order or exceed the ordered quantity.
+type InvalidReturnItemsError struct {
+	SKUs []string
+}
+
+func (e *InvalidReturnItemsError) Error() string {
+	return fmt.Sprintf("invalid return items: %s", strings.Join(e.SKUs, ", "))
+}
+
+func (e *InvalidReturnItemsError) Unwrap() error {
+	return ErrInvalidReturnItems
+}
+
+// InvalidDeliveryItemsError reports the delivery lines that reference SKUs not
+// on the order or exceed the quantity still to be delivered.
+type InvalidDeliveryItemsError struct {
+	SKUs []string
+}
+
+func (e *InvalidDeliveryItemsError) Error() string {
+	return fmt.Sprintf("invalid delivery items: %s", strings.Join(e.SKUs, ", "))
+}
+
+func (e *InvalidDeliveryItemsError) Unwrap() error {
+	return ErrInvalidDeliveryItems
+}
+
+// UnknownSKUsError reports the SKUs of an order that the catalog does not know about.
+type UnknownSKUsError struct {
+	SKUs []string
+}
+
+func (e *UnknownSKUsError) Error() string {
+	return fmt.Sprintf("unknown SKUs: %s", strings.Join(e.SKUs, ", "))
+}
+
+// InsufficientStockError reports the SKUs of an order the inventory cannot
+// reserve in the requested quantities.
+type InsufficientStockError struct {
+	SKUs []string
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock: %s", strings.Join(e.SKUs, ", "))
 }
 
 func (s OrderStatus) IsValid() bool {
 	switch s {
-	case StatusNew, StatusInProgress, StatusDelivered, StatusCancelled:
+	case StatusNew, StatusInProgress, StatusPartiallyDelivered, StatusDelivered, StatusCancelled, StatusReturnRequested, StatusReturned:
 		return true
 	}
 	return false
 }
 
+// Transitions returns the statuses an order in this status can move to. It
+// is empty, never nil, for statuses without transitions.
+func (s OrderStatus) Transitions() []OrderStatus {
+	return append([]OrderStatus{}, statusTransitions[s]...)
+}
+
+// IsStarting reports whether orders can be created in the status.
+func (s OrderStatus) IsStarting() bool {
+	for _, status := range StartingStatuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (f CustomerIDFormat) IsValid() bool {
+	switch f {
+	case CustomerIDUUID, CustomerIDAlphanumeric, CustomerIDAny:
+		return true
+	}
+	return false
+}
+
+// Accepts reports whether the customer ID follows the format. The empty
+// format is treated as CustomerIDUUID.
+func (f CustomerIDFormat) Accepts(customerID string) bool {
+	switch f {
+	case "", CustomerIDUUID:
+		_, ok := CanonicalUUID(customerID)
+		return ok
+	case CustomerIDAlphanumeric:
+		if customerID == "" || len(customerID) > MaxCustomerIDLength {
+			return false
+		}
+		for _, r := range customerID {
+			if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '-' && r != '_' {
+				return false
+			}
+		}
+		return true
+	case CustomerIDAny:
+		if strings.TrimSpace(customerID) == "" || utf8.RuneCountInString(customerID) > MaxCustomerIDLength {
+			return false
+		}
+		for _, r := range customerID {
+			if unicode.IsControl(r) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (ch OrderChannel) IsValid() bool {
+	for _, channel := range Channels {
+		if ch == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// ChannelForClient returns the channel of orders placed with the API key of
+// the given client: keys named after a channel (e.g. "mobile") belong to it,
+// any other client is a partner integration.
+func ChannelForClient(name string) OrderChannel {
+	if channel := OrderChannel(strings.ToUpper(name)); channel.IsValid() {
+		return channel
+	}
+	return ChannelPartner
+}
+
+func (p OrderPriority) IsValid() bool {
+	return p.Rank() > 0
+}
+
+// Rank orders priorities from LOW (1) to URGENT (4); invalid priorities rank 0.
+func (p OrderPriority) Rank() int {
+	for i, priority := range Priorities {
+		if p == priority {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// IsFinal reports whether the order is out of the fulfillment flow: it was
+// delivered, cancelled or returned. Delivered orders can still be returned.
+func (s OrderStatus) IsFinal() bool {
+	return s != StatusNew && s != StatusInProgress && s != StatusPartiallyDelivered
+}
+
 func (i OrderItem) Subtotal() float64 {
 	return float64(i.Quantity) * i.Price
 }
 
-func NewOrder(customerID string, items []OrderItem) (*Order, error) {
+// validateMeasures checks the optional unit weight and volume of the line.
+func (i OrderItem) validateMeasures() error {
+	switch {
+	case i.WeightGrams < 0:
+		return &ItemError{SKU: i.SKU, Field: "weightGrams", Message: "must not be negative"}
+	case i.WeightGrams > MaxItemWeightGrams:
+		return &ItemError{SKU: i.SKU, Field: "weightGrams", Message: fmt.Sprintf("exceeds the maximum of %d", MaxItemWeightGrams)}
+	case i.VolumeCm3 < 0:
+		return &ItemError{SKU: i.SKU, Field: "volumeCm3", Message: "must not be negative"}
+	case i.VolumeCm3 > MaxItemVolumeCm3:
+		return &ItemError{SKU: i.SKU, Field: "volumeCm3", Message: fmt.Sprintf("exceeds the maximum of %d", MaxItemVolumeCm3)}
+	}
+	return nil
+}
+
+// NormalizeSKU trims surrounding whitespace and upper-cases a SKU.
+func NormalizeSKU(sku string) string {
+	return strings.ToUpper(strings.TrimSpace(sku))
+}
+
+// NormalizeItems returns a copy of the items with normalized SKUs.
+func NormalizeItems(items []OrderItem) []OrderItem {
+	normalized := make([]OrderItem, len(items))
+	for i, item := range items {
+		item.SKU = NormalizeSKU(item.SKU)
+		normalized[i] = item
+	}
+	return normalized
+}
+
+// ConsolidateItems merges lines sharing the same (normalized) SKU by summing
+// their quantities, keeping the position of the first occurrence. Lines of the
+// same SKU with different prices, or whose merged quantity exceeds
+// MaxItemQuantity, are rejected with an *ItemError.
+func ConsolidateItems(items []OrderItem) ([]OrderItem, error) {
+	index := make(map[string]int, len(items))
+	consolidated := make([]OrderItem, 0, len(items))
+
+	for _, item := range NormalizeItems(items) {
+		pos, seen := index[item.SKU]
+		if !seen {
+			pos = len(consolidated)
+			index[item.SKU] = pos
+			consolidated = append(consolidated, item)
+		} else {
+			if consolidated[pos].Price != item.Price {
+				return nil, &ItemError{SKU: item.SKU, Field: "price", Message: "differs between duplicate lines"}
+			}
+			consolidated[pos].Quantity += item.Quantity
+		}
+
+		if consolidated[pos].Quantity > MaxItemQuantity {
+			return nil, &ItemError{
+				SKU:     item.SKU,
+				Field:   "quantity",
+				Message: fmt.Sprintf("exceeds the maximum of %d", MaxItemQuantity),
+			}
+		}
+	}
+
+	return consolidated, nil
+}
+
+// Normalize returns the customer ID as it is stored: UUIDs in their canonical
+// lowercase form, other formats as given.
+func (f CustomerIDFormat) Normalize(customerID string) string {
+	if f == "" || f == CustomerIDUUID {
+		if canonical, ok := CanonicalUUID(customerID); ok {
+			return canonical
+		}
+	}
+	return customerID
+}
+
+// CanonicalUUID returns the UUID in its canonical lowercase form. Any casing,
+// braces and the urn:uuid: prefix are accepted; the nil UUID and UUIDs of an
+// unknown version or variant are not.
+func CanonicalUUID(value string) (string, bool) {
+	id, err := uuid.Parse(value)
+	if err != nil || id.Variant() != uuid.RFC4122 || id.Version() < 1 || id.Version() > 8 {
+		return "", false
+	}
+	return id.String(), true
+}
+
+// NewOrder creates an order in NEW for the customer, whose ID must follow
+// idFormat (an empty format requires a UUID).
+func NewOrder(customerID string, items []OrderItem, idFormat CustomerIDFormat) (*Order, error) {
 	if customerID == "" {
 		return nil, ErrInvalidOrderData
 	}
@@ -61,43 +654,308 @@ func NewOrder(customerID string, items []OrderItem) (*Order, error) {
 		return nil, ErrInvalidOrderData
 	}
 
-	if _, err := uuid.Parse(customerID); err != nil {
+	if !idFormat.Accepts(customerID) {
 		return nil, ErrInvalidOrderData
 	}
 
-	totalAmount := 0.0
 	for _, item := range items {
 		if item.Quantity <= 0 || item.Price <= 0 {
 			return nil, ErrInvalidOrderData
 		}
-		totalAmount += item.Subtotal()
+		if err := item.validateMeasures(); err != nil {
+			return nil, err
+		}
 	}
 
 	now := time.Now()
-	return &Order{
-		ID:          uuid.New().String(),
-		CustomerID:  customerID,
-		Status:      StatusNew,
-		Items:       items,
-		TotalAmount: totalAmount,
-		Version:     1,
-		CreatedAt:   now,
-		UpdatedAt:   now,
-	}, nil
+	order := &Order{
+		ID:           uuid.New().String(),
+		CustomerID:   idFormat.Normalize(customerID),
+		Status:       StatusNew,
+		Priority:     PriorityNormal,
+		PriorityRank: PriorityNormal.Rank(),
+		Items:        items,
+		Version:      1,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	order.CalculateTotals()
+	return order, nil
+}
+
+// NormalizeTags trims and lower-cases tags, drops duplicates while keeping the
+// first occurrence order, and validates the result. Malformed tags are
+// reported with *InvalidTagsError; more than MaxTags distinct tags with
+// ErrTooManyTags.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	var invalid []string
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !validTag(tag) {
+			invalid = append(invalid, tag)
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+
+	if len(invalid) > 0 {
+		return nil, &InvalidTagsError{Tags: invalid}
+	}
+	if len(normalized) > MaxTags {
+		return nil, ErrTooManyTags
+	}
+	return normalized, nil
+}
+
+func validTag(tag string) bool {
+	length := utf8.RuneCountInString(tag)
+	if length == 0 || length > MaxTagLength {
+		return false
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateClientMetadata checks the client metadata against MaxMetadataKeys,
+// MaxMetadataKeyLength and MaxMetadataValueLength, reporting every violation
+// with *InvalidMetadataError.
+func ValidateClientMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataKeys {
+		return &InvalidMetadataError{Fields: []MetadataFieldError{{
+			Field:   "clientMetadata",
+			Message: fmt.Sprintf("must have at most %d keys", MaxMetadataKeys),
+		}}}
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var fields []MetadataFieldError
+	for _, key := range keys {
+		field := "clientMetadata." + key
+		if length := utf8.RuneCountInString(key); length == 0 || length > MaxMetadataKeyLength {
+			fields = append(fields, MetadataFieldError{
+				Field:   field,
+				Message: fmt.Sprintf("key must be between 1 and %d characters long", MaxMetadataKeyLength),
+			})
+			continue
+		}
+		if utf8.RuneCountInString(metadata[key]) > MaxMetadataValueLength {
+			fields = append(fields, MetadataFieldError{
+				Field:   field,
+				Message: fmt.Sprintf("must be at most %d characters long", MaxMetadataValueLength),
+			})
+		}
+	}
+
+	if len(fields) > 0 {
+		return &InvalidMetadataError{Fields: fields}
+	}
+	return nil
+}
+
+// ValidateNote checks that a note is not blank and does not exceed maxLength
+// characters. A maxLength of zero or less disables the length check.
+func ValidateNote(text string, maxLength int) error {
+	if strings.TrimSpace(text) == "" {
+		return ErrNoteEmpty
+	}
+	if maxLength > 0 && utf8.RuneCountInString(text) > maxLength {
+		return ErrNoteTooLong
+	}
+	return nil
+}
+
+// SanitizeNote trims a note and strips control characters other than line
+// breaks and tabs, so notes cannot smuggle escape sequences into logs or UIs.
+func SanitizeNote(text string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, text)
+	return strings.TrimSpace(cleaned)
+}
+
+// NewOrderNote builds a sanitized note entry stamped with the current time.
+func NewOrderNote(author, text string) OrderNote {
+	return OrderNote{
+		Author:    strings.TrimSpace(author),
+		Text:      SanitizeNote(text),
+		CreatedAt: time.Now(),
+	}
+}
+
+// IsSLABreached reports whether the order missed its promised delivery time:
+// it was delivered late, or it is still open past the promise.
+func (o *Order) IsSLABreached(now time.Time) bool {
+	if o.PromisedDeliveryAt == nil {
+		return false
+	}
+	if o.DeliveredAt != nil {
+		return o.DeliveredAt.After(*o.PromisedDeliveryAt)
+	}
+	if o.Status.IsFinal() {
+		return false
+	}
+	return now.After(*o.PromisedDeliveryAt)
+}
+
+// MarshalJSON serializes the order with BreachedSLA computed at the time of
+// serialization, so cached copies never report a stale value.
+func (o Order) MarshalJSON() ([]byte, error) {
+	type plain Order
+	o.BreachedSLA = o.IsSLABreached(time.Now())
+	return codec.Marshal(plain(o))
+}
+
+// Summary returns the compact projection of the order.
+func (o *Order) Summary() *OrderSummary {
+	return &OrderSummary{
+		ID:          o.ID,
+		TenantID:    o.TenantID,
+		CustomerID:  o.CustomerID,
+		Status:      o.Status,
+		TotalAmount: o.TotalAmount,
+		Version:     o.Version,
+	}
+}
+
+// SummaryRecord returns the row of the order in the orders summary.
+func (o *Order) SummaryRecord() *OrderSummaryRecord {
+	return &OrderSummaryRecord{
+		ID:          o.ID,
+		TenantID:    o.TenantID,
+		CustomerID:  o.CustomerID,
+		Status:      o.Status,
+		TotalAmount: o.TotalAmount,
+		Version:     o.Version,
+		CreatedAt:   o.CreatedAt,
+		UpdatedAt:   o.UpdatedAt,
+	}
+}
+
+// Mismatches lists the fields in which the summary row disagrees with the
+// order, none when it is up to date. The timestamps follow the version, so
+// they are not compared.
+func (r *OrderSummaryRecord) Mismatches(o *Order) []string {
+	var fields []string
+	if r.CustomerID != o.CustomerID {
+		fields = append(fields, "customerId")
+	}
+	if r.Status != o.Status {
+		fields = append(fields, "status")
+	}
+	if r.TotalAmount != o.TotalAmount {
+		fields = append(fields, "totalAmount")
+	}
+	if r.Version != o.Version {
+		fields = append(fields, "version")
+	}
+	return fields
+}
+
+// Clone returns a deep copy of the order, used to keep its state before a change.
+func (o *Order) Clone() *Order {
+	clone := *o
+	clone.Items = append([]OrderItem(nil), o.Items...)
+	clone.Tags = append([]string(nil), o.Tags...)
+	clone.NoteEntries = append([]OrderNote(nil), o.NoteEntries...)
+	clone.SearchKeys = append([]string(nil), o.SearchKeys...)
+	if o.ClientMetadata != nil {
+		clone.ClientMetadata = make(map[string]string, len(o.ClientMetadata))
+		for key, value := range o.ClientMetadata {
+			clone.ClientMetadata[key] = value
+		}
+	}
+	if o.CustomerSnapshot != nil {
+		snapshot := *o.CustomerSnapshot
+		clone.CustomerSnapshot = &snapshot
+	}
+	if o.Return != nil {
+		ret := *o.Return
+		ret.Items = append([]ReturnItem(nil), o.Return.Items...)
+		clone.Return = &ret
+	}
+	// Time pointers are replaced rather than modified, so they can be shared.
+	return &clone
+}
+
+// IsCacheable reports whether the order is complete enough to be cached: it
+// has an ID and a known status. Orders that fail it were only partially
+// decoded, e.g. after a schema change, and must not be served from the cache.
+func (o *Order) IsCacheable() bool {
+	return o.ID != "" && o.Status.IsValid()
+}
+
+// IsDeleted reports whether the order has been soft-deleted.
+func (o *Order) IsDeleted() bool {
+	return o.DeletedAt != nil
+}
+
+// SetInitialStatus starts the new order in status instead of NEW. The status
+// must be a starting status listed in allowed; an empty status keeps NEW.
+// Later changes still follow the status transitions.
+func (o *Order) SetInitialStatus(status OrderStatus, allowed []OrderStatus) error {
+	if status == "" {
+		return nil
+	}
+	if !status.IsStarting() {
+		return ErrInvalidInitialStatus
+	}
+	for _, s := range allowed {
+		if s == status {
+			o.Status = status
+			return nil
+		}
+	}
+	return ErrInvalidInitialStatus
 }
 
 func (o *Order) CanTransitionTo(newStatus OrderStatus) bool {
-	switch o.Status {
-	case StatusNew:
-		return newStatus == StatusInProgress || newStatus == StatusCancelled
-	case StatusInProgress:
-		return newStatus == StatusDelivered || newStatus == StatusCancelled
-	case StatusDelivered, StatusCancelled:
-		return false // Estados finales
+	for _, next := range statusTransitions[o.Status] {
+		if next == newStatus {
+			return true
+		}
 	}
 	return false
 }
 
+// NextStatuses lists the statuses the order can move to now, in the order of
+// the fulfillment flow. A return is only offered within window of the
+// delivery, as in RequestReturn.
+func (o *Order) NextStatuses(window time.Duration, now time.Time) []OrderStatus {
+	next := make([]OrderStatus, 0, len(statusTransitions[o.Status]))
+	for _, status := range Statuses {
+		if !o.CanTransitionTo(status) {
+			continue
+		}
+		if status == StatusReturnRequested && window > 0 && o.DeliveredAt != nil && now.After(o.DeliveredAt.Add(window)) {
+			continue
+		}
+		next = append(next, status)
+	}
+	return next
+}
+
 func (o *Order) UpdateStatus(newStatus OrderStatus) error {
 	if !newStatus.IsValid() {
 		return ErrInvalidOrderData
@@ -107,9 +965,247 @@ func (o *Order) UpdateStatus(newStatus OrderStatus) error {
 		return ErrInvalidStatusTransition
 	}
 
-	o.Status = newStatus
-	o.UpdatedAt = time.Now()
+	// A return carries a reason and items, see RequestReturn, and a partial
+	// delivery the delivered items, see RecordDelivery.
+	switch newStatus {
+	case StatusReturnRequested:
+		return ErrReturnDetailsRequired
+	case StatusPartiallyDelivered:
+		return ErrDeliveryDetailsRequired
+	}
+
+	o.setStatus(newStatus, time.Now())
+	return nil
+}
+
+// ForceStatus moves the order to the given status without checking the
+// transition rules, to correct mistakes such as a wrong delivery scan. Moving
+// back to NEW or IN_PROGRESS undoes the deliveries, and moving to any status
+// but RETURNED drops the return. Statuses that need details, a return or a
+// partial delivery, cannot be forced.
+func (o *Order) ForceStatus(newStatus OrderStatus) error {
+	if !newStatus.IsValid() {
+		return ErrInvalidOrderData
+	}
+
+	switch newStatus {
+	case o.Status:
+		return ErrInvalidStatusTransition
+	case StatusReturnRequested:
+		return ErrReturnDetailsRequired
+	case StatusPartiallyDelivered:
+		return ErrDeliveryDetailsRequired
+	}
+
+	o.setStatus(newStatus, time.Now())
+	if newStatus == StatusNew || newStatus == StatusInProgress {
+		o.DeliveredAt = nil
+		for i := range o.Items {
+			o.Items[i].DeliveredQuantity = 0
+		}
+	}
+	if newStatus != StatusReturned {
+		o.Return = nil
+	}
+	return nil
+}
+
+// touch records a change of the order at now, bumping its version once. Every
+// mutator calls it exactly once, as the version is the optimistic lock of the
+// order.
+func (o *Order) touch(now time.Time) {
+	o.UpdatedAt = now
 	o.Version++
+}
+
+// VerifyChange reports whether the order was changed from before through a
+// single mutator, which bumps the version exactly once. A status assigned
+// directly, without bumping the version, is rejected with
+// ErrUnversionedChange, as saving it would break the optimistic lock.
+func (o *Order) VerifyChange(before *Order) error {
+	if o.Version != before.Version+1 {
+		return ErrUnversionedChange
+	}
+	return nil
+}
+
+// setStatus moves the order to the given status, bumping its version and
+// stamping the delivery or return time the status implies.
+func (o *Order) setStatus(newStatus OrderStatus, now time.Time) {
+	o.Status = newStatus
+	o.touch(now)
+	switch newStatus {
+	case StatusDelivered:
+		deliveredAt := o.UpdatedAt
+		o.DeliveredAt = &deliveredAt
+		for i := range o.Items {
+			o.Items[i].DeliveredQuantity = o.Items[i].Quantity
+		}
+	case StatusReturned:
+		if o.Return != nil {
+			returnedAt := o.UpdatedAt
+			o.Return.ReturnedAt = &returnedAt
+		}
+	}
+}
+
+// RecordDelivery adds the delivered quantities to the order items. The order
+// moves to DELIVERED once every item is fully delivered, to
+// PARTIALLY_DELIVERED otherwise. Deliveries are cumulative and can never
+// exceed the ordered quantities.
+func (o *Order) RecordDelivery(items []DeliveryItem, now time.Time) error {
+	if o.Status != StatusPartiallyDelivered && !o.CanTransitionTo(StatusPartiallyDelivered) {
+		return ErrInvalidStatusTransition
+	}
+	if len(items) == 0 {
+		return ErrInvalidDeliveryItems
+	}
+
+	merged := mergeDeliveryItems(items)
+
+	remaining := make(map[string]int, len(o.Items))
+	for _, item := range o.Items {
+		remaining[item.SKU] += item.Quantity - item.DeliveredQuantity
+	}
+	var invalid []string
+	for _, item := range merged {
+		if item.Quantity < 1 || item.Quantity > remaining[item.SKU] {
+			invalid = append(invalid, item.SKU)
+		}
+	}
+	if len(invalid) > 0 {
+		return &InvalidDeliveryItemsError{SKUs: invalid}
+	}
+
+	// Orders may carry several lines of the same SKU; fill them in order.
+	for _, delivered := range merged {
+		left := delivered.Quantity
+		for i := range o.Items {
+			if left == 0 {
+				break
+			}
+			if o.Items[i].SKU != delivered.SKU {
+				continue
+			}
+			take := min(left, o.Items[i].Quantity-o.Items[i].DeliveredQuantity)
+			o.Items[i].DeliveredQuantity += take
+			left -= take
+		}
+	}
+
+	status := StatusDelivered
+	for _, item := range o.Items {
+		if item.DeliveredQuantity < item.Quantity {
+			status = StatusPartiallyDelivered
+			break
+		}
+	}
+	o.setStatus(status, now)
+
+	return nil
+}
+
+// mergeDeliveryItems merges delivery lines of the same SKU, keeping the order
+// in which SKUs first appear.
+func mergeDeliveryItems(items []DeliveryItem) []DeliveryItem {
+	merged := make([]DeliveryItem, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		sku := strings.TrimSpace(item.SKU)
+		if i, ok := index[sku]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[sku] = len(merged)
+		merged = append(merged, DeliveryItem{SKU: sku, Quantity: item.Quantity})
+	}
+	return merged
+}
+
+// RequestReturn moves a delivered order to RETURN_REQUESTED. The return must
+// be requested within window of the delivery (zero means no limit) and its
+// items must be a subset of the order items.
+func (o *Order) RequestReturn(reason string, items []ReturnItem, window time.Duration, now time.Time) error {
+	if !o.CanTransitionTo(StatusReturnRequested) {
+		return ErrInvalidStatusTransition
+	}
+	if window > 0 && o.DeliveredAt != nil && now.After(o.DeliveredAt.Add(window)) {
+		return ErrReturnWindowExpired
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrReturnReasonRequired
+	}
+	items, err := o.validateReturnItems(items)
+	if err != nil {
+		return err
+	}
+
+	o.Return = &OrderReturn{
+		Reason:      reason,
+		Items:       items,
+		RequestedAt: now,
+	}
+	o.setStatus(StatusReturnRequested, now)
+
+	return nil
+}
+
+// validateReturnItems merges return lines of the same SKU and checks them
+// against the ordered quantities.
+func (o *Order) validateReturnItems(items []ReturnItem) ([]ReturnItem, error) {
+	if len(items) == 0 {
+		return nil, ErrInvalidReturnItems
+	}
+
+	ordered := make(map[string]int, len(o.Items))
+	for _, item := range o.Items {
+		ordered[item.SKU] += item.Quantity
+	}
+
+	merged := make([]ReturnItem, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		sku := strings.TrimSpace(item.SKU)
+		if i, ok := index[sku]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[sku] = len(merged)
+		merged = append(merged, ReturnItem{SKU: sku, Quantity: item.Quantity})
+	}
+
+	var invalid []string
+	for _, item := range merged {
+		if item.Quantity < 1 || item.Quantity > ordered[item.SKU] {
+			invalid = append(invalid, item.SKU)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, &InvalidReturnItemsError{SKUs: invalid}
+	}
+	return merged, nil
+}
+
+// SetTags replaces the tags of the order with the given, already normalized, tags.
+func (o *Order) SetTags(tags []string) {
+	o.Tags = tags
+	o.touch(time.Now())
+}
+
+// SetPriority changes the priority of an order that is not in a final status.
+func (o *Order) SetPriority(priority OrderPriority) error {
+	if !priority.IsValid() {
+		return ErrInvalidPriority
+	}
+	if o.Status.IsFinal() {
+		return ErrOrderFinal
+	}
+
+	o.Priority = priority
+	o.PriorityRank = priority.Rank()
+	o.touch(time.Now())
 
 	return nil
 }
@@ -121,3 +1217,77 @@ func (o *Order) CalculateTotalAmount() {
 	}
 	o.TotalAmount = total
 }
+
+// RefreshSearchKeys recomputes the search keys of the order: its ID, the
+// prefixes of the ID between SearchPrefixMinLength and SearchPrefixMaxLength
+// characters and the SKUs of its lines, all in the form of SearchKey. A
+// prefix search is then an exact match on an indexed field.
+func (o *Order) RefreshSearchKeys() {
+	id := SearchKey(o.ID)
+	keys := make([]string, 0, SearchPrefixMaxLength-SearchPrefixMinLength+2+len(o.Items))
+	seen := make(map[string]bool, cap(keys))
+	add := func(key string) {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	runes := []rune(id)
+	for length := SearchPrefixMinLength; length <= SearchPrefixMaxLength && length < len(runes); length++ {
+		add(string(runes[:length]))
+	}
+	add(id)
+	for _, item := range o.Items {
+		add(SearchKey(item.SKU))
+	}
+	o.SearchKeys = keys
+}
+
+// SearchKey normalizes a search term into the form of the search keys.
+func SearchKey(term string) string {
+	return strings.ToLower(strings.TrimSpace(term))
+}
+
+// CalculateTotals refreshes the total amount, weight and volume of the order.
+// Lines without a weight or volume do not contribute to those totals.
+func (o *Order) CalculateTotals() {
+	o.CalculateTotalAmount()
+	weight, volume := 0, 0
+	for _, item := range o.Items {
+		weight += item.Quantity * item.WeightGrams
+		volume += item.Quantity * item.VolumeCm3
+	}
+	o.TotalWeightGrams = weight
+	o.TotalVolumeCm3 = volume
+}
+
+// ListVersion is the last write seen by an order listing, as recorded by the
+// write tracker, along with the time it was read. Both come from the same
+// clock. Writes are tracked coarsely, so a listing may be reported as
+// modified when its orders did not change, but never the other way around.
+type ListVersion struct {
+	LastWrite  time.Time
+	ObservedAt time.Time
+}
+
+// LastModified is the Last-Modified time to send with a listing read after
+// the version. HTTP dates have second precision, so when a later write could
+// still land in the same second the previous second is reported instead.
+func (v *ListVersion) LastModified() time.Time {
+	lastModified := v.LastWrite.Truncate(time.Second)
+	if !v.ObservedAt.Truncate(time.Second).After(lastModified) {
+		lastModified = lastModified.Add(-time.Second)
+	}
+	return lastModified.UTC()
+}
+
+// ModifiedSince reports whether the listing may have changed after the given
+// If-Modified-Since time. Times later than the version was observed are not
+// trusted and always report a modification.
+func (v *ListVersion) ModifiedSince(since time.Time) bool {
+	if since.After(v.ObservedAt) {
+		return true
+	}
+	return v.LastWrite.Truncate(time.Second).After(since)
+}
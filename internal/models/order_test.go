@@ -1,12 +1,15 @@
 package models_test
 
 import (
+	"encoding/json"
 	. "orders/internal/models"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 func TestOrderStatus_IsValid(t *testing.T) {
@@ -26,6 +29,45 @@ func TestOrderStatus_IsValid(t *testing.T) {
 	}
 }
 
+func TestOrder_SetInitialStatus(t *testing.T) {
+	allowed := []OrderStatus{StatusNew, StatusInProgress}
+	newOrder := func(t *testing.T) *Order {
+		order, err := NewOrder(uuid.New().String(), []OrderItem{{SKU: "SKU123", Quantity: 1, Price: 10}}, CustomerIDUUID)
+		assert.NoError(t, err)
+		return order
+	}
+
+	t.Run("default", func(t *testing.T) {
+		order := newOrder(t)
+		assert.NoError(t, order.SetInitialStatus("", allowed))
+		assert.Equal(t, StatusNew, order.Status)
+	})
+
+	t.Run("allowed starting status", func(t *testing.T) {
+		order := newOrder(t)
+		assert.NoError(t, order.SetInitialStatus(StatusInProgress, allowed))
+		assert.Equal(t, StatusInProgress, order.Status)
+
+		// Las transiciones siguen rigiendo a partir del estado inicial
+		assert.True(t, order.CanTransitionTo(StatusDelivered))
+		assert.False(t, order.CanTransitionTo(StatusNew))
+	})
+
+	t.Run("starting status not allowed", func(t *testing.T) {
+		order := newOrder(t)
+		err := order.SetInitialStatus(StatusInProgress, []OrderStatus{StatusNew})
+		assert.ErrorIs(t, err, ErrInvalidInitialStatus)
+		assert.Equal(t, StatusNew, order.Status)
+	})
+
+	t.Run("not a starting status", func(t *testing.T) {
+		order := newOrder(t)
+		err := order.SetInitialStatus(StatusDelivered, append(allowed, StatusDelivered))
+		assert.ErrorIs(t, err, ErrInvalidInitialStatus)
+		assert.Equal(t, StatusNew, order.Status)
+	})
+}
+
 func TestOrderItem_Subtotal(t *testing.T) {
 	item := OrderItem{SKU: "ABC123", Quantity: 2, Price: 10.5}
 	assert.Equal(t, 21.0, item.Subtotal())
@@ -38,7 +80,7 @@ func TestNewOrder_Success(t *testing.T) {
 		{SKU: "SKU456", Quantity: 1, Price: 50},
 	}
 
-	order, err := NewOrder(customerID, items)
+	order, err := NewOrder(customerID, items, CustomerIDUUID)
 	assert.NoError(t, err)
 	assert.NotNil(t, order)
 	assert.Equal(t, StatusNew, order.Status)
@@ -58,18 +100,25 @@ func TestNewOrder_InvalidData(t *testing.T) {
 		customerID string
 		items      []OrderItem
 		wantErr    error
+		message    string
 	}{
-		{"Empty customerID", "", validItems, ErrInvalidOrderData},
-		{"Invalid UUID", invalidUUID, validItems, ErrInvalidOrderData},
-		{"Empty items", uuid.New().String(), invalidItems, ErrInvalidOrderData},
-		{"Invalid item data", uuid.New().String(), []OrderItem{{SKU: "SKU", Quantity: 0, Price: 10}}, ErrInvalidOrderData},
+		{"Empty customerID", "", validItems, ErrInvalidOrderData, ""},
+		{"Invalid UUID", invalidUUID, validItems, ErrInvalidOrderData, ""},
+		{"Empty items", uuid.New().String(), invalidItems, ErrInvalidOrderData, ""},
+		{"Invalid item data", uuid.New().String(), []OrderItem{{SKU: "SKU", Quantity: 0, Price: 10}}, ErrInvalidOrderData, ""},
+		{"Negative weight", uuid.New().String(), []OrderItem{{SKU: "SKU", Quantity: 1, Price: 10, WeightGrams: -1}}, ErrInvalidOrderData, "item SKU: weightGrams must not be negative"},
+		{"Negative volume", uuid.New().String(), []OrderItem{{SKU: "SKU", Quantity: 1, Price: 10, VolumeCm3: -1}}, ErrInvalidOrderData, "item SKU: volumeCm3 must not be negative"},
+		{"Volume above the cap", uuid.New().String(), []OrderItem{{SKU: "SKU", Quantity: 1, Price: 10, VolumeCm3: MaxItemVolumeCm3 + 1}}, ErrInvalidOrderData, "item SKU: volumeCm3 exceeds the maximum of 10000000"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			order, err := NewOrder(tt.customerID, tt.items)
+			order, err := NewOrder(tt.customerID, tt.items, CustomerIDUUID)
 			assert.Nil(t, order)
 			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.message != "" {
+				assert.EqualError(t, err, tt.message)
+			}
 		})
 	}
 }
@@ -88,6 +137,14 @@ func TestOrder_CanTransitionTo(t *testing.T) {
 
 	order.Status = StatusDelivered
 	assert.False(t, order.CanTransitionTo(StatusCancelled))
+	assert.True(t, order.CanTransitionTo(StatusReturnRequested))
+	assert.False(t, order.CanTransitionTo(StatusReturned))
+
+	order.Status = StatusReturnRequested
+	assert.True(t, order.CanTransitionTo(StatusReturned))
+
+	order.Status = StatusReturned
+	assert.False(t, order.CanTransitionTo(StatusReturnRequested))
 }
 
 func TestOrder_UpdateStatus(t *testing.T) {
@@ -115,6 +172,121 @@ func TestOrder_UpdateStatus(t *testing.T) {
 	})
 }
 
+func TestOrder_ForceStatus(t *testing.T) {
+	deliveredAt := time.Now().Add(-time.Hour)
+	delivered := func() *Order {
+		return &Order{
+			Status:      StatusDelivered,
+			Version:     3,
+			DeliveredAt: &deliveredAt,
+			Items:       []OrderItem{{SKU: "LAPTOP-001", Quantity: 2, DeliveredQuantity: 2}},
+		}
+	}
+
+	t.Run("Back from delivered undoes the delivery", func(t *testing.T) {
+		order := delivered()
+		// La transición sigue prohibida por la máquina de estados
+		assert.ErrorIs(t, order.UpdateStatus(StatusInProgress), ErrInvalidStatusTransition)
+
+		err := order.ForceStatus(StatusInProgress)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusInProgress, order.Status)
+		assert.Equal(t, 4, order.Version)
+		assert.Nil(t, order.DeliveredAt)
+		assert.Equal(t, 0, order.Items[0].DeliveredQuantity)
+	})
+
+	t.Run("Back from returned drops the return", func(t *testing.T) {
+		order := delivered()
+		order.Status = StatusReturned
+		order.Return = &OrderReturn{Reason: "Escaneo erróneo", Items: []ReturnItem{{SKU: "LAPTOP-001", Quantity: 1}}}
+
+		err := order.ForceStatus(StatusDelivered)
+		assert.NoError(t, err)
+		assert.Nil(t, order.Return)
+		assert.NotNil(t, order.DeliveredAt)
+	})
+
+	t.Run("Rejected statuses", func(t *testing.T) {
+		tests := []struct {
+			status   OrderStatus
+			expected error
+		}{
+			{StatusDelivered, ErrInvalidStatusTransition},
+			{StatusReturnRequested, ErrReturnDetailsRequired},
+			{StatusPartiallyDelivered, ErrDeliveryDetailsRequired},
+			{"UNKNOWN", ErrInvalidOrderData},
+		}
+		for _, tt := range tests {
+			order := delivered()
+			assert.ErrorIs(t, order.ForceStatus(tt.status), tt.expected, tt.status)
+			assert.Equal(t, 3, order.Version)
+		}
+	})
+}
+
+func TestCustomerIDFormat_Accepts(t *testing.T) {
+	tests := []struct {
+		format     CustomerIDFormat
+		customerID string
+		expected   bool
+	}{
+		{CustomerIDUUID, "123e4567-e89b-12d3-a456-426614174000", true},
+		{CustomerIDUUID, "10442", false},
+		{CustomerIDUUID, "", false},
+		{CustomerIDUUID, "123E4567-E89B-12D3-A456-426614174000", true},
+		{CustomerIDUUID, "{123e4567-e89b-12d3-a456-426614174000}", true},
+		{CustomerIDUUID, "urn:uuid:123e4567-e89b-12d3-a456-426614174000", true},
+		{CustomerIDUUID, "00000000-0000-0000-0000-000000000000", false},
+		{"", "123e4567-e89b-12d3-a456-426614174000", true},
+		{"", "cus_8f2k", false},
+		{CustomerIDAlphanumeric, "10442", true},
+		{CustomerIDAlphanumeric, "cus_8f2k", true},
+		{CustomerIDAlphanumeric, "123e4567-e89b-12d3-a456-426614174000", true},
+		{CustomerIDAlphanumeric, "cus 8f2k", false},
+		{CustomerIDAlphanumeric, "cus:8f2k", false},
+		{CustomerIDAlphanumeric, strings.Repeat("a", MaxCustomerIDLength+1), false},
+		{CustomerIDAny, "ACME/customer:42", true},
+		{CustomerIDAny, "cliente ñandú", true},
+		{CustomerIDAny, "   ", false},
+		{CustomerIDAny, "bad\nid", false},
+		{CustomerIDAny, strings.Repeat("a", MaxCustomerIDLength+1), false},
+		{"email", "10442", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format)+"/"+tt.customerID, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.format.Accepts(tt.customerID))
+		})
+	}
+}
+
+func TestNewOrder_CustomerIDFormat(t *testing.T) {
+	items := []OrderItem{{SKU: "SKU", Quantity: 1, Price: 10}}
+
+	_, err := NewOrder("10442", items, CustomerIDUUID)
+	assert.ErrorIs(t, err, ErrInvalidOrderData)
+
+	order, err := NewOrder("10442", items, CustomerIDAlphanumeric)
+	assert.NoError(t, err)
+	assert.Equal(t, "10442", order.CustomerID)
+
+	_, err = NewOrder("ACME/42", items, CustomerIDAlphanumeric)
+	assert.ErrorIs(t, err, ErrInvalidOrderData)
+
+	_, err = NewOrder("ACME/42", items, CustomerIDAny)
+	assert.NoError(t, err)
+
+	// Los UUIDs se guardan en minúsculas
+	order, err = NewOrder("urn:uuid:123E4567-E89B-12D3-A456-426614174000", items, CustomerIDUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "123e4567-e89b-12d3-a456-426614174000", order.CustomerID)
+
+	order, err = NewOrder("CUS_8F2K", items, CustomerIDAlphanumeric)
+	assert.NoError(t, err)
+	assert.Equal(t, "CUS_8F2K", order.CustomerID)
+}
+
 func TestOrder_CalculateTotalAmount(t *testing.T) {
 	order := &Order{
 		Items: []OrderItem{
@@ -126,3 +298,531 @@ func TestOrder_CalculateTotalAmount(t *testing.T) {
 	order.CalculateTotalAmount()
 	assert.Equal(t, 25.0, order.TotalAmount)
 }
+
+func TestOrder_CalculateTotals(t *testing.T) {
+	order := &Order{
+		Items: []OrderItem{
+			{SKU: "A", Quantity: 2, Price: 10, WeightGrams: 1500, VolumeCm3: 3000},
+			{SKU: "B", Quantity: 3, Price: 5, WeightGrams: 200},
+			{SKU: "C", Quantity: 1, Price: 1},
+		},
+	}
+
+	order.CalculateTotals()
+	assert.Equal(t, 36.0, order.TotalAmount)
+	assert.Equal(t, 3600, order.TotalWeightGrams)
+	assert.Equal(t, 6000, order.TotalVolumeCm3)
+}
+
+func TestNewOrder_AggregatesMeasures(t *testing.T) {
+	tests := []struct {
+		name   string
+		items  []OrderItem
+		weight int
+		volume int
+	}{
+		{"Lines without measures", []OrderItem{
+			{SKU: "A", Quantity: 2, Price: 10},
+			{SKU: "B", Quantity: 1, Price: 5},
+		}, 0, 0},
+		// Las líneas sin medidas no suman, pero tampoco impiden sumar las demás
+		{"Mixed lines", []OrderItem{
+			{SKU: "A", Quantity: 4, Price: 10, WeightGrams: 250},
+			{SKU: "B", Quantity: 2, Price: 5},
+			{SKU: "C", Quantity: 3, Price: 1, VolumeCm3: 800},
+			{SKU: "D", Quantity: 1, Price: 2, WeightGrams: 1200, VolumeCm3: 5000},
+		}, 2200, 7400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := NewOrder(uuid.New().String(), tt.items, CustomerIDUUID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.weight, order.TotalWeightGrams)
+			assert.Equal(t, tt.volume, order.TotalVolumeCm3)
+		})
+	}
+}
+
+func TestOrder_Clone(t *testing.T) {
+	order := &Order{
+		Items:          []OrderItem{{SKU: "A", Quantity: 1, Price: 10}},
+		Tags:           []string{"gift"},
+		ClientMetadata: map[string]string{"appVersion": "1.0"},
+	}
+
+	clone := order.Clone()
+	clone.Items[0].DeliveredQuantity = 1
+	clone.Tags[0] = "fragile"
+	clone.ClientMetadata["appVersion"] = "2.0"
+
+	assert.Equal(t, 0, order.Items[0].DeliveredQuantity)
+	assert.Equal(t, "gift", order.Tags[0])
+	assert.Equal(t, "1.0", order.ClientMetadata["appVersion"])
+}
+
+func TestValidateNote(t *testing.T) {
+	assert.NoError(t, ValidateNote("gate code 4411", 20))
+	assert.NoError(t, ValidateNote("ñandú", 5), "length is measured in characters, not bytes")
+	assert.ErrorIs(t, ValidateNote("", 20), ErrNoteEmpty)
+	assert.ErrorIs(t, ValidateNote("   ", 20), ErrNoteEmpty)
+	assert.ErrorIs(t, ValidateNote("this is too long", 5), ErrNoteTooLong)
+	assert.NoError(t, ValidateNote("no cap configured", 0))
+}
+
+func TestConsolidateItems(t *testing.T) {
+	t.Run("Merges duplicate SKUs", func(t *testing.T) {
+		items, err := ConsolidateItems([]OrderItem{
+			{SKU: "SKU-1", Quantity: 2, Price: 10},
+			{SKU: "SKU-2", Quantity: 1, Price: 5},
+			{SKU: "SKU-1", Quantity: 3, Price: 10},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []OrderItem{
+			{SKU: "SKU-1", Quantity: 5, Price: 10},
+			{SKU: "SKU-2", Quantity: 1, Price: 5},
+		}, items)
+	})
+
+	t.Run("Normalizes case and whitespace before merging", func(t *testing.T) {
+		items, err := ConsolidateItems([]OrderItem{
+			{SKU: " sku-1", Quantity: 1, Price: 10},
+			{SKU: "SKU-1 ", Quantity: 1, Price: 10},
+			{SKU: "Sku-1", Quantity: 1, Price: 10},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []OrderItem{{SKU: "SKU-1", Quantity: 3, Price: 10}}, items)
+	})
+
+	t.Run("Rejects merged quantity overflow", func(t *testing.T) {
+		_, err := ConsolidateItems([]OrderItem{
+			{SKU: "SKU-1", Quantity: MaxItemQuantity, Price: 10},
+			{SKU: "sku-1", Quantity: 1, Price: 10},
+		})
+		var itemErr *ItemError
+		assert.ErrorAs(t, err, &itemErr)
+		assert.Equal(t, "SKU-1", itemErr.SKU)
+		assert.Equal(t, "quantity", itemErr.Field)
+		assert.ErrorIs(t, err, ErrInvalidOrderData)
+	})
+
+	t.Run("Rejects conflicting prices", func(t *testing.T) {
+		_, err := ConsolidateItems([]OrderItem{
+			{SKU: "SKU-1", Quantity: 1, Price: 10},
+			{SKU: "SKU-1", Quantity: 1, Price: 12},
+		})
+		var itemErr *ItemError
+		assert.ErrorAs(t, err, &itemErr)
+		assert.Equal(t, "price", itemErr.Field)
+	})
+}
+
+func TestOrder_IsSLABreached(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	early := past.Add(-time.Minute)
+
+	tests := []struct {
+		name  string
+		order Order
+		want  bool
+	}{
+		{"no promise", Order{Status: StatusInProgress}, false},
+		{"open before promise", Order{Status: StatusInProgress, PromisedDeliveryAt: &future}, false},
+		{"open past promise", Order{Status: StatusInProgress, PromisedDeliveryAt: &past}, true},
+		{"delivered late", Order{Status: StatusDelivered, PromisedDeliveryAt: &past, DeliveredAt: &now}, true},
+		{"delivered on time", Order{Status: StatusDelivered, PromisedDeliveryAt: &past, DeliveredAt: &early}, false},
+		{"cancelled past promise", Order{Status: StatusCancelled, PromisedDeliveryAt: &past}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.order.IsSLABreached(now))
+		})
+	}
+}
+
+func TestOrder_MarshalJSON_ComputesBreachedSLA(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	order := Order{ID: "order-1", Status: StatusInProgress, PromisedDeliveryAt: &past}
+
+	data, err := json.Marshal(&order)
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"breachedSLA":true`)
+}
+
+func TestOrder_MarshalJSON_OmitsAllowedTransitions(t *testing.T) {
+	// Sin la ventana de devolución el modelo no puede calcularlas; las sirve la API
+	order := Order{ID: "order-1", Status: StatusInProgress}
+
+	data, err := json.Marshal(&order)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "allowedTransitions")
+
+	raw, err := bson.Marshal(&order)
+	assert.NoError(t, err)
+	_, lookupErr := bson.Raw(raw).LookupErr("allowedTransitions")
+	assert.Error(t, lookupErr)
+}
+
+func TestStatusGraph(t *testing.T) {
+	graph := StatusGraph()
+
+	assert.Len(t, graph, len(Statuses))
+	for _, node := range graph {
+		order := &Order{Status: node.Status}
+		for _, next := range Statuses {
+			assert.Equal(t, order.CanTransitionTo(next), contains(node.Transitions, next), "%s -> %s", node.Status, next)
+		}
+	}
+}
+
+func contains(statuses []OrderStatus, status OrderStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func TestOrder_PriorityRoundTripsThroughJSON(t *testing.T) {
+	order, err := NewOrder(uuid.New().String(), []OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 10}}, CustomerIDUUID)
+	assert.NoError(t, err)
+	assert.NoError(t, order.SetPriority(PriorityHigh))
+
+	data, err := json.Marshal(order)
+	assert.NoError(t, err)
+
+	var cached Order
+	assert.NoError(t, json.Unmarshal(data, &cached))
+	assert.Equal(t, PriorityHigh, cached.Priority)
+}
+
+func TestOrderPriority_Rank(t *testing.T) {
+	assert.Less(t, PriorityLow.Rank(), PriorityNormal.Rank())
+	assert.Less(t, PriorityHigh.Rank(), PriorityUrgent.Rank())
+	assert.False(t, OrderPriority("CRITICAL").IsValid())
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{" VIP ", "fragile", "vip", "same-day_1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vip", "fragile", "same-day_1"}, tags)
+
+	_, err = NormalizeTags([]string{"this-tag-is-definitely-longer-than-thirty"})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+
+	_, err = NormalizeTags([]string{"no spaces"})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+
+	// Duplicates do not count towards the limit.
+	_, err = NormalizeTags([]string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "J"})
+	assert.NoError(t, err)
+
+	_, err = NormalizeTags([]string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"})
+	assert.ErrorIs(t, err, ErrTooManyTags)
+}
+
+func TestOrder_RequestReturn(t *testing.T) {
+	now := time.Now()
+	deliveredAt := now.Add(-24 * time.Hour)
+	delivered := func() *Order {
+		return &Order{
+			Status:      StatusDelivered,
+			Version:     3,
+			DeliveredAt: &deliveredAt,
+			Items: []OrderItem{
+				{SKU: "LAPTOP-001", Quantity: 2, Price: 999.99},
+				{SKU: "MOUSE-001", Quantity: 1, Price: 19.99},
+			},
+		}
+	}
+
+	t.Run("Subset of the items", func(t *testing.T) {
+		order := delivered()
+		err := order.RequestReturn(" damaged ", []ReturnItem{{SKU: "LAPTOP-001", Quantity: 1}, {SKU: "LAPTOP-001", Quantity: 1}}, 48*time.Hour, now)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusReturnRequested, order.Status)
+		assert.Equal(t, 4, order.Version)
+		assert.Equal(t, "damaged", order.Return.Reason)
+		assert.Equal(t, []ReturnItem{{SKU: "LAPTOP-001", Quantity: 2}}, order.Return.Items)
+
+		assert.NoError(t, order.UpdateStatus(StatusReturned))
+		assert.NotNil(t, order.Return.ReturnedAt)
+	})
+
+	t.Run("Outside the window", func(t *testing.T) {
+		err := delivered().RequestReturn("damaged", []ReturnItem{{SKU: "MOUSE-001", Quantity: 1}}, 12*time.Hour, now)
+		assert.ErrorIs(t, err, ErrReturnWindowExpired)
+	})
+
+	t.Run("Items not on the order or over the ordered quantity", func(t *testing.T) {
+		err := delivered().RequestReturn("damaged", []ReturnItem{{SKU: "MOUSE-001", Quantity: 2}, {SKU: "KEYBOARD-001", Quantity: 1}}, 0, now)
+		var itemsErr *InvalidReturnItemsError
+		assert.ErrorAs(t, err, &itemsErr)
+		assert.Equal(t, []string{"MOUSE-001", "KEYBOARD-001"}, itemsErr.SKUs)
+	})
+
+	t.Run("Not delivered", func(t *testing.T) {
+		order := delivered()
+		order.Status = StatusInProgress
+		err := order.RequestReturn("damaged", []ReturnItem{{SKU: "MOUSE-001", Quantity: 1}}, 0, now)
+		assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	})
+
+	t.Run("Status update without details", func(t *testing.T) {
+		err := delivered().UpdateStatus(StatusReturnRequested)
+		assert.ErrorIs(t, err, ErrReturnDetailsRequired)
+	})
+}
+
+func TestOrder_RecordDelivery(t *testing.T) {
+	now := time.Now()
+	newOrder := func() *Order {
+		return &Order{
+			Status:  StatusInProgress,
+			Version: 2,
+			Items: []OrderItem{
+				{SKU: "LAPTOP-001", Quantity: 2, Price: 999.99},
+				{SKU: "MOUSE-001", Quantity: 1, Price: 19.99},
+			},
+		}
+	}
+
+	t.Run("Cumulative deliveries complete the order", func(t *testing.T) {
+		order := newOrder()
+
+		assert.NoError(t, order.RecordDelivery([]DeliveryItem{{SKU: "LAPTOP-001", Quantity: 1}}, now))
+		assert.Equal(t, StatusPartiallyDelivered, order.Status)
+		assert.Equal(t, 1, order.Items[0].DeliveredQuantity)
+		assert.Nil(t, order.DeliveredAt)
+
+		assert.NoError(t, order.RecordDelivery([]DeliveryItem{{SKU: "LAPTOP-001", Quantity: 1}}, now))
+		assert.Equal(t, StatusPartiallyDelivered, order.Status)
+
+		assert.NoError(t, order.RecordDelivery([]DeliveryItem{{SKU: "MOUSE-001", Quantity: 1}}, now))
+		assert.Equal(t, StatusDelivered, order.Status)
+		assert.Equal(t, 5, order.Version)
+		assert.NotNil(t, order.DeliveredAt)
+	})
+
+	t.Run("Over-delivery is rejected", func(t *testing.T) {
+		order := newOrder()
+		assert.NoError(t, order.RecordDelivery([]DeliveryItem{{SKU: "LAPTOP-001", Quantity: 1}}, now))
+
+		err := order.RecordDelivery([]DeliveryItem{{SKU: "LAPTOP-001", Quantity: 1}, {SKU: "LAPTOP-001", Quantity: 1}, {SKU: "CABLE-001", Quantity: 1}}, now)
+		var itemsErr *InvalidDeliveryItemsError
+		assert.ErrorAs(t, err, &itemsErr)
+		assert.Equal(t, []string{"LAPTOP-001", "CABLE-001"}, itemsErr.SKUs)
+		assert.Equal(t, 1, order.Items[0].DeliveredQuantity)
+		assert.Equal(t, StatusPartiallyDelivered, order.Status)
+	})
+
+	t.Run("Duplicate SKU lines are filled in order", func(t *testing.T) {
+		order := &Order{
+			Status: StatusInProgress,
+			Items: []OrderItem{
+				{SKU: "LAPTOP-001", Quantity: 1, Price: 999.99},
+				{SKU: "LAPTOP-001", Quantity: 2, Price: 999.99},
+			},
+		}
+		assert.NoError(t, order.RecordDelivery([]DeliveryItem{{SKU: "LAPTOP-001", Quantity: 2}}, now))
+		assert.Equal(t, 1, order.Items[0].DeliveredQuantity)
+		assert.Equal(t, 1, order.Items[1].DeliveredQuantity)
+	})
+
+	t.Run("Order not in progress", func(t *testing.T) {
+		order := newOrder()
+		order.Status = StatusNew
+		err := order.RecordDelivery([]DeliveryItem{{SKU: "MOUSE-001", Quantity: 1}}, now)
+		assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	})
+}
+
+func TestOrderEvent_CausedBy(t *testing.T) {
+	order := &Order{ID: "order-123", Status: StatusDelivered}
+	cause := NewOrderStatusChangedEvent(order.ID, order.CustomerID, StatusInProgress, StatusDelivered)
+	cause.CorrelationID = "request-1"
+
+	event := NewOrderReturnEvent(order, StatusDelivered)
+	event.CausedBy(cause)
+
+	assert.Equal(t, "request-1", event.CorrelationID)
+	assert.Equal(t, cause.EventID, event.CausationID)
+}
+
+func TestChannelForClient(t *testing.T) {
+	assert.Equal(t, ChannelMobile, ChannelForClient("mobile"))
+	assert.Equal(t, ChannelPartner, ChannelForClient("partner_api"))
+	assert.Equal(t, ChannelPartner, ChannelForClient("acme"))
+}
+
+func TestCustomerSnapshot_Masked(t *testing.T) {
+	snapshot := CustomerSnapshot{Email: "jane@example.com", Name: "Jane Doe", Phone: "+34600123456"}
+
+	masked := snapshot.Masked()
+
+	assert.Equal(t, "j***@example.com", masked.Email)
+	assert.Equal(t, "J*******", masked.Name)
+	assert.Equal(t, "**********56", masked.Phone)
+	assert.NotContains(t, snapshot.String(), "jane")
+	assert.NotContains(t, snapshot.String(), "600123")
+}
+
+func TestValidateClientMetadata(t *testing.T) {
+	assert.NoError(t, ValidateClientMetadata(nil))
+	assert.NoError(t, ValidateClientMetadata(map[string]string{"appVersion": "4.2.0"}))
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= MaxMetadataKeys; i++ {
+		tooMany[uuid.New().String()] = "x"
+	}
+	var metadataErr *InvalidMetadataError
+	assert.ErrorAs(t, ValidateClientMetadata(tooMany), &metadataErr)
+	assert.Equal(t, "clientMetadata", metadataErr.Fields[0].Field)
+
+	err := ValidateClientMetadata(map[string]string{
+		"appVersion": "4.2.0",
+		"userAgent":  strings.Repeat("a", MaxMetadataValueLength+1),
+		"":           "empty key",
+	})
+	assert.ErrorIs(t, err, ErrInvalidClientMetadata)
+	assert.ErrorAs(t, err, &metadataErr)
+	assert.Equal(t, []MetadataFieldError{
+		{Field: "clientMetadata.", Message: "key must be between 1 and 64 characters long"},
+		{Field: "clientMetadata.userAgent", Message: "must be at most 256 characters long"},
+	}, metadataErr.Fields)
+}
+
+func TestListVersion_NeverNotModifiedAfterWrite(t *testing.T) {
+	base := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	step := 250 * time.Millisecond
+
+	// A listing read at readAt after the last write, followed by either no
+	// write or a write at any later time
+	for lastWrite := base; lastWrite.Before(base.Add(2 * time.Second)); lastWrite = lastWrite.Add(step) {
+		for readAt := lastWrite; readAt.Before(lastWrite.Add(2 * time.Second)); readAt = readAt.Add(step) {
+			read := &ListVersion{LastWrite: lastWrite, ObservedAt: readAt}
+			lastModified := read.LastModified()
+			assert.Zero(t, lastModified.Nanosecond())
+
+			for written := readAt.Add(time.Millisecond); written.Before(readAt.Add(2 * time.Second)); written = written.Add(step) {
+				later := &ListVersion{LastWrite: written, ObservedAt: written.Add(time.Second)}
+				assert.True(t, later.ModifiedSince(lastModified), "write at %s after read at %s", written, readAt)
+			}
+
+			unchanged := &ListVersion{LastWrite: lastWrite, ObservedAt: readAt.Add(5 * time.Second)}
+			if lastModified.Equal(lastWrite.Truncate(time.Second)) {
+				assert.False(t, unchanged.ModifiedSince(lastModified))
+			}
+		}
+	}
+
+	// Dates later than the version are not trusted
+	version := &ListVersion{LastWrite: base, ObservedAt: base.Add(time.Minute)}
+	assert.False(t, version.ModifiedSince(base.Add(time.Minute)))
+	assert.True(t, version.ModifiedSince(base.Add(time.Hour)))
+}
+
+func TestOrder_IsCacheable(t *testing.T) {
+	assert.True(t, (&Order{ID: "order-123", Status: StatusNew}).IsCacheable())
+	assert.False(t, (&Order{Status: StatusNew}).IsCacheable())
+	assert.False(t, (&Order{ID: "order-123"}).IsCacheable())
+	assert.False(t, (&Order{ID: "order-123", Status: "SHIPPED"}).IsCacheable())
+}
+
+func TestOrder_RefreshSearchKeys(t *testing.T) {
+	order := &Order{
+		ID: "3F2A9C1E-7B4D-4E8F-9A0B-1C2D3E4F5A6B",
+		Items: []OrderItem{
+			{SKU: "LAPTOP-001", Quantity: 1, Price: 999},
+			{SKU: " laptop-001 ", Quantity: 1, Price: 999},
+			{SKU: "MOUSE-002", Quantity: 2, Price: 25},
+		},
+	}
+
+	order.RefreshSearchKeys()
+
+	// Prefijos del ID de 4 a 12 caracteres, el ID completo y las SKUs sin repetir
+	assert.Equal(t, []string{
+		"3f2a", "3f2a9", "3f2a9c", "3f2a9c1", "3f2a9c1e", "3f2a9c1e-", "3f2a9c1e-7", "3f2a9c1e-7b", "3f2a9c1e-7b4",
+		"3f2a9c1e-7b4d-4e8f-9a0b-1c2d3e4f5a6b",
+		"laptop-001", "mouse-002",
+	}, order.SearchKeys)
+	assert.Contains(t, order.SearchKeys, SearchKey(" 3F2A9c1e "))
+
+	// Los IDs cortos no repiten el ID completo como prefijo
+	short := &Order{ID: "order-1"}
+	short.RefreshSearchKeys()
+	assert.Equal(t, []string{"orde", "order", "order-", "order-1"}, short.SearchKeys)
+}
+
+func TestOrder_MutatorsBumpVersionOnce(t *testing.T) {
+	now := time.Now()
+	deliveredAt := now.Add(-time.Hour)
+	newOrder := func(status OrderStatus) *Order {
+		order := &Order{
+			Status:    status,
+			Version:   3,
+			UpdatedAt: now.Add(-24 * time.Hour),
+			Items:     []OrderItem{{SKU: "LAPTOP-001", Quantity: 2, Price: 999.99}},
+		}
+		if status == StatusDelivered {
+			order.DeliveredAt = &deliveredAt
+			order.Items[0].DeliveredQuantity = 2
+		}
+		return order
+	}
+
+	mutations := []struct {
+		name   string
+		status OrderStatus
+		mutate func(o *Order) error
+	}{
+		{"UpdateStatus", StatusNew, func(o *Order) error { return o.UpdateStatus(StatusInProgress) }},
+		{"UpdateStatus to DELIVERED", StatusInProgress, func(o *Order) error { return o.UpdateStatus(StatusDelivered) }},
+		{"ForceStatus", StatusDelivered, func(o *Order) error { return o.ForceStatus(StatusInProgress) }},
+		{"RecordDelivery partial", StatusInProgress, func(o *Order) error {
+			return o.RecordDelivery([]DeliveryItem{{SKU: "LAPTOP-001", Quantity: 1}}, now)
+		}},
+		{"RecordDelivery complete", StatusInProgress, func(o *Order) error {
+			return o.RecordDelivery([]DeliveryItem{{SKU: "LAPTOP-001", Quantity: 2}}, now)
+		}},
+		{"RequestReturn", StatusDelivered, func(o *Order) error {
+			return o.RequestReturn("damaged", []ReturnItem{{SKU: "LAPTOP-001", Quantity: 1}}, 0, now)
+		}},
+		{"SetTags", StatusNew, func(o *Order) error { o.SetTags([]string{"gift"}); return nil }},
+		{"SetPriority", StatusNew, func(o *Order) error { return o.SetPriority(PriorityHigh) }},
+	}
+
+	for _, tt := range mutations {
+		t.Run(tt.name, func(t *testing.T) {
+			order := newOrder(tt.status)
+			before := order.Clone()
+
+			assert.NoError(t, tt.mutate(order))
+
+			assert.Equal(t, before.Version+1, order.Version)
+			assert.True(t, order.UpdatedAt.After(before.UpdatedAt))
+			assert.NoError(t, order.VerifyChange(before))
+		})
+	}
+}
+
+func TestOrder_VerifyChange(t *testing.T) {
+	order := &Order{Status: StatusNew, Version: 3}
+	before := order.Clone()
+
+	// Asignar el estado directamente no incrementa la versión
+	order.Status = StatusInProgress
+	assert.ErrorIs(t, order.VerifyChange(before), ErrUnversionedChange)
+
+	// Dos mutaciones sobre el mismo guardado tampoco son válidas
+	order = before.Clone()
+	assert.NoError(t, order.UpdateStatus(StatusInProgress))
+	assert.NoError(t, order.SetPriority(PriorityHigh))
+	assert.ErrorIs(t, order.VerifyChange(before), ErrUnversionedChange)
+}
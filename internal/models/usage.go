@@ -0,0 +1,30 @@
+package models
+
+// Periods of the request quotas of the API clients, in UTC.
+const (
+	QuotaDaily   = "daily"
+	QuotaMonthly = "monthly"
+)
+
+// Quota bounds the requests of an API client per period. Zero does not
+// limit the period.
+type Quota struct {
+	Daily   int64
+	Monthly int64
+}
+
+// DailyUsage is the number of requests of a client on a day, broken down by
+// route.
+type DailyUsage struct {
+	Date     string           `json:"date"`
+	Requests int64            `json:"requests"`
+	Routes   map[string]int64 `json:"routes,omitempty"`
+}
+
+// ClientUsage is the number of requests of a client over a range of days,
+// oldest day first. Days without requests are left out.
+type ClientUsage struct {
+	Client   string       `json:"client"`
+	Requests int64        `json:"requests"`
+	Days     []DailyUsage `json:"days"`
+}
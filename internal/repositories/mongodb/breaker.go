@@ -0,0 +1,139 @@
+package mongodb
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"orders/internal/breaker"
+	"orders/internal/models"
+	"orders/internal/repositories"
+)
+
+// breakerFailure reports whether the call counts as a failure of the database. Not
+// found, conflicts and calls given up by the caller say nothing about its
+// health.
+func breakerFailure(ctx context.Context, err *repositories.RepositoryError) bool {
+	return err != nil && err.StatusCode >= http.StatusInternalServerError && ctx.Err() == nil
+}
+
+// circuitOpenError is returned while the breaker fails calls.
+func circuitOpenError() *repositories.RepositoryError {
+	return &repositories.RepositoryError{
+		StatusCode: http.StatusServiceUnavailable,
+		Cause:      repositories.CauseCircuitOpen,
+		Message:    "Database temporarily unavailable",
+	}
+}
+
+// breakerRepository guards every operation of the wrapped repository with a
+// circuit breaker.
+type breakerRepository struct {
+	next    Repository
+	breaker *breaker.Breaker
+}
+
+// WithCircuitBreaker wraps the repository so its operations fail fast with
+// 503 while the breaker is open.
+func WithCircuitBreaker(repo Repository, b *breaker.Breaker) Repository {
+	return &breakerRepository{next: repo, breaker: b}
+}
+
+func (r *breakerRepository) Create(ctx context.Context, order *models.Order) *repositories.RepositoryError {
+	if !r.breaker.Allow() {
+		return circuitOpenError()
+	}
+	err := r.next.Create(ctx, order)
+	r.breaker.Record(breakerFailure(ctx, err))
+	return err
+}
+
+func (r *breakerRepository) FindByID(ctx context.Context, id string) (*models.Order, *repositories.RepositoryError) {
+	if !r.breaker.Allow() {
+		return nil, circuitOpenError()
+	}
+	order, err := r.next.FindByID(ctx, id)
+	r.breaker.Record(breakerFailure(ctx, err))
+	return order, err
+}
+
+func (r *breakerRepository) FindByIDs(ctx context.Context, ids []string) ([]*models.Order, *repositories.RepositoryError) {
+	if !r.breaker.Allow() {
+		return nil, circuitOpenError()
+	}
+	orders, err := r.next.FindByIDs(ctx, ids)
+	r.breaker.Record(breakerFailure(ctx, err))
+	return orders, err
+}
+
+func (r *breakerRepository) FindWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.Order, int64, *repositories.RepositoryError) {
+	if !r.breaker.Allow() {
+		return nil, 0, circuitOpenError()
+	}
+	orders, total, err := r.next.FindWithFilters(ctx, filters, page, limit)
+	r.breaker.Record(breakerFailure(ctx, err))
+	return orders, total, err
+}
+
+func (r *breakerRepository) StreamWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int, each func(*models.Order) error) (int64, *repositories.RepositoryError) {
+	if !r.breaker.Allow() {
+		return 0, circuitOpenError()
+	}
+	total, err := r.next.StreamWithFilters(ctx, filters, page, limit, each)
+	r.breaker.Record(breakerFailure(ctx, err))
+	return total, err
+}
+
+func (r *breakerRepository) Update(ctx context.Context, order *models.Order) *repositories.RepositoryError {
+	if !r.breaker.Allow() {
+		return circuitOpenError()
+	}
+	err := r.next.Update(ctx, order)
+	r.breaker.Record(breakerFailure(ctx, err))
+	return err
+}
+
+func (r *breakerRepository) AppendNote(ctx context.Context, id string, note models.OrderNote, maxNotes int) (*models.Order, *repositories.RepositoryError) {
+	if !r.breaker.Allow() {
+		return nil, circuitOpenError()
+	}
+	order, err := r.next.AppendNote(ctx, id, note, maxNotes)
+	r.breaker.Record(breakerFailure(ctx, err))
+	return order, err
+}
+
+func (r *breakerRepository) FindSLABreachCandidates(ctx context.Context, now time.Time, limit int) ([]*models.Order, *repositories.RepositoryError) {
+	if !r.breaker.Allow() {
+		return nil, circuitOpenError()
+	}
+	orders, err := r.next.FindSLABreachCandidates(ctx, now, limit)
+	r.breaker.Record(breakerFailure(ctx, err))
+	return orders, err
+}
+
+func (r *breakerRepository) MarkSLABreachNotified(ctx context.Context, id string, at time.Time) (bool, *repositories.RepositoryError) {
+	if !r.breaker.Allow() {
+		return false, circuitOpenError()
+	}
+	claimed, err := r.next.MarkSLABreachNotified(ctx, id, at)
+	r.breaker.Record(breakerFailure(ctx, err))
+	return claimed, err
+}
+
+func (r *breakerRepository) Stats(ctx context.Context, since time.Time) (*models.OrderStats, *repositories.RepositoryError) {
+	if !r.breaker.Allow() {
+		return nil, circuitOpenError()
+	}
+	stats, err := r.next.Stats(ctx, since)
+	r.breaker.Record(breakerFailure(ctx, err))
+	return stats, err
+}
+
+func (r *breakerRepository) Search(ctx context.Context, term string, limit int) ([]*models.Order, *repositories.RepositoryError) {
+	if !r.breaker.Allow() {
+		return nil, circuitOpenError()
+	}
+	orders, err := r.next.Search(ctx, term, limit)
+	r.breaker.Record(breakerFailure(ctx, err))
+	return orders, err
+}
@@ -0,0 +1,77 @@
+package mongodb_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"orders/internal/breaker"
+	"orders/internal/metrics"
+	"orders/internal/repositories"
+	"orders/internal/repositories/mongodb"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCircuitBreaker_TripsAndRecovers(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("trip and recovery", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		failure := mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 50, Name: "MaxTimeMSExpired", Message: "operation exceeded time limit"})
+		found := mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "_id", Value: "order-123"}, {Key: "status", Value: "NEW"}})
+		notFound := mtest.CreateCursorResponse(0, ns, mtest.FirstBatch)
+
+		b := breaker.New(2, 50*time.Millisecond, metrics.MongoCircuitBreakerState)
+		repo := mongodb.WithCircuitBreaker(mongodb.NewOrderRepository(mt.DB), b)
+		ctx := context.Background()
+
+		// Un pedido inexistente no cuenta como fallo ni un éxito deja fallos acumulados
+		mt.AddMockResponses(failure, notFound, failure, found)
+		for range 4 {
+			repo.FindByID(ctx, "order-123")
+		}
+		assert.Equal(mt, breaker.Closed, b.State())
+
+		// Dos fallos seguidos abren el circuito
+		mt.AddMockResponses(failure, failure)
+		for range 2 {
+			_, err := repo.FindByID(ctx, "order-123")
+			require.NotNil(mt, err)
+			assert.Equal(mt, http.StatusInternalServerError, err.StatusCode)
+		}
+		assert.Equal(mt, breaker.Open, b.State())
+		assert.Equal(mt, float64(breaker.Open), testutil.ToFloat64(metrics.MongoCircuitBreakerState))
+
+		// Abierto, falla con 503 sin llegar a MongoDB
+		mt.ClearEvents()
+		_, err := repo.FindByID(ctx, "order-123")
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusServiceUnavailable, err.StatusCode)
+		assert.Equal(mt, repositories.CauseCircuitOpen, err.Cause)
+		assert.Empty(mt, mt.GetAllStartedEvents())
+
+		// Pasado el tiempo de apertura, una sonda fallida lo vuelve a abrir
+		time.Sleep(60 * time.Millisecond)
+		assert.Equal(mt, breaker.HalfOpen, b.State())
+		mt.AddMockResponses(failure)
+		_, err = repo.FindByID(ctx, "order-123")
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusInternalServerError, err.StatusCode)
+		assert.Equal(mt, breaker.Open, b.State())
+
+		// y una sonda correcta lo cierra
+		time.Sleep(60 * time.Millisecond)
+		mt.AddMockResponses(found)
+		order, err := repo.FindByID(ctx, "order-123")
+		require.Nil(mt, err)
+		assert.Equal(mt, "order-123", order.ID)
+		assert.Equal(mt, breaker.Closed, b.State())
+		assert.Equal(mt, float64(breaker.Closed), testutil.ToFloat64(metrics.MongoCircuitBreakerState))
+	})
+}
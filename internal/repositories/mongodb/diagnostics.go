@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Topologies reported by Describe.
+const (
+	TopologyStandalone = "standalone"
+	TopologyReplicaSet = "replicaSet"
+	TopologySharded    = "sharded"
+)
+
+// ServerInfo describes the MongoDB deployment the service talks to, as
+// reported by the server it reached.
+type ServerInfo struct {
+	Version  string `json:"version"`
+	Topology string `json:"topology"`
+	// SetName, Primary and Hosts describe the replica set, when there is one
+	SetName string   `json:"setName,omitempty"`
+	Primary string   `json:"primary,omitempty"`
+	Hosts   []string `json:"hosts,omitempty"`
+	// Me is the member that answered and WritablePrimary whether it is the primary
+	Me              string `json:"me,omitempty"`
+	WritablePrimary bool   `json:"isWritablePrimary"`
+}
+
+// Describe reports the version and topology of the deployment from the hello
+// and buildInfo commands.
+func Describe(ctx context.Context, client *mongo.Client) (*ServerInfo, error) {
+	admin := client.Database("admin")
+
+	var hello struct {
+		SetName         string   `bson:"setName"`
+		Primary         string   `bson:"primary"`
+		Hosts           []string `bson:"hosts"`
+		Me              string   `bson:"me"`
+		WritablePrimary bool     `bson:"isWritablePrimary"`
+		Msg             string   `bson:"msg"`
+	}
+	if err := admin.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return nil, fmt.Errorf("hello: %w", err)
+	}
+	var buildInfo struct {
+		Version string `bson:"version"`
+	}
+	if err := admin.RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&buildInfo); err != nil {
+		return nil, fmt.Errorf("buildInfo: %w", err)
+	}
+
+	info := &ServerInfo{
+		Version:         buildInfo.Version,
+		Topology:        TopologyStandalone,
+		SetName:         hello.SetName,
+		Primary:         hello.Primary,
+		Hosts:           hello.Hosts,
+		Me:              hello.Me,
+		WritablePrimary: hello.WritablePrimary,
+	}
+	switch {
+	case hello.Msg == "isdbgrid":
+		info.Topology = TopologySharded
+	case hello.SetName != "":
+		info.Topology = TopologyReplicaSet
+	}
+	return info, nil
+}
@@ -0,0 +1,60 @@
+package mongodb_test
+
+import (
+	"context"
+	"testing"
+
+	"orders/internal/repositories/mongodb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestDescribe(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("replica set", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(
+				bson.E{Key: "setName", Value: "rs0"},
+				bson.E{Key: "primary", Value: "mongo-0:27017"},
+				bson.E{Key: "hosts", Value: bson.A{"mongo-0:27017", "mongo-1:27017"}},
+				bson.E{Key: "me", Value: "mongo-1:27017"},
+				bson.E{Key: "isWritablePrimary", Value: false},
+			),
+			mtest.CreateSuccessResponse(bson.E{Key: "version", Value: "7.0.12"}),
+		)
+
+		info, err := mongodb.Describe(context.Background(), mt.Client)
+		require.NoError(mt, err)
+		assert.Equal(mt, &mongodb.ServerInfo{
+			Version:  "7.0.12",
+			Topology: mongodb.TopologyReplicaSet,
+			SetName:  "rs0",
+			Primary:  "mongo-0:27017",
+			Hosts:    []string{"mongo-0:27017", "mongo-1:27017"},
+			Me:       "mongo-1:27017",
+		}, info)
+	})
+
+	mt.Run("mongos", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "msg", Value: "isdbgrid"}, bson.E{Key: "isWritablePrimary", Value: true}),
+			mtest.CreateSuccessResponse(bson.E{Key: "version", Value: "6.0.4"}),
+		)
+
+		info, err := mongodb.Describe(context.Background(), mt.Client)
+		require.NoError(mt, err)
+		assert.Equal(mt, mongodb.TopologySharded, info.Topology)
+		assert.True(mt, info.WritablePrimary)
+	})
+
+	mt.Run("command failure", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 13, Message: "not authorized"}))
+
+		_, err := mongodb.Describe(context.Background(), mt.Client)
+		assert.ErrorContains(mt, err, "hello")
+	})
+}
@@ -0,0 +1,233 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"orders/internal/models"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// OrderDocument is an order as stored in the orders collection. It is kept
+// apart from models.Order, which is also served by the API, cached and
+// published, so the stored layout can change, e.g. to keep amounts as
+// integers, without changing those.
+type OrderDocument struct {
+	ID                  string                    `bson:"_id"`
+	TenantID            string                    `bson:"tenantId,omitempty"`
+	CustomerID          string                    `bson:"customerId"`
+	Status              models.OrderStatus        `bson:"status"`
+	Priority            models.OrderPriority      `bson:"priority"`
+	Channel             models.OrderChannel       `bson:"channel,omitempty"`
+	Items               []OrderItemDocument       `bson:"items"`
+	Tags                []string                  `bson:"tags,omitempty"`
+	TotalAmount         float64                   `bson:"totalAmount"`
+	Notes               string                    `bson:"notes,omitempty"`
+	NoteEntries         []OrderNoteDocument       `bson:"noteEntries,omitempty"`
+	Version             int                       `bson:"version"`
+	CreatedAt           time.Time                 `bson:"createdAt"`
+	UpdatedAt           time.Time                 `bson:"updatedAt"`
+	DeletedAt           *time.Time                `bson:"deletedAt,omitempty"`
+	ClientMetadata      map[string]string         `bson:"clientMetadata,omitempty"`
+	CustomerSnapshot    *CustomerSnapshotDocument `bson:"customerSnapshot,omitempty"`
+	TotalWeightGrams    int                       `bson:"totalWeightGrams"`
+	TotalVolumeCm3      int                       `bson:"totalVolumeCm3"`
+	PromisedDeliveryAt  *time.Time                `bson:"promisedDeliveryAt,omitempty"`
+	DeliveredAt         *time.Time                `bson:"deliveredAt,omitempty"`
+	SLABreachNotifiedAt *time.Time                `bson:"slaBreachNotifiedAt,omitempty"`
+	PriorityRank        int                       `bson:"priorityRank"`
+	Return              *OrderReturnDocument      `bson:"return,omitempty"`
+	SearchKeys          []string                  `bson:"searchKeys,omitempty"`
+}
+
+// OrderItemDocument is a stored order line.
+type OrderItemDocument struct {
+	SKU               string     `bson:"sku"`
+	Quantity          int        `bson:"quantity"`
+	Price             float64    `bson:"price"`
+	WeightGrams       int        `bson:"weightGrams,omitempty"`
+	VolumeCm3         int        `bson:"volumeCm3,omitempty"`
+	DeliveredQuantity int        `bson:"deliveredQuantity"`
+	PriceSnapshotAt   *time.Time `bson:"priceSnapshotAt,omitempty"`
+}
+
+// OrderNoteDocument is a stored note of an order.
+type OrderNoteDocument struct {
+	Author    string    `bson:"author,omitempty"`
+	Text      string    `bson:"text"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+// CustomerSnapshotDocument is the stored contact data of the customer.
+type CustomerSnapshotDocument struct {
+	Email string `bson:"email,omitempty"`
+	Name  string `bson:"name,omitempty"`
+	Phone string `bson:"phone,omitempty"`
+}
+
+// OrderReturnDocument is the stored return of an order.
+type OrderReturnDocument struct {
+	Reason      string               `bson:"reason"`
+	Items       []ReturnItemDocument `bson:"items"`
+	RequestedAt time.Time            `bson:"requestedAt"`
+	ReturnedAt  *time.Time           `bson:"returnedAt,omitempty"`
+}
+
+// ReturnItemDocument is a stored returned quantity of a SKU.
+type ReturnItemDocument struct {
+	SKU      string `bson:"sku"`
+	Quantity int    `bson:"quantity"`
+}
+
+// ToOrderDocument returns the order as stored.
+func ToOrderDocument(order *models.Order) *OrderDocument {
+	doc := &OrderDocument{
+		ID:                  order.ID,
+		TenantID:            order.TenantID,
+		CustomerID:          order.CustomerID,
+		Status:              order.Status,
+		Priority:            order.Priority,
+		Channel:             order.Channel,
+		Items:               toItemDocuments(order.Items),
+		Tags:                order.Tags,
+		TotalAmount:         order.TotalAmount,
+		Notes:               order.Notes,
+		Version:             order.Version,
+		CreatedAt:           order.CreatedAt,
+		UpdatedAt:           order.UpdatedAt,
+		DeletedAt:           order.DeletedAt,
+		ClientMetadata:      order.ClientMetadata,
+		TotalWeightGrams:    order.TotalWeightGrams,
+		TotalVolumeCm3:      order.TotalVolumeCm3,
+		PromisedDeliveryAt:  order.PromisedDeliveryAt,
+		DeliveredAt:         order.DeliveredAt,
+		SLABreachNotifiedAt: order.SLABreachNotifiedAt,
+		PriorityRank:        order.PriorityRank,
+		Return:              toReturnDocument(order.Return),
+		SearchKeys:          order.SearchKeys,
+	}
+	if order.NoteEntries != nil {
+		doc.NoteEntries = make([]OrderNoteDocument, len(order.NoteEntries))
+		for i, note := range order.NoteEntries {
+			doc.NoteEntries[i] = toNoteDocument(note)
+		}
+	}
+	if snapshot := order.CustomerSnapshot; snapshot != nil {
+		doc.CustomerSnapshot = &CustomerSnapshotDocument{Email: snapshot.Email, Name: snapshot.Name, Phone: snapshot.Phone}
+	}
+	return doc
+}
+
+// Order returns the stored order.
+func (d *OrderDocument) Order() *models.Order {
+	order := &models.Order{
+		ID:                  d.ID,
+		TenantID:            d.TenantID,
+		CustomerID:          d.CustomerID,
+		Status:              d.Status,
+		Priority:            d.Priority,
+		Channel:             d.Channel,
+		Tags:                d.Tags,
+		TotalAmount:         d.TotalAmount,
+		Notes:               d.Notes,
+		Version:             d.Version,
+		CreatedAt:           d.CreatedAt,
+		UpdatedAt:           d.UpdatedAt,
+		DeletedAt:           d.DeletedAt,
+		ClientMetadata:      d.ClientMetadata,
+		TotalWeightGrams:    d.TotalWeightGrams,
+		TotalVolumeCm3:      d.TotalVolumeCm3,
+		PromisedDeliveryAt:  d.PromisedDeliveryAt,
+		DeliveredAt:         d.DeliveredAt,
+		SLABreachNotifiedAt: d.SLABreachNotifiedAt,
+		PriorityRank:        d.PriorityRank,
+		SearchKeys:          d.SearchKeys,
+	}
+	if d.Items != nil {
+		order.Items = make([]models.OrderItem, len(d.Items))
+		for i, item := range d.Items {
+			order.Items[i] = models.OrderItem{
+				SKU:               item.SKU,
+				Quantity:          item.Quantity,
+				Price:             item.Price,
+				WeightGrams:       item.WeightGrams,
+				VolumeCm3:         item.VolumeCm3,
+				DeliveredQuantity: item.DeliveredQuantity,
+				PriceSnapshotAt:   item.PriceSnapshotAt,
+			}
+		}
+	}
+	if d.NoteEntries != nil {
+		order.NoteEntries = make([]models.OrderNote, len(d.NoteEntries))
+		for i, note := range d.NoteEntries {
+			order.NoteEntries[i] = models.OrderNote{Author: note.Author, Text: note.Text, CreatedAt: note.CreatedAt}
+		}
+	}
+	if snapshot := d.CustomerSnapshot; snapshot != nil {
+		order.CustomerSnapshot = &models.CustomerSnapshot{Email: snapshot.Email, Name: snapshot.Name, Phone: snapshot.Phone}
+	}
+	if ret := d.Return; ret != nil {
+		order.Return = &models.OrderReturn{Reason: ret.Reason, RequestedAt: ret.RequestedAt, ReturnedAt: ret.ReturnedAt}
+		if ret.Items != nil {
+			order.Return.Items = make([]models.ReturnItem, len(ret.Items))
+			for i, item := range ret.Items {
+				order.Return.Items[i] = models.ReturnItem{SKU: item.SKU, Quantity: item.Quantity}
+			}
+		}
+	}
+	return order
+}
+
+func toItemDocuments(items []models.OrderItem) []OrderItemDocument {
+	if items == nil {
+		return nil
+	}
+	docs := make([]OrderItemDocument, len(items))
+	for i, item := range items {
+		docs[i] = OrderItemDocument{
+			SKU:               item.SKU,
+			Quantity:          item.Quantity,
+			Price:             item.Price,
+			WeightGrams:       item.WeightGrams,
+			VolumeCm3:         item.VolumeCm3,
+			DeliveredQuantity: item.DeliveredQuantity,
+			PriceSnapshotAt:   item.PriceSnapshotAt,
+		}
+	}
+	return docs
+}
+
+func toNoteDocument(note models.OrderNote) OrderNoteDocument {
+	return OrderNoteDocument{Author: note.Author, Text: note.Text, CreatedAt: note.CreatedAt}
+}
+
+func toReturnDocument(ret *models.OrderReturn) *OrderReturnDocument {
+	if ret == nil {
+		return nil
+	}
+	doc := &OrderReturnDocument{Reason: ret.Reason, RequestedAt: ret.RequestedAt, ReturnedAt: ret.ReturnedAt}
+	if ret.Items != nil {
+		doc.Items = make([]ReturnItemDocument, len(ret.Items))
+		for i, item := range ret.Items {
+			doc.Items[i] = ReturnItemDocument{SKU: item.SKU, Quantity: item.Quantity}
+		}
+	}
+	return doc
+}
+
+// decodeOrders reads every order left in the cursor.
+func decodeOrders(ctx context.Context, cursor *mongo.Cursor) ([]*models.Order, error) {
+	var docs []*OrderDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	if docs == nil {
+		return nil, nil
+	}
+	orders := make([]*models.Order, len(docs))
+	for i, doc := range docs {
+		orders[i] = doc.Order()
+	}
+	return orders, nil
+}
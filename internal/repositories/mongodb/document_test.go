@@ -0,0 +1,114 @@
+package mongodb_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories/mongodb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// El fichero golden recoge el formato de los documentos ya guardados; si
+// cambia, los pedidos existentes necesitan una migración:
+//
+//	go test ./internal/repositories/mongodb -run Golden -update
+var update = flag.Bool("update", false, "rewrite the golden documents")
+
+// storedOrder devuelve un pedido con todos los campos que se guardan, con
+// fechas en UTC y milisegundos como las devuelve MongoDB
+func storedOrder() *models.Order {
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 123000000, time.UTC)
+	deliveredAt := createdAt.Add(26 * time.Hour)
+	promisedAt := createdAt.Add(48 * time.Hour)
+	notifiedAt := createdAt.Add(50 * time.Hour)
+	deletedAt := createdAt.Add(72 * time.Hour)
+	return &models.Order{
+		ID:         "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+		TenantID:   "brand-a",
+		CustomerID: "customer-1",
+		Status:     models.StatusReturnRequested,
+		Priority:   models.PriorityHigh,
+		Channel:    models.ChannelWeb,
+		Items: []models.OrderItem{
+			{SKU: "SKU-001", Quantity: 3, Price: 0.1, WeightGrams: 250, DeliveredQuantity: 3},
+			{SKU: "SKU-ÑÜ", Quantity: 1, Price: 19.99, VolumeCm3: 1200, DeliveredQuantity: 1, PriceSnapshotAt: &createdAt},
+		},
+		Tags:                []string{"vip", "regalo"},
+		TotalAmount:         20.29,
+		Notes:               "Dejar en portería",
+		NoteEntries:         []models.OrderNote{{Author: "ops", Text: "Cliente avisado", CreatedAt: createdAt}},
+		Version:             4,
+		CreatedAt:           createdAt,
+		UpdatedAt:           deliveredAt,
+		DeletedAt:           &deletedAt,
+		ClientMetadata:      map[string]string{"utm_source": "newsletter"},
+		CustomerSnapshot:    &models.CustomerSnapshot{Email: "jane.doe@example.com", Name: "Jane Doe", Phone: "+34600123456"},
+		TotalWeightGrams:    750,
+		TotalVolumeCm3:      1200,
+		PromisedDeliveryAt:  &promisedAt,
+		DeliveredAt:         &deliveredAt,
+		SLABreachNotifiedAt: &notifiedAt,
+		PriorityRank:        models.PriorityHigh.Rank(),
+		Return: &models.OrderReturn{
+			Reason:      "Talla incorrecta",
+			Items:       []models.ReturnItem{{SKU: "SKU-001", Quantity: 1}},
+			RequestedAt: deliveredAt.Add(time.Hour),
+		},
+		SearchKeys: []string{"sku-001", "sku-ñü"},
+	}
+}
+
+func TestOrderDocument_Golden(t *testing.T) {
+	order := storedOrder()
+
+	data, err := bson.MarshalExtJSON(mongodb.ToOrderDocument(order), false, false)
+	require.NoError(t, err)
+
+	path := filepath.Join("testdata", "golden", "order_document.json")
+	if *update {
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+	}
+	golden, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), string(data))
+
+	// Los documentos guardados se leen como el pedido original
+	var doc mongodb.OrderDocument
+	require.NoError(t, bson.UnmarshalExtJSON(golden, false, &doc))
+	assert.Equal(t, order, doc.Order())
+}
+
+func TestOrderDocument_RoundTrip(t *testing.T) {
+	// Un pedido recién creado, sin campos opcionales
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	order := &models.Order{
+		ID:          "order-1",
+		CustomerID:  "customer-1",
+		Status:      models.StatusNew,
+		Priority:    models.PriorityNormal,
+		Items:       []models.OrderItem{{SKU: "SKU-001", Quantity: 1, Price: 9.99}},
+		TotalAmount: 9.99,
+		Version:     1,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+
+	data, err := bson.Marshal(mongodb.ToOrderDocument(order))
+	require.NoError(t, err)
+	var raw bson.M
+	require.NoError(t, bson.Unmarshal(data, &raw))
+	for _, field := range []string{"tenantId", "channel", "tags", "notes", "noteEntries", "deletedAt", "clientMetadata", "customerSnapshot", "promisedDeliveryAt", "deliveredAt", "slaBreachNotifiedAt", "return", "searchKeys"} {
+		assert.NotContains(t, raw, field)
+	}
+
+	var doc mongodb.OrderDocument
+	require.NoError(t, bson.Unmarshal(data, &doc))
+	assert.Equal(t, order, doc.Order())
+}
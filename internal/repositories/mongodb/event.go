@@ -0,0 +1,293 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	eventsCollection = "order_events"
+)
+
+// EventRepository persists the events emitted for each order.
+type EventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewEventRepository(db *mongo.Database) *EventRepository {
+	return &EventRepository{
+		collection: db.Collection(eventsCollection),
+	}
+}
+
+func (r *EventRepository) Save(ctx context.Context, record *models.EventRecord) *repositories.RepositoryError {
+	if _, err := r.collection.InsertOne(ctx, record); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to save event",
+		}
+	}
+	return nil
+}
+
+// UpdateDelivery records the outcome of a publish attempt.
+func (r *EventRepository) UpdateDelivery(ctx context.Context, eventID string, status models.EventDeliveryStatus, lastError string) *repositories.RepositoryError {
+	set := bson.M{"status": status, "lastError": lastError}
+	if status == models.EventStatusSent {
+		set["publishedAt"] = time.Now()
+	}
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	if _, err := r.collection.UpdateByID(ctx, eventID, update); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to update event delivery",
+		}
+	}
+	return nil
+}
+
+// FindByOrderID returns the events of an order in the order they were emitted.
+func (r *EventRepository) FindByOrderID(ctx context.Context, orderID string) ([]*models.EventRecord, *repositories.RepositoryError) {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"orderId": orderID}), opts)
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find events",
+		}
+	}
+	defer cursor.Close(ctx)
+
+	var records []*models.EventRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find events",
+		}
+	}
+	return records, nil
+}
+
+// FindRecentByOrderID returns the last limit events of an order, in the order
+// they were emitted. It is served by the orderId and timestamp index.
+func (r *EventRepository) FindRecentByOrderID(ctx context.Context, orderID string, limit int) ([]*models.EventRecord, *repositories.RepositoryError) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"orderId": orderID}), opts)
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find events",
+		}
+	}
+	defer cursor.Close(ctx)
+
+	var records []*models.EventRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find events",
+		}
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, nil
+}
+
+// FindByID returns a single event of the log.
+func (r *EventRepository) FindByID(ctx context.Context, eventID string) (*models.EventRecord, *repositories.RepositoryError) {
+	var record models.EventRecord
+	if err := r.collection.FindOne(ctx, scoped(ctx, bson.M{"_id": eventID})).Decode(&record); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, &repositories.RepositoryError{
+				StatusCode: http.StatusNotFound,
+				Cause:      "event not found",
+				Message:    "Event not found",
+			}
+		}
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find event",
+		}
+	}
+	return &record, nil
+}
+
+// eventFilter builds the query of the event log filters: orderId, eventType,
+// eventTypes, any of several types, status and from and to, which bound the
+// event timestamp inclusively.
+func eventFilter(ctx context.Context, filters map[string]interface{}) bson.M {
+	return newFilterBuilder(filters).
+		equal("orderId", "orderId").
+		in("eventType", "eventType").
+		in("eventTypes", "eventType").
+		equal("status", "status").
+		timeRange("from", "to", "timestamp").
+		build(ctx)
+}
+
+// FindWithFilters returns a page of the event log, newest first, along with
+// the number of events matching the filters described by eventFilter.
+func (r *EventRepository) FindWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.EventRecord, int64, *repositories.RepositoryError) {
+	filter := eventFilter(ctx, filters)
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to count events",
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64((page - 1) * limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find events",
+		}
+	}
+	defer cursor.Close(ctx)
+
+	records := []*models.EventRecord{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, 0, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find events",
+		}
+	}
+	return records, total, nil
+}
+
+// CountWithFilters returns the number of events matching the filters
+// described by eventFilter.
+func (r *EventRepository) CountWithFilters(ctx context.Context, filters map[string]interface{}) (int64, *repositories.RepositoryError) {
+	total, err := r.collection.CountDocuments(ctx, eventFilter(ctx, filters))
+	if err != nil {
+		return 0, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to count events",
+		}
+	}
+	return total, nil
+}
+
+// StreamWithFilters calls each with the events matching the filters described
+// by eventFilter, in the order they were emitted, reading them from the
+// cursor as they are consumed. It stops at the first error returned by each.
+func (r *EventRepository) StreamWithFilters(ctx context.Context, filters map[string]interface{}, each func(*models.EventRecord) error) *repositories.RepositoryError {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, eventFilter(ctx, filters), opts)
+	if err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find events",
+		}
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var record models.EventRecord
+		if err := cursor.Decode(&record); err != nil {
+			return &repositories.RepositoryError{
+				StatusCode: http.StatusInternalServerError,
+				Cause:      err.Error(),
+				Message:    "Failed to decode event",
+			}
+		}
+		if err := each(&record); err != nil {
+			return &repositories.RepositoryError{
+				StatusCode: http.StatusInternalServerError,
+				Cause:      err.Error(),
+				Message:    "Failed to stream events",
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find events",
+		}
+	}
+	return nil
+}
+
+// AssignTenant moves the events stored before tenants were introduced to the
+// given tenant, so they stay visible to it.
+func (r *EventRepository) AssignTenant(ctx context.Context, tenantID string) error {
+	return assignTenant(ctx, r.collection, tenantID)
+}
+
+// Indexes are the indexes the queries of the repository rely on.
+func (r *EventRepository) Indexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "orderId", Value: 1},
+				{Key: "timestamp", Value: 1},
+			},
+		},
+		{
+			// Browsing the log by delivery status, e.g. the failed events
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "timestamp", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "eventType", Value: 1},
+				{Key: "timestamp", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{{Key: "timestamp", Value: -1}},
+		},
+	}
+}
+
+// CreateIndexes creates the missing indexes of the repository.
+func (r *EventRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, r.Indexes())
+	return err
+}
+
+// EnsureIndexes checks that every index of the repository exists.
+func (r *EventRepository) EnsureIndexes(ctx context.Context) (IndexReport, error) {
+	return missingIndexes(ctx, r.collection, r.Indexes())
+}
@@ -0,0 +1,134 @@
+package mongodb_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories/mongodb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestEventRepository_FindRecentByOrderID(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("returns the last events oldest first", func(mt *mtest.T) {
+		ns := mt.DB.Name() + ".order_events"
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "event-2"}, {Key: "orderId", Value: "order-123"}},
+			bson.D{{Key: "_id", Value: "event-1"}, {Key: "orderId", Value: "order-123"}},
+		))
+		repo := mongodb.NewEventRepository(mt.DB)
+
+		records, err := repo.FindRecentByOrderID(context.Background(), "order-123", 50)
+		require.Nil(mt, err)
+		require.Len(mt, records, 2)
+		assert.Equal(mt, "event-1", records[0].EventID)
+		assert.Equal(mt, "event-2", records[1].EventID)
+
+		cmd := findCommand(mt)
+		require.NotNil(mt, cmd)
+		assert.Equal(mt, "order-123", cmd.Lookup("filter", "orderId").StringValue())
+		assert.Equal(mt, int32(-1), cmd.Lookup("sort", "timestamp").Int32())
+		assert.Equal(mt, int64(50), cmd.Lookup("limit").AsInt64())
+	})
+}
+
+func TestEventRepository_FindWithFilters(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("filters the log newest first", func(mt *mtest.T) {
+		ns := mt.DB.Name() + ".order_events"
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: 21}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "event-1"}, {Key: "status", Value: "FAILED"}},
+			),
+		)
+		repo := mongodb.NewEventRepository(mt.DB)
+		from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+		records, total, err := repo.FindWithFilters(context.Background(), map[string]interface{}{
+			"orderId":   "order-123",
+			"eventType": "ORDER_STATUS_CHANGED",
+			"status":    "FAILED",
+			"from":      from,
+		}, 3, 10)
+		require.Nil(mt, err)
+		assert.Equal(mt, int64(21), total)
+		require.Len(mt, records, 1)
+
+		cmd := startedCommand(mt, "find")
+		require.NotNil(mt, cmd)
+		assert.Equal(mt, "order-123", cmd.Lookup("filter", "orderId").StringValue())
+		assert.Equal(mt, "ORDER_STATUS_CHANGED", cmd.Lookup("filter", "eventType").StringValue())
+		assert.Equal(mt, "FAILED", cmd.Lookup("filter", "status").StringValue())
+		assert.Equal(mt, from, cmd.Lookup("filter", "timestamp", "$gte").Time().UTC())
+		assert.Equal(mt, int32(-1), cmd.Lookup("sort", "timestamp").Int32())
+		assert.Equal(mt, int64(20), cmd.Lookup("skip").AsInt64())
+	})
+
+	mt.Run("streams a time range oldest first", func(mt *mtest.T) {
+		ns := mt.DB.Name() + ".order_events"
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "event-1"}},
+			bson.D{{Key: "_id", Value: "event-2"}},
+		))
+		repo := mongodb.NewEventRepository(mt.DB)
+		from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		to := from.Add(48 * time.Hour)
+
+		var streamed []string
+		err := repo.StreamWithFilters(context.Background(), map[string]interface{}{
+			"eventTypes": []string{"ORDER_CREATED", "ORDER_RETURNED"},
+			"from":       from,
+			"to":         to,
+		}, func(record *models.EventRecord) error {
+			streamed = append(streamed, record.EventID)
+			return nil
+		})
+		require.Nil(mt, err)
+		assert.Equal(mt, []string{"event-1", "event-2"}, streamed)
+
+		cmd := startedCommand(mt, "find")
+		require.NotNil(mt, cmd)
+		assert.Equal(mt, `{"$in": ["ORDER_CREATED","ORDER_RETURNED"]}`, cmd.Lookup("filter", "eventType").String())
+		assert.Equal(mt, from, cmd.Lookup("filter", "timestamp", "$gte").Time().UTC())
+		assert.Equal(mt, to, cmd.Lookup("filter", "timestamp", "$lte").Time().UTC())
+		assert.Equal(mt, int32(1), cmd.Lookup("sort", "timestamp").Int32())
+	})
+
+	mt.Run("unknown event", func(mt *mtest.T) {
+		ns := mt.DB.Name() + ".order_events"
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch))
+		repo := mongodb.NewEventRepository(mt.DB)
+
+		record, err := repo.FindByID(context.Background(), "event-404")
+		assert.Nil(mt, record)
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusNotFound, err.StatusCode)
+	})
+
+	mt.Run("creates the browsing indexes", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		repo := mongodb.NewEventRepository(mt.DB)
+
+		require.NoError(mt, repo.CreateIndexes(context.Background()))
+
+		cmd := startedCommand(mt, "createIndexes")
+		require.NotNil(mt, cmd)
+		indexes, _ := cmd.Lookup("indexes").Array().Values()
+		var keys []bson.Raw
+		for _, index := range indexes {
+			keys = append(keys, index.Document().Lookup("key").Document())
+		}
+		assert.Contains(mt, keys, mustMarshal(mt, bson.D{{Key: "status", Value: 1}, {Key: "timestamp", Value: -1}}))
+		assert.Contains(mt, keys, mustMarshal(mt, bson.D{{Key: "eventType", Value: 1}, {Key: "timestamp", Value: -1}}))
+	})
+}
@@ -0,0 +1,124 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// filterBuilder translates the filters of a listing, as normalized by the
+// services, into a query. Every predicate reads one or two filters, adds
+// nothing when they are missing, empty or of another type, and otherwise
+// sets one field of the query, so the same filter is always matched the same
+// way. A later predicate on the same field replaces the earlier one.
+type filterBuilder struct {
+	filters map[string]interface{}
+	query   bson.M
+}
+
+func newFilterBuilder(filters map[string]interface{}) *filterBuilder {
+	return &filterBuilder{filters: filters, query: bson.M{}}
+}
+
+// equal matches field against the string filter key.
+func (b *filterBuilder) equal(key, field string) *filterBuilder {
+	return b.match(key, field, func(value string) interface{} { return value })
+}
+
+// match matches field against the predicate built from the string filter key.
+func (b *filterBuilder) match(key, field string, predicate func(value string) interface{}) *filterBuilder {
+	if value, ok := b.filters[key].(string); ok && value != "" {
+		b.query[field] = predicate(value)
+	}
+	return b
+}
+
+// in matches field against any of the values of filter key, a list of
+// strings or a single one.
+func (b *filterBuilder) in(key, field string) *filterBuilder {
+	switch values := b.filters[key].(type) {
+	case string:
+		if values != "" {
+			b.query[field] = values
+		}
+	case []string:
+		if len(values) == 1 {
+			b.query[field] = values[0]
+		} else if len(values) > 1 {
+			b.query[field] = bson.M{"$in": values}
+		}
+	}
+	return b
+}
+
+// all matches the documents whose array field holds every value of filter key.
+func (b *filterBuilder) all(key, field string) *filterBuilder {
+	if values, ok := b.filters[key].([]string); ok && len(values) > 0 {
+		b.query[field] = bson.M{"$all": values}
+	}
+	return b
+}
+
+// text runs a full-text search for filter key over the text index.
+func (b *filterBuilder) text(key string) *filterBuilder {
+	if search, ok := b.filters[key].(string); ok && search != "" {
+		b.query["$text"] = bson.M{"$search": search}
+	}
+	return b
+}
+
+// timeRange bounds field by the times of filters from and to, inclusively.
+func (b *filterBuilder) timeRange(fromKey, toKey, field string) *filterBuilder {
+	from, hasFrom := b.filters[fromKey].(time.Time)
+	to, hasTo := b.filters[toKey].(time.Time)
+	return b.between(field, from, hasFrom, to, hasTo)
+}
+
+// intRange bounds field by the integers of filters min and max, inclusively.
+func (b *filterBuilder) intRange(minKey, maxKey, field string) *filterBuilder {
+	lower, hasLower := b.filters[minKey].(int)
+	upper, hasUpper := b.filters[maxKey].(int)
+	return b.between(field, lower, hasLower, upper, hasUpper)
+}
+
+// floatRange bounds field by the numbers of filters min and max, inclusively.
+func (b *filterBuilder) floatRange(minKey, maxKey, field string) *filterBuilder {
+	lower, hasLower := b.filters[minKey].(float64)
+	upper, hasUpper := b.filters[maxKey].(float64)
+	return b.between(field, lower, hasLower, upper, hasUpper)
+}
+
+func (b *filterBuilder) between(field string, lower interface{}, hasLower bool, upper interface{}, hasUpper bool) *filterBuilder {
+	bounds := bson.M{}
+	if hasLower {
+		bounds["$gte"] = lower
+	}
+	if hasUpper {
+		bounds["$lte"] = upper
+	}
+	if len(bounds) > 0 {
+		b.query[field] = bounds
+	}
+	return b
+}
+
+// flag adds the predicates of whenTrue or whenFalse, depending on the boolean
+// filter key.
+func (b *filterBuilder) flag(key string, whenTrue, whenFalse bson.M) *filterBuilder {
+	if set, ok := b.filters[key].(bool); ok {
+		predicates := whenFalse
+		if set {
+			predicates = whenTrue
+		}
+		for field, predicate := range predicates {
+			b.query[field] = predicate
+		}
+	}
+	return b
+}
+
+// build returns the query scoped to the tenant of the context.
+func (b *filterBuilder) build(ctx context.Context) bson.M {
+	return scoped(ctx, b.query)
+}
@@ -0,0 +1,233 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"orders/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFilterBuilder_Predicates(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		filters  map[string]interface{}
+		build    func(b *filterBuilder) *filterBuilder
+		expected bson.M
+	}{
+		{
+			name:     "equal",
+			filters:  map[string]interface{}{"priority": "HIGH"},
+			build:    func(b *filterBuilder) *filterBuilder { return b.equal("priority", "priority") },
+			expected: bson.M{"priority": "HIGH"},
+		},
+		{
+			name:     "equal vacío",
+			filters:  map[string]interface{}{"priority": ""},
+			build:    func(b *filterBuilder) *filterBuilder { return b.equal("priority", "priority") },
+			expected: bson.M{},
+		},
+		{
+			name:     "equal de otro tipo",
+			filters:  map[string]interface{}{"priority": 3},
+			build:    func(b *filterBuilder) *filterBuilder { return b.equal("priority", "priority") },
+			expected: bson.M{},
+		},
+		{
+			name:    "match",
+			filters: map[string]interface{}{"customerId": "customer-1"},
+			build: func(b *filterBuilder) *filterBuilder {
+				return b.match("customerId", "customerId", func(value string) interface{} { return bson.M{"$eq": value} })
+			},
+			expected: bson.M{"customerId": bson.M{"$eq": "customer-1"}},
+		},
+		{
+			name:     "in con un valor",
+			filters:  map[string]interface{}{"status": "NEW"},
+			build:    func(b *filterBuilder) *filterBuilder { return b.in("status", "status") },
+			expected: bson.M{"status": "NEW"},
+		},
+		{
+			name:     "in con una lista de un valor",
+			filters:  map[string]interface{}{"status": []string{"NEW"}},
+			build:    func(b *filterBuilder) *filterBuilder { return b.in("status", "status") },
+			expected: bson.M{"status": "NEW"},
+		},
+		{
+			name:     "in con varios valores",
+			filters:  map[string]interface{}{"status": []string{"NEW", "IN_PROGRESS"}},
+			build:    func(b *filterBuilder) *filterBuilder { return b.in("status", "status") },
+			expected: bson.M{"status": bson.M{"$in": []string{"NEW", "IN_PROGRESS"}}},
+		},
+		{
+			name:     "in con una lista vacía",
+			filters:  map[string]interface{}{"status": []string{}},
+			build:    func(b *filterBuilder) *filterBuilder { return b.in("status", "status") },
+			expected: bson.M{},
+		},
+		{
+			name:     "all",
+			filters:  map[string]interface{}{"tags": []string{"vip", "gift"}},
+			build:    func(b *filterBuilder) *filterBuilder { return b.all("tags", "tags") },
+			expected: bson.M{"tags": bson.M{"$all": []string{"vip", "gift"}}},
+		},
+		{
+			name:     "text",
+			filters:  map[string]interface{}{"q": "SKU-001"},
+			build:    func(b *filterBuilder) *filterBuilder { return b.text("q") },
+			expected: bson.M{"$text": bson.M{"$search": "SKU-001"}},
+		},
+		{
+			name:     "timeRange",
+			filters:  map[string]interface{}{"from": from, "to": to},
+			build:    func(b *filterBuilder) *filterBuilder { return b.timeRange("from", "to", "createdAt") },
+			expected: bson.M{"createdAt": bson.M{"$gte": from, "$lte": to}},
+		},
+		{
+			name:     "timeRange solo desde",
+			filters:  map[string]interface{}{"from": from},
+			build:    func(b *filterBuilder) *filterBuilder { return b.timeRange("from", "to", "createdAt") },
+			expected: bson.M{"createdAt": bson.M{"$gte": from}},
+		},
+		{
+			name:     "intRange solo hasta",
+			filters:  map[string]interface{}{"maxWeight": 5000},
+			build:    func(b *filterBuilder) *filterBuilder { return b.intRange("minWeight", "maxWeight", "totalWeightGrams") },
+			expected: bson.M{"totalWeightGrams": bson.M{"$lte": 5000}},
+		},
+		{
+			name:     "intRange con ceros",
+			filters:  map[string]interface{}{"minWeight": 0, "maxWeight": 0},
+			build:    func(b *filterBuilder) *filterBuilder { return b.intRange("minWeight", "maxWeight", "totalWeightGrams") },
+			expected: bson.M{"totalWeightGrams": bson.M{"$gte": 0, "$lte": 0}},
+		},
+		{
+			name:     "floatRange",
+			filters:  map[string]interface{}{"minAmount": 10.5, "maxAmount": 99.99},
+			build:    func(b *filterBuilder) *filterBuilder { return b.floatRange("minAmount", "maxAmount", "totalAmount") },
+			expected: bson.M{"totalAmount": bson.M{"$gte": 10.5, "$lte": 99.99}},
+		},
+		{
+			name:     "floatRange de otro tipo",
+			filters:  map[string]interface{}{"minAmount": 10},
+			build:    func(b *filterBuilder) *filterBuilder { return b.floatRange("minAmount", "maxAmount", "totalAmount") },
+			expected: bson.M{},
+		},
+		{
+			name:    "flag a true",
+			filters: map[string]interface{}{"slaBreached": true},
+			build: func(b *filterBuilder) *filterBuilder {
+				return b.flag("slaBreached", bson.M{"$or": bson.A{"breached"}}, bson.M{"$nor": bson.A{"breached"}})
+			},
+			expected: bson.M{"$or": bson.A{"breached"}},
+		},
+		{
+			name:    "flag a false",
+			filters: map[string]interface{}{"slaBreached": false},
+			build: func(b *filterBuilder) *filterBuilder {
+				return b.flag("slaBreached", bson.M{"$or": bson.A{"breached"}}, bson.M{"$nor": bson.A{"breached"}})
+			},
+			expected: bson.M{"$nor": bson.A{"breached"}},
+		},
+		{
+			name:    "flag sin filtro",
+			filters: map[string]interface{}{},
+			build: func(b *filterBuilder) *filterBuilder {
+				return b.flag("slaBreached", bson.M{"$or": bson.A{"breached"}}, bson.M{"$nor": bson.A{"breached"}})
+			},
+			expected: bson.M{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.build(newFilterBuilder(tt.filters)).build(context.Background())
+			assert.Equal(t, tt.expected, query)
+		})
+	}
+}
+
+func TestFilterBuilder_Combinations(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	filters := map[string]interface{}{
+		"status":    []string{"NEW", "IN_PROGRESS"},
+		"channel":   "WEB",
+		"q":         "regalo",
+		"from":      from,
+		"minAmount": 20.0,
+		"ignored":   "value",
+	}
+
+	query := newFilterBuilder(filters).
+		in("status", "status").
+		equal("channel", "channel").
+		equal("priority", "priority").
+		text("q").
+		timeRange("from", "to", "createdAt").
+		floatRange("minAmount", "maxAmount", "totalAmount").
+		build(tenant.WithID(context.Background(), "brand-a"))
+
+	assert.Equal(t, bson.M{
+		"status":      bson.M{"$in": []string{"NEW", "IN_PROGRESS"}},
+		"channel":     "WEB",
+		"$text":       bson.M{"$search": "regalo"},
+		"createdAt":   bson.M{"$gte": from},
+		"totalAmount": bson.M{"$gte": 20.0},
+		"tenantId":    "brand-a",
+	}, query)
+
+	// Un predicado posterior sobre el mismo campo sustituye al anterior
+	query = newFilterBuilder(map[string]interface{}{"eventType": "OrderCreated", "eventTypes": []string{"OrderCreated", "OrderCancelled"}}).
+		in("eventType", "eventType").
+		in("eventTypes", "eventType").
+		build(context.Background())
+	assert.Equal(t, bson.M{"eventType": bson.M{"$in": []string{"OrderCreated", "OrderCancelled"}}}, query)
+}
+
+func TestListFilter(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	query := listFilter(tenant.WithID(context.Background(), "brand-a"), map[string]interface{}{
+		"status":      "NEW",
+		"customerId":  "123e4567-e89b-12d3-a456-426614174000",
+		"priority":    "HIGH",
+		"channel":     "MOBILE",
+		"tags":        []string{"vip"},
+		"q":           "SKU-001",
+		"from":        from,
+		"to":          to,
+		"minWeight":   100,
+		"maxWeight":   5000,
+		"minAmount":   10.0,
+		"maxAmount":   500.0,
+		"slaBreached": false,
+		"sortBy":      "createdAt",
+		"skipTotal":   true,
+	})
+
+	assert.Contains(t, query, "$nor")
+	delete(query, "$nor")
+	assert.Equal(t, bson.M{
+		"status":           "NEW",
+		"customerId":       bson.M{"$in": bson.A{"123e4567-e89b-12d3-a456-426614174000", "123E4567-E89B-12D3-A456-426614174000"}},
+		"priority":         "HIGH",
+		"channel":          "MOBILE",
+		"tags":             bson.M{"$all": []string{"vip"}},
+		"$text":            bson.M{"$search": "SKU-001"},
+		"createdAt":        bson.M{"$gte": from, "$lte": to},
+		"totalWeightGrams": bson.M{"$gte": 100, "$lte": 5000},
+		"totalAmount":      bson.M{"$gte": 10.0, "$lte": 500.0},
+		"tenantId":         "brand-a",
+		"deletedAt":        bson.M{"$exists": false},
+	}, query)
+
+	// Los pedidos borrados nunca se listan, aunque no se filtre
+	assert.Equal(t, bson.M{"deletedAt": bson.M{"$exists": false}}, listFilter(context.Background(), map[string]interface{}{}))
+}
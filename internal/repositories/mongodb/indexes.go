@@ -0,0 +1,81 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// IndexReport compares the indexes of a collection against the ones its
+// repository relies on. Without them list queries scan the whole collection.
+type IndexReport struct {
+	Collection string `json:"collection"`
+	Expected   int    `json:"expected"`
+	// Missing lists the missing indexes by name, see IndexName
+	Missing []string `json:"missing,omitempty"`
+	// Error is set when the indexes of the collection could not be listed
+	Error string `json:"error,omitempty"`
+}
+
+// IndexName is the name the index is created with: the one set in its
+// options or, as MongoDB names it by default, its keys and directions joined
+// by underscores, e.g. status_1_createdAt_-1.
+func IndexName(index mongo.IndexModel) string {
+	if index.Options != nil && index.Options.Name != nil {
+		return *index.Options.Name
+	}
+	return keysName(index.Keys.(bson.D))
+}
+
+// IndexKeys formats the keys of the index, e.g. {status: 1, createdAt: -1}.
+func IndexKeys(index mongo.IndexModel) string {
+	keys := index.Keys.(bson.D)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s: %v", key.Key, key.Value)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func keysName(keys bson.D) string {
+	parts := make([]string, 0, 2*len(keys))
+	for _, key := range keys {
+		parts = append(parts, key.Key, fmt.Sprint(key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// missingIndexes lists the indexes of the collection and reports the expected
+// ones it lacks. Unnamed indexes are matched by their keys, so an index
+// created by hand under another name still counts; named ones, such as text
+// indexes whose keys MongoDB rewrites, are matched by name.
+func missingIndexes(ctx context.Context, collection *mongo.Collection, expected []mongo.IndexModel) (IndexReport, error) {
+	report := IndexReport{Collection: collection.Name(), Expected: len(expected)}
+
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return report, err
+	}
+	var existing []struct {
+		Name string `bson:"name"`
+		Key  bson.D `bson:"key"`
+	}
+	if err := cursor.All(ctx, &existing); err != nil {
+		return report, err
+	}
+
+	present := make(map[string]bool, 2*len(existing))
+	for _, index := range existing {
+		present[index.Name] = true
+		present[keysName(index.Key)] = true
+	}
+	for _, index := range expected {
+		if name := IndexName(index); !present[name] {
+			report.Missing = append(report.Missing, name)
+		}
+	}
+	return report, nil
+}
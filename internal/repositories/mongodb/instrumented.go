@@ -0,0 +1,109 @@
+package mongodb
+
+import (
+	"context"
+	"orders/internal/metrics"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/timing"
+	"time"
+)
+
+// instrumentedRepository times every operation of the wrapped repository.
+type instrumentedRepository struct {
+	next Repository
+}
+
+// Instrument wraps the repository so the duration and result of each
+// operation are recorded in mongo_operation_duration_seconds.
+func Instrument(repo Repository) Repository {
+	return &instrumentedRepository{next: repo}
+}
+
+// observe records an operation that started at start and failed when err is
+// not nil, and reports its time to the request timing of ctx.
+func observe(ctx context.Context, operation string, start time.Time, err *repositories.RepositoryError) {
+	timing.Since(ctx, timing.DB, start)
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.MongoOperationDuration.WithLabelValues(operation, result).Observe(time.Since(start).Seconds())
+}
+
+func (r *instrumentedRepository) Create(ctx context.Context, order *models.Order) *repositories.RepositoryError {
+	start := time.Now()
+	err := r.next.Create(ctx, order)
+	observe(ctx, "create", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) FindByID(ctx context.Context, id string) (*models.Order, *repositories.RepositoryError) {
+	start := time.Now()
+	order, err := r.next.FindByID(ctx, id)
+	observe(ctx, "find_by_id", start, err)
+	return order, err
+}
+
+func (r *instrumentedRepository) FindByIDs(ctx context.Context, ids []string) ([]*models.Order, *repositories.RepositoryError) {
+	start := time.Now()
+	orders, err := r.next.FindByIDs(ctx, ids)
+	observe(ctx, "find_by_ids", start, err)
+	return orders, err
+}
+
+func (r *instrumentedRepository) FindWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.Order, int64, *repositories.RepositoryError) {
+	start := time.Now()
+	orders, total, err := r.next.FindWithFilters(ctx, filters, page, limit)
+	observe(ctx, "find_with_filters", start, err)
+	return orders, total, err
+}
+
+func (r *instrumentedRepository) StreamWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int, each func(*models.Order) error) (int64, *repositories.RepositoryError) {
+	start := time.Now()
+	total, err := r.next.StreamWithFilters(ctx, filters, page, limit, each)
+	observe(ctx, "stream_with_filters", start, err)
+	return total, err
+}
+
+func (r *instrumentedRepository) Update(ctx context.Context, order *models.Order) *repositories.RepositoryError {
+	start := time.Now()
+	err := r.next.Update(ctx, order)
+	observe(ctx, "update", start, err)
+	return err
+}
+
+func (r *instrumentedRepository) AppendNote(ctx context.Context, id string, note models.OrderNote, maxNotes int) (*models.Order, *repositories.RepositoryError) {
+	start := time.Now()
+	order, err := r.next.AppendNote(ctx, id, note, maxNotes)
+	observe(ctx, "append_note", start, err)
+	return order, err
+}
+
+func (r *instrumentedRepository) FindSLABreachCandidates(ctx context.Context, now time.Time, limit int) ([]*models.Order, *repositories.RepositoryError) {
+	start := time.Now()
+	orders, err := r.next.FindSLABreachCandidates(ctx, now, limit)
+	observe(ctx, "find_sla_breach_candidates", start, err)
+	return orders, err
+}
+
+func (r *instrumentedRepository) MarkSLABreachNotified(ctx context.Context, id string, at time.Time) (bool, *repositories.RepositoryError) {
+	start := time.Now()
+	claimed, err := r.next.MarkSLABreachNotified(ctx, id, at)
+	observe(ctx, "mark_sla_breach_notified", start, err)
+	return claimed, err
+}
+
+func (r *instrumentedRepository) Stats(ctx context.Context, since time.Time) (*models.OrderStats, *repositories.RepositoryError) {
+	start := time.Now()
+	stats, err := r.next.Stats(ctx, since)
+	observe(ctx, "stats", start, err)
+	return stats, err
+}
+
+func (r *instrumentedRepository) Search(ctx context.Context, term string, limit int) ([]*models.Order, *repositories.RepositoryError) {
+	start := time.Now()
+	orders, err := r.next.Search(ctx, term, limit)
+	observe(ctx, "search", start, err)
+	return orders, err
+}
@@ -0,0 +1,55 @@
+package mongodb_test
+
+import (
+	"context"
+	"testing"
+
+	"orders/internal/models"
+	"orders/internal/repositories/mongodb"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// operationSamples devuelve cuántas muestras registró el histograma para la operación y el resultado
+func operationSamples(t require.TestingT, operation, result string) uint64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "mongo_operation_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["operation"] == operation && labels["result"] == result {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestInstrument_ObservesOperations(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("create", func(mt *mtest.T) {
+		repo := mongodb.Instrument(mongodb.NewOrderRepository(mt.DB))
+		successes := operationSamples(mt, "create", "success")
+		failures := operationSamples(mt, "create", "error")
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		require.Nil(mt, repo.Create(context.Background(), &models.Order{ID: "order-123"}))
+		assert.Equal(mt, successes+1, operationSamples(mt, "create", "success"))
+
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}))
+		assert.NotNil(mt, repo.Create(context.Background(), &models.Order{ID: "order-123"}))
+		assert.Equal(mt, failures+1, operationSamples(mt, "create", "error"))
+		assert.Equal(mt, successes+1, operationSamples(mt, "create", "success"))
+	})
+}
@@ -0,0 +1,120 @@
+package mongodb
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	inventoryReleasesCollection = "inventory_releases"
+)
+
+// InventoryReleaseRepository is the outbox of inventory releases: a release
+// is stored before it is attempted and deleted once the inventory confirmed
+// it, so the releases that failed are left for a retry.
+type InventoryReleaseRepository struct {
+	collection *mongo.Collection
+}
+
+func NewInventoryReleaseRepository(db *mongo.Database) *InventoryReleaseRepository {
+	return &InventoryReleaseRepository{
+		collection: db.Collection(inventoryReleasesCollection),
+	}
+}
+
+// SaveRelease stores the pending release, replacing an earlier one of the
+// same order.
+func (r *InventoryReleaseRepository) SaveRelease(ctx context.Context, release *models.InventoryRelease) *repositories.RepositoryError {
+	opts := options.Replace().SetUpsert(true)
+	if _, err := r.collection.ReplaceOne(ctx, bson.M{"_id": release.OrderID}, release, opts); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to save inventory release",
+		}
+	}
+	return nil
+}
+
+// DeleteRelease removes the release of the order once it succeeded.
+func (r *InventoryReleaseRepository) DeleteRelease(ctx context.Context, orderID string) *repositories.RepositoryError {
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": orderID}); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to delete inventory release",
+		}
+	}
+	return nil
+}
+
+// FindDueReleases returns up to limit releases due at now, the longest
+// overdue first.
+func (r *InventoryReleaseRepository) FindDueReleases(ctx context.Context, now time.Time, limit int) ([]*models.InventoryRelease, *repositories.RepositoryError) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, bson.M{"nextAttemptAt": bson.M{"$lte": now}}, opts)
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find inventory releases",
+		}
+	}
+	defer cursor.Close(ctx)
+
+	releases := []*models.InventoryRelease{}
+	if err := cursor.All(ctx, &releases); err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find inventory releases",
+		}
+	}
+	return releases, nil
+}
+
+// RescheduleRelease records a failed attempt of the release and when to try
+// it again.
+func (r *InventoryReleaseRepository) RescheduleRelease(ctx context.Context, orderID string, next time.Time, lastError string) *repositories.RepositoryError {
+	update := bson.M{
+		"$set": bson.M{"nextAttemptAt": next, "lastError": lastError},
+		"$inc": bson.M{"attempts": 1},
+	}
+	if _, err := r.collection.UpdateByID(ctx, orderID, update); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to reschedule inventory release",
+		}
+	}
+	return nil
+}
+
+// Indexes lists the indexes the retries of the releases rely on.
+func (r *InventoryReleaseRepository) Indexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{Keys: bson.D{{Key: "nextAttemptAt", Value: 1}}},
+	}
+}
+
+// CreateIndexes creates the missing indexes of the repository.
+func (r *InventoryReleaseRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, r.Indexes())
+	return err
+}
+
+// EnsureIndexes checks that every index of the repository exists.
+func (r *InventoryReleaseRepository) EnsureIndexes(ctx context.Context) (IndexReport, error) {
+	return missingIndexes(ctx, r.collection, r.Indexes())
+}
@@ -0,0 +1,63 @@
+package mongodb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories/mongodb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestInventoryReleaseRepository(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mt.Run("save upserts the release of the order", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		repo := mongodb.NewInventoryReleaseRepository(mt.DB)
+
+		err := repo.SaveRelease(context.Background(), &models.InventoryRelease{OrderID: "order-123", NextAttemptAt: now, CreatedAt: now})
+		require.Nil(mt, err)
+
+		update := startedCommand(mt, "update").Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(mt, "order-123", update.Lookup("q", "_id").StringValue())
+		assert.True(mt, update.Lookup("upsert").Boolean())
+	})
+
+	mt.Run("due releases are read oldest first", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "order-123"}, {Key: "attempts", Value: 2}, {Key: "nextAttemptAt", Value: now}}))
+		repo := mongodb.NewInventoryReleaseRepository(mt.DB)
+
+		releases, err := repo.FindDueReleases(context.Background(), now, 50)
+		require.Nil(mt, err)
+		if assert.Len(mt, releases, 1) {
+			assert.Equal(mt, "order-123", releases[0].OrderID)
+			assert.Equal(mt, 2, releases[0].Attempts)
+		}
+
+		find := findCommand(mt)
+		assert.Equal(mt, now, find.Lookup("filter", "nextAttemptAt", "$lte").Time().UTC())
+		assert.Equal(mt, int32(1), find.Lookup("sort", "nextAttemptAt").Int32())
+		assert.Equal(mt, int64(50), find.Lookup("limit").Int64())
+	})
+
+	mt.Run("reschedule counts the failed attempt", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		repo := mongodb.NewInventoryReleaseRepository(mt.DB)
+
+		err := repo.RescheduleRelease(context.Background(), "order-123", now.Add(time.Minute), "inventory service returned status 503")
+		require.Nil(mt, err)
+
+		update := startedCommand(mt, "update").Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(mt, int32(1), update.Lookup("u", "$inc", "attempts").Int32())
+		assert.Equal(mt, "inventory service returned status 503", update.Lookup("u", "$set", "lastError").StringValue())
+	})
+}
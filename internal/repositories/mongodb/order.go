@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"orders/internal/models"
 	"orders/internal/repositories"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -19,13 +21,22 @@ const (
 type OrderRepository struct {
 	db         *mongo.Database
 	collection *mongo.Collection
+	// listMaxTime bounds how long MongoDB may spend on a listing, 0 if unbounded
+	listMaxTime time.Duration
 }
 
 type Repository interface {
 	Create(ctx context.Context, order *models.Order) *repositories.RepositoryError
 	FindByID(ctx context.Context, id string) (*models.Order, *repositories.RepositoryError)
+	FindByIDs(ctx context.Context, ids []string) ([]*models.Order, *repositories.RepositoryError)
 	FindWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.Order, int64, *repositories.RepositoryError)
+	StreamWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int, each func(*models.Order) error) (int64, *repositories.RepositoryError)
 	Update(ctx context.Context, order *models.Order) *repositories.RepositoryError
+	AppendNote(ctx context.Context, id string, note models.OrderNote, maxNotes int) (*models.Order, *repositories.RepositoryError)
+	FindSLABreachCandidates(ctx context.Context, now time.Time, limit int) ([]*models.Order, *repositories.RepositoryError)
+	MarkSLABreachNotified(ctx context.Context, id string, at time.Time) (bool, *repositories.RepositoryError)
+	Stats(ctx context.Context, since time.Time) (*models.OrderStats, *repositories.RepositoryError)
+	Search(ctx context.Context, term string, limit int) ([]*models.Order, *repositories.RepositoryError)
 }
 
 func NewOrderRepository(db *mongo.Database) *OrderRepository {
@@ -35,8 +46,15 @@ func NewOrderRepository(db *mongo.Database) *OrderRepository {
 	}
 }
 
+// SetListMaxTime makes MongoDB abort the count and find of a listing running
+// longer than d. 0 leaves them unbounded.
+func (r *OrderRepository) SetListMaxTime(d time.Duration) {
+	r.listMaxTime = d
+}
+
 func (r *OrderRepository) Create(ctx context.Context, order *models.Order) *repositories.RepositoryError {
-	_, err := r.collection.InsertOne(ctx, order)
+	order.RefreshSearchKeys()
+	_, err := r.collection.InsertOne(ctx, ToOrderDocument(order))
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
 			return &repositories.RepositoryError{
@@ -54,16 +72,15 @@ func (r *OrderRepository) Create(ctx context.Context, order *models.Order) *repo
 	return nil
 }
 
+// FindByID returns the order with the given ID. Soft-deleted orders are not
+// found, unless the context was marked with repositories.WithDeleted, in
+// which case they are reported with 410 Gone.
 func (r *OrderRepository) FindByID(ctx context.Context, id string) (*models.Order, *repositories.RepositoryError) {
-	var order models.Order
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&order)
+	var doc OrderDocument
+	err := r.collection.FindOne(ctx, scoped(ctx, bson.M{"_id": id})).Decode(&doc)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, &repositories.RepositoryError{
-				StatusCode: http.StatusNotFound,
-				Cause:      "order not found",
-				Message:    "Order not found",
-			}
+			return nil, orderNotFound()
 		}
 		return nil, &repositories.RepositoryError{
 			StatusCode: http.StatusInternalServerError,
@@ -71,36 +88,68 @@ func (r *OrderRepository) FindByID(ctx context.Context, id string) (*models.Orde
 			Message:    "Failed to find order",
 		}
 	}
-	return &order, nil
+	order := doc.Order()
+	if order.IsDeleted() {
+		if repositories.IncludeDeleted(ctx) {
+			return nil, orderGone()
+		}
+		return nil, orderNotFound()
+	}
+	return order, nil
 }
 
-func (r *OrderRepository) FindWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.Order, int64, *repositories.RepositoryError) {
-	// Construir filtro
-	filter := bson.M{}
-	if status, ok := filters["status"].(string); ok && status != "" {
-		filter["status"] = status
-	}
-	if customerID, ok := filters["customerId"].(string); ok && customerID != "" {
-		filter["customerId"] = customerID
+// FindByIDs finds the orders with the given IDs in no particular order,
+// leaving out the ones that do not exist or are deleted.
+func (r *OrderRepository) FindByIDs(ctx context.Context, ids []string) ([]*models.Order, *repositories.RepositoryError) {
+	filter := scoped(ctx, bson.M{
+		"_id":       bson.M{"$in": ids},
+		"deletedAt": bson.M{"$exists": false},
+	})
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find orders",
+		}
 	}
+	defer cursor.Close(ctx)
 
-	total, err := r.collection.CountDocuments(ctx, filter)
+	orders, err := decodeOrders(ctx, cursor)
 	if err != nil {
-		return nil, 0, &repositories.RepositoryError{
+		return nil, &repositories.RepositoryError{
 			StatusCode: http.StatusInternalServerError,
 			Cause:      err.Error(),
-			Message:    "Failed to count orders",
+			Message:    "Failed to find orders",
 		}
 	}
+	return orders, nil
+}
 
-	skip := (page - 1) * limit
+func orderNotFound() *repositories.RepositoryError {
+	return &repositories.RepositoryError{
+		StatusCode: http.StatusNotFound,
+		Cause:      "order not found",
+		Message:    "Order not found",
+	}
+}
 
-	opts := options.Find().
-		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
-		SetLimit(int64(limit)).
-		SetSkip(int64(skip))
+func orderGone() *repositories.RepositoryError {
+	return &repositories.RepositoryError{
+		StatusCode: http.StatusGone,
+		Cause:      "order deleted",
+		Message:    "Order has been deleted",
+	}
+}
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+func (r *OrderRepository) FindWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.Order, int64, *repositories.RepositoryError) {
+	cursor, total, repoErr := r.findPage(ctx, filters, page, limit)
+	if repoErr != nil {
+		return nil, 0, repoErr
+	}
+	defer cursor.Close(ctx)
+
+	orders, err := decodeOrders(ctx, cursor)
 	if err != nil {
 		return nil, 0, &repositories.RepositoryError{
 			StatusCode: http.StatusInternalServerError,
@@ -108,10 +157,102 @@ func (r *OrderRepository) FindWithFilters(ctx context.Context, filters map[strin
 			Message:    "Failed to find orders",
 		}
 	}
+
+	return orders, total, nil
+}
+
+// StreamWithFilters finds the same page as FindWithFilters but hands each
+// order to each as soon as it is decoded, so only one order of the page is
+// held in memory at a time. The total is known before the first call. An
+// error from each stops the iteration and is returned as the cause.
+func (r *OrderRepository) StreamWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int, each func(*models.Order) error) (int64, *repositories.RepositoryError) {
+	cursor, total, repoErr := r.findPage(ctx, filters, page, limit)
+	if repoErr != nil {
+		return 0, repoErr
+	}
 	defer cursor.Close(ctx)
 
-	var orders []*models.Order
-	if err = cursor.All(ctx, &orders); err != nil {
+	for cursor.Next(ctx) {
+		var doc OrderDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return 0, &repositories.RepositoryError{
+				StatusCode: http.StatusInternalServerError,
+				Cause:      err.Error(),
+				Message:    "Failed to decode order",
+			}
+		}
+		if err := each(doc.Order()); err != nil {
+			return 0, &repositories.RepositoryError{
+				StatusCode: http.StatusInternalServerError,
+				Cause:      err.Error(),
+				Message:    "Failed to stream orders",
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find orders",
+		}
+	}
+
+	return total, nil
+}
+
+// customerIDFilter matches the orders of the customer. UUID customer IDs are
+// stored lowercase, but orders created before they were normalized may still
+// hold them uppercase until migrated (see the README), so both are matched.
+func customerIDFilter(customerID string) interface{} {
+	if canonical, ok := models.CanonicalUUID(customerID); !ok || canonical != customerID {
+		return customerID
+	}
+	return bson.M{"$in": bson.A{customerID, strings.ToUpper(customerID)}}
+}
+
+// findPage counts the orders matching the filters, unless skipTotal is set,
+// and opens a cursor over the requested page.
+func (r *OrderRepository) findPage(ctx context.Context, filters map[string]interface{}, page, limit int) (*mongo.Cursor, int64, *repositories.RepositoryError) {
+	return findListPage(ctx, r.collection, r.listMaxTime, filters, page, limit)
+}
+
+// findListPage runs a listing over collection, which holds orders or their
+// summary rows, bounding the count and the find by maxTime unless it is 0.
+func findListPage(ctx context.Context, collection *mongo.Collection, maxTime time.Duration, filters map[string]interface{}, page, limit int) (*mongo.Cursor, int64, *repositories.RepositoryError) {
+	filter := listFilter(ctx, filters)
+
+	// Counting scans every match, so callers that do not need the total can skip it
+	total := repositories.UnknownTotal
+	if skip, _ := filters["skipTotal"].(bool); !skip {
+		countOpts := options.Count()
+		if maxTime > 0 {
+			countOpts.SetMaxTime(maxTime)
+		}
+		count, err := collection.CountDocuments(ctx, filter, countOpts)
+		if err != nil {
+			return nil, 0, &repositories.RepositoryError{
+				StatusCode: http.StatusInternalServerError,
+				Cause:      err.Error(),
+				Message:    "Failed to count orders",
+			}
+		}
+		total = count
+	}
+
+	skip := (page - 1) * limit
+
+	sort := sortOrder(filters)
+
+	opts := options.Find().
+		SetSort(sort).
+		SetLimit(int64(limit)).
+		SetSkip(int64(skip))
+	if maxTime > 0 {
+		opts.SetMaxTime(maxTime)
+	}
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
 		return nil, 0, &repositories.RepositoryError{
 			StatusCode: http.StatusInternalServerError,
 			Cause:      err.Error(),
@@ -119,25 +260,79 @@ func (r *OrderRepository) FindWithFilters(ctx context.Context, filters map[strin
 		}
 	}
 
-	return orders, total, nil
+	return cursor, total, nil
+}
+
+// listFilter builds the query of a listing from its filters, scoped to the
+// tenant of the context. Soft-deleted orders are left out, as in FindByID.
+func listFilter(ctx context.Context, filters map[string]interface{}) bson.M {
+	breached := slaBreachedClauses(time.Now())
+	query := newFilterBuilder(filters).
+		in("status", "status").
+		match("customerId", "customerId", customerIDFilter).
+		equal("priority", "priority").
+		equal("channel", "channel").
+		all("tags", "tags").
+		text("q").
+		timeRange("from", "to", "createdAt").
+		intRange("minWeight", "maxWeight", "totalWeightGrams").
+		floatRange("minAmount", "maxAmount", "totalAmount").
+		flag("slaBreached", bson.M{"$or": breached}, bson.M{"$nor": breached}).
+		build(ctx)
+	query["deletedAt"] = bson.M{"$exists": false}
+	return query
 }
 
 func (r *OrderRepository) Update(ctx context.Context, order *models.Order) *repositories.RepositoryError {
-	filter := bson.M{
+	filter := scoped(ctx, bson.M{
 		"_id":     order.ID,
 		"version": order.Version - 1, // Verificar versión anterior
-	}
+	})
 
-	update := bson.M{
-		"$set": bson.M{
-			"status":     order.Status,
-			"updated_at": order.UpdatedAt,
-			"version":    order.Version,
-		},
+	set := bson.M{
+		"status":    order.Status,
+		"updatedAt": order.UpdatedAt,
+		"version":   order.Version,
+	}
+	// The delivery and the return are removed along with the status they
+	// belong to, e.g. when an admin forces a delivered order back
+	unset := bson.M{}
+	if order.DeliveredAt != nil {
+		set["deliveredAt"] = order.DeliveredAt
+	} else {
+		unset["deliveredAt"] = ""
+	}
+	if order.Tags != nil {
+		set["tags"] = order.Tags
+	}
+	if order.Return != nil {
+		set["return"] = toReturnDocument(order.Return)
+	} else {
+		unset["return"] = ""
+	}
+	if len(order.Items) > 0 {
+		order.RefreshSearchKeys()
+		set["items"] = toItemDocuments(order.Items)
+		set["searchKeys"] = order.SearchKeys
+	}
+	if order.Priority != "" {
+		set["priority"] = order.Priority
+		set["priorityRank"] = order.Priority.Rank()
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
 	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return &repositories.RepositoryError{
+				StatusCode: http.StatusConflict,
+				Cause:      repositories.CauseDuplicateKey,
+				Message:    "Order update conflicts with a unique index",
+			}
+		}
 		return &repositories.RepositoryError{
 			StatusCode: http.StatusInternalServerError,
 			Cause:      err.Error(),
@@ -164,8 +359,239 @@ func (r *OrderRepository) Update(ctx context.Context, order *models.Order) *repo
 	return nil
 }
 
-func (r *OrderRepository) CreateIndexes(ctx context.Context) error {
-	indexes := []mongo.IndexModel{
+// AppendNote atomically pushes a note onto the order and bumps its version, so
+// concurrent status updates based on the previous version fail with a conflict.
+// Only the newest maxNotes entries are kept; zero keeps them all. Soft-deleted
+// orders are rejected with 410 Gone.
+func (r *OrderRepository) AppendNote(ctx context.Context, id string, note models.OrderNote, maxNotes int) (*models.Order, *repositories.RepositoryError) {
+	push := bson.M{"$each": []OrderNoteDocument{toNoteDocument(note)}}
+	if maxNotes > 0 {
+		push["$slice"] = -maxNotes
+	}
+	update := bson.M{
+		"$push": bson.M{"noteEntries": push},
+		"$set":  bson.M{"updatedAt": note.CreatedAt},
+		"$inc":  bson.M{"version": 1},
+	}
+	filter := scoped(ctx, bson.M{
+		"_id":       id,
+		"deletedAt": bson.M{"$exists": false},
+	})
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var doc OrderDocument
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			if _, findErr := r.FindByID(repositories.WithDeleted(ctx), id); findErr != nil && findErr.StatusCode == http.StatusGone {
+				return nil, findErr
+			}
+			return nil, orderNotFound()
+		}
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to append note",
+		}
+	}
+	return doc.Order(), nil
+}
+
+// sortOrder builds the sort of a listing from the sortBy and sortDir filters,
+// newest first by default. Ties are broken by creation time in the same
+// direction. Text searches are sorted by relevance, best match first, unless
+// another sort is requested.
+func sortOrder(filters map[string]interface{}) bson.D {
+	q, _ := filters["q"].(string)
+	sortBy, _ := filters["sortBy"].(string)
+	if q != "" && (sortBy == "" || sortBy == "relevance") {
+		return bson.D{
+			{Key: "score", Value: bson.M{"$meta": "textScore"}},
+			{Key: "createdAt", Value: -1},
+		}
+	}
+
+	direction := -1
+	if sortDir, _ := filters["sortDir"].(string); sortDir == "asc" {
+		direction = 1
+	}
+
+	field := "createdAt"
+	switch sortBy {
+	case "updatedAt", "totalAmount", "totalWeightGrams":
+		field = sortBy
+	case "priority":
+		field = "priorityRank"
+	}
+
+	sort := bson.D{{Key: field, Value: direction}}
+	if field != "createdAt" {
+		sort = append(sort, bson.E{Key: "createdAt", Value: direction})
+	}
+	return sort
+}
+
+// slaBreachedClauses matches orders that missed their promised delivery time:
+// open orders past the promise, or orders delivered after it (including the
+// ones returned since).
+func slaBreachedClauses(now time.Time) bson.A {
+	return bson.A{
+		bson.M{
+			"status":             bson.M{"$in": bson.A{models.StatusNew, models.StatusInProgress, models.StatusPartiallyDelivered}},
+			"promisedDeliveryAt": bson.M{"$lt": now},
+		},
+		bson.M{
+			"deliveredAt":        bson.M{"$ne": nil},
+			"promisedDeliveryAt": bson.M{"$ne": nil},
+			"$expr":              bson.M{"$gt": bson.A{"$deliveredAt", "$promisedDeliveryAt"}},
+		},
+	}
+}
+
+// FindSLABreachCandidates returns in-progress or partially delivered orders
+// past their promised delivery time that have not been reported as breached yet.
+func (r *OrderRepository) FindSLABreachCandidates(ctx context.Context, now time.Time, limit int) ([]*models.Order, *repositories.RepositoryError) {
+	filter := scoped(ctx, bson.M{
+		"status":              bson.M{"$in": bson.A{models.StatusInProgress, models.StatusPartiallyDelivered}},
+		"promisedDeliveryAt":  bson.M{"$lt": now},
+		"slaBreachNotifiedAt": bson.M{"$exists": false},
+		"deletedAt":           bson.M{"$exists": false},
+	})
+	opts := options.Find().
+		SetSort(bson.D{{Key: "promisedDeliveryAt", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find SLA breaches",
+		}
+	}
+	defer cursor.Close(ctx)
+
+	orders, err := decodeOrders(ctx, cursor)
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find SLA breaches",
+		}
+	}
+	return orders, nil
+}
+
+// MarkSLABreachNotified records that the breach of an order was reported. It
+// returns false when another instance already claimed it, so every breach is
+// reported once.
+func (r *OrderRepository) MarkSLABreachNotified(ctx context.Context, id string, at time.Time) (bool, *repositories.RepositoryError) {
+	filter := scoped(ctx, bson.M{
+		"_id":                 id,
+		"slaBreachNotifiedAt": bson.M{"$exists": false},
+	})
+	update := bson.M{"$set": bson.M{"slaBreachNotifiedAt": at}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to mark SLA breach",
+		}
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Stats aggregates the KPIs of the orders in a single pass: the orders of
+// each status, their average total and the orders created at or after since.
+func (r *OrderRepository) Stats(ctx context.Context, since time.Time) (*models.OrderStats, *repositories.RepositoryError) {
+	return aggregateStats(ctx, r.collection, since)
+}
+
+// aggregateStats aggregates the stats over collection, which holds orders or
+// their summary rows.
+func aggregateStats(ctx context.Context, collection *mongo.Collection, since time.Time) (*models.OrderStats, *repositories.RepositoryError) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: scoped(ctx, bson.M{"deletedAt": bson.M{"$exists": false}})}},
+		{{Key: "$facet", Value: bson.M{
+			"byStatus": bson.A{
+				bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+			},
+			"totals": bson.A{
+				bson.M{"$group": bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "averageTotal": bson.M{"$avg": "$totalAmount"}}},
+			},
+			"recent": bson.A{
+				bson.M{"$match": bson.M{"createdAt": bson.M{"$gte": since}}},
+				bson.M{"$count": "count"},
+			},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to aggregate order stats",
+		}
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		ByStatus []struct {
+			Status models.OrderStatus `bson:"_id"`
+			Count  int64              `bson:"count"`
+		} `bson:"byStatus"`
+		Totals []struct {
+			Count        int64   `bson:"count"`
+			AverageTotal float64 `bson:"averageTotal"`
+		} `bson:"totals"`
+		Recent []struct {
+			Count int64 `bson:"count"`
+		} `bson:"recent"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to aggregate order stats",
+		}
+	}
+
+	stats := &models.OrderStats{OrdersByStatus: make(map[models.OrderStatus]int64)}
+	if len(result) == 0 {
+		return stats, nil
+	}
+	for _, status := range result[0].ByStatus {
+		stats.OrdersByStatus[status.Status] = status.Count
+	}
+	// Facets over no orders come back empty
+	if len(result[0].Totals) > 0 {
+		stats.TotalOrders = result[0].Totals[0].Count
+		stats.AverageOrderValue = result[0].Totals[0].AverageTotal
+	}
+	if len(result[0].Recent) > 0 {
+		stats.OrdersLast24h = result[0].Recent[0].Count
+	}
+	return stats, nil
+}
+
+// AssignTenant moves the orders stored before tenants were introduced to the
+// given tenant, so they stay visible to it.
+func (r *OrderRepository) AssignTenant(ctx context.Context, tenantID string) error {
+	return assignTenant(ctx, r.collection, tenantID)
+}
+
+// Indexes are the indexes the queries of the repository rely on.
+func (r *OrderRepository) Indexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "tenantId", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
 		{
 			Keys: bson.D{
 				{Key: "status", Value: 1},
@@ -179,8 +605,68 @@ func (r *OrderRepository) CreateIndexes(ctx context.Context) error {
 				{Key: "createdAt", Value: -1},
 			},
 		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "promisedDeliveryAt", Value: 1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "priority", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "priorityRank", Value: -1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{{Key: "tags", Value: 1}},
+		},
+		{
+			Keys: bson.D{
+				{Key: "totalWeightGrams", Value: -1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "totalAmount", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "channel", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+		{
+			// Multikey index over the search keys, used by Search
+			Keys: bson.D{{Key: "searchKeys", Value: 1}},
+		},
+		{
+			// A collection can only have one text index, used by the q filter
+			Keys: bson.D{
+				{Key: "items.sku", Value: "text"},
+				{Key: "notes", Value: "text"},
+				{Key: "noteEntries.text", Value: "text"},
+			},
+			Options: options.Index().SetName("orders_text"),
+		},
 	}
+}
 
-	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+// CreateIndexes creates the missing indexes of the repository.
+func (r *OrderRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, r.Indexes())
 	return err
 }
+
+// EnsureIndexes checks that every index of the repository exists.
+func (r *OrderRepository) EnsureIndexes(ctx context.Context) (IndexReport, error) {
+	return missingIndexes(ctx, r.collection, r.Indexes())
+}
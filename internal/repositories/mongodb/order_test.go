@@ -0,0 +1,730 @@
+package mongodb_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/repositories/mongodb"
+	"orders/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// findCommand devuelve el comando find enviado al servidor simulado
+func findCommand(mt *mtest.T) bson.Raw {
+	for {
+		event := mt.GetStartedEvent()
+		if event == nil {
+			return nil
+		}
+		if event.CommandName == "find" {
+			return event.Command
+		}
+	}
+}
+
+func TestOrderRepository_FindWithFilters_TextSearch(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mockResponses := func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "order-123"},
+				{Key: "status", Value: "NEW"},
+				{Key: "score", Value: 1.5},
+			}),
+		)
+	}
+
+	mt.Run("sorts by text score", func(mt *mtest.T) {
+		mockResponses(mt)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		orders, total, err := repo.FindWithFilters(context.Background(), map[string]interface{}{"q": "laptop"}, 1, 10)
+		require.Nil(mt, err)
+		assert.Equal(mt, int64(1), total)
+		assert.Len(mt, orders, 1)
+
+		cmd := findCommand(mt)
+		require.NotNil(mt, cmd)
+		assert.Equal(mt, "laptop", cmd.Lookup("filter", "$text", "$search").StringValue())
+		assert.Equal(mt, "textScore", cmd.Lookup("sort", "score", "$meta").StringValue())
+
+		sortKeys, _ := cmd.Lookup("sort").Document().Elements()
+		require.Len(mt, sortKeys, 2)
+		assert.Equal(mt, "score", sortKeys[0].Key())
+		assert.Equal(mt, "createdAt", sortKeys[1].Key())
+	})
+
+	mt.Run("explicit sort overrides relevance", func(mt *mtest.T) {
+		mockResponses(mt)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		_, _, err := repo.FindWithFilters(context.Background(), map[string]interface{}{
+			"q":       "laptop",
+			"sortBy":  "totalAmount",
+			"sortDir": "asc",
+		}, 1, 10)
+		require.Nil(mt, err)
+
+		cmd := findCommand(mt)
+		require.NotNil(mt, cmd)
+		assert.Equal(mt, "laptop", cmd.Lookup("filter", "$text", "$search").StringValue())
+		assert.Equal(mt, int32(1), cmd.Lookup("sort", "totalAmount").Int32())
+		_, lookupErr := cmd.LookupErr("sort", "score")
+		assert.Error(mt, lookupErr)
+	})
+}
+
+func TestOrderRepository_FindWithFilters_Weight(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("filters and sorts by total weight", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: 0}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		_, _, err := repo.FindWithFilters(context.Background(), map[string]interface{}{
+			"minWeight": 1000,
+			"maxWeight": 5000,
+			"sortBy":    "totalWeightGrams",
+		}, 1, 10)
+		require.Nil(mt, err)
+
+		cmd := findCommand(mt)
+		require.NotNil(mt, cmd)
+		assert.Equal(mt, int64(1000), cmd.Lookup("filter", "totalWeightGrams", "$gte").AsInt64())
+		assert.Equal(mt, int64(5000), cmd.Lookup("filter", "totalWeightGrams", "$lte").AsInt64())
+		assert.Equal(mt, int32(-1), cmd.Lookup("sort", "totalWeightGrams").Int32())
+	})
+}
+
+func TestOrderRepository_FindWithFilters_MaxTime(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	commands := func(mt *mtest.T, maxTime time.Duration) map[string]bson.Raw {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: 0}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+		repo := mongodb.NewOrderRepository(mt.DB)
+		repo.SetListMaxTime(maxTime)
+
+		_, _, err := repo.FindWithFilters(context.Background(), map[string]interface{}{}, 1, 10)
+		require.Nil(mt, err)
+
+		sent := map[string]bson.Raw{}
+		for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+			sent[event.CommandName] = event.Command
+		}
+		return sent
+	}
+
+	mt.Run("count and find are bounded", func(mt *mtest.T) {
+		sent := commands(mt, 5*time.Second)
+
+		require.Contains(mt, sent, "find")
+		require.Contains(mt, sent, "aggregate")
+		assert.Equal(mt, int64(5000), sent["find"].Lookup("maxTimeMS").AsInt64())
+		assert.Equal(mt, int64(5000), sent["aggregate"].Lookup("maxTimeMS").AsInt64())
+	})
+
+	mt.Run("unbounded when disabled", func(mt *mtest.T) {
+		sent := commands(mt, 0)
+
+		_, err := sent["find"].LookupErr("maxTimeMS")
+		assert.Error(mt, err)
+	})
+}
+
+func TestOrderRepository_FindWithFilters_CustomerID(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	find := func(mt *mtest.T, customerID string) bson.RawValue {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: 0}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		_, _, err := repo.FindWithFilters(context.Background(), map[string]interface{}{"customerId": customerID}, 1, 10)
+		require.Nil(mt, err)
+
+		cmd := findCommand(mt)
+		require.NotNil(mt, cmd)
+		return cmd.Lookup("filter", "customerId")
+	}
+
+	mt.Run("matches legacy uppercase UUIDs", func(mt *mtest.T) {
+		filter := find(mt, "123e4567-e89b-12d3-a456-426614174000")
+		values, err := filter.Document().Lookup("$in").Array().Values()
+		require.NoError(mt, err)
+		require.Len(mt, values, 2)
+		assert.Equal(mt, "123e4567-e89b-12d3-a456-426614174000", values[0].StringValue())
+		assert.Equal(mt, "123E4567-E89B-12D3-A456-426614174000", values[1].StringValue())
+	})
+
+	mt.Run("matches other customer IDs exactly", func(mt *mtest.T) {
+		assert.Equal(mt, "cus_8F2k", find(mt, "cus_8F2k").StringValue())
+	})
+}
+
+func TestOrderRepository_FindWithFilters_Amount(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("filters and sorts by total amount", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: 0}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		_, _, err := repo.FindWithFilters(context.Background(), map[string]interface{}{
+			"minAmount": 50.0,
+			"maxAmount": 199.99,
+			"sortBy":    "totalAmount",
+			"sortDir":   "asc",
+		}, 1, 10)
+		require.Nil(mt, err)
+
+		cmd := findCommand(mt)
+		require.NotNil(mt, cmd)
+		assert.Equal(mt, 50.0, cmd.Lookup("filter", "totalAmount", "$gte").Double())
+		assert.Equal(mt, 199.99, cmd.Lookup("filter", "totalAmount", "$lte").Double())
+		assert.Equal(mt, int32(1), cmd.Lookup("sort", "totalAmount").Int32())
+	})
+
+	mt.Run("creates the amount index", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		require.NoError(mt, repo.CreateIndexes(context.Background()))
+
+		cmd := startedCommand(mt, "createIndexes")
+		require.NotNil(mt, cmd)
+		indexes, _ := cmd.Lookup("indexes").Array().Values()
+		var keys []bson.Raw
+		for _, index := range indexes {
+			keys = append(keys, index.Document().Lookup("key").Document())
+		}
+		// Ordenado por importe y, a igual importe, de más nuevo a más viejo
+		assert.Contains(mt, keys, mustMarshal(mt, bson.D{{Key: "totalAmount", Value: 1}, {Key: "createdAt", Value: -1}}))
+	})
+}
+
+func TestOrderRepository_StreamWithFilters(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	// El primer lote trae dos pedidos y el getMore el tercero
+	mockResponses := func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: 3}}),
+			mtest.CreateCursorResponse(42, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "order-1"}, {Key: "status", Value: "NEW"}},
+				bson.D{{Key: "_id", Value: "order-2"}, {Key: "status", Value: "NEW"}},
+			),
+			mtest.CreateCursorResponse(0, ns, mtest.NextBatch,
+				bson.D{{Key: "_id", Value: "order-3"}, {Key: "status", Value: "NEW"}},
+			),
+		)
+	}
+
+	mt.Run("hands over each order in order", func(mt *mtest.T) {
+		mockResponses(mt)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		var ids []string
+		total, err := repo.StreamWithFilters(context.Background(), map[string]interface{}{"status": "NEW"}, 1, 100, func(order *models.Order) error {
+			ids = append(ids, order.ID)
+			return nil
+		})
+		require.Nil(mt, err)
+		assert.Equal(mt, int64(3), total)
+		assert.Equal(mt, []string{"order-1", "order-2", "order-3"}, ids)
+
+		cmd := findCommand(mt)
+		require.NotNil(mt, cmd)
+		assert.Equal(mt, "NEW", cmd.Lookup("filter", "status").StringValue())
+		assert.Equal(mt, int64(100), cmd.Lookup("limit").AsInt64())
+	})
+
+	mt.Run("stops when the order cannot be written", func(mt *mtest.T) {
+		mockResponses(mt)
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		calls := 0
+		_, err := repo.StreamWithFilters(context.Background(), map[string]interface{}{}, 1, 100, func(order *models.Order) error {
+			calls++
+			return assert.AnError
+		})
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusInternalServerError, err.StatusCode)
+		assert.Equal(mt, "Failed to stream orders", err.Message)
+		assert.Equal(mt, 1, calls)
+	})
+}
+
+func TestOrderRepository_EnsureIndexes(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("reports the missing indexes", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		repo := mongodb.NewOrderRepository(mt.DB)
+		existing := []bson.D{{
+			{Key: "name", Value: "_id_"},
+			{Key: "key", Value: bson.D{{Key: "_id", Value: int32(1)}}},
+		}, {
+			{Key: "name", Value: "orders_text"},
+			{Key: "key", Value: bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}}},
+		}}
+		// Todos menos el de importe, uno de ellos creado a mano con otro nombre
+		for _, index := range repo.Indexes() {
+			name := mongodb.IndexName(index)
+			if name == "orders_text" || name == "totalAmount_1_createdAt_-1" {
+				continue
+			}
+			if name == "tags_1" {
+				name = "by_tag"
+			}
+			existing = append(existing, bson.D{{Key: "name", Value: name}, {Key: "key", Value: index.Keys}})
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, existing...))
+
+		report, err := repo.EnsureIndexes(context.Background())
+		require.NoError(mt, err)
+		assert.Equal(mt, len(repo.Indexes()), report.Expected)
+		assert.Equal(mt, []string{"totalAmount_1_createdAt_-1"}, report.Missing)
+	})
+}
+
+// mustMarshal codifica el documento para compararlo con los comandos enviados
+func mustMarshal(mt *mtest.T, doc bson.D) bson.Raw {
+	raw, err := bson.Marshal(doc)
+	require.NoError(mt, err)
+	return raw
+}
+
+func TestOrderRepository_FindWithFilters_SkipTotal(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("does not count the orders", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "order-123"},
+			{Key: "status", Value: "NEW"},
+		}))
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		orders, total, err := repo.FindWithFilters(context.Background(), map[string]interface{}{"skipTotal": true}, 1, 10)
+		require.Nil(mt, err)
+		assert.Equal(mt, repositories.UnknownTotal, total)
+		assert.Len(mt, orders, 1)
+
+		var commands []string
+		for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+			commands = append(commands, event.CommandName)
+		}
+		assert.Equal(mt, []string{"find"}, commands)
+	})
+}
+
+func TestOrderRepository_FindWithFilters_SoftDeleted(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("deleted orders are neither listed nor counted", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "_id", Value: "order-123"}, {Key: "status", Value: "NEW"}}),
+		)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		orders, total, err := repo.FindWithFilters(context.Background(), map[string]interface{}{"status": "NEW"}, 1, 10)
+		require.Nil(mt, err)
+		assert.Equal(mt, int64(1), total)
+		assert.Len(mt, orders, 1)
+
+		// Tanto el recuento como la página descartan los pedidos con deletedAt
+		count := startedCommand(mt, "aggregate")
+		require.NotNil(mt, count)
+		assert.False(mt, count.Lookup("pipeline", "0", "$match", "deletedAt", "$exists").Boolean())
+		find := findCommand(mt)
+		require.NotNil(mt, find)
+		assert.False(mt, find.Lookup("filter", "deletedAt", "$exists").Boolean())
+		assert.Equal(mt, "NEW", find.Lookup("filter", "status").StringValue())
+	})
+}
+
+func TestOrderRepository_FindByID_SoftDeleted(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	// orderResponse simula la orden guardada, eliminada o no
+	orderResponse := func(mt *mtest.T, deleted bool) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		doc := bson.D{
+			{Key: "_id", Value: "order-123"},
+			{Key: "status", Value: "NEW"},
+		}
+		if deleted {
+			doc = append(doc, bson.E{Key: "deletedAt", Value: time.Now()})
+		}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, doc))
+	}
+
+	mt.Run("live order", func(mt *mtest.T) {
+		orderResponse(mt, false)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		order, err := repo.FindByID(context.Background(), "order-123")
+		require.Nil(mt, err)
+		assert.Equal(mt, "order-123", order.ID)
+	})
+
+	mt.Run("deleted order as admin is gone", func(mt *mtest.T) {
+		orderResponse(mt, true)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		order, err := repo.FindByID(repositories.WithDeleted(context.Background()), "order-123")
+		assert.Nil(mt, order)
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusGone, err.StatusCode)
+		assert.Equal(mt, "order deleted", err.Cause)
+	})
+
+	mt.Run("deleted order as regular caller is not found", func(mt *mtest.T) {
+		orderResponse(mt, true)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		order, err := repo.FindByID(context.Background(), "order-123")
+		assert.Nil(mt, order)
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusNotFound, err.StatusCode)
+		assert.Equal(mt, "order not found", err.Cause)
+	})
+
+	mt.Run("notes cannot be added to a deleted order", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: nil}})
+		orderResponse(mt, true)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		_, err := repo.AppendNote(context.Background(), "order-123", models.OrderNote{Text: "late"}, 0)
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusGone, err.StatusCode)
+	})
+}
+
+func TestOrderRepository_Update_DuplicateKey(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	order := &models.Order{ID: "order-123", Status: models.StatusInProgress, Version: 2}
+
+	mt.Run("duplicate key is a distinct conflict", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Index:   0,
+			Code:    11000,
+			Message: "E11000 duplicate key error collection: orders_db.orders index: externalRef_1",
+		}))
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		err := repo.Update(context.Background(), order)
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusConflict, err.StatusCode)
+		assert.Equal(mt, repositories.CauseDuplicateKey, err.Cause)
+	})
+
+	mt.Run("version mismatch stays a version conflict", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: "order-123"},
+				{Key: "status", Value: "IN_PROGRESS"},
+				{Key: "version", Value: 3},
+			}),
+		)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		err := repo.Update(context.Background(), order)
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusConflict, err.StatusCode)
+		assert.Equal(mt, "version conflict", err.Cause)
+	})
+
+	mt.Run("other write errors are internal", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 121, Message: "Document failed validation"}))
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		err := repo.Update(context.Background(), order)
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusInternalServerError, err.StatusCode)
+	})
+}
+
+// startedCommand devuelve el primer comando con el nombre dado enviado al servidor simulado
+func startedCommand(mt *mtest.T, name string) bson.Raw {
+	for event := mt.GetStartedEvent(); event != nil; event = mt.GetStartedEvent() {
+		if event.CommandName == name {
+			return event.Command
+		}
+	}
+	return nil
+}
+
+func TestOrderRepository_TenantIsolation(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	tenantB := tenant.WithID(context.Background(), "brand-b")
+
+	mt.Run("orders of another tenant are not found", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch))
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		order, err := repo.FindByID(tenantB, "order-of-brand-a")
+		assert.Nil(mt, order)
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusNotFound, err.StatusCode)
+
+		cmd := startedCommand(mt, "find")
+		require.NotNil(mt, cmd)
+		assert.Equal(mt, "brand-b", cmd.Lookup("filter", "tenantId").StringValue())
+	})
+
+	mt.Run("listings only count and return the tenant's orders", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: 0}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		_, _, err := repo.FindWithFilters(tenantB, map[string]interface{}{"status": "NEW"}, 1, 10)
+		require.Nil(mt, err)
+
+		count := startedCommand(mt, "aggregate")
+		require.NotNil(mt, count)
+		assert.Equal(mt, "brand-b", count.Lookup("pipeline", "0", "$match", "tenantId").StringValue())
+		find := startedCommand(mt, "find")
+		require.NotNil(mt, find)
+		assert.Equal(mt, "brand-b", find.Lookup("filter", "tenantId").StringValue())
+	})
+
+	mt.Run("orders of another tenant cannot be updated", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		err := repo.Update(tenantB, &models.Order{ID: "order-of-brand-a", Status: models.StatusCancelled, Version: 2})
+		require.NotNil(mt, err)
+		assert.Equal(mt, http.StatusNotFound, err.StatusCode)
+
+		cmd := startedCommand(mt, "update")
+		require.NotNil(mt, cmd)
+		assert.Equal(mt, "brand-b", cmd.Lookup("updates", "0", "q", "tenantId").StringValue())
+	})
+
+	mt.Run("work without a tenant sees every tenant", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "order-of-brand-a"},
+			{Key: "tenantId", Value: "brand-a"},
+		}))
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		order, err := repo.FindByID(context.Background(), "order-of-brand-a")
+		require.Nil(mt, err)
+		assert.Equal(mt, "brand-a", order.TenantID)
+
+		cmd := startedCommand(mt, "find")
+		require.NotNil(mt, cmd)
+		_, lookupErr := cmd.LookupErr("filter", "tenantId")
+		assert.Error(mt, lookupErr)
+	})
+}
+
+func TestOrderRepository_Stats(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("aggregates the KPIs of the tenant", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+			{Key: "byStatus", Value: bson.A{
+				bson.D{{Key: "_id", Value: "NEW"}, {Key: "count", Value: int32(3)}},
+				bson.D{{Key: "_id", Value: "DELIVERED"}, {Key: "count", Value: int32(1)}},
+			}},
+			{Key: "totals", Value: bson.A{
+				bson.D{{Key: "_id", Value: nil}, {Key: "count", Value: int32(4)}, {Key: "averageTotal", Value: 62.5}},
+			}},
+			{Key: "recent", Value: bson.A{bson.D{{Key: "count", Value: int32(2)}}}},
+		}))
+		repo := mongodb.NewOrderRepository(mt.DB)
+		since := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+		stats, err := repo.Stats(tenant.WithID(context.Background(), "brand-b"), since)
+		require.Nil(mt, err)
+		assert.Equal(mt, map[models.OrderStatus]int64{models.StatusNew: 3, models.StatusDelivered: 1}, stats.OrdersByStatus)
+		assert.Equal(mt, int64(4), stats.TotalOrders)
+		assert.Equal(mt, 62.5, stats.AverageOrderValue)
+		assert.Equal(mt, int64(2), stats.OrdersLast24h)
+
+		cmd := startedCommand(mt, "aggregate")
+		require.NotNil(mt, cmd)
+		assert.Equal(mt, "brand-b", cmd.Lookup("pipeline", "0", "$match", "tenantId").StringValue())
+		assert.Equal(mt, since, cmd.Lookup("pipeline", "1", "$facet", "recent", "0", "$match", "createdAt", "$gte").Time().UTC())
+	})
+
+	mt.Run("no orders", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+			{Key: "byStatus", Value: bson.A{}},
+			{Key: "totals", Value: bson.A{}},
+			{Key: "recent", Value: bson.A{}},
+		}))
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		stats, err := repo.Stats(context.Background(), time.Now())
+		require.Nil(mt, err)
+		assert.Empty(mt, stats.OrdersByStatus)
+		assert.Zero(mt, stats.TotalOrders)
+		assert.Zero(mt, stats.AverageOrderValue)
+	})
+}
+
+func TestOrderRepository_Update_ForcedStatus(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	deliveredAt := time.Now().Add(-time.Hour)
+	order := &models.Order{
+		ID:          "order-123",
+		Status:      models.StatusReturned,
+		Version:     4,
+		DeliveredAt: &deliveredAt,
+		Items:       []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, DeliveredQuantity: 1}},
+		Return:      &models.OrderReturn{Reason: "Escaneo erróneo", RequestedAt: deliveredAt},
+	}
+
+	mt.Run("cleared delivery and return are removed", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		forced := order.Clone()
+		require.NoError(mt, forced.ForceStatus(models.StatusInProgress))
+		require.Nil(mt, repo.Update(context.Background(), forced))
+
+		// Los campos que ya no corresponden al estado se borran del documento
+		cmd := startedCommand(mt, "update")
+		require.NotNil(mt, cmd)
+		update := cmd.Lookup("updates", "0", "u")
+		assert.Equal(mt, "IN_PROGRESS", update.Document().Lookup("$set", "status").StringValue())
+		for _, field := range []string{"deliveredAt", "return"} {
+			_, err := update.Document().LookupErr("$unset", field)
+			assert.NoError(mt, err, field)
+			_, err = update.Document().LookupErr("$set", field)
+			assert.Error(mt, err, field)
+		}
+	})
+
+	mt.Run("kept delivery and return are saved", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		require.Nil(mt, repo.Update(context.Background(), order))
+
+		cmd := startedCommand(mt, "update")
+		require.NotNil(mt, cmd)
+		update := cmd.Lookup("updates", "0", "u").Document()
+		assert.Equal(mt, "Escaneo erróneo", update.Lookup("$set", "return", "reason").StringValue())
+		_, err := update.LookupErr("$set", "deliveredAt")
+		assert.NoError(mt, err)
+		_, err = update.LookupErr("$unset")
+		assert.Error(mt, err, "nothing is removed")
+	})
+}
+
+func TestOrderRepository_SortByUpdatedAt(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("sorts on the field updates write", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{{Key: "_id", Value: "order-123"}}),
+		)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		// Un pedido antiguo actualizado después de crearse otros
+		order := &models.Order{ID: "order-123", Status: models.StatusNew, Version: 1, CreatedAt: time.Now().Add(-48 * time.Hour)}
+		require.NoError(mt, order.UpdateStatus(models.StatusInProgress))
+		require.Nil(mt, repo.Update(context.Background(), order))
+		_, _, err := repo.FindWithFilters(context.Background(), map[string]interface{}{"sortBy": "updatedAt"}, 1, 10)
+		require.Nil(mt, err)
+
+		update := startedCommand(mt, "update")
+		require.NotNil(mt, update)
+		set := update.Lookup("updates", "0", "u", "$set").Document()
+		assert.Equal(mt, order.UpdatedAt.UnixMilli(), set.Lookup("updatedAt").Time().UnixMilli())
+		_, lookupErr := set.LookupErr("updated_at")
+		assert.Error(mt, lookupErr, "no stray field is written")
+
+		find := findCommand(mt)
+		require.NotNil(mt, find)
+		sort := find.Lookup("sort").Document()
+		keys, _ := sort.Elements()
+		require.NotEmpty(mt, keys)
+		assert.Equal(mt, "updatedAt", keys[0].Key())
+	})
+}
+
+func TestOrderRepository_Update_UpdatedAt(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	changes := []struct {
+		name   string
+		change func(o *models.Order) error
+	}{
+		{"status", func(o *models.Order) error { return o.UpdateStatus(models.StatusInProgress) }},
+		{"forced status", func(o *models.Order) error { return o.ForceStatus(models.StatusCancelled) }},
+		{"priority", func(o *models.Order) error { return o.SetPriority(models.PriorityHigh) }},
+		{"tags", func(o *models.Order) error { o.SetTags([]string{"vip"}); return nil }},
+	}
+
+	for _, tt := range changes {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+			repo := mongodb.NewOrderRepository(mt.DB)
+
+			saved := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+			order := &models.Order{ID: "order-123", Status: models.StatusNew, Priority: models.PriorityNormal, Version: 2, CreatedAt: saved, UpdatedAt: saved}
+			require.NoError(mt, tt.change(order))
+			require.Nil(mt, repo.Update(context.Background(), order))
+
+			// La fecha de actualización guardada avanza con cada cambio
+			cmd := startedCommand(mt, "update")
+			require.NotNil(mt, cmd)
+			update := cmd.Lookup("updates", "0", "u").Document()
+			assert.True(mt, update.Lookup("$set", "updatedAt").Time().After(saved))
+			assert.Equal(mt, int32(3), update.Lookup("$set", "version").Int32())
+			assert.Equal(mt, int32(2), cmd.Lookup("updates", "0", "q", "version").Int32())
+		})
+	}
+}
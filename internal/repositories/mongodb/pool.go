@@ -0,0 +1,69 @@
+package mongodb
+
+import (
+	"sync/atomic"
+
+	"orders/internal/metrics"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// PoolStats is the current usage of the connection pool.
+type PoolStats struct {
+	Open    int64  `json:"open"`
+	InUse   int64  `json:"inUse"`
+	Created uint64 `json:"created"`
+	MaxSize uint64 `json:"maxSize"`
+}
+
+// PoolMonitor follows the connection pool events of the client, recording
+// them in the mongo_pool_* metrics and keeping the current usage.
+type PoolMonitor struct {
+	open    atomic.Int64
+	inUse   atomic.Int64
+	created atomic.Uint64
+	maxSize atomic.Uint64
+}
+
+// NewPoolMonitor creates a monitor with an empty pool.
+func NewPoolMonitor() *PoolMonitor {
+	return &PoolMonitor{}
+}
+
+// Monitor returns the driver monitor to set on the client options.
+func (m *PoolMonitor) Monitor() *event.PoolMonitor {
+	return &event.PoolMonitor{Event: m.Event}
+}
+
+// Event records a connection pool event.
+func (m *PoolMonitor) Event(e *event.PoolEvent) {
+	switch e.Type {
+	case event.PoolCreated:
+		if e.PoolOptions != nil {
+			m.maxSize.Store(e.PoolOptions.MaxPoolSize)
+		}
+	case event.ConnectionCreated:
+		m.created.Add(1)
+		metrics.MongoPoolConnectionsCreatedTotal.Inc()
+		metrics.MongoPoolConnectionsOpen.Set(float64(m.open.Add(1)))
+	case event.ConnectionClosed:
+		metrics.MongoPoolConnectionsOpen.Set(float64(m.open.Add(-1)))
+	case event.GetSucceeded:
+		metrics.MongoPoolCheckoutWait.WithLabelValues("success").Observe(e.Duration.Seconds())
+		metrics.MongoPoolConnectionsInUse.Set(float64(m.inUse.Add(1)))
+	case event.GetFailed:
+		metrics.MongoPoolCheckoutWait.WithLabelValues("error").Observe(e.Duration.Seconds())
+	case event.ConnectionReturned:
+		metrics.MongoPoolConnectionsInUse.Set(float64(m.inUse.Add(-1)))
+	}
+}
+
+// Stats returns the current usage of the pool.
+func (m *PoolMonitor) Stats() PoolStats {
+	return PoolStats{
+		Open:    m.open.Load(),
+		InUse:   m.inUse.Load(),
+		Created: m.created.Load(),
+		MaxSize: m.maxSize.Load(),
+	}
+}
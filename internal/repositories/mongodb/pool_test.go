@@ -0,0 +1,40 @@
+package mongodb_test
+
+import (
+	"testing"
+	"time"
+
+	"orders/internal/metrics"
+	"orders/internal/repositories/mongodb"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestPoolMonitor(t *testing.T) {
+	pool := mongodb.NewPoolMonitor()
+	monitor := pool.Monitor()
+	created := testutil.ToFloat64(metrics.MongoPoolConnectionsCreatedTotal)
+	checkouts := testutil.CollectAndCount(metrics.MongoPoolCheckoutWait)
+
+	monitor.Event(&event.PoolEvent{Type: event.PoolCreated, PoolOptions: &event.MonitorPoolOptions{MaxPoolSize: 50}})
+	monitor.Event(&event.PoolEvent{Type: event.ConnectionCreated})
+	monitor.Event(&event.PoolEvent{Type: event.ConnectionCreated})
+	monitor.Event(&event.PoolEvent{Type: event.GetSucceeded, Duration: 3 * time.Millisecond})
+	monitor.Event(&event.PoolEvent{Type: event.GetSucceeded, Duration: time.Millisecond})
+	monitor.Event(&event.PoolEvent{Type: event.ConnectionReturned})
+	monitor.Event(&event.PoolEvent{Type: event.GetFailed, Duration: time.Second})
+
+	assert.Equal(t, mongodb.PoolStats{Open: 2, InUse: 1, Created: 2, MaxSize: 50}, pool.Stats())
+	assert.Equal(t, created+2, testutil.ToFloat64(metrics.MongoPoolConnectionsCreatedTotal))
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.MongoPoolConnectionsOpen))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.MongoPoolConnectionsInUse))
+	// Una serie por resultado: las esperas con éxito y la fallida
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.MongoPoolCheckoutWait)-checkouts)
+
+	// Al cerrarse una conexión deja de contar como abierta
+	monitor.Event(&event.PoolEvent{Type: event.ConnectionClosed})
+	assert.Equal(t, int64(1), pool.Stats().Open)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.MongoPoolConnectionsOpen))
+}
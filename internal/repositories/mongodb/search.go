@@ -0,0 +1,91 @@
+package mongodb
+
+import (
+	"context"
+	"net/http"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// searchKeysIndex is the index Search is pinned to.
+var searchKeysIndex = bson.D{{Key: "searchKeys", Value: 1}}
+
+// Search finds the orders whose ID starts with the term, or that contain a
+// SKU equal to it, newest first. Terms are matched exactly against the search
+// keys, so an ID prefix must be between models.SearchPrefixMinLength and
+// models.SearchPrefixMaxLength characters long unless it is the whole ID.
+func (r *OrderRepository) Search(ctx context.Context, term string, limit int) ([]*models.Order, *repositories.RepositoryError) {
+	filter := scoped(ctx, bson.M{
+		"searchKeys": models.SearchKey(term),
+		"deletedAt":  bson.M{"$exists": false},
+	})
+	// The hint keeps the query on the multikey index even when the planner
+	// would rather scan by tenant
+	opts := options.Find().
+		SetHint(searchKeysIndex).
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to search orders",
+		}
+	}
+	defer cursor.Close(ctx)
+
+	orders, err := decodeOrders(ctx, cursor)
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to search orders",
+		}
+	}
+	return orders, nil
+}
+
+// BackfillSearchKeys sets the search keys of the orders stored before they
+// were introduced, batchSize orders at a time, and returns how many orders
+// it updated. It covers every tenant and can be run again safely.
+func (r *OrderRepository) BackfillSearchKeys(ctx context.Context, batchSize int) (int64, error) {
+	filter := bson.M{"searchKeys": bson.M{"$exists": false}}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "items.sku": 1}).
+		SetLimit(int64(batchSize))
+
+	var updated int64
+	for {
+		cursor, err := r.collection.Find(ctx, filter, opts)
+		if err != nil {
+			return updated, err
+		}
+		orders, err := decodeOrders(ctx, cursor)
+		if err != nil {
+			return updated, err
+		}
+		if len(orders) == 0 {
+			return updated, nil
+		}
+
+		writes := make([]mongo.WriteModel, 0, len(orders))
+		for _, order := range orders {
+			order.RefreshSearchKeys()
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": order.ID, "searchKeys": bson.M{"$exists": false}}).
+				SetUpdate(bson.M{"$set": bson.M{"searchKeys": order.SearchKeys}}))
+		}
+		result, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return updated, err
+		}
+		updated += result.ModifiedCount
+	}
+}
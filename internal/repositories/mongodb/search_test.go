@@ -0,0 +1,115 @@
+package mongodb_test
+
+import (
+	"context"
+	"testing"
+
+	"orders/internal/models"
+	"orders/internal/repositories/mongodb"
+	"orders/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestOrderRepository_Search(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("matches the search keys exactly on their index", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "3f2a9c1e-7b4d"},
+			{Key: "status", Value: "NEW"},
+		}))
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		orders, err := repo.Search(tenant.WithID(context.Background(), "brand-b"), " 3F2A9c ", 20)
+		require.Nil(mt, err)
+		require.Len(mt, orders, 1)
+		assert.Equal(mt, "3f2a9c1e-7b4d", orders[0].ID)
+
+		cmd := findCommand(mt)
+		require.NotNil(mt, cmd)
+		// Igualdad sobre el campo multikey, sin expresiones regulares
+		assert.Equal(mt, "3f2a9c", cmd.Lookup("filter", "searchKeys").StringValue())
+		assert.Equal(mt, "brand-b", cmd.Lookup("filter", "tenantId").StringValue())
+		assert.True(mt, cmd.Lookup("filter", "deletedAt", "$exists").Equal(bson.RawValue{Type: bson.TypeBoolean, Value: []byte{0}}))
+		assert.NotContains(mt, cmd.Lookup("filter").String(), "$regex")
+		// La consulta se fija al índice de las claves de búsqueda
+		assert.Equal(mt, int32(1), cmd.Lookup("hint", "searchKeys").Int32())
+		assert.Equal(mt, int32(-1), cmd.Lookup("sort", "createdAt").Int32())
+		assert.Equal(mt, int64(20), cmd.Lookup("limit").AsInt64())
+	})
+}
+
+func TestOrderRepository_SearchKeysOnWrite(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("stored on creation", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		order := &models.Order{ID: "order-123", Status: models.StatusNew, Items: []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 999}}}
+		require.Nil(mt, repo.Create(context.Background(), order))
+
+		cmd := startedCommand(mt, "insert")
+		require.NotNil(mt, cmd)
+		values, err := cmd.Lookup("documents").Array().Index(0).Value().Document().Lookup("searchKeys").Array().Values()
+		require.NoError(mt, err)
+		keys := make([]string, len(values))
+		for i, value := range values {
+			keys[i] = value.StringValue()
+		}
+		assert.Equal(mt, []string{"orde", "order", "order-", "order-1", "order-12", "order-123", "laptop-001"}, keys)
+	})
+
+	mt.Run("refreshed when the items change", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		order := &models.Order{ID: "order-123", Status: models.StatusNew, Version: 2, Items: []models.OrderItem{{SKU: "MOUSE-002", Quantity: 1, Price: 25}}}
+		require.Nil(mt, repo.Update(context.Background(), order))
+
+		cmd := startedCommand(mt, "update")
+		require.NotNil(mt, cmd)
+		set := cmd.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set").Document()
+		assert.Contains(mt, set.Lookup("searchKeys").String(), `"mouse-002"`)
+	})
+}
+
+func TestOrderRepository_BackfillSearchKeys(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("updates the orders without keys in batches", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "order-1"}, {Key: "items", Value: bson.A{bson.D{{Key: "sku", Value: "LAPTOP-001"}}}}},
+				bson.D{{Key: "_id", Value: "order-2"}},
+			),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+		repo := mongodb.NewOrderRepository(mt.DB)
+
+		updated, err := repo.BackfillSearchKeys(context.Background(), 2)
+		require.NoError(mt, err)
+		assert.Equal(mt, int64(2), updated)
+
+		find := findCommand(mt)
+		require.NotNil(mt, find)
+		assert.True(mt, find.Lookup("filter", "searchKeys", "$exists").Equal(bson.RawValue{Type: bson.TypeBoolean, Value: []byte{0}}))
+		assert.Equal(mt, int64(2), find.Lookup("limit").AsInt64())
+
+		update := startedCommand(mt, "update")
+		require.NotNil(mt, update)
+		updates, err := update.Lookup("updates").Array().Values()
+		require.NoError(mt, err)
+		require.Len(mt, updates, 2)
+		first := updates[0].Document()
+		assert.Equal(mt, "order-1", first.Lookup("q", "_id").StringValue())
+		assert.Contains(mt, first.Lookup("u", "$set", "searchKeys").String(), `"laptop-001"`)
+	})
+}
@@ -0,0 +1,218 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	ordersSummaryCollection = "orders_summary"
+)
+
+// OrderSummaryRepository stores the orders summary, a compact copy of the
+// fields of the orders listings filter, sort and count by. It is kept up to
+// date from the order events and only serves reads that need no other field.
+type OrderSummaryRepository struct {
+	orders     *mongo.Collection
+	collection *mongo.Collection
+	// listMaxTime bounds how long MongoDB may spend on a listing, 0 if unbounded
+	listMaxTime time.Duration
+}
+
+func NewOrderSummaryRepository(db *mongo.Database) *OrderSummaryRepository {
+	return &OrderSummaryRepository{
+		orders:     db.Collection(ordersCollection),
+		collection: db.Collection(ordersSummaryCollection),
+	}
+}
+
+// SetListMaxTime makes MongoDB abort the count and find of a listing running
+// longer than d. 0 leaves them unbounded.
+func (r *OrderSummaryRepository) SetListMaxTime(d time.Duration) {
+	r.listMaxTime = d
+}
+
+// summaryProjection reads from the orders the fields of their summary rows.
+var summaryProjection = bson.M{
+	"tenantId":    1,
+	"customerId":  1,
+	"status":      1,
+	"totalAmount": 1,
+	"version":     1,
+	"createdAt":   1,
+	"updatedAt":   1,
+}
+
+// olderRow matches the stored row of the record unless it has a newer
+// version. Upserts replace an older row; a newer one makes them collide on _id.
+func olderRow(record *models.OrderSummaryRecord) bson.M {
+	return bson.M{"_id": record.ID, "version": bson.M{"$lte": record.Version}}
+}
+
+// Upsert stores the summary row of an order and reports whether it was
+// written, false when the stored row is already newer.
+func (r *OrderSummaryRepository) Upsert(ctx context.Context, record *models.OrderSummaryRecord) (bool, *repositories.RepositoryError) {
+	_, err := r.collection.ReplaceOne(ctx, olderRow(record), record, options.Replace().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to update order summary",
+		}
+	}
+	return true, nil
+}
+
+// Delete removes the summary row of an order that no longer exists.
+func (r *OrderSummaryRepository) Delete(ctx context.Context, id string) *repositories.RepositoryError {
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to delete order summary",
+		}
+	}
+	return nil
+}
+
+// FindWithFilters lists the summary rows like OrderRepository.FindWithFilters
+// lists the orders. Only the filters and sorts over the fields of the summary
+// are supported.
+func (r *OrderSummaryRepository) FindWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.OrderSummaryRecord, int64, *repositories.RepositoryError) {
+	cursor, total, repoErr := findListPage(ctx, r.collection, r.listMaxTime, filters, page, limit)
+	if repoErr != nil {
+		return nil, 0, repoErr
+	}
+	defer cursor.Close(ctx)
+
+	var records []*models.OrderSummaryRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, 0, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      err.Error(),
+			Message:    "Failed to find orders",
+		}
+	}
+	return records, total, nil
+}
+
+// Stats aggregates the KPIs of the orders from their summary rows.
+func (r *OrderSummaryRepository) Stats(ctx context.Context, since time.Time) (*models.OrderStats, *repositories.RepositoryError) {
+	return aggregateStats(ctx, r.collection, since)
+}
+
+// Backfill writes the summary rows of the stored orders, batchSize orders at
+// a time in _id order, and returns how many rows it wrote. Rows newer than
+// the order read are left alone, so it can run while the summary is being
+// kept up to date, and be run again safely.
+func (r *OrderSummaryRepository) Backfill(ctx context.Context, batchSize int) (int64, error) {
+	opts := options.Find().
+		SetProjection(summaryProjection).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(batchSize))
+
+	var written int64
+	var last string
+	for {
+		filter := bson.M{"deletedAt": bson.M{"$exists": false}}
+		if last != "" {
+			filter["_id"] = bson.M{"$gt": last}
+		}
+		cursor, err := r.orders.Find(ctx, filter, opts)
+		if err != nil {
+			return written, err
+		}
+		var records []*models.OrderSummaryRecord
+		if err := cursor.All(ctx, &records); err != nil {
+			return written, err
+		}
+		if len(records) == 0 {
+			return written, nil
+		}
+
+		writes := make([]mongo.WriteModel, 0, len(records))
+		for _, record := range records {
+			writes = append(writes, mongo.NewReplaceOneModel().
+				SetFilter(olderRow(record)).
+				SetReplacement(record).
+				SetUpsert(true))
+		}
+		result, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		if result != nil {
+			written += result.ModifiedCount + result.UpsertedCount
+		}
+		if err != nil && !onlyDuplicateKeys(err) {
+			return written, err
+		}
+		last = records[len(records)-1].ID
+	}
+}
+
+// onlyDuplicateKeys reports whether every write of a bulk write that failed
+// collided on a unique key, which for summary rows means they were newer.
+func onlyDuplicateKeys(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+	return true
+}
+
+// Indexes are the indexes the listings over the summary rely on.
+func (r *OrderSummaryRepository) Indexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "tenantId", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "customerId", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "customerId", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "totalAmount", Value: 1},
+				{Key: "createdAt", Value: -1},
+			},
+		},
+	}
+}
+
+// CreateIndexes creates the missing indexes of the repository.
+func (r *OrderSummaryRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, r.Indexes())
+	return err
+}
+
+// EnsureIndexes checks that every index of the repository exists.
+func (r *OrderSummaryRepository) EnsureIndexes(ctx context.Context) (IndexReport, error) {
+	return missingIndexes(ctx, r.collection, r.Indexes())
+}
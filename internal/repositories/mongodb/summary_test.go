@@ -0,0 +1,79 @@
+package mongodb_test
+
+import (
+	"context"
+	"testing"
+
+	"orders/internal/models"
+	"orders/internal/repositories/mongodb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestOrderSummaryRepository_Upsert(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("writes rows not older than the stored one", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		repo := mongodb.NewOrderSummaryRepository(mt.DB)
+
+		written, err := repo.Upsert(context.Background(), &models.OrderSummaryRecord{ID: "order-1", Status: models.StatusNew, Version: 3})
+		require.Nil(mt, err)
+		assert.True(mt, written)
+
+		update := startedCommand(mt, "update")
+		require.NotNil(mt, update)
+		assert.Equal(mt, "orders_summary", update.Lookup("update").StringValue())
+		assert.Equal(mt, int32(3), update.Lookup("updates", "0", "q", "version", "$lte").Int32())
+		assert.True(mt, update.Lookup("updates", "0", "upsert").Boolean())
+	})
+
+	mt.Run("keeps newer rows", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}))
+		repo := mongodb.NewOrderSummaryRepository(mt.DB)
+
+		written, err := repo.Upsert(context.Background(), &models.OrderSummaryRecord{ID: "order-1", Version: 1})
+		require.Nil(mt, err)
+		assert.False(mt, written)
+	})
+}
+
+func TestOrderSummaryRepository_Backfill(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("pages through the orders by _id", func(mt *mtest.T) {
+		ns := mt.DB.Name() + ".orders"
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "order-1"}, {Key: "status", Value: "NEW"}, {Key: "version", Value: 1}},
+				bson.D{{Key: "_id", Value: "order-2"}, {Key: "status", Value: "DELIVERED"}, {Key: "version", Value: 4}},
+			),
+			// order-2 ya tiene una fila más reciente
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 1, Code: 11000, Message: "duplicate key"}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+		repo := mongodb.NewOrderSummaryRepository(mt.DB)
+
+		_, err := repo.Backfill(context.Background(), 2)
+		require.NoError(mt, err)
+
+		find := findCommand(mt)
+		require.NotNil(mt, find)
+		assert.Equal(mt, "orders", find.Lookup("find").StringValue())
+		assert.Equal(mt, int64(2), find.Lookup("limit").AsInt64())
+
+		update := startedCommand(mt, "update")
+		require.NotNil(mt, update)
+		updates, err := update.Lookup("updates").Array().Values()
+		require.NoError(mt, err)
+		assert.Len(mt, updates, 2)
+
+		// La siguiente página empieza tras el último pedido leído
+		next := findCommand(mt)
+		require.NotNil(mt, next)
+		assert.Equal(mt, "order-2", next.Lookup("filter", "_id", "$gt").StringValue())
+	})
+}
@@ -0,0 +1,29 @@
+package mongodb
+
+import (
+	"context"
+
+	"orders/internal/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// scoped restricts a filter to the tenant of the context, so no query reads
+// or writes documents of another tenant. Without a tenant, as in background
+// workers, the filter is left as is.
+func scoped(ctx context.Context, filter bson.M) bson.M {
+	if tenantID := tenant.ID(ctx); tenantID != "" {
+		filter["tenantId"] = tenantID
+	}
+	return filter
+}
+
+// assignTenant sets the tenant of the documents that have none.
+func assignTenant(ctx context.Context, collection *mongo.Collection, tenantID string) error {
+	_, err := collection.UpdateMany(ctx,
+		bson.M{"tenantId": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"tenantId": tenantID}},
+	)
+	return err
+}
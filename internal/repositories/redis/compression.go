@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync/atomic"
+)
+
+// gzipMagic starts every gzip stream. Orders are cached as JSON, which never
+// starts with it, so it marks the compressed values and the ones cached
+// uncompressed are still read.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compression gzips the cached values of at least minSize bytes while
+// enabled. It can be changed while the repository is in use.
+type compression struct {
+	enabled atomic.Bool
+	minSize atomic.Int64
+}
+
+func (c *compression) Store(enabled bool, minSize int) {
+	c.minSize.Store(int64(minSize))
+	c.enabled.Store(enabled)
+}
+
+// encode returns data gzipped when compression is enabled and data is large
+// enough to be worth it, or data as is.
+func (c *compression) encode(data []byte) ([]byte, error) {
+	if !c.enabled.Load() || int64(len(data)) < c.minSize.Load() {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress returns the JSON of a cached value, whether it was compressed
+// or not.
+func decompress(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"orders/internal/repositories"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	customerKeyPrefix = "customer:exists:"
+)
+
+// CustomerCacheRepository caches the outcome of customer existence checks.
+type CustomerCacheRepository struct {
+	client *redis.Client
+	ttl    expiration
+}
+
+func NewCustomerCacheRepository(client *redis.Client, ttl time.Duration) *CustomerCacheRepository {
+	r := &CustomerCacheRepository{client: client}
+	r.ttl.Store(ttl)
+	return r
+}
+
+// SetTTL changes the TTL of the entries cached from now on.
+func (r *CustomerCacheRepository) SetTTL(ttl time.Duration) {
+	r.ttl.Store(ttl)
+}
+
+// GetExists returns the cached existence flag of a customer. The second
+// return value is false when there is no cached entry.
+func (r *CustomerCacheRepository) GetExists(ctx context.Context, customerID string) (bool, bool, *repositories.RepositoryError) {
+	value, err := r.client.Get(ctx, r.customerKey(customerID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return false, false, nil
+		}
+		return false, false, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to get customer from cache",
+			Message:    err.Error(),
+		}
+	}
+	return value == "1", true, nil
+}
+
+// SetExists caches the existence flag of a customer using the configured TTL.
+func (r *CustomerCacheRepository) SetExists(ctx context.Context, customerID string, exists bool) *repositories.RepositoryError {
+	value := "0"
+	if exists {
+		value = "1"
+	}
+	if err := r.client.Set(ctx, r.customerKey(customerID), value, r.ttl.Load()).Err(); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to set customer in cache",
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+func (r *CustomerCacheRepository) customerKey(customerID string) string {
+	return fmt.Sprintf("%s%s", customerKeyPrefix, customerID)
+}
@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// InfoReader is the part of the Redis client used by Describe.
+type InfoReader interface {
+	Info(ctx context.Context, sections ...string) *redis.StringCmd
+}
+
+// ServerInfo is the subset of INFO describing the Redis server the service
+// talks to.
+type ServerInfo struct {
+	Version string `json:"version"`
+	// Mode is standalone, sentinel or cluster
+	Mode string `json:"mode,omitempty"`
+	// Role is master or slave, and ConnectedReplicas how many replicas a
+	// master has
+	Role              string `json:"role,omitempty"`
+	ConnectedReplicas int    `json:"connectedReplicas"`
+	UsedMemory        int64  `json:"usedMemoryBytes"`
+	// MaxMemory is 0 when Redis may use all the memory of the host
+	MaxMemory       int64  `json:"maxMemoryBytes"`
+	MaxMemoryPolicy string `json:"maxMemoryPolicy,omitempty"`
+}
+
+// Describe reads the version, role and memory of the server from INFO.
+func Describe(ctx context.Context, client InfoReader) (*ServerInfo, error) {
+	raw, err := client.Info(ctx).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fields[key] = value
+		}
+	}
+
+	info := &ServerInfo{
+		Version:         fields["redis_version"],
+		Mode:            fields["redis_mode"],
+		Role:            fields["role"],
+		MaxMemoryPolicy: fields["maxmemory_policy"],
+	}
+	info.ConnectedReplicas, _ = strconv.Atoi(fields["connected_slaves"])
+	info.UsedMemory, _ = strconv.ParseInt(fields["used_memory"], 10, 64)
+	info.MaxMemory, _ = strconv.ParseInt(fields["maxmemory"], 10, 64)
+	return info, nil
+}
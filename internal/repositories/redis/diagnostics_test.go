@@ -0,0 +1,54 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	redisrepo "orders/internal/repositories/redis"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInfo responde a INFO con el texto dado
+type fakeInfo struct {
+	text string
+	err  error
+}
+
+func (f fakeInfo) Info(ctx context.Context, sections ...string) *redis.StringCmd {
+	return redis.NewStringResult(f.text, f.err)
+}
+
+func TestDescribe(t *testing.T) {
+	info, err := redisrepo.Describe(context.Background(), fakeInfo{text: "# Server\r\n" +
+		"redis_version:7.2.4\r\n" +
+		"redis_mode:standalone\r\n" +
+		"\r\n" +
+		"# Memory\r\n" +
+		"used_memory:1048576\r\n" +
+		"used_memory_human:1.00M\r\n" +
+		"maxmemory:268435456\r\n" +
+		"maxmemory_policy:allkeys-lru\r\n" +
+		"\r\n" +
+		"# Replication\r\n" +
+		"role:master\r\n" +
+		"connected_slaves:2\r\n" +
+		"slave0:ip=10.0.0.2,port=6379,state=online,offset=42,lag=0\r\n"})
+	require.NoError(t, err)
+
+	assert.Equal(t, &redisrepo.ServerInfo{
+		Version:           "7.2.4",
+		Mode:              "standalone",
+		Role:              "master",
+		ConnectedReplicas: 2,
+		UsedMemory:        1048576,
+		MaxMemory:         268435456,
+		MaxMemoryPolicy:   "allkeys-lru",
+	}, info)
+
+	_, err = redisrepo.Describe(context.Background(), fakeInfo{err: errors.New("connection refused")})
+	assert.Error(t, err)
+}
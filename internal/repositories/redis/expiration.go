@@ -0,0 +1,20 @@
+package redis
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// expiration is a TTL that can be changed while the repository is in use, so
+// a reloaded configuration applies without reconnecting to Redis.
+type expiration struct {
+	nanos atomic.Int64
+}
+
+func (e *expiration) Load() time.Duration {
+	return time.Duration(e.nanos.Load())
+}
+
+func (e *expiration) Store(ttl time.Duration) {
+	e.nanos.Store(int64(ttl))
+}
@@ -0,0 +1,135 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/tenant"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	lastWriteKey               = "orders:last-write"
+	customerLastWriteKeyPrefix = "orders:last-write:customer:"
+)
+
+// touchScript stamps the global key, and the customer key when given, with
+// the Redis server time in milliseconds. Using the server clock keeps the
+// stamps comparable across API instances.
+var touchScript = redis.NewScript(`
+local now = redis.call('TIME')
+local ms = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+redis.call('SET', KEYS[1], ms)
+if KEYS[2] then
+	redis.call('SET', KEYS[2], ms, 'PX', ARGV[1])
+end
+return ms
+`)
+
+// LastWriteRepository tracks when orders were last written, globally and per
+// customer, so unchanged listings can be answered with 304 Not Modified.
+type LastWriteRepository struct {
+	client      *redis.Client
+	customerTTL expiration
+}
+
+// NewLastWriteRepository creates the tracker. Per customer stamps expire
+// after customerTTL; a missing stamp is reported as unknown.
+func NewLastWriteRepository(client *redis.Client, customerTTL time.Duration) *LastWriteRepository {
+	r := &LastWriteRepository{client: client}
+	r.customerTTL.Store(customerTTL)
+	return r
+}
+
+// SetTTL changes the expiration of the per customer stamps written from now on.
+func (r *LastWriteRepository) SetTTL(customerTTL time.Duration) {
+	r.customerTTL.Store(customerTTL)
+}
+
+// Touch records a write to an order of the customer.
+func (r *LastWriteRepository) Touch(ctx context.Context, customerID string) *repositories.RepositoryError {
+	keys := r.keys(ctx, customerID)
+	if err := touchScript.Run(ctx, r.client, keys, r.customerTTL.Load().Milliseconds()).Err(); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to record last write",
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+// Forget drops the stamps a failed Touch could not move forward, so that
+// listings are reported as modified until the next write.
+func (r *LastWriteRepository) Forget(ctx context.Context, customerID string) *repositories.RepositoryError {
+	keys := r.keys(ctx, customerID)
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to drop last write",
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+// Version returns the last write to the customer's orders, or to any order
+// when customerID is empty, or nil when it is not known.
+func (r *LastWriteRepository) Version(ctx context.Context, customerID string) (*models.ListVersion, *repositories.RepositoryError) {
+	keys := r.keys(ctx, customerID)
+	key := keys[len(keys)-1]
+
+	var get *redis.StringCmd
+	var now *redis.TimeCmd
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		now = pipe.Time(ctx)
+		return nil
+	})
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to get last write",
+			Message:    err.Error(),
+		}
+	}
+
+	ms, err := get.Int64()
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "invalid last write",
+			Message:    fmt.Sprintf("Invalid last write stored under %s", key),
+		}
+	}
+
+	return &models.ListVersion{
+		LastWrite:  time.UnixMilli(ms).UTC(),
+		ObservedAt: now.Val().UTC(),
+	}, nil
+}
+
+// keys returns the stamp of the orders of the tenant of the context followed,
+// when customerID is given, by the stamp of the customer's orders. Stamps are
+// kept per tenant, so writes of a tenant never change the version of another.
+func (r *LastWriteRepository) keys(ctx context.Context, customerID string) []string {
+	global, customerPrefix := lastWriteKey, customerLastWriteKeyPrefix
+	if tenantID := tenant.ID(ctx); tenantID != "" {
+		global = fmt.Sprintf("%s:%s", lastWriteKey, tenantID)
+		customerPrefix = fmt.Sprintf("%s%s:", customerLastWriteKeyPrefix, tenantID)
+	}
+
+	keys := []string{global}
+	if customerID != "" {
+		keys = append(keys, customerPrefix+customerID)
+	}
+	return keys
+}
@@ -0,0 +1,78 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redisrepo "orders/internal/repositories/redis"
+	"orders/internal/tenant"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastWriteRepository(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	repo := redisrepo.NewLastWriteRepository(client, time.Hour)
+	ctx := context.Background()
+
+	// Nothing written yet
+	version, repoErr := repo.Version(ctx, "")
+	require.Nil(t, repoErr)
+	assert.Nil(t, version)
+
+	written := time.Date(2025, 3, 1, 10, 0, 0, 250_000_000, time.UTC)
+	server.SetTime(written)
+	require.Nil(t, repo.Touch(ctx, "customer-1"))
+
+	server.SetTime(written.Add(time.Minute))
+	version, repoErr = repo.Version(ctx, "")
+	require.Nil(t, repoErr)
+	require.NotNil(t, version)
+	assert.Equal(t, written, version.LastWrite)
+	assert.Equal(t, written.Add(time.Minute), version.ObservedAt)
+
+	version, repoErr = repo.Version(ctx, "customer-1")
+	require.Nil(t, repoErr)
+	require.NotNil(t, version)
+	assert.Equal(t, written, version.LastWrite)
+	assert.Equal(t, time.Hour, server.TTL("orders:last-write:customer:customer-1"))
+
+	// Other customers are not affected by the write
+	version, repoErr = repo.Version(ctx, "customer-2")
+	require.Nil(t, repoErr)
+	assert.Nil(t, version)
+
+	require.Nil(t, repo.Forget(ctx, "customer-1"))
+	assert.False(t, server.Exists("orders:last-write"))
+	assert.False(t, server.Exists("orders:last-write:customer:customer-1"))
+}
+
+func TestLastWriteRepository_TenantIsolation(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	repo := redisrepo.NewLastWriteRepository(client, time.Hour)
+	tenantA := tenant.WithID(context.Background(), "brand-a")
+	tenantB := tenant.WithID(context.Background(), "brand-b")
+
+	require.Nil(t, repo.Touch(tenantA, "customer-1"))
+	assert.True(t, server.Exists("orders:last-write:brand-a"))
+	assert.True(t, server.Exists("orders:last-write:customer:brand-a:customer-1"))
+
+	// Las escrituras de un tenant no cambian la versión de otro
+	for _, customerID := range []string{"", "customer-1"} {
+		version, repoErr := repo.Version(tenantB, customerID)
+		require.Nil(t, repoErr)
+		assert.Nil(t, version)
+
+		version, repoErr = repo.Version(tenantA, customerID)
+		require.Nil(t, repoErr)
+		assert.NotNil(t, version)
+	}
+}
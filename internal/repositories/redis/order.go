@@ -2,41 +2,76 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"orders/internal/codec"
 	"orders/internal/models"
 	"orders/internal/repositories"
+	"orders/internal/tenant"
+	"orders/internal/timing"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// Orders are cached under order:<tenant>:<id>, so a tenant never reads the
+// orders of another one.
 const (
 	orderKeyPrefix = "order:"
+	// Summaries live under their own prefix so they are not picked up by
+	// ScanOrders.
+	summaryKeyPrefix = "order-summary:"
+	// Stats are cached per tenant under order-stats:<tenant>
+	statsKey = "order-stats"
+	// The IDs of the cached orders of each customer are indexed in a set
+	// under customer:orders:<tenant>:<customer>, so they can be dropped at once
+	customerOrdersKeyPrefix = "customer:orders:"
+	// invalidateBatchSize is how many orders of a customer are dropped per
+	// round trip
+	invalidateBatchSize = 100
 )
 
 type Repository interface {
 	GetOrder(ctx context.Context, orderID string) (*models.Order, *repositories.RepositoryError)
 	SetOrder(ctx context.Context, order *models.Order) *repositories.RepositoryError
 	InvalidateOrder(ctx context.Context, orderID string) *repositories.RepositoryError
+	GetOrderSummary(ctx context.Context, orderID string) (*models.OrderSummary, *repositories.RepositoryError)
+	SetOrderSummary(ctx context.Context, summary *models.OrderSummary) *repositories.RepositoryError
+	GetOrderStats(ctx context.Context) (*models.OrderStats, *repositories.RepositoryError)
+	SetOrderStats(ctx context.Context, stats *models.OrderStats, ttl time.Duration) *repositories.RepositoryError
 }
 
 type CacheRepository struct {
 	client     *redis.Client
-	defaultTTL time.Duration
+	defaultTTL expiration
+	// compression applies to full orders only; summaries are small
+	compression compression
 }
 
 func NewCacheRepository(client *redis.Client, defaultTTL time.Duration) *CacheRepository {
-	return &CacheRepository{
-		client:     client,
-		defaultTTL: defaultTTL,
-	}
+	r := &CacheRepository{client: client}
+	r.defaultTTL.Store(defaultTTL)
+	return r
+}
+
+// SetDefaultTTL changes the TTL of the orders and summaries cached from now on.
+func (r *CacheRepository) SetDefaultTTL(ttl time.Duration) {
+	r.defaultTTL.Store(ttl)
+}
+
+// SetCompression gzips the orders of at least minSize bytes of JSON cached
+// from now on, or stops compressing them when disabled. Orders are read
+// whether they were cached compressed or not.
+func (r *CacheRepository) SetCompression(enabled bool, minSize int) {
+	r.compression.Store(enabled, minSize)
 }
 
 func (r *CacheRepository) GetOrder(ctx context.Context, orderID string) (*models.Order, *repositories.RepositoryError) {
-	key := r.orderKey(orderID)
+	defer timing.Since(ctx, timing.Cache, time.Now())
+
+	key := orderKey(tenant.ID(ctx), orderID)
 
 	data, err := r.client.Get(ctx, key).Bytes()
 	if err != nil {
@@ -51,7 +86,11 @@ func (r *CacheRepository) GetOrder(ctx context.Context, orderID string) (*models
 	}
 
 	var order models.Order
-	if err := json.Unmarshal(data, &order); err != nil {
+	data, err = decompress(data)
+	if err == nil {
+		err = codec.Unmarshal(data, &order)
+	}
+	if err != nil {
 		return nil, &repositories.RepositoryError{
 			StatusCode: http.StatusInternalServerError,
 			Cause:      "failed to unmarshal order",
@@ -63,9 +102,14 @@ func (r *CacheRepository) GetOrder(ctx context.Context, orderID string) (*models
 }
 
 func (r *CacheRepository) SetOrder(ctx context.Context, order *models.Order) *repositories.RepositoryError {
-	key := r.orderKey(order.ID)
+	defer timing.Since(ctx, timing.Cache, time.Now())
+
+	key := orderKey(order.TenantID, order.ID)
 
-	data, err := json.Marshal(order)
+	data, err := codec.Marshal(order)
+	if err == nil {
+		data, err = r.compression.encode(data)
+	}
 	if err != nil {
 		return &repositories.RepositoryError{
 			StatusCode: http.StatusInternalServerError,
@@ -74,8 +118,19 @@ func (r *CacheRepository) SetOrder(ctx context.Context, order *models.Order) *re
 		}
 	}
 
-	status := r.client.Set(ctx, key, data, r.defaultTTL)
-	if err := status.Err(); err != nil {
+	// The index of the customer lives as long as their longest cached order
+	ttl := r.defaultTTL.Load()
+	indexKey := customerOrdersKey(order.TenantID, order.CustomerID)
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, ttl)
+		pipe.SAdd(ctx, indexKey, order.ID)
+		if ttl > 0 {
+			pipe.ExpireNX(ctx, indexKey, ttl)
+			pipe.ExpireGT(ctx, indexKey, ttl)
+		}
+		return nil
+	})
+	if err != nil {
 		return &repositories.RepositoryError{
 			StatusCode: http.StatusInternalServerError,
 			Cause:      "failed to set order in cache",
@@ -85,19 +140,228 @@ func (r *CacheRepository) SetOrder(ctx context.Context, order *models.Order) *re
 	return nil
 }
 
+// GetOrderSummary returns the cached compact projection of an order, or nil
+// when it is not cached.
+func (r *CacheRepository) GetOrderSummary(ctx context.Context, orderID string) (*models.OrderSummary, *repositories.RepositoryError) {
+	defer timing.Since(ctx, timing.Cache, time.Now())
+
+	data, err := r.client.Get(ctx, summaryKey(tenant.ID(ctx), orderID)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to get order summary from cache",
+			Message:    err.Error(),
+		}
+	}
+
+	var summary models.OrderSummary
+	if err := codec.Unmarshal(data, &summary); err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to unmarshal order summary",
+			Message:    fmt.Sprintf("Failed to unmarshal summary of order with ID %s", orderID),
+		}
+	}
+
+	return &summary, nil
+}
+
+// SetOrderSummary caches the compact projection of an order.
+func (r *CacheRepository) SetOrderSummary(ctx context.Context, summary *models.OrderSummary) *repositories.RepositoryError {
+	defer timing.Since(ctx, timing.Cache, time.Now())
+
+	data, err := codec.Marshal(summary)
+	if err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to marshal order summary",
+			Message:    fmt.Sprintf("Failed to marshal summary of order with ID %s", summary.ID),
+		}
+	}
+
+	if err := r.client.Set(ctx, summaryKey(summary.TenantID, summary.ID), data, r.defaultTTL.Load()).Err(); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to set order summary in cache",
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+// GetOrderStats returns the cached KPIs of the tenant of the context, or nil
+// when they are not cached.
+func (r *CacheRepository) GetOrderStats(ctx context.Context) (*models.OrderStats, *repositories.RepositoryError) {
+	defer timing.Since(ctx, timing.Cache, time.Now())
+
+	data, err := r.client.Get(ctx, tenantStatsKey(tenant.ID(ctx))).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to get order stats from cache",
+			Message:    err.Error(),
+		}
+	}
+
+	var stats models.OrderStats
+	if err := codec.Unmarshal(data, &stats); err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to unmarshal order stats",
+			Message:    "Failed to unmarshal order stats",
+		}
+	}
+
+	return &stats, nil
+}
+
+// SetOrderStats caches the KPIs of the tenant of the context for ttl. They are
+// not invalidated on writes, so ttl is kept short.
+func (r *CacheRepository) SetOrderStats(ctx context.Context, stats *models.OrderStats, ttl time.Duration) *repositories.RepositoryError {
+	defer timing.Since(ctx, timing.Cache, time.Now())
+
+	data, err := codec.Marshal(stats)
+	if err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to marshal order stats",
+			Message:    "Failed to marshal order stats",
+		}
+	}
+
+	if err := r.client.Set(ctx, tenantStatsKey(tenant.ID(ctx)), data, ttl).Err(); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to set order stats in cache",
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+// InvalidateOrder drops both the full order and its summary from the cache,
+// and removes the order from the index of its customer.
 func (r *CacheRepository) InvalidateOrder(ctx context.Context, orderID string) *repositories.RepositoryError {
-	key := r.orderKey(orderID)
-	if err := r.client.Del(ctx, key).Err(); err != nil {
+	defer timing.Since(ctx, timing.Cache, time.Now())
+
+	tenantID := tenant.ID(ctx)
+	key := orderKey(tenantID, orderID)
+
+	// The customer is only known from the cached order itself
+	var cached struct {
+		CustomerID string `json:"customerId"`
+	}
+	data, err := r.client.Get(ctx, key).Bytes()
+	if err != nil && err != redis.Nil {
 		return &repositories.RepositoryError{
 			StatusCode: http.StatusInternalServerError,
 			Cause:      "failed to delete order from cache",
 			Message:    err.Error(),
 		}
 	}
+	if err == nil {
+		// An unreadable order is dropped all the same
+		if data, err := decompress(data); err == nil {
+			_ = codec.Unmarshal(data, &cached)
+		}
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key, summaryKey(tenantID, orderID))
+		if cached.CustomerID != "" {
+			pipe.SRem(ctx, customerOrdersKey(tenantID, cached.CustomerID), orderID)
+		}
+		return nil
+	})
+	if err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to delete order from cache",
+			Message:    err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// InvalidateCustomer drops every cached order of the customer, and their
+// summaries, e.g. after the customer is merged into another one. The orders
+// are found through the index of the customer rather than a scan of the
+// whole keyspace.
+func (r *CacheRepository) InvalidateCustomer(ctx context.Context, customerID string) *repositories.RepositoryError {
+	defer timing.Since(ctx, timing.Cache, time.Now())
+
+	tenantID := tenant.ID(ctx)
+	indexKey := customerOrdersKey(tenantID, customerID)
+
+	var cursor uint64
+	for {
+		orderIDs, next, err := r.client.SScan(ctx, indexKey, cursor, "", invalidateBatchSize).Result()
+		if err != nil {
+			return &repositories.RepositoryError{
+				StatusCode: http.StatusInternalServerError,
+				Cause:      "failed to scan cached orders of customer",
+				Message:    err.Error(),
+			}
+		}
+
+		if len(orderIDs) > 0 {
+			keys := make([]string, 0, 2*len(orderIDs))
+			for _, orderID := range orderIDs {
+				keys = append(keys, orderKey(tenantID, orderID), summaryKey(tenantID, orderID))
+			}
+			if err := r.client.Del(ctx, keys...).Err(); err != nil {
+				return &repositories.RepositoryError{
+					StatusCode: http.StatusInternalServerError,
+					Cause:      "failed to delete orders of customer from cache",
+					Message:    err.Error(),
+				}
+			}
+		}
+
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
 
+	// An order cached while scanning may be missed; it is left to expire
+	if err := r.client.Del(ctx, indexKey).Err(); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to delete orders of customer from cache",
+			Message:    err.Error(),
+		}
+	}
 	return nil
 }
 
+// ScanOrders walks the cached order keys starting at the given cursor and
+// returns the orders found along with the cursor to resume from. A returned
+// cursor of zero means the whole keyspace has been visited.
+func (r *CacheRepository) ScanOrders(ctx context.Context, cursor uint64, count int64) ([]repositories.OrderRef, uint64, *repositories.RepositoryError) {
+	keys, next, err := r.client.Scan(ctx, cursor, orderKeyPrefix+"*", count).Result()
+	if err != nil {
+		return nil, 0, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to scan cached orders",
+			Message:    err.Error(),
+		}
+	}
+
+	refs := make([]repositories.OrderRef, 0, len(keys))
+	for _, key := range keys {
+		refs = append(refs, orderRef(key))
+	}
+	return refs, next, nil
+}
+
 func (r *CacheRepository) Ping(ctx context.Context) *repositories.RepositoryError {
 	if err := r.client.Ping(ctx).Err(); err != nil {
 		return &repositories.RepositoryError{
@@ -109,6 +373,39 @@ func (r *CacheRepository) Ping(ctx context.Context) *repositories.RepositoryErro
 	return nil
 }
 
-func (r *CacheRepository) orderKey(orderID string) string {
-	return fmt.Sprintf("%s%s", orderKeyPrefix, orderID)
+func orderKey(tenantID, orderID string) string {
+	return scopedKey(orderKeyPrefix, tenantID, orderID)
+}
+
+func summaryKey(tenantID, orderID string) string {
+	return scopedKey(summaryKeyPrefix, tenantID, orderID)
+}
+
+func customerOrdersKey(tenantID, customerID string) string {
+	return scopedKey(customerOrdersKeyPrefix, tenantID, customerID)
+}
+
+func tenantStatsKey(tenantID string) string {
+	if tenantID == "" {
+		return statsKey
+	}
+	return statsKey + ":" + tenantID
+}
+
+// scopedKey builds the key of an order of a tenant. Orders without a tenant,
+// cached before tenants were introduced, keep the unscoped key.
+func scopedKey(prefix, tenantID, orderID string) string {
+	if tenantID == "" {
+		return prefix + orderID
+	}
+	return fmt.Sprintf("%s%s:%s", prefix, tenantID, orderID)
+}
+
+// orderRef parses an order key built by orderKey.
+func orderRef(key string) repositories.OrderRef {
+	scoped := strings.TrimPrefix(key, orderKeyPrefix)
+	if tenantID, orderID, ok := strings.Cut(scoped, ":"); ok {
+		return repositories.OrderRef{TenantID: tenantID, OrderID: orderID}
+	}
+	return repositories.OrderRef{OrderID: scoped}
 }
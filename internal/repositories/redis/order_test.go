@@ -0,0 +1,209 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+	redisrepo "orders/internal/repositories/redis"
+	"orders/internal/tenant"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheRepository(t *testing.T) (*redisrepo.CacheRepository, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return redisrepo.NewCacheRepository(client, time.Minute), server
+}
+
+func TestCacheRepository_OrderSummary(t *testing.T) {
+	repo, server := newCacheRepository(t)
+	ctx := context.Background()
+
+	order := &models.Order{
+		ID:          "order-123",
+		CustomerID:  "customer-1",
+		Status:      models.StatusNew,
+		TotalAmount: 42,
+		Version:     2,
+		Items:       []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 42}},
+	}
+
+	require.Nil(t, repo.SetOrderSummary(ctx, order.Summary()))
+
+	// Only the compact form is stored, under its own key.
+	assert.False(t, server.Exists("order:order-123"))
+	stored, err := server.Get("order-summary:order-123")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"orderId":"order-123","customerId":"customer-1","status":"NEW","totalAmount":42,"version":2}`, stored)
+
+	summary, repoErr := repo.GetOrderSummary(ctx, "order-123")
+	require.Nil(t, repoErr)
+	assert.Equal(t, order.Summary(), summary)
+
+	// Summaries are not reported as cached orders.
+	refs, _, repoErr := repo.ScanOrders(ctx, 0, 10)
+	require.Nil(t, repoErr)
+	assert.Empty(t, refs)
+
+	require.Nil(t, repo.SetOrder(ctx, order))
+	require.Nil(t, repo.InvalidateOrder(ctx, "order-123"))
+	assert.False(t, server.Exists("order:order-123"))
+	assert.False(t, server.Exists("order-summary:order-123"))
+
+	summary, repoErr = repo.GetOrderSummary(ctx, "order-123")
+	assert.Nil(t, repoErr)
+	assert.Nil(t, summary)
+}
+
+func TestCacheRepository_InvalidateCustomer(t *testing.T) {
+	repo, server := newCacheRepository(t)
+	ctx := context.Background()
+	brandB := tenant.WithID(ctx, "brand-b")
+
+	// 250 pedidos obligan a recorrer el índice en varios lotes
+	for i := 0; i < 250; i++ {
+		order := &models.Order{ID: fmt.Sprintf("order-%03d", i), CustomerID: "customer-1", Status: models.StatusNew}
+		require.Nil(t, repo.SetOrder(ctx, order))
+		require.Nil(t, repo.SetOrderSummary(ctx, order.Summary()))
+	}
+	require.Nil(t, repo.SetOrder(ctx, &models.Order{ID: "order-other", CustomerID: "customer-2", Status: models.StatusNew}))
+	require.Nil(t, repo.SetOrder(brandB, &models.Order{ID: "order-b", TenantID: "brand-b", CustomerID: "customer-1", Status: models.StatusNew}))
+
+	members, err := server.SMembers("customer:orders:customer-1")
+	require.NoError(t, err)
+	assert.Len(t, members, 250)
+	assert.Equal(t, time.Minute, server.TTL("customer:orders:customer-1"))
+
+	// Invalidar un pedido lo saca del índice de su cliente
+	require.Nil(t, repo.InvalidateOrder(ctx, "order-000"))
+	members, err = server.SMembers("customer:orders:customer-1")
+	require.NoError(t, err)
+	assert.Len(t, members, 249)
+	assert.NotContains(t, members, "order-000")
+
+	require.Nil(t, repo.InvalidateCustomer(ctx, "customer-1"))
+
+	for _, key := range server.Keys() {
+		assert.NotRegexp(t, `^order(-summary)?:order-\d+$`, key)
+	}
+	assert.False(t, server.Exists("customer:orders:customer-1"))
+	// Los pedidos de otros clientes y de otros tenants siguen en caché
+	assert.True(t, server.Exists("order:order-other"))
+	assert.True(t, server.Exists("order:brand-b:order-b"))
+	assert.True(t, server.Exists("customer:orders:brand-b:customer-1"))
+}
+
+func TestCacheRepository_TenantIsolation(t *testing.T) {
+	repo, server := newCacheRepository(t)
+	tenantA := tenant.WithID(context.Background(), "brand-a")
+	tenantB := tenant.WithID(context.Background(), "brand-b")
+
+	order := &models.Order{ID: "order-123", TenantID: "brand-a", CustomerID: "customer-1", Status: models.StatusNew, Version: 1}
+	require.Nil(t, repo.SetOrder(tenantA, order))
+	require.Nil(t, repo.SetOrderSummary(tenantA, order.Summary()))
+	assert.True(t, server.Exists("order:brand-a:order-123"))
+	assert.True(t, server.Exists("order-summary:brand-a:order-123"))
+
+	// Otro tenant no ve el pedido ni su resumen
+	cached, repoErr := repo.GetOrder(tenantB, "order-123")
+	require.Nil(t, repoErr)
+	assert.Nil(t, cached)
+	summary, repoErr := repo.GetOrderSummary(tenantB, "order-123")
+	require.Nil(t, repoErr)
+	assert.Nil(t, summary)
+
+	// Ni puede invalidarlo
+	require.Nil(t, repo.InvalidateOrder(tenantB, "order-123"))
+	cached, repoErr = repo.GetOrder(tenantA, "order-123")
+	require.Nil(t, repoErr)
+	assert.Equal(t, order.ID, cached.ID)
+
+	refs, _, repoErr := repo.ScanOrders(context.Background(), 0, 10)
+	require.Nil(t, repoErr)
+	assert.Equal(t, []repositories.OrderRef{{TenantID: "brand-a", OrderID: "order-123"}}, refs)
+
+	require.Nil(t, repo.InvalidateOrder(tenantA, "order-123"))
+	assert.False(t, server.Exists("order:brand-a:order-123"))
+	assert.False(t, server.Exists("order-summary:brand-a:order-123"))
+}
+
+func TestCacheRepository_OrderStats(t *testing.T) {
+	repo, server := newCacheRepository(t)
+	ctx := tenant.WithID(context.Background(), "brand-a")
+
+	stats, repoErr := repo.GetOrderStats(ctx)
+	require.Nil(t, repoErr)
+	assert.Nil(t, stats)
+
+	expected := &models.OrderStats{
+		OrdersByStatus:    map[models.OrderStatus]int64{models.StatusNew: 3},
+		TotalOrders:       3,
+		AverageOrderValue: 42,
+		OrdersLast24h:     1,
+		GeneratedAt:       time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+	}
+	require.Nil(t, repo.SetOrderStats(ctx, expected, 30*time.Second))
+	assert.Equal(t, 30*time.Second, server.TTL("order-stats:brand-a"))
+
+	stats, repoErr = repo.GetOrderStats(ctx)
+	require.Nil(t, repoErr)
+	assert.Equal(t, expected, stats)
+
+	// Cada tenant tiene sus propias estadísticas
+	stats, repoErr = repo.GetOrderStats(tenant.WithID(context.Background(), "brand-b"))
+	require.Nil(t, repoErr)
+	assert.Nil(t, stats)
+}
+
+func TestCacheRepository_Compression(t *testing.T) {
+	repo, server := newCacheRepository(t)
+	ctx := context.Background()
+	repo.SetCompression(true, 1024)
+
+	// Un pedido grande supera el umbral y se guarda comprimido
+	large := &models.Order{ID: "order-large", CustomerID: "customer-1", Status: models.StatusNew, Version: 1}
+	for i := 0; i < 200; i++ {
+		large.Items = append(large.Items, models.OrderItem{SKU: fmt.Sprintf("SKU-%03d", i), Quantity: 1, Price: 9.99})
+	}
+	require.Nil(t, repo.SetOrder(ctx, large))
+
+	raw, err := server.Get("order:order-large")
+	require.NoError(t, err)
+	assert.Equal(t, "\x1f\x8b", raw[:2])
+
+	cached, repoErr := repo.GetOrder(ctx, "order-large")
+	require.Nil(t, repoErr)
+	assert.Equal(t, large.Items, cached.Items)
+	assert.Equal(t, large.Version, cached.Version)
+
+	// Los pedidos pequeños se guardan sin comprimir
+	small := &models.Order{ID: "order-small", CustomerID: "customer-1", Status: models.StatusNew}
+	require.Nil(t, repo.SetOrder(ctx, small))
+	raw, err = server.Get("order:order-small")
+	require.NoError(t, err)
+	assert.Equal(t, "{", raw[:1])
+
+	// Los pedidos guardados sin compresión se siguen leyendo
+	repo.SetCompression(false, 0)
+	require.Nil(t, repo.SetOrder(ctx, large))
+	repo.SetCompression(true, 0)
+	cached, repoErr = repo.GetOrder(ctx, "order-large")
+	require.Nil(t, repoErr)
+	assert.Len(t, cached.Items, 200)
+
+	// La invalidación también lee el cliente de un pedido comprimido
+	require.Nil(t, repo.SetOrder(ctx, large))
+	require.Nil(t, repo.InvalidateOrder(ctx, "order-large"))
+	members, err := server.Members("customer:orders:customer-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"order-small"}, members)
+}
@@ -0,0 +1,105 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"orders/internal/codec"
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	priceKeyPrefix = "price:"
+)
+
+// PriceCacheRepository caches catalog prices per SKU for a short period.
+type PriceCacheRepository struct {
+	client *redis.Client
+	ttl    expiration
+}
+
+func NewPriceCacheRepository(client *redis.Client, ttl time.Duration) *PriceCacheRepository {
+	r := &PriceCacheRepository{client: client}
+	r.ttl.Store(ttl)
+	return r
+}
+
+// SetTTL changes the TTL of the entries cached from now on.
+func (r *PriceCacheRepository) SetTTL(ttl time.Duration) {
+	r.ttl.Store(ttl)
+}
+
+// GetPrices returns the cached prices for the given SKUs. SKUs missing from the
+// cache are simply absent from the returned map.
+func (r *PriceCacheRepository) GetPrices(ctx context.Context, skus []string) (map[string]models.ItemPrice, *repositories.RepositoryError) {
+	prices := make(map[string]models.ItemPrice, len(skus))
+	if len(skus) == 0 {
+		return prices, nil
+	}
+
+	keys := make([]string, len(skus))
+	for i, sku := range skus {
+		keys[i] = r.priceKey(sku)
+	}
+
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to get prices from cache",
+			Message:    err.Error(),
+		}
+	}
+
+	for _, value := range values {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var price models.ItemPrice
+		if err := codec.Unmarshal([]byte(raw), &price); err != nil {
+			continue
+		}
+		prices[price.SKU] = price
+	}
+
+	return prices, nil
+}
+
+// SetPrices stores the given prices using the configured TTL.
+func (r *PriceCacheRepository) SetPrices(ctx context.Context, prices []models.ItemPrice) *repositories.RepositoryError {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	for _, price := range prices {
+		data, err := codec.Marshal(price)
+		if err != nil {
+			return &repositories.RepositoryError{
+				StatusCode: http.StatusInternalServerError,
+				Cause:      "failed to marshal price",
+				Message:    fmt.Sprintf("Failed to marshal price for SKU %s", price.SKU),
+			}
+		}
+		pipe.Set(ctx, r.priceKey(price.SKU), data, r.ttl.Load())
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to set prices in cache",
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+func (r *PriceCacheRepository) priceKey(sku string) string {
+	return fmt.Sprintf("%s%s", priceKeyPrefix, sku)
+}
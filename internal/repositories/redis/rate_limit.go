@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"orders/internal/repositories"
+	"orders/internal/tenant"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	creationRateKeyPrefix = "orders:rate:customer:"
+)
+
+// reserveScript is a leaky bucket kept as the time the bucket drains, in
+// milliseconds of the Redis server clock. Each creation adds one interval;
+// up to burst creations fit at once. A creation that would overflow the
+// bucket by at most ARGV[3] ms is accepted and told to wait that long, any
+// other is rejected without touching the bucket. It returns whether the
+// creation was accepted and the wait in milliseconds.
+var reserveScript = redis.NewScript(`
+local now = redis.call('TIME')
+local ms = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local maxDelay = tonumber(ARGV[3])
+local drained = tonumber(redis.call('GET', KEYS[1])) or ms
+if drained < ms then
+	drained = ms
+end
+local wait = drained + interval - burst * interval - ms
+if wait > maxDelay then
+	return {0, wait}
+end
+redis.call('SET', KEYS[1], drained + interval, 'PX', drained + interval - ms)
+if wait < 0 then
+	wait = 0
+end
+return {1, wait}
+`)
+
+// CreationRateRepository limits the sustained rate at which each customer
+// creates orders, shared by every API instance.
+type CreationRateRepository struct {
+	client   *redis.Client
+	interval time.Duration
+	burst    int
+	maxDelay time.Duration
+}
+
+// NewCreationRateRepository allows perMinute creations per customer on
+// average, with bursts of up to burst creations. Creations over the rate are
+// delayed up to maxDelay; zero rejects them straight away.
+func NewCreationRateRepository(client *redis.Client, perMinute, burst int, maxDelay time.Duration) *CreationRateRepository {
+	return &CreationRateRepository{
+		client:   client,
+		interval: time.Minute / time.Duration(perMinute),
+		burst:    burst,
+		maxDelay: maxDelay,
+	}
+}
+
+// Reserve takes a slot for an order of the customer. When allowed, the
+// creation must wait the returned time first; when not, it is the time until
+// a slot frees up.
+func (r *CreationRateRepository) Reserve(ctx context.Context, customerID string) (bool, time.Duration, *repositories.RepositoryError) {
+	key := creationRateKeyPrefix + customerID
+	if tenantID := tenant.ID(ctx); tenantID != "" {
+		key = creationRateKeyPrefix + tenantID + ":" + customerID
+	}
+
+	result, err := reserveScript.Run(ctx, r.client, []string{key},
+		r.interval.Milliseconds(), r.burst, r.maxDelay.Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to reserve order creation",
+			Message:    err.Error(),
+		}
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
@@ -0,0 +1,60 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redisrepo "orders/internal/repositories/redis"
+	"orders/internal/tenant"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreationRateRepository(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	server.SetTime(now)
+
+	// reserve pide un hueco y comprueba si se concede y cuánto hay que esperar
+	reserve := func(repo *redisrepo.CreationRateRepository, ctx context.Context, customerID string, allowed bool, wait time.Duration) {
+		t.Helper()
+		ok, gotWait, repoErr := repo.Reserve(ctx, customerID)
+		require.Nil(t, repoErr)
+		assert.Equal(t, allowed, ok)
+		assert.Equal(t, wait, gotWait)
+	}
+
+	t.Run("Rejects over the rate", func(t *testing.T) {
+		// 6 por minuto: un hueco cada 10s, con ráfagas de 2
+		repo := redisrepo.NewCreationRateRepository(client, 6, 2, 0)
+
+		reserve(repo, ctx, "customer-1", true, 0)
+		reserve(repo, ctx, "customer-1", true, 0)
+		reserve(repo, ctx, "customer-1", false, 10*time.Second)
+		// Otros clientes y tenants tienen su propio cubo
+		reserve(repo, ctx, "customer-2", true, 0)
+		reserve(repo, tenant.WithID(ctx, "brand-b"), "customer-1", true, 0)
+
+		server.SetTime(now.Add(10 * time.Second))
+		reserve(repo, ctx, "customer-1", true, 0)
+		reserve(repo, ctx, "customer-1", false, 10*time.Second)
+	})
+
+	t.Run("Delays up to the maximum", func(t *testing.T) {
+		server.FlushAll()
+		server.SetTime(now)
+		repo := redisrepo.NewCreationRateRepository(client, 6, 1, 15*time.Second)
+
+		reserve(repo, ctx, "customer-1", true, 0)
+		reserve(repo, ctx, "customer-1", true, 10*time.Second)
+		reserve(repo, ctx, "customer-1", false, 20*time.Second)
+		assert.Equal(t, 20*time.Second, server.TTL("orders:rate:customer:customer-1"))
+	})
+}
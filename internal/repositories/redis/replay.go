@@ -0,0 +1,170 @@
+package redis
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"orders/internal/codec"
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	replayLockKey         = "events:replay:lock"
+	replayJobKeyPrefix    = "events:replay:job:"
+	replayCancelKeyPrefix = "events:replay:cancel:"
+
+	// replayJobTTL is how long replay jobs can be looked up once started.
+	replayJobTTL = 7 * 24 * time.Hour
+)
+
+// extendLockScript extends the lock only while it is held by ARGV[1].
+var extendLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLockScript releases the lock only while it is held by ARGV[1].
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// ReplayJobRepository keeps event replay jobs and the lock that lets a single
+// replay run at a time across API instances. Jobs are kept in Redis, so
+// their progress can be followed and cancelled from any instance.
+type ReplayJobRepository struct {
+	client *redis.Client
+}
+
+func NewReplayJobRepository(client *redis.Client) *ReplayJobRepository {
+	return &ReplayJobRepository{client: client}
+}
+
+// Lock takes the replay lock for the job for ttl. It reports false, along
+// with the job holding it, when another replay holds the lock.
+func (r *ReplayJobRepository) Lock(ctx context.Context, jobID string, ttl time.Duration) (bool, string, *repositories.RepositoryError) {
+	acquired, err := r.client.SetNX(ctx, replayLockKey, jobID, ttl).Result()
+	if err != nil {
+		return false, "", &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to take replay lock",
+			Message:    err.Error(),
+		}
+	}
+	if acquired {
+		return true, jobID, nil
+	}
+
+	holder, err := r.client.Get(ctx, replayLockKey).Result()
+	if err != nil && err != redis.Nil {
+		return false, "", &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to get replay lock",
+			Message:    err.Error(),
+		}
+	}
+	return false, holder, nil
+}
+
+// ExtendLock keeps the lock of the job for ttl more. It reports false when
+// the job no longer holds it.
+func (r *ReplayJobRepository) ExtendLock(ctx context.Context, jobID string, ttl time.Duration) (bool, *repositories.RepositoryError) {
+	extended, err := extendLockScript.Run(ctx, r.client, []string{replayLockKey}, jobID, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to extend replay lock",
+			Message:    err.Error(),
+		}
+	}
+	return extended == 1, nil
+}
+
+// Unlock releases the lock when it is held by the job.
+func (r *ReplayJobRepository) Unlock(ctx context.Context, jobID string) *repositories.RepositoryError {
+	if err := releaseLockScript.Run(ctx, r.client, []string{replayLockKey}, jobID).Err(); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to release replay lock",
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+// SaveJob stores the current state of the job.
+func (r *ReplayJobRepository) SaveJob(ctx context.Context, job *models.ReplayJob) *repositories.RepositoryError {
+	data, err := codec.Marshal(job)
+	if err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to marshal replay job",
+			Message:    err.Error(),
+		}
+	}
+	if err := r.client.Set(ctx, replayJobKeyPrefix+job.ID, data, replayJobTTL).Err(); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to save replay job",
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+// GetJob returns the job along with whether its cancellation was requested.
+func (r *ReplayJobRepository) GetJob(ctx context.Context, jobID string) (*models.ReplayJob, *repositories.RepositoryError) {
+	var get *redis.StringCmd
+	var cancelled *redis.IntCmd
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, replayJobKeyPrefix+jobID)
+		cancelled = pipe.Exists(ctx, replayCancelKeyPrefix+jobID)
+		return nil
+	})
+	if err == redis.Nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusNotFound,
+			Cause:      "replay job not found",
+			Message:    "Replay job not found",
+		}
+	}
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to get replay job",
+			Message:    err.Error(),
+		}
+	}
+
+	var job models.ReplayJob
+	if err := codec.Unmarshal([]byte(get.Val()), &job); err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to unmarshal replay job",
+			Message:    err.Error(),
+		}
+	}
+	job.CancelRequested = !job.Finished() && cancelled.Val() > 0
+	return &job, nil
+}
+
+// RequestCancel asks the job to stop. The instance running it notices the
+// request the next time it reports progress.
+func (r *ReplayJobRepository) RequestCancel(ctx context.Context, jobID string) *repositories.RepositoryError {
+	if err := r.client.Set(ctx, replayCancelKeyPrefix+jobID, 1, replayJobTTL).Err(); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to cancel replay job",
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package redis_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"orders/internal/models"
+	redisrepo "orders/internal/repositories/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayJobRepository_Lock(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	repo := redisrepo.NewReplayJobRepository(client)
+	ctx := context.Background()
+
+	locked, holder, repoErr := repo.Lock(ctx, "job-1", time.Minute)
+	require.Nil(t, repoErr)
+	assert.True(t, locked)
+	assert.Equal(t, "job-1", holder)
+
+	// Un segundo replay no entra mientras el primero tiene el lock
+	locked, holder, repoErr = repo.Lock(ctx, "job-2", time.Minute)
+	require.Nil(t, repoErr)
+	assert.False(t, locked)
+	assert.Equal(t, "job-1", holder)
+
+	// Solo quien tiene el lock puede extenderlo o soltarlo
+	extended, repoErr := repo.ExtendLock(ctx, "job-2", time.Minute)
+	require.Nil(t, repoErr)
+	assert.False(t, extended)
+	require.Nil(t, repo.Unlock(ctx, "job-2"))
+	assert.True(t, server.Exists("events:replay:lock"))
+
+	server.FastForward(30 * time.Second)
+	extended, repoErr = repo.ExtendLock(ctx, "job-1", time.Minute)
+	require.Nil(t, repoErr)
+	assert.True(t, extended)
+	assert.Equal(t, time.Minute, server.TTL("events:replay:lock"))
+
+	require.Nil(t, repo.Unlock(ctx, "job-1"))
+	locked, _, repoErr = repo.Lock(ctx, "job-2", time.Minute)
+	require.Nil(t, repoErr)
+	assert.True(t, locked)
+
+	// Un lock no renovado caduca
+	server.FastForward(time.Minute)
+	locked, _, repoErr = repo.Lock(ctx, "job-3", time.Minute)
+	require.Nil(t, repoErr)
+	assert.True(t, locked)
+}
+
+func TestReplayJobRepository_Jobs(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	repo := redisrepo.NewReplayJobRepository(client)
+	ctx := context.Background()
+
+	_, repoErr := repo.GetJob(ctx, "job-1")
+	require.NotNil(t, repoErr)
+	assert.Equal(t, http.StatusNotFound, repoErr.StatusCode)
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	job := &models.ReplayJob{
+		ID:        "job-1",
+		Status:    models.ReplayRunning,
+		From:      from,
+		To:        from.Add(48 * time.Hour),
+		Types:     []models.EventType{models.EventOrderCreated},
+		Total:     10,
+		Replayed:  4,
+		StartedAt: from,
+		UpdatedAt: from,
+	}
+	require.Nil(t, repo.SaveJob(ctx, job))
+
+	stored, repoErr := repo.GetJob(ctx, "job-1")
+	require.Nil(t, repoErr)
+	assert.Equal(t, job, stored)
+
+	require.Nil(t, repo.RequestCancel(ctx, "job-1"))
+	stored, repoErr = repo.GetJob(ctx, "job-1")
+	require.Nil(t, repoErr)
+	assert.True(t, stored.CancelRequested)
+
+	// Un trabajo terminado ya no tiene una cancelación pendiente
+	job.Status = models.ReplayCancelled
+	require.Nil(t, repo.SaveJob(ctx, job))
+	stored, repoErr = repo.GetJob(ctx, "job-1")
+	require.Nil(t, repoErr)
+	assert.False(t, stored.CancelRequested)
+}
@@ -0,0 +1,93 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"orders/internal/repositories"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	skuKeyPrefix = "sku:exists:"
+)
+
+// SKUCacheRepository caches the outcome of catalog SKU existence checks.
+type SKUCacheRepository struct {
+	client *redis.Client
+	ttl    expiration
+}
+
+func NewSKUCacheRepository(client *redis.Client, ttl time.Duration) *SKUCacheRepository {
+	r := &SKUCacheRepository{client: client}
+	r.ttl.Store(ttl)
+	return r
+}
+
+// SetTTL changes the TTL of the entries cached from now on.
+func (r *SKUCacheRepository) SetTTL(ttl time.Duration) {
+	r.ttl.Store(ttl)
+}
+
+// GetExists returns the cached existence flags of the given SKUs. SKUs
+// missing from the cache are absent from the returned map.
+func (r *SKUCacheRepository) GetExists(ctx context.Context, skus []string) (map[string]bool, *repositories.RepositoryError) {
+	exists := make(map[string]bool, len(skus))
+	if len(skus) == 0 {
+		return exists, nil
+	}
+
+	keys := make([]string, len(skus))
+	for i, sku := range skus {
+		keys[i] = r.skuKey(sku)
+	}
+
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to get SKUs from cache",
+			Message:    err.Error(),
+		}
+	}
+
+	for i, value := range values {
+		if raw, ok := value.(string); ok {
+			exists[skus[i]] = raw == "1"
+		}
+	}
+
+	return exists, nil
+}
+
+// SetExists caches the existence flags of the given SKUs using the configured TTL.
+func (r *SKUCacheRepository) SetExists(ctx context.Context, exists map[string]bool) *repositories.RepositoryError {
+	if len(exists) == 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	for sku, found := range exists {
+		value := "0"
+		if found {
+			value = "1"
+		}
+		pipe.Set(ctx, r.skuKey(sku), value, r.ttl.Load())
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to set SKUs in cache",
+			Message:    err.Error(),
+		}
+	}
+	return nil
+}
+
+func (r *SKUCacheRepository) skuKey(sku string) string {
+	return fmt.Sprintf("%s%s", skuKeyPrefix, sku)
+}
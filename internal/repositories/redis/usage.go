@@ -0,0 +1,137 @@
+package redis
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	usageKeyPrefix   = "orders:usage:"
+	usageDayFormat   = "2006-01-02"
+	usageMonthFormat = "2006-01"
+)
+
+// consumeScript counts a request of a client on the day (KEYS[1]), the month
+// (KEYS[2]) and the route (ARGV[3] of the KEYS[3] hash), unless the day or
+// month already reached its quota (ARGV[1] and ARGV[2], 0 for none). Every
+// counter expires ARGV[4] seconds after its last request. It returns 0 when
+// counted, 1 when the daily quota is exhausted and 2 when the monthly one is.
+var consumeScript = redis.NewScript(`
+local daily = tonumber(ARGV[1])
+local monthly = tonumber(ARGV[2])
+if daily > 0 and (tonumber(redis.call('GET', KEYS[1])) or 0) >= daily then
+	return 1
+end
+if monthly > 0 and (tonumber(redis.call('GET', KEYS[2])) or 0) >= monthly then
+	return 2
+end
+local ttl = tonumber(ARGV[4])
+redis.call('INCR', KEYS[1])
+redis.call('EXPIRE', KEYS[1], ttl)
+redis.call('INCR', KEYS[2])
+redis.call('EXPIRE', KEYS[2], ttl)
+redis.call('HINCRBY', KEYS[3], ARGV[3], 1)
+redis.call('EXPIRE', KEYS[3], ttl)
+return 0
+`)
+
+// UsageRepository counts the requests of each API client per day, month and
+// route, shared by every API instance, and enforces their quotas.
+type UsageRepository struct {
+	client    *redis.Client
+	retention time.Duration
+}
+
+// NewUsageRepository keeps the counters for retention after their last
+// request, so usage can be reported for billing.
+func NewUsageRepository(client *redis.Client, retention time.Duration) *UsageRepository {
+	return &UsageRepository{
+		client:    client,
+		retention: retention,
+	}
+}
+
+// Consume counts a request of the client to the route at now, unless it is
+// over its quota. It returns the exhausted quota period, models.QuotaDaily or
+// models.QuotaMonthly, or "" when the request was counted.
+func (r *UsageRepository) Consume(ctx context.Context, client, route string, now time.Time, quota models.Quota) (string, *repositories.RepositoryError) {
+	now = now.UTC()
+	day := usageKey(client, now.Format(usageDayFormat))
+	keys := []string{day, usageKey(client, now.Format(usageMonthFormat)), day + ":routes"}
+
+	result, err := consumeScript.Run(ctx, r.client, keys,
+		quota.Daily, quota.Monthly, route, int64(r.retention.Seconds())).Int64()
+	if err != nil {
+		return "", &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to count request",
+			Message:    err.Error(),
+		}
+	}
+
+	switch result {
+	case 1:
+		return models.QuotaDaily, nil
+	case 2:
+		return models.QuotaMonthly, nil
+	default:
+		return "", nil
+	}
+}
+
+// Usage returns the requests of the client on each day from from to to,
+// inclusive, in UTC.
+func (r *UsageRepository) Usage(ctx context.Context, client string, from, to time.Time) (*models.ClientUsage, *repositories.RepositoryError) {
+	var dates []string
+	for day := truncateDay(from); !day.After(truncateDay(to)); day = day.AddDate(0, 0, 1) {
+		dates = append(dates, day.Format(usageDayFormat))
+	}
+
+	pipe := r.client.Pipeline()
+	counts := make([]*redis.StringCmd, len(dates))
+	routes := make([]*redis.MapStringStringCmd, len(dates))
+	for i, date := range dates {
+		counts[i] = pipe.Get(ctx, usageKey(client, date))
+		routes[i] = pipe.HGetAll(ctx, usageKey(client, date)+":routes")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Cause:      "failed to read client usage",
+			Message:    err.Error(),
+		}
+	}
+
+	usage := &models.ClientUsage{Client: client, Days: []models.DailyUsage{}}
+	for i, date := range dates {
+		requests, err := counts[i].Int64()
+		if err != nil {
+			continue
+		}
+		day := models.DailyUsage{Date: date, Requests: requests, Routes: make(map[string]int64)}
+		for route, count := range routes[i].Val() {
+			if n, err := strconv.ParseInt(count, 10, 64); err == nil {
+				day.Routes[route] = n
+			}
+		}
+		usage.Requests += requests
+		usage.Days = append(usage.Days, day)
+	}
+	return usage, nil
+}
+
+func usageKey(client, period string) string {
+	return usageKeyPrefix + client + ":" + period
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,72 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"orders/internal/models"
+	redisrepo "orders/internal/repositories/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageRepository(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+	repo := redisrepo.NewUsageRepository(client, 400*24*time.Hour)
+	day := time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC)
+
+	// consume cuenta una petición y devuelve la cuota agotada, si la hay
+	consume := func(now time.Time, route string, quota models.Quota) string {
+		t.Helper()
+		exhausted, repoErr := repo.Consume(ctx, "partner", route, now, quota)
+		require.Nil(t, repoErr)
+		return exhausted
+	}
+
+	t.Run("Enforces the daily quota", func(t *testing.T) {
+		quota := models.Quota{Daily: 2}
+		assert.Empty(t, consume(day, "GET /api/orders", quota))
+		assert.Empty(t, consume(day, "POST /api/orders", quota))
+		assert.Equal(t, models.QuotaDaily, consume(day, "GET /api/orders", quota))
+
+		// Al día siguiente la cuota diaria se renueva
+		assert.Empty(t, consume(day.Add(2*time.Hour), "GET /api/orders", quota))
+		assert.Equal(t, 400*24*time.Hour, server.TTL("orders:usage:partner:2025-03-31"))
+	})
+
+	t.Run("Enforces the monthly quota", func(t *testing.T) {
+		// Marzo ya tiene 2 peticiones contadas; la rechazada no cuenta
+		assert.Equal(t, models.QuotaMonthly, consume(day, "GET /api/orders", models.Quota{Monthly: 2}))
+		assert.Empty(t, consume(day, "GET /api/orders", models.Quota{Monthly: 3}))
+	})
+
+	t.Run("Reports the usage per day and route", func(t *testing.T) {
+		usage, repoErr := repo.Usage(ctx, "partner", day.AddDate(0, 0, -1), day.AddDate(0, 0, 1))
+		require.Nil(t, repoErr)
+
+		assert.Equal(t, "partner", usage.Client)
+		assert.Equal(t, int64(4), usage.Requests)
+		assert.Equal(t, []models.DailyUsage{
+			{Date: "2025-03-31", Requests: 3, Routes: map[string]int64{"GET /api/orders": 2, "POST /api/orders": 1}},
+			{Date: "2025-04-01", Requests: 1, Routes: map[string]int64{"GET /api/orders": 1}},
+		}, usage.Days)
+
+		// Los clientes sin peticiones no tienen días
+		usage, repoErr = repo.Usage(ctx, "mobile", day, day)
+		require.Nil(t, repoErr)
+		assert.Empty(t, usage.Days)
+	})
+
+	t.Run("Fails when Redis is down", func(t *testing.T) {
+		server.Close()
+		_, repoErr := repo.Consume(ctx, "partner", "GET /api/orders", day, models.Quota{})
+		assert.NotNil(t, repoErr)
+	})
+}
@@ -1,6 +1,27 @@
 package repositories
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
+
+// UnknownTotal is the total reported by listings that were asked not to
+// count their results.
+const UnknownTotal int64 = -1
+
+// CauseDuplicateKey is the cause of conflicts raised by a unique index, as
+// opposed to optimistic locking version conflicts.
+const CauseDuplicateKey = "DUPLICATE_KEY"
+
+// CauseCircuitOpen is the cause of calls failed without reaching the database
+// because the circuit breaker is open.
+const CauseCircuitOpen = "CIRCUIT_OPEN"
+
+// OrderRef identifies an order along with the tenant owning it.
+type OrderRef struct {
+	TenantID string
+	OrderID  string
+}
 
 type RepositoryError struct {
 	StatusCode int    `json:"status_code"`
@@ -11,3 +32,21 @@ type RepositoryError struct {
 func (e *RepositoryError) Error() string {
 	return fmt.Sprintf("status=%d, message=%s", e.StatusCode, e.Message)
 }
+
+type contextKey int
+
+const includeDeletedKey contextKey = iota
+
+// WithDeleted returns a context in which lookups of a soft-deleted order
+// report it as gone, 410, rather than not found. It is meant for callers
+// allowed to know the order existed, such as admins.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey, true)
+}
+
+// IncludeDeleted reports whether lookups made with the context surface
+// soft-deleted orders.
+func IncludeDeleted(ctx context.Context) bool {
+	included, _ := ctx.Value(includeDeletedKey).(bool)
+	return included
+}
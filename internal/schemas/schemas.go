@@ -0,0 +1,76 @@
+// Package schemas holds the JSON Schemas of the API request bodies. They are
+// the single source of truth for request validation and the API documentation.
+package schemas
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+
+	"orders/internal/models"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const (
+	CreateOrder = "create_order.json"
+)
+
+//go:embed *.json
+var files embed.FS
+
+// Raw returns the raw JSON document of the named schema.
+func Raw(name string) ([]byte, error) {
+	return files.ReadFile(name)
+}
+
+// Option customizes how schemas are compiled.
+type Option func(*jsonschema.Compiler)
+
+// WithCustomerIDFormat sets the format checked for "customer-id" strings.
+// UUIDs are required by default.
+func WithCustomerIDFormat(format models.CustomerIDFormat) Option {
+	return func(compiler *jsonschema.Compiler) {
+		compiler.Formats["customer-id"] = customerIDChecker(format)
+	}
+}
+
+func customerIDChecker(format models.CustomerIDFormat) func(interface{}) bool {
+	return func(v interface{}) bool {
+		id, ok := v.(string)
+		// Other types are reported by the type keyword
+		return !ok || format.Accepts(id)
+	}
+}
+
+// Compile loads and compiles the named schema.
+func Compile(name string, opts ...Option) (*jsonschema.Schema, error) {
+	data, err := Raw(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema %s: %w", name, err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Formats["customer-id"] = customerIDChecker(models.CustomerIDUUID)
+	for _, opt := range opts {
+		opt(compiler)
+	}
+	if err := compiler.AddResource(name, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to load schema %s: %w", name, err)
+	}
+
+	schema, err := compiler.Compile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema %s: %w", name, err)
+	}
+	return schema, nil
+}
+
+// MustCompile is like Compile but panics if the schema cannot be compiled.
+func MustCompile(name string, opts ...Option) *jsonschema.Schema {
+	schema, err := Compile(name, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"orders/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// WithCache turns the order cache on or off. When off, the service never
+// touches the cache repository and always reads from the database.
+func WithCache(enabled bool) Option {
+	return func(s *order) {
+		s.cacheEnabled = enabled
+	}
+}
+
+// WithCompactSummaries caches the compact projection of orders served to
+// summary reads separately from the full order.
+func WithCompactSummaries(enabled bool) Option {
+	return func(s *order) {
+		s.compactCache = enabled
+	}
+}
+
+// WithCacheWriteRetry hands failed cache writes to the retrier instead of
+// dropping them.
+func WithCacheWriteRetry(retrier CacheWriteRetrier) Option {
+	return func(s *order) {
+		s.cacheRetrier = retrier
+	}
+}
+
+// cacheOrder stores the order in the cache. Failures are not fatal: they are
+// logged and handed to the retrier, if any. Incomplete orders are not cached,
+// so a decoding problem is not served for the whole TTL.
+func (s *order) cacheOrder(ctx context.Context, order *models.Order) {
+	if !s.useCache() {
+		return
+	}
+	if !order.IsCacheable() {
+		s.logger.Warn("Skipping cache of incomplete order",
+			zap.String("orderId", order.ID),
+			zap.String("status", string(order.Status)),
+		)
+		return
+	}
+	ctx, cancel := detach(ctx)
+	defer cancel()
+
+	if err := s.cacheRepo.SetOrder(ctx, order); err != nil {
+		s.logger.Warn("Failed to cache order",
+			zap.String("orderId", order.ID),
+		)
+		if s.cacheRetrier != nil {
+			s.cacheRetrier.Enqueue(order)
+		}
+	}
+}
+
+// invalidateCachedOrder drops the cached copy of an order after it changed.
+func (s *order) invalidateCachedOrder(ctx context.Context, orderID string) {
+	if !s.useCache() {
+		return
+	}
+	// A stale copy would be served after the change, so the invalidation
+	// outlives the request
+	ctx, cancel := detach(ctx)
+	defer cancel()
+
+	if err := s.cacheRepo.InvalidateOrder(ctx, orderID); err != nil {
+		s.logger.Warn("Failed to invalidate cache",
+			zap.String("orderId", orderID),
+		)
+	}
+}
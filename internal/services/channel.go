@@ -0,0 +1,43 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	"orders/internal/models"
+)
+
+// channelValidationError reports an unknown channel along with the allowed ones.
+func channelValidationError() *ServiceError {
+	allowed := make([]interface{}, 0, len(models.Channels))
+	for _, channel := range models.Channels {
+		allowed = append(allowed, string(channel))
+	}
+	return &ServiceError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_CHANNEL",
+		Message: "Invalid channel",
+		Cause:   allowed,
+	}
+}
+
+// metadataValidationError reports every client metadata entry over the limits
+// as a field-level error.
+func metadataValidationError(err error) *ServiceError {
+	cause := []interface{}{}
+	var metadataErr *models.InvalidMetadataError
+	if errors.As(err, &metadataErr) {
+		for _, field := range metadataErr.Fields {
+			cause = append(cause, map[string]interface{}{
+				"field":   field.Field,
+				"message": field.Message,
+			})
+		}
+	}
+	return &ServiceError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_CLIENT_METADATA",
+		Message: "Invalid client metadata",
+		Cause:   cause,
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"time"
+)
+
+// StatusClientClosedRequest is reported when the client went away before the
+// request completed, as nginx does.
+const StatusClientClosedRequest = 499
+
+// sideEffectTimeout bounds the best-effort writes detached from the request.
+const sideEffectTimeout = 5 * time.Second
+
+// detach returns the context of the best-effort writes that follow a change,
+// such as caching the order or publishing its event. Once the order is stored
+// they must complete even if the client went away, so the context keeps the
+// request values (tenant, correlation) but not its cancellation.
+func detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), sideEffectTimeout)
+}
+
+// contextError reports a request that ended before the service was done: 499
+// when the client went away and 504 when its deadline passed. It is nil while
+// the request is live.
+func contextError(ctx context.Context) *ServiceError {
+	switch err := ctx.Err(); {
+	case errors.Is(err, context.Canceled):
+		return &ServiceError{
+			Status:  StatusClientClosedRequest,
+			Code:    "REQUEST_CANCELED",
+			Message: "Request canceled by the client",
+			Cause:   []interface{}{err.Error()},
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &ServiceError{
+			Status:  http.StatusGatewayTimeout,
+			Code:    "REQUEST_TIMEOUT",
+			Message: "Request timed out",
+			Cause:   []interface{}{err.Error()},
+		}
+	}
+	return nil
+}
+
+// changeError rejects saving an order that was not changed through a single
+// mutator of the model, which would break its optimistic lock.
+func changeError(before, order *models.Order) *ServiceError {
+	if err := order.VerifyChange(before); err != nil {
+		return &ServiceError{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to update order",
+			Cause:   []interface{}{err.Error()},
+		}
+	}
+	return nil
+}
+
+// repositoryError maps a repository failure. Failures caused by the request
+// ending are not server errors, so they are reported by contextError rather
+// than as the 500 the repository saw.
+func repositoryError(ctx context.Context, err *repositories.RepositoryError) *ServiceError {
+	if svcErr := contextError(ctx); svcErr != nil {
+		return svcErr
+	}
+	svcErr := &ServiceError{
+		Status:  err.StatusCode,
+		Message: err.Message,
+		Cause:   []interface{}{err.Cause},
+	}
+	if err.Cause == repositories.CauseCircuitOpen {
+		svcErr.Code = repositories.CauseCircuitOpen
+	}
+	return svcErr
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+
+	"orders/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// CustomerValidator checks that the customer placing an order exists.
+type CustomerValidator interface {
+	// Exists reports whether the customer is known. A non-nil error means the
+	// check could not be performed (e.g. the customers service is down).
+	Exists(ctx context.Context, customerID string) (bool, error)
+}
+
+// WithCustomerIDFormat sets the format customer IDs must follow. UUIDs are
+// required by default.
+func WithCustomerIDFormat(format models.CustomerIDFormat) Option {
+	return func(s *order) {
+		s.customerIDs = format
+	}
+}
+
+// CustomerDirectory is implemented by customer validators that can also
+// return the contact details of a customer. It is used to complete the
+// customer snapshot of new orders.
+type CustomerDirectory interface {
+	// Contact returns the contact details of the customer, or nil when the
+	// customer is unknown.
+	Contact(ctx context.Context, customerID string) (*models.CustomerSnapshot, error)
+}
+
+// customerSnapshot completes the contact details given by the client with the
+// ones of the customers service, when the configured validator provides them.
+// Lookup failures are logged and the order keeps the client data.
+func (s *order) customerSnapshot(ctx context.Context, customerID string, given *models.CustomerSnapshot) *models.CustomerSnapshot {
+	snapshot := &models.CustomerSnapshot{}
+	if given != nil {
+		*snapshot = *given
+	}
+
+	directory, ok := s.customers.(CustomerDirectory)
+	if ok && (snapshot.Email == "" || snapshot.Name == "" || snapshot.Phone == "") {
+		contact, err := directory.Contact(ctx, customerID)
+		if err != nil {
+			s.logger.Warn("Failed to look up customer contact",
+				zap.Error(err),
+				zap.String("customerId", customerID),
+			)
+		} else {
+			snapshot.Merge(contact)
+		}
+	}
+
+	if snapshot.IsEmpty() {
+		return nil
+	}
+	s.logger.Debug("Customer snapshot taken",
+		zap.String("customerId", customerID),
+		zap.Stringer("customer", snapshot),
+	)
+	return snapshot
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"orders/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// RecordOrderDelivery records the items handed over in a delivery, completing
+// the order once everything has been delivered.
+func (s *order) RecordOrderDelivery(ctx context.Context, orderID string, items []models.DeliveryItem) (*models.Order, *ServiceError) {
+	s.logger.Debug("Recording order delivery",
+		zap.String("orderId", orderID),
+		zap.Int("itemsCount", len(items)),
+	)
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+
+	// Deliveries are also recorded by consumers acting for every tenant
+	ctx = orderScope(ctx, order)
+
+	before := order.Clone()
+	oldStatus := order.Status
+	if deliveryErr := order.RecordDelivery(items, time.Now()); deliveryErr != nil {
+		s.logger.Warn("Delivery rejected",
+			zap.Error(deliveryErr),
+			zap.String("orderId", orderID),
+			zap.String("status", string(oldStatus)),
+		)
+		return nil, deliveryValidationError(deliveryErr, oldStatus)
+	}
+
+	if svcErr := contextError(ctx); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := changeError(before, order); svcErr != nil {
+		return nil, svcErr
+	}
+
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		s.logger.Error("Failed to update order delivery",
+			zap.String("orderId", orderID),
+		)
+		return nil, repositoryError(ctx, err)
+	}
+
+	s.invalidateCachedOrder(ctx, orderID)
+	s.recordWrite(ctx, order.CustomerID)
+	s.emitEvent(ctx, models.NewOrderItemsDeliveredEvent(order, oldStatus, items).SetStates(before, order))
+	s.observeTransition(order, oldStatus)
+	s.notifyCustomer(ctx, order)
+
+	s.logger.Info("Order delivery recorded successfully",
+		zap.String("orderId", orderID),
+		zap.String("oldStatus", string(oldStatus)),
+		zap.String("newStatus", string(order.Status)),
+	)
+
+	return order, nil
+}
+
+// deliveryValidationError maps the reasons a delivery is rejected to service errors.
+func deliveryValidationError(err error, status models.OrderStatus) *ServiceError {
+	var itemsErr *models.InvalidDeliveryItemsError
+	switch {
+	case errors.Is(err, models.ErrInvalidStatusTransition):
+		return &ServiceError{
+			Status:  http.StatusConflict,
+			Code:    "DELIVERY_NOT_ALLOWED",
+			Message: "Deliveries can only be recorded for orders in progress, order is " + string(status),
+			Cause:   []interface{}{err.Error()},
+		}
+	case errors.As(err, &itemsErr):
+		cause := make([]interface{}, 0, len(itemsErr.SKUs))
+		for _, sku := range itemsErr.SKUs {
+			cause = append(cause, sku)
+		}
+		return &ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_DELIVERY_ITEMS",
+			Message: "Delivered items must be on the order and not exceed the quantities left to deliver",
+			Cause:   cause,
+		}
+	}
+	return &ServiceError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_DELIVERY_ITEMS",
+		Message: "At least one item must be delivered",
+	}
+}
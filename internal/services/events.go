@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"orders/internal/correlation"
+	"orders/internal/features"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/tenant"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EventStore persists emitted events so they can be inspected and replayed.
+type EventStore interface {
+	Save(ctx context.Context, record *models.EventRecord) *repositories.RepositoryError
+	UpdateDelivery(ctx context.Context, eventID string, status models.EventDeliveryStatus, lastError string) *repositories.RepositoryError
+	FindByOrderID(ctx context.Context, orderID string) ([]*models.EventRecord, *repositories.RepositoryError)
+	FindRecentByOrderID(ctx context.Context, orderID string, limit int) ([]*models.EventRecord, *repositories.RepositoryError)
+	FindByID(ctx context.Context, eventID string) (*models.EventRecord, *repositories.RepositoryError)
+	FindWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.EventRecord, int64, *repositories.RepositoryError)
+}
+
+// ListEventsFilter narrows down the events returned by ListEvents. Zero values
+// do not filter.
+type ListEventsFilter struct {
+	OrderID   string
+	EventType models.EventType
+	Status    models.EventDeliveryStatus
+	// From and To bound the time the events were emitted, inclusive.
+	From *time.Time
+	To   *time.Time
+}
+
+// WithEventStore records every emitted event in the given store.
+func WithEventStore(store EventStore) Option {
+	return func(s *order) {
+		s.eventStore = store
+	}
+}
+
+// emitEvent persists the event (when an event store is configured) and
+// publishes it, recording the delivery outcome. Failures are logged only:
+// the order change has already been committed.
+func (s *order) emitEvent(ctx context.Context, event *models.OrderEvent) {
+	// The change is stored, so its event is published even if the client
+	// went away meanwhile
+	ctx, cancel := detach(ctx)
+	defer cancel()
+
+	setCorrelation(ctx, event)
+	if event.TenantID == "" {
+		event.TenantID = tenant.ID(ctx)
+	}
+	if !s.feature(features.EventSnapshots, true) {
+		event.SetStates(nil, nil)
+	}
+
+	if s.eventStore != nil {
+		if err := s.eventStore.Save(ctx, models.NewEventRecord(event)); err != nil {
+			s.logger.Error("Failed to persist event",
+				zap.String("eventId", event.EventID),
+				zap.String("orderId", event.OrderID),
+				zap.String("cause", err.Cause),
+			)
+		}
+	}
+
+	status, lastError := models.EventStatusSent, ""
+	if err := s.eventPublisher.PublishOrderEvent(ctx, event); err != nil {
+		s.logger.Error("Failed to publish event",
+			zap.Error(err),
+			zap.String("orderId", event.OrderID),
+			zap.String("eventId", event.EventID),
+		)
+		status, lastError = models.EventStatusFailed, err.Error()
+	}
+
+	if s.eventStore != nil {
+		if err := s.eventStore.UpdateDelivery(ctx, event.EventID, status, lastError); err != nil {
+			s.logger.Warn("Failed to record event delivery",
+				zap.String("eventId", event.EventID),
+				zap.String("cause", err.Cause),
+			)
+		}
+	}
+}
+
+// setCorrelation fills the correlation and causation IDs the event does not
+// carry yet from the context. An event emitted outside of any transaction
+// starts its own, and the request is the cause of events it emits directly.
+func setCorrelation(ctx context.Context, event *models.OrderEvent) {
+	if event.CorrelationID == "" {
+		event.CorrelationID = correlation.ID(ctx)
+	}
+	if event.CorrelationID == "" {
+		event.CorrelationID = event.EventID
+	}
+	if event.CausationID == "" {
+		event.CausationID = correlation.CausationID(ctx)
+	}
+	if event.CausationID == "" {
+		event.CausationID = event.CorrelationID
+	}
+}
+
+func (s *order) ReplayOrderEvents(ctx context.Context, orderID string) (int, *ServiceError) {
+	s.logger.Debug("Replaying order events",
+		zap.String("orderId", orderID),
+	)
+
+	if s.eventStore == nil {
+		return 0, &ServiceError{
+			Status:  http.StatusServiceUnavailable,
+			Message: "Event log is not configured",
+		}
+	}
+
+	records, err := s.eventStore.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return 0, repositoryError(ctx, err)
+	}
+
+	if len(records) == 0 {
+		return 0, &ServiceError{
+			Status:  http.StatusNotFound,
+			Message: "No events found for order",
+			Cause:   []interface{}{orderID},
+		}
+	}
+
+	for i, record := range records {
+		event := record.OrderEvent
+		if err := s.eventPublisher.RepublishOrderEvent(ctx, &event); err != nil {
+			s.logger.Error("Failed to replay event",
+				zap.Error(err),
+				zap.String("orderId", orderID),
+				zap.String("eventId", event.EventID),
+				zap.Int("replayed", i),
+			)
+			return i, &ServiceError{
+				Status:  http.StatusBadGateway,
+				Message: "Failed to replay events",
+				Cause:   []interface{}{err.Error(), event.EventID},
+			}
+		}
+	}
+
+	s.logger.Info("Order events replayed",
+		zap.String("orderId", orderID),
+		zap.Int("count", len(records)),
+	)
+
+	return len(records), nil
+}
+
+// ListOrderEvents returns the last limit events recorded for an order, oldest
+// first, with their delivery status.
+func (s *order) ListOrderEvents(ctx context.Context, orderID string, limit int) ([]*models.EventRecord, *ServiceError) {
+	if s.eventStore == nil {
+		return nil, &ServiceError{
+			Status:  http.StatusServiceUnavailable,
+			Message: "Event log is not configured",
+		}
+	}
+
+	records, err := s.eventStore.FindRecentByOrderID(ctx, orderID, limit)
+	if err != nil {
+		s.logger.Error("Failed to list order events",
+			zap.String("orderId", orderID),
+			zap.String("cause", err.Cause),
+		)
+		return nil, repositoryError(ctx, err)
+	}
+	if records == nil {
+		records = []*models.EventRecord{}
+	}
+	return records, nil
+}
+
+// ListEvents returns a page of the event log across orders, newest first,
+// along with the number of events matching the filter.
+func (s *order) ListEvents(ctx context.Context, filter ListEventsFilter, page, limit int) ([]*models.EventRecord, int64, *ServiceError) {
+	if s.eventStore == nil {
+		return nil, 0, &ServiceError{
+			Status:  http.StatusServiceUnavailable,
+			Message: "Event log is not configured",
+		}
+	}
+
+	filters := make(map[string]interface{})
+	if filter.OrderID != "" {
+		filters["orderId"] = filter.OrderID
+	}
+	if filter.EventType != "" {
+		filters["eventType"] = string(filter.EventType)
+	}
+	if filter.Status != "" {
+		filters["status"] = string(filter.Status)
+	}
+	if filter.From != nil {
+		filters["from"] = *filter.From
+	}
+	if filter.To != nil {
+		filters["to"] = *filter.To
+	}
+
+	records, total, err := s.eventStore.FindWithFilters(ctx, filters, page, limit)
+	if err != nil {
+		s.logger.Error("Failed to list events",
+			zap.String("cause", err.Cause),
+		)
+		return nil, 0, repositoryError(ctx, err)
+	}
+	return records, total, nil
+}
+
+// GetEvent returns an event of the log with its delivery attempts.
+func (s *order) GetEvent(ctx context.Context, eventID string) (*models.EventRecord, *ServiceError) {
+	if s.eventStore == nil {
+		return nil, &ServiceError{
+			Status:  http.StatusServiceUnavailable,
+			Message: "Event log is not configured",
+		}
+	}
+
+	record, err := s.eventStore.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+	return record, nil
+}
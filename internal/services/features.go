@@ -0,0 +1,37 @@
+package services
+
+import "orders/internal/features"
+
+// FeatureFlags reports the behaviours that can be toggled at runtime.
+type FeatureFlags interface {
+	Enabled(name string) bool
+}
+
+// WithFeatureFlags reads the cache, compact summary and event snapshot
+// settings from flags on every use, so runtime changes apply right away.
+// Without flags the cache and compact summaries follow WithCache and
+// WithCompactSummaries, and events carry their snapshots.
+func WithFeatureFlags(flags FeatureFlags) Option {
+	return func(s *order) {
+		s.features = flags
+	}
+}
+
+// feature reports whether the named flag is on, fallback when there are no
+// feature flags.
+func (s *order) feature(name string, fallback bool) bool {
+	if s.features == nil {
+		return fallback
+	}
+	return s.features.Enabled(name)
+}
+
+// useCache reports whether the cache is configured and not switched off.
+func (s *order) useCache() bool {
+	return s.cacheEnabled && s.feature(features.Cache, true)
+}
+
+// useCompactCache reports whether summaries are cached on their own.
+func (s *order) useCompactCache() bool {
+	return s.useCache() && s.feature(features.CompactSummaries, s.compactCache)
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"net/http"
+
+	"orders/internal/models"
+)
+
+// WithInitialStatuses sets the statuses orders can be created in when the
+// client asks for one. Orders created without one start in NEW.
+func WithInitialStatuses(statuses []models.OrderStatus) Option {
+	return func(s *order) {
+		s.startStatuses = statuses
+	}
+}
+
+// initialStatusValidationError reports an initial status the orders cannot be
+// created in, along with the allowed ones.
+func initialStatusValidationError(allowed []models.OrderStatus) *ServiceError {
+	cause := make([]interface{}, 0, len(allowed))
+	for _, status := range allowed {
+		cause = append(cause, string(status))
+	}
+	return &ServiceError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_INITIAL_STATUS",
+		Message: "Orders cannot be created in this status",
+		Cause:   cause,
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"orders/internal/features"
+	"orders/internal/metrics"
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"go.uber.org/zap"
+)
+
+// InventoryReserver holds the stock of new orders and frees it when they are
+// cancelled.
+type InventoryReserver interface {
+	// Reserve holds the stock of the items for the order. A shortage is
+	// reported with *models.InsufficientStockError; any other error means the
+	// reservation could not be made.
+	Reserve(ctx context.Context, orderID string, items []models.OrderItem) error
+	// Release frees the stock of the order. Releasing an order without a
+	// reservation succeeds.
+	Release(ctx context.Context, orderID string) error
+}
+
+// noopInventoryReserver reserves nothing, preserving the behavior of
+// environments that have no inventory service available.
+type noopInventoryReserver struct{}
+
+// NewNoopInventoryReserver returns an InventoryReserver that always succeeds.
+func NewNoopInventoryReserver() InventoryReserver {
+	return noopInventoryReserver{}
+}
+
+func (noopInventoryReserver) Reserve(context.Context, string, []models.OrderItem) error {
+	return nil
+}
+
+func (noopInventoryReserver) Release(context.Context, string) error {
+	return nil
+}
+
+// ReservationStage is when new orders reserve their stock.
+type ReservationStage string
+
+const (
+	// ReserveBeforePersist reserves the stock before the order is stored, so
+	// no order is stored without stock.
+	ReserveBeforePersist ReservationStage = "pre-persist"
+	// ReserveAfterPersist reserves the stock once the order is stored, so no
+	// stock is held for an order that failed to be stored. An order whose
+	// reservation fails is cancelled.
+	ReserveAfterPersist ReservationStage = "post-persist"
+)
+
+// InventoryReleaseStore is the outbox of inventory releases. Releases are
+// stored before they are attempted and deleted once done; the ones left
+// behind are retried by workers.InventoryReleaseRetrier.
+type InventoryReleaseStore interface {
+	SaveRelease(ctx context.Context, release *models.InventoryRelease) *repositories.RepositoryError
+	RescheduleRelease(ctx context.Context, orderID string, next time.Time, lastError string) *repositories.RepositoryError
+	DeleteRelease(ctx context.Context, orderID string) *repositories.RepositoryError
+}
+
+// WithInventoryReserver reserves the stock of new orders at the given stage
+// and releases it when they are cancelled. Reservations follow the
+// inventoryReservation feature flag; releases do not, so the stock of orders
+// reserved before the flag was switched off is still freed.
+func WithInventoryReserver(reserver InventoryReserver, stage ReservationStage) Option {
+	return func(s *order) {
+		s.inventory = reserver
+		s.reserveStage = stage
+	}
+}
+
+// WithInventoryReleaseOutbox records every release in the store before it is
+// attempted, so a release that fails is retried after retryDelay instead of
+// being lost.
+func WithInventoryReleaseOutbox(store InventoryReleaseStore, retryDelay time.Duration) Option {
+	return func(s *order) {
+		s.releases = store
+		s.releaseDelay = retryDelay
+	}
+}
+
+// reservesInventory reports whether new orders reserve their stock.
+func (s *order) reservesInventory() bool {
+	return s.feature(features.InventoryReservation, true)
+}
+
+// reserveInventory reserves the stock of the order: 409 when some SKUs are
+// short, 503 when the inventory cannot be reached.
+func (s *order) reserveInventory(ctx context.Context, order *models.Order) *ServiceError {
+	err := s.inventory.Reserve(ctx, order.ID, order.Items)
+	if err == nil {
+		metrics.InventoryReservationsTotal.WithLabelValues("reserved").Inc()
+		return nil
+	}
+
+	var shortage *models.InsufficientStockError
+	if errors.As(err, &shortage) {
+		metrics.InventoryReservationsTotal.WithLabelValues("shortage").Inc()
+		s.logger.Warn("Order rejected for insufficient stock",
+			zap.String("orderId", order.ID),
+			zap.Strings("skus", shortage.SKUs),
+			zap.String("customerId", order.CustomerID),
+		)
+		cause := make([]interface{}, 0, len(shortage.SKUs))
+		for _, sku := range shortage.SKUs {
+			cause = append(cause, sku)
+		}
+		return &ServiceError{
+			Status:  http.StatusConflict,
+			Code:    "INSUFFICIENT_STOCK",
+			Message: fmt.Sprintf("Insufficient stock: %s", strings.Join(shortage.SKUs, ", ")),
+			Cause:   cause,
+		}
+	}
+
+	metrics.InventoryReservationsTotal.WithLabelValues("error").Inc()
+	s.logger.Error("Failed to reserve inventory",
+		zap.Error(err),
+		zap.String("orderId", order.ID),
+		zap.String("customerId", order.CustomerID),
+	)
+	// The inventory may have reserved the stock before the call failed
+	s.releaseInventory(ctx, order.ID)
+	return &ServiceError{
+		Status:  http.StatusServiceUnavailable,
+		Code:    "INVENTORY_UNAVAILABLE",
+		Message: "Failed to reserve inventory",
+		Cause:   []interface{}{err.Error()},
+	}
+}
+
+// releaseInventory frees the stock of the order. The release is recorded in
+// the outbox first and removed once done, so a failed release is retried.
+func (s *order) releaseInventory(ctx context.Context, orderID string) {
+	ctx, cancel := detach(ctx)
+	defer cancel()
+
+	recorded := false
+	if s.releases != nil {
+		now := time.Now().UTC()
+		release := &models.InventoryRelease{
+			OrderID:       orderID,
+			NextAttemptAt: now.Add(s.releaseDelay),
+			CreatedAt:     now,
+		}
+		if err := s.releases.SaveRelease(ctx, release); err != nil {
+			s.logger.Error("Failed to record inventory release",
+				zap.String("orderId", orderID),
+				zap.String("cause", err.Cause),
+			)
+		} else {
+			recorded = true
+		}
+	}
+
+	if err := s.inventory.Release(ctx, orderID); err != nil {
+		metrics.InventoryReleasesTotal.WithLabelValues("failed").Inc()
+		if !recorded {
+			s.logger.Error("Failed to release inventory",
+				zap.Error(err),
+				zap.String("orderId", orderID),
+			)
+			return
+		}
+		s.logger.Warn("Failed to release inventory, retrying later",
+			zap.Error(err),
+			zap.String("orderId", orderID),
+		)
+		if err := s.releases.RescheduleRelease(ctx, orderID, time.Now().UTC().Add(s.releaseDelay), err.Error()); err != nil {
+			s.logger.Warn("Failed to record inventory release attempt",
+				zap.String("orderId", orderID),
+				zap.String("cause", err.Cause),
+			)
+		}
+		return
+	}
+
+	metrics.InventoryReleasesTotal.WithLabelValues("released").Inc()
+	if recorded {
+		// A release left behind is retried, which the inventory ignores
+		if err := s.releases.DeleteRelease(ctx, orderID); err != nil {
+			s.logger.Warn("Failed to clear inventory release",
+				zap.String("orderId", orderID),
+				zap.String("cause", err.Cause),
+			)
+		}
+	}
+}
+
+// cancelUnreserved cancels an order stored before its reservation failed, so
+// it is not fulfilled without stock. No event is emitted for it, as its
+// creation was not announced either.
+func (s *order) cancelUnreserved(ctx context.Context, order *models.Order) {
+	ctx, cancel := detach(ctx)
+	defer cancel()
+
+	if err := order.UpdateStatus(models.StatusCancelled); err != nil {
+		s.logger.Error("Failed to cancel unreserved order",
+			zap.Error(err),
+			zap.String("orderId", order.ID),
+		)
+		return
+	}
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		s.logger.Error("Failed to cancel unreserved order",
+			zap.String("orderId", order.ID),
+			zap.String("cause", err.Cause),
+		)
+	}
+}
@@ -0,0 +1,271 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"orders/internal/clients/inventory"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/services"
+	"orders/pkg/httpclient"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeInventoryServer simula el servicio de inventario; down lo deja sin servicio
+type fakeInventoryServer struct {
+	mu       sync.Mutex
+	stock    map[string]int
+	reserved map[string]bool
+	releases int
+	down     atomic.Bool
+	url      string
+}
+
+func newFakeInventoryServer(t *testing.T, stock map[string]int) *fakeInventoryServer {
+	t.Helper()
+	fake := &fakeInventoryServer{stock: stock, reserved: map[string]bool{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /reservations", func(w http.ResponseWriter, r *http.Request) {
+		if fake.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body struct {
+			OrderID string `json:"orderId"`
+			Items   []struct {
+				SKU      string `json:"sku"`
+				Quantity int    `json:"quantity"`
+			} `json:"items"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		var unavailable []map[string]string
+		for _, item := range body.Items {
+			if fake.stock[item.SKU] < item.Quantity {
+				unavailable = append(unavailable, map[string]string{"sku": item.SKU})
+			}
+		}
+		if len(unavailable) > 0 {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"unavailable": unavailable})
+			return
+		}
+		fake.reserved[body.OrderID] = true
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("DELETE /reservations/{orderId}", func(w http.ResponseWriter, r *http.Request) {
+		if fake.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		fake.releases++
+		delete(fake.reserved, r.PathValue("orderId"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	fake.url = server.URL
+	return fake
+}
+
+func (f *fakeInventoryServer) isReserved(orderID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reserved[orderID]
+}
+
+// fakeReleaseOutbox guarda en memoria las liberaciones pendientes
+type fakeReleaseOutbox struct {
+	releases map[string]*models.InventoryRelease
+}
+
+func newFakeReleaseOutbox() *fakeReleaseOutbox {
+	return &fakeReleaseOutbox{releases: map[string]*models.InventoryRelease{}}
+}
+
+func (o *fakeReleaseOutbox) SaveRelease(_ context.Context, release *models.InventoryRelease) *repositories.RepositoryError {
+	o.releases[release.OrderID] = release
+	return nil
+}
+
+func (o *fakeReleaseOutbox) RescheduleRelease(_ context.Context, orderID string, next time.Time, lastError string) *repositories.RepositoryError {
+	if release, ok := o.releases[orderID]; ok {
+		release.Attempts++
+		release.NextAttemptAt = next
+		release.LastError = lastError
+	}
+	return nil
+}
+
+func (o *fakeReleaseOutbox) DeleteRelease(_ context.Context, orderID string) *repositories.RepositoryError {
+	delete(o.releases, orderID)
+	return nil
+}
+
+func TestOrderService_CreateOrder_InventoryReservation(t *testing.T) {
+	customerID := "123e4567-e89b-12d3-a456-426614174000"
+	items := []models.OrderItem{
+		{SKU: "LAPTOP-001", Quantity: 2, Price: 999.99},
+		{SKU: "MOUSE-002", Quantity: 1, Price: 19.99},
+	}
+
+	t.Run("Reserved before the order is stored", func(t *testing.T) {
+		fake := newFakeInventoryServer(t, map[string]int{"LAPTOP-001": 5, "MOUSE-002": 5})
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(order *models.Order) bool {
+			return fake.isReserved(order.ID)
+		})).Return(nil)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+			services.WithInventoryReserver(inventory.NewClient(fake.url, "", httpclient.New(httpclient.Config{Timeout: time.Second})), services.ReserveBeforePersist))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		require.Nil(t, err)
+		assert.True(t, fake.isReserved(order.ID))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Shortage", func(t *testing.T) {
+		fake := newFakeInventoryServer(t, map[string]int{"LAPTOP-001": 1, "MOUSE-002": 5})
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+			services.WithInventoryReserver(inventory.NewClient(fake.url, "", httpclient.New(httpclient.Config{Timeout: time.Second})), services.ReserveBeforePersist))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, order)
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusConflict, err.Status)
+		assert.Equal(t, "INSUFFICIENT_STOCK", err.Code)
+		assert.Equal(t, []interface{}{"LAPTOP-001"}, err.Cause)
+		mockRepo.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("Inventory down", func(t *testing.T) {
+		fake := newFakeInventoryServer(t, map[string]int{"LAPTOP-001": 5, "MOUSE-002": 5})
+		fake.down.Store(true)
+		outbox := newFakeReleaseOutbox()
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+			services.WithInventoryReserver(inventory.NewClient(fake.url, "", httpclient.New(httpclient.Config{Timeout: time.Second})), services.ReserveBeforePersist),
+			services.WithInventoryReleaseOutbox(outbox, time.Minute))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, order)
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, err.Status)
+		assert.Equal(t, "INVENTORY_UNAVAILABLE", err.Code)
+		mockRepo.AssertNotCalled(t, "Create")
+		// Por si el inventario llegó a reservar, la liberación queda pendiente
+		assert.Len(t, outbox.releases, 1)
+	})
+
+	t.Run("Stock released when the order cannot be stored", func(t *testing.T) {
+		fake := newFakeInventoryServer(t, map[string]int{"LAPTOP-001": 5, "MOUSE-002": 5})
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.Anything).
+			Return(&repositories.RepositoryError{StatusCode: http.StatusInternalServerError, Cause: "connection reset", Message: "Failed to create order"})
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+			services.WithInventoryReserver(inventory.NewClient(fake.url, "", httpclient.New(httpclient.Config{Timeout: time.Second})), services.ReserveBeforePersist))
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		require.NotNil(t, err)
+		assert.Equal(t, 1, fake.releases)
+		assert.Empty(t, fake.reserved)
+	})
+
+	t.Run("Shortage after the order is stored cancels it", func(t *testing.T) {
+		fake := newFakeInventoryServer(t, map[string]int{"LAPTOP-001": 1, "MOUSE-002": 5})
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(order *models.Order) bool {
+			return order.Status == models.StatusCancelled
+		})).Return(nil)
+		publisher := new(MockEventPublisher)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), publisher, zap.NewNop(),
+			services.WithInventoryReserver(inventory.NewClient(fake.url, "", httpclient.New(httpclient.Config{Timeout: time.Second})), services.ReserveAfterPersist))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, order)
+		require.NotNil(t, err)
+		assert.Equal(t, "INSUFFICIENT_STOCK", err.Code)
+		mockRepo.AssertExpectations(t)
+		publisher.AssertNotCalled(t, "PublishOrderEvent", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderService_UpdateOrderStatus_CancelReleasesInventory(t *testing.T) {
+	newService := func(fake *fakeInventoryServer, outbox *fakeReleaseOutbox) services.OrderService {
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("FindByID", mock.Anything, "order-123").
+			Return(&models.Order{ID: "order-123", CustomerID: "customer-456", Status: models.StatusNew, Version: 1}, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+		mockCache := new(MockCacheRepository)
+		mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+		publisher := new(MockEventPublisher)
+		publisher.On("PublishOrderEvent", mock.Anything, mock.Anything).Return(nil)
+		return services.NewOrderService(mockRepo, mockCache, publisher, zap.NewNop(),
+			services.WithInventoryReserver(inventory.NewClient(fake.url, "", httpclient.New(httpclient.Config{Timeout: time.Second})), services.ReserveBeforePersist),
+			services.WithInventoryReleaseOutbox(outbox, time.Minute))
+	}
+
+	t.Run("Released", func(t *testing.T) {
+		fake := newFakeInventoryServer(t, nil)
+		fake.reserved["order-123"] = true
+		outbox := newFakeReleaseOutbox()
+
+		_, err := newService(fake, outbox).UpdateOrderStatus(context.Background(), "order-123", models.StatusCancelled)
+
+		require.Nil(t, err)
+		assert.False(t, fake.isReserved("order-123"))
+		assert.Empty(t, outbox.releases)
+	})
+
+	t.Run("Inventory down keeps the release for a retry", func(t *testing.T) {
+		fake := newFakeInventoryServer(t, nil)
+		fake.reserved["order-123"] = true
+		fake.down.Store(true)
+		outbox := newFakeReleaseOutbox()
+
+		_, err := newService(fake, outbox).UpdateOrderStatus(context.Background(), "order-123", models.StatusCancelled)
+
+		// La cancelación no falla por el inventario
+		require.Nil(t, err)
+		assert.True(t, fake.isReserved("order-123"))
+		if release := outbox.releases["order-123"]; assert.NotNil(t, release) {
+			assert.Equal(t, 1, release.Attempts)
+			assert.Equal(t, "inventory service returned status 503", release.LastError)
+			assert.True(t, release.NextAttemptAt.After(time.Now()))
+		}
+	})
+
+	t.Run("Other transitions keep the reservation", func(t *testing.T) {
+		fake := newFakeInventoryServer(t, nil)
+		fake.reserved["order-123"] = true
+		outbox := newFakeReleaseOutbox()
+
+		_, err := newService(fake, outbox).UpdateOrderStatus(context.Background(), "order-123", models.StatusInProgress)
+
+		require.Nil(t, err)
+		assert.True(t, fake.isReserved("order-123"))
+		assert.Zero(t, fake.releases)
+	})
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"go.uber.org/zap"
+)
+
+// WriteTracker records when orders were last written so listings can be
+// served conditionally.
+type WriteTracker interface {
+	Touch(ctx context.Context, customerID string) *repositories.RepositoryError
+	Forget(ctx context.Context, customerID string) *repositories.RepositoryError
+	Version(ctx context.Context, customerID string) (*models.ListVersion, *repositories.RepositoryError)
+}
+
+// WithWriteTracker records every order write in the tracker.
+func WithWriteTracker(tracker WriteTracker) Option {
+	return func(s *order) {
+		s.writeTracker = tracker
+	}
+}
+
+// ListOrdersVersion returns the last write that can affect the listing, or
+// nil when it is not known. Only the customer narrows the tracked writes;
+// any other filter falls back to the last write to any order.
+func (s *order) ListOrdersVersion(ctx context.Context, filter ListOrdersFilter) *models.ListVersion {
+	if s.writeTracker == nil {
+		return nil
+	}
+
+	version, err := s.writeTracker.Version(ctx, filter.CustomerID)
+	if err != nil {
+		s.logger.Warn("Failed to get last write",
+			zap.String("customerId", filter.CustomerID),
+			zap.String("cause", err.Cause),
+		)
+		return nil
+	}
+	return version
+}
+
+// recordWrite moves the last write of the customer's orders forward. It must
+// run after the write is committed: a listing read in between reports an
+// older version and is only ever served again in full.
+func (s *order) recordWrite(ctx context.Context, customerID string) {
+	if s.writeTracker == nil {
+		return
+	}
+	ctx, cancel := detach(ctx)
+	defer cancel()
+
+	if err := s.writeTracker.Touch(ctx, customerID); err != nil {
+		s.logger.Warn("Failed to record last write",
+			zap.String("customerId", customerID),
+			zap.String("cause", err.Cause),
+		)
+		// A stale stamp would answer 304 for the changed listing
+		if err := s.writeTracker.Forget(ctx, customerID); err != nil {
+			s.logger.Error("Failed to drop last write",
+				zap.String("customerId", customerID),
+				zap.String("cause", err.Cause),
+			)
+		}
+	}
+}
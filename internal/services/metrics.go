@@ -0,0 +1,76 @@
+package services
+
+import (
+	"time"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+)
+
+// Results of a cache lookup.
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+// MetricsRecorder records how effective the cache is and how long orders
+// take to move through their lifecycle.
+type MetricsRecorder interface {
+	// CacheLookup records a lookup of an order or summary in the cache.
+	CacheLookup(entity, result string)
+	// OrderPickedUp records an order moving from NEW to IN_PROGRESS, d
+	// after it was created.
+	OrderPickedUp(channel models.OrderChannel, d time.Duration)
+	// OrderCompleted records an order reaching a final status, d after it
+	// was created.
+	OrderCompleted(status models.OrderStatus, channel models.OrderChannel, d time.Duration)
+}
+
+// WithMetricsRecorder sets where the service records its metrics. They are
+// exported to Prometheus by default.
+func WithMetricsRecorder(recorder MetricsRecorder) Option {
+	return func(s *order) {
+		s.metrics = recorder
+	}
+}
+
+// observeTransition records the lifecycle durations of an order that moved
+// from oldStatus to its current status. Only the first time an order leaves
+// the fulfillment flow is recorded, so returns do not count again.
+func (s *order) observeTransition(order *models.Order, oldStatus models.OrderStatus) {
+	if order.CreatedAt.IsZero() {
+		return
+	}
+	elapsed := time.Since(order.CreatedAt)
+	switch {
+	case oldStatus == models.StatusNew && order.Status == models.StatusInProgress:
+		s.metrics.OrderPickedUp(order.Channel, elapsed)
+	case !oldStatus.IsFinal() && order.Status.IsFinal():
+		s.metrics.OrderCompleted(order.Status, order.Channel, elapsed)
+	}
+}
+
+// prometheusRecorder exports the metrics of the service to Prometheus.
+type prometheusRecorder struct{}
+
+func (prometheusRecorder) CacheLookup(entity, result string) {
+	metrics.CacheLookupsTotal.WithLabelValues(entity, result).Inc()
+}
+
+func (prometheusRecorder) OrderPickedUp(channel models.OrderChannel, d time.Duration) {
+	metrics.OrderPickupDuration.WithLabelValues(channelLabel(channel)).Observe(d.Seconds())
+}
+
+func (prometheusRecorder) OrderCompleted(status models.OrderStatus, channel models.OrderChannel, d time.Duration) {
+	metrics.OrderCompletionDuration.WithLabelValues(string(status), channelLabel(channel)).Observe(d.Seconds())
+}
+
+// channelLabel names the channel of orders placed before channels were
+// recorded as unknown.
+func channelLabel(channel models.OrderChannel) string {
+	if channel == "" {
+		return "UNKNOWN"
+	}
+	return string(channel)
+}
@@ -0,0 +1,128 @@
+package services_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// fakeMetricsRecorder guarda las observaciones del servicio
+type fakeMetricsRecorder struct {
+	mu          sync.Mutex
+	lookups     []string
+	pickups     []models.OrderChannel
+	completions []models.OrderStatus
+	durations   []time.Duration
+}
+
+func (f *fakeMetricsRecorder) CacheLookup(entity, result string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lookups = append(f.lookups, entity+":"+result)
+}
+
+func (f *fakeMetricsRecorder) OrderPickedUp(channel models.OrderChannel, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pickups = append(f.pickups, channel)
+	f.durations = append(f.durations, d)
+}
+
+func (f *fakeMetricsRecorder) OrderCompleted(status models.OrderStatus, channel models.OrderChannel, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.completions = append(f.completions, status)
+	f.durations = append(f.durations, d)
+}
+
+func TestOrderService_LifecycleMetrics(t *testing.T) {
+	created := time.Now().Add(-2 * time.Hour)
+	newOrder := func(status models.OrderStatus) *models.Order {
+		return &models.Order{ID: "order-123", CustomerID: "customer-456", Status: status, Channel: models.ChannelWeb,
+			Version: 1, CreatedAt: created}
+	}
+	setup := func(existing *models.Order) (services.OrderService, *fakeMetricsRecorder) {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		mockPublisher := new(MockEventPublisher)
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(existing, nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+		mockPublisher.On("PublishOrderEvent", mock.Anything, mock.Anything).Return(nil)
+		recorder := &fakeMetricsRecorder{}
+		return services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop(), services.WithMetricsRecorder(recorder)), recorder
+	}
+
+	t.Run("Pickup latency from NEW to IN_PROGRESS", func(t *testing.T) {
+		service, recorder := setup(newOrder(models.StatusNew))
+
+		_, err := service.UpdateOrderStatus(context.Background(), "order-123", models.StatusInProgress)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []models.OrderChannel{models.ChannelWeb}, recorder.pickups)
+		assert.Empty(t, recorder.completions)
+		assert.GreaterOrEqual(t, recorder.durations[0], 2*time.Hour)
+	})
+
+	t.Run("Completion duration when the order reaches a final status", func(t *testing.T) {
+		service, recorder := setup(newOrder(models.StatusInProgress))
+
+		_, err := service.UpdateOrderStatus(context.Background(), "order-123", models.StatusCancelled)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []models.OrderStatus{models.StatusCancelled}, recorder.completions)
+		assert.Empty(t, recorder.pickups)
+		assert.GreaterOrEqual(t, recorder.durations[0], 2*time.Hour)
+	})
+
+	t.Run("Failed transitions are not observed", func(t *testing.T) {
+		service, recorder := setup(newOrder(models.StatusDelivered))
+
+		_, err := service.UpdateOrderStatus(context.Background(), "order-123", models.StatusInProgress)
+
+		assert.NotNil(t, err)
+		assert.Empty(t, recorder.completions)
+		assert.Empty(t, recorder.pickups)
+	})
+}
+
+func TestOrderService_CacheLookupMetrics(t *testing.T) {
+	stored := &models.Order{ID: "order-123", CustomerID: "customer-456", Status: models.StatusNew, Version: 1}
+
+	cases := []struct {
+		name   string
+		cached *models.Order
+		err    *repositories.RepositoryError
+		want   string
+	}{
+		{"Hit", stored, nil, "order:hit"},
+		{"Miss", nil, nil, "order:miss"},
+		{"Error", nil, &repositories.RepositoryError{StatusCode: 500, Cause: "redis down", Message: "Failed to get order from cache"}, "order:error"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockOrderRepository)
+			mockCache := new(MockCacheRepository)
+			recorder := &fakeMetricsRecorder{}
+			service := services.NewOrderService(mockRepo, mockCache, new(MockEventPublisher), zap.NewNop(),
+				services.WithMetricsRecorder(recorder))
+			mockCache.On("GetOrder", mock.Anything, "order-123").Return(tc.cached, tc.err)
+			mockRepo.On("FindByID", mock.Anything, "order-123").Return(stored, nil)
+			mockCache.On("SetOrder", mock.Anything, mock.Anything).Return(nil)
+
+			_, err := service.GetOrderByID(context.Background(), "order-123")
+
+			assert.Nil(t, err)
+			assert.Equal(t, []string{tc.want}, recorder.lookups)
+		})
+	}
+}
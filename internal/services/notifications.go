@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// Notifier asks the notifications pipeline to notify customers.
+type Notifier interface {
+	Notify(ctx context.Context, request *models.NotificationRequest) error
+}
+
+// NotificationRetrier retries the notification requests that could not be
+// published, off the request path.
+type NotificationRetrier interface {
+	Enqueue(request *models.NotificationRequest, cause string) bool
+}
+
+// noopNotifier notifies nobody, for environments without the notifications
+// pipeline.
+type noopNotifier struct{}
+
+// NewNoopNotifier returns a Notifier that does nothing.
+func NewNoopNotifier() Notifier {
+	return noopNotifier{}
+}
+
+func (noopNotifier) Notify(context.Context, *models.NotificationRequest) error {
+	return nil
+}
+
+// WithNotifier notifies customers when their orders are delivered or
+// cancelled. Requests that fail are handed to retrier, when set.
+func WithNotifier(notifier Notifier, retrier NotificationRetrier) Option {
+	return func(s *order) {
+		s.notifier = notifier
+		s.notifyRetrier = retrier
+	}
+}
+
+// notifyCustomer requests the notification of the order status, for the
+// statuses customers are notified of. A failed request never fails the
+// change; it is retried in the background.
+func (s *order) notifyCustomer(ctx context.Context, order *models.Order) {
+	template, ok := models.NotificationTemplates[order.Status]
+	if !ok {
+		return
+	}
+
+	ctx, cancel := detach(ctx)
+	defer cancel()
+
+	request := models.NewNotificationRequest(order, template)
+	err := s.notifier.Notify(ctx, request)
+	if err == nil {
+		return
+	}
+
+	s.logger.Warn("Failed to request customer notification",
+		zap.Error(err),
+		zap.String("orderId", order.ID),
+		zap.String("template", template),
+	)
+	if s.notifyRetrier == nil || !s.notifyRetrier.Enqueue(request, err.Error()) {
+		metrics.NotificationRequestsTotal.WithLabelValues("dropped").Inc()
+	}
+}
@@ -0,0 +1,129 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+	"orders/internal/services"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// recordingNotifier guarda las notificaciones pedidas, o falla si se le da
+// un error
+type recordingNotifier struct {
+	mu       sync.Mutex
+	requests []*models.NotificationRequest
+	err      error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, request *models.NotificationRequest) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.requests = append(n.requests, request)
+	return n.err
+}
+
+// queueRetrier guarda los reintentos encolados
+type queueRetrier struct {
+	requests []*models.NotificationRequest
+	causes   []string
+}
+
+func (r *queueRetrier) Enqueue(request *models.NotificationRequest, cause string) bool {
+	r.requests = append(r.requests, request)
+	r.causes = append(r.causes, cause)
+	return true
+}
+
+func TestOrderService_UpdateOrderStatus_NotifiesCustomer(t *testing.T) {
+	contact := &models.CustomerSnapshot{Email: "ana@example.com", Name: "Ana", Phone: "+34600000000"}
+	newService := func(status models.OrderStatus, logger *zap.Logger, opts ...services.Option) services.OrderService {
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(&models.Order{
+			ID:               "order-123",
+			CustomerID:       "customer-456",
+			Status:           status,
+			CustomerSnapshot: contact,
+			Version:          1,
+		}, nil)
+		mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+		mockCache := new(MockCacheRepository)
+		mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+		publisher := new(MockEventPublisher)
+		publisher.On("PublishOrderEvent", mock.Anything, mock.Anything).Return(nil)
+		return services.NewOrderService(mockRepo, mockCache, publisher, logger, opts...)
+	}
+
+	tests := []struct {
+		name     string
+		from     models.OrderStatus
+		to       models.OrderStatus
+		template string
+	}{
+		{"Delivered", models.StatusInProgress, models.StatusDelivered, models.TemplateOrderDelivered},
+		{"Cancelled", models.StatusNew, models.StatusCancelled, models.TemplateOrderCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &recordingNotifier{}
+			_, err := newService(tt.from, zap.NewNop(), services.WithNotifier(notifier, nil)).
+				UpdateOrderStatus(context.Background(), "order-123", tt.to)
+
+			require.Nil(t, err)
+			require.Len(t, notifier.requests, 1)
+			request := notifier.requests[0]
+			assert.Equal(t, tt.template, request.Template)
+			assert.Equal(t, "order-123", request.OrderNumber)
+			assert.Equal(t, "customer-456", request.CustomerID)
+			assert.Equal(t, contact, request.Contact)
+			assert.NotEmpty(t, request.NotificationID)
+		})
+	}
+
+	t.Run("Other transitions do not notify", func(t *testing.T) {
+		notifier := &recordingNotifier{}
+		_, err := newService(models.StatusNew, zap.NewNop(), services.WithNotifier(notifier, nil)).
+			UpdateOrderStatus(context.Background(), "order-123", models.StatusInProgress)
+
+		require.Nil(t, err)
+		assert.Empty(t, notifier.requests)
+	})
+
+	t.Run("Failure is retried without failing the update", func(t *testing.T) {
+		notifier := &recordingNotifier{err: errors.New("kafka unavailable")}
+		retrier := &queueRetrier{}
+		order, err := newService(models.StatusInProgress, zap.NewNop(), services.WithNotifier(notifier, retrier)).
+			UpdateOrderStatus(context.Background(), "order-123", models.StatusDelivered)
+
+		require.Nil(t, err)
+		assert.Equal(t, models.StatusDelivered, order.Status)
+		require.Len(t, retrier.requests, 1)
+		assert.Same(t, notifier.requests[0], retrier.requests[0])
+		assert.Equal(t, []string{"kafka unavailable"}, retrier.causes)
+	})
+
+	t.Run("Noop is silent", func(t *testing.T) {
+		core, logs := observer.New(zap.DebugLevel)
+		dropped := testutil.ToFloat64(metrics.NotificationRequestsTotal.WithLabelValues("dropped"))
+
+		_, err := newService(models.StatusInProgress, zap.New(core)).
+			UpdateOrderStatus(context.Background(), "order-123", models.StatusDelivered)
+
+		require.Nil(t, err)
+		for _, entry := range logs.All() {
+			assert.NotContains(t, strings.ToLower(entry.Message), "notification")
+		}
+		assert.Equal(t, dropped, testutil.ToFloat64(metrics.NotificationRequestsTotal.WithLabelValues("dropped")))
+	})
+}
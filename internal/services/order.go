@@ -2,32 +2,117 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	apperrors "orders/internal/errors"
 	"orders/internal/models"
 	"orders/internal/repositories"
 	"orders/internal/repositories/mongodb"
 	"orders/internal/repositories/redis"
+	"orders/internal/tenant"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type ServiceError struct {
 	Status            int           `json:"status"`
+	Code              string        `json:"code,omitempty"`
 	Message           string        `json:"message"`
 	Cause             []interface{} `json:"cause"`
 	StatusDescription string        `json:"status_description,omitempty"`
+	// Details carries structured data about the error, e.g. the current
+	// state of the order on conflicts.
+	Details interface{} `json:"details,omitempty"`
+	// RetryAfter tells rate limited clients when to try again.
+	RetryAfter time.Duration `json:"-"`
 }
 
 func (e *ServiceError) Error() string {
 	return fmt.Sprintf("status=%d, message=%s", e.Status, e.Message)
 }
 
+// CreateOrderInput carries the client-provided data of a new order.
+type CreateOrderInput struct {
+	CustomerID         string
+	Items              []models.OrderItem
+	Notes              string
+	PromisedDeliveryAt *time.Time
+	Priority           models.OrderPriority
+	Tags               []string
+	Channel            models.OrderChannel
+	ClientMetadata     map[string]string
+	// Customer is the optional contact data of the customer given by the client.
+	Customer *models.CustomerSnapshot
+	// InitialStatus is the status the order is created in, NEW when empty. It
+	// must be one of the statuses set with WithInitialStatuses.
+	InitialStatus models.OrderStatus
+	// TotalAmount is the total the client expects. When set, orders whose
+	// computed total differs by more than totalAmountTolerance are rejected.
+	TotalAmount *float64
+}
+
+// totalAmountTolerance absorbs the rounding differences between the total a
+// client declares and the one computed from the items.
+const totalAmountTolerance = 0.01
+
+// ListOrdersFilter narrows down the orders returned by ListOrders. Zero values
+// do not filter.
+type ListOrdersFilter struct {
+	Status     string
+	CustomerID string
+	Priority   string
+	Channel    string
+	// Tags only matches orders carrying all of the given tags.
+	Tags        []string
+	SLABreached *bool
+	// From and To bound the creation time of the orders, inclusive.
+	From *time.Time
+	To   *time.Time
+	// MinWeight and MaxWeight bound the total weight of the orders in grams,
+	// inclusive.
+	MinWeight *int
+	MaxWeight *int
+	// MinAmount and MaxAmount bound the total amount of the orders, inclusive.
+	MinAmount *float64
+	MaxAmount *float64
+	// Query is a text search over the SKUs and notes of the orders.
+	Query string
+	// SkipTotal lists the orders without counting them; the total is then
+	// repositories.UnknownTotal.
+	SkipTotal bool
+	// SortBy is one of createdAt, updatedAt, totalAmount, totalWeightGrams,
+	// priority or relevance and SortDir is asc or desc. Empty values sort newest first,
+	// or by relevance when searching.
+	SortBy  string
+	SortDir string
+}
+
 type OrderService interface {
-	CreateOrder(ctx context.Context, customerID string, items []models.OrderItem) (*models.Order, *ServiceError)
+	CreateOrder(ctx context.Context, input CreateOrderInput) (*models.Order, *ServiceError)
 	GetOrderByID(ctx context.Context, orderID string) (*models.Order, *ServiceError)
+	GetOrderSummary(ctx context.Context, orderID string) (*models.OrderSummary, *ServiceError)
+	GetOrderTransitions(ctx context.Context, orderID string) (*models.StatusTransitions, *ServiceError)
 	UpdateOrderStatus(ctx context.Context, orderID string, newStatus models.OrderStatus) (*models.Order, *ServiceError)
-	ListOrders(ctx context.Context, status, customerID string, page, limit int) ([]*models.Order, int64, *ServiceError)
+	ForceOrderStatus(ctx context.Context, orderID string, newStatus models.OrderStatus, actor, reason string) (*models.Order, *ServiceError)
+	UpdateOrderPriority(ctx context.Context, orderID string, priority models.OrderPriority) (*models.Order, *ServiceError)
+	UpdateOrderTags(ctx context.Context, orderID string, tags []string) (*models.Order, *ServiceError)
+	ListOrders(ctx context.Context, filter ListOrdersFilter, page, limit int) ([]*models.Order, int64, *ServiceError)
+	StreamOrders(ctx context.Context, filter ListOrdersFilter, page, limit int, each func(*models.Order) error) (int64, *ServiceError)
+	ListOrdersVersion(ctx context.Context, filter ListOrdersFilter) *models.ListVersion
+	AddOrderNote(ctx context.Context, orderID, author, text string) (*models.Order, *ServiceError)
+	ListOrderNotes(ctx context.Context, orderID string, page, limit int) ([]models.OrderNote, int, *ServiceError)
+	ReplayOrderEvents(ctx context.Context, orderID string) (int, *ServiceError)
+	ListOrderEvents(ctx context.Context, orderID string, limit int) ([]*models.EventRecord, *ServiceError)
+	ListEvents(ctx context.Context, filter ListEventsFilter, page, limit int) ([]*models.EventRecord, int64, *ServiceError)
+	GetEvent(ctx context.Context, eventID string) (*models.EventRecord, *ServiceError)
+	RetryEvent(ctx context.Context, eventID string) (*models.EventRecord, *ServiceError)
+	NotifySLABreaches(ctx context.Context, limit int) (int, *ServiceError)
+	RequestOrderReturn(ctx context.Context, orderID, reason string, items []models.ReturnItem) (*models.Order, *ServiceError)
+	RecordOrderDelivery(ctx context.Context, orderID string, items []models.DeliveryItem) (*models.Order, *ServiceError)
+	GetOrderStats(ctx context.Context) (*models.OrderStats, *ServiceError)
 }
 
 type CacheRepository interface {
@@ -36,42 +121,280 @@ type CacheRepository interface {
 	InvalidateOrder(ctx context.Context, orderID string) *repositories.RepositoryError
 }
 
+// CacheWriteRetrier retries failed cache writes off the request path.
+type CacheWriteRetrier interface {
+	Enqueue(order *models.Order) bool
+}
+
 type EventPublisher interface {
 	PublishOrderEvent(ctx context.Context, event *models.OrderEvent) error
+	RepublishOrderEvent(ctx context.Context, event *models.OrderEvent) error
 }
 
 type order struct {
 	orderRepo      mongodb.Repository
 	cacheRepo      redis.Repository
 	eventPublisher EventPublisher
+	eventStore     EventStore
+	priceProvider  PriceProvider
+	customers      CustomerValidator
+	customerSoft   bool
+	customerIDs    models.CustomerIDFormat
+	skus           SKUValidator
+	skuFailOpen    bool
+	startStatuses  []models.OrderStatus
+	inventory      InventoryReserver
+	reserveStage   ReservationStage
+	releases       InventoryReleaseStore
+	releaseDelay   time.Duration
+	notifier       Notifier
+	notifyRetrier  NotificationRetrier
+	maxNoteLength  int
+	maxNotes       int
+	consolidate    bool
+	maxWeight      int
+	cacheEnabled   bool
+	compactCache   bool
+	features       FeatureFlags
+	cacheRetrier   CacheWriteRetrier
+	writeTracker   WriteTracker
+	deliverySLA    time.Duration
+	minPromiseLead time.Duration
+	maxPromiseLead time.Duration
+	returnWindow   time.Duration
+	creationLimit  CreationLimiter
+	statsTTL       time.Duration
+	summaries      SummaryReader
+	metrics        MetricsRecorder
 	logger         *zap.Logger
 }
 
-func NewOrderService(orderRepo mongodb.Repository, cacheRepo redis.Repository, eventPublisher EventPublisher, logger *zap.Logger) OrderService {
-	return &order{
+// Option customizes optional collaborators of the order service.
+type Option func(*order)
+
+// WithPriceProvider sets the provider used to resolve item prices server-side.
+func WithPriceProvider(provider PriceProvider) Option {
+	return func(s *order) {
+		s.priceProvider = provider
+	}
+}
+
+// WithCustomerValidator enables customer existence checks on order creation.
+// When softFail is true, orders are accepted if the check cannot be performed.
+func WithCustomerValidator(validator CustomerValidator, softFail bool) Option {
+	return func(s *order) {
+		s.customers = validator
+		s.customerSoft = softFail
+	}
+}
+
+// WithMaxNoteLength caps the length of order notes. Zero disables the cap.
+func WithMaxNoteLength(maxLength int) Option {
+	return func(s *order) {
+		s.maxNoteLength = maxLength
+	}
+}
+
+// WithMaxNotesPerOrder caps how many note entries are kept on an order; the
+// oldest entries are dropped first. Zero keeps every note.
+func WithMaxNotesPerOrder(maxNotes int) Option {
+	return func(s *order) {
+		s.maxNotes = maxNotes
+	}
+}
+
+// WithItemConsolidation merges order lines that share the same SKU at creation.
+func WithItemConsolidation(enabled bool) Option {
+	return func(s *order) {
+		s.consolidate = enabled
+	}
+}
+
+// WithMaxOrderWeight rejects new orders heavier than maxGrams in total. Zero
+// disables the limit.
+func WithMaxOrderWeight(maxGrams int) Option {
+	return func(s *order) {
+		s.maxWeight = maxGrams
+	}
+}
+
+func NewOrderService(orderRepo mongodb.Repository, cacheRepo redis.Repository, eventPublisher EventPublisher, logger *zap.Logger, opts ...Option) OrderService {
+	s := &order{
 		orderRepo:      orderRepo,
 		cacheRepo:      cacheRepo,
 		eventPublisher: eventPublisher,
+		priceProvider:  NewPassthroughPriceProvider(),
+		inventory:      NewNoopInventoryReserver(),
+		notifier:       NewNoopNotifier(),
+		startStatuses:  []models.OrderStatus{models.StatusNew},
+		cacheEnabled:   true,
+		metrics:        prometheusRecorder{},
 		logger:         logger,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *order) CreateOrder(ctx context.Context, customerID string, items []models.OrderItem) (*models.Order, *ServiceError) {
+func (s *order) CreateOrder(ctx context.Context, input CreateOrderInput) (*models.Order, *ServiceError) {
+	customerID := input.CustomerID
 	s.logger.Debug("Creating order",
 		zap.String("customerId", customerID),
-		zap.Int("itemsCount", len(items)),
+		zap.Int("itemsCount", len(input.Items)),
 	)
 
-	order, err := models.NewOrder(customerID, items)
+	notes := models.SanitizeNote(input.Notes)
+	if notes != "" {
+		if err := models.ValidateNote(notes, s.maxNoteLength); err != nil {
+			return nil, noteValidationError(err, s.maxNoteLength)
+		}
+	}
+
+	promisedDeliveryAt, svcErr := s.promisedDelivery(input.PromisedDeliveryAt, time.Now())
+	if svcErr != nil {
+		return nil, svcErr
+	}
+
+	priority := input.Priority
+	if priority == "" {
+		priority = models.PriorityNormal
+	}
+	if !priority.IsValid() {
+		return nil, priorityValidationError()
+	}
+
+	tags, tagErr := models.NormalizeTags(input.Tags)
+	if tagErr != nil {
+		return nil, tagValidationError(tagErr)
+	}
+
+	if input.Channel != "" && !input.Channel.IsValid() {
+		return nil, channelValidationError()
+	}
+	if err := models.ValidateClientMetadata(input.ClientMetadata); err != nil {
+		return nil, metadataValidationError(err)
+	}
+
+	items := models.NormalizeItems(input.Items)
+	if s.consolidate {
+		consolidated, err := models.ConsolidateItems(items)
+		if err != nil {
+			s.logger.Warn("Failed to consolidate order items",
+				zap.Error(err),
+				zap.String("customerId", customerID),
+			)
+			return nil, itemValidationError(err)
+		}
+		items = consolidated
+	}
+
+	if svcErr := s.validateSKUs(ctx, customerID, items); svcErr != nil {
+		return nil, svcErr
+	}
+
+	items, priceErr := s.priceProvider.ResolvePrices(ctx, items)
+	if priceErr != nil {
+		var unknownErr *models.UnknownSKUsError
+		if errors.As(priceErr, &unknownErr) {
+			s.logger.Warn("Order references unknown SKUs",
+				zap.Strings("skus", unknownErr.SKUs),
+				zap.String("customerId", customerID),
+			)
+			cause := make([]interface{}, 0, len(unknownErr.SKUs))
+			for _, sku := range unknownErr.SKUs {
+				cause = append(cause, sku)
+			}
+			return nil, &ServiceError{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "UNKNOWN_SKU",
+				Message: "Order contains unknown SKUs",
+				Cause:   cause,
+			}
+		}
+		s.logger.Error("Failed to resolve item prices",
+			zap.Error(priceErr),
+			zap.String("customerId", customerID),
+		)
+		return nil, &ServiceError{
+			Status:  http.StatusServiceUnavailable,
+			Message: "Failed to resolve item prices",
+			Cause:   []interface{}{priceErr.Error()},
+		}
+	}
+
+	order, err := models.NewOrder(customerID, items, s.customerIDs)
 	if err != nil {
 		s.logger.Error("Failed to create order entity",
 			zap.Error(err),
 			zap.String("customerId", customerID),
 		)
+		return nil, itemValidationError(err)
+	}
+	order.TenantID = tenant.ID(ctx)
+	if err := order.SetInitialStatus(input.InitialStatus, s.startStatuses); err != nil {
+		return nil, initialStatusValidationError(s.startStatuses)
+	}
+	if input.TotalAmount != nil && math.Abs(*input.TotalAmount-order.TotalAmount) > totalAmountTolerance {
+		s.logger.Warn("Declared order total does not match the items",
+			zap.String("customerId", customerID),
+			zap.Float64("declaredTotal", *input.TotalAmount),
+			zap.Float64("computedTotal", order.TotalAmount),
+		)
 		return nil, &ServiceError{
 			Status:  http.StatusBadRequest,
-			Message: "Invalid order data",
-			Cause:   []interface{}{err.Error()},
+			Code:    "TOTAL_MISMATCH",
+			Message: fmt.Sprintf("Declared total %.2f does not match the computed total %.2f", *input.TotalAmount, order.TotalAmount),
+			Cause: []interface{}{map[string]float64{
+				"declared": *input.TotalAmount,
+				"computed": order.TotalAmount,
+			}},
+		}
+	}
+	if s.maxWeight > 0 && order.TotalWeightGrams > s.maxWeight {
+		s.logger.Warn("Order exceeds the maximum weight",
+			zap.String("customerId", customerID),
+			zap.Int("totalWeightGrams", order.TotalWeightGrams),
+			zap.Int("maxWeightGrams", s.maxWeight),
+		)
+		return nil, &ServiceError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "ORDER_TOO_HEAVY",
+			Message: fmt.Sprintf("Order weighs %d g, above the maximum of %d g", order.TotalWeightGrams, s.maxWeight),
+		}
+	}
+
+	order.Notes = notes
+	order.PromisedDeliveryAt = promisedDeliveryAt
+	order.Priority = priority
+	order.PriorityRank = priority.Rank()
+	if len(tags) > 0 {
+		order.Tags = tags
+	}
+	order.Channel = input.Channel
+	if len(input.ClientMetadata) > 0 {
+		order.ClientMetadata = input.ClientMetadata
+	}
+
+	if svcErr := s.validateCustomer(ctx, customerID); svcErr != nil {
+		return nil, svcErr
+	}
+	order.CustomerSnapshot = s.customerSnapshot(ctx, customerID, input.Customer)
+
+	if svcErr := s.throttleCreation(ctx, customerID); svcErr != nil {
+		return nil, svcErr
+	}
+
+	// Nothing is stored for a client that went away while the order was
+	// being validated
+	if svcErr := contextError(ctx); svcErr != nil {
+		return nil, svcErr
+	}
+
+	reserve := s.reservesInventory()
+	if reserve && s.reserveStage != ReserveAfterPersist {
+		if svcErr := s.reserveInventory(ctx, order); svcErr != nil {
+			return nil, svcErr
 		}
 	}
 
@@ -80,58 +403,114 @@ func (s *order) CreateOrder(ctx context.Context, customerID string, items []mode
 			// zap.Error(err),
 			zap.String("orderId", order.ID),
 		)
-		return nil, &ServiceError{
-			Status:  err.StatusCode,
-			Message: err.Message,
-			Cause:   []interface{}{err.Cause},
+		if reserve && s.reserveStage != ReserveAfterPersist {
+			s.releaseInventory(ctx, order.ID)
 		}
+		return nil, repositoryError(ctx, err)
 	}
 
+	if reserve && s.reserveStage == ReserveAfterPersist {
+		if svcErr := s.reserveInventory(ctx, order); svcErr != nil {
+			s.cancelUnreserved(ctx, order)
+			s.recordWrite(ctx, order.CustomerID)
+			return nil, svcErr
+		}
+	}
+
+	s.recordWrite(ctx, order.CustomerID)
+
 	s.logger.Info("Order created successfully",
 		zap.String("orderId", order.ID),
 		zap.String("customerId", order.CustomerID),
 		zap.Float64("totalAmount", order.TotalAmount),
 	)
 
+	s.emitEvent(ctx, models.NewOrderCreatedEvent(order).SetStates(nil, order))
+
 	return order, nil
 }
 
+// validateCustomer checks that the customer exists when a validator is configured.
+func (s *order) validateCustomer(ctx context.Context, customerID string) *ServiceError {
+	if s.customers == nil {
+		return nil
+	}
+
+	exists, err := s.customers.Exists(ctx, customerID)
+	if err != nil {
+		if s.customerSoft {
+			s.logger.Warn("Customer validation unavailable, accepting order",
+				zap.Error(err),
+				zap.String("customerId", customerID),
+			)
+			return nil
+		}
+		s.logger.Error("Failed to validate customer",
+			zap.Error(err),
+			zap.String("customerId", customerID),
+		)
+		return &ServiceError{
+			Status:  http.StatusServiceUnavailable,
+			Message: "Failed to validate customer",
+			Cause:   []interface{}{err.Error()},
+		}
+	}
+
+	if !exists {
+		s.logger.Warn("Order for unknown customer rejected",
+			zap.String("customerId", customerID),
+		)
+		return &ServiceError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "CUSTOMER_NOT_FOUND",
+			Message: "Customer not found",
+			Cause:   []interface{}{customerID},
+		}
+	}
+
+	return nil
+}
+
 func (s *order) GetOrderByID(ctx context.Context, orderID string) (*models.Order, *ServiceError) {
 	s.logger.Debug("Getting order by ID",
 		zap.String("orderId", orderID),
 	)
 
-	order, err := s.cacheRepo.GetOrder(ctx, orderID)
-	if err != nil {
-		s.logger.Warn("Cache error, falling back to database",
-			// zap.Error(err),
-			zap.String("orderId", orderID),
-		)
-	} else if order != nil {
-		s.logger.Debug("Order found in cache",
-			zap.String("orderId", orderID),
-		)
-		return order, nil
+	if s.useCache() {
+		order, err := s.cacheRepo.GetOrder(ctx, orderID)
+		if err != nil {
+			s.metrics.CacheLookup("order", CacheError)
+			s.logger.Warn("Cache error, falling back to database",
+				// zap.Error(err),
+				zap.String("orderId", orderID),
+			)
+		} else if order != nil && !order.IsDeleted() {
+			s.metrics.CacheLookup("order", CacheHit)
+			s.logger.Debug("Order found in cache",
+				zap.String("orderId", orderID),
+			)
+			return order, nil
+		} else {
+			s.metrics.CacheLookup("order", CacheMiss)
+		}
 	}
 
-	order, err = s.orderRepo.FindByID(ctx, orderID)
+	if svcErr := contextError(ctx); svcErr != nil {
+		return nil, svcErr
+	}
+
+	// Soft-deleted orders are reported by the repository, as not found or
+	// gone depending on the caller
+	order, err := s.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
 		s.logger.Error("Failed to get order from database",
 			zap.String("Message", err.Message),
 			zap.Int("StatusCode", err.StatusCode),
 		)
-		return nil, &ServiceError{
-			Status:  err.StatusCode,
-			Message: err.Message,
-			Cause:   []interface{}{err.Cause},
-		}
+		return nil, repositoryError(ctx, err)
 	}
 
-	if err := s.cacheRepo.SetOrder(ctx, order); err != nil {
-		s.logger.Warn("Failed to cache order",
-			zap.String("orderId", orderID),
-		)
-	}
+	s.cacheOrder(ctx, order)
 
 	s.logger.Debug("Order retrieved from database",
 		zap.String("orderId", orderID),
@@ -141,34 +520,72 @@ func (s *order) GetOrderByID(ctx context.Context, orderID string) (*models.Order
 
 }
 
-func (s *order) ListOrders(ctx context.Context, status, customerID string, page, limit int) ([]*models.Order, int64, *ServiceError) {
+// GetOrderSummary returns the compact projection of an order. With compact
+// caching the summary is cached on its own and the full order is left to be
+// cached by GetOrderByID; otherwise it is projected from the full order.
+func (s *order) GetOrderSummary(ctx context.Context, orderID string) (*models.OrderSummary, *ServiceError) {
+	if !s.useCompactCache() {
+		order, svcErr := s.GetOrderByID(ctx, orderID)
+		if svcErr != nil {
+			return nil, svcErr
+		}
+		return order.Summary(), nil
+	}
+
+	summary, err := s.cacheRepo.GetOrderSummary(ctx, orderID)
+	switch {
+	case err != nil:
+		s.metrics.CacheLookup("summary", CacheError)
+		s.logger.Warn("Cache error, falling back to database",
+			zap.String("orderId", orderID),
+		)
+	case summary != nil:
+		s.metrics.CacheLookup("summary", CacheHit)
+		return summary, nil
+	default:
+		s.metrics.CacheLookup("summary", CacheMiss)
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+
+	summary = order.Summary()
+	cacheCtx, cancel := detach(ctx)
+	defer cancel()
+	if err := s.cacheRepo.SetOrderSummary(cacheCtx, summary); err != nil {
+		s.logger.Warn("Failed to cache order summary",
+			zap.String("orderId", orderID),
+		)
+	}
+
+	return summary, nil
+}
+
+func (s *order) ListOrders(ctx context.Context, filter ListOrdersFilter, page, limit int) ([]*models.Order, int64, *ServiceError) {
 	s.logger.Debug("Listing orders",
-		zap.String("status", status),
-		zap.String("customerId", customerID),
+		zap.String("status", filter.Status),
+		zap.String("customerId", filter.CustomerID),
 		zap.Int("page", page),
 		zap.Int("limit", limit),
 	)
 
-	filters := make(map[string]interface{})
-	if status != "" {
-		filters["status"] = status
+	var orders []*models.Order
+	var total int64
+	var err *repositories.RepositoryError
+	if s.useSummary() && filter.summarized() {
+		orders, total, err = s.listFromSummary(ctx, filter, page, limit)
+	} else {
+		orders, total, err = s.orderRepo.FindWithFilters(ctx, filter.repositoryFilters(), page, limit)
 	}
-	if customerID != "" {
-		filters["customerId"] = customerID
-	}
-
-	orders, total, err := s.orderRepo.FindWithFilters(ctx, filters, page, limit)
 	if err != nil {
 		s.logger.Error("Failed to list orders",
 			zap.String("Message", err.Message),
 			zap.Int("StatusCode", err.StatusCode),
 			zap.String("Cause", err.Cause),
 		)
-		return nil, 0, &ServiceError{
-			Status:  err.StatusCode,
-			Message: err.Message,
-			Cause:   []interface{}{err.Cause},
-		}
+		return nil, 0, repositoryError(ctx, err)
 	}
 
 	s.logger.Debug("Orders listed successfully",
@@ -179,6 +596,86 @@ func (s *order) ListOrders(ctx context.Context, status, customerID string, page,
 	return orders, total, nil
 }
 
+// StreamOrders lists the same page as ListOrders but hands each order to each
+// as it is read, so large pages are never held in memory as a whole. It
+// returns the total once every order has been handed over; when each fails
+// the iteration stops and its error is returned as the cause.
+func (s *order) StreamOrders(ctx context.Context, filter ListOrdersFilter, page, limit int, each func(*models.Order) error) (int64, *ServiceError) {
+	s.logger.Debug("Streaming orders",
+		zap.String("status", filter.Status),
+		zap.String("customerId", filter.CustomerID),
+		zap.Int("page", page),
+		zap.Int("limit", limit),
+	)
+
+	total, err := s.orderRepo.StreamWithFilters(ctx, filter.repositoryFilters(), page, limit, each)
+	if err != nil {
+		s.logger.Error("Failed to stream orders",
+			zap.String("Message", err.Message),
+			zap.Int("StatusCode", err.StatusCode),
+			zap.String("Cause", err.Cause),
+		)
+		return 0, repositoryError(ctx, err)
+	}
+
+	return total, nil
+}
+
+// repositoryFilters translates the filter into the filters understood by the
+// order repository.
+func (filter ListOrdersFilter) repositoryFilters() map[string]interface{} {
+	filters := make(map[string]interface{})
+	if filter.Status != "" {
+		filters["status"] = filter.Status
+	}
+	if filter.CustomerID != "" {
+		filters["customerId"] = filter.CustomerID
+	}
+	if filter.Priority != "" {
+		filters["priority"] = filter.Priority
+	}
+	if filter.Channel != "" {
+		filters["channel"] = filter.Channel
+	}
+	if len(filter.Tags) > 0 {
+		filters["tags"] = filter.Tags
+	}
+	if filter.Query != "" {
+		filters["q"] = filter.Query
+	}
+	if filter.SLABreached != nil {
+		filters["slaBreached"] = *filter.SLABreached
+	}
+	if filter.From != nil {
+		filters["from"] = *filter.From
+	}
+	if filter.To != nil {
+		filters["to"] = *filter.To
+	}
+	if filter.MinWeight != nil {
+		filters["minWeight"] = *filter.MinWeight
+	}
+	if filter.MaxWeight != nil {
+		filters["maxWeight"] = *filter.MaxWeight
+	}
+	if filter.MinAmount != nil {
+		filters["minAmount"] = *filter.MinAmount
+	}
+	if filter.MaxAmount != nil {
+		filters["maxAmount"] = *filter.MaxAmount
+	}
+	if filter.SkipTotal {
+		filters["skipTotal"] = true
+	}
+	if filter.SortBy != "" {
+		filters["sortBy"] = filter.SortBy
+	}
+	if filter.SortDir != "" {
+		filters["sortDir"] = filter.SortDir
+	}
+	return filters
+}
+
 func (s *order) UpdateOrderStatus(ctx context.Context, orderID string, newStatus models.OrderStatus) (*models.Order, *ServiceError) {
 	s.logger.Debug("Updating order status",
 		zap.String("orderId", orderID),
@@ -187,13 +684,10 @@ func (s *order) UpdateOrderStatus(ctx context.Context, orderID string, newStatus
 
 	order, err := s.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
-		return nil, &ServiceError{
-			Status:  err.StatusCode,
-			Message: err.Message,
-			Cause:   []interface{}{err.Cause},
-		}
+		return nil, repositoryError(ctx, err)
 	}
 
+	before := order.Clone()
 	oldStatus := order.Status
 
 	if err := order.UpdateStatus(newStatus); err != nil {
@@ -205,42 +699,187 @@ func (s *order) UpdateOrderStatus(ctx context.Context, orderID string, newStatus
 		)
 		return nil, &ServiceError{
 			Status:  http.StatusBadRequest,
+			Code:    "INVALID_STATUS_TRANSITION",
 			Message: "Invalid status transition",
 			Cause:   []interface{}{err.Error()},
+			Details: apperrors.NewOrderStateDetails(before),
 		}
 	}
 
+	if svcErr := contextError(ctx); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := changeError(before, order); svcErr != nil {
+		return nil, svcErr
+	}
+
 	if err := s.orderRepo.Update(ctx, order); err != nil {
 		s.logger.Error("Failed to update order",
 			zap.String("orderId", orderID),
 		)
-		return nil, &ServiceError{
+		if svcErr := contextError(ctx); svcErr != nil {
+			return nil, svcErr
+		}
+		svcErr := &ServiceError{
 			Status:  err.StatusCode,
 			Message: err.Message,
 			Cause:   []interface{}{err.Cause},
 		}
+		switch {
+		case err.Cause == repositories.CauseDuplicateKey:
+			svcErr.Code = repositories.CauseDuplicateKey
+		case err.StatusCode == http.StatusConflict:
+			svcErr.Code = "VERSION_CONFLICT"
+			if current := s.currentOrderState(ctx, orderID); current != nil {
+				svcErr.Details = current
+			}
+		}
+		return nil, svcErr
+	}
+
+	s.invalidateCachedOrder(ctx, orderID)
+	s.recordWrite(ctx, order.CustomerID)
+
+	var event *models.OrderEvent
+	if newStatus == models.StatusReturned {
+		event = models.NewOrderReturnEvent(order, oldStatus)
+	} else {
+		event = models.NewOrderStatusChangedEvent(order.ID, order.CustomerID, oldStatus, newStatus)
+		event.SetOrderDetails(order)
+	}
+	s.emitEvent(ctx, event.SetStates(before, order))
+	s.observeTransition(order, oldStatus)
+
+	if newStatus == models.StatusCancelled {
+		s.releaseInventory(ctx, order.ID)
 	}
+	s.notifyCustomer(ctx, order)
+
+	s.logger.Info("Order status updated successfully",
+		zap.String("orderId", orderID),
+		zap.String("oldStatus", string(oldStatus)),
+		zap.String("newStatus", string(newStatus)),
+	)
 
-	if err := s.cacheRepo.InvalidateOrder(ctx, orderID); err != nil {
-		s.logger.Warn("Failed to invalidate cache",
+	return order, nil
+}
+
+// currentOrderState reads the order again after a conflicting write and
+// describes its state, or returns nil when it cannot be read.
+func (s *order) currentOrderState(ctx context.Context, orderID string) *apperrors.OrderStateDetails {
+	current, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		s.logger.Warn("Failed to reload conflicting order",
 			zap.String("orderId", orderID),
+			zap.String("cause", err.Cause),
 		)
+		return nil
 	}
+	return apperrors.NewOrderStateDetails(current)
+}
 
-	event := models.NewOrderStatusChangedEvent(order.ID, order.CustomerID, oldStatus, newStatus)
-	if err := s.eventPublisher.PublishOrderEvent(ctx, event); err != nil {
-		s.logger.Error("Failed to publish event",
-			zap.Error(err),
+func (s *order) AddOrderNote(ctx context.Context, orderID, author, text string) (*models.Order, *ServiceError) {
+	s.logger.Debug("Adding order note",
+		zap.String("orderId", orderID),
+		zap.String("author", author),
+	)
+
+	note := models.NewOrderNote(author, text)
+	if note.Author == "" {
+		return nil, &ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "NOTE_AUTHOR_REQUIRED",
+			Message: "Note author is required",
+			Cause:   []interface{}{models.ErrNoteAuthorRequired.Error()},
+		}
+	}
+	if err := models.ValidateNote(note.Text, s.maxNoteLength); err != nil {
+		return nil, noteValidationError(err, s.maxNoteLength)
+	}
+
+	if svcErr := contextError(ctx); svcErr != nil {
+		return nil, svcErr
+	}
+
+	order, err := s.orderRepo.AppendNote(ctx, orderID, note, s.maxNotes)
+	if err != nil {
+		s.logger.Error("Failed to append order note",
 			zap.String("orderId", orderID),
-			zap.String("eventId", event.EventID),
+			zap.String("Message", err.Message),
 		)
+		return nil, repositoryError(ctx, err)
 	}
 
-	s.logger.Info("Order status updated successfully",
+	s.invalidateCachedOrder(ctx, orderID)
+	s.recordWrite(ctx, order.CustomerID)
+
+	s.logger.Info("Order note added",
 		zap.String("orderId", orderID),
-		zap.String("oldStatus", string(oldStatus)),
-		zap.String("newStatus", string(newStatus)),
+		zap.String("author", note.Author),
+		zap.Int("notesCount", len(order.NoteEntries)),
 	)
 
 	return order, nil
 }
+
+// ListOrderNotes returns a page of the order's notes, newest first, along with
+// the total number of notes.
+func (s *order) ListOrderNotes(ctx context.Context, orderID string, page, limit int) ([]models.OrderNote, int, *ServiceError) {
+	order, svcErr := s.GetOrderByID(ctx, orderID)
+	if svcErr != nil {
+		return nil, 0, svcErr
+	}
+	if order.IsDeleted() {
+		return nil, 0, &ServiceError{
+			Status:  http.StatusGone,
+			Message: "Order has been deleted",
+			Cause:   []interface{}{models.ErrOrderDeleted.Error()},
+		}
+	}
+
+	total := len(order.NoteEntries)
+	notes := make([]models.OrderNote, 0, limit)
+	for i := total - 1 - (page-1)*limit; i >= 0 && len(notes) < limit; i-- {
+		notes = append(notes, order.NoteEntries[i])
+	}
+
+	return notes, total, nil
+}
+
+func noteValidationError(err error, maxLength int) *ServiceError {
+	if errors.Is(err, models.ErrNoteTooLong) {
+		return &ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "NOTE_TOO_LONG",
+			Message: fmt.Sprintf("Note must be at most %d characters", maxLength),
+			Cause:   []interface{}{err.Error()},
+		}
+	}
+	return &ServiceError{
+		Status:  http.StatusBadRequest,
+		Code:    "NOTE_EMPTY",
+		Message: "Note text is required",
+		Cause:   []interface{}{err.Error()},
+	}
+}
+
+func itemValidationError(err error) *ServiceError {
+	var itemErr *models.ItemError
+	if errors.As(err, &itemErr) {
+		return &ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_ITEM",
+			Message: "Invalid order data",
+			Cause: []interface{}{map[string]string{
+				"sku":     itemErr.SKU,
+				"field":   itemErr.Field,
+				"message": itemErr.Message,
+			}},
+		}
+	}
+	return &ServiceError{
+		Status:  http.StatusBadRequest,
+		Message: "Invalid order data",
+		Cause:   []interface{}{err.Error()},
+	}
+}
@@ -2,13 +2,27 @@ package services_test
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"orders/internal/clients/catalog"
+	"orders/internal/clients/customers"
+	"orders/internal/correlation"
+	apperrors "orders/internal/errors"
+	"orders/internal/features"
+	"orders/internal/messages/memory"
 	"orders/internal/models"
 	"orders/internal/repositories"
 	"orders/internal/services"
+	"orders/internal/tenant"
+	"orders/pkg/httpclient"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -40,6 +54,14 @@ func (m *MockOrderRepository) FindByID(ctx context.Context, id string) (*models.
 	return order, repoErr
 }
 
+func (m *MockOrderRepository) FindByIDs(ctx context.Context, ids []string) ([]*models.Order, *repositories.RepositoryError) {
+	args := m.Called(ctx, ids)
+	if v := args.Get(1); v != nil {
+		return nil, v.(*repositories.RepositoryError)
+	}
+	return args.Get(0).([]*models.Order), nil
+}
+
 func (m *MockOrderRepository) FindWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.Order, int64, *repositories.RepositoryError) {
 	args := m.Called(ctx, filters, page, limit)
 
@@ -61,6 +83,31 @@ func (m *MockOrderRepository) FindWithFilters(ctx context.Context, filters map[s
 	return orders, total, repoErr
 }
 
+// StreamWithFilters entrega uno a uno los pedidos configurados como primer retorno
+func (m *MockOrderRepository) StreamWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int, each func(*models.Order) error) (int64, *repositories.RepositoryError) {
+	args := m.Called(ctx, filters, page, limit)
+
+	if v := args.Get(0); v != nil {
+		for _, order := range v.([]*models.Order) {
+			if err := each(order); err != nil {
+				return 0, &repositories.RepositoryError{StatusCode: 500, Cause: err.Error(), Message: "Failed to stream orders"}
+			}
+		}
+	}
+
+	var total int64
+	if v := args.Get(1); v != nil {
+		total = v.(int64)
+	}
+
+	var repoErr *repositories.RepositoryError
+	if v := args.Get(2); v != nil {
+		repoErr = v.(*repositories.RepositoryError)
+	}
+
+	return total, repoErr
+}
+
 func (m *MockOrderRepository) Update(ctx context.Context, order *models.Order) *repositories.RepositoryError {
 	args := m.Called(ctx, order)
 
@@ -70,6 +117,54 @@ func (m *MockOrderRepository) Update(ctx context.Context, order *models.Order) *
 	return nil
 }
 
+func (m *MockOrderRepository) AppendNote(ctx context.Context, id string, note models.OrderNote, maxNotes int) (*models.Order, *repositories.RepositoryError) {
+	args := m.Called(ctx, id, note, maxNotes)
+
+	var order *models.Order
+	if v := args.Get(0); v != nil {
+		order = v.(*models.Order)
+	}
+
+	var repoErr *repositories.RepositoryError
+	if v := args.Get(1); v != nil {
+		repoErr = v.(*repositories.RepositoryError)
+	}
+
+	return order, repoErr
+}
+
+func (m *MockOrderRepository) FindSLABreachCandidates(ctx context.Context, now time.Time, limit int) ([]*models.Order, *repositories.RepositoryError) {
+	args := m.Called(ctx, now, limit)
+	if v := args.Get(1); v != nil {
+		return nil, v.(*repositories.RepositoryError)
+	}
+	return args.Get(0).([]*models.Order), nil
+}
+
+func (m *MockOrderRepository) MarkSLABreachNotified(ctx context.Context, id string, at time.Time) (bool, *repositories.RepositoryError) {
+	args := m.Called(ctx, id, at)
+	if v := args.Get(1); v != nil {
+		return false, v.(*repositories.RepositoryError)
+	}
+	return args.Bool(0), nil
+}
+
+func (m *MockOrderRepository) Stats(ctx context.Context, since time.Time) (*models.OrderStats, *repositories.RepositoryError) {
+	args := m.Called(ctx, since)
+	if v := args.Get(1); v != nil {
+		return nil, v.(*repositories.RepositoryError)
+	}
+	return args.Get(0).(*models.OrderStats), nil
+}
+
+func (m *MockOrderRepository) Search(ctx context.Context, term string, limit int) ([]*models.Order, *repositories.RepositoryError) {
+	args := m.Called(ctx, term, limit)
+	if v := args.Get(1); v != nil {
+		return nil, v.(*repositories.RepositoryError)
+	}
+	return args.Get(0).([]*models.Order), nil
+}
+
 // MockCacheRepository es un mock del repositorio de caché
 type MockCacheRepository struct {
 	mock.Mock
@@ -91,6 +186,56 @@ func (m *MockCacheRepository) GetOrder(ctx context.Context, orderID string) (*mo
 	return order, repoErr
 }
 
+func (m *MockCacheRepository) GetOrderSummary(ctx context.Context, orderID string) (*models.OrderSummary, *repositories.RepositoryError) {
+	args := m.Called(ctx, orderID)
+
+	var summary *models.OrderSummary
+	if v := args.Get(0); v != nil {
+		summary = v.(*models.OrderSummary)
+	}
+
+	var repoErr *repositories.RepositoryError
+	if v := args.Get(1); v != nil {
+		repoErr = v.(*repositories.RepositoryError)
+	}
+
+	return summary, repoErr
+}
+
+func (m *MockCacheRepository) SetOrderSummary(ctx context.Context, summary *models.OrderSummary) *repositories.RepositoryError {
+	args := m.Called(ctx, summary)
+
+	if v := args.Get(0); v != nil {
+		return v.(*repositories.RepositoryError)
+	}
+	return nil
+}
+
+func (m *MockCacheRepository) GetOrderStats(ctx context.Context) (*models.OrderStats, *repositories.RepositoryError) {
+	args := m.Called(ctx)
+
+	var stats *models.OrderStats
+	if v := args.Get(0); v != nil {
+		stats = v.(*models.OrderStats)
+	}
+
+	var repoErr *repositories.RepositoryError
+	if v := args.Get(1); v != nil {
+		repoErr = v.(*repositories.RepositoryError)
+	}
+
+	return stats, repoErr
+}
+
+func (m *MockCacheRepository) SetOrderStats(ctx context.Context, stats *models.OrderStats, ttl time.Duration) *repositories.RepositoryError {
+	args := m.Called(ctx, stats, ttl)
+
+	if v := args.Get(0); v != nil {
+		return v.(*repositories.RepositoryError)
+	}
+	return nil
+}
+
 func (m *MockCacheRepository) SetOrder(ctx context.Context, order *models.Order) *repositories.RepositoryError {
 	args := m.Called(ctx, order)
 
@@ -108,6 +253,15 @@ func (m *MockCacheRepository) InvalidateOrder(ctx context.Context, orderID strin
 	return nil
 }
 
+// newOrderCreatedPublisher devuelve un mock que acepta el evento ORDER_CREATED
+func newOrderCreatedPublisher() *MockEventPublisher {
+	publisher := new(MockEventPublisher)
+	publisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+		return event.EventType == models.EventOrderCreated
+	})).Return(nil)
+	return publisher
+}
+
 // MockEventPublisher es un mock del publicador de eventos
 type MockEventPublisher struct {
 	mock.Mock
@@ -118,6 +272,93 @@ func (m *MockEventPublisher) PublishOrderEvent(ctx context.Context, event *model
 	return args.Error(0)
 }
 
+func (m *MockEventPublisher) RepublishOrderEvent(ctx context.Context, event *models.OrderEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+// MockEventStore es un mock del registro de eventos
+type MockEventStore struct {
+	mock.Mock
+}
+
+func (m *MockEventStore) Save(ctx context.Context, record *models.EventRecord) *repositories.RepositoryError {
+	args := m.Called(ctx, record)
+	if v := args.Get(0); v != nil {
+		return v.(*repositories.RepositoryError)
+	}
+	return nil
+}
+
+func (m *MockEventStore) UpdateDelivery(ctx context.Context, eventID string, status models.EventDeliveryStatus, lastError string) *repositories.RepositoryError {
+	args := m.Called(ctx, eventID, status, lastError)
+	if v := args.Get(0); v != nil {
+		return v.(*repositories.RepositoryError)
+	}
+	return nil
+}
+
+func (m *MockEventStore) FindRecentByOrderID(ctx context.Context, orderID string, limit int) ([]*models.EventRecord, *repositories.RepositoryError) {
+	args := m.Called(ctx, orderID, limit)
+
+	var records []*models.EventRecord
+	if v := args.Get(0); v != nil {
+		records = v.([]*models.EventRecord)
+	}
+
+	var repoErr *repositories.RepositoryError
+	if v := args.Get(1); v != nil {
+		repoErr = v.(*repositories.RepositoryError)
+	}
+	return records, repoErr
+}
+
+func (m *MockEventStore) FindByID(ctx context.Context, eventID string) (*models.EventRecord, *repositories.RepositoryError) {
+	args := m.Called(ctx, eventID)
+
+	var record *models.EventRecord
+	if v := args.Get(0); v != nil {
+		record = v.(*models.EventRecord)
+	}
+
+	var repoErr *repositories.RepositoryError
+	if v := args.Get(1); v != nil {
+		repoErr = v.(*repositories.RepositoryError)
+	}
+	return record, repoErr
+}
+
+func (m *MockEventStore) FindWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.EventRecord, int64, *repositories.RepositoryError) {
+	args := m.Called(ctx, filters, page, limit)
+
+	var records []*models.EventRecord
+	if v := args.Get(0); v != nil {
+		records = v.([]*models.EventRecord)
+	}
+
+	var repoErr *repositories.RepositoryError
+	if v := args.Get(2); v != nil {
+		repoErr = v.(*repositories.RepositoryError)
+	}
+	return records, args.Get(1).(int64), repoErr
+}
+
+func (m *MockEventStore) FindByOrderID(ctx context.Context, orderID string) ([]*models.EventRecord, *repositories.RepositoryError) {
+	args := m.Called(ctx, orderID)
+
+	var records []*models.EventRecord
+	if v := args.Get(0); v != nil {
+		records = v.([]*models.EventRecord)
+	}
+
+	var repoErr *repositories.RepositoryError
+	if v := args.Get(1); v != nil {
+		repoErr = v.(*repositories.RepositoryError)
+	}
+
+	return records, repoErr
+}
+
 func TestOrderService_CreateOrder_Success(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockOrderRepository)
@@ -133,9 +374,12 @@ func TestOrderService_CreateOrder_Success(t *testing.T) {
 	}
 
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+	mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+		return event.EventType == models.EventOrderCreated && event.CustomerID == customerID && event.NewStatus == models.StatusNew
+	})).Return(nil)
 
 	// Act
-	order, err := service.CreateOrder(context.Background(), customerID, items)
+	order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
 
 	// Assert
 	assert.Nil(t, err)
@@ -144,6 +388,7 @@ func TestOrderService_CreateOrder_Success(t *testing.T) {
 	assert.Equal(t, models.StatusNew, order.Status)
 	assert.Equal(t, 1999.98, order.TotalAmount)
 	mockRepo.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
 }
 
 func TestOrderService_CreateOrder_InvalidCustomerID(t *testing.T) {
@@ -160,7 +405,7 @@ func TestOrderService_CreateOrder_InvalidCustomerID(t *testing.T) {
 	}
 
 	// Act
-	order, err := service.CreateOrder(context.Background(), "invalid-uuid", items)
+	order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: "invalid-uuid", Items: items})
 
 	// Assert
 	assert.Error(t, err)
@@ -312,6 +557,83 @@ func TestOrderService_UpdateOrderStatus_InvalidTransition(t *testing.T) {
 	mockPublisher.AssertNotCalled(t, "PublishOrderEvent")
 }
 
+func TestOrderService_ForceOrderStatus(t *testing.T) {
+	deliveredAt := time.Now().Add(-time.Hour)
+	delivered := func() *models.Order {
+		return &models.Order{ID: "order-123", CustomerID: "customer-456", Status: models.StatusDelivered, Version: 3,
+			DeliveredAt: &deliveredAt, Items: []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 10, DeliveredQuantity: 1}}}
+	}
+
+	t.Run("Public status update still rejects the transition", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(delivered(), nil)
+
+		_, err := service.UpdateOrderStatus(context.Background(), "order-123", models.StatusInProgress)
+
+		assert.Equal(t, 400, err.Status)
+		assert.Equal(t, "INVALID_STATUS_TRANSITION", err.Code)
+		mockRepo.AssertNotCalled(t, "Update")
+	})
+
+	t.Run("Forced transition is audited", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		mockPublisher := new(MockEventPublisher)
+		mockStore := new(MockEventStore)
+		service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop(), services.WithEventStore(mockStore))
+
+		forced := func(event *models.OrderEvent) bool {
+			return event.EventType == models.EventOrderStatusChanged &&
+				event.OldStatus == models.StatusDelivered && event.NewStatus == models.StatusInProgress &&
+				event.Metadata.Forced && event.Metadata.ChangedBy == "ops@example.com" && event.Metadata.Reason == "Mistaken delivery scan"
+		}
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(delivered(), nil)
+		mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(order *models.Order) bool {
+			return order.Status == models.StatusInProgress && order.Version == 4
+		})).Return(nil)
+		mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+		mockStore.On("Save", mock.Anything, mock.MatchedBy(func(record *models.EventRecord) bool {
+			return forced(&record.OrderEvent)
+		})).Return(nil)
+		mockStore.On("UpdateDelivery", mock.Anything, mock.Anything, models.EventStatusSent, "").Return(nil)
+		mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(forced)).Return(nil)
+
+		order, err := service.ForceOrderStatus(context.Background(), "order-123", models.StatusInProgress, "ops@example.com", " Mistaken delivery scan ")
+
+		assert.Nil(t, err)
+		assert.Equal(t, models.StatusInProgress, order.Status)
+		assert.Nil(t, order.DeliveredAt)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+		mockStore.AssertExpectations(t)
+		mockPublisher.AssertExpectations(t)
+	})
+
+	t.Run("Reason is mandatory", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+		_, err := service.ForceOrderStatus(context.Background(), "order-123", models.StatusInProgress, "ops@example.com", "  ")
+
+		assert.Equal(t, 400, err.Status)
+		assert.Equal(t, "OVERRIDE_REASON_REQUIRED", err.Code)
+		mockRepo.AssertNotCalled(t, "FindByID")
+	})
+
+	t.Run("Forcing the current status", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(delivered(), nil)
+
+		_, err := service.ForceOrderStatus(context.Background(), "order-123", models.StatusDelivered, "ops@example.com", "No-op")
+
+		assert.Equal(t, 409, err.Status)
+		assert.Equal(t, "STATUS_UNCHANGED", err.Code)
+		mockRepo.AssertNotCalled(t, "Update")
+	})
+}
+
 func TestOrderService_UpdateOrderStatus_VersionConflict(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockOrderRepository)
@@ -345,6 +667,55 @@ func TestOrderService_UpdateOrderStatus_VersionConflict(t *testing.T) {
 
 }
 
+func TestOrderService_UpdateOrderStatus_ConflictDetails(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	mockPublisher := new(MockEventPublisher)
+	service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop())
+
+	// Otro proceso ya movió el pedido a IN_PROGRESS
+	stale := &models.Order{ID: "order-123", CustomerID: "customer-456", Status: models.StatusNew, Version: 1}
+	current := &models.Order{ID: "order-123", CustomerID: "customer-456", Status: models.StatusInProgress, Version: 2}
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(stale, nil).Once()
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(current, nil).Once()
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Order")).Return(&repositories.RepositoryError{
+		StatusCode: 409,
+		Cause:      "version conflict",
+		Message:    "Order was modified by another process",
+	})
+
+	_, err := service.UpdateOrderStatus(context.Background(), "order-123", models.StatusCancelled)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 409, err.Status)
+	assert.Equal(t, "VERSION_CONFLICT", err.Code)
+	assert.Equal(t, &apperrors.OrderStateDetails{
+		CurrentStatus:      models.StatusInProgress,
+		CurrentVersion:     2,
+		AllowedTransitions: []models.OrderStatus{models.StatusPartiallyDelivered, models.StatusDelivered, models.StatusCancelled},
+	}, err.Details)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_UpdateOrderStatus_InvalidTransitionDetails(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(&models.Order{ID: "order-123", Status: models.StatusDelivered, Version: 4}, nil)
+
+	_, err := service.UpdateOrderStatus(context.Background(), "order-123", models.StatusNew)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 400, err.Status)
+	assert.Equal(t, "INVALID_STATUS_TRANSITION", err.Code)
+	assert.Equal(t, &apperrors.OrderStateDetails{
+		CurrentStatus:      models.StatusDelivered,
+		CurrentVersion:     4,
+		AllowedTransitions: []models.OrderStatus{models.StatusReturnRequested},
+	}, err.Details)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
 func TestOrderService_ListOrders_Success_NoFilters(t *testing.T) {
 	ctx := context.Background()
 	logger, _ := zap.NewDevelopment()
@@ -363,7 +734,7 @@ func TestOrderService_ListOrders_Success_NoFilters(t *testing.T) {
 	mockRepo.On("FindWithFilters", ctx, map[string]interface{}{}, 1, 10).
 		Return(ordersMock, totalMock, nil).Once()
 
-	orders, total, err := service.ListOrders(ctx, "", "", 1, 10)
+	orders, total, err := service.ListOrders(ctx, services.ListOrdersFilter{}, 1, 10)
 	assert.Nil(t, err)
 	assert.Len(t, orders, 2)
 	assert.Equal(t, int64(2), total)
@@ -392,13 +763,29 @@ func TestOrderService_ListOrders_Success_WithFilters(t *testing.T) {
 	mockRepo.On("FindWithFilters", ctx, filters, 1, 5).
 		Return(ordersMock, totalMock, nil).Once()
 
-	orders, total, err := service.ListOrders(ctx, string(models.StatusNew), "customer-1", 1, 5)
+	orders, total, err := service.ListOrders(ctx, services.ListOrdersFilter{Status: string(models.StatusNew), CustomerID: "customer-1"}, 1, 5)
 	assert.Nil(t, err)
 	assert.Len(t, orders, 1)
 	assert.Equal(t, int64(1), total)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestOrderService_ListOrders_SkipTotal(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockOrderRepository)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+	ordersMock := []*models.Order{{ID: "1", Status: models.StatusNew}}
+	mockRepo.On("FindWithFilters", ctx, map[string]interface{}{"skipTotal": true}, 2, 5).
+		Return(ordersMock, repositories.UnknownTotal, nil).Once()
+
+	orders, total, err := service.ListOrders(ctx, services.ListOrdersFilter{SkipTotal: true}, 2, 5)
+	assert.Nil(t, err)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, repositories.UnknownTotal, total)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestOrderService_ListOrders_RepoError(t *testing.T) {
 	ctx := context.Background()
 	logger, _ := zap.NewDevelopment()
@@ -417,7 +804,7 @@ func TestOrderService_ListOrders_RepoError(t *testing.T) {
 	mockRepo.On("FindWithFilters", ctx, map[string]interface{}{}, 1, 10).
 		Return(nil, int64(0), repoErr).Once()
 
-	orders, total, err := service.ListOrders(ctx, "", "", 1, 10)
+	orders, total, err := service.ListOrders(ctx, services.ListOrdersFilter{}, 1, 10)
 	assert.Nil(t, orders)
 	assert.Equal(t, int64(0), total)
 	assert.NotNil(t, err)
@@ -427,6 +814,43 @@ func TestOrderService_ListOrders_RepoError(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestOrderService_StreamOrders(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Hands over the orders with the same filters as ListOrders", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+		ordersMock := []*models.Order{{ID: "1", Status: models.StatusNew}, {ID: "2", Status: models.StatusNew}}
+		mockRepo.On("StreamWithFilters", ctx, map[string]interface{}{"status": string(models.StatusNew), "sortBy": "createdAt"}, 2, 100).
+			Return(ordersMock, int64(102), nil).Once()
+
+		var ids []string
+		total, err := service.StreamOrders(ctx, services.ListOrdersFilter{Status: string(models.StatusNew), SortBy: "createdAt"}, 2, 100, func(order *models.Order) error {
+			ids = append(ids, order.ID)
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(102), total)
+		assert.Equal(t, []string{"1", "2"}, ids)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Repository error", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+		mockRepo.On("StreamWithFilters", ctx, map[string]interface{}{}, 1, 100).
+			Return(nil, int64(0), &repositories.RepositoryError{StatusCode: 500, Message: "Failed to find orders", Cause: "connection failed"}).Once()
+
+		total, err := service.StreamOrders(ctx, services.ListOrdersFilter{}, 1, 100, func(*models.Order) error { return nil })
+		assert.Equal(t, int64(0), total)
+		require.NotNil(t, err)
+		assert.Equal(t, 500, err.Status)
+		assert.Equal(t, []interface{}{"connection failed"}, err.Cause)
+	})
+}
+
 func TestOrderService_ListOrders_Pagination(t *testing.T) {
 	ctx := context.Background()
 	logger, _ := zap.NewDevelopment()
@@ -445,9 +869,1803 @@ func TestOrderService_ListOrders_Pagination(t *testing.T) {
 	mockRepo.On("FindWithFilters", ctx, map[string]interface{}{}, 2, 3).
 		Return(ordersMock, totalMock, nil).Once()
 
-	orders, total, err := service.ListOrders(ctx, "", "", 2, 3)
+	orders, total, err := service.ListOrders(ctx, services.ListOrdersFilter{}, 2, 3)
 	assert.Nil(t, err)
 	assert.Len(t, orders, 2)
 	assert.Equal(t, int64(2), total)
 	mockRepo.AssertExpectations(t)
 }
+
+// MockPriceProvider es un mock del proveedor de precios del catálogo
+type MockPriceProvider struct {
+	mock.Mock
+}
+
+func (m *MockPriceProvider) ResolvePrices(ctx context.Context, items []models.OrderItem) ([]models.OrderItem, error) {
+	args := m.Called(ctx, items)
+	var resolved []models.OrderItem
+	if v := args.Get(0); v != nil {
+		resolved = v.([]models.OrderItem)
+	}
+	return resolved, args.Error(1)
+}
+
+func TestOrderService_CreateOrder_UsesCatalogPrices(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	mockPublisher := new(MockEventPublisher)
+	mockPrices := new(MockPriceProvider)
+	logger, _ := zap.NewDevelopment()
+
+	service := services.NewOrderService(mockRepo, mockCache, mockPublisher, logger, services.WithPriceProvider(mockPrices))
+
+	customerID := "123e4567-e89b-12d3-a456-426614174000"
+	items := []models.OrderItem{
+		{SKU: "LAPTOP-001", Quantity: 2, Price: 0.01},
+	}
+	snapshotAt := time.Now()
+	resolved := []models.OrderItem{
+		{SKU: "LAPTOP-001", Quantity: 2, Price: 999.99, PriceSnapshotAt: &snapshotAt},
+	}
+
+	mockPrices.On("ResolvePrices", mock.Anything, items).Return(resolved, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+	mockPublisher.On("PublishOrderEvent", mock.Anything, mock.AnythingOfType("*models.OrderEvent")).Return(nil)
+
+	order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1999.98, order.TotalAmount)
+	assert.Equal(t, 999.99, order.Items[0].Price)
+	assert.Equal(t, &snapshotAt, order.Items[0].PriceSnapshotAt)
+	mockPrices.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_CreateOrder_UnknownSKUs(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	mockPublisher := new(MockEventPublisher)
+	mockPrices := new(MockPriceProvider)
+	logger, _ := zap.NewDevelopment()
+
+	service := services.NewOrderService(mockRepo, mockCache, mockPublisher, logger, services.WithPriceProvider(mockPrices))
+
+	items := []models.OrderItem{
+		{SKU: "LAPTOP-001", Quantity: 1, Price: 10},
+		{SKU: "GHOST-404", Quantity: 1, Price: 10},
+	}
+	mockPrices.On("ResolvePrices", mock.Anything, items).
+		Return(nil, &models.UnknownSKUsError{SKUs: []string{"GHOST-404"}})
+
+	order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: "123e4567-e89b-12d3-a456-426614174000", Items: items})
+
+	assert.Nil(t, order)
+	assert.NotNil(t, err)
+	assert.Equal(t, 422, err.Status)
+	assert.Equal(t, "UNKNOWN_SKU", err.Code)
+	assert.Equal(t, []interface{}{"GHOST-404"}, err.Cause)
+	mockRepo.AssertNotCalled(t, "Create")
+}
+
+func TestOrderService_CreateOrder_CustomerValidation(t *testing.T) {
+	customerID := "123e4567-e89b-12d3-a456-426614174000"
+	items := []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 10}}
+
+	t.Run("Unknown customer", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+			services.WithCustomerValidator(customers.NewFake(), false))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, order)
+		assert.Equal(t, 422, err.Status)
+		assert.Equal(t, "CUSTOMER_NOT_FOUND", err.Code)
+		mockRepo.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("Known customer", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+			services.WithCustomerValidator(customers.NewFake(customerID), false))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, err)
+		assert.NotNil(t, order)
+	})
+
+	t.Run("Check disabled accepts any customer", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop())
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, err)
+		assert.NotNil(t, order)
+	})
+
+	t.Run("Outage with soft fail accepts the order", func(t *testing.T) {
+		fake := customers.NewFake()
+		fake.FailWith(errors.New("connection refused"))
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+			services.WithCustomerValidator(fake, true))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, err)
+		assert.NotNil(t, order)
+	})
+
+	t.Run("Outage without soft fail rejects the order", func(t *testing.T) {
+		fake := customers.NewFake()
+		fake.FailWith(errors.New("connection refused"))
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+			services.WithCustomerValidator(fake, false))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, order)
+		assert.Equal(t, 503, err.Status)
+		mockRepo.AssertNotCalled(t, "Create")
+	})
+}
+
+// MockSKUValidator es un mock de la validación de SKUs contra el catálogo
+type MockSKUValidator struct {
+	mock.Mock
+}
+
+func (m *MockSKUValidator) ExistingSKUs(ctx context.Context, skus []string) (map[string]bool, error) {
+	args := m.Called(ctx, skus)
+	if v := args.Get(0); v != nil {
+		return v.(map[string]bool), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestOrderService_CreateOrder_SKUValidation(t *testing.T) {
+	customerID := "123e4567-e89b-12d3-a456-426614174000"
+	items := []models.OrderItem{
+		{SKU: "LAPTOP-001", Quantity: 1, Price: 10},
+		{SKU: "MOUSE-002", Quantity: 2, Price: 5},
+		{SKU: "LAPTOP-001", Quantity: 1, Price: 10},
+	}
+
+	t.Run("All SKUs exist", func(t *testing.T) {
+		validator := new(MockSKUValidator)
+		// Una sola consulta con los SKUs sin repetir
+		validator.On("ExistingSKUs", mock.Anything, []string{"LAPTOP-001", "MOUSE-002"}).
+			Return(map[string]bool{"LAPTOP-001": true, "MOUSE-002": true}, nil).Once()
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+			services.WithSKUValidator(validator, false))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, err)
+		assert.NotNil(t, order)
+		validator.AssertExpectations(t)
+	})
+
+	t.Run("Unknown SKU", func(t *testing.T) {
+		validator := new(MockSKUValidator)
+		validator.On("ExistingSKUs", mock.Anything, []string{"LAPTOP-001", "MOUSE-002"}).
+			Return(map[string]bool{"LAPTOP-001": true, "MOUSE-002": false}, nil)
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+			services.WithSKUValidator(validator, false))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, order)
+		assert.Equal(t, 400, err.Status)
+		assert.Equal(t, "SKU_NOT_FOUND", err.Code)
+		assert.Equal(t, "Unknown SKU: MOUSE-002", err.Message)
+		assert.Equal(t, []interface{}{"MOUSE-002"}, err.Cause)
+		mockRepo.AssertNotCalled(t, "Create")
+	})
+
+	// Catálogo que responde después del timeout del cliente
+	slowCatalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"prices":[]}`))
+	}))
+	defer slowCatalog.Close()
+
+	t.Run("Catalog timeout with fail open accepts the order", func(t *testing.T) {
+		client := catalog.NewClient(slowCatalog.URL, "", httpclient.New(httpclient.Config{Timeout: 20 * time.Millisecond}), nil, nil, zap.NewNop())
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+			services.WithSKUValidator(client, true))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, err)
+		assert.NotNil(t, order)
+	})
+
+	t.Run("Catalog timeout with fail closed rejects the order", func(t *testing.T) {
+		client := catalog.NewClient(slowCatalog.URL, "", httpclient.New(httpclient.Config{Timeout: 20 * time.Millisecond}), nil, nil, zap.NewNop())
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+			services.WithSKUValidator(client, false))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, order)
+		assert.Equal(t, 503, err.Status)
+		mockRepo.AssertNotCalled(t, "Create")
+	})
+}
+
+func TestOrderService_CreateOrder_CustomerSnapshot(t *testing.T) {
+	customerID := "123e4567-e89b-12d3-a456-426614174000"
+	items := []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 10}}
+
+	t.Run("Completes missing fields from the customers service", func(t *testing.T) {
+		fake := customers.NewFake()
+		fake.SetContact(customerID, models.CustomerSnapshot{Email: "old@example.com", Name: "Jane Doe", Phone: "+34600123456"})
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		publisher := new(MockEventPublisher)
+		publisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+			return event.EventType == models.EventOrderCreated &&
+				event.CustomerSnapshot != nil && event.CustomerSnapshot.Email == "jane@example.com"
+		})).Return(nil)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), publisher, zap.NewNop(),
+			services.WithCustomerValidator(fake, false))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{
+			CustomerID: customerID,
+			Items:      items,
+			Customer:   &models.CustomerSnapshot{Email: "jane@example.com"},
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, &models.CustomerSnapshot{Email: "jane@example.com", Name: "Jane Doe", Phone: "+34600123456"}, order.CustomerSnapshot)
+		publisher.AssertExpectations(t)
+	})
+
+	t.Run("Lookup failure keeps the given data", func(t *testing.T) {
+		fake := customers.NewFake()
+		fake.FailWith(errors.New("connection refused"))
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+			services.WithCustomerValidator(fake, true))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{
+			CustomerID: customerID,
+			Items:      items,
+			Customer:   &models.CustomerSnapshot{Name: "Jane Doe"},
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, &models.CustomerSnapshot{Name: "Jane Doe"}, order.CustomerSnapshot)
+	})
+
+	t.Run("No contact data leaves the snapshot empty", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop())
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items})
+
+		assert.Nil(t, err)
+		assert.Nil(t, order.CustomerSnapshot)
+	})
+}
+
+func TestOrderService_CreateOrder_NotesTooLong(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+		services.WithMaxNoteLength(10))
+
+	order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{
+		CustomerID: "123e4567-e89b-12d3-a456-426614174000",
+		Items:      []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 10}},
+		Notes:      "this note is definitely too long",
+	})
+
+	assert.Nil(t, order)
+	assert.Equal(t, 400, err.Status)
+	assert.Equal(t, "NOTE_TOO_LONG", err.Code)
+	mockRepo.AssertNotCalled(t, "Create")
+}
+
+func TestOrderService_AddOrderNote_AppendsMultipleNotes(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	service := services.NewOrderService(mockRepo, mockCache, new(MockEventPublisher), zap.NewNop(),
+		services.WithMaxNoteLength(100), services.WithMaxNotesPerOrder(50))
+
+	first := models.NewOrderNote("dispatcher", "gate code 4411")
+	second := models.NewOrderNote("dispatcher", "call before arrival")
+	afterFirst := &models.Order{ID: "order-123", Status: models.StatusNew, Version: 2, NoteEntries: []models.OrderNote{first}}
+	afterSecond := &models.Order{ID: "order-123", Status: models.StatusNew, Version: 3, NoteEntries: []models.OrderNote{first, second}}
+
+	isNote := func(text string) interface{} {
+		return mock.MatchedBy(func(note models.OrderNote) bool { return note.Text == text })
+	}
+	mockRepo.On("AppendNote", mock.Anything, "order-123", isNote("gate code 4411"), 50).Return(afterFirst, nil).Once()
+	mockRepo.On("AppendNote", mock.Anything, "order-123", isNote("call before arrival"), 50).Return(afterSecond, nil).Once()
+	mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+
+	_, err := service.AddOrderNote(context.Background(), "order-123", "dispatcher", "gate code 4411")
+	assert.Nil(t, err)
+	order, err := service.AddOrderNote(context.Background(), "order-123", "dispatcher", "call before arrival")
+	assert.Nil(t, err)
+
+	assert.Len(t, order.NoteEntries, 2)
+	assert.Equal(t, "gate code 4411", order.NoteEntries[0].Text)
+	assert.Equal(t, "call before arrival", order.NoteEntries[1].Text)
+	assert.Equal(t, models.StatusNew, order.Status)
+	assert.Equal(t, 3, order.Version)
+	mockCache.AssertNumberOfCalls(t, "InvalidateOrder", 2)
+}
+
+func TestOrderService_AddOrderNote_Validation(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+		services.WithMaxNoteLength(5))
+
+	_, err := service.AddOrderNote(context.Background(), "order-123", "dispatcher", "   ")
+	assert.Equal(t, "NOTE_EMPTY", err.Code)
+
+	_, err = service.AddOrderNote(context.Background(), "order-123", "dispatcher", "too long")
+	assert.Equal(t, "NOTE_TOO_LONG", err.Code)
+
+	_, err = service.AddOrderNote(context.Background(), "order-123", " ", "ok")
+	assert.Equal(t, "NOTE_AUTHOR_REQUIRED", err.Code)
+
+	// Control characters are stripped before the length check.
+	_, err = service.AddOrderNote(context.Background(), "order-123", "dispatcher", "\x00\x07\x1b")
+	assert.Equal(t, "NOTE_EMPTY", err.Code)
+
+	mockRepo.AssertNotCalled(t, "AppendNote")
+}
+
+func TestOrderService_CreateOrder_MaxWeight(t *testing.T) {
+	customerID := "123e4567-e89b-12d3-a456-426614174000"
+	mockRepo := new(MockOrderRepository)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+		services.WithMaxOrderWeight(10000))
+
+	order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{
+		CustomerID: customerID,
+		Items:      []models.OrderItem{{SKU: "FRIDGE-1", Quantity: 2, Price: 300, WeightGrams: 6000}},
+	})
+	assert.Nil(t, order)
+	assert.Equal(t, 422, err.Status)
+	assert.Equal(t, "ORDER_TOO_HEAVY", err.Code)
+	mockRepo.AssertNotCalled(t, "Create")
+
+	order, err = service.CreateOrder(context.Background(), services.CreateOrderInput{
+		CustomerID: customerID,
+		Items:      []models.OrderItem{{SKU: "FRIDGE-1", Quantity: 1, Price: 300, WeightGrams: 6000, VolumeCm3: 500000}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 6000, order.TotalWeightGrams)
+	assert.Equal(t, 500000, order.TotalVolumeCm3)
+}
+
+func TestOrderService_CreateOrder_InitialStatus(t *testing.T) {
+	customerID := "123e4567-e89b-12d3-a456-426614174000"
+	items := []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 999.99}}
+
+	t.Run("Only NEW by default", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop())
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{
+			CustomerID:    customerID,
+			Items:         items,
+			InitialStatus: models.StatusInProgress,
+		})
+		assert.Nil(t, order)
+		require.NotNil(t, err)
+		assert.Equal(t, 400, err.Status)
+		assert.Equal(t, "INVALID_INITIAL_STATUS", err.Code)
+		assert.Equal(t, []interface{}{"NEW"}, err.Cause)
+		mockRepo.AssertNotCalled(t, "Create")
+	})
+
+	t.Run("Allowed by the deployment", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(order *models.Order) bool {
+			return order.Status == models.StatusInProgress
+		})).Return(nil)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+			services.WithInitialStatuses([]models.OrderStatus{models.StatusNew, models.StatusInProgress}))
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{
+			CustomerID:    customerID,
+			Items:         items,
+			InitialStatus: models.StatusInProgress,
+		})
+		require.Nil(t, err)
+		assert.Equal(t, models.StatusInProgress, order.Status)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestOrderService_CreateOrder_DeclaredTotal(t *testing.T) {
+	customerID := "123e4567-e89b-12d3-a456-426614174000"
+	items := []models.OrderItem{
+		{SKU: "LAPTOP-001", Quantity: 3, Price: 0.1},
+		{SKU: "MOUSE-001", Quantity: 1, Price: 19.99},
+	}
+	total := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name     string
+		declared *float64
+		wantCode string
+	}{
+		{"Omitted", nil, ""},
+		{"Matching within rounding", total(20.29), ""},
+		{"Mismatching", total(25), "TOTAL_MISMATCH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockOrderRepository)
+			mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+			service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop())
+
+			order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{
+				CustomerID:  customerID,
+				Items:       items,
+				TotalAmount: tt.declared,
+			})
+
+			if tt.wantCode == "" {
+				assert.Nil(t, err)
+				assert.InDelta(t, 20.29, order.TotalAmount, 0.001)
+				return
+			}
+			assert.Nil(t, order)
+			assert.Equal(t, 400, err.Status)
+			assert.Equal(t, tt.wantCode, err.Code)
+			mockRepo.AssertNotCalled(t, "Create")
+		})
+	}
+}
+
+func TestOrderService_AddOrderNote_DeletedOrder(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+	mockRepo.On("AppendNote", mock.Anything, "order-123", mock.Anything, 0).
+		Return(nil, &repositories.RepositoryError{StatusCode: 410, Message: "Order has been deleted"})
+
+	order, err := service.AddOrderNote(context.Background(), "order-123", "dispatcher", "gate code 4411")
+
+	assert.Nil(t, order)
+	assert.Equal(t, 410, err.Status)
+}
+
+func TestOrderService_ListOrderNotes_NewestFirst(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	service := services.NewOrderService(mockRepo, mockCache, new(MockEventPublisher), zap.NewNop())
+
+	stored := &models.Order{ID: "order-123", NoteEntries: []models.OrderNote{
+		{Author: "ops", Text: "one"},
+		{Author: "ops", Text: "two"},
+		{Author: "ops", Text: "three"},
+	}}
+	mockCache.On("GetOrder", mock.Anything, "order-123").Return(stored, nil)
+
+	notes, total, err := service.ListOrderNotes(context.Background(), "order-123", 1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []string{"three", "two"}, []string{notes[0].Text, notes[1].Text})
+
+	notes, _, err = service.ListOrderNotes(context.Background(), "order-123", 2, 2)
+	assert.Nil(t, err)
+	assert.Len(t, notes, 1)
+	assert.Equal(t, "one", notes[0].Text)
+}
+
+func TestOrderService_ListOrderNotes_DeletedOrder(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	service := services.NewOrderService(mockRepo, mockCache, new(MockEventPublisher), zap.NewNop())
+
+	// La orden eliminada en caché no se sirve: el repositorio decide entre 404 y 410
+	deletedAt := time.Now()
+	mockCache.On("GetOrder", mock.Anything, "order-123").Return(&models.Order{ID: "order-123", DeletedAt: &deletedAt}, nil)
+	mockRepo.On("FindByID", mock.MatchedBy(repositories.IncludeDeleted), "order-123").
+		Return(nil, &repositories.RepositoryError{StatusCode: 410, Cause: "order deleted", Message: "Order has been deleted"})
+	mockRepo.On("FindByID", mock.Anything, "order-123").
+		Return(nil, &repositories.RepositoryError{StatusCode: 404, Cause: "order not found", Message: "Order not found"})
+
+	_, _, err := service.ListOrderNotes(repositories.WithDeleted(context.Background()), "order-123", 1, 10)
+	assert.Equal(t, 410, err.Status)
+
+	_, _, err = service.ListOrderNotes(context.Background(), "order-123", 1, 10)
+	assert.Equal(t, 404, err.Status)
+}
+
+func TestOrderService_CreateOrder_ConsolidatesDuplicateSKUs(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+		services.WithItemConsolidation(true))
+
+	order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{
+		CustomerID: "123e4567-e89b-12d3-a456-426614174000",
+		Items: []models.OrderItem{
+			{SKU: "laptop-001", Quantity: 1, Price: 100},
+			{SKU: "LAPTOP-001", Quantity: 2, Price: 100},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 3, Price: 100}}, order.Items)
+	assert.Equal(t, 300.0, order.TotalAmount)
+}
+
+func TestOrderService_CreateOrder_ConsolidationOverflow(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+		services.WithItemConsolidation(true))
+
+	order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{
+		CustomerID: "123e4567-e89b-12d3-a456-426614174000",
+		Items: []models.OrderItem{
+			{SKU: "LAPTOP-001", Quantity: models.MaxItemQuantity, Price: 100},
+			{SKU: "LAPTOP-001", Quantity: 1, Price: 100},
+		},
+	})
+
+	assert.Nil(t, order)
+	assert.Equal(t, 400, err.Status)
+	assert.Equal(t, "INVALID_ITEM", err.Code)
+	assert.Equal(t, "LAPTOP-001", err.Cause[0].(map[string]string)["sku"])
+	mockRepo.AssertNotCalled(t, "Create")
+}
+
+func TestOrderService_CreateOrder_KeepsDuplicateLinesWhenConsolidationDisabled(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop())
+
+	order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{
+		CustomerID: "123e4567-e89b-12d3-a456-426614174000",
+		Items: []models.OrderItem{
+			{SKU: "LAPTOP-001", Quantity: 1, Price: 100},
+			{SKU: "LAPTOP-001", Quantity: 2, Price: 100},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Len(t, order.Items, 2)
+}
+
+func TestOrderService_UpdateOrderStatus_PersistsEvent(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	mockPublisher := new(MockEventPublisher)
+	mockStore := new(MockEventStore)
+	service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop(), services.WithEventStore(mockStore))
+
+	existingOrder := &models.Order{ID: "order-123", CustomerID: "customer-456", Status: models.StatusNew, Version: 1}
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(existingOrder, nil)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+	mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+	mockStore.On("Save", mock.Anything, mock.MatchedBy(func(record *models.EventRecord) bool {
+		return record.OrderID == "order-123" && record.Status == models.EventStatusPending
+	})).Return(nil)
+	mockPublisher.On("PublishOrderEvent", mock.Anything, mock.AnythingOfType("*models.OrderEvent")).Return(errors.New("broker down"))
+	mockStore.On("UpdateDelivery", mock.Anything, mock.Anything, models.EventStatusFailed, "broker down").Return(nil)
+
+	_, err := service.UpdateOrderStatus(context.Background(), "order-123", models.StatusInProgress)
+
+	assert.Nil(t, err)
+	mockStore.AssertExpectations(t)
+}
+
+// MockWriteTracker es un mock del registro de últimas escrituras
+type MockWriteTracker struct {
+	mock.Mock
+}
+
+func (m *MockWriteTracker) Touch(ctx context.Context, customerID string) *repositories.RepositoryError {
+	args := m.Called(ctx, customerID)
+	if v := args.Get(0); v != nil {
+		return v.(*repositories.RepositoryError)
+	}
+	return nil
+}
+
+func (m *MockWriteTracker) Forget(ctx context.Context, customerID string) *repositories.RepositoryError {
+	args := m.Called(ctx, customerID)
+	if v := args.Get(0); v != nil {
+		return v.(*repositories.RepositoryError)
+	}
+	return nil
+}
+
+func (m *MockWriteTracker) Version(ctx context.Context, customerID string) (*models.ListVersion, *repositories.RepositoryError) {
+	args := m.Called(ctx, customerID)
+
+	var version *models.ListVersion
+	if v := args.Get(0); v != nil {
+		version = v.(*models.ListVersion)
+	}
+
+	var repoErr *repositories.RepositoryError
+	if v := args.Get(1); v != nil {
+		repoErr = v.(*repositories.RepositoryError)
+	}
+	return version, repoErr
+}
+
+func TestOrderService_UpdateOrderStatus_RecordsWrite(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	mockPublisher := new(MockEventPublisher)
+	mockTracker := new(MockWriteTracker)
+	service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop(), services.WithWriteTracker(mockTracker))
+
+	existingOrder := &models.Order{ID: "order-123", CustomerID: "customer-456", Status: models.StatusNew, Version: 1}
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(existingOrder, nil)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+	mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+	mockPublisher.On("PublishOrderEvent", mock.Anything, mock.AnythingOfType("*models.OrderEvent")).Return(nil)
+
+	// Si no se puede avanzar la marca, se descarta para no responder 304
+	mockTracker.On("Touch", mock.Anything, "customer-456").Return(&repositories.RepositoryError{StatusCode: 500, Cause: "redis down"})
+	mockTracker.On("Forget", mock.Anything, "customer-456").Return(nil)
+
+	_, err := service.UpdateOrderStatus(context.Background(), "order-123", models.StatusInProgress)
+
+	assert.Nil(t, err)
+	mockTracker.AssertExpectations(t)
+}
+
+func TestOrderService_ListOrdersVersion(t *testing.T) {
+	mockTracker := new(MockWriteTracker)
+	service := services.NewOrderService(new(MockOrderRepository), new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(), services.WithWriteTracker(mockTracker))
+
+	version := &models.ListVersion{LastWrite: time.Now().Add(-time.Minute), ObservedAt: time.Now()}
+	mockTracker.On("Version", mock.Anything, "customer-456").Return(version, nil)
+	mockTracker.On("Version", mock.Anything, "").Return(nil, &repositories.RepositoryError{StatusCode: 500, Cause: "redis down"})
+
+	assert.Equal(t, version, service.ListOrdersVersion(context.Background(), services.ListOrdersFilter{CustomerID: "customer-456", Status: "NEW"}))
+	// Los errores se tratan como versión desconocida
+	assert.Nil(t, service.ListOrdersVersion(context.Background(), services.ListOrdersFilter{Status: "NEW"}))
+
+	// Sin registro no hay versión
+	service = services.NewOrderService(new(MockOrderRepository), new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+	assert.Nil(t, service.ListOrdersVersion(context.Background(), services.ListOrdersFilter{}))
+}
+
+func TestOrderService_ReplayOrderEvents_RepublishesInSequence(t *testing.T) {
+	mockPublisher := new(MockEventPublisher)
+	mockStore := new(MockEventStore)
+	service := services.NewOrderService(new(MockOrderRepository), new(MockCacheRepository), mockPublisher, zap.NewNop(), services.WithEventStore(mockStore))
+
+	first := models.NewEventRecord(models.NewOrderStatusChangedEvent("order-123", "customer-456", models.StatusNew, models.StatusInProgress))
+	second := models.NewEventRecord(models.NewOrderStatusChangedEvent("order-123", "customer-456", models.StatusInProgress, models.StatusDelivered))
+	mockStore.On("FindByOrderID", mock.Anything, "order-123").Return([]*models.EventRecord{first, second}, nil)
+
+	var replayed []string
+	mockPublisher.On("RepublishOrderEvent", mock.Anything, mock.AnythingOfType("*models.OrderEvent")).
+		Run(func(args mock.Arguments) {
+			replayed = append(replayed, args.Get(1).(*models.OrderEvent).EventID)
+		}).Return(nil)
+
+	count, err := service.ReplayOrderEvents(context.Background(), "order-123")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{first.EventID, second.EventID}, replayed, "events must keep their IDs and order")
+	mockPublisher.AssertNotCalled(t, "PublishOrderEvent")
+}
+
+func TestOrderService_ListOrderEvents(t *testing.T) {
+	mockStore := new(MockEventStore)
+	service := services.NewOrderService(new(MockOrderRepository), new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(), services.WithEventStore(mockStore))
+	mockStore.On("FindRecentByOrderID", mock.Anything, "order-999", 50).Return(nil, nil)
+
+	records, err := service.ListOrderEvents(context.Background(), "order-999", 50)
+	assert.Nil(t, err)
+	assert.NotNil(t, records)
+	assert.Empty(t, records)
+
+	withoutStore := services.NewOrderService(new(MockOrderRepository), new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+	_, err = withoutStore.ListOrderEvents(context.Background(), "order-999", 50)
+	assert.Equal(t, 503, err.Status)
+}
+
+func TestOrderService_ListEvents(t *testing.T) {
+	mockStore := new(MockEventStore)
+	service := services.NewOrderService(new(MockOrderRepository), new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(), services.WithEventStore(mockStore))
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockStore.On("FindWithFilters", mock.Anything, map[string]interface{}{
+		"eventType": "ORDER_CREATED",
+		"status":    "FAILED",
+		"from":      from,
+	}, 2, 20).Return([]*models.EventRecord{}, int64(0), nil)
+
+	records, total, err := service.ListEvents(context.Background(), services.ListEventsFilter{
+		EventType: models.EventOrderCreated,
+		Status:    models.EventStatusFailed,
+		From:      &from,
+	}, 2, 20)
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+	assert.Equal(t, int64(0), total)
+	mockStore.AssertExpectations(t)
+
+	mockStore.On("FindByID", mock.Anything, "event-404").Return(nil, &repositories.RepositoryError{StatusCode: 404, Message: "Event not found"})
+	_, err = service.GetEvent(context.Background(), "event-404")
+	assert.Equal(t, 404, err.Status)
+}
+
+func TestOrderService_RetryEvent(t *testing.T) {
+	failedRecord := func(status models.EventDeliveryStatus) *models.EventRecord {
+		record := models.NewEventRecord(models.NewOrderStatusChangedEvent("order-123", "customer-1", models.StatusNew, models.StatusInProgress))
+		record.EventID = "event-1"
+		record.Status = status
+		record.Attempts = 2
+		record.LastError = "broker down"
+		return record
+	}
+
+	t.Run("Republica un evento fallido", func(t *testing.T) {
+		mockStore := new(MockEventStore)
+		mockPublisher := new(MockEventPublisher)
+		service := services.NewOrderService(new(MockOrderRepository), new(MockCacheRepository), mockPublisher, zap.NewNop(), services.WithEventStore(mockStore))
+		mockStore.On("FindByID", mock.Anything, "event-1").Return(failedRecord(models.EventStatusFailed), nil)
+		mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(e *models.OrderEvent) bool {
+			return e.EventID == "event-1"
+		})).Return(nil)
+		mockStore.On("UpdateDelivery", mock.Anything, "event-1", models.EventStatusSent, "").Return(nil)
+
+		record, err := service.RetryEvent(context.Background(), "event-1")
+
+		assert.Nil(t, err)
+		assert.Equal(t, models.EventStatusSent, record.Status)
+		assert.Equal(t, 3, record.Attempts)
+		assert.Empty(t, record.LastError)
+		assert.NotNil(t, record.PublishedAt)
+		mockStore.AssertExpectations(t)
+		mockPublisher.AssertExpectations(t)
+	})
+
+	t.Run("Registra un nuevo fallo", func(t *testing.T) {
+		mockStore := new(MockEventStore)
+		mockPublisher := new(MockEventPublisher)
+		service := services.NewOrderService(new(MockOrderRepository), new(MockCacheRepository), mockPublisher, zap.NewNop(), services.WithEventStore(mockStore))
+		mockStore.On("FindByID", mock.Anything, "event-1").Return(failedRecord(models.EventStatusPending), nil)
+		mockPublisher.On("PublishOrderEvent", mock.Anything, mock.Anything).Return(errors.New("broker still down"))
+		mockStore.On("UpdateDelivery", mock.Anything, "event-1", models.EventStatusFailed, "broker still down").Return(nil)
+
+		_, err := service.RetryEvent(context.Background(), "event-1")
+
+		assert.Equal(t, 502, err.Status)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("Rechaza un evento ya enviado", func(t *testing.T) {
+		mockStore := new(MockEventStore)
+		mockPublisher := new(MockEventPublisher)
+		service := services.NewOrderService(new(MockOrderRepository), new(MockCacheRepository), mockPublisher, zap.NewNop(), services.WithEventStore(mockStore))
+		mockStore.On("FindByID", mock.Anything, "event-1").Return(failedRecord(models.EventStatusSent), nil)
+
+		_, err := service.RetryEvent(context.Background(), "event-1")
+
+		assert.Equal(t, 409, err.Status)
+		assert.Equal(t, "EVENT_ALREADY_SENT", err.Code)
+		mockPublisher.AssertNotCalled(t, "PublishOrderEvent")
+	})
+}
+
+func TestOrderService_ReplayOrderEvents_NoEvents(t *testing.T) {
+	mockStore := new(MockEventStore)
+	service := services.NewOrderService(new(MockOrderRepository), new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(), services.WithEventStore(mockStore))
+	mockStore.On("FindByOrderID", mock.Anything, "order-999").Return(nil, nil)
+
+	count, err := service.ReplayOrderEvents(context.Background(), "order-999")
+
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 404, err.Status)
+}
+
+func TestOrderService_CreateOrder_PromisedDelivery(t *testing.T) {
+	input := func(promised *time.Time) services.CreateOrderInput {
+		return services.CreateOrderInput{
+			CustomerID:         "123e4567-e89b-12d3-a456-426614174000",
+			Items:              []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 10}},
+			PromisedDeliveryAt: promised,
+		}
+	}
+	newService := func() (services.OrderService, *MockOrderRepository) {
+		mockRepo := new(MockOrderRepository)
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		return services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+			services.WithDeliverySLA(48*time.Hour, time.Hour, 7*24*time.Hour)), mockRepo
+	}
+
+	t.Run("defaults to the configured SLA", func(t *testing.T) {
+		service, _ := newService()
+		before := time.Now()
+
+		order, err := service.CreateOrder(context.Background(), input(nil))
+
+		assert.Nil(t, err)
+		assert.WithinDuration(t, before.Add(48*time.Hour), *order.PromisedDeliveryAt, time.Second)
+	})
+
+	t.Run("accepts a client promise within bounds", func(t *testing.T) {
+		service, _ := newService()
+		promised := time.Now().Add(24 * time.Hour)
+
+		order, err := service.CreateOrder(context.Background(), input(&promised))
+
+		assert.Nil(t, err)
+		assert.True(t, promised.Equal(*order.PromisedDeliveryAt))
+	})
+
+	t.Run("rejects a client promise out of bounds", func(t *testing.T) {
+		service, mockRepo := newService()
+		tooSoon := time.Now().Add(10 * time.Minute)
+		tooLate := time.Now().Add(30 * 24 * time.Hour)
+
+		_, err := service.CreateOrder(context.Background(), input(&tooSoon))
+		assert.Equal(t, "INVALID_PROMISED_DELIVERY", err.Code)
+		_, err = service.CreateOrder(context.Background(), input(&tooLate))
+		assert.Equal(t, "INVALID_PROMISED_DELIVERY", err.Code)
+
+		mockRepo.AssertNotCalled(t, "Create")
+	})
+}
+
+func TestOrderService_UpdateOrderStatus_StampsDeliveredAt(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	mockPublisher := new(MockEventPublisher)
+	service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop())
+
+	promised := time.Now().Add(-time.Hour)
+	existing := &models.Order{ID: "order-123", CustomerID: "customer-456", Status: models.StatusInProgress, Version: 2, PromisedDeliveryAt: &promised}
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(existing, nil)
+	mockRepo.On("Update", mock.Anything, existing).Return(nil)
+	mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+	mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+		return event.DeliveredAt != nil && event.PromisedDeliveryAt.Equal(promised)
+	})).Return(nil)
+
+	order, err := service.UpdateOrderStatus(context.Background(), "order-123", models.StatusDelivered)
+
+	assert.Nil(t, err)
+	assert.NotNil(t, order.DeliveredAt)
+	assert.True(t, order.IsSLABreached(time.Now()))
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestOrderService_NotifySLABreaches(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockPublisher := new(MockEventPublisher)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), mockPublisher, zap.NewNop())
+
+	promised := time.Now().Add(-time.Hour)
+	late := &models.Order{ID: "order-late", Status: models.StatusInProgress, PromisedDeliveryAt: &promised}
+	claimedElsewhere := &models.Order{ID: "order-claimed", Status: models.StatusInProgress, PromisedDeliveryAt: &promised}
+
+	mockRepo.On("FindSLABreachCandidates", mock.Anything, mock.Anything, 50).Return([]*models.Order{late, claimedElsewhere}, nil)
+	mockRepo.On("MarkSLABreachNotified", mock.Anything, "order-late", mock.Anything).Return(true, nil)
+	mockRepo.On("MarkSLABreachNotified", mock.Anything, "order-claimed", mock.Anything).Return(false, nil)
+	mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+		return event.EventType == models.EventOrderSLABreached && event.OrderID == "order-late"
+	})).Return(nil).Once()
+
+	notified, err := service.NotifySLABreaches(context.Background(), 50)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, notified)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestOrderService_CacheDisabled_SkipsCache(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	mockPublisher := new(MockEventPublisher)
+	service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop(), services.WithCache(false))
+
+	stored := &models.Order{ID: "order-123", CustomerID: "customer-456", Status: models.StatusNew, Version: 1}
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(stored, nil)
+	mockRepo.On("Update", mock.Anything, stored).Return(nil)
+	mockRepo.On("AppendNote", mock.Anything, "order-123", mock.Anything, 0).Return(stored, nil)
+	mockPublisher.On("PublishOrderEvent", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := service.GetOrderByID(context.Background(), "order-123")
+	assert.Nil(t, err)
+	_, err = service.UpdateOrderStatus(context.Background(), "order-123", models.StatusInProgress)
+	assert.Nil(t, err)
+	_, err = service.AddOrderNote(context.Background(), "order-123", "dispatcher", "gate code 4411")
+	assert.Nil(t, err)
+
+	assert.Empty(t, mockCache.Calls)
+}
+
+func TestOrderService_CreateOrder_Priority(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop())
+
+	input := services.CreateOrderInput{
+		CustomerID: "123e4567-e89b-12d3-a456-426614174000",
+		Items:      []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 10}},
+	}
+
+	order, err := service.CreateOrder(context.Background(), input)
+	assert.Nil(t, err)
+	assert.Equal(t, models.PriorityNormal, order.Priority)
+
+	input.Priority = models.PriorityUrgent
+	order, err = service.CreateOrder(context.Background(), input)
+	assert.Nil(t, err)
+	assert.Equal(t, models.PriorityUrgent, order.Priority)
+	assert.Equal(t, 4, order.PriorityRank)
+
+	input.Priority = "CRITICAL"
+	_, err = service.CreateOrder(context.Background(), input)
+	assert.Equal(t, "INVALID_PRIORITY", err.Code)
+	assert.Equal(t, []interface{}{"LOW", "NORMAL", "HIGH", "URGENT"}, err.Cause)
+}
+
+func TestOrderService_UpdateOrderPriority(t *testing.T) {
+	t.Run("updates an open order and emits an event", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		mockPublisher := new(MockEventPublisher)
+		service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop())
+
+		existing := &models.Order{ID: "order-123", Status: models.StatusInProgress, Priority: models.PriorityNormal, Version: 2}
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(existing, nil)
+		mockRepo.On("Update", mock.Anything, existing).Return(nil)
+		mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+		mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+			return event.EventType == models.EventOrderPriorityChanged &&
+				event.OldPriority == models.PriorityNormal &&
+				event.Priority == models.PriorityHigh
+		})).Return(nil)
+
+		order, err := service.UpdateOrderPriority(context.Background(), "order-123", models.PriorityHigh)
+
+		assert.Nil(t, err)
+		assert.Equal(t, models.PriorityHigh, order.Priority)
+		assert.Equal(t, 3, order.Version)
+		mockPublisher.AssertExpectations(t)
+	})
+
+	t.Run("rejects final orders", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(&models.Order{ID: "order-123", Status: models.StatusDelivered}, nil)
+
+		_, err := service.UpdateOrderPriority(context.Background(), "order-123", models.PriorityHigh)
+
+		assert.Equal(t, 409, err.Status)
+		assert.Equal(t, "ORDER_FINAL", err.Code)
+		mockRepo.AssertNotCalled(t, "Update")
+	})
+}
+
+func TestOrderService_UpdateOrderTags(t *testing.T) {
+	t.Run("normalizes tags and emits an event", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		mockPublisher := new(MockEventPublisher)
+		service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop())
+
+		existing := &models.Order{ID: "order-123", Status: models.StatusNew, Tags: []string{"vip"}, Version: 1}
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(existing, nil)
+		mockRepo.On("Update", mock.Anything, existing).Return(nil)
+		mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+		mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+			return event.EventType == models.EventOrderTagsChanged &&
+				assert.ObjectsAreEqual([]string{"vip"}, event.OldTags) &&
+				assert.ObjectsAreEqual([]string{"fragile", "vip"}, event.Tags)
+		})).Return(nil)
+
+		order, err := service.UpdateOrderTags(context.Background(), "order-123", []string{" Fragile", "VIP", "fragile"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"fragile", "vip"}, order.Tags)
+		assert.Equal(t, 2, order.Version)
+		mockPublisher.AssertExpectations(t)
+	})
+
+	t.Run("rejects malformed and too many tags", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+		_, err := service.UpdateOrderTags(context.Background(), "order-123", []string{"ok", "not ok", ""})
+		assert.Equal(t, "INVALID_TAG", err.Code)
+		assert.Equal(t, []interface{}{"not ok", ""}, err.Cause)
+
+		tooMany := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+		_, err = service.UpdateOrderTags(context.Background(), "order-123", tooMany)
+		assert.Equal(t, "TOO_MANY_TAGS", err.Code)
+
+		mockRepo.AssertNotCalled(t, "FindByID")
+	})
+}
+
+func TestOrderService_GetOrderTransitions(t *testing.T) {
+	deliveredAt := time.Now().Add(-48 * time.Hour)
+
+	tests := []struct {
+		name     string
+		order    *models.Order
+		window   time.Duration
+		expected []models.OrderStatus
+	}{
+		{"New order", &models.Order{ID: "order-123", Status: models.StatusNew}, 0,
+			[]models.OrderStatus{models.StatusInProgress, models.StatusCancelled}},
+		{"In progress order", &models.Order{ID: "order-123", Status: models.StatusInProgress}, 0,
+			[]models.OrderStatus{models.StatusPartiallyDelivered, models.StatusDelivered, models.StatusCancelled}},
+		{"Delivered order within the return window", &models.Order{ID: "order-123", Status: models.StatusDelivered, DeliveredAt: &deliveredAt}, 72 * time.Hour,
+			[]models.OrderStatus{models.StatusReturnRequested}},
+		{"Delivered order past the return window", &models.Order{ID: "order-123", Status: models.StatusDelivered, DeliveredAt: &deliveredAt}, 24 * time.Hour,
+			[]models.OrderStatus{}},
+		{"Cancelled order", &models.Order{ID: "order-123", Status: models.StatusCancelled}, 0, []models.OrderStatus{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockOrderRepository)
+			mockCache := new(MockCacheRepository)
+			service := services.NewOrderService(mockRepo, mockCache, new(MockEventPublisher), zap.NewNop(), services.WithReturnWindow(tt.window))
+
+			mockCache.On("GetOrder", mock.Anything, "order-123").Return(tt.order, nil)
+
+			transitions, err := service.GetOrderTransitions(context.Background(), "order-123")
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.order.Status, transitions.Status)
+			assert.Equal(t, tt.expected, transitions.Transitions)
+			mockRepo.AssertNotCalled(t, "FindByID")
+		})
+	}
+}
+
+func TestOrderService_RequestOrderReturn(t *testing.T) {
+	deliveredAt := time.Now().Add(-time.Hour)
+	delivered := func() *models.Order {
+		return &models.Order{
+			ID:          "order-123",
+			Status:      models.StatusDelivered,
+			Version:     3,
+			DeliveredAt: &deliveredAt,
+			Items:       []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 2, Price: 999.99}},
+		}
+	}
+
+	t.Run("Emits a return requested event", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		mockPublisher := new(MockEventPublisher)
+		service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop(), services.WithReturnWindow(24*time.Hour))
+
+		existing := delivered()
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(existing, nil)
+		mockRepo.On("Update", mock.Anything, existing).Return(nil)
+		mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+		mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+			return event.EventType == models.EventOrderReturnRequested &&
+				event.OldStatus == models.StatusDelivered &&
+				event.Return != nil && event.Return.Reason == "damaged"
+		})).Return(nil)
+
+		order, err := service.RequestOrderReturn(context.Background(), "order-123", "damaged", []models.ReturnItem{{SKU: "LAPTOP-001", Quantity: 1}})
+
+		assert.Nil(t, err)
+		assert.Equal(t, models.StatusReturnRequested, order.Status)
+		mockPublisher.AssertExpectations(t)
+	})
+
+	t.Run("Rejects returns outside the window", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(), services.WithReturnWindow(time.Minute))
+
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(delivered(), nil)
+
+		_, err := service.RequestOrderReturn(context.Background(), "order-123", "damaged", []models.ReturnItem{{SKU: "LAPTOP-001", Quantity: 1}})
+
+		assert.Equal(t, 409, err.Status)
+		assert.Equal(t, "RETURN_WINDOW_EXPIRED", err.Code)
+		mockRepo.AssertNotCalled(t, "Update")
+	})
+
+	t.Run("Rejects items over the ordered quantity", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(delivered(), nil)
+
+		_, err := service.RequestOrderReturn(context.Background(), "order-123", "damaged", []models.ReturnItem{{SKU: "LAPTOP-001", Quantity: 3}})
+
+		assert.Equal(t, 400, err.Status)
+		assert.Equal(t, "INVALID_RETURN_ITEMS", err.Code)
+		assert.Equal(t, []interface{}{"LAPTOP-001"}, err.Cause)
+	})
+}
+
+func TestOrderService_UpdateOrderStatus_ReturnedEmitsReturnedEvent(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	mockPublisher := new(MockEventPublisher)
+	service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop())
+
+	existing := &models.Order{
+		ID:      "order-123",
+		Status:  models.StatusReturnRequested,
+		Version: 4,
+		Return:  &models.OrderReturn{Reason: "damaged", Items: []models.ReturnItem{{SKU: "LAPTOP-001", Quantity: 1}}},
+	}
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(existing, nil)
+	mockRepo.On("Update", mock.Anything, existing).Return(nil)
+	mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+	mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+		return event.EventType == models.EventOrderReturned && event.Return.ReturnedAt != nil
+	})).Return(nil)
+
+	order, err := service.UpdateOrderStatus(context.Background(), "order-123", models.StatusReturned)
+
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusReturned, order.Status)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestOrderService_RecordOrderDelivery(t *testing.T) {
+	inProgress := func() *models.Order {
+		return &models.Order{
+			ID:      "order-123",
+			Status:  models.StatusInProgress,
+			Version: 2,
+			Items:   []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 2, Price: 999.99}},
+		}
+	}
+
+	t.Run("Completes the order and emits the delivery lines", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		mockPublisher := new(MockEventPublisher)
+		service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop())
+
+		existing := inProgress()
+		delivery := []models.DeliveryItem{{SKU: "LAPTOP-001", Quantity: 2}}
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(existing, nil)
+		mockRepo.On("Update", mock.Anything, existing).Return(nil)
+		mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+		mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+			return event.EventType == models.EventOrderItemsDelivered &&
+				event.NewStatus == models.StatusDelivered &&
+				assert.ObjectsAreEqual(delivery, event.Delivery)
+		})).Return(nil)
+
+		order, err := service.RecordOrderDelivery(context.Background(), "order-123", delivery)
+
+		assert.Nil(t, err)
+		assert.Equal(t, models.StatusDelivered, order.Status)
+		assert.Equal(t, 3, order.Version)
+		mockCache.AssertExpectations(t)
+		mockPublisher.AssertExpectations(t)
+	})
+
+	t.Run("Rejects over-delivery", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(inProgress(), nil)
+
+		_, err := service.RecordOrderDelivery(context.Background(), "order-123", []models.DeliveryItem{{SKU: "LAPTOP-001", Quantity: 3}})
+
+		assert.Equal(t, 400, err.Status)
+		assert.Equal(t, "INVALID_DELIVERY_ITEMS", err.Code)
+		mockRepo.AssertNotCalled(t, "Update")
+	})
+}
+
+func TestOrderService_EventsCarryCorrelation(t *testing.T) {
+	emit := func(ctx context.Context) *models.OrderEvent {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		mockPublisher := new(MockEventPublisher)
+		service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop())
+
+		var published *models.OrderEvent
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(&models.Order{ID: "order-123", Status: models.StatusNew, Version: 1}, nil)
+		mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+		mockPublisher.On("PublishOrderEvent", mock.Anything, mock.AnythingOfType("*models.OrderEvent")).
+			Run(func(args mock.Arguments) { published = args.Get(1).(*models.OrderEvent) }).
+			Return(nil)
+
+		_, err := service.UpdateOrderStatus(ctx, "order-123", models.StatusInProgress)
+		assert.Nil(t, err)
+		return published
+	}
+
+	t.Run("request is the correlation and the cause", func(t *testing.T) {
+		event := emit(correlation.WithID(context.Background(), "request-1"))
+		assert.Equal(t, "request-1", event.CorrelationID)
+		assert.Equal(t, "request-1", event.CausationID)
+	})
+
+	t.Run("causing event from the context", func(t *testing.T) {
+		ctx := correlation.WithCausationID(correlation.WithID(context.Background(), "request-1"), "event-1")
+		event := emit(ctx)
+		assert.Equal(t, "request-1", event.CorrelationID)
+		assert.Equal(t, "event-1", event.CausationID)
+	})
+
+	t.Run("no context starts a new transaction", func(t *testing.T) {
+		event := emit(context.Background())
+		assert.Equal(t, event.EventID, event.CorrelationID)
+		assert.Equal(t, event.EventID, event.CausationID)
+	})
+}
+
+func TestOrderService_CreateOrder_ChannelAndMetadata(t *testing.T) {
+	items := []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 999.99}}
+	customerID := "123e4567-e89b-12d3-a456-426614174000"
+
+	t.Run("Stored and carried by the event", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockPublisher := new(MockEventPublisher)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), mockPublisher, zap.NewNop())
+
+		metadata := map[string]string{"appVersion": "4.2.0"}
+		mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+		mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+			return event.Channel == models.ChannelMobile && event.ClientMetadata["appVersion"] == "4.2.0"
+		})).Return(nil)
+
+		order, err := service.CreateOrder(context.Background(), services.CreateOrderInput{
+			CustomerID:     customerID,
+			Items:          items,
+			Channel:        models.ChannelMobile,
+			ClientMetadata: metadata,
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, models.ChannelMobile, order.Channel)
+		assert.Equal(t, metadata, order.ClientMetadata)
+		mockPublisher.AssertExpectations(t)
+	})
+
+	t.Run("Rejects unknown channels and oversized metadata", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+		_, err := service.CreateOrder(context.Background(), services.CreateOrderInput{CustomerID: customerID, Items: items, Channel: "FAX"})
+		assert.Equal(t, "INVALID_CHANNEL", err.Code)
+
+		_, err = service.CreateOrder(context.Background(), services.CreateOrderInput{
+			CustomerID:     customerID,
+			Items:          items,
+			ClientMetadata: map[string]string{"note": strings.Repeat("a", 257)},
+		})
+		assert.Equal(t, 400, err.Status)
+		assert.Equal(t, "INVALID_CLIENT_METADATA", err.Code)
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"field":   "clientMetadata.note",
+			"message": "must be at most 256 characters long",
+		}}, err.Cause)
+
+		mockRepo.AssertNotCalled(t, "Create")
+	})
+}
+
+func TestOrderService_CreateOrder_Tenant(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockPublisher := new(MockEventPublisher)
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), mockPublisher, zap.NewNop())
+
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(order *models.Order) bool {
+		return order.TenantID == "brand-b"
+	})).Return(nil)
+	mockPublisher.On("PublishOrderEvent", mock.Anything, mock.MatchedBy(func(event *models.OrderEvent) bool {
+		return event.EventType == models.EventOrderCreated && event.TenantID == "brand-b"
+	})).Return(nil)
+
+	ctx := tenant.WithID(context.Background(), "brand-b")
+	order, err := service.CreateOrder(ctx, services.CreateOrderInput{
+		CustomerID: "123e4567-e89b-12d3-a456-426614174000",
+		Items:      []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 999.99}},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "brand-b", order.TenantID)
+	mockRepo.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+// MockCreationLimiter simula el limitador de creación de pedidos por cliente
+type MockCreationLimiter struct {
+	mock.Mock
+}
+
+func (m *MockCreationLimiter) Reserve(ctx context.Context, customerID string) (bool, time.Duration, *repositories.RepositoryError) {
+	args := m.Called(ctx, customerID)
+	return args.Bool(0), args.Get(1).(time.Duration), args.Get(2).(*repositories.RepositoryError)
+}
+
+func TestOrderService_CreateOrder_CreationRate(t *testing.T) {
+	input := services.CreateOrderInput{
+		CustomerID: "123e4567-e89b-12d3-a456-426614174000",
+		Items:      []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 999.99}},
+	}
+
+	t.Run("Rejected over the rate", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		limiter := new(MockCreationLimiter)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+			services.WithCreationLimiter(limiter))
+
+		limiter.On("Reserve", mock.Anything, input.CustomerID).Return(false, 12*time.Second, (*repositories.RepositoryError)(nil))
+
+		order, err := service.CreateOrder(context.Background(), input)
+
+		assert.Nil(t, order)
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusTooManyRequests, err.Status)
+		assert.Equal(t, "RATE_LIMITED", err.Code)
+		assert.Equal(t, 12*time.Second, err.RetryAfter)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Delayed within the maximum", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		limiter := new(MockCreationLimiter)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+			services.WithCreationLimiter(limiter))
+
+		limiter.On("Reserve", mock.Anything, input.CustomerID).Return(true, 20*time.Millisecond, (*repositories.RepositoryError)(nil))
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		start := time.Now()
+		order, err := service.CreateOrder(context.Background(), input)
+
+		assert.Nil(t, err)
+		assert.NotNil(t, order)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Delay cut short by the request", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		limiter := new(MockCreationLimiter)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+			services.WithCreationLimiter(limiter))
+
+		limiter.On("Reserve", mock.Anything, input.CustomerID).Return(true, time.Minute, (*repositories.RepositoryError)(nil))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := service.CreateOrder(ctx, input)
+
+		require.NotNil(t, err)
+		assert.Equal(t, services.StatusClientClosedRequest, err.Status)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Limiter unavailable accepts the order", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		limiter := new(MockCreationLimiter)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), newOrderCreatedPublisher(), zap.NewNop(),
+			services.WithCreationLimiter(limiter))
+
+		limiter.On("Reserve", mock.Anything, input.CustomerID).Return(false, time.Duration(0),
+			&repositories.RepositoryError{StatusCode: http.StatusInternalServerError, Cause: "failed to reserve order creation"})
+		mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		_, err := service.CreateOrder(context.Background(), input)
+
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestOrderService_GetOrderStats(t *testing.T) {
+	aggregated := &models.OrderStats{
+		OrdersByStatus:    map[models.OrderStatus]int64{models.StatusNew: 3},
+		TotalOrders:       3,
+		AverageOrderValue: 42,
+	}
+	// sinceYesterday comprueba que se cuentan los pedidos de las últimas 24 horas
+	sinceYesterday := mock.MatchedBy(func(since time.Time) bool {
+		return time.Since(since) >= 24*time.Hour && time.Since(since) < 25*time.Hour
+	})
+
+	t.Run("Cache miss aggregates and caches the stats", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		service := services.NewOrderService(mockRepo, mockCache, new(MockEventPublisher), zap.NewNop(), services.WithStatsCacheTTL(30*time.Second))
+
+		mockCache.On("GetOrderStats", mock.Anything).Return(nil, nil)
+		mockRepo.On("Stats", mock.Anything, sinceYesterday).Return(aggregated, nil)
+		mockCache.On("SetOrderStats", mock.Anything, aggregated, 30*time.Second).Return(nil)
+
+		stats, err := service.GetOrderStats(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), stats.TotalOrders)
+		assert.False(t, stats.GeneratedAt.IsZero())
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	t.Run("Cache hit skips the aggregation", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		service := services.NewOrderService(mockRepo, mockCache, new(MockEventPublisher), zap.NewNop(), services.WithStatsCacheTTL(30*time.Second))
+
+		mockCache.On("GetOrderStats", mock.Anything).Return(aggregated, nil)
+
+		stats, err := service.GetOrderStats(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, aggregated, stats)
+		mockRepo.AssertNotCalled(t, "Stats", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Not cached without TTL", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		service := services.NewOrderService(mockRepo, mockCache, new(MockEventPublisher), zap.NewNop())
+
+		mockRepo.On("Stats", mock.Anything, sinceYesterday).Return(&models.OrderStats{}, nil)
+
+		_, err := service.GetOrderStats(context.Background())
+
+		assert.Nil(t, err)
+		mockCache.AssertNotCalled(t, "GetOrderStats", mock.Anything)
+		mockCache.AssertNotCalled(t, "SetOrderStats", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Aggregation error", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+
+		mockRepo.On("Stats", mock.Anything, mock.Anything).Return(nil, &repositories.RepositoryError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Failed to aggregate order stats",
+		})
+
+		stats, err := service.GetOrderStats(context.Background())
+
+		assert.Nil(t, stats)
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusInternalServerError, err.Status)
+	})
+}
+
+// canceledContext devuelve un contexto de una petición cuyo cliente ya se ha ido
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func TestOrderService_CanceledContext(t *testing.T) {
+	deliveredAt := time.Now().Add(-time.Hour)
+	// stored devuelve el pedido guardado en el estado que cada operación admite
+	stored := func(status models.OrderStatus) *models.Order {
+		order := &models.Order{
+			ID:         "order-123",
+			CustomerID: "customer-1",
+			Status:     status,
+			Version:    2,
+			Items:      []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 2, Price: 999.99}},
+		}
+		if status == models.StatusDelivered {
+			order.DeliveredAt = &deliveredAt
+		}
+		return order
+	}
+
+	tests := []struct {
+		name   string
+		status models.OrderStatus
+		call   func(ctx context.Context, service services.OrderService) *services.ServiceError
+	}{
+		{"CreateOrder", "", func(ctx context.Context, service services.OrderService) *services.ServiceError {
+			_, err := service.CreateOrder(ctx, services.CreateOrderInput{
+				CustomerID: "123e4567-e89b-12d3-a456-426614174000",
+				Items:      []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 999.99}},
+			})
+			return err
+		}},
+		{"GetOrderByID", "", func(ctx context.Context, service services.OrderService) *services.ServiceError {
+			_, err := service.GetOrderByID(ctx, "order-123")
+			return err
+		}},
+		{"UpdateOrderStatus", models.StatusNew, func(ctx context.Context, service services.OrderService) *services.ServiceError {
+			_, err := service.UpdateOrderStatus(ctx, "order-123", models.StatusInProgress)
+			return err
+		}},
+		{"ForceOrderStatus", models.StatusDelivered, func(ctx context.Context, service services.OrderService) *services.ServiceError {
+			_, err := service.ForceOrderStatus(ctx, "order-123", models.StatusInProgress, "ops", "wrong scan")
+			return err
+		}},
+		{"UpdateOrderPriority", models.StatusNew, func(ctx context.Context, service services.OrderService) *services.ServiceError {
+			_, err := service.UpdateOrderPriority(ctx, "order-123", models.PriorityHigh)
+			return err
+		}},
+		{"UpdateOrderTags", models.StatusNew, func(ctx context.Context, service services.OrderService) *services.ServiceError {
+			_, err := service.UpdateOrderTags(ctx, "order-123", []string{"gift"})
+			return err
+		}},
+		{"AddOrderNote", "", func(ctx context.Context, service services.OrderService) *services.ServiceError {
+			_, err := service.AddOrderNote(ctx, "order-123", "dispatcher", "gate code 4411")
+			return err
+		}},
+		{"RecordOrderDelivery", models.StatusInProgress, func(ctx context.Context, service services.OrderService) *services.ServiceError {
+			_, err := service.RecordOrderDelivery(ctx, "order-123", []models.DeliveryItem{{SKU: "LAPTOP-001", Quantity: 2}})
+			return err
+		}},
+		{"RequestOrderReturn", models.StatusDelivered, func(ctx context.Context, service services.OrderService) *services.ServiceError {
+			_, err := service.RequestOrderReturn(ctx, "order-123", "damaged", []models.ReturnItem{{SKU: "LAPTOP-001", Quantity: 1}})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockOrderRepository)
+			mockCache := new(MockCacheRepository)
+			mockPublisher := new(MockEventPublisher)
+			service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop())
+
+			mockCache.On("GetOrder", mock.Anything, "order-123").Return(nil, nil)
+			if tt.status != "" {
+				mockRepo.On("FindByID", mock.Anything, "order-123").Return(stored(tt.status), nil)
+			}
+
+			err := tt.call(canceledContext(), service)
+
+			require.NotNil(t, err)
+			assert.Equal(t, services.StatusClientClosedRequest, err.Status)
+			assert.Equal(t, "REQUEST_CANCELED", err.Code)
+			// Nada se escribe ni se publica para un cliente que se ha ido
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "AppendNote", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			if tt.status == "" {
+				mockRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+			}
+			mockPublisher.AssertNotCalled(t, "PublishOrderEvent", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestOrderService_ContextRepositoryErrors(t *testing.T) {
+	driverErr := &repositories.RepositoryError{
+		StatusCode: http.StatusInternalServerError,
+		Cause:      "context canceled",
+		Message:    "Failed to find orders",
+	}
+
+	t.Run("Canceled request", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+		mockRepo.On("FindWithFilters", mock.Anything, mock.Anything, 1, 10).Return(nil, nil, driverErr)
+
+		_, _, err := service.ListOrders(canceledContext(), services.ListOrdersFilter{}, 1, 10)
+
+		require.NotNil(t, err)
+		assert.Equal(t, services.StatusClientClosedRequest, err.Status)
+	})
+
+	t.Run("Request deadline exceeded", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(nil, driverErr)
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		_, err := service.UpdateOrderStatus(ctx, "order-123", models.StatusInProgress)
+
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusGatewayTimeout, err.Status)
+		assert.Equal(t, "REQUEST_TIMEOUT", err.Code)
+	})
+
+	t.Run("Live request keeps the repository status", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop())
+		mockRepo.On("FindWithFilters", mock.Anything, mock.Anything, 1, 10).Return(nil, nil, driverErr)
+
+		_, _, err := service.ListOrders(context.Background(), services.ListOrdersFilter{}, 1, 10)
+
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusInternalServerError, err.Status)
+	})
+}
+
+func TestOrderService_SideEffectsOutliveTheRequest(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	mockPublisher := new(MockEventPublisher)
+	service := services.NewOrderService(mockRepo, mockCache, mockPublisher, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(tenant.WithID(context.Background(), "brand-b"))
+	defer cancel()
+	// live comprueba que el contexto sigue vivo y conserva el tenant
+	live := mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Err() == nil && tenant.ID(ctx) == "brand-b"
+	})
+
+	existing := &models.Order{ID: "order-123", CustomerID: "customer-1", TenantID: "brand-b", Status: models.StatusNew, Version: 1}
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(existing, nil)
+	// El cliente se va justo después de guardar el cambio
+	mockRepo.On("Update", mock.Anything, existing).Run(func(mock.Arguments) { cancel() }).Return(nil)
+	mockCache.On("InvalidateOrder", live, "order-123").Return(nil)
+	mockPublisher.On("PublishOrderEvent", live, mock.Anything).Return(nil)
+
+	_, err := service.UpdateOrderStatus(ctx, "order-123", models.StatusInProgress)
+
+	assert.Nil(t, err)
+	mockCache.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestOrderService_GetOrderByID_SkipsCachingIncompleteOrders(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	service := services.NewOrderService(mockRepo, mockCache, new(MockEventPublisher), zap.NewNop())
+
+	// Pedido decodificado a medias: el estado no es uno conocido
+	partial := &models.Order{ID: "order-123", CustomerID: "customer-1", Status: "SHIPPED"}
+	mockCache.On("GetOrder", mock.Anything, "order-123").Return(nil, nil)
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(partial, nil)
+
+	order, err := service.GetOrderByID(context.Background(), "order-123")
+
+	assert.Nil(t, err)
+	assert.Equal(t, partial, order)
+	mockCache.AssertNotCalled(t, "SetOrder", mock.Anything, mock.Anything)
+}
+
+func TestOrderService_GetOrderSummary(t *testing.T) {
+	stored := &models.Order{ID: "order-123", CustomerID: "customer-1", Status: models.StatusNew, TotalAmount: 42, Version: 2,
+		Items: []models.OrderItem{{SKU: "LAPTOP-001", Quantity: 1, Price: 42}}}
+
+	t.Run("Compact cache miss stores only the summary", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		service := services.NewOrderService(mockRepo, mockCache, new(MockEventPublisher), zap.NewNop(), services.WithCompactSummaries(true))
+
+		mockCache.On("GetOrderSummary", mock.Anything, "order-123").Return(nil, nil)
+		mockRepo.On("FindByID", mock.Anything, "order-123").Return(stored, nil)
+		mockCache.On("SetOrderSummary", mock.Anything, stored.Summary()).Return(nil)
+
+		summary, err := service.GetOrderSummary(context.Background(), "order-123")
+
+		assert.Nil(t, err)
+		assert.Equal(t, stored.Summary(), summary)
+		mockCache.AssertExpectations(t)
+		mockCache.AssertNotCalled(t, "SetOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Compact cache hit skips the database", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		mockCache := new(MockCacheRepository)
+		service := services.NewOrderService(mockRepo, mockCache, new(MockEventPublisher), zap.NewNop(), services.WithCompactSummaries(true))
+
+		mockCache.On("GetOrderSummary", mock.Anything, "order-123").Return(stored.Summary(), nil)
+
+		summary, err := service.GetOrderSummary(context.Background(), "order-123")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, summary.Version)
+		mockRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Projected from the full order without compact cache", func(t *testing.T) {
+		mockCache := new(MockCacheRepository)
+		service := services.NewOrderService(new(MockOrderRepository), mockCache, new(MockEventPublisher), zap.NewNop())
+
+		mockCache.On("GetOrder", mock.Anything, "order-123").Return(stored, nil)
+
+		summary, err := service.GetOrderSummary(context.Background(), "order-123")
+
+		assert.Nil(t, err)
+		assert.Equal(t, stored.Summary(), summary)
+		mockCache.AssertNotCalled(t, "GetOrderSummary", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderService_UpdateOrderStatus_InMemoryBus(t *testing.T) {
+	// Arrange: el bus en memoria reemplaza a Kafka
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	bus := memory.NewInMemoryPublisher(10, zap.NewNop())
+
+	service := services.NewOrderService(mockRepo, mockCache, bus, zap.NewNop())
+
+	existingOrder := &models.Order{
+		ID:         "order-123",
+		CustomerID: "customer-456",
+		Status:     models.StatusNew,
+		Version:    1,
+	}
+
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(existingOrder, nil)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+	mockCache.On("InvalidateOrder", mock.Anything, "order-123").Return(nil)
+
+	// Act
+	_, err := service.UpdateOrderStatus(context.Background(), "order-123", models.StatusInProgress)
+
+	// Assert
+	assert.Nil(t, err)
+	events := bus.EventsFor("order-123")
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, models.EventOrderStatusChanged, events[0].Event.EventType)
+		assert.Equal(t, models.StatusNew, events[0].Event.OldStatus)
+		assert.Equal(t, models.StatusInProgress, events[0].Event.NewStatus)
+		assert.False(t, events[0].Replayed)
+	}
+}
+
+func TestOrderService_FeatureFlags_RuntimeToggle(t *testing.T) {
+	// Arrange: la caché y las instantáneas de eventos se pueden apagar en caliente
+	mockRepo := new(MockOrderRepository)
+	mockCache := new(MockCacheRepository)
+	bus := memory.NewInMemoryPublisher(10, zap.NewNop())
+	flags := features.New(map[string]bool{features.Cache: true, features.EventSnapshots: true},
+		[]string{features.Cache, features.EventSnapshots}, zap.NewNop())
+	service := services.NewOrderService(mockRepo, mockCache, bus, zap.NewNop(), services.WithFeatureFlags(flags))
+
+	stored := &models.Order{ID: "order-123", CustomerID: "customer-456", Status: models.StatusNew, Version: 1}
+	mockRepo.On("FindByID", mock.Anything, "order-123").Return(stored, nil)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Order")).Return(nil)
+
+	_, err := flags.Set(features.Cache, false)
+	assert.NoError(t, err)
+	_, err = flags.Set(features.EventSnapshots, false)
+	assert.NoError(t, err)
+
+	// Act
+	_, svcErr := service.GetOrderByID(context.Background(), "order-123")
+	assert.Nil(t, svcErr)
+	_, svcErr = service.UpdateOrderStatus(context.Background(), "order-123", models.StatusInProgress)
+	assert.Nil(t, svcErr)
+
+	// Assert
+	assert.Empty(t, mockCache.Calls)
+	events := bus.EventsFor("order-123")
+	if assert.Len(t, events, 1) {
+		assert.Nil(t, events[0].Event.Before)
+		assert.Nil(t, events[0].Event.After)
+	}
+
+	// Al volver a encender la caché se consulta de nuevo
+	_, err = flags.Set(features.Cache, true)
+	assert.NoError(t, err)
+	mockCache.On("GetOrder", mock.Anything, "order-123").Return(stored, nil)
+	_, svcErr = service.GetOrderByID(context.Background(), "order-123")
+	assert.Nil(t, svcErr)
+	mockCache.AssertCalled(t, "GetOrder", mock.Anything, "order-123")
+}
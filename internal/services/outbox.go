@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"orders/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// RetryEvent publishes again an event of the log that did not reach the
+// broker, keeping its event ID so consumers can drop duplicates, and records
+// the attempt. Events already sent are rejected; ReplayOrderEvents publishes
+// them again. It returns the event with the outcome of the attempt.
+func (s *order) RetryEvent(ctx context.Context, eventID string) (*models.EventRecord, *ServiceError) {
+	if s.eventStore == nil {
+		return nil, &ServiceError{
+			Status:  http.StatusServiceUnavailable,
+			Message: "Event log is not configured",
+		}
+	}
+
+	record, err := s.eventStore.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+	if record.Status == models.EventStatusSent {
+		return nil, &ServiceError{
+			Status:  http.StatusConflict,
+			Code:    "EVENT_ALREADY_SENT",
+			Message: "Event was already published",
+			Cause:   []interface{}{eventID},
+		}
+	}
+
+	// The outcome is recorded even if the admin went away meanwhile
+	ctx, cancel := detach(ctx)
+	defer cancel()
+
+	event := record.OrderEvent
+	publishErr := s.eventPublisher.PublishOrderEvent(ctx, &event)
+
+	record.Attempts++
+	record.Status, record.LastError = models.EventStatusSent, ""
+	if publishErr != nil {
+		record.Status, record.LastError = models.EventStatusFailed, publishErr.Error()
+	} else {
+		publishedAt := time.Now()
+		record.PublishedAt = &publishedAt
+	}
+	if err := s.eventStore.UpdateDelivery(ctx, eventID, record.Status, record.LastError); err != nil {
+		s.logger.Warn("Failed to record event delivery",
+			zap.String("eventId", eventID),
+			zap.String("cause", err.Cause),
+		)
+	}
+
+	if publishErr != nil {
+		s.logger.Error("Failed to retry event",
+			zap.Error(publishErr),
+			zap.String("orderId", record.OrderID),
+			zap.String("eventId", eventID),
+			zap.Int("attempts", record.Attempts),
+		)
+		return nil, &ServiceError{
+			Status:  http.StatusBadGateway,
+			Message: "Failed to publish event",
+			Cause:   []interface{}{publishErr.Error(), eventID},
+		}
+	}
+
+	s.logger.Info("Event retried",
+		zap.String("orderId", record.OrderID),
+		zap.String("eventId", eventID),
+		zap.Int("attempts", record.Attempts),
+	)
+	return record, nil
+}
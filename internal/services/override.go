@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	apperrors "orders/internal/errors"
+	"orders/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// ForceOrderStatus moves an order to the given status bypassing the status
+// transition rules. It is meant for admins correcting mistakes, so a reason is
+// required and the status changed event is flagged as forced with the actor.
+func (s *order) ForceOrderStatus(ctx context.Context, orderID string, newStatus models.OrderStatus, actor, reason string) (*models.Order, *ServiceError) {
+	s.logger.Debug("Forcing order status",
+		zap.String("orderId", orderID),
+		zap.String("newStatus", string(newStatus)),
+		zap.String("actor", actor),
+	)
+
+	actor, reason = strings.TrimSpace(actor), strings.TrimSpace(reason)
+	if actor == "" || reason == "" {
+		return nil, &ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "OVERRIDE_REASON_REQUIRED",
+			Message: "An actor and a reason are required to force a status",
+			Cause:   []interface{}{models.ErrOverrideReasonRequired.Error()},
+		}
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+	ctx = orderScope(ctx, order)
+
+	before := order.Clone()
+	oldStatus := order.Status
+	if forceErr := order.ForceStatus(newStatus); forceErr != nil {
+		return nil, forceStatusError(forceErr, before)
+	}
+
+	if svcErr := contextError(ctx); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := changeError(before, order); svcErr != nil {
+		return nil, svcErr
+	}
+
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		s.logger.Error("Failed to force order status",
+			zap.String("orderId", orderID),
+		)
+		if svcErr := contextError(ctx); svcErr != nil {
+			return nil, svcErr
+		}
+		svcErr := &ServiceError{
+			Status:  err.StatusCode,
+			Message: err.Message,
+			Cause:   []interface{}{err.Cause},
+		}
+		if err.StatusCode == http.StatusConflict {
+			svcErr.Code = "VERSION_CONFLICT"
+		}
+		return nil, svcErr
+	}
+
+	s.invalidateCachedOrder(ctx, orderID)
+	s.recordWrite(ctx, order.CustomerID)
+	s.emitEvent(ctx, models.NewOrderStatusForcedEvent(order, oldStatus, actor, reason).SetStates(before, order))
+	s.observeTransition(order, oldStatus)
+
+	if newStatus == models.StatusCancelled && oldStatus != models.StatusCancelled {
+		s.releaseInventory(ctx, order.ID)
+	}
+
+	s.logger.Warn("Order status forced",
+		zap.String("orderId", orderID),
+		zap.String("oldStatus", string(oldStatus)),
+		zap.String("newStatus", string(newStatus)),
+		zap.String("actor", actor),
+		zap.String("reason", reason),
+	)
+
+	return order, nil
+}
+
+// forceStatusError maps the errors of models.Order.ForceStatus.
+func forceStatusError(err error, order *models.Order) *ServiceError {
+	switch {
+	case errors.Is(err, models.ErrInvalidOrderData):
+		return &ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_STATUS",
+			Message: "Invalid status",
+			Cause:   []interface{}{err.Error()},
+		}
+	case errors.Is(err, models.ErrInvalidStatusTransition):
+		return &ServiceError{
+			Status:  http.StatusConflict,
+			Code:    "STATUS_UNCHANGED",
+			Message: "Order is already " + string(order.Status),
+			Cause:   []interface{}{err.Error()},
+			Details: apperrors.NewOrderStateDetails(order),
+		}
+	default:
+		return &ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_STATUS_TRANSITION",
+			Message: "Status cannot be forced",
+			Cause:   []interface{}{err.Error()},
+			Details: apperrors.NewOrderStateDetails(order),
+		}
+	}
+}
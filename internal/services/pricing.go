@@ -0,0 +1,27 @@
+package services
+
+import (
+	"context"
+	"orders/internal/models"
+)
+
+// PriceProvider resolves the unit prices of order items on the server side
+// so that client-provided prices are never taken as the source of truth.
+type PriceProvider interface {
+	// ResolvePrices returns the items with their unit prices replaced by the
+	// authoritative ones. Unknown SKUs are reported with *models.UnknownSKUsError.
+	ResolvePrices(ctx context.Context, items []models.OrderItem) ([]models.OrderItem, error)
+}
+
+// passthroughPriceProvider keeps the prices sent by the client, preserving the
+// behavior of environments that have no catalog service available.
+type passthroughPriceProvider struct{}
+
+// NewPassthroughPriceProvider returns a PriceProvider that trusts client prices.
+func NewPassthroughPriceProvider() PriceProvider {
+	return passthroughPriceProvider{}
+}
+
+func (passthroughPriceProvider) ResolvePrices(_ context.Context, items []models.OrderItem) ([]models.OrderItem, error) {
+	return items, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"orders/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// UpdateOrderPriority changes the priority of an order that is not final yet.
+func (s *order) UpdateOrderPriority(ctx context.Context, orderID string, priority models.OrderPriority) (*models.Order, *ServiceError) {
+	s.logger.Debug("Updating order priority",
+		zap.String("orderId", orderID),
+		zap.String("priority", string(priority)),
+	)
+
+	if !priority.IsValid() {
+		return nil, priorityValidationError()
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+
+	before := order.Clone()
+	oldPriority := order.Priority
+	if setErr := order.SetPriority(priority); setErr != nil {
+		if errors.Is(setErr, models.ErrOrderFinal) {
+			return nil, &ServiceError{
+				Status:  http.StatusConflict,
+				Code:    "ORDER_FINAL",
+				Message: "Priority cannot be changed once the order is " + string(order.Status),
+				Cause:   []interface{}{setErr.Error()},
+			}
+		}
+		return nil, priorityValidationError()
+	}
+
+	if svcErr := contextError(ctx); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := changeError(before, order); svcErr != nil {
+		return nil, svcErr
+	}
+
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		s.logger.Error("Failed to update order priority",
+			zap.String("orderId", orderID),
+		)
+		return nil, repositoryError(ctx, err)
+	}
+
+	s.invalidateCachedOrder(ctx, orderID)
+	s.recordWrite(ctx, order.CustomerID)
+	s.emitEvent(ctx, models.NewOrderPriorityChangedEvent(order, oldPriority).SetStates(before, order))
+
+	s.logger.Info("Order priority updated successfully",
+		zap.String("orderId", orderID),
+		zap.String("oldPriority", string(oldPriority)),
+		zap.String("newPriority", string(priority)),
+	)
+
+	return order, nil
+}
+
+// priorityValidationError reports an unknown priority along with the allowed ones.
+func priorityValidationError() *ServiceError {
+	allowed := make([]interface{}, 0, len(models.Priorities))
+	for _, priority := range models.Priorities {
+		allowed = append(allowed, string(priority))
+	}
+	return &ServiceError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_PRIORITY",
+		Message: "Invalid priority",
+		Cause:   allowed,
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"orders/internal/repositories"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CreationLimiter smooths the order creation bursts of each customer.
+type CreationLimiter interface {
+	// Reserve takes a slot for an order of the customer. When allowed, the
+	// creation must wait the returned time first; when not, it is the time
+	// until a slot frees up.
+	Reserve(ctx context.Context, customerID string) (bool, time.Duration, *repositories.RepositoryError)
+}
+
+// WithCreationLimiter limits the rate at which each customer creates orders.
+func WithCreationLimiter(limiter CreationLimiter) Option {
+	return func(s *order) {
+		s.creationLimit = limiter
+	}
+}
+
+// throttleCreation waits for a creation slot of the customer, or rejects the
+// creation with 429 when the customer is over the rate. Orders are accepted
+// when the limiter cannot be reached.
+func (s *order) throttleCreation(ctx context.Context, customerID string) *ServiceError {
+	if s.creationLimit == nil {
+		return nil
+	}
+
+	allowed, wait, err := s.creationLimit.Reserve(ctx, customerID)
+	if err != nil {
+		s.logger.Warn("Order creation rate unavailable, accepting order",
+			zap.String("customerId", customerID),
+			zap.String("cause", err.Cause),
+		)
+		return nil
+	}
+	if !allowed {
+		s.logger.Warn("Order creation rate exceeded",
+			zap.String("customerId", customerID),
+			zap.Duration("retryAfter", wait),
+		)
+		return rateLimitedError(wait)
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	s.logger.Debug("Delaying order creation",
+		zap.String("customerId", customerID),
+		zap.Duration("wait", wait),
+	)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return contextError(ctx)
+	}
+}
+
+func rateLimitedError(retryAfter time.Duration) *ServiceError {
+	return &ServiceError{
+		Status:     http.StatusTooManyRequests,
+		Code:       "RATE_LIMITED",
+		Message:    "Too many orders created by the customer, retry later",
+		RetryAfter: retryAfter,
+	}
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// replayLockTTL is how long the replay lock outlives an instance that
+	// stopped reporting progress, e.g. because it crashed.
+	replayLockTTL = 30 * time.Second
+	// replayProgressInterval is how often a running replay saves its progress,
+	// extends its lock and checks whether it was cancelled.
+	replayProgressInterval = time.Second
+)
+
+// ReplayEventSource reads the ranges of the event log that are replayed.
+type ReplayEventSource interface {
+	CountWithFilters(ctx context.Context, filters map[string]interface{}) (int64, *repositories.RepositoryError)
+	StreamWithFilters(ctx context.Context, filters map[string]interface{}, each func(*models.EventRecord) error) *repositories.RepositoryError
+}
+
+// ReplayJobStore keeps the replay jobs and the lock that lets a single replay
+// run at a time across instances.
+type ReplayJobStore interface {
+	Lock(ctx context.Context, jobID string, ttl time.Duration) (bool, string, *repositories.RepositoryError)
+	ExtendLock(ctx context.Context, jobID string, ttl time.Duration) (bool, *repositories.RepositoryError)
+	Unlock(ctx context.Context, jobID string) *repositories.RepositoryError
+	SaveJob(ctx context.Context, job *models.ReplayJob) *repositories.RepositoryError
+	GetJob(ctx context.Context, jobID string) (*models.ReplayJob, *repositories.RepositoryError)
+	RequestCancel(ctx context.Context, jobID string) *repositories.RepositoryError
+}
+
+// ReplayRange selects the events replayed: those emitted between From and
+// To, inclusive, of any of Types, or of every type when empty.
+type ReplayRange struct {
+	From  time.Time
+	To    time.Time
+	Types []models.EventType
+}
+
+func (r ReplayRange) filters() map[string]interface{} {
+	filters := map[string]interface{}{
+		"from": r.From,
+		"to":   r.To,
+	}
+	if len(r.Types) > 0 {
+		types := make([]string, len(r.Types))
+		for i, eventType := range r.Types {
+			types[i] = string(eventType)
+		}
+		filters["eventTypes"] = types
+	}
+	return filters
+}
+
+// errReplayCancelled stops a replay whose cancellation was requested.
+var errReplayCancelled = errors.New("replay cancelled")
+
+// EventReplayer publishes the events of a time range again, flagged as
+// replays, so downstream consumers can rebuild their projections. Replays
+// run in the background at up to rate events per second.
+type EventReplayer struct {
+	events    ReplayEventSource
+	jobs      ReplayJobStore
+	publisher EventPublisher
+	rate      int
+	logger    *zap.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewEventReplayer creates the replayer. A rate of 0 does not limit replays.
+func NewEventReplayer(events ReplayEventSource, jobs ReplayJobStore, publisher EventPublisher, rate int, logger *zap.Logger) *EventReplayer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EventReplayer{
+		events:    events,
+		jobs:      jobs,
+		publisher: publisher,
+		rate:      rate,
+		logger:    logger,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// CountRange returns the number of events a replay of the range would
+// publish.
+func (r *EventReplayer) CountRange(ctx context.Context, replay ReplayRange) (int64, *ServiceError) {
+	total, err := r.events.CountWithFilters(ctx, replay.filters())
+	if err != nil {
+		return 0, repositoryError(ctx, err)
+	}
+	return total, nil
+}
+
+// StartRange starts replaying the range in the background and returns the
+// job tracking it. It fails with 409 while another replay is running.
+func (r *EventReplayer) StartRange(ctx context.Context, replay ReplayRange) (*models.ReplayJob, *ServiceError) {
+	jobID := uuid.New().String()
+	locked, holder, err := r.jobs.Lock(ctx, jobID, replayLockTTL)
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+	if !locked {
+		return nil, &ServiceError{
+			Status:  http.StatusConflict,
+			Code:    "REPLAY_IN_PROGRESS",
+			Message: "Another event replay is running",
+			Cause:   []interface{}{holder},
+		}
+	}
+
+	total, svcErr := r.CountRange(ctx, replay)
+	if svcErr == nil {
+		now := time.Now().UTC()
+		job := &models.ReplayJob{
+			ID:        jobID,
+			Status:    models.ReplayRunning,
+			From:      replay.From,
+			To:        replay.To,
+			Types:     replay.Types,
+			Total:     total,
+			StartedAt: now,
+			UpdatedAt: now,
+		}
+		if err := r.jobs.SaveJob(ctx, job); err != nil {
+			svcErr = repositoryError(ctx, err)
+		} else {
+			r.wg.Add(1)
+			go r.run(job, replay)
+			return job, nil
+		}
+	}
+
+	r.unlock(jobID)
+	return nil, svcErr
+}
+
+// GetJob returns a replay job with its progress.
+func (r *EventReplayer) GetJob(ctx context.Context, jobID string) (*models.ReplayJob, *ServiceError) {
+	job, err := r.jobs.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+	return job, nil
+}
+
+// CancelJob asks a running replay to stop. The instance running it stops
+// within a second; a finished job is returned as it is.
+func (r *EventReplayer) CancelJob(ctx context.Context, jobID string) (*models.ReplayJob, *ServiceError) {
+	job, err := r.jobs.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+	if job.Finished() {
+		return job, nil
+	}
+	if err := r.jobs.RequestCancel(ctx, jobID); err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+	job.CancelRequested = true
+	return job, nil
+}
+
+// Stop cancels the replays running on this instance and waits for them to
+// record where they stopped.
+func (r *EventReplayer) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// run replays the events of the job, reporting its progress, and releases
+// the lock when done.
+func (r *EventReplayer) run(job *models.ReplayJob, replay ReplayRange) {
+	defer r.wg.Done()
+	defer r.unlock(job.ID)
+
+	var pace *time.Ticker
+	if r.rate > 0 {
+		pace = time.NewTicker(time.Second / time.Duration(r.rate))
+		defer pace.Stop()
+	}
+	lastProgress := time.Now()
+
+	var stopErr error
+	err := r.events.StreamWithFilters(r.ctx, replay.filters(), func(record *models.EventRecord) error {
+		if pace != nil {
+			select {
+			case <-r.ctx.Done():
+				stopErr = r.ctx.Err()
+				return stopErr
+			case <-pace.C:
+			}
+		}
+
+		event := record.OrderEvent
+		if err := r.publisher.RepublishOrderEvent(r.ctx, &event); err != nil {
+			stopErr = err
+			return err
+		}
+		job.Replayed++
+
+		if time.Since(lastProgress) >= replayProgressInterval {
+			lastProgress = time.Now()
+			if err := r.progress(job); err != nil {
+				stopErr = err
+				return err
+			}
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		job.Status = models.ReplayCompleted
+	case errors.Is(stopErr, errReplayCancelled):
+		job.Status = models.ReplayCancelled
+	case r.ctx.Err() != nil:
+		job.Status = models.ReplayCancelled
+		job.Error = "instance shutting down"
+	default:
+		job.Status = models.ReplayFailed
+		job.Error = err.Cause
+	}
+	r.finish(job)
+}
+
+// progress saves the progress of the job and keeps its lock, returning
+// errReplayCancelled when the job was cancelled.
+func (r *EventReplayer) progress(job *models.ReplayJob) error {
+	ctx, cancel := context.WithTimeout(r.ctx, sideEffectTimeout)
+	defer cancel()
+
+	job.UpdatedAt = time.Now().UTC()
+	if err := r.jobs.SaveJob(ctx, job); err != nil {
+		r.logger.Warn("Failed to save replay progress",
+			zap.String("jobId", job.ID),
+			zap.String("cause", err.Cause),
+		)
+	}
+
+	held, err := r.jobs.ExtendLock(ctx, job.ID, replayLockTTL)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return errors.New("replay lock lost")
+	}
+
+	current, err := r.jobs.GetJob(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	if current.CancelRequested {
+		return errReplayCancelled
+	}
+	return nil
+}
+
+// finish records the final state of the job.
+func (r *EventReplayer) finish(job *models.ReplayJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), sideEffectTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	job.UpdatedAt = now
+	job.FinishedAt = &now
+	if err := r.jobs.SaveJob(ctx, job); err != nil {
+		r.logger.Error("Failed to save replay job",
+			zap.String("jobId", job.ID),
+			zap.String("cause", err.Cause),
+		)
+	}
+
+	r.logger.Info("Event replay finished",
+		zap.String("jobId", job.ID),
+		zap.String("status", string(job.Status)),
+		zap.Int64("replayed", job.Replayed),
+		zap.Int64("total", job.Total),
+	)
+}
+
+func (r *EventReplayer) unlock(jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), sideEffectTimeout)
+	defer cancel()
+	if err := r.jobs.Unlock(ctx, jobID); err != nil {
+		r.logger.Warn("Failed to release replay lock",
+			zap.String("jobId", jobID),
+			zap.String("cause", err.Cause),
+		)
+	}
+}
@@ -0,0 +1,137 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"orders/internal/messages/memory"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	redisrepo "orders/internal/repositories/redis"
+	"orders/internal/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeEventLog sirve los eventos de un rango desde memoria y guarda los filtros recibidos
+type fakeEventLog struct {
+	records []*models.EventRecord
+	filters map[string]interface{}
+}
+
+func (l *fakeEventLog) CountWithFilters(ctx context.Context, filters map[string]interface{}) (int64, *repositories.RepositoryError) {
+	l.filters = filters
+	return int64(len(l.records)), nil
+}
+
+func (l *fakeEventLog) StreamWithFilters(ctx context.Context, filters map[string]interface{}, each func(*models.EventRecord) error) *repositories.RepositoryError {
+	for _, record := range l.records {
+		if err := each(record); err != nil {
+			return &repositories.RepositoryError{StatusCode: http.StatusInternalServerError, Cause: err.Error(), Message: "Failed to stream events"}
+		}
+	}
+	return nil
+}
+
+func newEventLog(n int) *fakeEventLog {
+	log := &fakeEventLog{}
+	for i := range n {
+		order := &models.Order{ID: fmt.Sprintf("order-%d", i), CustomerID: "customer-1", Status: models.StatusNew}
+		log.records = append(log.records, models.NewEventRecord(models.NewOrderCreatedEvent(order)))
+	}
+	return log
+}
+
+func newReplayJobs(t *testing.T) *redisrepo.ReplayJobRepository {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return redisrepo.NewReplayJobRepository(client)
+}
+
+// waitForJob espera a que el trabajo termine y lo devuelve
+func waitForJob(t *testing.T, replayer *services.EventReplayer, jobID string) *models.ReplayJob {
+	var job *models.ReplayJob
+	require.Eventually(t, func() bool {
+		var svcErr *services.ServiceError
+		job, svcErr = replayer.GetJob(context.Background(), jobID)
+		return svcErr == nil && job.Finished()
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestEventReplayer_ReplaysRange(t *testing.T) {
+	events := newEventLog(3)
+	publisher := memory.NewInMemoryPublisher(10, zap.NewNop())
+	replayer := services.NewEventReplayer(events, newReplayJobs(t), publisher, 0, zap.NewNop())
+	t.Cleanup(replayer.Stop)
+	ctx := context.Background()
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	replay := services.ReplayRange{From: from, To: from.Add(48 * time.Hour), Types: []models.EventType{models.EventOrderCreated}}
+
+	count, svcErr := replayer.CountRange(ctx, replay)
+	require.Nil(t, svcErr)
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, []string{"ORDER_CREATED"}, events.filters["eventTypes"])
+	assert.Equal(t, from, events.filters["from"])
+	assert.Empty(t, publisher.Events(), "counting publishes nothing")
+
+	job, svcErr := replayer.StartRange(ctx, replay)
+	require.Nil(t, svcErr)
+	assert.Equal(t, models.ReplayRunning, job.Status)
+	assert.Equal(t, int64(3), job.Total)
+
+	job = waitForJob(t, replayer, job.ID)
+	assert.Equal(t, models.ReplayCompleted, job.Status)
+	assert.Equal(t, int64(3), job.Replayed)
+	assert.NotNil(t, job.FinishedAt)
+
+	published := publisher.Events()
+	require.Len(t, published, 3)
+	for i, event := range published {
+		assert.True(t, event.Replayed)
+		assert.Equal(t, events.records[i].EventID, event.Event.EventID)
+	}
+}
+
+func TestEventReplayer_SingleReplayAndCancel(t *testing.T) {
+	publisher := memory.NewInMemoryPublisher(100, zap.NewNop())
+	// Limitado a 20 eventos por segundo, el replay de 100 eventos dura 5 s
+	replayer := services.NewEventReplayer(newEventLog(100), newReplayJobs(t), publisher, 20, zap.NewNop())
+	t.Cleanup(replayer.Stop)
+	ctx := context.Background()
+	replay := services.ReplayRange{From: time.Now().Add(-time.Hour), To: time.Now()}
+
+	job, svcErr := replayer.StartRange(ctx, replay)
+	require.Nil(t, svcErr)
+
+	// No se permiten dos replays a la vez
+	_, svcErr = replayer.StartRange(ctx, replay)
+	require.NotNil(t, svcErr)
+	assert.Equal(t, http.StatusConflict, svcErr.Status)
+	assert.Equal(t, "REPLAY_IN_PROGRESS", svcErr.Code)
+
+	cancelled, svcErr := replayer.CancelJob(ctx, job.ID)
+	require.Nil(t, svcErr)
+	assert.True(t, cancelled.CancelRequested)
+
+	job = waitForJob(t, replayer, job.ID)
+	assert.Equal(t, models.ReplayCancelled, job.Status)
+	assert.Less(t, job.Replayed, int64(100))
+	assert.Len(t, publisher.Events(), int(job.Replayed))
+
+	// Cancelado el replay, el lock queda libre
+	next, svcErr := replayer.StartRange(ctx, replay)
+	require.Nil(t, svcErr)
+	_, svcErr = replayer.CancelJob(ctx, next.ID)
+	require.Nil(t, svcErr)
+	waitForJob(t, replayer, next.ID)
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"orders/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// WithReturnWindow sets how long after delivery a return can be requested.
+// Zero allows returns at any time.
+func WithReturnWindow(window time.Duration) Option {
+	return func(s *order) {
+		s.returnWindow = window
+	}
+}
+
+// GetOrderTransitions returns the current status of an order and the statuses
+// it can move to now, honouring the return window.
+func (s *order) GetOrderTransitions(ctx context.Context, orderID string) (*models.StatusTransitions, *ServiceError) {
+	order, svcErr := s.GetOrderByID(ctx, orderID)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	return &models.StatusTransitions{
+		Status:      order.Status,
+		Transitions: order.NextStatuses(s.returnWindow, time.Now()),
+	}, nil
+}
+
+// RequestOrderReturn records a return request for some or all of the items of
+// a delivered order.
+func (s *order) RequestOrderReturn(ctx context.Context, orderID, reason string, items []models.ReturnItem) (*models.Order, *ServiceError) {
+	s.logger.Debug("Requesting order return",
+		zap.String("orderId", orderID),
+		zap.Int("itemsCount", len(items)),
+	)
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+
+	before := order.Clone()
+	oldStatus := order.Status
+	if returnErr := order.RequestReturn(reason, items, s.returnWindow, time.Now()); returnErr != nil {
+		s.logger.Warn("Return request rejected",
+			zap.Error(returnErr),
+			zap.String("orderId", orderID),
+			zap.String("status", string(oldStatus)),
+		)
+		return nil, returnValidationError(returnErr, oldStatus)
+	}
+
+	if svcErr := contextError(ctx); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := changeError(before, order); svcErr != nil {
+		return nil, svcErr
+	}
+
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		s.logger.Error("Failed to update order return",
+			zap.String("orderId", orderID),
+		)
+		return nil, repositoryError(ctx, err)
+	}
+
+	s.invalidateCachedOrder(ctx, orderID)
+	s.recordWrite(ctx, order.CustomerID)
+	s.emitEvent(ctx, models.NewOrderReturnEvent(order, oldStatus).SetStates(before, order))
+
+	s.logger.Info("Order return requested successfully",
+		zap.String("orderId", orderID),
+		zap.Int("itemsCount", len(order.Return.Items)),
+	)
+
+	return order, nil
+}
+
+// returnValidationError maps the reasons a return is rejected to service errors.
+func returnValidationError(err error, status models.OrderStatus) *ServiceError {
+	var itemsErr *models.InvalidReturnItemsError
+	switch {
+	case errors.Is(err, models.ErrInvalidStatusTransition):
+		return &ServiceError{
+			Status:  http.StatusConflict,
+			Code:    "RETURN_NOT_ALLOWED",
+			Message: "Only delivered orders can be returned, order is " + string(status),
+			Cause:   []interface{}{err.Error()},
+		}
+	case errors.Is(err, models.ErrReturnWindowExpired):
+		return &ServiceError{
+			Status:  http.StatusConflict,
+			Code:    "RETURN_WINDOW_EXPIRED",
+			Message: "The return window of the order has expired",
+			Cause:   []interface{}{err.Error()},
+		}
+	case errors.As(err, &itemsErr):
+		cause := make([]interface{}, 0, len(itemsErr.SKUs))
+		for _, sku := range itemsErr.SKUs {
+			cause = append(cause, sku)
+		}
+		return &ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_RETURN_ITEMS",
+			Message: "Returned items must be on the order and not exceed the ordered quantities",
+			Cause:   cause,
+		}
+	case errors.Is(err, models.ErrInvalidReturnItems):
+		return &ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_RETURN_ITEMS",
+			Message: "At least one item must be returned",
+		}
+	}
+	return &ServiceError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_RETURN",
+		Message: "Invalid return request",
+		Cause:   []interface{}{err.Error()},
+	}
+}
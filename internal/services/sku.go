@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"orders/internal/models"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// SKUValidator checks that the SKUs of an order exist in the catalog.
+type SKUValidator interface {
+	// ExistingSKUs reports whether each of the SKUs exists. A non-nil error
+	// means the check could not be performed (e.g. the catalog timed out).
+	ExistingSKUs(ctx context.Context, skus []string) (map[string]bool, error)
+}
+
+// WithSKUValidator rejects orders referencing SKUs unknown to the catalog.
+// When failOpen is true, orders are accepted if the check cannot be performed.
+func WithSKUValidator(validator SKUValidator, failOpen bool) Option {
+	return func(s *order) {
+		s.skus = validator
+		s.skuFailOpen = failOpen
+	}
+}
+
+// validateSKUs checks every distinct SKU of the items in one lookup.
+func (s *order) validateSKUs(ctx context.Context, customerID string, items []models.OrderItem) *ServiceError {
+	if s.skus == nil {
+		return nil
+	}
+
+	skus := make([]string, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		if _, ok := seen[item.SKU]; ok {
+			continue
+		}
+		seen[item.SKU] = struct{}{}
+		skus = append(skus, item.SKU)
+	}
+
+	exists, err := s.skus.ExistingSKUs(ctx, skus)
+	if err != nil {
+		if s.skuFailOpen {
+			s.logger.Warn("SKU validation unavailable, accepting order",
+				zap.Error(err),
+				zap.String("customerId", customerID),
+			)
+			return nil
+		}
+		s.logger.Error("Failed to validate SKUs",
+			zap.Error(err),
+			zap.String("customerId", customerID),
+		)
+		return &ServiceError{
+			Status:  http.StatusServiceUnavailable,
+			Message: "Failed to validate SKUs",
+			Cause:   []interface{}{err.Error()},
+		}
+	}
+
+	var unknown []string
+	for _, sku := range skus {
+		if !exists[sku] {
+			unknown = append(unknown, sku)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	s.logger.Warn("Order with unknown SKUs rejected",
+		zap.Strings("skus", unknown),
+		zap.String("customerId", customerID),
+	)
+	cause := make([]interface{}, 0, len(unknown))
+	for _, sku := range unknown {
+		cause = append(cause, sku)
+	}
+	return &ServiceError{
+		Status:  http.StatusBadRequest,
+		Code:    "SKU_NOT_FOUND",
+		Message: fmt.Sprintf("Unknown SKU: %s", strings.Join(unknown, ", ")),
+		Cause:   cause,
+	}
+}
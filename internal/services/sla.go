@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"orders/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// WithDeliverySLA sets the delivery promise of new orders. Orders without a
+// client-provided promise are due sla after creation; client promises must
+// fall between minLead and maxLead from now. A zero sla leaves orders without
+// a promise unless the client sends one, and a zero maxLead does not cap it.
+func WithDeliverySLA(sla, minLead, maxLead time.Duration) Option {
+	return func(s *order) {
+		s.deliverySLA = sla
+		s.minPromiseLead = minLead
+		s.maxPromiseLead = maxLead
+	}
+}
+
+// promisedDelivery resolves the promised delivery time of an order created at now.
+func (s *order) promisedDelivery(requested *time.Time, now time.Time) (*time.Time, *ServiceError) {
+	if requested == nil {
+		if s.deliverySLA <= 0 {
+			return nil, nil
+		}
+		promised := now.Add(s.deliverySLA)
+		return &promised, nil
+	}
+
+	earliest := now.Add(s.minPromiseLead)
+	if requested.Before(earliest) || (s.maxPromiseLead > 0 && requested.After(now.Add(s.maxPromiseLead))) {
+		return nil, &ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "INVALID_PROMISED_DELIVERY",
+			Message: "Promised delivery time is out of the allowed range",
+			Cause: []interface{}{map[string]interface{}{
+				"minLead": s.minPromiseLead.String(),
+				"maxLead": s.maxPromiseLead.String(),
+			}},
+		}
+	}
+
+	promised := requested.UTC()
+	return &promised, nil
+}
+
+// NotifySLABreaches emits an ORDER_SLA_BREACHED event for up to limit
+// in-progress orders that passed their promised delivery time, once per order.
+// It returns how many breaches were reported.
+func (s *order) NotifySLABreaches(ctx context.Context, limit int) (int, *ServiceError) {
+	now := time.Now()
+
+	orders, err := s.orderRepo.FindSLABreachCandidates(ctx, now, limit)
+	if err != nil {
+		s.logger.Error("Failed to find SLA breaches", zap.String("cause", err.Cause))
+		return 0, repositoryError(ctx, err)
+	}
+
+	notified := 0
+	for _, order := range orders {
+		ctx := orderScope(ctx, order)
+		claimed, err := s.orderRepo.MarkSLABreachNotified(ctx, order.ID, now)
+		if err != nil {
+			s.logger.Warn("Failed to mark SLA breach",
+				zap.String("orderId", order.ID),
+				zap.String("cause", err.Cause),
+			)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		s.recordWrite(ctx, order.CustomerID)
+
+		// The breach does not change the order data consumers see
+		s.emitEvent(ctx, models.NewOrderSLABreachedEvent(order).SetStates(order, order))
+		notified++
+
+		s.logger.Info("Order SLA breached",
+			zap.String("orderId", order.ID),
+			zap.Timep("promisedDeliveryAt", order.PromisedDeliveryAt),
+		)
+	}
+
+	return notified, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// statsWindow is the window of recent orders counted by the stats.
+const statsWindow = 24 * time.Hour
+
+// WithStatsCacheTTL caches the order stats for ttl. Zero aggregates them on
+// every request.
+func WithStatsCacheTTL(ttl time.Duration) Option {
+	return func(s *order) {
+		s.statsTTL = ttl
+	}
+}
+
+// GetOrderStats returns the KPIs of the orders of the tenant. They are
+// aggregated over the whole collection, so they are cached briefly rather
+// than computed on every dashboard refresh.
+func (s *order) GetOrderStats(ctx context.Context) (*models.OrderStats, *ServiceError) {
+	cached := s.useCache() && s.statsTTL > 0
+	if cached {
+		stats, err := s.cacheRepo.GetOrderStats(ctx)
+		if err != nil {
+			s.logger.Warn("Cache error, falling back to database",
+				zap.String("cause", err.Cause),
+			)
+		} else if stats != nil {
+			return stats, nil
+		}
+	}
+
+	now := time.Now().UTC()
+	var stats *models.OrderStats
+	var err *repositories.RepositoryError
+	if s.useSummary() {
+		stats, err = s.summaries.Stats(ctx, now.Add(-statsWindow))
+	} else {
+		stats, err = s.orderRepo.Stats(ctx, now.Add(-statsWindow))
+	}
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+	stats.GeneratedAt = now
+
+	if cached {
+		cacheCtx, cancel := detach(ctx)
+		defer cancel()
+		if err := s.cacheRepo.SetOrderStats(cacheCtx, stats, s.statsTTL); err != nil {
+			s.logger.Warn("Failed to cache order stats",
+				zap.String("cause", err.Cause),
+			)
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"orders/internal/features"
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"go.uber.org/zap"
+)
+
+// SummaryReader reads the orders summary, the compact read model of the
+// orders kept up to date from their events.
+type SummaryReader interface {
+	FindWithFilters(ctx context.Context, filters map[string]interface{}, page, limit int) ([]*models.OrderSummaryRecord, int64, *repositories.RepositoryError)
+	Stats(ctx context.Context, since time.Time) (*models.OrderStats, *repositories.RepositoryError)
+}
+
+// WithSummaryReads serves listings, counts and stats from the orders summary
+// while the summaryReads flag is on. Listings filtering or sorting by fields
+// the summary lacks keep reading the orders.
+func WithSummaryReads(summaries SummaryReader) Option {
+	return func(s *order) {
+		s.summaries = summaries
+	}
+}
+
+// useSummary reports whether the orders summary serves reads.
+func (s *order) useSummary() bool {
+	return s.summaries != nil && s.feature(features.SummaryReads, true)
+}
+
+// summarized reports whether the listing only filters and sorts by fields of
+// the orders summary.
+func (filter ListOrdersFilter) summarized() bool {
+	switch filter.SortBy {
+	case "", "createdAt", "updatedAt", "totalAmount":
+	default:
+		return false
+	}
+	return filter.Priority == "" && filter.Channel == "" && len(filter.Tags) == 0 && filter.Query == "" &&
+		filter.SLABreached == nil && filter.MinWeight == nil && filter.MaxWeight == nil
+}
+
+// listFromSummary finds the page and total in the orders summary and reads
+// the full orders of the page, in the order of the summary. Rows that
+// disagree with their order are logged, and the order is served as stored.
+func (s *order) listFromSummary(ctx context.Context, filter ListOrdersFilter, page, limit int) ([]*models.Order, int64, *repositories.RepositoryError) {
+	records, total, err := s.summaries.FindWithFilters(ctx, filter.repositoryFilters(), page, limit)
+	if err != nil || len(records) == 0 {
+		return nil, total, err
+	}
+
+	ids := make([]string, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+	found, err := s.orderRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[string]*models.Order, len(found))
+	for _, order := range found {
+		byID[order.ID] = order
+	}
+
+	orders := make([]*models.Order, 0, len(records))
+	for _, record := range records {
+		order, ok := byID[record.ID]
+		if !ok {
+			s.logger.Warn("Order summary has no order",
+				zap.String("orderId", record.ID),
+				zap.Int("summaryVersion", record.Version),
+			)
+			continue
+		}
+		if fields := record.Mismatches(order); len(fields) > 0 {
+			s.logger.Warn("Order summary disagrees with order",
+				zap.String("orderId", record.ID),
+				zap.Strings("fields", fields),
+				zap.Int("summaryVersion", record.Version),
+				zap.Int("orderVersion", order.Version),
+			)
+		}
+		orders = append(orders, order)
+	}
+	return orders, total, nil
+}
@@ -0,0 +1,116 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"orders/internal/features"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeSummaryReader devuelve las filas y estadísticas configuradas
+type fakeSummaryReader struct {
+	records []*models.OrderSummaryRecord
+	total   int64
+	stats   *models.OrderStats
+	filters map[string]interface{}
+}
+
+func (f *fakeSummaryReader) FindWithFilters(_ context.Context, filters map[string]interface{}, page, limit int) ([]*models.OrderSummaryRecord, int64, *repositories.RepositoryError) {
+	f.filters = filters
+	return f.records, f.total, nil
+}
+
+func (f *fakeSummaryReader) Stats(context.Context, time.Time) (*models.OrderStats, *repositories.RepositoryError) {
+	return f.stats, nil
+}
+
+func TestOrderService_ListOrders_FromSummary(t *testing.T) {
+	ctx := context.Background()
+	core, logs := observer.New(zap.WarnLevel)
+	mockRepo := new(MockOrderRepository)
+	summaries := &fakeSummaryReader{
+		records: []*models.OrderSummaryRecord{
+			{ID: "order-2", Status: models.StatusNew, Version: 1},
+			{ID: "order-1", Status: models.StatusNew, Version: 1},
+			{ID: "order-gone", Status: models.StatusNew, Version: 1},
+		},
+		total: 30,
+	}
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.New(core),
+		services.WithSummaryReads(summaries))
+
+	mockRepo.On("FindByIDs", ctx, []string{"order-2", "order-1", "order-gone"}).Return([]*models.Order{
+		{ID: "order-1", Status: models.StatusNew, Version: 1},
+		{ID: "order-2", Status: models.StatusInProgress, Version: 2},
+	}, nil).Once()
+
+	orders, total, err := service.ListOrders(ctx, services.ListOrdersFilter{Status: string(models.StatusNew)}, 1, 3)
+	require.Nil(t, err)
+
+	// Las órdenes completas se sirven en el orden del resumen
+	require.Len(t, orders, 2)
+	assert.Equal(t, "order-2", orders[0].ID)
+	assert.Equal(t, "order-1", orders[1].ID)
+	assert.Equal(t, int64(30), total)
+	assert.Equal(t, map[string]interface{}{"status": string(models.StatusNew)}, summaries.filters)
+	mockRepo.AssertNotCalled(t, "FindWithFilters", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// Las discrepancias con la colección completa se registran
+	disagree := logs.FilterMessage("Order summary disagrees with order").All()
+	require.Len(t, disagree, 1)
+	assert.Equal(t, "order-2", disagree[0].ContextMap()["orderId"])
+	assert.Equal(t, []interface{}{"status", "version"}, disagree[0].ContextMap()["fields"])
+	assert.Equal(t, 1, logs.FilterMessage("Order summary has no order").Len())
+}
+
+func TestOrderService_ListOrders_SummaryFallback(t *testing.T) {
+	ctx := context.Background()
+	summaries := &fakeSummaryReader{}
+
+	t.Run("Filters the summary lacks", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+			services.WithSummaryReads(summaries))
+		mockRepo.On("FindWithFilters", ctx, map[string]interface{}{"channel": "WEB"}, 1, 10).
+			Return([]*models.Order{}, int64(0), nil).Once()
+
+		_, _, err := service.ListOrders(ctx, services.ListOrdersFilter{Channel: "WEB"}, 1, 10)
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Flag off", func(t *testing.T) {
+		mockRepo := new(MockOrderRepository)
+		flags := features.New(map[string]bool{features.SummaryReads: false}, nil, zap.NewNop())
+		service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+			services.WithSummaryReads(summaries), services.WithFeatureFlags(flags))
+		mockRepo.On("FindWithFilters", ctx, map[string]interface{}{}, 1, 10).
+			Return([]*models.Order{}, int64(0), nil).Once()
+
+		_, _, err := service.ListOrders(ctx, services.ListOrdersFilter{}, 1, 10)
+		assert.Nil(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestOrderService_GetOrderStats_FromSummary(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	summaries := &fakeSummaryReader{stats: &models.OrderStats{TotalOrders: 42}}
+	service := services.NewOrderService(mockRepo, new(MockCacheRepository), new(MockEventPublisher), zap.NewNop(),
+		services.WithCache(false), services.WithSummaryReads(summaries))
+
+	stats, err := service.GetOrderStats(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, int64(42), stats.TotalOrders)
+	mockRepo.AssertNotCalled(t, "Stats", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"orders/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// UpdateOrderTags replaces the tags of an order with the normalized tags.
+func (s *order) UpdateOrderTags(ctx context.Context, orderID string, tags []string) (*models.Order, *ServiceError) {
+	s.logger.Debug("Updating order tags",
+		zap.String("orderId", orderID),
+		zap.Strings("tags", tags),
+	)
+
+	normalized, tagErr := models.NormalizeTags(tags)
+	if tagErr != nil {
+		return nil, tagValidationError(tagErr)
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, repositoryError(ctx, err)
+	}
+
+	before := order.Clone()
+	oldTags := order.Tags
+	order.SetTags(normalized)
+
+	if svcErr := contextError(ctx); svcErr != nil {
+		return nil, svcErr
+	}
+	if svcErr := changeError(before, order); svcErr != nil {
+		return nil, svcErr
+	}
+
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		s.logger.Error("Failed to update order tags",
+			zap.String("orderId", orderID),
+		)
+		return nil, repositoryError(ctx, err)
+	}
+
+	s.invalidateCachedOrder(ctx, orderID)
+	s.recordWrite(ctx, order.CustomerID)
+	s.emitEvent(ctx, models.NewOrderTagsChangedEvent(order, oldTags).SetStates(before, order))
+
+	s.logger.Info("Order tags updated successfully",
+		zap.String("orderId", orderID),
+		zap.Strings("tags", normalized),
+	)
+
+	return order, nil
+}
+
+// tagValidationError maps tag normalization errors to a 400 response.
+func tagValidationError(err error) *ServiceError {
+	if errors.Is(err, models.ErrTooManyTags) {
+		return &ServiceError{
+			Status:  http.StatusBadRequest,
+			Code:    "TOO_MANY_TAGS",
+			Message: fmt.Sprintf("An order can carry at most %d tags", models.MaxTags),
+			Cause:   []interface{}{map[string]interface{}{"max": models.MaxTags}},
+		}
+	}
+
+	var invalidErr *models.InvalidTagsError
+	cause := []interface{}{}
+	if errors.As(err, &invalidErr) {
+		for _, tag := range invalidErr.Tags {
+			cause = append(cause, tag)
+		}
+	}
+	return &ServiceError{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_TAG",
+		Message: fmt.Sprintf("Tags must be 1 to %d letters, digits, '-' or '_'", models.MaxTagLength),
+		Cause:   cause,
+	}
+}
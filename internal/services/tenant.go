@@ -0,0 +1,18 @@
+package services
+
+import (
+	"context"
+
+	"orders/internal/models"
+	"orders/internal/tenant"
+)
+
+// orderScope returns the context to keep working on an order with. Workers
+// and consumers find orders of every tenant; once an order is found, its
+// cache entries, write stamps and events belong to its tenant.
+func orderScope(ctx context.Context, order *models.Order) context.Context {
+	if tenant.ID(ctx) != "" || order.TenantID == "" {
+		return ctx
+	}
+	return tenant.WithID(ctx, order.TenantID)
+}
@@ -0,0 +1,21 @@
+// Package tenant carries the tenant a piece of work acts for. Repositories
+// only see the data of the tenant in the context; work without one, such as
+// background workers, sees every tenant.
+package tenant
+
+import "context"
+
+type contextKey int
+
+const tenantIDKey contextKey = iota
+
+// WithID returns a context acting for the given tenant.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, id)
+}
+
+// ID returns the tenant carried by the context, if any.
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(tenantIDKey).(string)
+	return id
+}
@@ -0,0 +1,99 @@
+// Package timing collects how long a request spends in each dependency, for
+// the Server-Timing response header. Repositories and producers report their
+// calls to the collector carried by the context; without one, reporting does
+// nothing.
+package timing
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Phase is a dependency whose time is reported.
+type Phase int
+
+const (
+	Cache Phase = iota
+	DB
+	Kafka
+	phaseCount
+)
+
+// phaseNames are the metric names of the phases in the Server-Timing header.
+var phaseNames = [phaseCount]string{"cache", "db", "kafka"}
+
+func (p Phase) String() string {
+	return phaseNames[p]
+}
+
+type contextKey int
+
+const collectorKey contextKey = iota
+
+// Collector sums the time spent in each phase. It is safe for concurrent use
+// and does not allocate when recording.
+type Collector struct {
+	durations [phaseCount]atomic.Int64
+	calls     [phaseCount]atomic.Int32
+}
+
+// WithCollector returns a context whose phases are reported to collector.
+func WithCollector(ctx context.Context, collector *Collector) context.Context {
+	return context.WithValue(ctx, collectorKey, collector)
+}
+
+// FromContext returns the collector carried by the context, if any.
+func FromContext(ctx context.Context) *Collector {
+	collector, _ := ctx.Value(collectorKey).(*Collector)
+	return collector
+}
+
+// Since reports the time elapsed since start in the phase to the collector of
+// the context. It is meant to be deferred:
+//
+//	defer timing.Since(ctx, timing.DB, time.Now())
+func Since(ctx context.Context, phase Phase, start time.Time) {
+	if collector := FromContext(ctx); collector != nil {
+		collector.Add(phase, time.Since(start))
+	}
+}
+
+// Add records d spent in the phase.
+func (c *Collector) Add(phase Phase, d time.Duration) {
+	c.durations[phase].Add(int64(d))
+	c.calls[phase].Add(1)
+}
+
+// Duration returns the time recorded in the phase.
+func (c *Collector) Duration(phase Phase) time.Duration {
+	return time.Duration(c.durations[phase].Load())
+}
+
+// Header formats the phases that were reported, followed by the total time
+// of the request, as a Server-Timing header value in milliseconds, e.g.
+// "cache;dur=1.2, db;dur=14.5, total;dur=31.0".
+func (c *Collector) Header(total time.Duration) string {
+	var b strings.Builder
+	b.Grow(64)
+	for phase := Phase(0); phase < phaseCount; phase++ {
+		if c.calls[phase].Load() == 0 {
+			continue
+		}
+		writeMetric(&b, phaseNames[phase], c.Duration(phase))
+	}
+	writeMetric(&b, "total", total)
+	return b.String()
+}
+
+func writeMetric(b *strings.Builder, name string, d time.Duration) {
+	if b.Len() > 0 {
+		b.WriteString(", ")
+	}
+	b.WriteString(name)
+	b.WriteString(";dur=")
+	var buf [24]byte
+	b.Write(strconv.AppendFloat(buf[:0], float64(d)/float64(time.Millisecond), 'f', 1, 64))
+}
@@ -0,0 +1,46 @@
+package timing_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"orders/internal/timing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	collector := &timing.Collector{}
+	ctx := timing.WithCollector(context.Background(), collector)
+
+	// Las llamadas concurrentes se suman por fase
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collector.Add(timing.DB, time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	collector.Add(timing.Cache, 1200*time.Microsecond)
+
+	assert.Equal(t, 100*time.Millisecond, collector.Duration(timing.DB))
+	assert.Equal(t, "cache;dur=1.2, db;dur=100.0, total;dur=130.5", collector.Header(130500*time.Microsecond))
+	assert.Same(t, collector, timing.FromContext(ctx))
+
+	// Sin colector no se registra nada
+	timing.Since(context.Background(), timing.Kafka, time.Now())
+	assert.Zero(t, collector.Duration(timing.Kafka))
+}
+
+func TestSince_Allocations(t *testing.T) {
+	ctx := timing.WithCollector(context.Background(), &timing.Collector{})
+	start := time.Now()
+
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		timing.Since(ctx, timing.Cache, start)
+	}))
+}
@@ -0,0 +1,160 @@
+package workers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/tenant"
+
+	"go.uber.org/zap"
+)
+
+// OrderCache is the subset of the order cache the reconciler works with.
+type OrderCache interface {
+	ScanOrders(ctx context.Context, cursor uint64, count int64) ([]repositories.OrderRef, uint64, *repositories.RepositoryError)
+	GetOrder(ctx context.Context, orderID string) (*models.Order, *repositories.RepositoryError)
+	SetOrder(ctx context.Context, order *models.Order) *repositories.RepositoryError
+	InvalidateOrder(ctx context.Context, orderID string) *repositories.RepositoryError
+}
+
+// OrderStore is the source of truth the cached orders are compared against.
+type OrderStore interface {
+	FindByID(ctx context.Context, id string) (*models.Order, *repositories.RepositoryError)
+}
+
+// CacheReconciler periodically samples cached orders and refreshes the ones
+// whose version no longer matches the database, catching missed invalidations.
+type CacheReconciler struct {
+	cache      OrderCache
+	store      OrderStore
+	sampleSize int
+	scheduler  *scheduler
+	logger     *zap.Logger
+
+	// cursor is where the next sample resumes, so successive runs walk the
+	// whole keyspace instead of checking the same keys over and over.
+	cursor uint64
+}
+
+func NewCacheReconciler(cache OrderCache, store OrderStore, interval time.Duration, sampleSize int, logger *zap.Logger) *CacheReconciler {
+	return &CacheReconciler{
+		cache:      cache,
+		store:      store,
+		sampleSize: sampleSize,
+		scheduler:  newScheduler(interval),
+		logger:     logger,
+	}
+}
+
+// Start runs the reconciler in the background until Stop is called.
+func (r *CacheReconciler) Start() {
+	r.scheduler.start(func(ctx context.Context) { r.RunOnce(ctx) })
+}
+
+// Stop signals the background loop to exit and waits for the current run.
+func (r *CacheReconciler) Stop() {
+	r.scheduler.shutdown()
+}
+
+// RunOnce checks one sample of cached orders and returns how many had drifted.
+func (r *CacheReconciler) RunOnce(ctx context.Context) int {
+	refs, err := r.sample(ctx)
+	if err != nil {
+		r.logger.Warn("Failed to sample cached orders", zap.String("cause", err.Cause), zap.String("message", err.Message))
+		return 0
+	}
+
+	drifted := 0
+	for _, ref := range refs {
+		// Each order is checked as its tenant, which also scopes the lookups
+		orderCtx := ctx
+		if ref.TenantID != "" {
+			orderCtx = tenant.WithID(ctx, ref.TenantID)
+		}
+		if r.reconcile(orderCtx, ref.OrderID) {
+			drifted++
+		}
+	}
+
+	metrics.CacheDriftTotal.Add(float64(drifted))
+	if drifted > 0 {
+		r.logger.Info("Cache drift detected",
+			zap.Int("sampled", len(refs)),
+			zap.Int("drifted", drifted),
+		)
+	} else {
+		r.logger.Debug("Cache reconciled", zap.Int("sampled", len(refs)))
+	}
+
+	return drifted
+}
+
+func (r *CacheReconciler) sample(ctx context.Context) ([]repositories.OrderRef, *repositories.RepositoryError) {
+	refs := make([]repositories.OrderRef, 0, r.sampleSize)
+	for len(refs) < r.sampleSize {
+		batch, next, err := r.cache.ScanOrders(ctx, r.cursor, int64(r.sampleSize-len(refs)))
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, batch...)
+		r.cursor = next
+		if next == 0 {
+			break
+		}
+	}
+
+	if len(refs) > r.sampleSize {
+		refs = refs[:r.sampleSize]
+	}
+	return refs, nil
+}
+
+// reconcile compares a cached order against the database and reports whether
+// the cached copy was stale.
+func (r *CacheReconciler) reconcile(ctx context.Context, orderID string) bool {
+	cached, err := r.cache.GetOrder(ctx, orderID)
+	if err != nil || cached == nil {
+		// Expired or unreadable entries are left to the regular read path.
+		return false
+	}
+
+	current, err := r.store.FindByID(ctx, orderID)
+	if err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			r.invalidate(ctx, orderID)
+			return true
+		}
+		r.logger.Warn("Failed to load order for reconciliation",
+			zap.String("orderId", orderID),
+			zap.String("cause", err.Cause),
+		)
+		return false
+	}
+
+	if cached.Version == current.Version {
+		return false
+	}
+
+	r.logger.Debug("Refreshing stale cached order",
+		zap.String("orderId", orderID),
+		zap.Int("cachedVersion", cached.Version),
+		zap.Int("currentVersion", current.Version),
+	)
+	if err := r.cache.SetOrder(ctx, current); err != nil {
+		r.invalidate(ctx, orderID)
+	}
+	return true
+}
+
+func (r *CacheReconciler) invalidate(ctx context.Context, orderID string) {
+	if err := r.cache.InvalidateOrder(ctx, orderID); err != nil {
+		r.logger.Warn("Failed to invalidate cached order",
+			zap.String("orderId", orderID),
+			zap.String("cause", err.Cause),
+		)
+	}
+}
@@ -0,0 +1,115 @@
+package workers_test
+
+import (
+	"context"
+	"testing"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/tenant"
+	"orders/internal/workers"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// MockOrderCache es un mock de la caché de pedidos
+type MockOrderCache struct {
+	mock.Mock
+}
+
+func (m *MockOrderCache) ScanOrders(ctx context.Context, cursor uint64, count int64) ([]repositories.OrderRef, uint64, *repositories.RepositoryError) {
+	args := m.Called(ctx, cursor, count)
+	if v := args.Get(2); v != nil {
+		return nil, 0, v.(*repositories.RepositoryError)
+	}
+	return args.Get(0).([]repositories.OrderRef), args.Get(1).(uint64), nil
+}
+
+func (m *MockOrderCache) GetOrder(ctx context.Context, orderID string) (*models.Order, *repositories.RepositoryError) {
+	args := m.Called(ctx, orderID)
+	if v := args.Get(0); v != nil {
+		return v.(*models.Order), nil
+	}
+	if v := args.Get(1); v != nil {
+		return nil, v.(*repositories.RepositoryError)
+	}
+	return nil, nil
+}
+
+func (m *MockOrderCache) SetOrder(ctx context.Context, order *models.Order) *repositories.RepositoryError {
+	args := m.Called(ctx, order)
+	if v := args.Get(0); v != nil {
+		return v.(*repositories.RepositoryError)
+	}
+	return nil
+}
+
+func (m *MockOrderCache) InvalidateOrder(ctx context.Context, orderID string) *repositories.RepositoryError {
+	args := m.Called(ctx, orderID)
+	if v := args.Get(0); v != nil {
+		return v.(*repositories.RepositoryError)
+	}
+	return nil
+}
+
+// MockOrderStore es un mock del repositorio de pedidos
+type MockOrderStore struct {
+	mock.Mock
+}
+
+func (m *MockOrderStore) FindByID(ctx context.Context, id string) (*models.Order, *repositories.RepositoryError) {
+	args := m.Called(ctx, id)
+	if v := args.Get(1); v != nil {
+		return nil, v.(*repositories.RepositoryError)
+	}
+	return args.Get(0).(*models.Order), nil
+}
+
+func TestCacheReconciler_RefreshesStaleEntry(t *testing.T) {
+	cache := new(MockOrderCache)
+	store := new(MockOrderStore)
+	reconciler := workers.NewCacheReconciler(cache, store, 0, 10, zap.NewNop())
+
+	stale := &models.Order{ID: "order-stale", Status: models.StatusNew, Version: 1}
+	current := &models.Order{ID: "order-stale", Status: models.StatusInProgress, Version: 2}
+	fresh := &models.Order{ID: "order-fresh", Status: models.StatusNew, Version: 4}
+
+	cache.On("ScanOrders", mock.Anything, uint64(0), int64(10)).
+		Return([]repositories.OrderRef{{OrderID: "order-stale"}, {OrderID: "order-fresh"}}, uint64(0), nil)
+	cache.On("GetOrder", mock.Anything, "order-stale").Return(stale, nil)
+	cache.On("GetOrder", mock.Anything, "order-fresh").Return(fresh, nil)
+	store.On("FindByID", mock.Anything, "order-stale").Return(current, nil)
+	store.On("FindByID", mock.Anything, "order-fresh").Return(fresh, nil)
+	cache.On("SetOrder", mock.Anything, current).Return(nil)
+
+	before := testutil.ToFloat64(metrics.CacheDriftTotal)
+	drifted := reconciler.RunOnce(context.Background())
+
+	assert.Equal(t, 1, drifted)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.CacheDriftTotal)-before)
+	cache.AssertCalled(t, "SetOrder", mock.Anything, current)
+	cache.AssertNumberOfCalls(t, "SetOrder", 1)
+}
+
+func TestCacheReconciler_InvalidatesDeletedOrder(t *testing.T) {
+	cache := new(MockOrderCache)
+	store := new(MockOrderStore)
+	reconciler := workers.NewCacheReconciler(cache, store, 0, 10, zap.NewNop())
+
+	// El pedido se comprueba como su tenant
+	asTenant := mock.MatchedBy(func(ctx context.Context) bool { return tenant.ID(ctx) == "brand-a" })
+	cache.On("ScanOrders", mock.Anything, uint64(0), int64(10)).
+		Return([]repositories.OrderRef{{TenantID: "brand-a", OrderID: "order-gone"}}, uint64(0), nil)
+	cache.On("GetOrder", asTenant, "order-gone").Return(&models.Order{ID: "order-gone", Version: 1}, nil)
+	store.On("FindByID", asTenant, "order-gone").Return(nil, &repositories.RepositoryError{StatusCode: 404})
+	cache.On("InvalidateOrder", asTenant, "order-gone").Return(nil)
+
+	drifted := reconciler.RunOnce(context.Background())
+
+	assert.Equal(t, 1, drifted)
+	cache.AssertCalled(t, "InvalidateOrder", asTenant, "order-gone")
+}
@@ -0,0 +1,140 @@
+package workers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+	"orders/internal/repositories"
+
+	"go.uber.org/zap"
+)
+
+// OrderCacheWriter is the cache operation retried by the CacheWriteRetrier.
+type OrderCacheWriter interface {
+	GetOrder(ctx context.Context, orderID string) (*models.Order, *repositories.RepositoryError)
+	SetOrder(ctx context.Context, order *models.Order) *repositories.RepositoryError
+}
+
+type cacheWrite struct {
+	order     *models.Order
+	attempt   int
+	notBefore time.Time
+}
+
+// CacheWriteRetrier retries failed cache writes in the background so that a
+// transient Redis error does not leave hot orders uncached. The queue is
+// bounded; writes that do not fit are dropped and counted.
+type CacheWriteRetrier struct {
+	cache       OrderCacheWriter
+	queue       chan cacheWrite
+	maxAttempts int
+	delay       time.Duration
+	logger      *zap.Logger
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewCacheWriteRetrier(cache OrderCacheWriter, queueSize, maxAttempts int, delay time.Duration, logger *zap.Logger) *CacheWriteRetrier {
+	return &CacheWriteRetrier{
+		cache:       cache,
+		queue:       make(chan cacheWrite, queueSize),
+		maxAttempts: maxAttempts,
+		delay:       delay,
+		logger:      logger,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Enqueue schedules a retry of the cache write without blocking. It reports
+// false when the queue is full and the write was dropped.
+func (r *CacheWriteRetrier) Enqueue(order *models.Order) bool {
+	return r.push(cacheWrite{order: order, attempt: 1})
+}
+
+// Start processes queued writes in the background until Stop is called.
+func (r *CacheWriteRetrier) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case write := <-r.queue:
+				if !r.wait(write.notBefore) {
+					return
+				}
+				r.process(write)
+			}
+		}
+	}()
+}
+
+// Stop signals the background loop to exit. Pending writes are discarded.
+func (r *CacheWriteRetrier) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+func (r *CacheWriteRetrier) push(write cacheWrite) bool {
+	write.notBefore = time.Now().Add(r.delay * time.Duration(write.attempt))
+	select {
+	case r.queue <- write:
+		return true
+	default:
+		metrics.CacheWriteRetryDroppedTotal.Inc()
+		r.logger.Warn("Cache write retry queue full, dropping write",
+			zap.String("orderId", write.order.ID),
+		)
+		return false
+	}
+}
+
+func (r *CacheWriteRetrier) wait(until time.Time) bool {
+	delay := time.Until(until)
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-r.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (r *CacheWriteRetrier) process(write cacheWrite) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A newer copy may have been cached since the write failed; never let the
+	// retry overwrite it with an older version.
+	if cached, err := r.cache.GetOrder(ctx, write.order.ID); err == nil && cached != nil && cached.Version >= write.order.Version {
+		return
+	}
+
+	if err := r.cache.SetOrder(ctx, write.order); err != nil {
+		if write.attempt >= r.maxAttempts {
+			r.logger.Warn("Giving up on cache write",
+				zap.String("orderId", write.order.ID),
+				zap.Int("attempts", write.attempt),
+				zap.String("cause", err.Cause),
+			)
+			return
+		}
+		write.attempt++
+		r.push(write)
+		return
+	}
+
+	r.logger.Debug("Cache write retried successfully",
+		zap.String("orderId", write.order.ID),
+		zap.Int("attempt", write.attempt),
+	)
+}
@@ -0,0 +1,48 @@
+package workers_test
+
+import (
+	"testing"
+	"time"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/workers"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestCacheWriteRetrier_RetriesUntilWriteSucceeds(t *testing.T) {
+	cache := new(MockOrderCache)
+	retrier := workers.NewCacheWriteRetrier(cache, 10, 3, time.Millisecond, zap.NewNop())
+	retrier.Start()
+	defer retrier.Stop()
+
+	order := &models.Order{ID: "order-123", Version: 2}
+	cache.On("GetOrder", mock.Anything, "order-123").Return(nil, nil)
+	cache.On("SetOrder", mock.Anything, order).Return(&repositories.RepositoryError{Cause: "connection reset"}).Once()
+	written := make(chan struct{})
+	cache.On("SetOrder", mock.Anything, order).Return(nil).Once().Run(func(mock.Arguments) { close(written) })
+
+	assert.True(t, retrier.Enqueue(order))
+
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Fatal("cache write was not retried")
+	}
+	cache.AssertNumberOfCalls(t, "SetOrder", 2)
+}
+
+func TestCacheWriteRetrier_DropsWhenQueueFull(t *testing.T) {
+	// Not started, so nothing drains the queue.
+	retrier := workers.NewCacheWriteRetrier(new(MockOrderCache), 1, 3, time.Millisecond, zap.NewNop())
+
+	before := testutil.ToFloat64(metrics.CacheWriteRetryDroppedTotal)
+	assert.True(t, retrier.Enqueue(&models.Order{ID: "order-1"}))
+	assert.False(t, retrier.Enqueue(&models.Order{ID: "order-2"}))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.CacheWriteRetryDroppedTotal)-before)
+}
@@ -0,0 +1,145 @@
+package workers
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/services"
+
+	"go.uber.org/zap"
+)
+
+// maxReleaseBackoff caps the delay between the attempts of a release.
+const maxReleaseBackoff = time.Hour
+
+// InventoryReleaseOutbox holds the inventory releases that still have to be
+// made.
+type InventoryReleaseOutbox interface {
+	FindDueReleases(ctx context.Context, now time.Time, limit int) ([]*models.InventoryRelease, *repositories.RepositoryError)
+	RescheduleRelease(ctx context.Context, orderID string, next time.Time, lastError string) *repositories.RepositoryError
+	DeleteRelease(ctx context.Context, orderID string) *repositories.RepositoryError
+}
+
+// InventoryReleaseRetrier periodically retries the inventory releases left in
+// the outbox, so the stock of a cancelled order is freed even when the
+// inventory service was down at the time. Releases are retried until they
+// succeed, backing off exponentially.
+type InventoryReleaseRetrier struct {
+	outbox    InventoryReleaseOutbox
+	inventory services.InventoryReserver
+	delay     time.Duration
+	batchSize int
+	scheduler *scheduler
+	logger    *zap.Logger
+	lastRun   atomic.Int64 // unix nanoseconds
+}
+
+// NewInventoryReleaseRetrier creates the retrier. delay is the wait after the
+// first failed attempt, doubled on every further one.
+func NewInventoryReleaseRetrier(outbox InventoryReleaseOutbox, inventory services.InventoryReserver, interval, delay time.Duration, batchSize int, logger *zap.Logger) *InventoryReleaseRetrier {
+	return &InventoryReleaseRetrier{
+		outbox:    outbox,
+		inventory: inventory,
+		delay:     delay,
+		batchSize: batchSize,
+		scheduler: newScheduler(interval),
+		logger:    logger,
+	}
+}
+
+// Start retries the due releases in the background until Stop is called.
+func (r *InventoryReleaseRetrier) Start() {
+	r.heartbeat()
+	r.scheduler.start(func(ctx context.Context) {
+		r.RunOnce(ctx)
+		r.heartbeat()
+	})
+}
+
+// heartbeat records that the background loop is alive.
+func (r *InventoryReleaseRetrier) heartbeat() {
+	now := time.Now()
+	r.lastRun.Store(now.UnixNano())
+	metrics.DispatcherLastRunTimestamp.Set(float64(now.Unix()))
+}
+
+// LastRun returns when the background loop last went over the outbox, or the
+// zero time before Start.
+func (r *InventoryReleaseRetrier) LastRun() time.Time {
+	nanos := r.lastRun.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// Stop signals the background loop to exit and waits for the current run.
+func (r *InventoryReleaseRetrier) Stop() {
+	r.scheduler.shutdown()
+}
+
+// RunOnce retries one batch of due releases and returns how many succeeded.
+func (r *InventoryReleaseRetrier) RunOnce(ctx context.Context) int {
+	now := time.Now().UTC()
+	releases, err := r.outbox.FindDueReleases(ctx, now, r.batchSize)
+	if err != nil {
+		r.logger.Warn("Failed to read inventory releases", zap.String("cause", err.Cause))
+		return 0
+	}
+
+	released := 0
+	for _, release := range releases {
+		if ctx.Err() != nil {
+			break
+		}
+		if r.retry(ctx, release, now) {
+			released++
+		}
+	}
+	if released > 0 {
+		r.logger.Info("Inventory releases retried", zap.Int("released", released), zap.Int("due", len(releases)))
+	}
+	return released
+}
+
+func (r *InventoryReleaseRetrier) retry(ctx context.Context, release *models.InventoryRelease, now time.Time) bool {
+	if err := r.inventory.Release(ctx, release.OrderID); err != nil {
+		metrics.InventoryReleasesTotal.WithLabelValues("failed").Inc()
+		next := now.Add(r.backoff(release.Attempts + 1))
+		r.logger.Warn("Inventory release failed again",
+			zap.Error(err),
+			zap.String("orderId", release.OrderID),
+			zap.Int("attempts", release.Attempts+1),
+			zap.Time("nextAttemptAt", next),
+		)
+		if err := r.outbox.RescheduleRelease(ctx, release.OrderID, next, err.Error()); err != nil {
+			r.logger.Warn("Failed to reschedule inventory release",
+				zap.String("orderId", release.OrderID),
+				zap.String("cause", err.Cause),
+			)
+		}
+		return false
+	}
+
+	metrics.InventoryReleasesTotal.WithLabelValues("released").Inc()
+	if err := r.outbox.DeleteRelease(ctx, release.OrderID); err != nil {
+		r.logger.Warn("Failed to clear inventory release",
+			zap.String("orderId", release.OrderID),
+			zap.String("cause", err.Cause),
+		)
+	}
+	return true
+}
+
+// backoff is the wait after the given number of failed attempts.
+func (r *InventoryReleaseRetrier) backoff(attempts int) time.Duration {
+	delay := r.delay
+	for i := 1; i < attempts && delay < maxReleaseBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxReleaseBackoff)
+}
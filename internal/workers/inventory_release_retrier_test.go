@@ -0,0 +1,121 @@
+package workers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+	"orders/internal/repositories"
+	"orders/internal/workers"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryReleaseOutbox guarda en memoria las liberaciones pendientes
+type memoryReleaseOutbox struct {
+	releases map[string]*models.InventoryRelease
+}
+
+func (o *memoryReleaseOutbox) FindDueReleases(_ context.Context, now time.Time, limit int) ([]*models.InventoryRelease, *repositories.RepositoryError) {
+	var due []*models.InventoryRelease
+	for _, release := range o.releases {
+		if !release.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, release)
+		}
+	}
+	return due, nil
+}
+
+func (o *memoryReleaseOutbox) RescheduleRelease(_ context.Context, orderID string, next time.Time, lastError string) *repositories.RepositoryError {
+	release := o.releases[orderID]
+	release.Attempts++
+	release.NextAttemptAt = next
+	release.LastError = lastError
+	return nil
+}
+
+func (o *memoryReleaseOutbox) DeleteRelease(_ context.Context, orderID string) *repositories.RepositoryError {
+	delete(o.releases, orderID)
+	return nil
+}
+
+// stubInventory libera los pedidos mientras no esté caído
+type stubInventory struct {
+	down     bool
+	released []string
+}
+
+func (s *stubInventory) Reserve(context.Context, string, []models.OrderItem) error {
+	return nil
+}
+
+func (s *stubInventory) Release(_ context.Context, orderID string) error {
+	if s.down {
+		return errors.New("inventory service returned status 503")
+	}
+	s.released = append(s.released, orderID)
+	return nil
+}
+
+func TestInventoryReleaseRetrier_RunOnce(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	outbox := &memoryReleaseOutbox{releases: map[string]*models.InventoryRelease{
+		"order-1": {OrderID: "order-1", Attempts: 1, NextAttemptAt: past},
+		"order-2": {OrderID: "order-2", Attempts: 3, NextAttemptAt: past},
+		// Todavía no toca reintentarla
+		"order-3": {OrderID: "order-3", Attempts: 1, NextAttemptAt: time.Now().Add(time.Hour)},
+	}}
+	inventory := &stubInventory{down: true}
+	retrier := workers.NewInventoryReleaseRetrier(outbox, inventory, time.Minute, 10*time.Second, 10, zap.NewNop())
+
+	// Con el inventario caído se reprograman con espera exponencial
+	assert.Equal(t, 0, retrier.RunOnce(context.Background()))
+	require.Len(t, outbox.releases, 3)
+	assert.Equal(t, 2, outbox.releases["order-1"].Attempts)
+	assert.WithinDuration(t, time.Now().Add(20*time.Second), outbox.releases["order-1"].NextAttemptAt, time.Second)
+	assert.Equal(t, 4, outbox.releases["order-2"].Attempts)
+	assert.WithinDuration(t, time.Now().Add(80*time.Second), outbox.releases["order-2"].NextAttemptAt, time.Second)
+	assert.Equal(t, "inventory service returned status 503", outbox.releases["order-2"].LastError)
+	assert.Equal(t, 1, outbox.releases["order-3"].Attempts)
+
+	// Recuperado, las liberaciones vencidas se hacen y salen del outbox
+	inventory.down = false
+	outbox.releases["order-1"].NextAttemptAt = past
+	outbox.releases["order-2"].NextAttemptAt = past
+	assert.Equal(t, 2, retrier.RunOnce(context.Background()))
+	assert.ElementsMatch(t, []string{"order-1", "order-2"}, inventory.released)
+	assert.Len(t, outbox.releases, 1)
+	assert.Contains(t, outbox.releases, "order-3")
+}
+
+func TestInventoryReleaseRetrier_Heartbeat(t *testing.T) {
+	outbox := &memoryReleaseOutbox{releases: map[string]*models.InventoryRelease{}}
+	retrier := workers.NewInventoryReleaseRetrier(outbox, &stubInventory{}, 10*time.Millisecond, time.Second, 10, zap.NewNop())
+	assert.True(t, retrier.LastRun().IsZero())
+
+	retrier.Start()
+	defer retrier.Stop()
+	started := retrier.LastRun()
+	assert.WithinDuration(t, time.Now(), started, time.Second)
+
+	// Cada vuelta del bucle renueva el latido
+	assert.Eventually(t, func() bool { return retrier.LastRun().After(started) }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, float64(retrier.LastRun().Unix()), testutil.ToFloat64(metrics.DispatcherLastRunTimestamp))
+}
+
+func TestInventoryReleaseRetrier_BackoffIsCapped(t *testing.T) {
+	outbox := &memoryReleaseOutbox{releases: map[string]*models.InventoryRelease{
+		"order-1": {OrderID: "order-1", Attempts: 40, NextAttemptAt: time.Now().Add(-time.Minute)},
+	}}
+	retrier := workers.NewInventoryReleaseRetrier(outbox, &stubInventory{down: true}, time.Minute, 10*time.Second, 10, zap.NewNop())
+
+	retrier.RunOnce(context.Background())
+
+	assert.WithinDuration(t, time.Now().Add(time.Hour), outbox.releases["order-1"].NextAttemptAt, time.Second)
+}
@@ -0,0 +1,148 @@
+package workers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// NotificationPublisher publishes the notification requests retried by the
+// NotificationRetrier, and the ones it gives up on to the dead letter topic.
+type NotificationPublisher interface {
+	Notify(ctx context.Context, request *models.NotificationRequest) error
+	DeadLetter(ctx context.Context, request *models.NotificationRequest, cause string) error
+}
+
+type notificationRetry struct {
+	request   *models.NotificationRequest
+	cause     string
+	attempt   int
+	notBefore time.Time
+}
+
+// NotificationRetrier retries the notification requests that could not be
+// published, so a transient Kafka error does not lose the notification.
+// Requests still failing after maxAttempts are dead lettered. The queue is
+// bounded; requests that do not fit are dropped and counted.
+type NotificationRetrier struct {
+	publisher   NotificationPublisher
+	queue       chan notificationRetry
+	maxAttempts int
+	delay       time.Duration
+	logger      *zap.Logger
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewNotificationRetrier(publisher NotificationPublisher, queueSize, maxAttempts int, delay time.Duration, logger *zap.Logger) *NotificationRetrier {
+	return &NotificationRetrier{
+		publisher:   publisher,
+		queue:       make(chan notificationRetry, queueSize),
+		maxAttempts: maxAttempts,
+		delay:       delay,
+		logger:      logger,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Enqueue schedules a retry of the request that failed with cause, without
+// blocking. It reports false when the queue is full and the request was
+// dropped.
+func (r *NotificationRetrier) Enqueue(request *models.NotificationRequest, cause string) bool {
+	return r.push(notificationRetry{request: request, cause: cause, attempt: 1})
+}
+
+// Start processes queued requests in the background until Stop is called.
+func (r *NotificationRetrier) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case retry := <-r.queue:
+				if !r.wait(retry.notBefore) {
+					return
+				}
+				r.process(retry)
+			}
+		}
+	}()
+}
+
+// Stop signals the background loop to exit. Pending requests are discarded.
+func (r *NotificationRetrier) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+func (r *NotificationRetrier) push(retry notificationRetry) bool {
+	retry.notBefore = time.Now().Add(r.delay * time.Duration(retry.attempt))
+	select {
+	case r.queue <- retry:
+		return true
+	default:
+		metrics.NotificationRequestsTotal.WithLabelValues("dropped").Inc()
+		r.logger.Warn("Notification retry queue full, dropping request",
+			zap.String("notificationId", retry.request.NotificationID),
+			zap.String("orderId", retry.request.OrderNumber),
+		)
+		return false
+	}
+}
+
+func (r *NotificationRetrier) wait(until time.Time) bool {
+	delay := time.Until(until)
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-r.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (r *NotificationRetrier) process(retry notificationRetry) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := r.publisher.Notify(ctx, retry.request)
+	if err == nil {
+		metrics.NotificationRequestsTotal.WithLabelValues("retried").Inc()
+		return
+	}
+	retry.cause = err.Error()
+
+	if retry.attempt < r.maxAttempts {
+		retry.attempt++
+		r.push(retry)
+		return
+	}
+
+	r.logger.Warn("Giving up on notification request",
+		zap.String("notificationId", retry.request.NotificationID),
+		zap.String("orderId", retry.request.OrderNumber),
+		zap.Int("attempts", retry.attempt),
+		zap.String("cause", retry.cause),
+	)
+	if err := r.publisher.DeadLetter(ctx, retry.request, retry.cause); err != nil {
+		metrics.NotificationRequestsTotal.WithLabelValues("dropped").Inc()
+		r.logger.Error("Failed to dead letter notification request",
+			zap.Error(err),
+			zap.String("notificationId", retry.request.NotificationID),
+		)
+		return
+	}
+	metrics.NotificationRequestsTotal.WithLabelValues("dead_lettered").Inc()
+}
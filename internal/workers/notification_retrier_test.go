@@ -0,0 +1,103 @@
+package workers_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"orders/internal/metrics"
+	"orders/internal/models"
+	"orders/internal/workers"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// flakyNotifier falla las primeras failures publicaciones y avisa de cada
+// petición enviada a mensajes muertos
+type flakyNotifier struct {
+	mu          sync.Mutex
+	failures    int
+	calls       int
+	published   chan *models.NotificationRequest
+	deadLetters chan string
+}
+
+func newFlakyNotifier(failures int) *flakyNotifier {
+	return &flakyNotifier{
+		failures:    failures,
+		published:   make(chan *models.NotificationRequest, 10),
+		deadLetters: make(chan string, 10),
+	}
+}
+
+func (n *flakyNotifier) Notify(ctx context.Context, request *models.NotificationRequest) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.calls++
+	if n.calls <= n.failures {
+		return errors.New("broker unavailable")
+	}
+	n.published <- request
+	return nil
+}
+
+func (n *flakyNotifier) DeadLetter(ctx context.Context, request *models.NotificationRequest, cause string) error {
+	n.deadLetters <- cause
+	return nil
+}
+
+func TestNotificationRetrier_RetriesUntilPublished(t *testing.T) {
+	notifier := newFlakyNotifier(1)
+	retrier := workers.NewNotificationRetrier(notifier, 10, 3, time.Millisecond, zap.NewNop())
+	retrier.Start()
+	defer retrier.Stop()
+
+	before := testutil.ToFloat64(metrics.NotificationRequestsTotal.WithLabelValues("retried"))
+	request := &models.NotificationRequest{NotificationID: "notification-1", OrderNumber: "order-123"}
+	assert.True(t, retrier.Enqueue(request, "broker unavailable"))
+
+	select {
+	case published := <-notifier.published:
+		assert.Same(t, request, published)
+	case <-time.After(time.Second):
+		t.Fatal("notification request was not retried")
+	}
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.NotificationRequestsTotal.WithLabelValues("retried"))-before == 1
+	}, time.Second, time.Millisecond)
+	assert.Empty(t, notifier.deadLetters)
+}
+
+func TestNotificationRetrier_DeadLettersAfterMaxAttempts(t *testing.T) {
+	notifier := newFlakyNotifier(100)
+	retrier := workers.NewNotificationRetrier(notifier, 10, 3, time.Millisecond, zap.NewNop())
+	retrier.Start()
+	defer retrier.Stop()
+
+	assert.True(t, retrier.Enqueue(&models.NotificationRequest{NotificationID: "notification-1", OrderNumber: "order-123"}, "broker unavailable"))
+
+	select {
+	case cause := <-notifier.deadLetters:
+		assert.Equal(t, "broker unavailable", cause)
+	case <-time.After(time.Second):
+		t.Fatal("notification request was not dead lettered")
+	}
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	assert.Equal(t, 3, notifier.calls)
+	assert.Empty(t, notifier.published)
+}
+
+func TestNotificationRetrier_DropsWhenQueueFull(t *testing.T) {
+	// Sin arrancar, nada vacía la cola
+	retrier := workers.NewNotificationRetrier(newFlakyNotifier(0), 1, 3, time.Millisecond, zap.NewNop())
+
+	before := testutil.ToFloat64(metrics.NotificationRequestsTotal.WithLabelValues("dropped"))
+	assert.True(t, retrier.Enqueue(&models.NotificationRequest{NotificationID: "notification-1"}, "broker unavailable"))
+	assert.False(t, retrier.Enqueue(&models.NotificationRequest{NotificationID: "notification-2"}, "broker unavailable"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.NotificationRequestsTotal.WithLabelValues("dropped"))-before)
+}
@@ -0,0 +1,49 @@
+package workers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// scheduler runs a job every interval in the background until stopped. Each
+// run gets a context bounded by the interval so a slow run cannot pile up.
+type scheduler struct {
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newScheduler(interval time.Duration) *scheduler {
+	return &scheduler{
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+func (s *scheduler) start(job func(ctx context.Context)) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+				job(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// shutdown signals the loop to exit and waits for the current run.
+func (s *scheduler) shutdown() {
+	close(s.stop)
+	s.wg.Wait()
+}
@@ -0,0 +1,59 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"orders/internal/correlation"
+	"orders/internal/services"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SLABreachNotifier reports orders that passed their promised delivery time.
+type SLABreachNotifier interface {
+	NotifySLABreaches(ctx context.Context, limit int) (int, *services.ServiceError)
+}
+
+// SLASweeper periodically reports in-progress orders that missed their
+// promised delivery time.
+type SLASweeper struct {
+	notifier  SLABreachNotifier
+	batchSize int
+	scheduler *scheduler
+	logger    *zap.Logger
+}
+
+func NewSLASweeper(notifier SLABreachNotifier, interval time.Duration, batchSize int, logger *zap.Logger) *SLASweeper {
+	return &SLASweeper{
+		notifier:  notifier,
+		batchSize: batchSize,
+		scheduler: newScheduler(interval),
+		logger:    logger,
+	}
+}
+
+// Start runs the sweep in the background until Stop is called.
+func (w *SLASweeper) Start() {
+	w.scheduler.start(w.RunOnce)
+}
+
+// Stop signals the background loop to exit and waits for the current run.
+func (w *SLASweeper) Stop() {
+	w.scheduler.shutdown()
+}
+
+// RunOnce reports one batch of SLA breaches.
+func (w *SLASweeper) RunOnce(ctx context.Context) {
+	// Breaches reported by one sweep share a correlation ID
+	ctx = correlation.WithID(ctx, uuid.New().String())
+	notified, err := w.notifier.NotifySLABreaches(ctx, w.batchSize)
+	if err != nil {
+		w.logger.Warn("SLA sweep failed", zap.String("message", err.Message))
+		return
+	}
+	if notified > 0 {
+		w.logger.Info("SLA sweep reported breaches", zap.Int("breaches", notified))
+	}
+}
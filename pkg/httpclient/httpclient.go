@@ -0,0 +1,243 @@
+// Package httpclient is the HTTP client of the integrations with other
+// services. Every attempt is bounded by a timeout, idempotent requests are
+// retried, a circuit breaker per host fails calls at once while the host is
+// unhealthy, and the request ID and trace headers of the request being served
+// are passed on.
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"orders/internal/breaker"
+	"orders/internal/correlation"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Headers passed on to the services called.
+const (
+	RequestIDHeader   = "X-Request-ID"
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+// IdempotencyKeyHeader marks a request that is safe to retry although its
+// method is not idempotent, e.g. a POST the service deduplicates.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxErrorBody bounds how much of an error response is kept for decoding.
+const maxErrorBody = 64 << 10
+
+// ErrCircuitOpen is returned without calling a host whose breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Config configures a Client.
+type Config struct {
+	// Timeout bounds each attempt, reading the response included
+	Timeout time.Duration
+	// MaxRetries is how many times idempotent requests are retried after a
+	// network error, 429 or 5xx, waiting RetryBackoff before the first retry
+	// and twice as long before each next one
+	MaxRetries   int
+	RetryBackoff time.Duration
+	// BreakerFailureThreshold consecutive failed attempts against a host open
+	// its breaker for BreakerOpenTimeout; 0 disables the breakers
+	BreakerFailureThreshold int
+	BreakerOpenTimeout      time.Duration
+	// BreakerState, if set, reports the state of the breaker of each host
+	BreakerState *prometheus.GaugeVec
+}
+
+// Client sends requests as configured. It is safe for concurrent use.
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu       sync.Mutex
+	breakers map[string]*breaker.Breaker
+}
+
+// New creates a client.
+func New(cfg Config) *Client {
+	return &Client{
+		cfg:      cfg,
+		http:     &http.Client{Timeout: cfg.Timeout},
+		breakers: make(map[string]*breaker.Breaker),
+	}
+}
+
+// StatusError is returned by DoJSON for responses other than 2xx.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+// Decode decodes the JSON body of the response into v, typically the error
+// document of the service.
+func (e *StatusError) Decode(v interface{}) error {
+	return json.Unmarshal(e.Body, v)
+}
+
+// NewJSONRequest builds a request accepting JSON, sending body encoded as
+// JSON unless it is nil.
+func NewJSONRequest(ctx context.Context, method, url string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// DoJSON sends the request and decodes a 2xx response into out, unless out
+// is nil or the response has no content. Other responses are returned as
+// *StatusError.
+func (c *Client) DoJSON(req *http.Request, out interface{}) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &StatusError{StatusCode: resp.StatusCode, Body: body}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Do sends the request, retrying it when it is idempotent, and returns the
+// response of the last attempt.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	propagate(req)
+
+	attempts := 1
+	if retriable(req) {
+		attempts += c.cfg.MaxRetries
+	}
+	backoff := c.cfg.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(req)
+		if attempt == attempts || !shouldRetry(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+	}
+}
+
+// attempt sends the request once through the breaker of its host.
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	b := c.breaker(req.URL.Host)
+	if b != nil && !b.Allow() {
+		return nil, fmt.Errorf("%s: %w", req.URL.Host, ErrCircuitOpen)
+	}
+	resp, err := c.http.Do(req)
+	if b != nil {
+		b.Record(err != nil || resp.StatusCode >= http.StatusInternalServerError)
+	}
+	return resp, err
+}
+
+// breaker returns the breaker of host, nil when breakers are disabled.
+func (c *Client) breaker(host string) *breaker.Breaker {
+	if c.cfg.BreakerFailureThreshold <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.breakers[host]
+	if !ok {
+		var gauge prometheus.Gauge = prometheus.NewGauge(prometheus.GaugeOpts{Name: "unreported"})
+		if c.cfg.BreakerState != nil {
+			gauge = c.cfg.BreakerState.WithLabelValues(host)
+		}
+		b = breaker.New(c.cfg.BreakerFailureThreshold, c.cfg.BreakerOpenTimeout, gauge)
+		c.breakers[host] = b
+	}
+	return b
+}
+
+// propagate passes the request ID and trace headers of the request being
+// served on, unless the request sets them already.
+func propagate(req *http.Request) {
+	ctx := req.Context()
+	setDefault(req.Header, RequestIDHeader, correlation.ID(ctx))
+	parent, state := correlation.Trace(ctx)
+	setDefault(req.Header, TraceParentHeader, parent)
+	setDefault(req.Header, TraceStateHeader, state)
+}
+
+func setDefault(header http.Header, key, value string) {
+	if value != "" && header.Get(key) == "" {
+		header.Set(key, value)
+	}
+}
+
+// retriable reports whether the request may be sent again: its method is
+// idempotent, or it carries an idempotency key, and its body can be rewound.
+func retriable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// shouldRetry reports whether an attempt failed in a way another attempt
+// may not: a network error or timeout, throttling or a server error.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
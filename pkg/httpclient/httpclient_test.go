@@ -0,0 +1,184 @@
+package httpclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"orders/internal/correlation"
+	"orders/pkg/httpclient"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failing devuelve un servidor que responde status a las primeras failures
+// peticiones y 200 con body al resto, contando las peticiones recibidas
+func failing(t *testing.T, failures int32, status int, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestClient_Retries(t *testing.T) {
+	cfg := httpclient.Config{Timeout: time.Second, MaxRetries: 2, RetryBackoff: time.Millisecond}
+
+	t.Run("Success after retrying a 5xx", func(t *testing.T) {
+		server, calls := failing(t, 2, http.StatusServiceUnavailable, `{"sku":"LAPTOP-001"}`)
+		req, err := httpclient.NewJSONRequest(context.Background(), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		var out struct {
+			SKU string `json:"sku"`
+		}
+		require.NoError(t, httpclient.New(cfg).DoJSON(req, &out))
+
+		assert.Equal(t, "LAPTOP-001", out.SKU)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("Gives up after the last retry", func(t *testing.T) {
+		server, calls := failing(t, 10, http.StatusBadGateway, `{}`)
+		req, _ := httpclient.NewJSONRequest(context.Background(), http.MethodGet, server.URL, nil)
+
+		err := httpclient.New(cfg).DoJSON(req, nil)
+
+		var status *httpclient.StatusError
+		require.ErrorAs(t, err, &status)
+		assert.Equal(t, http.StatusBadGateway, status.StatusCode)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("Client errors are not retried", func(t *testing.T) {
+		server, calls := failing(t, 10, http.StatusBadRequest, `{}`)
+		req, _ := httpclient.NewJSONRequest(context.Background(), http.MethodGet, server.URL, nil)
+
+		assert.Error(t, httpclient.New(cfg).DoJSON(req, nil))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("POST is only retried with an idempotency key", func(t *testing.T) {
+		server, calls := failing(t, 1, http.StatusInternalServerError, `{}`)
+		client := httpclient.New(cfg)
+
+		req, _ := httpclient.NewJSONRequest(context.Background(), http.MethodPost, server.URL, map[string]string{"orderId": "order-123"})
+		assert.Error(t, client.DoJSON(req, nil))
+		assert.Equal(t, int32(1), calls.Load())
+
+		calls.Store(0)
+		req, _ = httpclient.NewJSONRequest(context.Background(), http.MethodPost, server.URL, map[string]string{"orderId": "order-123"})
+		req.Header.Set(httpclient.IdempotencyKeyHeader, "order-123")
+		assert.NoError(t, client.DoJSON(req, nil))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Timeouts are retried", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				time.Sleep(100 * time.Millisecond)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+		client := httpclient.New(httpclient.Config{Timeout: 20 * time.Millisecond, MaxRetries: 1, RetryBackoff: time.Millisecond})
+
+		req, _ := httpclient.NewJSONRequest(context.Background(), http.MethodDelete, server.URL, nil)
+		assert.NoError(t, client.DoJSON(req, nil))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Timeout without retries", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		}))
+		defer server.Close()
+		client := httpclient.New(httpclient.Config{Timeout: 20 * time.Millisecond})
+
+		req, _ := httpclient.NewJSONRequest(context.Background(), http.MethodGet, server.URL, nil)
+		err := client.DoJSON(req, nil)
+
+		require.Error(t, err)
+		var status *httpclient.StatusError
+		assert.False(t, errors.As(err, &status))
+	})
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	server, calls := failing(t, 1000, http.StatusInternalServerError, `{}`)
+	client := httpclient.New(httpclient.Config{
+		Timeout:                 time.Second,
+		BreakerFailureThreshold: 3,
+		BreakerOpenTimeout:      time.Hour,
+	})
+
+	for i := 0; i < 3; i++ {
+		req, _ := httpclient.NewJSONRequest(context.Background(), http.MethodGet, server.URL, nil)
+		var status *httpclient.StatusError
+		assert.ErrorAs(t, client.DoJSON(req, nil), &status)
+	}
+
+	// Tras la ráfaga de 5xx el circuito se abre y no se llama al servicio
+	req, _ := httpclient.NewJSONRequest(context.Background(), http.MethodGet, server.URL, nil)
+	assert.ErrorIs(t, client.DoJSON(req, nil), httpclient.ErrCircuitOpen)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Cada host tiene su propio circuito
+	other, _ := failing(t, 0, http.StatusOK, `{}`)
+	req, _ = httpclient.NewJSONRequest(context.Background(), http.MethodGet, other.URL, nil)
+	assert.NoError(t, client.DoJSON(req, nil))
+}
+
+func TestClient_PropagatesHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := correlation.WithID(context.Background(), "request-1")
+	ctx = correlation.WithTrace(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "vendor=1")
+	req, _ := httpclient.NewJSONRequest(ctx, http.MethodGet, server.URL, nil)
+
+	require.NoError(t, httpclient.New(httpclient.Config{Timeout: time.Second}).DoJSON(req, nil))
+
+	assert.Equal(t, "request-1", received.Get(httpclient.RequestIDHeader))
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", received.Get(httpclient.TraceParentHeader))
+	assert.Equal(t, "vendor=1", received.Get(httpclient.TraceStateHeader))
+	assert.Equal(t, "application/json", received.Get("Accept"))
+}
+
+func TestStatusError_Decode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"code":"OUT_OF_STOCK","unavailable":["LAPTOP-001"]}`))
+	}))
+	defer server.Close()
+
+	req, _ := httpclient.NewJSONRequest(context.Background(), http.MethodGet, server.URL, nil)
+	err := httpclient.New(httpclient.Config{Timeout: time.Second}).DoJSON(req, nil)
+
+	var status *httpclient.StatusError
+	require.ErrorAs(t, err, &status)
+	var body struct {
+		Code        string   `json:"code"`
+		Unavailable []string `json:"unavailable"`
+	}
+	require.NoError(t, status.Decode(&body))
+	assert.Equal(t, "OUT_OF_STOCK", body.Code)
+	assert.Equal(t, []string{"LAPTOP-001"}, body.Unavailable)
+}
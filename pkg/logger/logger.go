@@ -10,30 +10,57 @@ import (
 
 var log *zap.Logger
 
-// Init initializes the global logger with the given level and format
-func Init(level, format string) error {
-	var err error
+// level is shared by the logger and its children, so changing it applies to
+// every logger handed out.
+var level = zap.NewAtomicLevel()
 
-	// Determine log level
-	var zapLevel zapcore.Level
-	switch strings.ToLower(level) {
+// parseLevel maps a configured level name to its zap level, info by default
+func parseLevel(name string) zapcore.Level {
+	switch strings.ToLower(name) {
 	case "debug":
-		zapLevel = zapcore.DebugLevel
+		return zapcore.DebugLevel
 	case "info":
-		zapLevel = zapcore.InfoLevel
+		return zapcore.InfoLevel
 	case "warn", "warning":
-		zapLevel = zapcore.WarnLevel
+		return zapcore.WarnLevel
 	case "error":
-		zapLevel = zapcore.ErrorLevel
+		return zapcore.ErrorLevel
 	default:
-		zapLevel = zapcore.InfoLevel
+		return zapcore.InfoLevel
+	}
+}
+
+// Options tune how log entries are encoded.
+type Options struct {
+	// Color encodes levels as colored capitals, for terminals; otherwise as
+	// plain capitals, which log ingestion expects from JSON
+	Color bool
+	// Caller adds the file and line logging each entry
+	Caller bool
+}
+
+// levelEncoder returns the level encoder of the options.
+func (o Options) levelEncoder() zapcore.LevelEncoder {
+	if o.Color {
+		return zapcore.CapitalColorLevelEncoder
 	}
+	return zapcore.CapitalLevelEncoder
+}
+
+// Init initializes the global logger with the given level, format and
+// encoding options
+func Init(levelName, format string, opts Options) error {
+	var err error
+
+	zapLevel := parseLevel(levelName)
+	level.SetLevel(zapLevel)
 
 	// Base logger configuration
 	cfg := zap.Config{
-		Level:            zap.NewAtomicLevelAt(zapLevel),
+		Level:            level,
 		Development:      zapLevel == zapcore.DebugLevel,
 		Encoding:         strings.ToLower(format), // "json" or "console"
+		DisableCaller:    !opts.Caller,
 		OutputPaths:      []string{"stdout"},
 		ErrorOutputPaths: []string{"stderr"},
 		EncoderConfig: zapcore.EncoderConfig{
@@ -44,7 +71,7 @@ func Init(level, format string) error {
 			MessageKey:     "message",
 			StacktraceKey:  "stacktrace",
 			LineEnding:     zapcore.DefaultLineEnding,
-			EncodeLevel:    zapcore.CapitalColorLevelEncoder,
+			EncodeLevel:    opts.levelEncoder(),
 			EncodeTime:     zapcore.ISO8601TimeEncoder,
 			EncodeDuration: zapcore.StringDurationEncoder,
 			EncodeCaller:   zapcore.ShortCallerEncoder,
@@ -61,6 +88,11 @@ func Init(level, format string) error {
 	return nil
 }
 
+// SetLevel changes the level of the running logger
+func SetLevel(levelName string) {
+	level.SetLevel(parseLevel(levelName))
+}
+
 // Get returns the current logger instance
 func Get() *zap.Logger {
 	if log == nil {
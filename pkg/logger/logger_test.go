@@ -0,0 +1,66 @@
+package logger_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"orders/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capture devuelve las líneas que escribe en stdout el logger creado por init
+func capture(t *testing.T, init func() error, log func()) []string {
+	t.Helper()
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	require.NoError(t, init())
+	log()
+	logger.Sync()
+	require.NoError(t, w.Close())
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+func TestInit_Options(t *testing.T) {
+	t.Run("Plain JSON for production", func(t *testing.T) {
+		lines := capture(t, func() error {
+			return logger.Init("info", "json", logger.Options{})
+		}, func() {
+			logger.Get().Warn("cache unavailable")
+		})
+
+		require.Len(t, lines, 1)
+		// Sin códigos de color que rompan la ingesta
+		assert.NotContains(t, lines[0], "\x1b[")
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+		assert.Equal(t, "WARN", entry["level"])
+		assert.NotContains(t, entry, "caller")
+	})
+
+	t.Run("Colored levels and caller for the console", func(t *testing.T) {
+		lines := capture(t, func() error {
+			return logger.Init("info", "console", logger.Options{Color: true, Caller: true})
+		}, func() {
+			logger.Get().Info("starting")
+		})
+
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], "\x1b[")
+		assert.True(t, strings.Contains(lines[0], "logger/logger_test.go:"), lines[0])
+	})
+}